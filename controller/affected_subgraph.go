@@ -0,0 +1,170 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/samber/lo"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// AffectedSubgraph is the part of a topology affected by a list of events, so reconcilers can limit their work to the
+// affected targetables and policies instead of re-evaluating the whole topology on each event.
+// The affected targetables are:
+//   - the targetables changed, the ones that reference them (i.e. their ancestors, e.g. the routes and gateways of a
+//     service) and the ones that inherit from them (i.e. their descendants, e.g. the routes of a gateway);
+//   - the targetables linked to the other objects changed, with their ancestors and descendants;
+//   - the targets of the policies changed, with their descendants, before and after the change.
+//
+// The affected policies are the policies changed and the policies attached to the affected targetables.
+type AffectedSubgraph struct {
+	// Targetables are the affected targetables, sorted by URL.
+	Targetables []machinery.Targetable
+	// Policies are the affected policies in the topology, sorted by URL.
+	Policies []machinery.Policy
+	// All is true if the affected subgraph cannot be determined, e.g. because a targetable was deleted and the nodes
+	// that used to reference it are unknown. The whole topology must be treated as affected then.
+	All bool
+
+	urls map[string]struct{}
+}
+
+// Contains returns true if a node of the topology is affected.
+func (s *AffectedSubgraph) Contains(obj machinery.Object) bool {
+	if s.All {
+		return true
+	}
+	_, ok := s.urls[obj.GetURL()]
+	return ok
+}
+
+// ContainsPath returns true if any of the targetables of a path is affected.
+func (s *AffectedSubgraph) ContainsPath(path []machinery.Targetable) bool {
+	return lo.ContainsBy(path, func(t machinery.Targetable) bool { return s.Contains(t) })
+}
+
+// AffectedByEvents returns the subgraph of a topology affected by a list of events.
+func AffectedByEvents(topology *machinery.Topology, events []ResourceEvent) *AffectedSubgraph {
+	targetables := topology.Targetables()
+	affected := make(map[string]machinery.Targetable)
+	expanded := make(map[string]struct{})
+	policies := make(map[string]machinery.Policy)
+	subgraph := &AffectedSubgraph{}
+
+	// the targetable, with its ancestors and descendants
+	addLineage := func(targetable machinery.Targetable) {
+		for _, t := range targetables.Ancestors(targetable) {
+			affected[t.GetURL()] = t
+		}
+		addDescendants(topology, targetable, affected, expanded)
+	}
+
+	for _, event := range events {
+		url := eventObjectURL(event)
+		if targetable, found := targetables.Get(url); found {
+			addLineage(targetable)
+			continue
+		}
+		if obj, found := topology.Objects().Get(url); found {
+			for _, t := range append(targetables.Parents(obj), targetables.Children(obj)...) {
+				addLineage(t)
+			}
+			continue
+		}
+		policy, isPolicy := topology.Policies().Get(url)
+		if isPolicy {
+			policies[url] = policy
+			targets, _ := topology.Targets(policy)
+			for _, target := range targets {
+				addDescendants(topology, target, affected, expanded)
+			}
+		}
+		oldPolicy, wasPolicy := event.OldObject.(machinery.Policy)
+		if wasPolicy {
+			for _, ref := range oldPolicy.GetTargetRefs() {
+				if target, found := targetables.Get(ref.GetURL()); found {
+					addDescendants(topology, target, affected, expanded)
+				}
+			}
+		}
+		if !isPolicy && !wasPolicy && event.EventType == DeleteEvent {
+			subgraph.All = true
+		}
+	}
+
+	for _, targetable := range affected {
+		for _, policy := range targetable.Policies() {
+			policies[policy.GetURL()] = policy
+		}
+	}
+
+	subgraph.Targetables = lo.Values(affected)
+	sort.Slice(subgraph.Targetables, func(i, j int) bool { return subgraph.Targetables[i].GetURL() < subgraph.Targetables[j].GetURL() })
+	subgraph.Policies = lo.Values(policies)
+	sort.Slice(subgraph.Policies, func(i, j int) bool { return subgraph.Policies[i].GetURL() < subgraph.Policies[j].GetURL() })
+	subgraph.urls = make(map[string]struct{}, len(affected)+len(policies))
+	for url := range affected {
+		subgraph.urls[url] = struct{}{}
+	}
+	for url := range policies {
+		subgraph.urls[url] = struct{}{}
+	}
+	return subgraph
+}
+
+// addDescendants adds a targetable and all its descendants in a topology to a set of targetables.
+// Targetables whose descendants were already added are skipped.
+func addDescendants(topology *machinery.Topology, targetable machinery.Targetable, set map[string]machinery.Targetable, expanded map[string]struct{}) {
+	if _, ok := expanded[targetable.GetURL()]; ok {
+		return
+	}
+	expanded[targetable.GetURL()] = struct{}{}
+	set[targetable.GetURL()] = targetable
+	for _, child := range topology.Targetables().Children(targetable) {
+		addDescendants(topology, child, set, expanded)
+	}
+}
+
+// eventObjectURL returns the URL of the topology node of the object of an event
+func eventObjectURL(event ResourceEvent) string {
+	obj := event.NewObject
+	if obj == nil {
+		obj = event.OldObject
+	}
+	return machinery.UrlFromObject(&machinery.SnapshotObject{
+		Group:     event.Kind.Group,
+		Kind:      event.Kind.Kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	})
+}
+
+type affectedSubgraphKey struct{}
+
+type lazyAffectedSubgraph struct {
+	once     sync.Once
+	events   []ResourceEvent
+	topology *machinery.Topology
+	subgraph *AffectedSubgraph
+}
+
+// AffectedSubgraphFromContext returns the subgraph of the topology affected by the events being reconciled, or nil if
+// not found. The controller sets it for all the events of a reconciliation, and subscriptions for their matching events
+// only. The subgraph is computed on first use.
+func AffectedSubgraphFromContext(ctx context.Context) *AffectedSubgraph {
+	lazy, ok := ctx.Value(affectedSubgraphKey{}).(*lazyAffectedSubgraph)
+	if !ok {
+		return nil
+	}
+	lazy.once.Do(func() {
+		lazy.subgraph = AffectedByEvents(lazy.topology, lazy.events)
+	})
+	return lazy.subgraph
+}
+
+// AffectedSubgraphIntoContext returns a new context with the subgraph of a topology affected by a list of events set.
+func AffectedSubgraphIntoContext(ctx context.Context, events []ResourceEvent, topology *machinery.Topology) context.Context {
+	return context.WithValue(ctx, affectedSubgraphKey{}, &lazyAffectedSubgraph{events: events, topology: topology})
+}
@@ -0,0 +1,166 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// runtimePolicy is a test policy that can be the object of a resource event
+type runtimePolicy struct {
+	*machinery.TestPolicy
+}
+
+func (p *runtimePolicy) DeepCopyObject() runtime.Object {
+	return p
+}
+
+func TestAffectedByEvents(t *testing.T) {
+	policy := func(name, target string) *machinery.TestPolicy {
+		return &machinery.TestPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+			Spec: machinery.TestPolicySpec{
+				TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+					LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+						Group: gwapiv1.GroupName,
+						Kind:  "Gateway",
+						Name:  gwapiv1.ObjectName(target),
+					},
+				},
+			},
+		}
+	}
+	gateway2Policy := policy("gateway-2-policy", "gateway-2")
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(machinery.BuildGatewayClass()),
+		machinery.WithGateways(
+			machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-1" }),
+			machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-2" }),
+		),
+		machinery.WithHTTPRoutes(
+			machinery.BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+				r.Name = "route-1"
+				r.Spec.ParentRefs[0].Name = "gateway-1"
+				r.Spec.Rules[0].BackendRefs[0].Name = "service-1"
+			}),
+			machinery.BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+				r.Name = "route-2"
+				r.Spec.ParentRefs[0].Name = "gateway-2"
+				r.Spec.Rules[0].BackendRefs[0].Name = "service-2"
+			}),
+		),
+		machinery.WithServices(
+			machinery.BuildService(func(s *corev1.Service) { s.Name = "service-1" }),
+			machinery.BuildService(func(s *corev1.Service) { s.Name = "service-2" }),
+		),
+		machinery.WithGatewayAPITopologyPolicies(gateway2Policy),
+	)
+
+	gatewayClass := "gatewayclass.gateway.networking.k8s.io:my-gateway-class"
+	gateway1 := "gateway.gateway.networking.k8s.io:my-namespace/gateway-1"
+	gateway2 := "gateway.gateway.networking.k8s.io:my-namespace/gateway-2"
+	route1 := "httproute.gateway.networking.k8s.io:my-namespace/route-1"
+	route2 := "httproute.gateway.networking.k8s.io:my-namespace/route-2"
+	service1 := "service:my-namespace/service-1"
+	service2 := "service:my-namespace/service-2"
+
+	testCases := []struct {
+		name                string
+		events              []ResourceEvent
+		expectedTargetables []string
+		expectedPolicies    []string
+		expectedAll         bool
+	}{
+		{
+			name: "service updated",
+			events: []ResourceEvent{{
+				Kind:      ServiceKind,
+				EventType: UpdateEvent,
+				NewObject: machinery.BuildService(func(s *corev1.Service) { s.Name = "service-1" }),
+			}},
+			expectedTargetables: []string{gateway1, gatewayClass, route1, service1},
+		},
+		{
+			name: "gateway updated",
+			events: []ResourceEvent{{
+				Kind:      GatewayKind,
+				EventType: UpdateEvent,
+				NewObject: machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-2" }),
+			}},
+			expectedTargetables: []string{gateway2, gatewayClass, route2, service2},
+			expectedPolicies:    []string{gateway2Policy.GetURL()},
+		},
+		{
+			name: "policy created",
+			events: []ResourceEvent{{
+				Kind:      gateway2Policy.GroupVersionKind().GroupKind(),
+				EventType: CreateEvent,
+				NewObject: &runtimePolicy{gateway2Policy},
+			}},
+			expectedTargetables: []string{gateway2, route2, service2},
+			expectedPolicies:    []string{gateway2Policy.GetURL()},
+		},
+		{
+			name: "policy deleted",
+			events: []ResourceEvent{{
+				Kind:      gateway2Policy.GroupVersionKind().GroupKind(),
+				EventType: DeleteEvent,
+				OldObject: &runtimePolicy{policy("deleted-policy", "gateway-1")},
+			}},
+			expectedTargetables: []string{gateway1, route1, service1},
+		},
+		{
+			name: "targetable deleted",
+			events: []ResourceEvent{{
+				Kind:      ServiceKind,
+				EventType: DeleteEvent,
+				OldObject: machinery.BuildService(func(s *corev1.Service) { s.Name = "service-3" }),
+			}},
+			expectedAll: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			subgraph := AffectedByEvents(topology, tc.events)
+			if targetables := lo.Map(subgraph.Targetables, machinery.MapTargetableToURLFunc); !slices.Equal(targetables, tc.expectedTargetables) {
+				t.Errorf("expected targetables %v, got %v", tc.expectedTargetables, targetables)
+			}
+			if policies := lo.Map(subgraph.Policies, func(p machinery.Policy, _ int) string { return p.GetURL() }); !slices.Equal(policies, tc.expectedPolicies) {
+				t.Errorf("expected policies %v, got %v", tc.expectedPolicies, policies)
+			}
+			if subgraph.All != tc.expectedAll {
+				t.Errorf("expected all %t, got %t", tc.expectedAll, subgraph.All)
+			}
+		})
+	}
+
+	// lazily computed out of the matching events of a subscription
+	var subgraph *AffectedSubgraph
+	Subscription{
+		ReconcileFunc: func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) {
+			subgraph = AffectedSubgraphFromContext(ctx)
+		},
+		Events: []ResourceEventMatcher{{Kind: &ServiceKind}},
+	}.Reconcile(context.TODO(), append(testCases[0].events, testCases[1].events...), topology)
+	if subgraph == nil {
+		t.Fatal("expected affected subgraph in the context")
+	}
+	if subgraph.Contains(&machinery.Gateway{Gateway: machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-2" })}) {
+		t.Errorf("expected the subgraph of the subscription not to contain %s", gateway2)
+	}
+	if AffectedSubgraphFromContext(context.TODO()) != nil {
+		t.Errorf("expected no affected subgraph in an empty context")
+	}
+}
@@ -0,0 +1,157 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultFieldManager is the field manager of the objects applied with ApplyObject, unless another one is set with
+// WithFieldManager.
+const DefaultFieldManager = "policy-machinery"
+
+type ApplyOption func(*applyOptions)
+
+type applyOptions struct {
+	fieldManager string
+	force        bool
+}
+
+// WithFieldManager sets the name of the field manager that owns the fields of the applied objects.
+// Controllers should use a stable name of their own, e.g. the value of the managed-by label of the generated resources.
+func WithFieldManager(fieldManager string) ApplyOption {
+	return func(o *applyOptions) {
+		o.fieldManager = fieldManager
+	}
+}
+
+// WithForceOwnership makes the field manager take the ownership of the applied fields that are owned by other
+// managers, instead of failing with a conflict.
+func WithForceOwnership() ApplyOption {
+	return func(o *applyOptions) {
+		o.force = true
+	}
+}
+
+func (o *applyOptions) applyOptions(dryRun bool) metav1.ApplyOptions {
+	options := metav1.ApplyOptions{FieldManager: o.fieldManager, Force: o.force}
+	if dryRun {
+		options.DryRun = []string{metav1.DryRunAll}
+	}
+	return options
+}
+
+// ApplyObject creates or updates an object with server-side apply, replacing the usual create/compare/update
+// sequence of the reconcilers of generated resources. The object can be a typed object or an unstructured one, and
+// must declare its apiVersion and kind. Only the fields set in the object are owned by the field manager; the status
+// and the fields set by the API server are dropped from the applied configuration.
+// Applying an object whose fields are up to date is a no-op in the API server.
+// If the management state of the controller in the context is ManagementStateUnmanaged, the object is only applied in
+// dry-run mode, and the object as it would be after applying the configuration is returned.
+func ApplyObject(ctx context.Context, client dynamic.ResourceInterface, obj any, opts ...ApplyOption) (*unstructured.Unstructured, error) {
+	desired, o, err := applyConfiguration(obj, opts...)
+	if err != nil {
+		return nil, err
+	}
+	applied, err := client.Apply(ctx, desired.GetName(), desired, o.applyOptions(IsUnmanagedState(ctx)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s %s: %w", desired.GetKind(), namespacedName(desired), wrapAPIError(err))
+	}
+	return applied, nil
+}
+
+// ObjectDiff is the difference between the live state of an object and the state resulting from applying a
+// configuration to it.
+type ObjectDiff struct {
+	// Current is the live object; nil if the object does not exist.
+	Current *unstructured.Unstructured
+	// Desired is the object as it would be after applying the configuration, as computed by the API server.
+	Desired *unstructured.Unstructured
+	// Fields are the paths of the fields that would change, e.g. "metadata.labels.app" or "spec.rules", sorted.
+	// Lists are compared as a whole.
+	Fields []string
+}
+
+// Empty returns true if applying the configuration would not change the object.
+func (d *ObjectDiff) Empty() bool {
+	return d.Current != nil && len(d.Fields) == 0
+}
+
+// Created returns true if applying the configuration would create the object.
+func (d *ObjectDiff) Created() bool {
+	return d.Current == nil
+}
+
+// DiffObject applies an object with server-side apply in dry-run mode and returns the difference to the live object,
+// without persisting any change. It accepts the same objects and options as ApplyObject.
+func DiffObject(ctx context.Context, client dynamic.ResourceInterface, obj any, opts ...ApplyOption) (*ObjectDiff, error) {
+	desired, o, err := applyConfiguration(obj, opts...)
+	if err != nil {
+		return nil, err
+	}
+	diff := &ObjectDiff{}
+	current, err := client.Get(ctx, desired.GetName(), metav1.GetOptions{})
+	switch {
+	case apierrors.IsNotFound(err):
+	case err != nil:
+		return nil, fmt.Errorf("failed to get %s %s: %w", desired.GetKind(), namespacedName(desired), err)
+	default:
+		diff.Current = current
+	}
+	diff.Desired, err = client.Apply(ctx, desired.GetName(), desired, o.applyOptions(true))
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply %s %s in dry-run mode: %w", desired.GetKind(), namespacedName(desired), wrapAPIError(err))
+	}
+	before := map[string]any{}
+	if diff.Current != nil {
+		before = sanitize(diff.Current).Object
+	}
+	diff.Fields = diffFields(before, sanitize(diff.Desired).Object, "")
+	sort.Strings(diff.Fields)
+	return diff, nil
+}
+
+func applyConfiguration(obj any, opts ...ApplyOption) (*unstructured.Unstructured, *applyOptions, error) {
+	o := &applyOptions{fieldManager: DefaultFieldManager}
+	for _, f := range opts {
+		f(o)
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		var err error
+		if u, err = Destruct(obj); err != nil {
+			return nil, nil, err
+		}
+	}
+	if u.GetAPIVersion() == "" || u.GetKind() == "" {
+		return nil, nil, fmt.Errorf("cannot apply %s without apiVersion and kind", namespacedName(u))
+	}
+	return sanitize(u), o, nil
+}
+
+// diffFields returns the paths of the fields that differ between two unstructured contents
+func diffFields(before, after map[string]any, prefix string) []string {
+	var fields []string
+	for _, key := range lo.Union(lo.Keys(before), lo.Keys(after)) {
+		path := strings.TrimPrefix(prefix+"."+key, ".")
+		b, a := before[key], after[key]
+		bm, bIsMap := b.(map[string]any)
+		am, aIsMap := a.(map[string]any)
+		if bIsMap && aIsMap {
+			fields = append(fields, diffFields(bm, am, path)...)
+			continue
+		}
+		if !reflect.DeepEqual(b, a) {
+			fields = append(fields, path)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,155 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+// applyRecorder emulates server-side apply on top of the fake dynamic client, which ignores the apply options
+type applyRecorder struct {
+	dynamic.ResourceInterface
+	options []metav1.ApplyOptions
+}
+
+func (r *applyRecorder) Apply(ctx context.Context, name string, obj *unstructured.Unstructured, options metav1.ApplyOptions, _ ...string) (*unstructured.Unstructured, error) {
+	r.options = append(r.options, options)
+	current, err := r.Get(ctx, name, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		if len(options.DryRun) > 0 {
+			return obj.DeepCopy(), nil
+		}
+		return r.Create(ctx, obj, metav1.CreateOptions{})
+	}
+	if err != nil {
+		return nil, err
+	}
+	merged := &unstructured.Unstructured{Object: mergeContent(current.Object, obj.Object)}
+	if len(options.DryRun) > 0 {
+		return merged, nil
+	}
+	return r.Update(ctx, merged, metav1.UpdateOptions{})
+}
+
+func mergeContent(current, applied map[string]any) map[string]any {
+	merged := runtime.DeepCopyJSON(current)
+	for key, value := range applied {
+		c, cIsMap := merged[key].(map[string]any)
+		a, aIsMap := value.(map[string]any)
+		if cIsMap && aIsMap {
+			merged[key] = mergeContent(c, a)
+			continue
+		}
+		merged[key] = value
+	}
+	return merged
+}
+
+func TestApplyObject(t *testing.T) {
+	ctx := context.TODO()
+	configMapsResource := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{configMapsResource: "ConfigMapList"})
+	client := &applyRecorder{ResourceInterface: fakeClient.Resource(configMapsResource).Namespace("my-namespace")}
+
+	configMap := func(value string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", Namespace: "my-namespace", Labels: map[string]string{"app": "test"}},
+			Data:       map[string]string{"key": value},
+		}
+	}
+
+	// dry-run of a new object
+	diff, err := DiffObject(ctx, client, configMap("1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.Created() || diff.Empty() {
+		t.Errorf("expected the object to be created, got %+v", diff)
+	}
+	if _, err := client.Get(ctx, "my-configmap", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the dry-run not to create the object, got %v", err)
+	}
+
+	// create
+	obj, err := ApplyObject(ctx, client, configMap("1"), WithFieldManager("my-controller"), WithForceOwnership())
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, _, _ := unstructured.NestedString(obj.Object, "data", "key"); value != "1" {
+		t.Errorf("expected data.key to be 1, got %q", value)
+	}
+	if options := client.options[len(client.options)-1]; options.FieldManager != "my-controller" || !options.Force || len(options.DryRun) > 0 {
+		t.Errorf("unexpected apply options: %+v", options)
+	}
+
+	// dry-run of the same configuration
+	diff, err = DiffObject(ctx, client, configMap("1"))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !diff.Empty() {
+		t.Errorf("expected no changes, got %v", diff.Fields)
+	}
+	if options := client.options[len(client.options)-1]; options.FieldManager != DefaultFieldManager || options.Force || !reflect.DeepEqual(options.DryRun, []string{metav1.DryRunAll}) {
+		t.Errorf("unexpected apply options: %+v", options)
+	}
+
+	// dry-run of a changed configuration
+	changed := configMap("2")
+	changed.Labels["tier"] = "gold"
+	diff, err = DiffObject(ctx, client, changed)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := []string{"data.key", "metadata.labels.tier"}; !reflect.DeepEqual(diff.Fields, expected) {
+		t.Errorf("expected changed fields %v, got %v", expected, diff.Fields)
+	}
+	if current, _ := client.Get(ctx, "my-configmap", metav1.GetOptions{}); current.GetLabels()["tier"] != "" {
+		t.Errorf("expected the dry-run not to update the object")
+	}
+
+	// update, with an unstructured object
+	u, _ := Destruct(changed)
+	if _, err := ApplyObject(ctx, client, u); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	current, _ := client.Get(ctx, "my-configmap", metav1.GetOptions{})
+	if value, _, _ := unstructured.NestedString(current.Object, "data", "key"); value != "2" || current.GetLabels()["tier"] != "gold" {
+		t.Errorf("expected the object to be updated, got %v", current.Object)
+	}
+
+	// missing type meta
+	if _, err := ApplyObject(ctx, client, &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other"}}); err == nil {
+		t.Error("expected error applying an object without apiVersion and kind")
+	}
+}
+
+func TestApplyObjectConflict(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{resource: "ConfigMapList"})
+	fakeClient.PrependReactor("patch", "configmaps", func(_ k8stesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewConflict(resource.GroupResource(), "my-configmap", errors.New("field owned by another manager"))
+	})
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("my-namespace")
+	obj.SetName("my-configmap")
+	if _, err := ApplyObject(context.TODO(), fakeClient.Resource(resource).Namespace("my-namespace"), obj); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
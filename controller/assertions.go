@@ -0,0 +1,179 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/controller/metrics"
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// AssertionRule is an invariant of the topology, e.g. "every HTTPS listener must have a BackendTLSPolicy downstream",
+// evaluated by the controller after every reconciliation (see WithAssertionRules).
+type AssertionRule struct {
+	// Name identifies the rule in the violations and in the metrics.
+	Name string
+	// Message describes the invariant, reported with the violations.
+	Message string
+	// Violations returns the nodes of the topology that violate the rule.
+	Violations func(topology *machinery.Topology) []machinery.Object
+}
+
+// QueryAssertionRule returns a rule whose violations are the nodes bound to the first variable returned by a query of
+// the topology (see machinery.Query), e.g. the routes without an AuthPolicy attached:
+//
+//	MATCH (r:HTTPRoute) WHERE NOT (r)<-[:TARGETS]-(:AuthPolicy) RETURN r
+func QueryAssertionRule(name, message, query string) (AssertionRule, error) {
+	q, err := machinery.ParseQuery(query)
+	if err != nil {
+		return AssertionRule{}, fmt.Errorf("invalid assertion rule %s: %w", name, err)
+	}
+	return AssertionRule{
+		Name:    name,
+		Message: message,
+		Violations: func(topology *machinery.Topology) []machinery.Object {
+			return lo.Map(q.Run(topology).Rows, func(row []machinery.Object, _ int) machinery.Object { return row[0] })
+		},
+	}, nil
+}
+
+// CELAssertionRule returns a rule whose violations are the targetables of given kinds (or of any kind, if none is
+// given) for which a CEL expression evaluated against their variables (see machinery.NodeVariables) is false, e.g. the
+// HTTPS listeners without a TLS certificate:
+//
+//	self.protocol != "HTTPS" || size(self.tls.certificateRefs) > 0
+//
+// The effective policies of the targetables are computed with a given function, if any (see
+// machinery.NodeVariables). The expression is compiled once into a CEL program (see machinery.NewCELExpressionCache);
+// an invalid expression is an error. Targetables the expression fails to evaluate against are violations too, as the
+// invariant cannot be verified.
+func CELAssertionRule(name, message, expression string, effectivePolicy func(topology *machinery.Topology, path []machinery.Targetable) (machinery.Policy, bool), kinds ...schema.GroupKind) (AssertionRule, error) {
+	cache, err := machinery.NewCELExpressionCache(1)
+	if err != nil {
+		return AssertionRule{}, fmt.Errorf("invalid assertion rule %s: %w", name, err)
+	}
+	if _, err := cache.Compile(expression); err != nil {
+		return AssertionRule{}, fmt.Errorf("invalid assertion rule %s: %w", name, err)
+	}
+	return AssertionRule{
+		Name:    name,
+		Message: message,
+		Violations: func(topology *machinery.Topology) []machinery.Object {
+			return lo.FilterMap(topology.Targetables().Items(), func(t machinery.Targetable, _ int) (machinery.Object, bool) {
+				if len(kinds) > 0 && !lo.Contains(kinds, t.GroupVersionKind().GroupKind()) {
+					return nil, false
+				}
+				variables, err := machinery.NodeVariables(topology, t, effectivePolicy)
+				if err != nil {
+					return t, true
+				}
+				holds, err := cache.EvalBool(expression, variables)
+				return t, err != nil || !holds
+			})
+		},
+	}, nil
+}
+
+// AssertionViolation is a node of the topology that violates an assertion rule.
+type AssertionViolation struct {
+	Rule    string `json:"rule"`
+	Message string `json:"message,omitempty"`
+	// Object is the URL of the node that violates the rule.
+	Object string `json:"object"`
+}
+
+// WithAssertionRules registers invariants of the topology that the controller evaluates after every reconciliation,
+// as a built-in policy-compliance watchdog. Violations are logged and recorded as Warning events against the violating
+// nodes (see WithEventRecorder) when they appear, counted in the metrics of the controller (see WithMetrics), and
+// available with Controller.AssertionViolations.
+func WithAssertionRules(rules ...AssertionRule) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.assertionRules = append(o.assertionRules, rules...)
+	}
+}
+
+// AssertionViolations returns the violations of the assertion rules found in the last reconciliation, sorted by rule
+// and by object.
+func (c *Controller) AssertionViolations() []AssertionViolation {
+	if c.assertions == nil {
+		return nil
+	}
+	return c.assertions.list()
+}
+
+// AssertionViolationsHandler returns an HTTP handler that serves the violations of the assertion rules found in the
+// last reconciliation as JSON, e.g. to be mounted in the debug endpoint of the binary.
+func (c *Controller) AssertionViolationsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		violations := c.AssertionViolations()
+		if violations == nil {
+			violations = []AssertionViolation{}
+		}
+		if err := json.NewEncoder(w).Encode(violations); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+type assertions struct {
+	sync.RWMutex
+	rules      []AssertionRule
+	violations []AssertionViolation
+}
+
+// evaluate evaluates the assertion rules on a topology, logging the violations that appeared or were resolved since the
+// last evaluation, and recording events of the violations that appeared
+func (a *assertions) evaluate(ctx context.Context, topology *machinery.Topology, m *metrics.Metrics) {
+	logger := LoggerFromContext(ctx).WithName("assertions")
+	recorder := EventRecorderFromContext(ctx)
+
+	var violations []AssertionViolation
+	violating := make(map[string]machinery.Object)
+	counts := make(map[string]int, len(a.rules))
+	for _, rule := range a.rules {
+		counts[rule.Name] = 0
+		if rule.Violations == nil {
+			continue
+		}
+		for _, obj := range lo.UniqBy(rule.Violations(topology), func(obj machinery.Object) string { return obj.GetURL() }) {
+			violations = append(violations, AssertionViolation{Rule: rule.Name, Message: rule.Message, Object: obj.GetURL()})
+			violating[obj.GetURL()] = obj
+			counts[rule.Name]++
+		}
+	}
+	sort.Slice(violations, func(i, j int) bool {
+		if violations[i].Rule != violations[j].Rule {
+			return violations[i].Rule < violations[j].Rule
+		}
+		return violations[i].Object < violations[j].Object
+	})
+	m.ObserveAssertionViolations(counts)
+
+	a.Lock()
+	previous := a.violations
+	a.violations = violations
+	a.Unlock()
+
+	for _, violation := range lo.Without(violations, previous...) {
+		logger.Info("assertion rule violated", "rule", violation.Rule, "message", violation.Message, "object", violation.Object)
+		recorder.Eventf(violating[violation.Object], corev1.EventTypeWarning, EventReasonAssertionViolated, "assertion rule %s violated: %s", violation.Rule, violation.Message)
+	}
+	for _, violation := range lo.Without(previous, violations...) {
+		logger.V(1).Info("assertion rule violation resolved", "rule", violation.Rule, "object", violation.Object)
+	}
+}
+
+func (a *assertions) list() []AssertionViolation {
+	a.RLock()
+	defer a.RUnlock()
+	return append([]AssertionViolation(nil), a.violations...)
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestAssertionRules(t *testing.T) {
+	httpsListeners := AssertionRule{
+		Name:    "https-listeners",
+		Message: "listeners must not be HTTPS",
+		Violations: func(topology *machinery.Topology) []machinery.Object {
+			return lo.FilterMap(topology.Targetables().Items(), func(t machinery.Targetable, _ int) (machinery.Object, bool) {
+				listener, ok := t.(*machinery.Listener)
+				return listener, ok && listener.Protocol == gwapiv1.HTTPSProtocolType
+			})
+		},
+	}
+	routesWithoutPolicies, err := QueryAssertionRule("routes-without-policies", "routes must have a TestPolicy", `MATCH (r:HTTPRoute) WHERE NOT (r)<-[:TARGETS]-(:TestPolicy) RETURN r`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := QueryAssertionRule("invalid", "", "MATCH"); err == nil {
+		t.Error("expected error of invalid query")
+	}
+
+	c := NewController(WithAssertionRules(httpsListeners, routesWithoutPolicies))
+
+	gateway := machinery.BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Listeners = []gwapiv1.Listener{
+			{Name: "http", Protocol: gwapiv1.HTTPProtocolType},
+			{Name: "https", Protocol: gwapiv1.HTTPSProtocolType},
+		}
+	})
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGateways(gateway),
+		machinery.WithHTTPRoutes(machinery.BuildHTTPRoute(), machinery.BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) { r.Name = "other-http-route" })),
+		machinery.ExpandGatewayListeners(),
+	)
+	c.assertions.evaluate(context.Background(), topology, nil)
+
+	violations := lo.Map(c.AssertionViolations(), func(v AssertionViolation, _ int) string { return v.Rule + " " + v.Object })
+	expected := []string{
+		"https-listeners gateway.gateway.networking.k8s.io:my-namespace/my-gateway#https",
+		"routes-without-policies httproute.gateway.networking.k8s.io:my-namespace/my-http-route",
+		"routes-without-policies httproute.gateway.networking.k8s.io:my-namespace/other-http-route",
+	}
+	if !slices.Equal(violations, expected) {
+		t.Errorf("expected violations %v, got %v", expected, violations)
+	}
+
+	recorder := httptest.NewRecorder()
+	c.AssertionViolationsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/assertions", nil))
+	var served []AssertionViolation
+	if err := json.NewDecoder(recorder.Body).Decode(&served); err != nil {
+		t.Fatal(err)
+	}
+	if len(served) != 3 || served[0].Message != "listeners must not be HTTPS" {
+		t.Errorf("unexpected served violations %+v", served)
+	}
+
+	// violations resolved
+	c.assertions.evaluate(context.Background(), machinery.NewGatewayAPITopology(machinery.WithGateways(machinery.BuildGateway())), nil)
+	if violations := c.AssertionViolations(); len(violations) != 0 {
+		t.Errorf("expected no violations, got %v", violations)
+	}
+}
+
+func TestCELAssertionRules(t *testing.T) {
+	httpsListeners, err := CELAssertionRule("https-listeners", "listeners must not be HTTPS", `self.protocol != "HTTPS"`, nil, (&machinery.Listener{}).GroupVersionKind().GroupKind())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := CELAssertionRule("invalid", "", `self.protocol !=`, nil); err == nil {
+		t.Error("expected error of invalid expression")
+	}
+	if _, err := CELAssertionRule("undeclared", "", `listener.protocol != "HTTPS"`, nil); err == nil {
+		t.Error("expected error of undeclared variable")
+	}
+
+	capturing := &capturingRecorder{}
+	recorder := NewEventRecorder(capturing, time.Minute)
+	now := time.Now()
+	recorder.now = func() time.Time { return now }
+	ctx := EventRecorderIntoContext(context.Background(), recorder)
+
+	c := NewController(WithAssertionRules(httpsListeners))
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGateways(machinery.BuildGateway(func(g *gwapiv1.Gateway) {
+			g.Spec.Listeners = []gwapiv1.Listener{
+				{Name: "http", Protocol: gwapiv1.HTTPProtocolType},
+				{Name: "https", Protocol: gwapiv1.HTTPSProtocolType},
+			}
+		})),
+		machinery.ExpandGatewayListeners(),
+	)
+	c.assertions.evaluate(ctx, topology, nil)
+
+	violations := lo.Map(c.AssertionViolations(), func(v AssertionViolation, _ int) string { return v.Rule + " " + v.Object })
+	if expected := []string{"https-listeners gateway.gateway.networking.k8s.io:my-namespace/my-gateway#https"}; !slices.Equal(violations, expected) {
+		t.Errorf("expected violations %v, got %v", expected, violations)
+	}
+	if len(capturing.events) != 1 {
+		t.Fatalf("expected 1 event recorded, got %d", len(capturing.events))
+	}
+	if event := capturing.events[0]; event.eventType != corev1.EventTypeWarning || event.reason != EventReasonAssertionViolated || event.ref.Kind != "Gateway" || event.ref.FieldPath != "sections{https}" {
+		t.Errorf("unexpected event %+v", event)
+	}
+
+	// events are recorded when the violations appear only
+	now = now.Add(time.Hour)
+	c.assertions.evaluate(ctx, topology, nil)
+	if len(capturing.events) != 1 {
+		t.Errorf("expected no event of a violation already reported, got %d events", len(capturing.events))
+	}
+}
+
+func TestNoAssertionRules(t *testing.T) {
+	c := NewController()
+	if c.AssertionViolations() != nil {
+		t.Error("expected no violations")
+	}
+	recorder := httptest.NewRecorder()
+	c.AssertionViolationsHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/assertions", nil))
+	if body := recorder.Body.String(); body != "[]\n" {
+		t.Errorf("expected empty list, got %s", body)
+	}
+}
@@ -0,0 +1,112 @@
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"runtime"
+	"runtime/debug"
+	"sort"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiconsts "sigs.k8s.io/gateway-api/pkg/consts"
+)
+
+// ModulePath is the path of the Go module of the library.
+const ModulePath = "github.com/kuadrant/policy-machinery"
+
+// BuildInfo describes the build of the library and the resources watched by a controller, to be included in bug
+// reports and fleet dashboards.
+type BuildInfo struct {
+	// Controller is the name of the controller.
+	Controller string `json:"controller"`
+	// Version is the version of the library, as recorded in the build of the binary, or "(devel)" if unknown.
+	Version string `json:"version"`
+	// GoVersion is the version of Go the binary was built with.
+	GoVersion string `json:"goVersion"`
+	// GatewayAPIVersion is the bundle version of the Gateway API the library was compiled against.
+	GatewayAPIVersion string `json:"gatewayAPIVersion"`
+	// Resources are the group/version/resources watched by the controller, in the versions served to the controller.
+	Resources []string `json:"resources,omitempty"`
+	// PolicyKinds are the kinds of policies of the topology.
+	PolicyKinds []string `json:"policyKinds,omitempty"`
+	// ObjectKinds are the kinds of other objects of the topology.
+	ObjectKinds []string `json:"objectKinds,omitempty"`
+}
+
+// BuildInfo returns the build of the library and the resources watched by the controller.
+func (c *Controller) BuildInfo() BuildInfo {
+	info := BuildInfo{
+		Controller:        c.name,
+		Version:           libraryVersion(),
+		GoVersion:         runtime.Version(),
+		GatewayAPIVersion: gwapiconsts.BundleVersion,
+		PolicyKinds:       kindStrings(c.topology.policyKinds),
+		ObjectKinds:       kindStrings(c.topology.objectKinds),
+	}
+	c.watchedResources.Range(func(key, value any) bool {
+		info.Resources = append(info.Resources, value.(schema.GroupVersionResource).String())
+		return true
+	})
+	sort.Strings(info.Resources)
+	return info
+}
+
+// BuildInfoHandler returns an HTTP handler that serves the build info of the controller as JSON, e.g. to be mounted
+// in the debug endpoint of the binary.
+func (c *Controller) BuildInfoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(c.BuildInfo()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// watching records a resource watched by the controller.
+func (c *Controller) watching(resource schema.GroupVersionResource) {
+	c.watchedResources.LoadOrStore(resource, resource)
+	if c.registering != nil {
+		*c.registering = append(*c.registering, resource)
+	}
+}
+
+// watches returns true if a resource is watched by the controller, in any version.
+func (c *Controller) watches(resource schema.GroupResource) bool {
+	watched := false
+	c.watchedResources.Range(func(key, _ any) bool {
+		watched = key.(schema.GroupVersionResource).GroupResource() == resource
+		return !watched
+	})
+	return watched
+}
+
+// served records the version of a resource served to the controller, if the resource is watched.
+func (c *Controller) served(resource, served schema.GroupVersionResource) {
+	if _, ok := c.watchedResources.Load(resource); ok {
+		c.watchedResources.Store(resource, served)
+	}
+}
+
+func libraryVersion() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return "(devel)"
+	}
+	if info.Main.Path == ModulePath {
+		return info.Main.Version
+	}
+	if dep, found := lo.Find(info.Deps, func(dep *debug.Module) bool { return dep.Path == ModulePath }); found {
+		if dep.Replace != nil {
+			return dep.Replace.Version
+		}
+		return dep.Version
+	}
+	return "(devel)"
+}
+
+func kindStrings(kinds []schema.GroupKind) []string {
+	strs := lo.Uniq(lo.Map(kinds, func(kind schema.GroupKind, _ int) string { return kind.String() }))
+	sort.Strings(strs)
+	return strs
+}
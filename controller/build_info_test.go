@@ -0,0 +1,47 @@
+//go:build unit
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	gwapiconsts "sigs.k8s.io/gateway-api/pkg/consts"
+)
+
+func TestBuildInfo(t *testing.T) {
+	c := NewController(
+		WithName("test"),
+		WithClient(testClient),
+		WithRunnable("configmap watcher", Watch(&corev1.ConfigMap{}, ConfigMapsResource, "my-namespace")),
+		WithRunnable("service watcher", Watch(&corev1.Service{}, ServicesResource, "my-namespace", Builder(IncrementalInformer[*corev1.Service]))),
+		WithPolicyKinds(testPolicyKinds...),
+		WithObjectKinds(ConfigMapKind, ConfigMapKind),
+	)
+
+	info := c.BuildInfo()
+	if info.Controller != "test" || info.Version == "" || info.GoVersion == "" {
+		t.Errorf("unexpected build info %+v", info)
+	}
+	if info.GatewayAPIVersion != gwapiconsts.BundleVersion {
+		t.Errorf("expected Gateway API version %s, got %s", gwapiconsts.BundleVersion, info.GatewayAPIVersion)
+	}
+	if len(info.Resources) != 2 || info.Resources[0] != "/v1, Resource=configmaps" || info.Resources[1] != "/v1, Resource=services" {
+		t.Errorf("unexpected resources %v", info.Resources)
+	}
+	if len(info.PolicyKinds) != len(testPolicyKinds) || len(info.ObjectKinds) != 1 {
+		t.Errorf("unexpected kinds %v %v", info.PolicyKinds, info.ObjectKinds)
+	}
+
+	recorder := httptest.NewRecorder()
+	c.BuildInfoHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/buildinfo", nil))
+	var served BuildInfo
+	if err := json.NewDecoder(recorder.Body).Decode(&served); err != nil {
+		t.Fatal(err)
+	}
+	if served.Controller != "test" || len(served.Resources) != 2 {
+		t.Errorf("unexpected served build info %+v", served)
+	}
+}
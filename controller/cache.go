@@ -1,11 +1,16 @@
 package controller
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"strconv"
 	"sync"
 
 	"github.com/samber/lo"
 	"github.com/telepresenceio/watchable"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
@@ -27,16 +32,115 @@ func (s Store) FilterByGroupKind(gk schema.GroupKind) []Object {
 	})
 }
 
+// FilterByLabelSelector returns the objects of a given kind whose labels match a label selector.
+func (s Store) FilterByLabelSelector(gk schema.GroupKind, selector labels.Selector) []Object {
+	return s.Filter(func(o Object) bool {
+		return o.GetObjectKind().GroupVersionKind().GroupKind() == gk && selector.Matches(labels.Set(o.GetLabels()))
+	})
+}
+
+// Cache is the concurrency-safe store of the objects watched by the controller, keyed by UID.
+// Objects read from the cache are copies, thus they can be read and modified outside the reconcile goroutine, e.g. by
+// subscribers of the cache, without affecting the state of the cache.
 type Cache interface {
+	// List returns a copy of the whole store.
 	List() Store
+	// Get returns an object of a given kind by namespace and name. Use an empty namespace for cluster-scoped objects.
+	Get(gk schema.GroupKind, namespace, name string) (Object, bool)
+	// FilterByGroupKind returns the objects of a given kind.
+	FilterByGroupKind(gk schema.GroupKind) []Object
+	// FilterByLabelSelector returns the objects of a given kind whose labels match a label selector.
+	FilterByLabelSelector(gk schema.GroupKind, selector labels.Selector) []Object
+	// ByIndex returns the objects of a given kind indexed by a value in an index registered with WithIndex.
+	ByIndex(gk schema.GroupKind, index, value string) ([]Object, error)
 	Add(obj Object)
 	Delete(obj Object)
 	Replace(Store)
+	Commit(*CacheBatch)
+}
+
+// CacheBatch accumulates mutations to a cache, e.g. from a burst of events, to be committed at once with Commit.
+// Readers of the cache never observe a store with only part of the mutations of a batch applied.
+type CacheBatch struct {
+	mutations []cacheMutation
+	// propagated marks the batches whose events are propagated by the writer, so the subscription of the controller to
+	// the cache does not propagate them again (see Controller.commitWrites)
+	propagated bool
+}
+
+type cacheMutation struct {
+	obj    Object
+	delete bool
+}
+
+// Add adds or replaces an object in the batch.
+func (b *CacheBatch) Add(obj Object) {
+	b.mutations = append(b.mutations, cacheMutation{obj: obj})
+}
+
+// Delete removes an object in the batch.
+func (b *CacheBatch) Delete(obj Object) {
+	b.mutations = append(b.mutations, cacheMutation{obj: obj, delete: true})
+}
+
+// Len returns the number of mutations in the batch.
+func (b *CacheBatch) Len() int {
+	return len(b.mutations)
+}
+
+// changes returns the final state of each object mutated in the batch; nil values represent deletions.
+func (b *CacheBatch) changes() map[string]Object {
+	changes := make(map[string]Object, len(b.mutations))
+	for _, m := range b.mutations {
+		if m.delete {
+			changes[string(m.obj.GetUID())] = nil
+			continue
+		}
+		changes[string(m.obj.GetUID())] = m.obj
+	}
+	return changes
+}
+
+// cacheKey identifies an object of the cache by kind, namespace and name.
+type cacheKey struct {
+	schema.GroupKind
+	namespace string
+	name      string
+}
+
+func cacheKeyOf(obj Object) cacheKey {
+	return cacheKey{obj.GetObjectKind().GroupVersionKind().GroupKind(), obj.GetNamespace(), obj.GetName()}
+}
+
+// cacheIndex maps the objects of a store by kind, namespace and name to their UIDs.
+type cacheIndex map[cacheKey]string
+
+// delete removes the key of an object from the index, unless the key already refers to another object, e.g. one
+// recreated with the same name whose addition was observed before the deletion of the object.
+func (i cacheIndex) delete(obj Object) {
+	key := cacheKeyOf(obj)
+	if i[key] == string(obj.GetUID()) {
+		delete(i, key)
+	}
+}
+
+func indexStore(store Store) cacheIndex {
+	index := make(cacheIndex, len(store))
+	for uid, obj := range store {
+		index[cacheKeyOf(obj)] = uid
+	}
+	return index
 }
 
 type cacheStore struct {
 	sync.RWMutex
-	store Store
+	store    Store
+	index    cacheIndex
+	indexers *cacheIndexers
+}
+
+func newCacheStore(store Store) *cacheStore {
+	return &cacheStore{store: store, index: indexStore(store)}
 }
 
 func (c *cacheStore) List() Store {
@@ -50,11 +154,67 @@ func (c *cacheStore) List() Store {
 	return ret
 }
 
+func (c *cacheStore) Get(gk schema.GroupKind, namespace, name string) (Object, bool) {
+	c.RLock()
+	defer c.RUnlock()
+
+	obj, ok := c.store[c.index[cacheKey{gk, namespace, name}]]
+	if !ok {
+		return nil, false
+	}
+	return obj.DeepCopyObject().(Object), true
+}
+
+func (c *cacheStore) FilterByGroupKind(gk schema.GroupKind) []Object {
+	return c.filter(func(obj Object) bool {
+		return obj.GetObjectKind().GroupVersionKind().GroupKind() == gk
+	})
+}
+
+func (c *cacheStore) FilterByLabelSelector(gk schema.GroupKind, selector labels.Selector) []Object {
+	return c.filter(func(obj Object) bool {
+		return obj.GetObjectKind().GroupVersionKind().GroupKind() == gk && selector.Matches(labels.Set(obj.GetLabels()))
+	})
+}
+
+func (c *cacheStore) ByIndex(gk schema.GroupKind, index, value string) ([]Object, error) {
+	uids, ok := c.indexers.lookup(gk, index, value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrIndexNotFound, gk, index)
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	return lo.FilterMap(uids, func(uid string, _ int) (Object, bool) {
+		obj, ok := c.store[uid]
+		if !ok {
+			return nil, false
+		}
+		return obj.DeepCopyObject().(Object), true
+	}), nil
+}
+
+func (c *cacheStore) filter(predicate func(Object) bool) []Object {
+	c.RLock()
+	defer c.RUnlock()
+
+	var objects []Object
+	for _, obj := range c.store {
+		if predicate(obj) {
+			objects = append(objects, obj.DeepCopyObject().(Object))
+		}
+	}
+	return objects
+}
+
 func (c *cacheStore) Add(obj Object) {
 	c.Lock()
 	defer c.Unlock()
 
 	c.store[string(obj.GetUID())] = obj
+	c.index[cacheKeyOf(obj)] = string(obj.GetUID())
+	c.indexers.add(obj)
 }
 
 func (c *cacheStore) Delete(obj Object) {
@@ -62,6 +222,8 @@ func (c *cacheStore) Delete(obj Object) {
 	defer c.Unlock()
 
 	delete(c.store, string(obj.GetUID()))
+	c.index.delete(obj)
+	c.indexers.delete(obj)
 }
 
 func (c *cacheStore) Replace(store Store) {
@@ -72,14 +234,56 @@ func (c *cacheStore) Replace(store Store) {
 	for k, v := range store {
 		c.store[k] = v.DeepCopyObject().(Object)
 	}
+	c.index = indexStore(c.store)
+	c.indexers.reset(c.store)
+}
+
+// Commit applies the mutations of a batch to a copy of the store and swaps the store with the copy.
+func (c *cacheStore) Commit(batch *CacheBatch) {
+	c.Lock()
+	defer c.Unlock()
+
+	store := make(Store, len(c.store))
+	for k, v := range c.store {
+		store[k] = v
+	}
+	for uid, obj := range batch.changes() {
+		if obj == nil {
+			delete(store, uid)
+			continue
+		}
+		store[uid] = obj
+	}
+	c.store = store
+	c.index = indexStore(store)
+	c.indexers.reset(store)
 }
 
+// watchableCommitPropagatedAnnotation marks the commits of batches whose events are propagated by the writer.
+const watchableCommitPropagatedAnnotation = "policy-machinery.kuadrant.io/propagated"
+
+// watchableCommitKey is the key of the entry of the watchable map that marks the end of each commit, so subscribers
+// are notified of the mutations of a batch at once (see subscribeCommits). It is not a valid UID.
+const watchableCommitKey = "policy-machinery.kuadrant.io/commit"
+
 type watchableCacheStore struct {
 	watchable.Map[string, watchableCacheEntry]
+
+	// commitLock prevents List from observing a batch partially committed to the watchable map
+	commitLock sync.RWMutex
+	// commits is the sequence number of the latest commit, stored in the commit marker entry
+	commits uint64
+
+	indexLock sync.RWMutex
+	index     cacheIndex
+	indexers  *cacheIndexers
 }
 
 func (c *watchableCacheStore) List() Store {
-	entries := c.LoadAll()
+	c.commitLock.RLock()
+	defer c.commitLock.RUnlock()
+
+	entries := c.LoadAllMatching(isWatchableCacheObject)
 	store := make(Store, len(entries))
 	for uid, obj := range entries {
 		store[uid] = obj.Object
@@ -87,23 +291,215 @@ func (c *watchableCacheStore) List() Store {
 	return store
 }
 
+func (c *watchableCacheStore) Get(gk schema.GroupKind, namespace, name string) (Object, bool) {
+	c.indexLock.RLock()
+	uid, ok := c.index[cacheKey{gk, namespace, name}]
+	c.indexLock.RUnlock()
+	if !ok {
+		return nil, false
+	}
+	entry, ok := c.Load(uid) // a deep copy
+	if !ok {
+		return nil, false
+	}
+	return entry.Object, true
+}
+
+func (c *watchableCacheStore) FilterByGroupKind(gk schema.GroupKind) []Object {
+	return c.filter(func(obj Object) bool {
+		return obj.GetObjectKind().GroupVersionKind().GroupKind() == gk
+	})
+}
+
+func (c *watchableCacheStore) FilterByLabelSelector(gk schema.GroupKind, selector labels.Selector) []Object {
+	return c.filter(func(obj Object) bool {
+		return obj.GetObjectKind().GroupVersionKind().GroupKind() == gk && selector.Matches(labels.Set(obj.GetLabels()))
+	})
+}
+
+func (c *watchableCacheStore) ByIndex(gk schema.GroupKind, index, value string) ([]Object, error) {
+	uids, ok := c.indexers.lookup(gk, index, value)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s %s", ErrIndexNotFound, gk, index)
+	}
+	return lo.FilterMap(uids, func(uid string, _ int) (Object, bool) {
+		entry, ok := c.Load(uid) // a deep copy
+		return entry.Object, ok
+	}), nil
+}
+
+func (c *watchableCacheStore) filter(predicate func(Object) bool) []Object {
+	c.commitLock.RLock()
+	defer c.commitLock.RUnlock()
+
+	// deep copies of the matching entries only
+	entries := c.LoadAllMatching(func(uid string, entry watchableCacheEntry) bool {
+		return isWatchableCacheObject(uid, entry) && predicate(entry.Object)
+	})
+	return lo.MapToSlice(entries, func(_ string, entry watchableCacheEntry) Object {
+		return entry.Object
+	})
+}
+
 func (c *watchableCacheStore) Add(obj Object) {
-	c.Store(string(obj.GetUID()), watchableCacheEntry{obj})
+	batch := &CacheBatch{}
+	batch.Add(obj)
+	c.Commit(batch)
 }
 
 func (c *watchableCacheStore) Delete(obj Object) {
-	c.Map.Delete(string(obj.GetUID()))
+	batch := &CacheBatch{}
+	batch.Delete(obj)
+	c.Commit(batch)
+}
+
+func (c *watchableCacheStore) indexAdd(obj Object) {
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	if c.index == nil {
+		c.index = make(cacheIndex)
+	}
+	c.index[cacheKeyOf(obj)] = string(obj.GetUID())
+	c.indexers.add(obj)
+}
+
+func (c *watchableCacheStore) indexDelete(obj Object) {
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	c.index.delete(obj)
+	c.indexers.delete(obj)
+}
+
+func (c *watchableCacheStore) reindex(store Store) {
+	c.indexLock.Lock()
+	defer c.indexLock.Unlock()
+
+	c.index = indexStore(store)
+	c.indexers.reset(store)
 }
 
 func (c *watchableCacheStore) Replace(store Store) {
+	c.commitLock.Lock()
+	defer c.commitLock.Unlock()
+
 	for uid, obj := range store {
 		c.Store(uid, watchableCacheEntry{obj})
 	}
-	for uid := range c.LoadAll() {
+	for uid := range c.LoadAllMatching(isWatchableCacheObject) {
 		if _, ok := store[uid]; !ok {
 			c.Map.Delete(uid)
 		}
 	}
+	c.reindex(store)
+	c.markCommit(false)
+}
+
+// Commit applies the mutations of a batch to the watchable map.
+// List only returns the store after the whole batch is committed, and the subscribers of the commits are notified of
+// the mutations of the batch at once (see subscribeCommits).
+func (c *watchableCacheStore) Commit(batch *CacheBatch) {
+	c.commitLock.Lock()
+	defer c.commitLock.Unlock()
+
+	for uid, obj := range batch.changes() {
+		if obj == nil {
+			c.Map.Delete(uid)
+			continue
+		}
+		c.Store(uid, watchableCacheEntry{obj})
+	}
+	for _, m := range batch.mutations {
+		if m.delete {
+			c.indexDelete(m.obj)
+			continue
+		}
+		c.indexAdd(m.obj)
+	}
+	c.markCommit(batch.propagated)
+}
+
+// markCommit stores the marker of the end of a commit, and whether the events of the commit were propagated by the
+// writer. It must be called with the commit lock held.
+func (c *watchableCacheStore) markCommit(propagated bool) {
+	c.commits++
+	marker := &metav1.PartialObjectMetadata{
+		ObjectMeta: metav1.ObjectMeta{ResourceVersion: strconv.FormatUint(c.commits, 10)},
+	}
+	if propagated {
+		marker.SetAnnotations(map[string]string{watchableCommitPropagatedAnnotation: "true"})
+	}
+	c.Store(watchableCommitKey, watchableCacheEntry{marker})
+}
+
+// watchableCacheUpdate is a mutation of an object of a watchable cache, with the previous version of the object, if
+// any.
+type watchableCacheUpdate struct {
+	watchable.Update[string, watchableCacheEntry]
+	Previous Object
+}
+
+// watchableCacheCommit is the set of mutations of a commit to a watchable cache.
+type watchableCacheCommit struct {
+	Updates []watchableCacheUpdate
+	// Propagated is true if the events of the commit were propagated by the writer (see CacheBatch).
+	Propagated bool
+}
+
+// subscribeCommits returns a channel that emits the mutations of each commit to the cache (i.e. each batch, or each
+// object added or deleted outside of a batch) at once, unlike the snapshots of the watchable map, which may split the
+// mutations of a batch or coalesce the mutations of multiple batches.
+// The channel is closed when the context is done.
+func (c *watchableCacheStore) subscribeCommits(ctx context.Context) <-chan watchableCacheCommit {
+	commits := make(chan watchableCacheCommit)
+
+	// the state of the first snapshot may already include updates, so the state the updates apply to is loaded along
+	// with the subscription, in between commits
+	c.commitLock.RLock()
+	subscription := c.Subscribe(ctx)
+	state := c.LoadAllMatching(isWatchableCacheObject)
+	c.commitLock.RUnlock()
+
+	go func() {
+		defer close(commits)
+		var pending []watchableCacheUpdate
+		for snapshot := range subscription {
+			for _, update := range snapshot.Updates {
+				if update.Key == watchableCommitKey {
+					if len(pending) == 0 {
+						continue
+					}
+					commit := watchableCacheCommit{
+						Updates:    pending,
+						Propagated: update.Value.GetAnnotations()[watchableCommitPropagatedAnnotation] == "true",
+					}
+					select {
+					case commits <- commit:
+					case <-ctx.Done():
+						return
+					}
+					pending = nil
+					continue
+				}
+				u := watchableCacheUpdate{Update: update}
+				if previous, ok := state[update.Key]; ok {
+					u.Previous = previous.Object
+				}
+				if update.Delete {
+					delete(state, update.Key)
+				} else {
+					state[update.Key] = update.Value
+				}
+				pending = append(pending, u)
+			}
+		}
+	}()
+	return commits
+}
+
+func isWatchableCacheObject(uid string, _ watchableCacheEntry) bool {
+	return uid != watchableCommitKey
 }
 
 type watchableCacheEntry struct {
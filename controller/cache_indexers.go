@@ -0,0 +1,135 @@
+package controller
+
+import (
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// IndexFunc extracts the values to index an object by, e.g. the names of the Services an HTTPRoute refers to in its
+// backendRefs. Objects can be indexed by zero or many values.
+type IndexFunc func(Object) []string
+
+// WithIndex registers an index of the objects of a given kind in the cache of the controller, like the field indexers
+// of controller-runtime, so reconcilers can look up objects by the values extracted from them instead of scanning all
+// the objects of the cache, e.g. all HTTPRoutes referring to a given Service (see CachedClient.ByIndex).
+// The name of the index is unique per kind; registering an index with the same name for the same kind replaces it.
+func WithIndex(kind schema.GroupKind, name string, extract IndexFunc) ControllerOption {
+	return func(o *ControllerOptions) {
+		if o.indexes == nil {
+			o.indexes = map[cacheIndexName]IndexFunc{}
+		}
+		o.indexes[cacheIndexName{kind, name}] = extract
+	}
+}
+
+type cacheIndexName struct {
+	schema.GroupKind
+	name string
+}
+
+// cacheIndexers maintains the registered indexes of the objects of a cache, mapping the values extracted from the
+// objects to their UIDs. A nil *cacheIndexers indexes nothing.
+type cacheIndexers struct {
+	sync.RWMutex
+	funcs map[cacheIndexName]IndexFunc
+	// uids by index and value
+	uids map[cacheIndexName]map[string]map[string]struct{}
+	// values by index and uid, to unindex the previous values of updated and deleted objects
+	values map[cacheIndexName]map[string][]string
+}
+
+func newCacheIndexers(funcs map[cacheIndexName]IndexFunc) *cacheIndexers {
+	if len(funcs) == 0 {
+		return nil
+	}
+	i := &cacheIndexers{funcs: funcs}
+	i.reset(nil)
+	return i
+}
+
+func (i *cacheIndexers) add(obj Object) {
+	if i == nil {
+		return
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	i.unlockedDelete(obj)
+	uid := string(obj.GetUID())
+	gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+	for name, extract := range i.funcs {
+		if name.GroupKind != gk {
+			continue
+		}
+		values := lo.Uniq(extract(obj))
+		if len(values) == 0 {
+			continue
+		}
+		for _, value := range values {
+			if i.uids[name][value] == nil {
+				i.uids[name][value] = map[string]struct{}{}
+			}
+			i.uids[name][value][uid] = struct{}{}
+		}
+		i.values[name][uid] = values
+	}
+}
+
+func (i *cacheIndexers) delete(obj Object) {
+	if i == nil {
+		return
+	}
+	i.Lock()
+	defer i.Unlock()
+
+	i.unlockedDelete(obj)
+}
+
+func (i *cacheIndexers) unlockedDelete(obj Object) {
+	uid := string(obj.GetUID())
+	for name := range i.funcs {
+		for _, value := range i.values[name][uid] {
+			delete(i.uids[name][value], uid)
+			if len(i.uids[name][value]) == 0 {
+				delete(i.uids[name], value)
+			}
+		}
+		delete(i.values[name], uid)
+	}
+}
+
+// reset reindexes all the objects of a store.
+func (i *cacheIndexers) reset(store Store) {
+	if i == nil {
+		return
+	}
+	i.Lock()
+	i.uids = make(map[cacheIndexName]map[string]map[string]struct{}, len(i.funcs))
+	i.values = make(map[cacheIndexName]map[string][]string, len(i.funcs))
+	for name := range i.funcs {
+		i.uids[name] = map[string]map[string]struct{}{}
+		i.values[name] = map[string][]string{}
+	}
+	i.Unlock()
+
+	for _, obj := range store {
+		i.add(obj)
+	}
+}
+
+// lookup returns the UIDs of the objects of a given kind indexed by a value, and whether the index exists.
+func (i *cacheIndexers) lookup(gk schema.GroupKind, name, value string) ([]string, bool) {
+	if i == nil {
+		return nil, false
+	}
+	i.RLock()
+	defer i.RUnlock()
+
+	index := cacheIndexName{gk, name}
+	if _, ok := i.funcs[index]; !ok {
+		return nil, false
+	}
+	return lo.Keys(i.uids[index][value]), true
+}
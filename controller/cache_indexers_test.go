@@ -0,0 +1,120 @@
+//go:build unit
+
+package controller
+
+import (
+	"errors"
+	"sort"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestCacheIndexers(t *testing.T) {
+	const index = "backendRefs"
+	byBackendRefs := func(obj Object) []string {
+		route := obj.(*gwapiv1.HTTPRoute)
+		return lo.FlatMap(route.Spec.Rules, func(rule gwapiv1.HTTPRouteRule, _ int) []string {
+			return lo.Map(rule.BackendRefs, func(ref gwapiv1.HTTPBackendRef, _ int) string {
+				return route.Namespace + "/" + string(ref.Name)
+			})
+		})
+	}
+	route := func(uid string, services ...string) *gwapiv1.HTTPRoute {
+		return &gwapiv1.HTTPRoute{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gwapiv1.GroupVersion.String(), Kind: "HTTPRoute"},
+			ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), Name: uid, Namespace: "my-namespace"},
+			Spec: gwapiv1.HTTPRouteSpec{
+				Rules: []gwapiv1.HTTPRouteRule{{
+					BackendRefs: lo.Map(services, func(service string, _ int) gwapiv1.HTTPBackendRef {
+						return gwapiv1.HTTPBackendRef{BackendRef: gwapiv1.BackendRef{BackendObjectReference: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(service)}}}
+					}),
+				}},
+			},
+		}
+	}
+	uids := func(objs []Object) []string {
+		ret := lo.Map(objs, func(obj Object, _ int) string { return string(obj.GetUID()) })
+		sort.Strings(ret)
+		return ret
+	}
+
+	opts := &ControllerOptions{}
+	WithIndex(HTTPRouteKind, index, byBackendRefs)(opts)
+
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "cache store", cache: &cacheStore{store: make(Store), index: make(cacheIndex), indexers: newCacheIndexers(opts.indexes)}},
+		{name: "watchable cache store", cache: &watchableCacheStore{indexers: newCacheIndexers(opts.indexes)}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cache.Add(route("a", "toys", "cars"))
+			tc.cache.Add(route("b", "toys"))
+			tc.cache.Add(route("c", "dolls"))
+
+			objs, err := tc.cache.ByIndex(HTTPRouteKind, index, "my-namespace/toys")
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got := uids(objs); len(got) != 2 || got[0] != "a" || got[1] != "b" {
+				t.Errorf("expected routes a and b referring to toys, got %v", got)
+			}
+
+			// updates unindex the previous values
+			tc.cache.Add(route("b", "dolls"))
+			objs, _ = tc.cache.ByIndex(HTTPRouteKind, index, "my-namespace/toys")
+			if got := uids(objs); len(got) != 1 || got[0] != "a" {
+				t.Errorf("expected route a referring to toys, got %v", got)
+			}
+
+			batch := &CacheBatch{}
+			batch.Delete(route("c"))
+			batch.Add(route("d", "dolls"))
+			tc.cache.Commit(batch)
+			objs, _ = tc.cache.ByIndex(HTTPRouteKind, index, "my-namespace/dolls")
+			if got := uids(objs); len(got) != 2 || got[0] != "b" || got[1] != "d" {
+				t.Errorf("expected routes b and d referring to dolls, got %v", got)
+			}
+
+			tc.cache.Replace(Store{"c": route("c", "dolls")})
+			objs, _ = tc.cache.ByIndex(HTTPRouteKind, index, "my-namespace/dolls")
+			if got := uids(objs); len(got) != 1 || got[0] != "c" {
+				t.Errorf("expected route c referring to dolls, got %v", got)
+			}
+			if objs, _ := tc.cache.ByIndex(HTTPRouteKind, index, "my-namespace/cars"); len(objs) != 0 {
+				t.Errorf("expected no routes referring to cars, got %v", uids(objs))
+			}
+
+			if _, err := tc.cache.ByIndex(HTTPRouteKind, "unknown", "my-namespace/dolls"); !errors.Is(err, ErrIndexNotFound) {
+				t.Errorf("expected an index not found error, got %v", err)
+			}
+		})
+	}
+}
+
+func TestCachedClientByIndex(t *testing.T) {
+	c := NewController(WithIndex(ServiceKind, "app", func(obj Object) []string {
+		return []string{obj.GetLabels()["app"]}
+	}))
+	c.cache.Add(testConfigMap("a"))
+	c.cache.Add(&corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{UID: "b", Name: "my-service", Namespace: "my-namespace", Labels: map[string]string{"app": "toys"}},
+	})
+
+	client := &CachedClient{cache: c.cache}
+	objs, err := client.ByIndex(ServiceKind, "app", "toys")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(objs) != 1 || objs[0].GetUID() != "b" {
+		t.Errorf("expected object b indexed by app, got %v", objs)
+	}
+}
@@ -0,0 +1,249 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func testConfigMap(uid string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), Name: uid}}
+}
+
+func TestCacheCommit(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "cache store", cache: newCacheStore(make(Store))},
+		{name: "watchable cache store", cache: &watchableCacheStore{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cache.Add(testConfigMap("a"))
+			tc.cache.Add(testConfigMap("b"))
+
+			batch := &CacheBatch{}
+			batch.Delete(testConfigMap("a"))
+			batch.Add(testConfigMap("c"))
+			batch.Add(testConfigMap("d"))
+			batch.Delete(testConfigMap("d"))
+			batch.Delete(testConfigMap("e"))
+			batch.Add(testConfigMap("e"))
+			if batch.Len() != 6 {
+				t.Errorf("expected 6 mutations in the batch, got %d", batch.Len())
+			}
+			tc.cache.Commit(batch)
+
+			store := tc.cache.List()
+			if len(store) != 3 {
+				t.Fatalf("expected 3 objects, got %d", len(store))
+			}
+			for _, uid := range []string{"b", "c", "e"} {
+				if _, ok := store[uid]; !ok {
+					t.Errorf("expected object %s in the store", uid)
+				}
+			}
+		})
+	}
+}
+
+func TestCacheCommitIsAtomic(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "cache store", cache: newCacheStore(make(Store))},
+		{name: "watchable cache store", cache: &watchableCacheStore{}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			const batchSize = 10
+			const batches = 50
+
+			var wg sync.WaitGroup
+			done := make(chan struct{})
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						if n := len(tc.cache.List()); n%batchSize != 0 {
+							t.Errorf("observed a partially committed batch: %d objects", n)
+							return
+						}
+					}
+				}
+			}()
+
+			for i := 0; i < batches; i++ {
+				batch := &CacheBatch{}
+				for j := 0; j < batchSize; j++ {
+					batch.Add(testConfigMap(fmt.Sprintf("%d-%d", i, j)))
+				}
+				tc.cache.Commit(batch)
+			}
+			close(done)
+			wg.Wait()
+
+			if n := len(tc.cache.List()); n != batchSize*batches {
+				t.Errorf("expected %d objects, got %d", batchSize*batches, n)
+			}
+		})
+	}
+}
+
+func TestCacheGetRecreatedObject(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "cache store", cache: newCacheStore(make(Store))},
+		{name: "watchable cache store", cache: &watchableCacheStore{}},
+	}
+	service := func(uid string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), Name: "my-service", Namespace: "my-namespace"},
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cache.Add(service("old"))
+			// the addition of the recreated object is observed before the deletion of the old one
+			tc.cache.Add(service("new"))
+			tc.cache.Delete(service("old"))
+
+			obj, ok := tc.cache.Get(ServiceKind, "my-namespace", "my-service")
+			if !ok || obj.GetUID() != "new" {
+				t.Fatalf("expected the recreated service, got %v", obj)
+			}
+
+			tc.cache.Delete(service("new"))
+			if _, ok := tc.cache.Get(ServiceKind, "my-namespace", "my-service"); ok {
+				t.Error("expected no service after deleting the recreated one")
+			}
+		})
+	}
+}
+
+func TestCacheQueries(t *testing.T) {
+	testCases := []struct {
+		name  string
+		cache Cache
+	}{
+		{name: "cache store", cache: newCacheStore(make(Store))},
+		{name: "watchable cache store", cache: &watchableCacheStore{}},
+	}
+	service := func(uid, namespace string, labels map[string]string) *corev1.Service {
+		return &corev1.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{UID: types.UID(uid), Name: "my-service", Namespace: namespace, Labels: labels},
+		}
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.cache.Add(service("a", "ns-1", map[string]string{"app": "toys"}))
+			tc.cache.Add(service("b", "ns-2", map[string]string{"app": "cars"}))
+			configMap := testConfigMap("c")
+			configMap.TypeMeta = metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"}
+			tc.cache.Add(configMap)
+
+			obj, ok := tc.cache.Get(ServiceKind, "ns-2", "my-service")
+			if !ok || obj.GetUID() != "b" {
+				t.Fatalf("expected service b, got %v", obj)
+			}
+			if _, ok := tc.cache.Get(ServiceKind, "ns-3", "my-service"); ok {
+				t.Error("expected no service in ns-3")
+			}
+
+			// copy on read
+			obj.SetLabels(map[string]string{"app": "modified"})
+			if obj, _ := tc.cache.Get(ServiceKind, "ns-2", "my-service"); obj.GetLabels()["app"] != "cars" {
+				t.Errorf("expected the cached object unmodified, got labels %v", obj.GetLabels())
+			}
+
+			if objs := tc.cache.FilterByGroupKind(ServiceKind); len(objs) != 2 {
+				t.Errorf("expected 2 services, got %d", len(objs))
+			}
+			selector := labels.SelectorFromSet(labels.Set{"app": "toys"})
+			if objs := tc.cache.FilterByLabelSelector(ServiceKind, selector); len(objs) != 1 || objs[0].GetUID() != "a" {
+				t.Errorf("expected service a to match the selector, got %v", objs)
+			}
+
+			tc.cache.Delete(service("a", "ns-1", nil))
+			batch := &CacheBatch{}
+			batch.Delete(service("b", "ns-2", nil))
+			batch.Add(service("d", "ns-1", nil))
+			tc.cache.Commit(batch)
+			if _, ok := tc.cache.Get(ServiceKind, "ns-2", "my-service"); ok {
+				t.Error("expected service b deleted")
+			}
+			if obj, ok := tc.cache.Get(ServiceKind, "ns-1", "my-service"); !ok || obj.GetUID() != "d" {
+				t.Errorf("expected service d, got %v", obj)
+			}
+
+			tc.cache.Replace(Store{"b": service("b", "ns-2", nil)})
+			if _, ok := tc.cache.Get(ServiceKind, "ns-1", "my-service"); ok {
+				t.Error("expected service d replaced")
+			}
+			if obj, ok := tc.cache.Get(ServiceKind, "ns-2", "my-service"); !ok || obj.GetUID() != "b" {
+				t.Errorf("expected service b, got %v", obj)
+			}
+		})
+	}
+}
+
+func TestWatchableCacheSubscribeCommits(t *testing.T) {
+	cache := &watchableCacheStore{}
+	cache.Add(testConfigMap("a"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	commits := cache.subscribeCommits(ctx)
+
+	next := func() []watchableCacheUpdate {
+		select {
+		case commit := <-commits:
+			return commit.Updates
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected a commit")
+			return nil
+		}
+	}
+
+	batch := &CacheBatch{}
+	batch.Add(testConfigMap("a"))
+	batch.Add(testConfigMap("b"))
+	batch.Add(testConfigMap("c"))
+	cache.Commit(batch)
+	cache.Delete(testConfigMap("b"))
+
+	// a no-op add of an object already in the cache is not published
+	updates := next()
+	if len(updates) != 2 {
+		t.Fatalf("expected the 2 updates of the batch at once, got %v", updates)
+	}
+	for _, update := range updates {
+		if update.Delete || update.Previous != nil {
+			t.Errorf("expected the creation of %s, got %v", update.Key, update)
+		}
+	}
+
+	updates = next()
+	if len(updates) != 1 || !updates[0].Delete || updates[0].Key != "b" || updates[0].Previous == nil {
+		t.Errorf("expected the deletion of b, got %v", updates)
+	}
+}
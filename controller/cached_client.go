@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+type cachedClientKey struct{}
+
+// CachedClient is a read-only client that serves objects from the controller cache, only reaching out to the API
+// server on cache misses or when a live read is explicitly requested.
+// The cache is authoritative for the resources watched by the controller once the caches of its runnables have
+// synced: objects of those resources missing from the cache are reported as not found, and lists of those resources
+// can be empty, without reaching out to the API server.
+// Objects read from the cache are of the concrete types of the watched resources; objects read from the API server
+// are unstructured. Objects read from the cache are copies, thus can be modified by the caller, and only hold the
+// fields kept by the field masks of their kinds (see WithFieldMask); use LiveRead to read the complete objects.
+type CachedClient struct {
+	client dynamic.Interface
+	cache  Cache
+	// watched returns true if a resource is watched by the controller and the caches of the runnables have synced
+	watched func(schema.GroupResource) bool
+}
+
+// authoritative returns true if the cache holds all the objects of a resource.
+func (c *CachedClient) authoritative(resource schema.GroupVersionResource) bool {
+	return c.cache != nil && c.watched != nil && c.watched(resource.GroupResource())
+}
+
+type ReadOptions struct {
+	Live bool
+}
+
+type ReadOption func(*ReadOptions)
+
+// LiveRead forces the read to hit the API server, bypassing the cache.
+func LiveRead() ReadOption {
+	return func(o *ReadOptions) {
+		o.Live = true
+	}
+}
+
+// Get returns an object of a given kind by namespace and name.
+func (c *CachedClient) Get(ctx context.Context, resource schema.GroupVersionResource, kind schema.GroupKind, namespace, name string, options ...ReadOption) (Object, error) {
+	o := readOptions(options)
+	if !o.Live && c.cache != nil {
+		if obj, ok := c.cache.Get(kind, namespace, name); ok {
+			return obj.DeepCopyObject().(Object), nil
+		}
+		if c.authoritative(resource) {
+			return nil, apierrors.NewNotFound(resource.GroupResource(), name)
+		}
+	}
+	obj, err := c.client.Resource(resource).Namespace(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return obj, nil
+}
+
+// List returns all objects of a given kind in a namespace. Use metav1.NamespaceAll to list objects in all namespaces.
+func (c *CachedClient) List(ctx context.Context, resource schema.GroupVersionResource, kind schema.GroupKind, namespace string, options ...ReadOption) ([]Object, error) {
+	o := readOptions(options)
+	if !o.Live && c.cache != nil {
+		objs := lo.Filter(c.cache.FilterByGroupKind(kind), func(obj Object, _ int) bool {
+			return namespace == metav1.NamespaceAll || obj.GetNamespace() == namespace
+		})
+		if len(objs) > 0 || c.authoritative(resource) {
+			return deepCopyObjects(objs), nil
+		}
+	}
+	list, err := c.client.Resource(resource).Namespace(namespace).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return lo.Map(list.Items, func(item unstructured.Unstructured, _ int) Object {
+		return &item
+	}), nil
+}
+
+// ByIndex returns the objects of a given kind indexed by a value in an index registered with WithIndex, e.g. all the
+// HTTPRoutes referring to a Service. Lookups by index are always served from the cache, thus return copies of the
+// objects as kept by the field masks of their kinds.
+func (c *CachedClient) ByIndex(kind schema.GroupKind, index, value string) ([]Object, error) {
+	if c.cache == nil {
+		return nil, fmt.Errorf("%w: %s %s", ErrIndexNotFound, kind, index)
+	}
+	objs, err := c.cache.ByIndex(kind, index, value)
+	if err != nil {
+		return nil, err
+	}
+	return deepCopyObjects(objs), nil
+}
+
+// deepCopyObjects returns deep copies of objects read from the cache, so the callers cannot mutate the cache.
+func deepCopyObjects(objs []Object) []Object {
+	return lo.Map(objs, func(obj Object, _ int) Object {
+		return obj.DeepCopyObject().(Object)
+	})
+}
+
+func readOptions(options []ReadOption) *ReadOptions {
+	o := &ReadOptions{}
+	for _, f := range options {
+		f(o)
+	}
+	return o
+}
+
+// CachedClientFromContext returns the cached client from the context, or nil if no client is found.
+func CachedClientFromContext(ctx context.Context) *CachedClient {
+	client, ok := ctx.Value(cachedClientKey{}).(*CachedClient)
+	if !ok {
+		return nil
+	}
+	return client
+}
+
+// CachedClientIntoContext returns a new context with the cached client set.
+func CachedClientIntoContext(ctx context.Context, client *CachedClient) context.Context {
+	return context.WithValue(ctx, cachedClientKey{}, client)
+}
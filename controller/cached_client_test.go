@@ -0,0 +1,84 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCachedClient(t *testing.T) {
+	serviceTypeMeta := metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+	cache := newCacheStore(Store{
+		"a": &corev1.Service{TypeMeta: serviceTypeMeta, ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns-1", UID: "a"}},
+		"b": &corev1.Service{TypeMeta: serviceTypeMeta, ObjectMeta: metav1.ObjectMeta{Name: "svc-b", Namespace: "ns-2", UID: "b"}},
+	})
+	client := CachedClientFromContext(CachedClientIntoContext(context.TODO(), &CachedClient{cache: cache}))
+	if client == nil {
+		t.Fatal("expected cached client in the context, got nil")
+	}
+
+	obj, err := client.Get(context.TODO(), ServicesResource, ServiceKind, "ns-1", "svc-a")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if _, ok := obj.(*corev1.Service); !ok || obj.GetName() != "svc-a" {
+		t.Errorf("expected service svc-a from the cache, got %v", obj)
+	}
+
+	// the objects read are copies of the cached ones
+	obj.SetLabels(map[string]string{"app": "modified"})
+	if cached, _ := cache.Get(ServiceKind, "ns-1", "svc-a"); len(cached.GetLabels()) != 0 {
+		t.Errorf("expected the cached service unmodified, got labels %v", cached.GetLabels())
+	}
+
+	objs, err := client.List(context.TODO(), ServicesResource, ServiceKind, metav1.NamespaceAll)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(objs) != 2 {
+		t.Errorf("expected 2 services from the cache, got %d", len(objs))
+	}
+
+	objs, err = client.List(context.TODO(), ServicesResource, ServiceKind, "ns-2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(objs) != 1 || objs[0].GetName() != "svc-b" {
+		t.Fatalf("expected service svc-b from the cache, got %v", objs)
+	}
+	objs[0].SetLabels(map[string]string{"app": "modified"})
+	if cached, _ := cache.Get(ServiceKind, "ns-2", "svc-b"); len(cached.GetLabels()) != 0 {
+		t.Errorf("expected the cached service unmodified, got labels %v", cached.GetLabels())
+	}
+}
+
+func TestCachedClientWatchedResources(t *testing.T) {
+	serviceTypeMeta := metav1.TypeMeta{APIVersion: "v1", Kind: "Service"}
+	cache := newCacheStore(Store{
+		"a": &corev1.Service{TypeMeta: serviceTypeMeta, ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns-1", UID: "a"}},
+	})
+	// no client to reach out to the API server, the cache is authoritative for the services
+	client := &CachedClient{cache: cache, watched: func(resource schema.GroupResource) bool {
+		return resource == ServicesResource.GroupResource()
+	}}
+
+	if _, err := client.Get(context.TODO(), ServicesResource, ServiceKind, "ns-1", "svc-b"); !apierrors.IsNotFound(err) {
+		t.Errorf("expected a not found error from the cache, got %v", err)
+	}
+	objs, err := client.List(context.TODO(), ServicesResource, ServiceKind, "ns-2")
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(objs) != 0 {
+		t.Errorf("expected no service from the cache, got %v", objs)
+	}
+	if obj, err := client.Get(context.TODO(), ServicesResource, ServiceKind, "ns-1", "svc-a"); err != nil || obj.GetName() != "svc-a" {
+		t.Errorf("expected service svc-a from the cache, got %v, %v", obj, err)
+	}
+}
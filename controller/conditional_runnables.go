@@ -0,0 +1,127 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+)
+
+const defaultDiscoveryInterval = 30 * time.Second
+
+// ResourceServed returns whether a resource is served by the API server, as informed by the discovery API, e.g.
+// whether the CRD of the resource is installed in the cluster.
+func ResourceServed(client discovery.DiscoveryInterface, resource schema.GroupVersionResource) (bool, error) {
+	resources, err := client.ServerResourcesForGroupVersion(resource.GroupVersion().String())
+	if apierrors.IsNotFound(err) {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return lo.ContainsBy(resources.APIResources, func(r metav1.APIResource) bool { return r.Name == resource.Resource }), nil
+}
+
+type conditionalRunnable struct {
+	resource schema.GroupVersionResource
+	builder  RunnableBuilder
+}
+
+// WithConditionalRunnable adds a runnable that only runs while a resource is served by the API server, so the same
+// controller can run in clusters with different CRDs installed, e.g. of Gateway API implementations.
+// The discovery API is checked when the controller starts, and periodically afterwards (see WithDiscoveryInterval):
+// the runnable is added once the CRD of the resource is installed, and removed if the CRD is uninstalled (see
+// Controller.AddRunnable and Controller.RemoveRunnable).
+// It requires a discovery client, set with WithDiscoveryClient or created from the rest config (see WithRestConfig).
+func WithConditionalRunnable(name string, resource schema.GroupVersionResource, builder RunnableBuilder) ControllerOption {
+	return func(o *ControllerOptions) {
+		if o.conditionalRunnables == nil {
+			o.conditionalRunnables = make(map[string]conditionalRunnable)
+		}
+		o.conditionalRunnables[name] = conditionalRunnable{resource: resource, builder: builder}
+	}
+}
+
+// WithDiscoveryClient sets the client of the discovery API used to check the resources of the conditional runnables
+// (see WithConditionalRunnable). Defaults to a client created from the rest config.
+func WithDiscoveryClient(client discovery.DiscoveryInterface) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.discoveryClient = client
+	}
+}
+
+// WithDiscoveryInterval sets how often the discovery API is checked for the resources of the conditional runnables
+// (see WithConditionalRunnable). Defaults to 30 seconds.
+func WithDiscoveryInterval(interval time.Duration) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.discoveryInterval = interval
+	}
+}
+
+// runnableDiscoveries are the conditional runnables of a controller
+type runnableDiscoveries struct {
+	runnables map[string]conditionalRunnable
+	client    discovery.DiscoveryInterface
+	interval  time.Duration
+}
+
+// discoverRunnables adds the conditional runnables whose resources are served by the API server and removes the ones
+// whose resources are no longer served
+func (c *Controller) discoverRunnables() {
+	if c.discoveries.client == nil {
+		c.logger.Error(errors.New("no discovery client"), "failed to discover the resources of the conditional runnables")
+		return
+	}
+
+	names := lo.Keys(c.discoveries.runnables)
+	sort.Strings(names)
+	for _, name := range names {
+		runnable := c.discoveries.runnables[name]
+		served, err := ResourceServed(c.discoveries.client, runnable.resource)
+		if err != nil {
+			c.logger.Error(err, "failed to discover resource", "resource", runnable.resource.String())
+			continue
+		}
+
+		c.runnablesMutex.Lock()
+		_, active := c.runnables[name]
+		c.runnablesMutex.Unlock()
+
+		switch {
+		case served && !active:
+			c.logger.Info("resource discovered, adding runnable", "resource", runnable.resource.String(), "name", name)
+			if err := c.AddRunnable(name, runnable.builder); err != nil {
+				c.logger.Error(err, "failed to add runnable", "name", name)
+			}
+		case !served && active:
+			c.logger.Info("resource no longer served, removing runnable", "resource", runnable.resource.String(), "name", name)
+			if err := c.RemoveRunnable(name); err != nil {
+				c.logger.Error(err, "failed to remove runnable", "name", name)
+			}
+		}
+	}
+}
+
+// watchDiscovery checks the resources of the conditional runnables periodically until the context is cancelled
+func (c *Controller) watchDiscovery(ctx context.Context) {
+	interval := c.discoveries.interval
+	if interval <= 0 {
+		interval = defaultDiscoveryInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.discoverRunnables()
+		}
+	}
+}
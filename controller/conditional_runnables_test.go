@@ -0,0 +1,95 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	fakediscovery "k8s.io/client-go/discovery/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+var gatewaysResource = schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "gateways"}
+
+func gatewayAPIResources() []*metav1.APIResourceList {
+	return []*metav1.APIResourceList{
+		{
+			GroupVersion: "gateway.networking.k8s.io/v1",
+			APIResources: []metav1.APIResource{{Name: "gateways", Kind: "Gateway", Namespaced: true}},
+		},
+	}
+}
+
+func TestResourceServed(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{Resources: gatewayAPIResources()}}
+
+	testCases := []struct {
+		name     string
+		resource schema.GroupVersionResource
+		expected bool
+	}{
+		{
+			name:     "served",
+			resource: gatewaysResource,
+			expected: true,
+		},
+		{
+			name:     "resource not served",
+			resource: schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1", Resource: "httproutes"},
+			expected: false,
+		},
+		{
+			name:     "group version not served",
+			resource: schema.GroupVersionResource{Group: "gateway.networking.k8s.io", Version: "v1alpha2", Resource: "gateways"},
+			expected: false,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			served, err := ResourceServed(client, tc.resource)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if served != tc.expected {
+				t.Errorf("expected served %v, got %v", tc.expected, served)
+			}
+		})
+	}
+}
+
+func TestConditionalRunnable(t *testing.T) {
+	client := &fakediscovery.FakeDiscovery{Fake: &clienttesting.Fake{}}
+	builder := func(controller *Controller) Runnable {
+		controller.watching(gatewaysResource)
+		return &fakeRunnable{}
+	}
+	c := NewController(WithConditionalRunnable("gateways", gatewaysResource, builder), WithDiscoveryClient(client))
+
+	c.discoverRunnables()
+	if _, found := c.runnables["gateways"]; found {
+		t.Errorf("expected no runnable while the resource is not served")
+	}
+
+	// the crd is installed
+	client.Resources = gatewayAPIResources()
+	c.discoverRunnables()
+	if _, found := c.runnables["gateways"]; !found {
+		t.Errorf("expected the runnable to be added once the resource is served")
+	}
+	if _, watched := c.watchedResources.Load(gatewaysResource); !watched {
+		t.Errorf("expected resource %s to be watched", gatewaysResource)
+	}
+
+	// the crd is uninstalled
+	client.Resources = nil
+	c.discoverRunnables()
+	if _, found := c.runnables["gateways"]; found {
+		t.Errorf("expected the runnable to be removed once the resource is no longer served")
+	}
+	if _, watched := c.watchedResources.Load(gatewaysResource); watched {
+		t.Errorf("expected resource %s not to be watched", gatewaysResource)
+	}
+}
@@ -0,0 +1,132 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Standard policy condition types and reasons
+const (
+	PolicyConditionAccepted = string(gwapiv1alpha2.PolicyConditionAccepted)
+	PolicyConditionEnforced = "Enforced"
+
+	PolicyReasonAccepted            = string(gwapiv1alpha2.PolicyReasonAccepted)
+	PolicyReasonConflicted          = string(gwapiv1alpha2.PolicyReasonConflicted)
+	PolicyReasonEnforced            = "Enforced"
+	PolicyReasonWaitingOnDependency = "WaitingOnDependency"
+)
+
+// Dependency is a resource that a policy depends on to be enforced, such as a resource generated for a gateway
+// provider out of the effective policies.
+// The dependency is ready when all its conditions of the readiness type are true.
+type Dependency struct {
+	Object        machinery.Object
+	ConditionType string
+	Conditions    []metav1.Condition
+}
+
+// Ready returns true if the dependency reports at least one condition of the readiness type and all of them are true.
+func (d Dependency) Ready() bool {
+	conditions := lo.Filter(d.Conditions, func(c metav1.Condition, _ int) bool {
+		return c.Type == d.ConditionType
+	})
+	return len(conditions) > 0 && lo.EveryBy(conditions, func(c metav1.Condition) bool {
+		return c.Status == metav1.ConditionTrue
+	})
+}
+
+func (d Dependency) String() string {
+	return objectString(d.Object)
+}
+
+func objectString(obj machinery.Object) string {
+	return fmt.Sprintf("%s %s", obj.GroupVersionKind().Kind, objectName(obj))
+}
+
+// objectName returns the name of an object prefixed by its namespace, if any.
+func objectName(obj machinery.Object) string {
+	if namespace := obj.GetNamespace(); namespace != "" {
+		return fmt.Sprintf("%s/%s", namespace, obj.GetName())
+	}
+	return obj.GetName()
+}
+
+// DependencyFromPolicyStatus returns a dependency whose readiness is given by the conditions of all the ancestors
+// reported in the status of a Gateway API policy, such as an Envoy Gateway SecurityPolicy.
+func DependencyFromPolicyStatus(obj machinery.Object, status gwapiv1alpha2.PolicyStatus, conditionType string) Dependency {
+	return Dependency{
+		Object:        obj,
+		ConditionType: conditionType,
+		Conditions: lo.FlatMap(status.Ancestors, func(ancestor gwapiv1alpha2.PolicyAncestorStatus, _ int) []metav1.Condition {
+			return ancestor.Conditions
+		}),
+	}
+}
+
+// AcceptedCondition returns a true Accepted condition for a policy.
+func AcceptedCondition(generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               PolicyConditionAccepted,
+		Status:             metav1.ConditionTrue,
+		Reason:             PolicyReasonAccepted,
+		Message:            "Policy has been accepted",
+		ObservedGeneration: generation,
+	}
+}
+
+// ConflictedCondition returns a false Accepted condition for a policy that loses a conflict with another policy
+// of the same kind attached to the same targetable.
+func ConflictedCondition(generation int64, conflict machinery.Conflict) metav1.Condition {
+	return metav1.Condition{
+		Type:   PolicyConditionAccepted,
+		Status: metav1.ConditionFalse,
+		Reason: PolicyReasonConflicted,
+		Message: fmt.Sprintf("Policy conflicts with %s targeting %s %s, which takes precedence (%s)",
+			objectString(conflict.Winner), conflict.Target.GroupVersionKind().Kind, objectName(conflict.Target), conflict.Reason),
+		ObservedGeneration: generation,
+	}
+}
+
+// EnforcedCondition returns the Enforced condition for a policy that depends on other resources to be enforced.
+// The condition is true if all dependencies are ready; otherwise, it is a waiting-on-dependency condition.
+func EnforcedCondition(generation int64, dependencies ...Dependency) metav1.Condition {
+	if waiting := lo.Filter(dependencies, func(d Dependency, _ int) bool { return !d.Ready() }); len(waiting) > 0 {
+		return WaitingOnDependencyCondition(PolicyConditionEnforced, generation, waiting...)
+	}
+	return metav1.Condition{
+		Type:               PolicyConditionEnforced,
+		Status:             metav1.ConditionTrue,
+		Reason:             PolicyReasonEnforced,
+		Message:            "Policy has been successfully enforced",
+		ObservedGeneration: generation,
+	}
+}
+
+// maxListedDependencies is the maximum number of dependencies listed in the message of a condition
+const maxListedDependencies = 10
+
+// WaitingOnDependencyCondition returns a false condition of a given type stating that the policy is waiting on
+// dependencies that are not ready yet.
+func WaitingOnDependencyCondition(conditionType string, generation int64, dependencies ...Dependency) metav1.Condition {
+	return metav1.Condition{
+		Type:   conditionType,
+		Status: metav1.ConditionFalse,
+		Reason: PolicyReasonWaitingOnDependency,
+		Message: fmt.Sprintf("Waiting on %s", AggregateItems(lo.Map(dependencies, func(d Dependency, _ int) string {
+			return fmt.Sprintf("%s to be %s", d.String(), d.ConditionType)
+		}), maxListedDependencies)),
+		ObservedGeneration: generation,
+	}
+}
+
+// IsWaitingOnDependency returns true if the condition of a given type is false because of a dependency not ready yet.
+func IsWaitingOnDependency(conditions []metav1.Condition, conditionType string) bool {
+	condition := meta.FindStatusCondition(conditions, conditionType)
+	return condition != nil && condition.Status == metav1.ConditionFalse && condition.Reason == PolicyReasonWaitingOnDependency
+}
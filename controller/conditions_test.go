@@ -0,0 +1,99 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestEnforcedCondition(t *testing.T) {
+	securityPolicy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "gateway.envoyproxy.io/v1alpha1", Kind: "SecurityPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-gateway", Namespace: "my-namespace"},
+	}
+	accepted := func(status metav1.ConditionStatus) gwapiv1alpha2.PolicyAncestorStatus {
+		return gwapiv1alpha2.PolicyAncestorStatus{
+			Conditions: []metav1.Condition{{Type: PolicyConditionAccepted, Status: status}},
+		}
+	}
+
+	testCases := []struct {
+		name           string
+		status         gwapiv1alpha2.PolicyStatus
+		expectedStatus metav1.ConditionStatus
+		expectedReason string
+	}{
+		{
+			name:           "no status yet",
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: PolicyReasonWaitingOnDependency,
+		},
+		{
+			name:           "not accepted by all ancestors",
+			status:         gwapiv1alpha2.PolicyStatus{Ancestors: []gwapiv1alpha2.PolicyAncestorStatus{accepted(metav1.ConditionTrue), accepted(metav1.ConditionFalse)}},
+			expectedStatus: metav1.ConditionFalse,
+			expectedReason: PolicyReasonWaitingOnDependency,
+		},
+		{
+			name:           "accepted",
+			status:         gwapiv1alpha2.PolicyStatus{Ancestors: []gwapiv1alpha2.PolicyAncestorStatus{accepted(metav1.ConditionTrue)}},
+			expectedStatus: metav1.ConditionTrue,
+			expectedReason: PolicyReasonEnforced,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			dependency := DependencyFromPolicyStatus(securityPolicy, tc.status, PolicyConditionAccepted)
+			condition := EnforcedCondition(3, dependency)
+			if condition.Type != PolicyConditionEnforced {
+				t.Errorf("expected condition type %s, got %s", PolicyConditionEnforced, condition.Type)
+			}
+			if condition.Status != tc.expectedStatus || condition.Reason != tc.expectedReason {
+				t.Errorf("expected condition %s/%s, got %s/%s", tc.expectedStatus, tc.expectedReason, condition.Status, condition.Reason)
+			}
+			if condition.ObservedGeneration != 3 {
+				t.Errorf("expected observed generation 3, got %d", condition.ObservedGeneration)
+			}
+			if tc.expectedReason == PolicyReasonWaitingOnDependency {
+				if expected := "Waiting on SecurityPolicy my-namespace/my-gateway to be Accepted"; condition.Message != expected {
+					t.Errorf("expected message %q, got %q", expected, condition.Message)
+				}
+			}
+
+			var conditions []metav1.Condition
+			meta.SetStatusCondition(&conditions, AcceptedCondition(3))
+			meta.SetStatusCondition(&conditions, condition)
+			if IsWaitingOnDependency(conditions, PolicyConditionEnforced) != (tc.expectedReason == PolicyReasonWaitingOnDependency) {
+				t.Errorf("unexpected waiting on dependency state for conditions %v", conditions)
+			}
+			if IsWaitingOnDependency(conditions, PolicyConditionAccepted) {
+				t.Errorf("expected policy not to be waiting on dependency to be accepted")
+			}
+		})
+	}
+}
+
+func TestConflictedCondition(t *testing.T) {
+	listener := &machinery.Listener{
+		Gateway:  &machinery.Gateway{Gateway: machinery.BuildGateway()},
+		Listener: &machinery.BuildGateway().Spec.Listeners[0],
+	}
+	winner := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace"},
+	}
+
+	condition := ConflictedCondition(2, machinery.Conflict{Target: listener, Winner: winner, Reason: machinery.ConflictReasonOverridden})
+	if condition.Type != PolicyConditionAccepted || condition.Status != metav1.ConditionFalse || condition.Reason != PolicyReasonConflicted || condition.ObservedGeneration != 2 {
+		t.Errorf("unexpected condition: %+v", condition)
+	}
+	if expected := "Policy conflicts with TestPolicy my-namespace/my-policy targeting Listener my-namespace/my-gateway#my-listener, which takes precedence (Overridden)"; condition.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, condition.Message)
+	}
+}
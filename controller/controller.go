@@ -2,36 +2,96 @@ package controller
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/samber/lo"
-	"github.com/telepresenceio/watchable"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/discovery"
 	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 
 	ctrlruntime "sigs.k8s.io/controller-runtime"
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
 	ctrlruntimectrl "sigs.k8s.io/controller-runtime/pkg/controller"
+	ctrlruntimemetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	ctrlruntimereconcile "sigs.k8s.io/controller-runtime/pkg/reconcile"
 	ctrlruntimesrc "sigs.k8s.io/controller-runtime/pkg/source"
 
+	"github.com/kuadrant/policy-machinery/controller/metrics"
 	"github.com/kuadrant/policy-machinery/machinery"
 )
 
 type ControllerOptions struct {
-	name        string
-	logger      logr.Logger
-	client      *dynamic.DynamicClient
-	manager     ctrlruntime.Manager
-	runnables   map[string]RunnableBuilder
-	reconcile   ReconcileFunc
-	policyKinds []schema.GroupKind
-	objectKinds []schema.GroupKind
-	objectLinks []LinkFunc
+	name                  string
+	logger                logr.Logger
+	client                dynamic.Interface
+	restConfig            *rest.Config
+	preferredAPIVersions  bool
+	manager               ctrlruntime.Manager
+	runnables             map[string]RunnableBuilder
+	reconcile             ReconcileFunc
+	errorReconcile        ErrorReconcileFunc
+	retryBackoff          retryBackoff
+	metrics               *metrics.Metrics
+	metricsRegisterer     prometheus.Registerer
+	tracerProvider        trace.TracerProvider
+	scheme                *runtime.Scheme
+	typedClient           ctrlruntimeclient.WithWatch
+	policyKinds           []schema.GroupKind
+	objectKinds           []schema.GroupKind
+	objectLinks           []LinkFunc
+	statusFeedbacks       []StatusFeedback
+	garbageCollections    []GarbageCollection
+	finalizations         []Finalization
+	policyHooks           []policyHooks
+	pause                 *pauseOptions
+	warmingHints          []machinery.WarmingHint
+	externalEntries       []ExternalServiceEntriesFunc
+	gatewayMerging        []machinery.GatewayMergingFunc
+	objectWrappers        []ObjectWrapperFunc
+	pruneEmptySections    bool
+	backendRefTypes       bool
+	sectionNameSeparator  machinery.SectionNameSeparator
+	gatewaySections       bool
+	endpoints             bool
+	namespaces            bool
+	gatewayClassParams    []schema.GroupKind
+	inferenceExtension    bool
+	fieldMasks            map[schema.GroupKind]FieldMask
+	eventFilters          []EventPredicate
+	assertionRules        []AssertionRule
+	conditionalRunnables  map[string]conditionalRunnable
+	discoveryClient       discovery.DiscoveryInterface
+	discoveryInterval     time.Duration
+	selectableKinds       []schema.GroupKind
+	configKind            *schema.GroupKind
+	configuredKinds       []schema.GroupKind
+	managementState       ManagementState
+	statusReporterWorkers int
+	indexes               map[cacheIndexName]IndexFunc
+	eventBatching         *eventBatch
+	eventBatchMaxLatency  time.Duration
+	initialSync           bool
+	shutdownTimeout       time.Duration
+	statusFlushOnShutdown bool
+	healthProbeAddress    string
+	debugHandlers         bool
+	logLevel              *int
+	logEncoding           LogEncoding
+	topologyDumpLevel     *int
+	eventRecorder         *EventRecorder
+	mutationPlans         *mutationPlans
 }
 
 type ControllerOption func(*ControllerOptions)
@@ -42,12 +102,31 @@ func WithName(name string) ControllerOption {
 	}
 }
 
-func WithClient(client *dynamic.DynamicClient) ControllerOption {
+func WithClient(client dynamic.Interface) ControllerOption {
 	return func(o *ControllerOptions) {
 		o.client = client
 	}
 }
 
+// WithRestConfig sets the config used to create one client per watched resource, so the warnings returned by the
+// API server (e.g. deprecated API versions) are captured per resource, and counted in the metrics of the controller
+// if enabled (see WithMetrics). See Controller.APIWarnings().
+// If no client is provided with WithClient, a default one is created from the config.
+func WithRestConfig(config *rest.Config) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.restConfig = config
+	}
+}
+
+// WithPreferredAPIVersions makes the controller watch the resources in the versions preferred by the API server,
+// as informed by the discovery API, instead of the versions specified in the runnables.
+// It requires the rest config to be set with WithRestConfig.
+func WithPreferredAPIVersions() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.preferredAPIVersions = true
+	}
+}
+
 func WithLogger(logger logr.Logger) ControllerOption {
 	return func(o *ControllerOptions) {
 		o.logger = logger
@@ -82,12 +161,151 @@ func WithObjectKinds(objectKinds ...schema.GroupKind) ControllerOption {
 
 type LinkFunc func(objs Store) machinery.LinkFunc
 
+// ObjectWrapperFunc wraps a cluster runtime object into a topology object of a specific type. It returns false if it
+// does not wrap objects of the type of the given one.
+type ObjectWrapperFunc func(obj Object) (machinery.Object, bool)
+
+// WithObjectWrappers sets functions that wrap the objects of the object kinds (see WithObjectKinds) into topology
+// objects of specific types, e.g. of third-party APIs, instead of the generic RuntimeObject. The first wrapper that
+// wraps an object is used; objects that no wrapper wraps, and are not topology objects already, are wrapped into
+// RuntimeObjects.
+func WithObjectWrappers(wrappers ...ObjectWrapperFunc) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.objectWrappers = append(o.objectWrappers, wrappers...)
+	}
+}
+
 func WithObjectLinks(objectLinks ...LinkFunc) ControllerOption {
 	return func(o *ControllerOptions) {
 		o.objectLinks = append(o.objectLinks, objectLinks...)
 	}
 }
 
+// WithSingletonConfig adds the object of a given kind to the topology as a singleton configuration object, linked to
+// all nodes of the kinds it configures. Reconcilers can read the config object with topology.Config().
+// The kind must be watched by the controller. If multiple objects of the kind exist, the oldest one is used.
+func WithSingletonConfig(kind schema.GroupKind, configures ...schema.GroupKind) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.configKind = &kind
+		o.configuredKinds = configures
+	}
+}
+
+// WithCacheWarming declares queries of the topology expected to be hot, e.g. all paths from gateways to route rules
+// (see machinery.WarmPaths). The queries are precomputed every time the topology is built, before the reconcile
+// function is called, smoothing the latency of the first reconciliation after a restart in large clusters.
+func WithCacheWarming(hints ...machinery.WarmingHint) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.warmingHints = append(o.warmingHints, hints...)
+	}
+}
+
+// WithMetrics enables the metrics of the reconciliation cycles and of the size of the topology (see package
+// metrics), registered against a given registerer. If the registerer is nil, the metrics are registered against the
+// registry of the controller-runtime metrics.
+func WithMetrics(registerer prometheus.Registerer) ControllerOption {
+	return func(o *ControllerOptions) {
+		if registerer == nil {
+			registerer = ctrlruntimemetrics.Registry
+		}
+		o.metrics = metrics.New()
+		o.metricsRegisterer = registerer
+	}
+}
+
+// ExternalServiceEntriesFunc returns the entries of an external service catalog (e.g. Consul or the service registries
+// of cloud providers). It is called every time the topology is built, thus it is expected to return the entries
+// cached by a client of the catalog, instead of querying the catalog.
+type ExternalServiceEntriesFunc func() []*machinery.ExternalServiceEntry
+
+// WithExternalServiceEntries adds the entries of external service catalogs to the topology as targetable backends,
+// linked from the routes that refer to them in their backendRefs, so policies can attach to backends that are not
+// Kubernetes Services. Changes in the catalogs are only reflected in the topology on the next reconciliation.
+func WithExternalServiceEntries(entries ...ExternalServiceEntriesFunc) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.externalEntries = append(o.externalEntries, entries...)
+	}
+}
+
+// WithGatewayMerging opts in to link gateways merged into other gateways to the gateways they are merged into (see
+// machinery.GatewayMergedByAnnotation and machinery.GatewayMergedByInfrastructureLabel), so policies attached to the
+// parent gateways flow to the merged gateways.
+func WithGatewayMerging(merging ...machinery.GatewayMergingFunc) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.gatewayMerging = append(o.gatewayMerging, merging...)
+	}
+}
+
+// WithEmptySectionsPruned opts in to prune from the topology the listeners, route rules and service ports that have no
+// children, no attached policies and no links other than the one from the object they belong to (see
+// machinery.PruneEmptyExpansions), to keep path enumeration manageable on very wide topologies.
+func WithEmptySectionsPruned() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.pruneEmptySections = true
+	}
+}
+
+// WithBackendRefTypes opts in to link route rules to the backends of their RequestMirror filters, and to distinguish
+// the types of the backend references (regular, zero-weight and mirror backends) and their weight in the attributes
+// of the edges from route rules to services (see machinery.WithBackendRefTypes).
+func WithBackendRefTypes() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.backendRefTypes = true
+	}
+}
+
+// WithSectionNameSeparator sets the separator between the names of resources and the names of their sections in the
+// locators of the topology, e.g. '~' to avoid clashes with the fragments of URLs when locators are used in HTTP
+// endpoints (see machinery.WithSectionNameSeparator). Invalid separators are reported and ignored.
+func WithSectionNameSeparator(separator machinery.SectionNameSeparator) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.sectionNameSeparator = separator
+	}
+}
+
+// WithGatewaySections opts in to expand the infrastructure and the addresses of the gateways as sections of the
+// gateways in the topology, so policies can target them by section name like listeners (see
+// machinery.ExpandGatewayInfrastructure and machinery.ExpandGatewayAddresses).
+func WithGatewaySections() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.gatewaySections = true
+	}
+}
+
+// WithEndpoints opts in to expand the services of the topology below to their EndpointSlices and the Pods of their
+// endpoints (see machinery.WithEndpointSlices and machinery.WithPods), for reconcilers that need to know where the
+// workloads actually run.
+// The EndpointSlices and Pods still need to be watched, e.g. with WithRunnable or WithConditionalRunnable.
+func WithEndpoints() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.endpoints = true
+	}
+}
+
+// WithNamespaces adds the Namespaces to the topology as targetables, linked to the Gateways, routes and Services in
+// them (see machinery.WithNamespaces), so policies attached to a namespace apply by default to its resources.
+// The Namespaces still need to be watched, e.g. with WithRunnable or WithConditionalRunnable.
+func WithNamespaces() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.namespaces = true
+	}
+}
+
+// WithGatewayClassParameters adds the objects of the given kinds to the topology (see WithObjectKinds), linked from the
+// GatewayClasses that refer to them in their `parametersRef` (see machinery.WithGatewayClassParameters), so
+// reconcilers can read the configuration of the providers through the topology. If no kind is given, the parameters of
+// kind ConfigMap are linked.
+// The objects still need to be watched, e.g. with WithRunnable or WithConditionalRunnable.
+func WithGatewayClassParameters(kinds ...schema.GroupKind) ControllerOption {
+	if len(kinds) == 0 {
+		kinds = []schema.GroupKind{ConfigMapKind}
+	}
+	return func(o *ControllerOptions) {
+		o.gatewayClassParams = append(o.gatewayClassParams, kinds...)
+		o.objectKinds = append(o.objectKinds, lo.Without(kinds, o.objectKinds...)...)
+	}
+}
+
 func ManagedBy(manager ctrlruntime.Manager) ControllerOption {
 	return func(o *ControllerOptions) {
 		o.manager = manager
@@ -96,29 +314,147 @@ func ManagedBy(manager ctrlruntime.Manager) ControllerOption {
 
 func NewController(f ...ControllerOption) *Controller {
 	opts := &ControllerOptions{
-		name:      "controller",
-		logger:    logr.Discard(),
-		runnables: map[string]RunnableBuilder{},
+		name:            "controller",
+		logger:          logr.Discard(),
+		runnables:       map[string]RunnableBuilder{},
+		managementState: ManagementStateManaged,
 		reconcile: func(context.Context, []ResourceEvent, *machinery.Topology) {
 		},
+		retryBackoff:    defaultRetryBackoff,
+		shutdownTimeout: defaultShutdownTimeout,
 	}
 	for _, fn := range f {
 		fn(opts)
 	}
+	if logger, ok := newControllerLogger(opts.logLevel, opts.logEncoding); ok {
+		opts.logger = logger
+	}
+	if opts.tracerProvider != nil && opts.restConfig != nil {
+		opts.restConfig = tracedRestConfig(opts.restConfig, opts.tracerProvider)
+	}
 
 	controller := &Controller{
-		name:      opts.name,
-		logger:    opts.logger,
-		client:    opts.client,
-		manager:   opts.manager,
-		cache:     &watchableCacheStore{},
-		topology:  newGatewayAPITopologyBuilder(opts.policyKinds, opts.objectKinds, opts.objectLinks),
-		runnables: map[string]Runnable{},
-		reconcile: opts.reconcile,
+		name:                 opts.name,
+		logger:               opts.logger,
+		client:               opts.client,
+		restConfig:           opts.restConfig,
+		preferredAPIVersions: opts.preferredAPIVersions,
+		apiWarnings:          &APIWarnings{metrics: opts.metrics},
+		manager:              opts.manager,
+		cache:                &watchableCacheStore{indexers: newCacheIndexers(opts.indexes)},
+		topology:             newGatewayAPITopologyBuilder(opts.policyKinds, opts.objectKinds, opts.objectLinks, opts.configKind, opts.configuredKinds),
+		runnables:            map[string]Runnable{},
+		runnableResources:    map[string][]schema.GroupVersionResource{},
+		runnableStops:        map[string]chan struct{}{},
+		inventory:            newInventory(),
+		reconcile:            WithoutErrors(opts.reconcile),
+		retries:              &retries{backoff: opts.retryBackoff},
+		eventBatch:           opts.eventBatching,
+		awaitingInitialSync:  opts.initialSync,
+		shutdownTimeout:      opts.shutdownTimeout,
+		flushStatuses:        opts.statusFlushOnShutdown,
+		healthProbeAddress:   opts.healthProbeAddress,
+		debugHandlers:        opts.debugHandlers,
+		topologyDumpLevel:    opts.topologyDumpLevel,
+		eventRecorder:        opts.eventRecorder,
+		mutationPlans:        opts.mutationPlans,
+		metrics:              opts.metrics,
+		typedClient:          opts.typedClient,
+		statusFeedbacks:      opts.statusFeedbacks,
+		garbageCollections:   opts.garbageCollections,
+		finalizations:        opts.finalizations,
+		policyHooks:          lo.Map(opts.policyHooks, func(h policyHooks, _ int) *policyHooks { return &h }),
+		selectableKinds:      opts.selectableKinds,
+		pause:                opts.pause,
+		fieldMasks:           opts.fieldMasks,
+		eventFilters:         opts.eventFilters,
+		managementState:      opts.managementState,
+		removedCh:            make(chan struct{}),
+	}
+
+	if controller.eventBatch != nil && opts.eventBatchMaxLatency > 0 {
+		controller.eventBatch.maxLatency = opts.eventBatchMaxLatency
+	}
+
+	if opts.statusReporterWorkers > 0 {
+		controller.statusReporter = newStatusReporter(opts.statusReporterWorkers, opts.retryBackoff, opts.logger, func() bool {
+			return controller.ManagementState() != ManagementStateManaged
+		})
+	}
+
+	if opts.tracerProvider != nil {
+		controller.tracer = opts.tracerProvider.Tracer(TracerName)
+	}
+
+	if len(opts.assertionRules) > 0 {
+		controller.assertions = &assertions{rules: opts.assertionRules}
+	}
+
+	if len(opts.conditionalRunnables) > 0 {
+		controller.discoveries = &runnableDiscoveries{
+			runnables: opts.conditionalRunnables,
+			client:    opts.discoveryClient,
+			interval:  opts.discoveryInterval,
+		}
+		if controller.discoveries.client == nil && opts.restConfig != nil {
+			client, err := discovery.NewDiscoveryClientForConfig(opts.restConfig)
+			if err != nil {
+				controller.logger.Error(err, "failed to create discovery client from rest config")
+			} else {
+				controller.discoveries.client = client
+			}
+		}
+	}
+
+	controller.topology.warmingHints = opts.warmingHints
+	controller.topology.externalEntries = opts.externalEntries
+	controller.topology.gatewayMerging = opts.gatewayMerging
+	controller.topology.objectWrappers = opts.objectWrappers
+	controller.topology.pruneEmptySections = opts.pruneEmptySections
+	controller.topology.backendRefTypes = opts.backendRefTypes
+	if opts.sectionNameSeparator != 0 {
+		if err := opts.sectionNameSeparator.Validate(); err != nil {
+			controller.logger.Error(err, "ignoring the section name separator")
+		} else {
+			controller.topology.sectionSeparator = opts.sectionNameSeparator
+		}
+	}
+	controller.topology.gatewaySections = opts.gatewaySections
+	controller.topology.endpoints = opts.endpoints
+	controller.topology.namespaces = opts.namespaces
+	controller.topology.gatewayClassParams = opts.gatewayClassParams
+	controller.topology.inferenceExtension = opts.inferenceExtension
+
+	if controller.metrics != nil {
+		if err := controller.metrics.Register(opts.metricsRegisterer); err != nil {
+			controller.logger.Error(err, "failed to register metrics")
+		}
+	}
+
+	if opts.errorReconcile != nil {
+		controller.reconcile = opts.errorReconcile
+	}
+
+	if controller.client == nil && controller.restConfig != nil {
+		client, err := dynamic.NewForConfig(controller.restConfig)
+		if err != nil {
+			controller.logger.Error(err, "failed to create client from rest config")
+		} else {
+			controller.client = client
+		}
+	}
+
+	if controller.typedClient == nil && opts.scheme != nil && controller.restConfig != nil {
+		client, err := ctrlruntimeclient.NewWithWatch(controller.restConfig, ctrlruntimeclient.Options{Scheme: opts.scheme})
+		if err != nil {
+			controller.logger.Error(err, "failed to create typed client from rest config")
+		} else {
+			controller.typedClient = client
+		}
 	}
 
 	for name, builder := range opts.runnables {
-		controller.runnables[name] = builder(controller)
+		controller.buildRunnable(name, builder)
 	}
 
 	return controller
@@ -129,52 +465,120 @@ type WatchFunc func(ctrlruntime.Manager) ctrlruntimesrc.Source
 
 type Controller struct {
 	sync.Mutex
-	name       string
-	logger     logr.Logger
-	client     *dynamic.DynamicClient
-	manager    ctrlruntime.Manager
-	cache      Cache
-	topology   *gatewayAPITopologyBuilder
-	runnables  map[string]Runnable
-	listFuncs  []ListFunc
-	watchFuncs []WatchFunc
-	reconcile  ReconcileFunc
-}
-
-// Start starts the runnables and blocks until the context is cancelled
+	name                 string
+	logger               logr.Logger
+	client               dynamic.Interface
+	restConfig           *rest.Config
+	preferredAPIVersions bool
+	resourceClients      sync.Map
+	watchedResources     sync.Map
+	apiWarnings          *APIWarnings
+	manager              ctrlruntime.Manager
+	cache                Cache
+	topology             *gatewayAPITopologyBuilder
+	latestTopology       machinery.SharedTopology
+	runnables            map[string]Runnable
+	writes               cacheWrites
+	runnablesMutex       sync.Mutex
+	syncables            sync.Map // runnables by name, readable without the runnables mutex (see runnablesSynced)
+	runnableResources    map[string][]schema.GroupVersionResource
+	runnableStops        map[string]chan struct{}
+	inventory            *inventory
+	registering          *[]schema.GroupVersionResource
+	stopCh               <-chan struct{}
+	ctrl                 ctrlruntimectrl.Controller
+	listFuncs            []ListFunc
+	watchFuncs           []WatchFunc
+	reconcile            ErrorReconcileFunc
+	retries              *retries
+	eventBatch           *eventBatch
+	metrics              *metrics.Metrics
+	tracer               trace.Tracer
+	typedClient          ctrlruntimeclient.WithWatch
+	statusFeedbacks      []StatusFeedback
+	garbageCollections   []GarbageCollection
+	finalizations        []Finalization
+	policyHooks          []*policyHooks
+	selectableKinds      []schema.GroupKind
+	pause                *pauseOptions
+	fieldMasks           map[schema.GroupKind]FieldMask
+	eventFilters         []EventPredicate
+	assertions           *assertions
+	oneShot              bool
+	awaitingInitialSync  bool
+	shutdownTimeout      time.Duration
+	flushStatuses        bool
+	stopped              atomic.Bool
+	lifecycleMutex       sync.Mutex
+	cancel               context.CancelFunc
+	done                 chan struct{}
+	health               health
+	healthProbeAddress   string
+	debugHandlers        bool
+	topologyDumpLevel    *int
+	eventRecorder        *EventRecorder
+	mutationPlans        *mutationPlans
+	discoveries          *runnableDiscoveries
+	managementState      ManagementState
+	managementStateMutex sync.Mutex
+	removedCh            chan struct{}
+	removedOnce          sync.Once
+	statusReporter       *StatusReporter
+}
+
+// Start starts the runnables and blocks until the context is cancelled or the controller is stopped (see Stop), then
+// shuts the controller down gracefully (see WithShutdownTimeout).
 func (c *Controller) Start(ctx context.Context) error {
 	stopCh := make(chan struct{})
 
+	// stop once cleaned up in the removed management state
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer c.started(cancel)()
+	go func() {
+		select {
+		case <-c.removedCh:
+			c.logger.Info("controller removed")
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	stopHealthProbes := c.serveHealthProbes()
+	defer stopHealthProbes()
+
 	// subscribe to cache
 	c.subscribe()
 
-	// start runnables
-	for name := range c.runnables {
-		c.logger.Info("starting runnable", "name", name)
-		go c.runnables[name].Run(stopCh)
+	// the status reporter outlives the context, to flush the statuses on shutdown (see WithStatusFlushOnShutdown)
+	statusReporterCtx, stopStatusReporter := context.WithCancel(context.WithoutCancel(ctx))
+	defer stopStatusReporter()
+	if c.statusReporter != nil {
+		c.statusReporter.start(statusReporterCtx)
 	}
 
-	// wait for cache sync
-	for name := range c.runnables {
-		if !cache.WaitForCacheSync(stopCh, c.runnables[name].HasSynced) {
-			return fmt.Errorf("error waiting for %s cache sync", name)
-		}
+	// add the conditional runnables of the resources already served, so they start with the others
+	if c.discoveries != nil {
+		c.discoverRunnables()
+		go c.watchDiscovery(ctx)
+	}
+
+	if err := c.startRunnables(stopCh); err != nil {
+		return err
 	}
+	c.completeInitialSync()
+	c.health.started.Store(true)
 
 	// start controller manager
 	if c.manager != nil {
-		ctrl, err := ctrlruntimectrl.New(c.name, c.manager, ctrlruntimectrl.Options{Reconciler: c})
-		if err != nil {
-			return fmt.Errorf("Error creating controller: %v", err)
-		}
-		for _, f := range c.watchFuncs {
-			if err := ctrl.Watch(f(c.manager)); err != nil {
-				return fmt.Errorf("Error watching resource: %v", err)
-			}
+		if err := c.watchWithManager(); err != nil {
+			return err
 		}
 		c.logger.V(1).Info("starting controller manager")
 		c.manager.Start(ctx)
 		c.logger.V(1).Info("finishing controller manager")
+		close(stopCh)
+		c.shutdown(stopStatusReporter)
 		return nil
 	}
 
@@ -187,10 +591,53 @@ func (c *Controller) Start(ctx context.Context) error {
 		}
 	}, time.Second, stopCh)
 	c.logger.Info("stop signal received. finishing controller...")
+	c.shutdown(stopStatusReporter)
 
 	return nil
 }
 
+// startRunnables starts the runnables and waits for their caches to sync.
+// Runnables added afterwards (see AddRunnable) are started right away.
+func (c *Controller) startRunnables(stopCh <-chan struct{}) error {
+	c.runnablesMutex.Lock()
+	defer c.runnablesMutex.Unlock()
+
+	c.stopCh = stopCh
+
+	// start runnables
+	synced := make(map[string]<-chan struct{}, len(c.runnables))
+	for name := range c.runnables {
+		c.logger.Info("starting runnable", "name", name)
+		synced[name] = c.startRunnable(name)
+	}
+
+	// wait for cache sync
+	for name, runCh := range synced {
+		if !cache.WaitForCacheSync(runCh, c.runnables[name].HasSynced) {
+			return fmt.Errorf("error waiting for %s cache sync: %w", name, ErrNotSynced)
+		}
+	}
+	return nil
+}
+
+// watchWithManager creates the controller of the controller manager and sets up the watches of the runnables.
+// Runnables that set up watches afterwards are watched right away (see listAndWatch).
+func (c *Controller) watchWithManager() error {
+	ctrl, err := ctrlruntimectrl.New(c.name, c.manager, ctrlruntimectrl.Options{Reconciler: c})
+	if err != nil {
+		return fmt.Errorf("Error creating controller: %v", err)
+	}
+	c.Lock()
+	defer c.Unlock()
+	for _, f := range c.watchFuncs {
+		if err := ctrl.Watch(f(c.manager)); err != nil {
+			return fmt.Errorf("Error watching resource: %v", err)
+		}
+	}
+	c.ctrl = ctrl
+	return nil
+}
+
 func (c *Controller) Reconcile(ctx context.Context, _ ctrlruntimereconcile.Request) (ctrlruntimereconcile.Result, error) {
 	c.Lock()
 	defer c.Unlock()
@@ -198,15 +645,65 @@ func (c *Controller) Reconcile(ctx context.Context, _ ctrlruntimereconcile.Reque
 	c.logger.Info("reconciling state of the world started")
 	defer c.logger.Info("reconciling state of the world finished")
 
+	c.listState()
+
+	return ctrlruntimereconcile.Result{}, nil
+}
+
+// listState replaces the objects in the cache with the ones listed by the runnables that list the state of the world
+// (see StateReconciler). It must be called with the lock held.
+func (c *Controller) listState() {
 	store := Store{}
 	for _, f := range c.listFuncs {
 		for _, object := range f() {
 			store[string(object.GetUID())] = object
 		}
 	}
+	if len(c.fieldMasks) > 0 {
+		compacted, err := store.Compact(c.fieldMasks)
+		if err != nil {
+			c.logger.Error(err, "failed to compact store")
+		}
+		store = compacted
+	}
 	c.cache.Replace(store)
+}
 
-	return ctrlruntimereconcile.Result{}, nil
+// APIWarnings returns the warnings returned by the API server for each watched resource.
+// Warnings are only captured if the controller was created with a rest config (see WithRestConfig).
+func (c *Controller) APIWarnings() map[schema.GroupVersionResource][]string {
+	return c.apiWarnings.List()
+}
+
+// resourceClient returns a client for a given resource.
+// If the controller has a rest config, the client is specific to the resource and records the warnings returned by
+// the API server; it also targets the version of the resource preferred by the API server, if so configured.
+func (c *Controller) resourceClient(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	if c.restConfig == nil {
+		return c.client.Resource(resource)
+	}
+	if client, ok := c.resourceClients.Load(resource); ok {
+		return client.(dynamic.NamespaceableResourceInterface)
+	}
+	targetResource := resource
+	if c.preferredAPIVersions {
+		if discoveryClient, err := discovery.NewDiscoveryClientForConfig(c.restConfig); err == nil {
+			if preferred, err := PreferredVersionFor(discoveryClient, resource); err == nil && preferred != resource {
+				c.logger.Info("switching to preferred api version", "resource", resource.String(), "preferred", preferred.String())
+				targetResource = preferred
+			}
+		}
+	}
+	config := rest.CopyConfig(c.restConfig)
+	config.WarningHandler = c.apiWarnings.HandlerFor(targetResource)
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		c.logger.Error(err, "failed to create client for resource", "resource", resource.String())
+		return c.client.Resource(targetResource)
+	}
+	c.served(resource, targetResource)
+	resourceClient, _ := c.resourceClients.LoadOrStore(resource, client.Resource(targetResource))
+	return resourceClient.(dynamic.NamespaceableResourceInterface)
 }
 
 func (c *Controller) listAndWatch(listFunc ListFunc, watchFunc WatchFunc) {
@@ -215,39 +712,293 @@ func (c *Controller) listAndWatch(listFunc ListFunc, watchFunc WatchFunc) {
 
 	c.listFuncs = append(c.listFuncs, listFunc)
 	c.watchFuncs = append(c.watchFuncs, watchFunc)
+
+	// the controller manager is already watching the resources of the other runnables
+	if c.ctrl != nil {
+		if err := c.ctrl.Watch(watchFunc(c.manager)); err != nil {
+			c.logger.Error(err, "failed to watch resource")
+		}
+	}
+}
+
+// cacheWrites buffers the writes of the informers to the cache, so a burst of events received while the controller
+// holds the lock is committed to the cache as one batch (see commitWrites).
+type cacheWrites struct {
+	sync.Mutex
+	batch  CacheBatch
+	events []ResourceEvent
+}
+
+func (w *cacheWrites) add(obj Object, events ...ResourceEvent) {
+	w.Lock()
+	defer w.Unlock()
+	w.batch.Add(obj)
+	w.events = append(w.events, events...)
+}
+
+func (w *cacheWrites) delete(obj Object, events ...ResourceEvent) {
+	w.Lock()
+	defer w.Unlock()
+	w.batch.Delete(obj)
+	w.events = append(w.events, events...)
+}
+
+func (w *cacheWrites) drain() (*CacheBatch, []ResourceEvent) {
+	w.Lock()
+	defer w.Unlock()
+	batch, events := w.batch, w.events
+	w.batch, w.events = CacheBatch{}, nil
+	return &batch, events
+}
+
+// commitWrites commits the writes buffered by the informers to the cache as one batch and propagates their events.
+// The batch is marked as propagated, so the subscription to the cache does not propagate its events again (see
+// subscribe).
+// The writes of an informer may have been committed already by a concurrent call. It must be called with the lock held.
+func (c *Controller) commitWrites() {
+	batch, events := c.writes.drain()
+	if batch.Len() == 0 {
+		return
+	}
+	batch.propagated = true
+	c.cache.Commit(batch)
+	// writes without events, e.g. of status-only changes, only update the cache
+	if len(events) > 0 {
+		c.propagate(events)
+	}
 }
 
 func (c *Controller) add(obj Object) {
+	obj = c.compact(obj)
+	c.writes.add(obj, ResourceEvent{obj.GetObjectKind().GroupVersionKind().GroupKind(), CreateEvent, nil, obj})
+
 	c.Lock()
 	defer c.Unlock()
 
-	c.cache.Add(obj)
-	c.propagate([]ResourceEvent{{obj.GetObjectKind().GroupVersionKind().GroupKind(), CreateEvent, nil, obj}})
+	c.commitWrites()
 }
 
 func (c *Controller) update(oldObj, newObj Object) {
+	oldObj, newObj = c.compact(oldObj), c.compact(newObj)
+
 	c.Lock()
 	defer c.Unlock()
 
-	if oldObj.GetGeneration() == newObj.GetGeneration() {
+	// pausing or resuming a resource, relabeling a resource selected by policies, or marking a policy for deletion does
+	// not necessarily change its generation, yet it must be reconciled
+	if oldObj.GetGeneration() == newObj.GetGeneration() && (c.pause == nil || !pauseChanged(oldObj, newObj)) && !selectableLabelsChanged(c.selectableKinds, oldObj, newObj) && (len(c.finalizations) == 0 || !deletionChanged(oldObj, newObj)) {
+		// status-only changes of generated resources are fed back into the status of the owning policies
+		if isStatusFeedbackKind(c.statusFeedbacks, newObj.GetObjectKind().GroupVersionKind().GroupKind()) {
+			c.writes.add(newObj)
+			c.commitWrites()
+			// as in the reconciliations, the status is only fed back in the managed state (see run)
+			if managementState := c.ManagementState(); managementState == ManagementStateManaged {
+				ctx := ManagementStateIntoContext(LoggerIntoContext(context.TODO(), c.logger), managementState)
+				reconcileStatusFeedback(c.statusReporterIntoContext(ctx), c.resourceClient, c.statusFeedbacks, c.topology.Build(c.cache.List()))
+			}
+		}
 		return
 	}
 
-	c.cache.Add(newObj)
-	c.propagate([]ResourceEvent{{newObj.GetObjectKind().GroupVersionKind().GroupKind(), UpdateEvent, oldObj, newObj}})
+	c.writes.add(newObj, ResourceEvent{newObj.GetObjectKind().GroupVersionKind().GroupKind(), UpdateEvent, oldObj, newObj})
+	c.commitWrites()
 }
 
 func (c *Controller) delete(obj Object) {
+	obj = c.compact(obj)
+	c.writes.delete(obj, ResourceEvent{obj.GetObjectKind().GroupVersionKind().GroupKind(), DeleteEvent, obj, nil})
+
 	c.Lock()
 	defer c.Unlock()
 
-	c.cache.Delete(obj)
-	c.propagate([]ResourceEvent{{obj.GetObjectKind().GroupVersionKind().GroupKind(), DeleteEvent, obj, nil}})
+	c.commitWrites()
 }
 
 func (c *Controller) propagate(resourceEvents []ResourceEvent) {
+	// in one-shot mode, the events only update the cache, which is reconciled once at the end (see RunOnce)
+	if c.oneShot {
+		return
+	}
+	for _, event := range resourceEvents {
+		obj := event.NewObject
+		if obj == nil {
+			obj = event.OldObject
+		}
+		gvk := event.Kind.WithVersion("")
+		if obj != nil {
+			gvk = obj.GetObjectKind().GroupVersionKind()
+		}
+		c.metrics.ObserveEvent(gvk, event.EventType.String())
+		for _, runnable := range c.inventory.recordEvent(gvk, time.Now()) {
+			c.metrics.ObserveRunnableEvent(runnable)
+		}
+	}
+	// until the initial sync, the events only fill the cache (see WithInitialSync)
+	if c.awaitingInitialSync {
+		return
+	}
+	events := c.filterEvents(resourceEvents)
+	if len(events) == 0 && len(resourceEvents) > 0 {
+		c.logger.V(1).Info("skipping reconciliation of filtered out events")
+		return
+	}
+	if c.eventBatch != nil && len(events) > 0 {
+		if !c.eventBatch.add(events, c.flushEventBatch) {
+			return
+		}
+		// events of objects created and deleted within the batch cancel each other out
+		if events = c.eventBatch.take(); len(events) == 0 {
+			return
+		}
+	}
+	c.run(events)
+}
+
+// flushEventBatch reconciles the events batched at the end of the debounce window (see WithEventBatching).
+func (c *Controller) flushEventBatch() {
+	c.Lock()
+	defer c.Unlock()
+
+	// the batch may have been reconciled meanwhile, on reaching its maximum size
+	if events := c.eventBatch.take(); len(events) > 0 {
+		c.run(events)
+	}
+}
+
+// Topology returns the topology of the latest reconciliation of the controller, or nil before the first
+// reconciliation. It is safe to call concurrently with the reconciliations, e.g. from the reconcilers of a parallel
+// workflow or from HTTP handlers: the topology returned is never modified, as every reconciliation builds a new one.
+func (c *Controller) Topology() *machinery.Topology {
+	return c.latestTopology.Load()
+}
+
+// run builds the topology and reconciles a list of events, returning the error of the reconciliation.
+// It must be called with the lock held.
+func (c *Controller) run(resourceEvents []ResourceEvent) error {
+	// no reconciliation after shutdown, e.g. of retries or batches of events due afterwards
+	if c.stopped.Load() {
+		return nil
+	}
 	topology := c.topology.Build(c.cache.List())
-	c.reconcile(LoggerIntoContext(context.TODO(), c.logger), resourceEvents, topology)
+	c.latestTopology.Store(topology)
+	if c.topologyDumpLevel != nil {
+		if logger := c.logger.V(*c.topologyDumpLevel); logger.Enabled() {
+			logger.Info("topology", "dot", topology.ToDot())
+		}
+	}
+	c.metrics.ObserveTopology(topology)
+	c.observeInventory()
+	synced := c.runnablesSynced()
+	ctx := LoggerIntoContext(context.TODO(), c.logger)
+	ctx = CachedClientIntoContext(ctx, &CachedClient{client: c.client, cache: c.cache, watched: func(resource schema.GroupResource) bool {
+		return synced && c.watches(resource)
+	}})
+	if c.typedClient != nil {
+		ctx = TypedClientIntoContext(ctx, c.typedClient)
+	}
+	if c.tracer != nil {
+		ctx = TracerIntoContext(ctx, c.tracer)
+	}
+	ctx = c.statusReporterIntoContext(ctx)
+	if c.eventRecorder != nil && !c.oneShot {
+		ctx = EventRecorderIntoContext(ctx, c.eventRecorder)
+	}
+	managementState := c.ManagementState()
+	ctx = ManagementStateIntoContext(ctx, managementState)
+	ctx = CacheSyncedIntoContext(ctx, synced)
+	ctx, span := StartSpan(ctx, "reconcile")
+	defer span.End()
+	span.SetAttributes(attribute.String("controller", c.name), attribute.Int("events", len(resourceEvents)))
+	if managementState == ManagementStateRemoved {
+		return c.remove(ctx, resourceEvents, topology)
+	}
+	unmanaged := managementState == ManagementStateUnmanaged
+	events := resourceEvents
+	if c.pause != nil {
+		events = unpausedEvents(resourceEvents)
+	}
+	var errs []error
+	if len(events) > 0 || len(resourceEvents) == 0 {
+		ctx := AffectedSubgraphIntoContext(ctx, events, topology)
+		var desired *desiredObjects
+		if c.mutationPlans != nil {
+			desired = &desiredObjects{}
+			ctx = context.WithValue(ctx, desiredObjectsKey{}, desired)
+		}
+		start := time.Now()
+		err := c.reconcile(ctx, events, topology)
+		errs = append(errs, err)
+		c.metrics.ObserveReconcile(time.Since(start), err)
+		if IsFailure(err) {
+			recordSpanError(span, err)
+		}
+		if err != nil {
+			c.retry(events, err)
+		} else {
+			c.retries.failures = 0
+			c.health.lastReconciled.Store(time.Now().UnixNano())
+		}
+		if desired != nil && err == nil {
+			if err := c.planMutations(ctx, desired, unmanaged); err != nil {
+				errs = append(errs, err)
+				c.retry(events, err)
+			}
+		}
+	} else {
+		c.logger.V(1).Info("skipping reconciliation of events of paused resources")
+	}
+	if c.pause != nil && !unmanaged {
+		reconcilePausedStatus(ctx, c.resourceClient, c.pause.policyResources, topology)
+	}
+	if len(c.statusFeedbacks) > 0 && !unmanaged {
+		reconcileStatusFeedback(ctx, c.resourceClient, c.statusFeedbacks, topology)
+	}
+	if len(c.garbageCollections) > 0 && !unmanaged {
+		if synced {
+			collectGarbage(ctx, c.resourceClient, c.garbageCollections, topology)
+		} else {
+			c.logger.V(1).Info("skipping garbage collection until the caches of the runnables have synced")
+		}
+	}
+	if !unmanaged {
+		for _, hooks := range c.policyHooks {
+			hooks.invoke(ctx, topology)
+		}
+	}
+	if c.assertions != nil {
+		c.assertions.evaluate(ctx, topology, c.metrics)
+	}
+	if len(c.finalizations) > 0 && !unmanaged {
+		if err := reconcileFinalizations(ctx, c.resourceClient, c.finalizations, c.cache.List(), c.topology.Build); err != nil {
+			errs = append(errs, err)
+			c.retry(events, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// retry schedules the reconciliation of events that failed to be reconciled.
+// It must be called with the lock held.
+func (c *Controller) retry(resourceEvents []ResourceEvent, err error) {
+	// in one-shot mode, the errors are reported instead (see RunOnce)
+	if c.oneShot {
+		return
+	}
+	delay := c.retries.schedule(resourceEvents, err, func() {
+		c.Lock()
+		defer c.Unlock()
+		events := c.retries.take()
+		c.metrics.SetQueueDepth(0)
+		c.health.retryEvents.Store(0)
+		c.run(events)
+	})
+	c.metrics.SetQueueDepth(len(c.retries.events))
+	c.health.retryEvents.Store(int64(len(c.retries.events)))
+	if IsFailure(err) {
+		c.logger.Error(err, "reconciliation failed", "retryAfter", delay.String(), "failures", c.retries.failures)
+	} else {
+		c.logger.V(1).Info("reconciliation requeued", "retryAfter", delay.String())
+	}
 }
 
 func (c *Controller) subscribe() {
@@ -255,13 +1006,22 @@ func (c *Controller) subscribe() {
 	if !ok {
 		return
 	}
-	subscription := cache.Subscribe(context.TODO())
+	// each commit to the cache is published at once, with the previous version of the objects, to report changes of
+	// objects already in the cache as updates, e.g. so an update followed by a deletion of an object within a batch of
+	// events is not mistaken for a creation and a deletion that cancel each other out (see CoalesceEvents).
+	// The commits of the informers are propagated by the informers themselves (see commitWrites), thus only the other
+	// commits, e.g. of the state of the world (see listState), are propagated by the subscription.
+	subscription := cache.subscribeCommits(context.TODO())
 	go func() {
-		for snapshot := range subscription {
+		for commit := range subscription {
+			if commit.Propagated {
+				continue
+			}
+
 			c.Lock()
 
-			c.propagate(lo.FlatMap(snapshot.Updates, func(update watchable.Update[string, watchableCacheEntry], _ int) []ResourceEvent {
-				obj := update.Value
+			c.propagate(lo.Map(commit.Updates, func(update watchableCacheUpdate, _ int) ResourceEvent {
+				obj := update.Value.Object
 
 				event := ResourceEvent{
 					Kind: obj.GetObjectKind().GroupVersionKind().GroupKind(),
@@ -270,12 +1030,16 @@ func (c *Controller) subscribe() {
 				if update.Delete {
 					event.EventType = DeleteEvent
 					event.OldObject = obj
+				} else if update.Previous != nil {
+					event.EventType = UpdateEvent
+					event.OldObject = update.Previous
+					event.NewObject = obj
 				} else {
-					event.EventType = CreateEvent // what about UpdateEvent?
+					event.EventType = CreateEvent
 					event.NewObject = obj
 				}
 
-				return []ResourceEvent{event}
+				return event
 			}))
 
 			c.Unlock()
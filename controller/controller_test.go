@@ -83,6 +83,14 @@ func TestControllerOptions(t *testing.T) {
 		t.Errorf("expected 1 object link, got %d", len(opts.objectLinks))
 	}
 
+	WithSingletonConfig(ConfigMapKind, GatewayClassKind)(opts)
+	if opts.configKind == nil || *opts.configKind != ConfigMapKind {
+		t.Errorf("expected config kind %v, got %v", ConfigMapKind, opts.configKind)
+	}
+	if len(opts.configuredKinds) != 1 || opts.configuredKinds[0] != GatewayClassKind {
+		t.Errorf("expected configured kinds [%v], got %v", GatewayClassKind, opts.configuredKinds)
+	}
+
 	ManagedBy(testManager)(opts)
 	if opts.manager != testManager {
 		t.Errorf("expected manager %v, got %v", testManager, opts.manager)
@@ -98,7 +106,7 @@ func TestNewController(t *testing.T) {
 	type expected struct {
 		name          string
 		logger        logr.Logger
-		client        *dynamic.DynamicClient
+		client        dynamic.Interface
 		manager       ctrlruntime.Manager
 		policyKinds   []schema.GroupKind
 		objectKinds   []schema.GroupKind
@@ -218,7 +226,7 @@ func TestControllerReconcile(t *testing.T) {
 		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "test-configmap", UID: "aed148b1-285a-48ab-8839-fe99475bc6fc"}},
 	}
 	objUIDs := lo.Map(objs, func(o Object, _ int) string { return string(o.GetUID()) })
-	cache := &cacheStore{store: make(Store)}
+	cache := newCacheStore(make(Store))
 	controller := &Controller{
 		logger: testLogger,
 		cache:  cache,
@@ -0,0 +1,197 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// WorkflowTask is a reconciliation task of a DAGWorkflow that declares the data it reads and writes, so the workflow
+// can order it after the tasks it depends on.
+// Inputs and outputs are keys of any comparable type, e.g. the keys of the values the tasks exchange with the
+// WorkflowState, or the kinds (schema.GroupKind) of the resources generated by one task and read by another.
+type WorkflowTask struct {
+	// Name identifies the task in the errors, the traces and the logs of the workflow. Defaults to the name of the
+	// reconciliation function.
+	Name      string
+	Reconcile ErrorReconcileFunc
+	// Inputs are the keys of the data read by the task. The task runs after all the tasks that output any of them.
+	Inputs []any
+	// Outputs are the keys of the data written by the task.
+	Outputs []any
+}
+
+func (t WorkflowTask) name() string {
+	if t.Name != "" {
+		return t.Name
+	}
+	return reconcileFuncName(t.Reconcile)
+}
+
+// DAGWorkflow runs reconciliation tasks in the order given by the dependencies between their inputs and outputs.
+// Independent tasks run concurrently, up to MaxParallelism tasks at a time; a task runs once all the tasks it depends
+// on are done. The tasks that depend on a failed task, directly or not, are skipped; errors that only ask for the
+// reconciliation to be retried (see IsFailure) do not skip the dependents.
+// The errors of all the tasks run are aggregated with errors.Join.
+type DAGWorkflow struct {
+	Tasks []WorkflowTask
+	// MaxParallelism is the maximum number of tasks that run concurrently. Less than 1 means no limit.
+	MaxParallelism int
+}
+
+// Validate returns an error if the dependencies between the tasks are circular.
+func (w *DAGWorkflow) Validate() error {
+	_, err := w.dependencies()
+	return err
+}
+
+func (w *DAGWorkflow) Run(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) error {
+	dependencies, err := w.dependencies()
+	if err != nil {
+		return err
+	}
+	if WorkflowStateFromContext(ctx) == nil {
+		ctx = WorkflowStateIntoContext(ctx, &sync.Map{})
+	}
+
+	n := len(w.Tasks)
+	pending := make([]int, n)      // number of dependencies of each task that are not done yet
+	dependents := make([][]int, n) // tasks that depend on each task
+	for i, deps := range dependencies {
+		pending[i] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+	}
+
+	var queue []int
+	for i := range w.Tasks {
+		if pending[i] == 0 {
+			queue = append(queue, i)
+		}
+	}
+
+	errs := make([]error, n)
+	skipped := make([]bool, n)
+	done := make(chan int, n)
+	finished, running := 0, 0
+
+	// release the dependents of a finished task, skipping them if the task failed or was skipped
+	release := func(i int) {
+		finished++
+		for _, dependent := range dependents[i] {
+			if skipped[i] || IsFailure(errs[i]) {
+				skipped[dependent] = true
+			}
+			if pending[dependent]--; pending[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+
+	for finished < n {
+		for len(queue) > 0 && (w.MaxParallelism < 1 || running < w.MaxParallelism) {
+			i := queue[0]
+			queue = queue[1:]
+			if skipped[i] {
+				release(i)
+				continue
+			}
+			running++
+			go func(i int) {
+				defer func() { done <- i }()
+				if f := w.Tasks[i].Reconcile; f != nil {
+					errs[i] = tracedNamedErrorReconcileFunc(w.Tasks[i].name(), f)(ctx, resourceEvents, topology)
+				}
+			}(i)
+		}
+		if running == 0 {
+			continue
+		}
+		i := <-done
+		running--
+		if errs[i] != nil {
+			errs[i] = fmt.Errorf("task %s: %w", w.Tasks[i].name(), errs[i])
+		}
+		release(i)
+	}
+
+	return errors.Join(errs...)
+}
+
+// dependencies returns the indexes of the tasks each task depends on, i.e. the tasks that output any of its inputs,
+// or an error if the dependencies are circular.
+func (w *DAGWorkflow) dependencies() ([][]int, error) {
+	producers := make(map[any][]int)
+	for i, task := range w.Tasks {
+		for _, output := range task.Outputs {
+			producers[output] = append(producers[output], i)
+		}
+	}
+
+	dependencies := make([][]int, len(w.Tasks))
+	for i, task := range w.Tasks {
+		seen := make(map[int]struct{})
+		for _, input := range task.Inputs {
+			for _, producer := range producers[input] {
+				if _, ok := seen[producer]; ok || producer == i {
+					continue
+				}
+				seen[producer] = struct{}{}
+				dependencies[i] = append(dependencies[i], producer)
+			}
+		}
+	}
+
+	// detect cycles by sorting the tasks topologically
+	pending := make([]int, len(w.Tasks))
+	dependents := make([][]int, len(w.Tasks))
+	var queue []int
+	for i, deps := range dependencies {
+		pending[i] = len(deps)
+		for _, dep := range deps {
+			dependents[dep] = append(dependents[dep], i)
+		}
+		if len(deps) == 0 {
+			queue = append(queue, i)
+		}
+	}
+	for len(queue) > 0 {
+		i := queue[0]
+		queue = queue[1:]
+		for _, dependent := range dependents[i] {
+			if pending[dependent]--; pending[dependent] == 0 {
+				queue = append(queue, dependent)
+			}
+		}
+	}
+	var circular []string
+	for i, p := range pending {
+		if p > 0 {
+			circular = append(circular, w.Tasks[i].name())
+		}
+	}
+	if len(circular) > 0 {
+		return nil, fmt.Errorf("circular dependencies between workflow tasks: %s", strings.Join(circular, ", "))
+	}
+
+	return dependencies, nil
+}
+
+type workflowStateKey struct{}
+
+// WorkflowStateFromContext returns the state shared by the tasks of a workflow, or nil if not found.
+// DAGWorkflow sets a new state for each run, unless the context already has one.
+func WorkflowStateFromContext(ctx context.Context) *sync.Map {
+	state, _ := ctx.Value(workflowStateKey{}).(*sync.Map)
+	return state
+}
+
+// WorkflowStateIntoContext returns a new context with the state shared by the tasks of a workflow set.
+func WorkflowStateIntoContext(ctx context.Context, state *sync.Map) context.Context {
+	return context.WithValue(ctx, workflowStateKey{}, state)
+}
@@ -0,0 +1,147 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestDAGWorkflowOrdersDependentTasks(t *testing.T) {
+	routeKind := schema.GroupKind{Group: "gateway.networking.k8s.io", Kind: "HTTPRoute"}
+
+	var mu sync.Mutex
+	var order []string
+	task := func(name string, inputs, outputs []any) WorkflowTask {
+		return WorkflowTask{
+			Name: name,
+			Reconcile: func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) error {
+				state := WorkflowStateFromContext(ctx)
+				for _, input := range inputs {
+					if _, ok := state.Load(input); !ok {
+						t.Errorf("task %s: expected input %v to be stored", name, input)
+					}
+				}
+				for _, output := range outputs {
+					state.Store(output, name)
+				}
+				mu.Lock()
+				order = append(order, name)
+				mu.Unlock()
+				return nil
+			},
+			Inputs:  inputs,
+			Outputs: outputs,
+		}
+	}
+
+	workflow := &DAGWorkflow{
+		Tasks: []WorkflowTask{
+			task("status", []any{"effective-policies", routeKind}, nil),
+			task("routes", []any{"effective-policies"}, []any{routeKind}),
+			task("effective-policies", nil, []any{"effective-policies"}),
+		},
+	}
+	if err := workflow.Run(context.Background(), nil, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if expected := "effective-policies,routes,status"; strings.Join(order, ",") != expected {
+		t.Errorf("expected order %s, got %s", expected, strings.Join(order, ","))
+	}
+}
+
+func TestDAGWorkflowMaxParallelism(t *testing.T) {
+	var running, maxRunning int32
+	task := WorkflowTask{
+		Reconcile: func(context.Context, []ResourceEvent, *machinery.Topology) error {
+			n := atomic.AddInt32(&running, 1)
+			for {
+				m := atomic.LoadInt32(&maxRunning)
+				if n <= m || atomic.CompareAndSwapInt32(&maxRunning, m, n) {
+					break
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+			atomic.AddInt32(&running, -1)
+			return nil
+		},
+	}
+
+	workflow := &DAGWorkflow{Tasks: []WorkflowTask{task, task, task, task, task, task}, MaxParallelism: 2}
+	if err := workflow.Run(context.Background(), nil, nil); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if maxRunning != 2 {
+		t.Errorf("expected at most 2 tasks running concurrently, got %d", maxRunning)
+	}
+}
+
+func TestDAGWorkflowSkipsDependentsOfFailedTasks(t *testing.T) {
+	var ran sync.Map
+	task := func(name string, err error, inputs, outputs []any) WorkflowTask {
+		return WorkflowTask{
+			Name: name,
+			Reconcile: func(context.Context, []ResourceEvent, *machinery.Topology) error {
+				ran.Store(name, true)
+				return err
+			},
+			Inputs:  inputs,
+			Outputs: outputs,
+		}
+	}
+
+	workflow := &DAGWorkflow{
+		Tasks: []WorkflowTask{
+			task("failed", errors.New("boom"), nil, []any{"a"}),
+			task("dependent", nil, []any{"a"}, []any{"b"}),
+			task("transitive", nil, []any{"b"}, nil),
+			task("requeued", Requeue(time.Second), nil, []any{"c"}),
+			task("after-requeue", nil, []any{"c"}, nil),
+			task("independent", nil, nil, nil),
+		},
+	}
+	err := workflow.Run(context.Background(), nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "task failed: boom") {
+		t.Errorf("expected error of the failed task, got %v", err)
+	}
+	if _, found := RequeueAfterOf(err); !found {
+		t.Errorf("expected requeue error to be kept, got %v", err)
+	}
+	for _, name := range []string{"failed", "requeued", "after-requeue", "independent"} {
+		if _, ok := ran.Load(name); !ok {
+			t.Errorf("expected task %s to run", name)
+		}
+	}
+	for _, name := range []string{"dependent", "transitive"} {
+		if _, ok := ran.Load(name); ok {
+			t.Errorf("expected task %s to be skipped", name)
+		}
+	}
+}
+
+func TestDAGWorkflowCircularDependencies(t *testing.T) {
+	noop := func(context.Context, []ResourceEvent, *machinery.Topology) error { return nil }
+	workflow := &DAGWorkflow{
+		Tasks: []WorkflowTask{
+			{Name: "a", Reconcile: noop, Inputs: []any{"y"}, Outputs: []any{"x"}},
+			{Name: "b", Reconcile: noop, Inputs: []any{"x"}, Outputs: []any{"y"}},
+			{Name: "c", Reconcile: noop, Inputs: []any{"c"}, Outputs: []any{"c"}},
+		},
+	}
+	err := workflow.Validate()
+	if err == nil || err.Error() != "circular dependencies between workflow tasks: a, b" {
+		t.Errorf("expected circular dependencies error, got %v", err)
+	}
+	if err := workflow.Run(context.Background(), nil, nil); err == nil {
+		t.Error("expected run to fail")
+	}
+}
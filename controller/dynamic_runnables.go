@@ -0,0 +1,147 @@
+package controller
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// AddRunnable adds a runnable to the controller, e.g. to watch a resource whose CRD is installed after the controller
+// has started. If the controller has already started, the runnable starts right away, and the objects it watches are
+// added to the store as they come, each triggering a rebuild of the topology; otherwise it starts with the other
+// runnables.
+// AddRunnable returns without waiting for the cache of the runnable to sync, so it can be called from reconciliation
+// functions.
+func (c *Controller) AddRunnable(name string, builder RunnableBuilder) error {
+	c.runnablesMutex.Lock()
+	defer c.runnablesMutex.Unlock()
+
+	if _, exists := c.runnables[name]; exists {
+		return fmt.Errorf("%w: %s", ErrRunnableExists, name)
+	}
+	c.buildRunnable(name, builder)
+
+	if c.stopCh != nil {
+		c.logger.Info("starting runnable", "name", name)
+		c.startRunnable(name)
+	}
+	return nil
+}
+
+// RemoveRunnable stops a runnable of the controller and removes it, e.g. to stop watching a resource whose CRD was
+// uninstalled. The objects of the resources watched only by the runnable are deleted from the store, triggering a
+// rebuild of the topology.
+// Watches set up with the controller manager (see StateReconciler) cannot be stopped, but the resources are no longer
+// listed after the runnable is removed.
+func (c *Controller) RemoveRunnable(name string) error {
+	c.runnablesMutex.Lock()
+	defer c.runnablesMutex.Unlock()
+
+	if _, exists := c.runnables[name]; !exists {
+		return fmt.Errorf("%w: %s", ErrRunnableNotFound, name)
+	}
+	if stop, ok := c.runnableStops[name]; ok {
+		close(stop)
+		delete(c.runnableStops, name)
+	}
+	c.logger.Info("stopped runnable", "name", name)
+
+	resources := c.runnableResources[name]
+	delete(c.runnables, name)
+	c.syncables.Delete(name)
+	delete(c.runnableResources, name)
+	c.inventory.untrack(name)
+
+	// resources still watched by other runnables are kept
+	stillWatched := lo.Flatten(lo.Values(c.runnableResources))
+	removed := lo.Filter(resources, func(resource schema.GroupVersionResource, _ int) bool {
+		return !lo.Contains(stillWatched, resource)
+	})
+	for _, resource := range removed {
+		c.watchedResources.Delete(resource)
+	}
+	c.purge(removed)
+
+	return nil
+}
+
+// buildRunnable builds a runnable of the controller, recording the resources it watches.
+// It must be called with the runnables mutex held, except while building the controller.
+func (c *Controller) buildRunnable(name string, builder RunnableBuilder) {
+	var resources []schema.GroupVersionResource
+	c.registering = &resources
+	defer func() { c.registering = nil }()
+	c.runnables[name] = builder(c)
+	c.syncables.Store(name, c.runnables[name])
+	c.runnableResources[name] = lo.Uniq(resources)
+	c.inventory.track(name, c.runnableResources[name])
+}
+
+// startRunnable runs a runnable of the controller until either the runnable is removed or the controller stops, and
+// returns the channel that is closed when the runnable stops.
+// It must be called with the runnables mutex held.
+func (c *Controller) startRunnable(name string) <-chan struct{} {
+	stop := make(chan struct{})
+	c.runnableStops[name] = stop
+	runCh := make(chan struct{})
+	go func(stopCh <-chan struct{}) {
+		select {
+		case <-stopCh:
+		case <-stop:
+		}
+		close(runCh)
+	}(c.stopCh)
+	go c.runnables[name].Run(runCh)
+	return runCh
+}
+
+// purge deletes the objects of a list of resources from the store.
+// The kinds of the objects are mapped to resources by guessing the plural of the kinds (see
+// meta.UnsafeGuessKindToResource), as the resources are not discovered.
+func (c *Controller) purge(resources []schema.GroupVersionResource) {
+	if len(resources) == 0 {
+		return
+	}
+	groupResources := lo.Map(resources, func(resource schema.GroupVersionResource, _ int) schema.GroupResource {
+		return resource.GroupResource()
+	})
+	objs := c.cache.List().Filter(func(obj Object) bool {
+		resource, _ := meta.UnsafeGuessKindToResource(obj.GetObjectKind().GroupVersionKind())
+		return lo.Contains(groupResources, resource.GroupResource())
+	})
+	if len(objs) == 0 {
+		return
+	}
+
+	batch := &CacheBatch{}
+	for _, obj := range objs {
+		batch.Delete(obj)
+	}
+	c.cache.Commit(batch)
+
+	// watchable caches propagate the deletions to the subscription of the controller
+	if _, ok := c.cache.(*watchableCacheStore); ok {
+		return
+	}
+	go func() {
+		c.Lock()
+		defer c.Unlock()
+		c.propagate(lo.Map(objs, func(obj Object, _ int) ResourceEvent {
+			return ResourceEvent{obj.GetObjectKind().GroupVersionKind().GroupKind(), DeleteEvent, obj, nil}
+		}))
+	}()
+}
+
+// runnablesSynced returns true if the caches of all the runnables of the controller have synced, i.e. the cache of the
+// controller reflects the whole state of the world. It does not acquire the runnables mutex, so it can be called with
+// the lock held.
+func (c *Controller) runnablesSynced() bool {
+	synced := true
+	c.syncables.Range(func(_, runnable any) bool {
+		synced = runnable.(Runnable).HasSynced()
+		return synced
+	})
+	return synced
+}
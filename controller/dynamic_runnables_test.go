@@ -0,0 +1,159 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// objectsRunnable adds a list of objects to the controller when it runs
+type objectsRunnable struct {
+	controller *Controller
+	objs       []Object
+	mu         sync.Mutex
+	synced     bool
+	stopped    bool
+}
+
+func (r *objectsRunnable) Run(stopCh <-chan struct{}) {
+	for _, obj := range r.objs {
+		r.controller.add(obj)
+	}
+	r.mu.Lock()
+	r.synced = true
+	r.mu.Unlock()
+	<-stopCh
+	r.mu.Lock()
+	r.stopped = true
+	r.mu.Unlock()
+}
+
+func (r *objectsRunnable) HasSynced() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.synced
+}
+
+func (r *objectsRunnable) isStopped() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stopped
+}
+
+func waitFor(t *testing.T, condition func() bool, msg string) {
+	t.Helper()
+	for i := 0; i < 100; i++ {
+		if condition() {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatal(msg)
+}
+
+func TestAddAndRemoveRunnable(t *testing.T) {
+	configMapsResource := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "default", UID: "b4b5b2a4-4f6e-4b8a-9a0e-4a9b0f4c1d2e"},
+	}
+
+	var mu sync.Mutex
+	var events []ResourceEvent
+	c := NewController(WithReconcile(func(_ context.Context, resourceEvents []ResourceEvent, _ *machinery.Topology) {
+		mu.Lock()
+		defer mu.Unlock()
+		events = append(events, resourceEvents...)
+	}))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			t.Errorf("expected no error when starting the controller, got %s", err.Error())
+		}
+	}()
+
+	var runnable *objectsRunnable
+	builder := func(controller *Controller) Runnable {
+		controller.watching(configMapsResource)
+		runnable = &objectsRunnable{controller: controller, objs: []Object{configMap}}
+		return runnable
+	}
+
+	if err := c.AddRunnable("configmaps", builder); err != nil {
+		t.Fatalf("expected no error adding the runnable, got %v", err)
+	}
+	waitFor(t, func() bool { return len(c.cache.List()) == 1 }, "expected the objects of the runnable to be cached")
+	if _, watched := c.watchedResources.Load(configMapsResource); !watched {
+		t.Errorf("expected resource %s to be watched", configMapsResource)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return lo.ContainsBy(events, func(e ResourceEvent) bool { return e.EventType == CreateEvent })
+	}, "expected the objects of the runnable to be reconciled")
+
+	if err := c.AddRunnable("configmaps", builder); !errors.Is(err, ErrRunnableExists) {
+		t.Errorf("expected error %v, got %v", ErrRunnableExists, err)
+	}
+
+	if err := c.RemoveRunnable("configmaps"); err != nil {
+		t.Fatalf("expected no error removing the runnable, got %v", err)
+	}
+	waitFor(t, runnable.isStopped, "expected the runnable to be stopped")
+	if len(c.cache.List()) != 0 {
+		t.Errorf("expected the objects of the runnable to be deleted from the cache, got %d objects", len(c.cache.List()))
+	}
+	if _, watched := c.watchedResources.Load(configMapsResource); watched {
+		t.Errorf("expected resource %s not to be watched", configMapsResource)
+	}
+	waitFor(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+		return lo.ContainsBy(events, func(e ResourceEvent) bool { return e.EventType == DeleteEvent })
+	}, "expected the deletion of the objects of the runnable to be reconciled")
+
+	if err := c.RemoveRunnable("configmaps"); !errors.Is(err, ErrRunnableNotFound) {
+		t.Errorf("expected error %v, got %v", ErrRunnableNotFound, err)
+	}
+}
+
+func TestRemoveRunnableKeepsResourcesWatchedByOtherRunnables(t *testing.T) {
+	configMapsResource := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "default", UID: "b4b5b2a4-4f6e-4b8a-9a0e-4a9b0f4c1d2e"},
+	}
+	builder := func(controller *Controller) Runnable {
+		controller.watching(configMapsResource)
+		return &objectsRunnable{controller: controller}
+	}
+
+	c := NewController(WithRunnable("configmaps in ns1", builder), WithRunnable("configmaps in ns2", builder))
+	c.cache.Add(configMap)
+
+	if err := c.RemoveRunnable("configmaps in ns1"); err != nil {
+		t.Fatalf("expected no error removing the runnable, got %v", err)
+	}
+	if len(c.cache.List()) != 1 {
+		t.Errorf("expected the objects watched by other runnables to be kept, got %d objects", len(c.cache.List()))
+	}
+	if _, watched := c.watchedResources.Load(configMapsResource); !watched {
+		t.Errorf("expected resource %s to be watched", configMapsResource)
+	}
+	if len(c.runnables) != 1 {
+		t.Errorf("expected 1 runnable, got %d", len(c.runnables))
+	}
+}
@@ -0,0 +1,235 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+const DefaultEffectivePolicyHistoryRevisions = 10
+
+// EffectivePolicyRevision is a snapshot of an effective policy at a given point in time.
+// Revisions recorded when the effective policy was removed (see EffectivePolicyHistory.RecordRemoval) are tombstones,
+// without policy nor hash.
+type EffectivePolicyRevision struct {
+	Revision  int64
+	Timestamp time.Time
+	Hash      string
+	Policy    machinery.Policy
+}
+
+// Removed returns true if the revision is a tombstone, i.e. the effective policy was removed.
+func (r EffectivePolicyRevision) Removed() bool {
+	return r.Policy == nil
+}
+
+// EffectivePolicyChange is a change to a field of an effective policy between two revisions.
+// The path of the field is a dot-separated list of keys of the JSON representation of the policy.
+// Old is nil for added fields; New is nil for removed fields.
+type EffectivePolicyChange struct {
+	Path string
+	Old  any
+	New  any
+}
+
+// EffectivePolicyHistory keeps the last N revisions of the effective policies, indexed by a key.
+// The key is typically the URL of the targetable or an identifier of the topology path to which the effective
+// policy applies.
+type EffectivePolicyHistory struct {
+	mu           sync.RWMutex
+	maxRevisions int
+	revisions    map[string][]EffectivePolicyRevision
+}
+
+// NewEffectivePolicyHistory returns a history that retains up to maxRevisions effective policies per key.
+// If maxRevisions is less than 1, DefaultEffectivePolicyHistoryRevisions is used.
+func NewEffectivePolicyHistory(maxRevisions int) *EffectivePolicyHistory {
+	if maxRevisions < 1 {
+		maxRevisions = DefaultEffectivePolicyHistoryRevisions
+	}
+	return &EffectivePolicyHistory{
+		maxRevisions: maxRevisions,
+		revisions:    make(map[string][]EffectivePolicyRevision),
+	}
+}
+
+// Record adds a new revision of the effective policy for a given key, unless the policy is identical to the latest
+// revision recorded for the key. It returns true if a new revision was recorded.
+func (h *EffectivePolicyHistory) Record(key string, policy machinery.Policy) (bool, error) {
+	hash, err := HashEffectivePolicy(policy)
+	if err != nil {
+		return false, err
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	revisions := h.revisions[key]
+	if n := len(revisions); n > 0 && !revisions[n-1].Removed() && revisions[n-1].Hash == hash {
+		return false, nil
+	}
+	h.append(key, EffectivePolicyRevision{Hash: hash, Policy: policy})
+	return true, nil
+}
+
+// RecordRemoval adds a tombstone revision for a given key, recording that the effective policy was removed, e.g.
+// because the policies that made it up were deleted while the topology path to which it applied still exists.
+// Nothing is recorded for keys without revisions, or whose latest revision is a tombstone already. It returns true if
+// a tombstone was recorded.
+func (h *EffectivePolicyHistory) RecordRemoval(key string) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	revisions := h.revisions[key]
+	if n := len(revisions); n == 0 || revisions[n-1].Removed() {
+		return false
+	}
+	h.append(key, EffectivePolicyRevision{})
+	return true
+}
+
+// append adds a revision for a key, numbered after the latest one, dropping the oldest revisions beyond the maximum
+func (h *EffectivePolicyHistory) append(key string, revision EffectivePolicyRevision) {
+	revisions := h.revisions[key]
+	revision.Revision = 1
+	if n := len(revisions); n > 0 {
+		revision.Revision = revisions[n-1].Revision + 1
+	}
+	revision.Timestamp = time.Now()
+
+	revisions = append(revisions, revision)
+	if len(revisions) > h.maxRevisions {
+		revisions = revisions[len(revisions)-h.maxRevisions:]
+	}
+	h.revisions[key] = revisions
+}
+
+// Forget deletes all revisions recorded for a given key, e.g. once the topology path to which the effective policy
+// applied no longer exists.
+func (h *EffectivePolicyHistory) Forget(key string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	delete(h.revisions, key)
+}
+
+// Keys returns the sorted list of keys with recorded revisions.
+func (h *EffectivePolicyHistory) Keys() []string {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	keys := make([]string, 0, len(h.revisions))
+	for key := range h.revisions {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// Revisions returns the retained revisions for a given key, sorted from the oldest to the latest.
+func (h *EffectivePolicyHistory) Revisions(key string) []EffectivePolicyRevision {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	return append([]EffectivePolicyRevision{}, h.revisions[key]...)
+}
+
+// Diff returns the changes to the effective policy for a given key between two retained revisions.
+// All the fields of the effective policy are reported as removed in the diff to a tombstone revision.
+func (h *EffectivePolicyHistory) Diff(key string, fromRevision, toRevision int64) ([]EffectivePolicyChange, error) {
+	revisions := h.Revisions(key)
+	from, err := findRevision(revisions, fromRevision)
+	if err != nil {
+		return nil, err
+	}
+	to, err := findRevision(revisions, toRevision)
+	if err != nil {
+		return nil, err
+	}
+	return DiffEffectivePolicies(from.Policy, to.Policy)
+}
+
+// DiffEffectivePolicies returns the changes between the JSON representations of two effective policies.
+// The changes are sorted by path. Policies that implement machinery.NormalizablePolicy are normalized before comparing.
+func DiffEffectivePolicies(from, to machinery.Policy) ([]EffectivePolicyChange, error) {
+	from, to = machinery.NormalizePolicy(from), machinery.NormalizePolicy(to)
+	fromFields, err := flattenJSON(from)
+	if err != nil {
+		return nil, err
+	}
+	toFields, err := flattenJSON(to)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []EffectivePolicyChange
+	for path, oldValue := range fromFields {
+		newValue, ok := toFields[path]
+		if !ok {
+			changes = append(changes, EffectivePolicyChange{Path: path, Old: oldValue})
+			continue
+		}
+		if !reflect.DeepEqual(oldValue, newValue) {
+			changes = append(changes, EffectivePolicyChange{Path: path, Old: oldValue, New: newValue})
+		}
+	}
+	for path, newValue := range toFields {
+		if _, ok := fromFields[path]; !ok {
+			changes = append(changes, EffectivePolicyChange{Path: path, New: newValue})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].Path < changes[j].Path
+	})
+	return changes, nil
+}
+
+func findRevision(revisions []EffectivePolicyRevision, revision int64) (*EffectivePolicyRevision, error) {
+	for i := range revisions {
+		if revisions[i].Revision == revision {
+			return &revisions[i], nil
+		}
+	}
+	return nil, fmt.Errorf("revision %d not found", revision)
+}
+
+// flattenJSON returns the leaf values of the JSON representation of an object, indexed by dot-separated paths.
+// Lists are treated as leaf values.
+func flattenJSON(obj any) (map[string]any, error) {
+	fields := make(map[string]any)
+	if obj == nil {
+		return fields, nil
+	}
+	if v := reflect.ValueOf(obj); v.Kind() == reflect.Pointer && v.IsNil() {
+		return fields, nil
+	}
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]any
+	if err := json.Unmarshal(b, &m); err != nil {
+		return nil, err
+	}
+	flatten("", m, fields)
+	return fields, nil
+}
+
+func flatten(prefix string, value any, fields map[string]any) {
+	m, ok := value.(map[string]any)
+	if !ok {
+		fields[prefix] = value
+		return
+	}
+	for key, v := range m {
+		path := key
+		if prefix != "" {
+			path = strings.Join([]string{prefix, key}, ".")
+		}
+		flatten(path, v, fields)
+	}
+}
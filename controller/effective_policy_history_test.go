@@ -0,0 +1,89 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestEffectivePolicyHistory(t *testing.T) {
+	policyTargeting := func(name string) *machinery.TestPolicy {
+		return &machinery.TestPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace"},
+			Spec: machinery.TestPolicySpec{
+				TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+					LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Kind: "Gateway", Name: gwapiv1alpha2.ObjectName(name)},
+				},
+			},
+		}
+	}
+
+	history := NewEffectivePolicyHistory(2)
+	key := "gateway.gateway.networking.k8s.io:my-namespace/my-gateway"
+
+	if recorded, err := history.Record(key, policyTargeting("gw-1")); err != nil || !recorded {
+		t.Errorf("expected revision 1 to be recorded, got %v", err)
+	}
+	if recorded, _ := history.Record(key, policyTargeting("gw-1")); recorded {
+		t.Error("expected identical policy not to be recorded")
+	}
+	history.Record(key, policyTargeting("gw-2"))
+	history.Record(key, policyTargeting("gw-3"))
+
+	revisions := history.Revisions(key)
+	if len(revisions) != 2 {
+		t.Fatalf("expected 2 retained revisions, got %d", len(revisions))
+	}
+	if revisions[0].Revision != 2 || revisions[1].Revision != 3 {
+		t.Errorf("expected revisions 2 and 3, got %d and %d", revisions[0].Revision, revisions[1].Revision)
+	}
+
+	if _, err := history.Diff(key, 1, 3); err == nil {
+		t.Error("expected error diffing a revision no longer retained")
+	}
+	changes, err := history.Diff(key, 2, 3)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "spec.targetRef.name" || changes[0].Old != "gw-2" || changes[0].New != "gw-3" {
+		t.Errorf("expected change to spec.targetRef.name from gw-2 to gw-3, got %+v", changes)
+	}
+
+	if !history.RecordRemoval(key) {
+		t.Error("expected the removal of the effective policy to be recorded")
+	}
+	if history.RecordRemoval(key) {
+		t.Error("expected the removal not to be recorded twice")
+	}
+	revisions = history.Revisions(key)
+	if latest := revisions[len(revisions)-1]; latest.Revision != 4 || !latest.Removed() {
+		t.Errorf("expected revision 4 to be a tombstone, got %+v", latest)
+	}
+	changes, err = history.Diff(key, 3, 4)
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if !lo.ContainsBy(changes, func(change EffectivePolicyChange) bool {
+		return change.Path == "spec.targetRef.name" && change.Old == "gw-3" && change.New == nil
+	}) {
+		t.Errorf("expected spec.targetRef.name to be removed, got %+v", changes)
+	}
+	if recorded, _ := history.Record(key, policyTargeting("gw-3")); !recorded {
+		t.Error("expected the effective policy to be recorded again after its removal")
+	}
+
+	if history.RecordRemoval("unknown") {
+		t.Error("expected no tombstone for a key without revisions")
+	}
+
+	history.Forget(key)
+	if len(history.Keys()) != 0 {
+		t.Errorf("expected no keys after forgetting, got %v", history.Keys())
+	}
+}
@@ -0,0 +1,129 @@
+package controller
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Envoy Gateway
+var (
+	EnvoyGatewayGroupVersion = schema.GroupVersion{Group: "gateway.envoyproxy.io", Version: "v1alpha1"}
+
+	EnvoyPatchPolicyKind     = EnvoyGatewayGroupVersion.WithKind("EnvoyPatchPolicy").GroupKind()
+	ClientTrafficPolicyKind  = EnvoyGatewayGroupVersion.WithKind("ClientTrafficPolicy").GroupKind()
+	BackendTrafficPolicyKind = EnvoyGatewayGroupVersion.WithKind("BackendTrafficPolicy").GroupKind()
+	SecurityPolicyKind       = EnvoyGatewayGroupVersion.WithKind("SecurityPolicy").GroupKind()
+
+	EnvoyPatchPoliciesResource     = EnvoyGatewayGroupVersion.WithResource("envoypatchpolicies")
+	ClientTrafficPoliciesResource  = EnvoyGatewayGroupVersion.WithResource("clienttrafficpolicies")
+	BackendTrafficPoliciesResource = EnvoyGatewayGroupVersion.WithResource("backendtrafficpolicies")
+	SecurityPoliciesResource       = EnvoyGatewayGroupVersion.WithResource("securitypolicies")
+
+	// EnvoyGatewayPolicyKinds are the kinds of Envoy Gateway policies that can be wrapped as EnvoyGatewayPolicy
+	EnvoyGatewayPolicyKinds = []schema.GroupKind{EnvoyPatchPolicyKind, ClientTrafficPolicyKind, BackendTrafficPolicyKind, SecurityPolicyKind}
+)
+
+// EnvoyGatewayPolicy is an Envoy Gateway EnvoyPatchPolicy, ClientTrafficPolicy, BackendTrafficPolicy or SecurityPolicy
+// that is a node of the topology.
+// The policy is kept as the cluster runtime object it was read from, typed or unstructured, so the Envoy Gateway API
+// is not a dependency of this package. Its target references are read from `spec.targetRef` and `spec.targetRefs`.
+type EnvoyGatewayPolicy struct {
+	*RuntimeObject
+
+	TargetRefs []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
+}
+
+var _ machinery.Object = &EnvoyGatewayPolicy{}
+
+// WrapEnvoyGatewayPolicy wraps the Envoy Gateway policies into EnvoyGatewayPolicy objects.
+// See WithObjectWrappers.
+func WrapEnvoyGatewayPolicy(obj Object) (machinery.Object, bool) {
+	if !lo.Contains(EnvoyGatewayPolicyKinds, obj.GetObjectKind().GroupVersionKind().GroupKind()) {
+		return nil, false
+	}
+	targetRefs, err := envoyGatewayPolicyTargetRefs(obj)
+	if err != nil {
+		return nil, false
+	}
+	return &EnvoyGatewayPolicy{RuntimeObject: &RuntimeObject{obj}, TargetRefs: targetRefs}, true
+}
+
+var _ ObjectWrapperFunc = WrapEnvoyGatewayPolicy
+
+func envoyGatewayPolicyTargetRefs(obj Object) ([]gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName, error) {
+	var u map[string]interface{}
+	if o, ok := obj.(*unstructured.Unstructured); ok {
+		u = o.Object
+	} else {
+		var err error
+		if u, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, err
+		}
+	}
+	spec, _, err := unstructured.NestedMap(u, "spec")
+	if err != nil {
+		return nil, err
+	}
+	var targets struct {
+		TargetRef  *gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName  `json:"targetRef,omitempty"`
+		TargetRefs []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName `json:"targetRefs,omitempty"`
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(spec, &targets); err != nil {
+		return nil, err
+	}
+	if targets.TargetRef != nil {
+		return append([]gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{*targets.TargetRef}, targets.TargetRefs...), nil
+	}
+	return targets.TargetRefs, nil
+}
+
+// LinkEnvoyGatewayPolicyFunc links the objects of a target kind to the Envoy Gateway policies of a kind that target
+// them by PolicyTargetReference. Section names in the target references are disregarded, i.e. a policy that targets
+// a section of an object is linked to the object itself.
+// The policies must be wrapped as EnvoyGatewayPolicy objects (see WrapEnvoyGatewayPolicy).
+func LinkEnvoyGatewayPolicyFunc(targetKind, policyKind schema.GroupKind) LinkFunc {
+	return func(_ Store) machinery.LinkFunc {
+		return machinery.LinkFunc{
+			From:         targetKind,
+			To:           policyKind,
+			Relationship: machinery.EdgeRelationshipPolicyTargetRef,
+			Func: func(child machinery.Object) []machinery.Object {
+				policy, ok := child.(*EnvoyGatewayPolicy)
+				if !ok {
+					return nil
+				}
+				refs := lo.FilterMap(policy.TargetRefs, func(ref gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName, _ int) (machinery.Object, bool) {
+					if string(ref.Group) != targetKind.Group || string(ref.Kind) != targetKind.Kind {
+						return nil, false
+					}
+					ref.SectionName = nil
+					return machinery.ToPolicyTargetReference(policy.GetNamespace(), ref), true
+				})
+				return lo.UniqBy(refs, func(ref machinery.Object) string { return ref.GetURL() })
+			},
+		}
+	}
+}
+
+// WithEnvoyGatewayPolicies adds the Envoy Gateway policies as objects of the topology, wrapped as EnvoyGatewayPolicy
+// objects and linked to the objects of the target kinds they target. Defaults to linking to Gateways and HTTPRoutes.
+// The policies still need to be watched, e.g. with WithRunnable or WithConditionalRunnable.
+func WithEnvoyGatewayPolicies(targetKinds ...schema.GroupKind) ControllerOption {
+	if len(targetKinds) == 0 {
+		targetKinds = []schema.GroupKind{GatewayKind, HTTPRouteKind}
+	}
+	return func(o *ControllerOptions) {
+		o.objectKinds = append(o.objectKinds, EnvoyGatewayPolicyKinds...)
+		o.objectWrappers = append(o.objectWrappers, WrapEnvoyGatewayPolicy)
+		for _, targetKind := range targetKinds {
+			for _, policyKind := range EnvoyGatewayPolicyKinds {
+				o.objectLinks = append(o.objectLinks, LinkEnvoyGatewayPolicyFunc(targetKind, policyKind))
+			}
+		}
+	}
+}
@@ -0,0 +1,102 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func envoyGatewayPolicy(kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": EnvoyGatewayGroupVersion.String(),
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "my-namespace",
+			"uid":       name,
+		},
+		"spec": spec,
+	}}
+}
+
+func TestWrapEnvoyGatewayPolicy(t *testing.T) {
+	policy := envoyGatewayPolicy(SecurityPolicyKind.Kind, "my-policy", map[string]interface{}{
+		"targetRef": map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "my-gateway"},
+		"targetRefs": []interface{}{
+			map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "HTTPRoute", "name": "my-route", "sectionName": "rule-1"},
+		},
+	})
+
+	obj, ok := WrapEnvoyGatewayPolicy(policy)
+	if !ok {
+		t.Fatal("expected the policy to be wrapped")
+	}
+	wrapped, ok := obj.(*EnvoyGatewayPolicy)
+	if !ok {
+		t.Fatalf("expected an EnvoyGatewayPolicy, got %T", obj)
+	}
+	if len(wrapped.TargetRefs) != 2 {
+		t.Fatalf("expected 2 target refs, got %d", len(wrapped.TargetRefs))
+	}
+	if wrapped.TargetRefs[0].Name != "my-gateway" || wrapped.TargetRefs[1].Name != "my-route" {
+		t.Errorf("unexpected target refs: %v", wrapped.TargetRefs)
+	}
+	if wrapped.GetURL() != "securitypolicy.gateway.envoyproxy.io:my-namespace/my-policy" {
+		t.Errorf("unexpected url: %s", wrapped.GetURL())
+	}
+
+	if _, ok := WrapEnvoyGatewayPolicy(&unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}); ok {
+		t.Error("expected a ConfigMap not to be wrapped")
+	}
+}
+
+func TestWithEnvoyGatewayPolicies(t *testing.T) {
+	gateway := machinery.BuildGateway()
+	route := machinery.BuildHTTPRoute()
+	securityPolicy := envoyGatewayPolicy(SecurityPolicyKind.Kind, "security", map[string]interface{}{
+		"targetRefs": []interface{}{
+			map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "HTTPRoute", "name": route.Name, "sectionName": "rule-1"},
+			map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "HTTPRoute", "name": route.Name, "sectionName": "rule-2"},
+		},
+	})
+	clientTrafficPolicy := envoyGatewayPolicy(ClientTrafficPolicyKind.Kind, "client-traffic", map[string]interface{}{
+		"targetRef": map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": gateway.Name},
+	})
+	backendTrafficPolicy := envoyGatewayPolicy(BackendTrafficPolicyKind.Kind, "backend-traffic", map[string]interface{}{
+		"targetRefs": []interface{}{
+			map[string]interface{}{"group": "gateway.networking.k8s.io", "kind": "Gateway", "name": "other-gateway"},
+		},
+	})
+	store := Store{
+		"gateway":              gateway,
+		"route":                route,
+		"securityPolicy":       securityPolicy,
+		"clientTrafficPolicy":  clientTrafficPolicy,
+		"backendTrafficPolicy": backendTrafficPolicy,
+	}
+
+	topology := NewController(WithEnvoyGatewayPolicies()).topology.Build(store)
+
+	childrenOf := func(url string) []string {
+		targetable, ok := lo.Find(topology.Targetables().Items(), func(t machinery.Targetable) bool { return t.GetURL() == url })
+		if !ok {
+			t.Fatalf("targetable %s not found", url)
+		}
+		return lo.Map(topology.Objects().Children(targetable), func(o machinery.Object, _ int) string { return o.GetName() })
+	}
+
+	if children := childrenOf("gateway.gateway.networking.k8s.io:my-namespace/" + gateway.Name); len(children) != 1 || children[0] != "client-traffic" {
+		t.Errorf("expected the gateway to be linked to the client-traffic policy, got %v", children)
+	}
+	if children := childrenOf("httproute.gateway.networking.k8s.io:my-namespace/" + route.Name); len(children) != 1 || children[0] != "security" {
+		t.Errorf("expected the route to be linked to the security policy, got %v", children)
+	}
+	if objects := topology.Objects().Items(); len(objects) != 3 {
+		t.Errorf("expected 3 objects, got %d", len(objects))
+	}
+}
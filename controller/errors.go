@@ -0,0 +1,38 @@
+package controller
+
+import (
+	"errors"
+	"fmt"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+)
+
+var (
+	// ErrConflict is returned when a write to the API server conflicts with the current state of the resource,
+	// e.g. because the resource was modified concurrently or already exists.
+	ErrConflict = errors.New("conflict")
+	// ErrNotSynced is returned when the cache of a watched resource fails to sync.
+	ErrNotSynced = errors.New("cache not synced")
+	// ErrConversion is returned when an object cannot be converted between its typed and unstructured forms.
+	ErrConversion = errors.New("conversion failed")
+	// ErrRunnableExists is returned when adding a runnable with the name of another runnable of the controller.
+	ErrRunnableExists = errors.New("runnable already exists")
+	// ErrRunnableNotFound is returned when removing a runnable that the controller does not have.
+	ErrRunnableNotFound = errors.New("runnable not found")
+	// ErrInvalidManagementState is returned when setting a management state other than the ones defined.
+	ErrInvalidManagementState = errors.New("invalid management state")
+	// ErrIndexNotFound is returned when looking up objects by an index that is not registered (see WithIndex).
+	ErrIndexNotFound = errors.New("index not found")
+)
+
+// wrapAPIError wraps the errors returned by the API server with the corresponding error kinds of the library.
+func wrapAPIError(err error) error {
+	if err != nil && (apierrors.IsConflict(err) || apierrors.IsAlreadyExists(err)) {
+		return fmt.Errorf("%w: %w", ErrConflict, err)
+	}
+	return err
+}
+
+func conversionError(err error) error {
+	return fmt.Errorf("%w: %w", ErrConversion, err)
+}
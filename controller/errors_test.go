@@ -0,0 +1,50 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestErrorKinds(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+
+	if _, err := Restructure[corev1.ConfigMap](&corev1.ConfigMap{}); !errors.Is(err, ErrConversion) {
+		t.Errorf("expected conversion error, got %v", err)
+	}
+
+	conflict := wrapAPIError(apierrors.NewConflict(resource.GroupResource(), "my-configmap", errors.New("modified")))
+	if !errors.Is(conflict, ErrConflict) {
+		t.Errorf("expected conflict error, got %v", conflict)
+	}
+	if !apierrors.IsConflict(conflict) {
+		t.Errorf("expected wrapped error to preserve the api error, got %v", conflict)
+	}
+	if err := wrapAPIError(apierrors.NewNotFound(resource.GroupResource(), "my-configmap")); errors.Is(err, ErrConflict) {
+		t.Errorf("expected not found error not to be a conflict, got %v", err)
+	}
+	if err := wrapAPIError(nil); err != nil {
+		t.Errorf("expected nil error, got %v", err)
+	}
+
+	// creating an object that already exists
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("ConfigMap")
+	obj.SetNamespace("my-namespace")
+	obj.SetName("my-configmap")
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{resource: "ConfigMapList"}, obj)
+	plan := &RestorePlan{Creates: []ResourceChange{{Resource: resource, Object: obj.DeepCopy()}}}
+	if err := plan.Apply(context.TODO(), client); !errors.Is(err, ErrConflict) {
+		t.Errorf("expected conflict error, got %v", err)
+	}
+}
@@ -0,0 +1,146 @@
+package controller
+
+import (
+	"fmt"
+	"time"
+)
+
+// WithEventBatching coalesces the events that arrive in a burst, e.g. on the initial sync of the caches or on a mass
+// apply of resources, into a single reconciliation of the batch of events, instead of building the topology and
+// reconciling once per event.
+// The batch is reconciled when no event arrives for the duration of the debounce window, as soon as it reaches a
+// maximum number of events, or at the latest after a maximum latency since the first event of the batch, so a steady
+// stream of events does not postpone the reconciliation forever. A maximum size of 0 or less leaves the size of the
+// batches unbounded. The maximum latency defaults to DefaultEventBatchMaxLatencyWindows times the debounce window
+// (see WithEventBatchMaxLatency).
+// Events of the same object within a batch are coalesced into one (see CoalesceEvents).
+func WithEventBatching(window time.Duration, maxSize int) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.eventBatching = &eventBatch{window: window, maxSize: maxSize, maxLatency: DefaultEventBatchMaxLatencyWindows * window}
+	}
+}
+
+// DefaultEventBatchMaxLatencyWindows is the default maximum latency of the batches of events, in debounce windows.
+const DefaultEventBatchMaxLatencyWindows = 10
+
+// WithEventBatchMaxLatency sets the maximum time a batch of events waits to be reconciled since its first event
+// (see WithEventBatching). It has no effect without event batching.
+func WithEventBatchMaxLatency(maxLatency time.Duration) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.eventBatchMaxLatency = maxLatency
+	}
+}
+
+// CoalesceEvents merges the events of the same object, in order, into a single event that goes from the state of the
+// object before the first event to the state after the last one, e.g. a creation followed by updates of an object is a
+// creation of the last version of the object, and a creation followed by a deletion is no event at all.
+// The coalesced events keep the order of the first event of each object.
+func CoalesceEvents(resourceEvents []ResourceEvent) []ResourceEvent {
+	var coalesced []*ResourceEvent
+	byObject := map[string]*ResourceEvent{}
+	for _, event := range resourceEvents {
+		key := eventObjectKey(event)
+		previous, ok := byObject[key]
+		if !ok {
+			event := event
+			byObject[key] = &event
+			coalesced = append(coalesced, &event)
+			continue
+		}
+		merged, ok := mergeEvents(*previous, event)
+		if !ok {
+			delete(byObject, key)
+			*previous = ResourceEvent{} // dropped
+			continue
+		}
+		*previous = merged
+	}
+
+	var events []ResourceEvent
+	for _, event := range coalesced {
+		if event.OldObject == nil && event.NewObject == nil {
+			continue
+		}
+		events = append(events, *event)
+	}
+	return events
+}
+
+func eventObjectKey(event ResourceEvent) string {
+	obj := event.NewObject
+	if obj == nil {
+		obj = event.OldObject
+	}
+	if obj == nil {
+		return ""
+	}
+	if uid := obj.GetUID(); uid != "" {
+		return fmt.Sprintf("%s#%s", event.Kind, uid)
+	}
+	return fmt.Sprintf("%s#%s", event.Kind, namespacedName(obj))
+}
+
+// mergeEvents returns the event equivalent to two consecutive events of the same object, or false if the events
+// cancel each other out.
+func mergeEvents(first, next ResourceEvent) (ResourceEvent, bool) {
+	switch {
+	case first.EventType == CreateEvent && next.EventType == DeleteEvent:
+		return ResourceEvent{}, false
+	case first.EventType == CreateEvent:
+		return ResourceEvent{Kind: next.Kind, EventType: CreateEvent, NewObject: next.NewObject}, true
+	case first.EventType == DeleteEvent && next.EventType == CreateEvent:
+		return ResourceEvent{Kind: next.Kind, EventType: UpdateEvent, OldObject: first.OldObject, NewObject: next.NewObject}, true
+	case first.EventType == UpdateEvent && next.EventType != CreateEvent:
+		return ResourceEvent{Kind: next.Kind, EventType: next.EventType, OldObject: first.OldObject, NewObject: next.NewObject}, true
+	}
+	return next, true
+}
+
+// eventBatch accumulates the events to be reconciled at once (see WithEventBatching).
+type eventBatch struct {
+	window     time.Duration
+	maxSize    int
+	maxLatency time.Duration
+	events     []ResourceEvent
+	started    time.Time
+	timer      *time.Timer
+}
+
+// add adds events to the batch and (re)starts the debounce window, at the end of which the batch is flushed. The
+// window is shortened so the batch is flushed no later than the maximum latency since its first event.
+// It returns true if the batch reached its maximum size or latency and must be reconciled right away.
+// It must be called with the lock of the controller held.
+func (b *eventBatch) add(resourceEvents []ResourceEvent, flush func()) bool {
+	if len(b.events) == 0 {
+		b.started = time.Now()
+	}
+	b.events = append(b.events, resourceEvents...)
+	if b.maxSize > 0 && len(b.events) >= b.maxSize {
+		return true
+	}
+	delay := b.window
+	if b.maxLatency > 0 {
+		remaining := b.maxLatency - time.Since(b.started)
+		if remaining <= 0 {
+			return true
+		}
+		delay = min(delay, remaining)
+	}
+	if b.timer != nil {
+		b.timer.Stop()
+	}
+	b.timer = time.AfterFunc(delay, flush)
+	return false
+}
+
+// take returns the coalesced events of the batch and clears it. It must be called with the lock of the controller
+// held.
+func (b *eventBatch) take() []ResourceEvent {
+	if b.timer != nil {
+		b.timer.Stop()
+		b.timer = nil
+	}
+	events := CoalesceEvents(b.events)
+	b.events = nil
+	return events
+}
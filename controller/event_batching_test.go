@@ -0,0 +1,276 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func batchedConfigMap(name string, generation int64) *corev1.ConfigMap {
+	cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name), Generation: generation}}
+	cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	return cm
+}
+
+func TestCoalesceEvents(t *testing.T) {
+	event := func(eventType EventType, oldObj, newObj Object) ResourceEvent {
+		return ResourceEvent{Kind: ConfigMapKind, EventType: eventType, OldObject: oldObj, NewObject: newObj}
+	}
+	a1, a2, a3 := batchedConfigMap("a", 1), batchedConfigMap("a", 2), batchedConfigMap("a", 3)
+	b1, b2 := batchedConfigMap("b", 1), batchedConfigMap("b", 2)
+	c1 := batchedConfigMap("c", 1)
+	d1, d2 := batchedConfigMap("d", 1), batchedConfigMap("d", 2)
+	e1, e2 := batchedConfigMap("e", 1), batchedConfigMap("e", 2)
+
+	events := CoalesceEvents([]ResourceEvent{
+		event(CreateEvent, nil, a1),
+		event(UpdateEvent, b1, b2),
+		event(UpdateEvent, a1, a2),
+		event(CreateEvent, nil, c1),
+		event(DeleteEvent, d1, nil),
+		event(UpdateEvent, a2, a3),
+		event(DeleteEvent, c1, nil),
+		event(CreateEvent, nil, d2),
+		event(UpdateEvent, e1, e2),
+		event(DeleteEvent, e2, nil),
+	})
+
+	expected := []ResourceEvent{
+		event(CreateEvent, nil, a3),
+		event(UpdateEvent, b1, b2),
+		event(UpdateEvent, d1, d2),
+		event(DeleteEvent, e1, nil),
+	}
+	if len(events) != len(expected) {
+		t.Fatalf("expected %d events, got %d: %v", len(expected), len(events), events)
+	}
+	for i := range expected {
+		if events[i].EventType != expected[i].EventType || events[i].OldObject != expected[i].OldObject || events[i].NewObject != expected[i].NewObject {
+			t.Errorf("expected event %d to be %v, got %v", i, expected[i], events[i])
+		}
+	}
+}
+
+func TestControllerEventBatching(t *testing.T) {
+	var mu sync.Mutex
+	var reconciliations [][]ResourceEvent
+	reconciled := func() [][]ResourceEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return reconciliations
+	}
+	c := NewController(
+		WithEventBatching(50*time.Millisecond, 3),
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			reconciliations = append(reconciliations, events)
+		}),
+	)
+
+	// reconciled at the end of the debounce window
+	c.add(batchedConfigMap("a", 1))
+	c.update(batchedConfigMap("a", 1), batchedConfigMap("a", 2))
+	if len(reconciled()) != 0 {
+		t.Fatalf("expected no reconciliation within the debounce window, got %v", reconciled())
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for len(reconciled()) == 0 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if r := reconciled(); len(r) != 1 || len(r[0]) != 1 || r[0][0].EventType != CreateEvent || r[0][0].NewObject.GetGeneration() != 2 {
+		t.Fatalf("expected one reconciliation of the coalesced creation, got %v", r)
+	}
+
+	// reconciled right away on reaching the maximum size
+	c.add(batchedConfigMap("b", 1))
+	c.add(batchedConfigMap("c", 1))
+	c.add(batchedConfigMap("d", 1))
+	if r := reconciled(); len(r) != 2 || len(r[1]) != 3 {
+		t.Fatalf("expected a reconciliation of the full batch, got %v", r)
+	}
+
+	// events that cancel each other out are not reconciled
+	c.add(batchedConfigMap("e", 1))
+	c.delete(batchedConfigMap("e", 1))
+	time.Sleep(200 * time.Millisecond)
+	if r := reconciled(); len(r) != 2 {
+		t.Errorf("expected no reconciliation of a creation and deletion, got %v", r)
+	}
+}
+
+func TestControllerEventBatchingUpdateAndDeletion(t *testing.T) {
+	var mu sync.Mutex
+	var reconciled []ResourceEvent
+	c := NewController(
+		WithEventBatching(200*time.Millisecond, 0),
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			reconciled = append(reconciled, events...)
+		}),
+	)
+	c.subscribe()
+
+	// events reported by the subscription to the cache only
+	c.cache.Add(batchedConfigMap("a", 1))
+	time.Sleep(500 * time.Millisecond)
+	mu.Lock()
+	reconciled = nil
+	mu.Unlock()
+
+	// the object existed before the batch, thus its update and deletion do not cancel each other out
+	c.cache.Add(batchedConfigMap("a", 2))
+	time.Sleep(20 * time.Millisecond)
+	c.cache.Delete(batchedConfigMap("a", 2))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		deleted := lo.ContainsBy(reconciled, func(event ResourceEvent) bool { return event.EventType == DeleteEvent })
+		mu.Unlock()
+		if deleted {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("expected a reconciliation of the deletion of the object")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestControllerCommitsInformerBursts(t *testing.T) {
+	c := NewController()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	commits := c.cache.(*watchableCacheStore).subscribeCommits(ctx)
+
+	// events received by the informers while the controller holds the lock
+	c.Lock()
+	var wg sync.WaitGroup
+	for _, name := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.add(batchedConfigMap(name, 1))
+		}()
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		c.writes.Lock()
+		pending := c.writes.batch.Len()
+		c.writes.Unlock()
+		if pending == 3 {
+			break
+		}
+		if time.Now().After(deadline) {
+			c.Unlock()
+			t.Fatalf("expected 3 pending writes, got %d", pending)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Unlock()
+	wg.Wait()
+
+	select {
+	case commit := <-commits:
+		if len(commit.Updates) != 3 {
+			t.Errorf("expected the burst committed as one batch, got %v", commit.Updates)
+		}
+		if !commit.Propagated {
+			t.Error("expected the commit of the burst marked as propagated by the informers")
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected a commit of the burst")
+	}
+	if n := len(c.cache.List()); n != 3 {
+		t.Errorf("expected 3 objects in the cache, got %d", n)
+	}
+}
+
+func TestControllerPropagatesInformerEventsOnce(t *testing.T) {
+	var mu sync.Mutex
+	var reconciliations [][]ResourceEvent
+	c := NewController(
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			reconciliations = append(reconciliations, events)
+		}),
+		WithStatusFeedback(StatusFeedback{
+			GeneratedKind: corev1.SchemeGroupVersion.WithKind("ConfigMap").GroupKind(),
+			Owners:        func(machinery.Object, *machinery.Topology) []machinery.Policy { return nil },
+			Dependency:    func(machinery.Object) Dependency { return Dependency{} },
+		}),
+	)
+	c.subscribe()
+
+	c.add(batchedConfigMap("a", 1))
+	// status-only changes of generated resources are fed back without reconciliation
+	updated := batchedConfigMap("a", 1)
+	updated.SetResourceVersion("2")
+	c.update(batchedConfigMap("a", 1), updated)
+
+	// the commits are published in order, thus once the object written to the cache by another writer is reconciled,
+	// the commit of the informer would have been reconciled again by the subscription too
+	c.cache.Add(batchedConfigMap("b", 1))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(reconciliations)
+		mu.Unlock()
+		if n >= 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the object written to the cache reconciled, got %d reconciliations", n)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(reconciliations) != 2 {
+		t.Fatalf("expected 2 reconciliations, got %v", reconciliations)
+	}
+	if events := reconciliations[0]; len(events) != 1 || events[0].EventType != CreateEvent || events[0].NewObject.GetName() != "a" {
+		t.Errorf("expected exactly one reconciliation of the events of the informer, got %v", reconciliations)
+	}
+	if events := reconciliations[1]; len(events) != 1 || events[0].NewObject.GetName() != "b" {
+		t.Errorf("expected a reconciliation of the object written to the cache, got %v", events)
+	}
+}
+
+func TestEventBatchMaxLatency(t *testing.T) {
+	batch := &eventBatch{window: 50 * time.Millisecond, maxLatency: 120 * time.Millisecond}
+	flushed := make(chan time.Time, 1)
+	flush := func() { flushed <- time.Now() }
+
+	// a steady stream of events, each within the debounce window of the previous one
+	start := time.Now()
+	for i := 0; i < 10; i++ {
+		if batch.add([]ResourceEvent{{EventType: CreateEvent, NewObject: batchedConfigMap("a", int64(i))}}, flush) {
+			break
+		}
+		select {
+		case at := <-flushed:
+			if latency := at.Sub(start); latency > 200*time.Millisecond {
+				t.Errorf("expected the batch flushed within its maximum latency, got %s", latency)
+			}
+			return
+		case <-time.After(30 * time.Millisecond):
+		}
+	}
+	if latency := time.Since(start); latency > 200*time.Millisecond {
+		t.Errorf("expected the batch flushed within its maximum latency, got %s", latency)
+	}
+}
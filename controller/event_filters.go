@@ -0,0 +1,63 @@
+package controller
+
+import (
+	"reflect"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// GenerationChangedPredicate is an event filter that drops the updates that do not change the generation of the
+// objects (see GenerationChanged). Kinds whose generation is not incremented by the API server (e.g. ConfigMaps and
+// Secrets) only trigger reconciliations on creation and deletion with this filter.
+var GenerationChangedPredicate EventPredicate = GenerationChanged
+
+// SkipStatusOnlyUpdates returns an event filter that drops the updates where only the status, the resource version or
+// the managed fields of the objects changed.
+func SkipStatusOnlyUpdates() EventPredicate {
+	return func(event ResourceEvent) bool {
+		if event.EventType != UpdateEvent || event.OldObject == nil || event.NewObject == nil {
+			return true
+		}
+		oldObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event.OldObject)
+		if err != nil {
+			return true
+		}
+		newObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event.NewObject)
+		if err != nil {
+			return true
+		}
+		return !reflect.DeepEqual(withoutStatus(oldObj), withoutStatus(newObj))
+	}
+}
+
+// withoutStatus returns a shallow copy of an unstructured object without the status, the resource version and the
+// managed fields
+func withoutStatus(obj map[string]any) map[string]any {
+	obj = lo.OmitByKeys(obj, []string{"status"})
+	if metadata, ok := obj["metadata"].(map[string]any); ok {
+		obj["metadata"] = lo.OmitByKeys(metadata, []string{"resourceVersion", "managedFields"})
+	}
+	return obj
+}
+
+// WithEventFilters sets filters of the events that trigger reconciliations. Events that do not satisfy all the filters
+// update the cache, but are not reconciled. Filters apply to all the reconcilers; to filter the events of a single
+// reconciler, use the predicates of a Subscription instead.
+// With event filters, the changes of the objects watched with StateReconciler are reported as updates, with the
+// previous version of the objects, so the filters can compare them.
+func WithEventFilters(filters ...EventPredicate) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.eventFilters = append(o.eventFilters, filters...)
+	}
+}
+
+// filterEvents returns the events that satisfy all the event filters of the controller
+func (c *Controller) filterEvents(resourceEvents []ResourceEvent) []ResourceEvent {
+	if len(c.eventFilters) == 0 {
+		return resourceEvents
+	}
+	return lo.Filter(resourceEvents, func(event ResourceEvent, _ int) bool {
+		return lo.EveryBy(c.eventFilters, func(filter EventPredicate) bool { return filter(event) })
+	})
+}
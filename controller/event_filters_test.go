@@ -0,0 +1,116 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func eventFiltersTestService(resourceVersion, clusterIP, loadBalancerIP string) *corev1.Service {
+	return &corev1.Service{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "svc", UID: "svc", ResourceVersion: resourceVersion},
+		Spec:       corev1.ServiceSpec{ClusterIP: clusterIP},
+		Status:     corev1.ServiceStatus{LoadBalancer: corev1.LoadBalancerStatus{Ingress: []corev1.LoadBalancerIngress{{IP: loadBalancerIP}}}},
+	}
+}
+
+func TestSkipStatusOnlyUpdates(t *testing.T) {
+	serviceKind := schema.GroupKind{Kind: "Service"}
+	testCases := []struct {
+		name     string
+		event    ResourceEvent
+		expected bool
+	}{
+		{
+			name:     "create",
+			event:    ResourceEvent{Kind: serviceKind, EventType: CreateEvent, NewObject: eventFiltersTestService("1", "10.0.0.1", "")},
+			expected: true,
+		},
+		{
+			name:     "delete",
+			event:    ResourceEvent{Kind: serviceKind, EventType: DeleteEvent, OldObject: eventFiltersTestService("1", "10.0.0.1", "")},
+			expected: true,
+		},
+		{
+			name: "status only",
+			event: ResourceEvent{Kind: serviceKind, EventType: UpdateEvent,
+				OldObject: eventFiltersTestService("1", "10.0.0.1", ""),
+				NewObject: eventFiltersTestService("2", "10.0.0.1", "1.2.3.4"),
+			},
+			expected: false,
+		},
+		{
+			name: "spec",
+			event: ResourceEvent{Kind: serviceKind, EventType: UpdateEvent,
+				OldObject: eventFiltersTestService("1", "10.0.0.1", ""),
+				NewObject: eventFiltersTestService("2", "10.0.0.2", ""),
+			},
+			expected: true,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := SkipStatusOnlyUpdates()(tc.event); got != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, got)
+			}
+		})
+	}
+}
+
+func TestControllerWithEventFilters(t *testing.T) {
+	var mu sync.Mutex
+	var reconciled []ResourceEvent
+	c := NewController(
+		WithEventFilters(SkipStatusOnlyUpdates()),
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			reconciled = append(reconciled, events...)
+		}),
+	)
+	c.subscribe()
+
+	waitFor := func(n int) []ResourceEvent {
+		deadline := time.Now().Add(5 * time.Second)
+		for {
+			mu.Lock()
+			events := append([]ResourceEvent(nil), reconciled...)
+			mu.Unlock()
+			if len(events) >= n || time.Now().After(deadline) {
+				return events
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}
+
+	c.cache.Add(eventFiltersTestService("1", "10.0.0.1", ""))
+	if events := waitFor(1); len(events) != 1 || events[0].EventType != CreateEvent {
+		t.Fatalf("expected a create event, got %v", events)
+	}
+
+	c.cache.Add(eventFiltersTestService("2", "10.0.0.1", "1.2.3.4"))
+	c.cache.Add(eventFiltersTestService("3", "10.0.0.2", "1.2.3.4"))
+	events := waitFor(2)
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %v", events)
+	}
+	if events[1].EventType != UpdateEvent || events[1].OldObject == nil {
+		t.Fatalf("expected an update event with the old object, got %v", events[1])
+	}
+	if clusterIP := events[1].NewObject.(*corev1.Service).Spec.ClusterIP; clusterIP != "10.0.0.2" {
+		t.Errorf("expected the update of the spec, got cluster ip %s", clusterIP)
+	}
+	if len(waitFor(3)) != 2 {
+		t.Error("expected the status-only update to be filtered out")
+	}
+}
@@ -0,0 +1,162 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+const (
+	// EventReasonPolicyConflict is the reason of the events recorded against policies that conflict with other policies
+	// targeting the same resources.
+	EventReasonPolicyConflict = "PolicyConflict"
+	// EventReasonTargetNotFound is the reason of the events recorded against policies whose targets are missing from the
+	// topology.
+	EventReasonTargetNotFound = "TargetNotFound"
+	// EventReasonAssertionViolated is the reason of the events recorded against the nodes of the topology that violate an
+	// assertion rule (see WithAssertionRules).
+	EventReasonAssertionViolated = "AssertionViolated"
+
+	defaultEventDeduplicationWindow = 5 * time.Minute
+)
+
+// WithEventRecorder sets the recorder of the Kubernetes Events emitted by the reconcilers (see RecordEvent), e.g. a
+// recorder created by a record.EventBroadcaster or the one of a controller-runtime manager.
+// Events of the same type, reason and message against the same object are recorded once per deduplication window,
+// so reconciling the same topology over and over does not flood the API server with events. A window of 0 or less
+// defaults to 5m.
+// No event is recorded in one-shot mode.
+func WithEventRecorder(recorder record.EventRecorder, deduplicationWindow time.Duration) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.eventRecorder = NewEventRecorder(recorder, deduplicationWindow)
+	}
+}
+
+// EventRecorder records Kubernetes Events against the policies and targetables of a topology, deduplicating the
+// events recorded within a window (see WithEventRecorder).
+type EventRecorder struct {
+	recorder record.EventRecorder
+	window   time.Duration
+	mutex    sync.Mutex
+	recorded map[eventRecordKey]time.Time
+	now      func() time.Time
+}
+
+type eventRecordKey struct {
+	url       string
+	eventType string
+	reason    string
+	message   string
+}
+
+// NewEventRecorder returns an event recorder that records the events with a given recorder, once per deduplication
+// window. A window of 0 or less defaults to 5m.
+func NewEventRecorder(recorder record.EventRecorder, deduplicationWindow time.Duration) *EventRecorder {
+	if deduplicationWindow <= 0 {
+		deduplicationWindow = defaultEventDeduplicationWindow
+	}
+	return &EventRecorder{
+		recorder: recorder,
+		window:   deduplicationWindow,
+		recorded: map[eventRecordKey]time.Time{},
+		now:      time.Now,
+	}
+}
+
+// Event records an event of a given type (corev1.EventTypeNormal or corev1.EventTypeWarning) against an object, unless
+// the same event was recorded against the object within the deduplication window. It returns whether the event was
+// recorded.
+// Events against sections of resources (e.g. a Listener) are recorded against the resource that declares the section
+// (e.g. the Gateway), with the name of the section as field path.
+func (r *EventRecorder) Event(obj machinery.Object, eventType, reason, message string) bool {
+	if r == nil || obj == nil {
+		return false
+	}
+	if !r.shouldRecord(eventRecordKey{url: obj.GetURL(), eventType: eventType, reason: reason, message: message}) {
+		return false
+	}
+	r.recorder.Event(eventObjectReference(obj), eventType, reason, message)
+	return true
+}
+
+// Eventf is like Event, with the message formatted according to a format specifier.
+func (r *EventRecorder) Eventf(obj machinery.Object, eventType, reason, messageFmt string, args ...any) bool {
+	return r.Event(obj, eventType, reason, fmt.Sprintf(messageFmt, args...))
+}
+
+// shouldRecord records the time of an event, if not recorded within the window, and forgets the events recorded
+// before the window.
+func (r *EventRecorder) shouldRecord(key eventRecordKey) bool {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	now := r.now()
+	for k, recordedAt := range r.recorded {
+		if now.Sub(recordedAt) >= r.window {
+			delete(r.recorded, k)
+		}
+	}
+	if _, ok := r.recorded[key]; ok {
+		return false
+	}
+	r.recorded[key] = now
+	return true
+}
+
+// eventObjectReference returns the reference to the Kubernetes resource of an object to record events against.
+func eventObjectReference(obj machinery.Object) *corev1.ObjectReference {
+	gvk := obj.GroupVersionKind()
+	ref := &corev1.ObjectReference{
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Namespace:  obj.GetNamespace(),
+		Name:       obj.GetName(),
+	}
+	if o, ok := obj.(interface{ GetUID() types.UID }); ok {
+		ref.UID = o.GetUID()
+	}
+	if o, ok := obj.(interface{ GetResourceVersion() string }); ok {
+		ref.ResourceVersion = o.GetResourceVersion()
+	}
+	if ownerKind, ok := machinery.SectionOwnerKind(gvk.GroupKind()); ok {
+		if name, section, ok := machinery.SectionNameSeparatorOf(obj).ParseSectionName(obj.GetName()); ok {
+			ref.Kind = ownerKind.Kind
+			ref.APIVersion = ownerKind.WithVersion(gvk.Version).GroupVersion().String()
+			ref.Name = name
+			ref.FieldPath = fmt.Sprintf("sections{%s}", section)
+		}
+	}
+	return ref
+}
+
+type eventRecorderKey struct{}
+
+// EventRecorderFromContext returns the event recorder of the controller from the context, or nil if none is found.
+func EventRecorderFromContext(ctx context.Context) *EventRecorder {
+	recorder, _ := ctx.Value(eventRecorderKey{}).(*EventRecorder)
+	return recorder
+}
+
+// EventRecorderIntoContext returns a new context with the event recorder set.
+func EventRecorderIntoContext(ctx context.Context, recorder *EventRecorder) context.Context {
+	return context.WithValue(ctx, eventRecorderKey{}, recorder)
+}
+
+// RecordEvent records an event against an object with the event recorder of the context (see WithEventRecorder),
+// e.g. a Warning event with reason EventReasonTargetNotFound against a policy whose target is missing.
+// It does nothing if the context has no event recorder.
+func RecordEvent(ctx context.Context, obj machinery.Object, eventType, reason, message string) {
+	EventRecorderFromContext(ctx).Event(obj, eventType, reason, message)
+}
+
+// RecordEventf is like RecordEvent, with the message formatted according to a format specifier.
+func RecordEventf(ctx context.Context, obj machinery.Object, eventType, reason, messageFmt string, args ...any) {
+	EventRecorderFromContext(ctx).Eventf(obj, eventType, reason, messageFmt, args...)
+}
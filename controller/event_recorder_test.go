@@ -0,0 +1,100 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+type recordedEvent struct {
+	ref       *corev1.ObjectReference
+	eventType string
+	reason    string
+	message   string
+}
+
+// capturingRecorder is an event recorder that captures the references of the objects the events are recorded against
+type capturingRecorder struct {
+	record.EventRecorder
+	events []recordedEvent
+}
+
+func (r *capturingRecorder) Event(object runtime.Object, eventType, reason, message string) {
+	r.events = append(r.events, recordedEvent{object.(*corev1.ObjectReference), eventType, reason, message})
+}
+
+func TestEventRecorder(t *testing.T) {
+	capturing := &capturingRecorder{}
+	recorder := NewEventRecorder(capturing, time.Minute)
+	now := time.Now()
+	recorder.now = func() time.Time { return now }
+
+	gateway := &machinery.Gateway{Gateway: machinery.BuildGateway()}
+	listener := &machinery.Listener{Listener: &gateway.Spec.Listeners[0], Gateway: gateway}
+
+	if !recorder.Eventf(gateway, corev1.EventTypeWarning, EventReasonPolicyConflict, "conflicts with %s", "other-policy") {
+		t.Errorf("expected the event to be recorded")
+	}
+	if recorder.Event(gateway, corev1.EventTypeWarning, EventReasonPolicyConflict, "conflicts with other-policy") {
+		t.Errorf("expected the same event within the window to be deduplicated")
+	}
+	if !recorder.Event(gateway, corev1.EventTypeWarning, EventReasonPolicyConflict, "conflicts with another-policy") {
+		t.Errorf("expected an event with a different message to be recorded")
+	}
+	if !recorder.Event(listener, corev1.EventTypeWarning, EventReasonPolicyConflict, "conflicts with other-policy") {
+		t.Errorf("expected the same event against a different object to be recorded")
+	}
+	now = now.Add(time.Minute)
+	if !recorder.Event(gateway, corev1.EventTypeWarning, EventReasonPolicyConflict, "conflicts with other-policy") {
+		t.Errorf("expected the same event to be recorded again after the window")
+	}
+
+	if len(capturing.events) != 4 {
+		t.Fatalf("expected 4 events recorded, got %d", len(capturing.events))
+	}
+	if ref := capturing.events[0].ref; ref.Kind != "Gateway" || ref.APIVersion != gwapiv1.GroupVersion.String() || ref.Namespace != gateway.Namespace || ref.Name != gateway.Name || ref.FieldPath != "" {
+		t.Errorf("unexpected reference to the gateway: %+v", ref)
+	}
+	if ref := capturing.events[2].ref; ref.Kind != "Gateway" || ref.Name != gateway.Name || ref.FieldPath != "sections{"+string(listener.Name)+"}" {
+		t.Errorf("expected the event against the listener to be recorded against its gateway, got %+v", ref)
+	}
+	if len(recorder.recorded) != 1 {
+		t.Errorf("expected the events recorded before the window to be forgotten, got %d", len(recorder.recorded))
+	}
+
+	var nilRecorder *EventRecorder
+	if nilRecorder.Event(gateway, corev1.EventTypeNormal, "Reason", "message") {
+		t.Errorf("expected a nil recorder to record nothing")
+	}
+}
+
+func TestControllerEventRecorder(t *testing.T) {
+	capturing := &capturingRecorder{}
+	gateway := &machinery.Gateway{Gateway: machinery.BuildGateway()}
+	c := NewController(
+		WithEventRecorder(capturing, 0),
+		WithReconcile(func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) {
+			RecordEvent(ctx, gateway, corev1.EventTypeWarning, EventReasonTargetNotFound, "target not found")
+		}),
+	)
+
+	for range 2 {
+		c.Lock()
+		c.run(nil)
+		c.Unlock()
+	}
+	if len(capturing.events) != 1 || capturing.events[0].reason != EventReasonTargetNotFound {
+		t.Errorf("expected one event recorded across reconciliations, got %+v", capturing.events)
+	}
+
+	RecordEvent(context.Background(), gateway, corev1.EventTypeWarning, EventReasonTargetNotFound, "no recorder")
+}
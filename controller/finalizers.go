@@ -0,0 +1,163 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Finalization declares the cleanup to run when a policy of a kind is deleted, before the policy is gone, e.g. to
+// delete the resources generated out of the policy that are not garbage collected otherwise.
+type Finalization struct {
+	// PolicyKind is the kind of the policies. The kind must be watched by the controller.
+	PolicyKind schema.GroupKind
+	// PolicyResource is the resource of the policies, used to add and remove the finalizer.
+	PolicyResource schema.GroupVersionResource
+	// Finalizer is the finalizer added to the policies, e.g. "kuadrant.io/authpolicies".
+	Finalizer string
+	// Cleanup is called with delete events of the policies marked for deletion and a topology without them. The
+	// finalizer is only removed from the policies if the cleanup succeeds; otherwise, the cleanup is retried.
+	Cleanup ErrorReconcileFunc
+}
+
+// WithFinalization registers cleanups to run when policies are deleted.
+// Every time the topology is reconciled, the controller adds the finalizers to the policies of the given kinds, and
+// runs the cleanups of the policies marked for deletion, removing the finalizers once the cleanups succeed.
+func WithFinalization(finalizations ...Finalization) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.finalizations = append(o.finalizations, finalizations...)
+	}
+}
+
+// IsMarkedForDeletion returns true if a resource has a deletion timestamp set.
+// Both cluster runtime objects and topology objects that wrap them are supported.
+func IsMarkedForDeletion(obj any) bool {
+	o, ok := objectMeta(obj)
+	return ok && o.GetDeletionTimestamp() != nil
+}
+
+// HasFinalizer returns true if a resource has a given finalizer.
+// Both cluster runtime objects and topology objects that wrap them are supported.
+func HasFinalizer(obj any, finalizer string) bool {
+	o, ok := objectMeta(obj)
+	return ok && lo.Contains(o.GetFinalizers(), finalizer)
+}
+
+// EnsureFinalizer adds a finalizer to a resource in the cluster, unless the resource already has it or is marked for
+// deletion. It returns true if the resource was updated. The patch is rejected with ErrConflict if the resource
+// changed since it was read. No finalizer is added in the ManagementStateUnmanaged management state.
+func EnsureFinalizer(ctx context.Context, client dynamic.NamespaceableResourceInterface, obj any, finalizer string) (bool, error) {
+	o, ok := objectMeta(obj)
+	if !ok {
+		return false, fmt.Errorf("%w: unexpected object type: %T", ErrConversion, obj)
+	}
+	if o.GetDeletionTimestamp() != nil || lo.Contains(o.GetFinalizers(), finalizer) || IsUnmanagedState(ctx) {
+		return false, nil
+	}
+	return true, patchFinalizers(ctx, client, o, append(append([]string(nil), o.GetFinalizers()...), finalizer))
+}
+
+// RemoveFinalizer removes a finalizer from a resource in the cluster, if the resource has it.
+// It returns true if the resource was updated. The patch is rejected with ErrConflict if the resource changed since it
+// was read. No finalizer is removed in the ManagementStateUnmanaged management state.
+func RemoveFinalizer(ctx context.Context, client dynamic.NamespaceableResourceInterface, obj any, finalizer string) (bool, error) {
+	o, ok := objectMeta(obj)
+	if !ok {
+		return false, fmt.Errorf("%w: unexpected object type: %T", ErrConversion, obj)
+	}
+	if !lo.Contains(o.GetFinalizers(), finalizer) || IsUnmanagedState(ctx) {
+		return false, nil
+	}
+	return true, patchFinalizers(ctx, client, o, lo.Without(o.GetFinalizers(), finalizer))
+}
+
+// patchFinalizers sets the finalizers of a resource with a merge patch locked to the version of the resource read.
+func patchFinalizers(ctx context.Context, client dynamic.NamespaceableResourceInterface, obj metav1.Object, finalizers []string) error {
+	patch, err := json.Marshal(map[string]any{
+		"metadata": map[string]any{
+			"finalizers":      finalizers,
+			"resourceVersion": obj.GetResourceVersion(),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if _, err := client.Namespace(obj.GetNamespace()).Patch(ctx, obj.GetName(), types.MergePatchType, patch, metav1.PatchOptions{}); err != nil {
+		return fmt.Errorf("failed to patch the finalizers of %s: %w", namespacedName(obj), wrapAPIError(err))
+	}
+	return nil
+}
+
+// deletionChanged returns true if a resource was marked for deletion.
+func deletionChanged(oldObj, newObj Object) bool {
+	return IsMarkedForDeletion(oldObj) != IsMarkedForDeletion(newObj)
+}
+
+// reconcileFinalizations adds the finalizers to the policies of the given kinds, and runs the cleanups of the policies
+// marked for deletion. The topology to clean up is built out of the objects without the policies marked for deletion.
+func reconcileFinalizations(ctx context.Context, client func(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface, finalizations []Finalization, store Store, build func(Store) *machinery.Topology) error {
+	logger := LoggerFromContext(ctx).WithName("finalization")
+
+	var errs []error
+	for _, finalization := range finalizations {
+		policies := store.FilterByGroupKind(finalization.PolicyKind)
+		deleting := lo.Filter(policies, func(policy Object, _ int) bool {
+			return IsMarkedForDeletion(policy) && HasFinalizer(policy, finalization.Finalizer)
+		})
+
+		for _, policy := range policies {
+			updated, err := EnsureFinalizer(ctx, client(finalization.PolicyResource), policy, finalization.Finalizer)
+			if err != nil {
+				logger.Error(err, "failed to add finalizer", "policy", namespacedName(policy))
+				continue
+			}
+			if updated {
+				logger.V(1).Info("finalizer added", "policy", namespacedName(policy), "finalizer", finalization.Finalizer)
+			}
+		}
+
+		if len(deleting) == 0 || finalization.Cleanup == nil {
+			continue
+		}
+
+		remaining := Store(lo.OmitBy(store, func(_ string, obj Object) bool {
+			return lo.Contains(deleting, obj)
+		}))
+		events := lo.Map(deleting, func(policy Object, _ int) ResourceEvent {
+			return ResourceEvent{Kind: finalization.PolicyKind, EventType: DeleteEvent, OldObject: policy}
+		})
+		if err := finalization.Cleanup(ctx, events, build(remaining)); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		for _, policy := range deleting {
+			if _, err := RemoveFinalizer(ctx, client(finalization.PolicyResource), policy, finalization.Finalizer); err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			logger.V(1).Info("finalizer removed", "policy", namespacedName(policy), "finalizer", finalization.Finalizer)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// objectMeta returns the metadata of a cluster runtime object or of a topology object that wraps one.
+func objectMeta(obj any) (metav1.Object, bool) {
+	switch o := obj.(type) {
+	case *RuntimeObject:
+		return o.Object, true
+	case metav1.Object:
+		return o, true
+	default:
+		return nil, false
+	}
+}
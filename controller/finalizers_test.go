@@ -0,0 +1,146 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/utils/ptr"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestFinalizers(t *testing.T) {
+	const finalizer = "test/finalizer"
+	ctx := context.TODO()
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := &unstructured.Unstructured{}
+	policy.SetAPIVersion("test/v1")
+	policy.SetKind("TestPolicy")
+	policy.SetNamespace("my-namespace")
+	policy.SetName("my-policy")
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"}, policy.DeepCopy())
+	get := func() *unstructured.Unstructured {
+		obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(ctx, "my-policy", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get policy: %v", err)
+		}
+		return obj
+	}
+
+	if updated, err := EnsureFinalizer(ctx, client.Resource(policyResource), policy, finalizer); err != nil || !updated {
+		t.Fatalf("expected finalizer to be added, got updated=%t, err=%v", updated, err)
+	}
+	current := get()
+	if !HasFinalizer(current, finalizer) {
+		t.Fatalf("expected finalizer in %v", current.GetFinalizers())
+	}
+	if updated, err := EnsureFinalizer(ctx, client.Resource(policyResource), current, finalizer); err != nil || updated {
+		t.Errorf("expected no update, got updated=%t, err=%v", updated, err)
+	}
+
+	deleting := current.DeepCopy()
+	deleting.SetDeletionTimestamp(ptr.To(metav1.Now()))
+	if !IsMarkedForDeletion(deleting) || IsMarkedForDeletion(current) {
+		t.Error("unexpected deletion mark")
+	}
+	if updated, err := EnsureFinalizer(ctx, client.Resource(policyResource), lo.Must(Destruct(*deleting)), "test/other"); err != nil || updated {
+		t.Errorf("expected no finalizer added to a resource marked for deletion, got updated=%t, err=%v", updated, err)
+	}
+
+	if updated, err := RemoveFinalizer(ctx, client.Resource(policyResource), current, finalizer); err != nil || !updated {
+		t.Fatalf("expected finalizer to be removed, got updated=%t, err=%v", updated, err)
+	}
+	if current := get(); HasFinalizer(current, finalizer) {
+		t.Errorf("expected no finalizer, got %v", current.GetFinalizers())
+	}
+	if updated, err := RemoveFinalizer(ctx, client.Resource(policyResource), policy, finalizer); err != nil || updated {
+		t.Errorf("expected no update, got updated=%t, err=%v", updated, err)
+	}
+}
+
+func TestReconcileFinalizations(t *testing.T) {
+	const finalizer = "test/finalizer"
+	ctx := context.TODO()
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := func(name string, deleting bool, finalizers ...string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("test/v1")
+		obj.SetKind(policyKind.Kind)
+		obj.SetNamespace("my-namespace")
+		obj.SetName(name)
+		obj.SetFinalizers(finalizers)
+		if deleting {
+			obj.SetDeletionTimestamp(ptr.To(metav1.Now()))
+		}
+		return obj
+	}
+	policies := []*unstructured.Unstructured{
+		policy("new", false),
+		policy("deleting", true, finalizer),
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"},
+		lo.Map(policies, func(p *unstructured.Unstructured, _ int) runtime.Object { return p.DeepCopy() })...,
+	)
+	store := Store(lo.SliceToMap(policies, func(p *unstructured.Unstructured) (string, Object) { return p.GetName(), p }))
+	build := func(s Store) *machinery.Topology {
+		return machinery.NewTopology(machinery.WithObjects(lo.Map(lo.Values(s), func(obj Object, _ int) machinery.Object { return &RuntimeObject{obj} })...))
+	}
+	finalizers := func(name string) []string {
+		obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get policy: %v", err)
+		}
+		return obj.GetFinalizers()
+	}
+
+	// failed cleanup
+	var cleanedUp []string
+	var topology *machinery.Topology
+	cleanupErr := errors.New("cleanup failed")
+	finalization := Finalization{
+		PolicyKind:     policyKind,
+		PolicyResource: policyResource,
+		Finalizer:      finalizer,
+		Cleanup: func(_ context.Context, events []ResourceEvent, t *machinery.Topology) error {
+			cleanedUp = lo.Map(events, func(e ResourceEvent, _ int) string { return e.OldObject.GetName() })
+			topology = t
+			return cleanupErr
+		},
+	}
+	if err := reconcileFinalizations(ctx, client.Resource, []Finalization{finalization}, store, build); !errors.Is(err, cleanupErr) {
+		t.Errorf("expected cleanup error, got %v", err)
+	}
+	if len(cleanedUp) != 1 || cleanedUp[0] != "deleting" {
+		t.Errorf("expected cleanup of the deleting policy, got %v", cleanedUp)
+	}
+	if names := lo.Map(topology.Objects().Items(), func(o machinery.Object, _ int) string { return o.GetName() }); len(names) != 1 || names[0] != "new" {
+		t.Errorf("expected topology without the deleting policy, got %v", names)
+	}
+	if f := finalizers("new"); len(f) != 1 || f[0] != finalizer {
+		t.Errorf("expected finalizer added to the new policy, got %v", f)
+	}
+	if f := finalizers("deleting"); len(f) != 1 {
+		t.Errorf("expected finalizer kept after failed cleanup, got %v", f)
+	}
+
+	// successful cleanup
+	finalization.Cleanup = func(context.Context, []ResourceEvent, *machinery.Topology) error { return nil }
+	if err := reconcileFinalizations(ctx, client.Resource, []Finalization{finalization}, store, build); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if f := finalizers("deleting"); len(f) != 0 {
+		t.Errorf("expected finalizer removed after cleanup, got %v", f)
+	}
+}
@@ -0,0 +1,98 @@
+package controller
+
+import (
+	"context"
+	"strings"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// GarbageCollection declares a kind of resource generated by the reconcilers of a controller, whose orphans are
+// deleted by the controller (see OrphanObjects).
+type GarbageCollection struct {
+	// GeneratedKind is the kind of the generated resources. The kind must be watched by the controller.
+	GeneratedKind schema.GroupKind
+	// GeneratedResource is the resource of the generated resources, used to delete them.
+	GeneratedResource schema.GroupVersionResource
+	// ManagedBy restricts the collection to the resources whose managed-by label (see ManagedByLabel) is set to the
+	// given value. Empty means any resource of the kind.
+	ManagedBy string
+}
+
+// WithGarbageCollection registers kinds of generated resources to garbage collect.
+// Every time the topology is reconciled, the controller deletes the generated resources whose owners, as recorded by
+// SetGeneratedResourceLabels, no longer exist in the topology, so reconcilers do not need to handle the deletion of
+// the resources they generated for deleted targetables or policies. Unmanaged resources are never deleted.
+// The garbage is not collected until the caches of all the runnables of the controller have synced, regardless of
+// WithInitialSync, so the owners not listed yet are not mistaken for deleted ones.
+func WithGarbageCollection(collections ...GarbageCollection) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.garbageCollections = append(o.garbageCollections, collections...)
+	}
+}
+
+// OwningTargetables returns the URLs of the targetables that own a generated resource, as recorded in the
+// OwningTargetablesAnnotation. Both cluster runtime objects and topology objects that wrap them are supported.
+func OwningTargetables(obj any) []string {
+	return splitAnnotation(objectAnnotations(obj)[OwningTargetablesAnnotation])
+}
+
+// OwningPolicies returns the owning policies recorded in the OwningPoliciesAnnotation of a generated resource, in the
+// form kind:namespace/name. Both cluster runtime objects and topology objects that wrap them are supported.
+func OwningPolicies(obj any) []string {
+	return splitAnnotation(objectAnnotations(obj)[OwningPoliciesAnnotation])
+}
+
+// OrphanObjects returns the generated objects whose owners no longer exist in a topology, i.e. objects that either
+// record an owning targetable that is not in the topology, or record owning policies none of which is in the topology.
+// Objects that record no owners and unmanaged objects are never orphans.
+// The owning targetables are resolved regardless of the section name separator they were recorded with (see
+// machinery.NormalizeLocator).
+func OrphanObjects(topology *machinery.Topology, objs []machinery.Object) []machinery.Object {
+	targetables := topology.Targetables()
+	policies := lo.SliceToMap(topology.Policies().Items(), func(p machinery.Policy) (string, struct{}) {
+		return owningPolicyKey(p), struct{}{}
+	})
+	return lo.Filter(objs, func(obj machinery.Object, _ int) bool {
+		if IsUnmanaged(obj) {
+			return false
+		}
+		if lo.ContainsBy(OwningTargetables(obj), func(url string) bool { _, ok := targetables.Get(url); return !ok }) {
+			return true
+		}
+		owningPolicies := OwningPolicies(obj)
+		return len(owningPolicies) > 0 && !lo.ContainsBy(owningPolicies, func(key string) bool { _, ok := policies[key]; return ok })
+	})
+}
+
+// collectGarbage deletes the orphans of the generated resources in the topology.
+func collectGarbage(ctx context.Context, client func(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface, collections []GarbageCollection, topology *machinery.Topology) {
+	logger := LoggerFromContext(ctx).WithName("garbage collection")
+
+	for _, collection := range collections {
+		generated := topology.Objects().Items(func(o machinery.Object) bool {
+			return o.GroupVersionKind().GroupKind() == collection.GeneratedKind && (collection.ManagedBy == "" || objectLabels(o)[ManagedByLabel] == collection.ManagedBy)
+		})
+		for _, obj := range OrphanObjects(topology, generated) {
+			err := client(collection.GeneratedResource).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				logger.Error(err, "failed to delete orphan", "object", obj.GetURL())
+				continue
+			}
+			logger.V(1).Info("orphan deleted", "object", obj.GetURL())
+		}
+	}
+}
+
+func splitAnnotation(value string) []string {
+	if value == "" {
+		return nil
+	}
+	return strings.Split(value, ",")
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestGarbageCollection(t *testing.T) {
+	generatedResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "generateds"}
+	generatedKind := schema.GroupKind{Group: "test", Kind: "Generated"}
+
+	gateway := &machinery.Gateway{Gateway: machinery.BuildGateway()}
+	deletedGateway := &machinery.Gateway{Gateway: machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "deleted-gateway" })}
+	testPolicy := func(name string) *machinery.TestPolicy {
+		return &machinery.TestPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace"},
+		}
+	}
+	listener := &machinery.Listener{Listener: &gateway.Spec.Listeners[0], Gateway: gateway}
+	// the section name of the listener escaped where it needs not, e.g. by an older version of the controller
+	escapedListenerURL := strings.Replace(listener.GetURL(), "my-listener", "my%2Dlistener", 1)
+	policy := testPolicy("my-policy")
+	deletedPolicy := testPolicy("deleted-policy")
+
+	generated := func(name, managedBy string, l GeneratedResourceLabels, annotations map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("test/v1")
+		obj.SetKind(generatedKind.Kind)
+		obj.SetNamespace("my-namespace")
+		obj.SetName(name)
+		obj.SetLabels(map[string]string{})
+		obj.SetAnnotations(annotations)
+		l.ManagedBy = managedBy
+		SetGeneratedResourceLabels(obj, l)
+		return obj
+	}
+	objs := []*unstructured.Unstructured{
+		generated("live-targetable", "test", GeneratedResourceLabels{OwningTargetables: []machinery.Targetable{gateway}}, nil),
+		generated("orphan-targetable", "test", GeneratedResourceLabels{OwningTargetables: []machinery.Targetable{gateway, deletedGateway}}, nil),
+		generated("live-listener", "test", GeneratedResourceLabels{}, map[string]string{OwningTargetablesAnnotation: escapedListenerURL}),
+		generated("live-policy", "test", GeneratedResourceLabels{OwningPolicies: []machinery.Policy{policy, deletedPolicy}}, nil),
+		generated("orphan-policy", "test", GeneratedResourceLabels{OwningPolicies: []machinery.Policy{deletedPolicy}}, nil),
+		generated("orphan-unmanaged", "test", GeneratedResourceLabels{OwningPolicies: []machinery.Policy{deletedPolicy}}, map[string]string{UnmanagedAnnotation: "true"}),
+		generated("orphan-other-controller", "other", GeneratedResourceLabels{OwningPolicies: []machinery.Policy{deletedPolicy}}, nil),
+		generated("no-owners", "test", GeneratedResourceLabels{}, nil),
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{generatedResource: "GeneratedList"},
+		lo.Map(objs, func(obj *unstructured.Unstructured, _ int) runtime.Object { return obj.DeepCopy() })...,
+	)
+	topology := machinery.NewTopology(
+		machinery.WithTargetables(gateway),
+		machinery.WithTargetables(listener),
+		machinery.WithPolicies(policy),
+		machinery.WithObjects(lo.Map(objs, func(obj *unstructured.Unstructured, _ int) machinery.Object { return &RuntimeObject{obj} })...),
+	)
+
+	if orphans := OrphanObjects(topology, topology.Objects().Items()); len(orphans) != 3 {
+		t.Errorf("expected 3 orphans, got %v", lo.Map(orphans, func(o machinery.Object, _ int) string { return o.GetName() }))
+	}
+
+	collectGarbage(context.TODO(), client.Resource, []GarbageCollection{{GeneratedKind: generatedKind, GeneratedResource: generatedResource, ManagedBy: "test"}}, topology)
+
+	for _, obj := range objs {
+		_, err := client.Resource(generatedResource).Namespace("my-namespace").Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+		deleted := apierrors.IsNotFound(err)
+		if expected := obj.GetName() == "orphan-targetable" || obj.GetName() == "orphan-policy"; deleted != expected {
+			t.Errorf("expected %s deleted to be %t, got %t", obj.GetName(), expected, deleted)
+		}
+	}
+}
+
+func TestControllerGarbageCollectionAwaitsSync(t *testing.T) {
+	generatedResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "generateds"}
+	generatedKind := schema.GroupKind{Group: "test", Kind: "Generated"}
+
+	orphan := &unstructured.Unstructured{}
+	orphan.SetAPIVersion("test/v1")
+	orphan.SetKind(generatedKind.Kind)
+	orphan.SetNamespace("my-namespace")
+	orphan.SetName("orphan")
+	orphan.SetUID("orphan")
+	gateway := &machinery.Gateway{Gateway: machinery.BuildGateway()}
+	SetGeneratedResourceLabels(orphan, GeneratedResourceLabels{ManagedBy: "test", OwningTargetables: []machinery.Targetable{gateway}})
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{generatedResource: "GeneratedList"},
+		orphan.DeepCopy(),
+	)
+	var runnable *objectsRunnable
+	c := NewController(
+		WithClient(client),
+		WithObjectKinds(generatedKind),
+		WithGarbageCollection(GarbageCollection{GeneratedKind: generatedKind, GeneratedResource: generatedResource, ManagedBy: "test"}),
+		// the runnable of the gateways has not synced yet
+		WithRunnable("gateways", func(controller *Controller) Runnable {
+			runnable = &objectsRunnable{controller: controller}
+			return runnable
+		}),
+	)
+	c.cache.Add(orphan)
+
+	exists := func() bool {
+		_, err := client.Resource(generatedResource).Namespace("my-namespace").Get(context.TODO(), "orphan", metav1.GetOptions{})
+		return !apierrors.IsNotFound(err)
+	}
+
+	c.Lock()
+	c.run(nil)
+	c.Unlock()
+	if !exists() {
+		t.Fatal("expected no garbage collection before the runnables have synced")
+	}
+
+	runnable.mu.Lock()
+	runnable.synced = true
+	runnable.mu.Unlock()
+	c.Lock()
+	c.run(nil)
+	c.Unlock()
+	if exists() {
+		t.Error("expected the orphan collected after the runnables have synced")
+	}
+}
@@ -0,0 +1,221 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Standard labels and annotations of generated resources
+const (
+	// EffectivePolicyHashAnnotation is the annotation set on generated resources to record the hash of the effective
+	// policy that produced the last applied version of the resource.
+	EffectivePolicyHashAnnotation = "policy-machinery.kuadrant.io/effective-policy-hash"
+
+	// ManagedByLabel is the label set on generated resources with the name of the controller that manages them.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+
+	// OwningPolicyLabelPrefix is the prefix of the labels set on generated resources for each of the policies that own
+	// them, e.g. 'owning-policy.policy-machinery.kuadrant.io/<hash of the policy>: "true"'. Label values cannot hold
+	// the identity of a policy, nor of multiple policies, hence one label per owning policy, keyed by hash.
+	OwningPolicyLabelPrefix = "owning-policy.policy-machinery.kuadrant.io/"
+
+	// OwningPoliciesAnnotation is the annotation set on generated resources with the comma-separated list of the
+	// policies that own them, in the human-readable form kind:namespace/name.
+	OwningPoliciesAnnotation = "policy-machinery.kuadrant.io/owning-policies"
+
+	// OwningTargetablesAnnotation is the annotation set on generated resources with the comma-separated list of the
+	// URLs of the targetables that own them, e.g. the gateway a SecurityPolicy is generated for.
+	OwningTargetablesAnnotation = "policy-machinery.kuadrant.io/owning-targetables"
+
+	// TopologyRevisionLabel is the label set on generated resources with the revision of the topology out of which
+	// the last applied version of the resources was generated.
+	TopologyRevisionLabel = "policy-machinery.kuadrant.io/topology-revision"
+
+	// UnmanagedAnnotation is the annotation that operators set to "true" on a generated resource to tell the
+	// controller to stop reconciling it, e.g. to hot-fix the resource manually. The controller neither updates nor
+	// deletes unmanaged resources.
+	UnmanagedAnnotation = "policy-machinery.kuadrant.io/unmanaged"
+)
+
+// GeneratedResourceLabels are the standard labels of a generated resource.
+type GeneratedResourceLabels struct {
+	// ManagedBy is the name of the controller that manages the resource.
+	ManagedBy string
+	// OwningPolicies are the policies out of which the resource is generated.
+	OwningPolicies []machinery.Policy
+	// OwningTargetables are the targetables for which the resource is generated.
+	OwningTargetables []machinery.Targetable
+	// TopologyRevision is the revision of the topology out of which the resource is generated (see TopologyRevision).
+	TopologyRevision string
+}
+
+// SetGeneratedResourceLabels stamps a generated resource with the standard labels and annotations, replacing the
+// owning policy labels set before. Empty values are omitted.
+func SetGeneratedResourceLabels(obj metav1.Object, l GeneratedResourceLabels) {
+	labels := lo.OmitBy(obj.GetLabels(), func(key string, _ string) bool {
+		return strings.HasPrefix(key, OwningPolicyLabelPrefix)
+	})
+	if l.ManagedBy != "" {
+		labels[ManagedByLabel] = l.ManagedBy
+	}
+	for _, policy := range l.OwningPolicies {
+		labels[OwningPolicyLabel(policy)] = "true"
+	}
+	if l.TopologyRevision != "" {
+		labels[TopologyRevisionLabel] = l.TopologyRevision
+	}
+	obj.SetLabels(labels)
+
+	if len(l.OwningPolicies) > 0 {
+		owners := lo.Uniq(lo.Map(l.OwningPolicies, func(policy machinery.Policy, _ int) string {
+			return owningPolicyKey(policy)
+		}))
+		sort.Strings(owners)
+		setAnnotation(obj, OwningPoliciesAnnotation, strings.Join(owners, ","))
+	}
+
+	if len(l.OwningTargetables) > 0 {
+		owners := lo.Uniq(lo.Map(l.OwningTargetables, machinery.MapTargetableToURLFunc))
+		sort.Strings(owners)
+		setAnnotation(obj, OwningTargetablesAnnotation, strings.Join(owners, ","))
+	}
+}
+
+// owningPolicyKey returns the human-readable identity of a policy recorded in OwningPoliciesAnnotation.
+func owningPolicyKey(policy machinery.Policy) string {
+	return fmt.Sprintf("%s:%s", policy.GroupVersionKind().Kind, objectName(policy))
+}
+
+// OwningPolicyLabel returns the key of the label set on the resources generated out of a given policy.
+func OwningPolicyLabel(policy machinery.Policy) string {
+	return OwningPolicyLabelPrefix + fmt.Sprintf("%x", sha256.Sum256([]byte(policy.GetURL())))[:16]
+}
+
+// OwningPolicySelector returns a label selector of the resources generated out of a given policy.
+func OwningPolicySelector(policy machinery.Policy) string {
+	return OwningPolicyLabel(policy)
+}
+
+// TopologyRevision returns a revision of a topology, i.e. a short hash of the identities and resource versions of
+// all the objects in the topology. Two topologies built out of the same versions of the same objects share the same
+// revision, whatever the order the objects were added in.
+func TopologyRevision(topology *machinery.Topology) string {
+	var objs []machinery.Object
+	objs = append(objs, lo.Map(topology.Targetables().Items(), func(t machinery.Targetable, _ int) machinery.Object { return t })...)
+	objs = append(objs, lo.Map(topology.Policies().Items(), func(p machinery.Policy, _ int) machinery.Object { return p })...)
+	objs = append(objs, topology.Objects().Items()...)
+	keys := lo.Map(objs, func(obj machinery.Object, _ int) string {
+		return fmt.Sprintf("%s@%s", obj.GetURL(), resourceVersion(obj))
+	})
+	sort.Strings(keys)
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(keys, "\n"))))[:16]
+}
+
+func resourceVersion(obj machinery.Object) string {
+	if o, ok := obj.(*RuntimeObject); ok {
+		return o.Object.GetResourceVersion()
+	}
+	if o, ok := obj.(interface{ GetResourceVersion() string }); ok {
+		return o.GetResourceVersion()
+	}
+	return ""
+}
+
+// IsUnmanaged returns true if a generated resource is annotated as unmanaged, i.e. the controller must not reconcile it.
+// Both cluster runtime objects and topology objects that wrap them are supported.
+func IsUnmanaged(obj any) bool {
+	return objectAnnotations(obj)[UnmanagedAnnotation] == "true"
+}
+
+// ManagedObjects filters out the generated objects annotated as unmanaged.
+func ManagedObjects(objs []machinery.Object) []machinery.Object {
+	return lo.Reject(objs, func(obj machinery.Object, _ int) bool {
+		return IsUnmanaged(obj)
+	})
+}
+
+// HashEffectivePolicy returns a stable hash of any json-serializable representation of one or more effective policies.
+// Callers should pass only the parts of the policies that are relevant to the generated resource (e.g. the rules),
+// so changes to metadata or status do not cause generated resources to be considered stale.
+// Policies that implement machinery.NormalizablePolicy are normalized before hashing.
+// It fails if the effective policy cannot be serialized, rather than sharing a hash among all unserializable policies.
+func HashEffectivePolicy(effectivePolicy any) (string, error) {
+	if policy, ok := effectivePolicy.(machinery.Policy); ok {
+		effectivePolicy = machinery.NormalizePolicy(policy)
+	}
+	b, err := json.Marshal(effectivePolicy)
+	if err != nil {
+		return "", fmt.Errorf("failed to hash effective policy: %w", err)
+	}
+	return fmt.Sprintf("%x", sha256.Sum256(b)), nil
+}
+
+// SetEffectivePolicyHash annotates a generated resource with the hash of the effective policy that produced it.
+func SetEffectivePolicyHash(obj metav1.Object, hash string) {
+	setAnnotation(obj, EffectivePolicyHashAnnotation, hash)
+}
+
+// EffectivePolicyHash returns the hash of the last applied effective policy recorded in a generated resource.
+// Both cluster runtime objects and topology objects that wrap them are supported.
+func EffectivePolicyHash(obj any) (string, bool) {
+	hash, ok := objectAnnotations(obj)[EffectivePolicyHashAnnotation]
+	return hash, ok
+}
+
+// StaleObjects returns the generated objects whose last applied effective policy differs from the current one.
+// The desiredHash function returns the hash of the current effective policy for a given object, or false if the
+// object should be skipped. Objects without the annotation are considered stale; unmanaged objects are skipped.
+func StaleObjects(objs []machinery.Object, desiredHash func(machinery.Object) (string, bool)) []machinery.Object {
+	return lo.Filter(objs, func(obj machinery.Object, _ int) bool {
+		if IsUnmanaged(obj) {
+			return false
+		}
+		desired, ok := desiredHash(obj)
+		if !ok {
+			return false
+		}
+		current, ok := EffectivePolicyHash(obj)
+		return !ok || current != desired
+	})
+}
+
+func setAnnotation(obj metav1.Object, key, value string) {
+	annotations := obj.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[key] = value
+	obj.SetAnnotations(annotations)
+}
+
+// objectLabels returns the labels of a cluster runtime object or of a topology object that wraps one.
+func objectLabels(obj any) map[string]string {
+	switch o := obj.(type) {
+	case *RuntimeObject:
+		return o.Object.GetLabels()
+	case metav1.Object:
+		return o.GetLabels()
+	default:
+		return nil
+	}
+}
+
+// objectAnnotations returns the annotations of a cluster runtime object or of a topology object that wraps one.
+func objectAnnotations(obj any) map[string]string {
+	switch o := obj.(type) {
+	case *RuntimeObject:
+		return o.Object.GetAnnotations()
+	case metav1.Object:
+		return o.GetAnnotations()
+	default:
+		return nil
+	}
+}
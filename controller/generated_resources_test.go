@@ -0,0 +1,138 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestHashEffectivePolicy(t *testing.T) {
+	a, err := HashEffectivePolicy(map[string]any{"foo": "bar", "baz": 1})
+	if err != nil || a == "" {
+		t.Fatalf("expected non-empty hash, got %q (%v)", a, err)
+	}
+	b, _ := HashEffectivePolicy(map[string]any{"baz": 1, "foo": "bar"})
+	c, _ := HashEffectivePolicy(map[string]any{"foo": "bar"})
+	if a != b {
+		t.Errorf("expected equal hashes for equal policies, got %s and %s", a, b)
+	}
+	if a == c {
+		t.Errorf("expected different hashes for different policies, got %s", a)
+	}
+	if hash, err := HashEffectivePolicy(map[string]any{"foo": func() {}}); err == nil {
+		t.Errorf("expected an error hashing an unserializable policy, got %s", hash)
+	}
+}
+
+func TestStaleObjects(t *testing.T) {
+	upToDate := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "up-to-date"}}
+	SetEffectivePolicyHash(upToDate, "abc")
+	outdated := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "outdated"}}
+	SetEffectivePolicyHash(outdated, "xyz")
+	unannotated := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unannotated"}}
+	skipped := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "skipped"}}
+
+	objs := []machinery.Object{
+		&RuntimeObject{upToDate},
+		&RuntimeObject{outdated},
+		&RuntimeObject{unannotated},
+		&RuntimeObject{skipped},
+	}
+
+	stale := StaleObjects(objs, func(obj machinery.Object) (string, bool) {
+		return "abc", obj.GetName() != "skipped"
+	})
+
+	if len(stale) != 2 {
+		t.Fatalf("expected 2 stale objects, got %d", len(stale))
+	}
+	if stale[0].GetName() != "outdated" || stale[1].GetName() != "unannotated" {
+		t.Errorf("expected outdated and unannotated objects to be stale, got %s and %s", stale[0].GetName(), stale[1].GetName())
+	}
+}
+
+func TestSetGeneratedResourceLabels(t *testing.T) {
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace"},
+	}
+	other := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "other-policy", Namespace: "my-namespace"},
+	}
+
+	obj := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "generated", Labels: map[string]string{"app": "foo"}}}
+	SetGeneratedResourceLabels(obj, GeneratedResourceLabels{
+		ManagedBy:        "my-controller",
+		OwningPolicies:   []machinery.Policy{policy, other},
+		TopologyRevision: "abc",
+	})
+	expectedLabels := map[string]string{
+		"app":                     "foo",
+		ManagedByLabel:            "my-controller",
+		OwningPolicyLabel(policy): "true",
+		OwningPolicyLabel(other):  "true",
+		TopologyRevisionLabel:     "abc",
+	}
+	if len(obj.Labels) != len(expectedLabels) {
+		t.Errorf("expected labels %v, got %v", expectedLabels, obj.Labels)
+	}
+	for key, value := range expectedLabels {
+		if obj.Labels[key] != value {
+			t.Errorf("expected label %s=%s, got %q", key, value, obj.Labels[key])
+		}
+	}
+	if owners := obj.Annotations[OwningPoliciesAnnotation]; owners != "TestPolicy:my-namespace/my-policy,TestPolicy:my-namespace/other-policy" {
+		t.Errorf("unexpected owning policies annotation: %s", owners)
+	}
+	if len(OwningPolicyLabel(policy)) > len(OwningPolicyLabelPrefix)+63 {
+		t.Errorf("owning policy label name too long: %s", OwningPolicyLabel(policy))
+	}
+
+	// owners changed
+	SetGeneratedResourceLabels(obj, GeneratedResourceLabels{OwningPolicies: []machinery.Policy{other}})
+	if _, ok := obj.Labels[OwningPolicyLabel(policy)]; ok {
+		t.Errorf("expected label of former owning policy removed, got %v", obj.Labels)
+	}
+	if obj.Labels[OwningPolicySelector(other)] != "true" || obj.Labels[ManagedByLabel] != "my-controller" {
+		t.Errorf("unexpected labels %v", obj.Labels)
+	}
+}
+
+func TestUnmanagedObjects(t *testing.T) {
+	managed := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "managed"}}
+	unmanaged := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "unmanaged", Annotations: map[string]string{UnmanagedAnnotation: "true"}}}
+
+	if IsUnmanaged(managed) || !IsUnmanaged(unmanaged) || !IsUnmanaged(&RuntimeObject{unmanaged}) {
+		t.Error("unexpected unmanaged state")
+	}
+	objs := []machinery.Object{&RuntimeObject{managed}, &RuntimeObject{unmanaged}}
+	if managedObjs := ManagedObjects(objs); len(managedObjs) != 1 || managedObjs[0].GetName() != "managed" {
+		t.Errorf("expected only the managed object, got %v", managedObjs)
+	}
+	if stale := StaleObjects(objs, func(machinery.Object) (string, bool) { return "abc", true }); len(stale) != 1 || stale[0].GetName() != "managed" {
+		t.Errorf("expected unmanaged object not to be stale, got %v", stale)
+	}
+}
+
+func TestTopologyRevision(t *testing.T) {
+	gateway := machinery.BuildGateway()
+	gateway.ResourceVersion = "1"
+	route := machinery.BuildHTTPRoute()
+	revision := TopologyRevision(machinery.NewGatewayAPITopology(machinery.WithGateways(gateway), machinery.WithHTTPRoutes(route)))
+	if len(revision) != 16 {
+		t.Errorf("expected short revision, got %s", revision)
+	}
+	if r := TopologyRevision(machinery.NewGatewayAPITopology(machinery.WithHTTPRoutes(route), machinery.WithGateways(gateway))); r != revision {
+		t.Errorf("expected same revision for the same objects, got %s and %s", revision, r)
+	}
+	gateway.ResourceVersion = "2"
+	if r := TopologyRevision(machinery.NewGatewayAPITopology(machinery.WithGateways(gateway), machinery.WithHTTPRoutes(route))); r == revision {
+		t.Errorf("expected different revision after an object changed, got %s", r)
+	}
+}
@@ -0,0 +1,154 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// WithHealthProbes opts in to serve the health (/healthz) and readiness (/readyz) probes of the controller on a given
+// address, e.g. ":8081", for the liveness and readiness probes of the pods of the controller (see HealthzHandler and
+// ReadyzHandler). The server is started with the controller and shut down with it.
+func WithHealthProbes(address string) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.healthProbeAddress = address
+	}
+}
+
+// WithDebugHandlers opts in to serve the debug endpoints of the controller on the server of the health probes (see
+// WithHealthProbes), next to the probes:
+//   - /debug/query: read-only queries of the topology (see QueryHandler);
+//   - /debug/assertions: the violations of the assertion rules (see AssertionViolationsHandler);
+//   - /debug/mutation-plan: the plan of the latest reconciliation (see MutationPlanHandler);
+//   - /debug/buildinfo: the build info of the controller (see BuildInfoHandler).
+//
+// The debug endpoints expose the topology of the controller, so the address of the probes should not be reachable
+// from outside the cluster. Binaries with their own debug server can mount the handlers there instead.
+func WithDebugHandlers() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.debugHandlers = true
+	}
+}
+
+// HealthStatus is the health of a controller, as served by its health and readiness probes.
+type HealthStatus struct {
+	// Ready is true once the controller has started and the caches of its runnables have synced, until it shuts down.
+	Ready bool `json:"ready"`
+	// Runnables are the names of the runnables of the controller and whether their caches have synced.
+	// Runnables are only reported once the controller has started.
+	Runnables map[string]bool `json:"runnables,omitempty"`
+	// LastReconciled is the time of the last successful reconciliation, if any.
+	LastReconciled *time.Time `json:"lastReconciled,omitempty"`
+	// Backlog is the work of the controller waiting to be done.
+	Backlog HealthBacklog `json:"backlog"`
+}
+
+// HealthBacklog is the work of a controller waiting to be done.
+type HealthBacklog struct {
+	// RetryEvents is the number of events waiting to be reconciled again after a failed reconciliation.
+	RetryEvents int `json:"retryEvents"`
+	// StatusWrites is the number of status writes waiting to be written by the status reporter, if any.
+	StatusWrites int `json:"statusWrites"`
+}
+
+// health tracks the state of a controller reported by its probes, readable without the lock of the controller, so the
+// probes do not wait for the reconciliation in flight.
+type health struct {
+	started        atomic.Bool
+	lastReconciled atomic.Int64
+	retryEvents    atomic.Int64
+}
+
+// Health returns the health of the controller.
+func (c *Controller) Health() HealthStatus {
+	status := HealthStatus{
+		Ready:   c.health.started.Load() && !c.stopped.Load(),
+		Backlog: HealthBacklog{RetryEvents: int(c.health.retryEvents.Load())},
+	}
+	if c.health.started.Load() {
+		c.runnablesMutex.Lock()
+		status.Runnables = make(map[string]bool, len(c.runnables))
+		for name, runnable := range c.runnables {
+			status.Runnables[name] = runnable.HasSynced()
+		}
+		c.runnablesMutex.Unlock()
+	}
+	if nanos := c.health.lastReconciled.Load(); nanos > 0 {
+		lastReconciled := time.Unix(0, nanos)
+		status.LastReconciled = &lastReconciled
+	}
+	if c.statusReporter != nil {
+		status.Backlog.StatusWrites = c.statusReporter.Len()
+	}
+	return status
+}
+
+// HealthzHandler returns an HTTP handler that serves the health of the controller as JSON, with status 200 while the
+// controller is not shut down, e.g. for liveness probes.
+func (c *Controller) HealthzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		writeHealthStatus(w, c.Health(), !c.stopped.Load())
+	})
+}
+
+// ReadyzHandler returns an HTTP handler that serves the health of the controller as JSON, with status 200 when the
+// controller is ready (see HealthStatus.Ready) and 503 otherwise, e.g. for readiness probes.
+func (c *Controller) ReadyzHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		status := c.Health()
+		writeHealthStatus(w, status, status.Ready)
+	})
+}
+
+func writeHealthStatus(w http.ResponseWriter, status HealthStatus, ok bool) {
+	w.Header().Set("Content-Type", "application/json")
+	if !ok {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	if err := json.NewEncoder(w).Encode(status); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+// serveHealthProbes starts the server of the health probes, if enabled (see WithHealthProbes), along with the debug
+// endpoints, if enabled (see WithDebugHandlers), and returns the function to shut it down.
+func (c *Controller) serveHealthProbes() (stop func()) {
+	if c.healthProbeAddress == "" {
+		if c.debugHandlers {
+			c.logger.Info("not serving the debug endpoints without the server of the health probes")
+		}
+		return func() {}
+	}
+	mux := http.NewServeMux()
+	mux.Handle("/healthz", c.HealthzHandler())
+	mux.Handle("/readyz", c.ReadyzHandler())
+	if c.debugHandlers {
+		c.mountDebugHandlers(mux)
+	}
+	server := &http.Server{Addr: c.healthProbeAddress, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	server.SetKeepAlivesEnabled(false) // probes do not reuse connections, that would only delay the shutdown
+	go func() {
+		c.logger.Info("serving health probes", "address", c.healthProbeAddress)
+		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			c.logger.Error(err, "failed to serve health probes")
+		}
+	}()
+	return func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		if err := server.Shutdown(ctx); err != nil {
+			c.logger.Error(err, "failed to shut down the server of the health probes")
+		}
+	}
+}
+
+// mountDebugHandlers mounts the debug endpoints of the controller on a mux (see WithDebugHandlers).
+func (c *Controller) mountDebugHandlers(mux *http.ServeMux) {
+	mux.Handle("/debug/query", c.QueryHandler())
+	mux.Handle("/debug/assertions", c.AssertionViolationsHandler())
+	mux.Handle("/debug/mutation-plan", c.MutationPlanHandler())
+	mux.Handle("/debug/buildinfo", c.BuildInfoHandler())
+}
@@ -0,0 +1,159 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestControllerHealth(t *testing.T) {
+	failing := true
+	var runnable *gatedRunnable
+	c := NewController(
+		WithRunnable("configmaps", func(controller *Controller) Runnable {
+			runnable = &gatedRunnable{objectsRunnable: objectsRunnable{controller: controller}, release: make(chan struct{})}
+			return runnable
+		}),
+		WithErrorReconcile(func(context.Context, []ResourceEvent, *machinery.Topology) error {
+			if failing {
+				return errors.New("failure")
+			}
+			return nil
+		}),
+	)
+
+	probe := func(handler http.Handler) (int, HealthStatus) {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+		var status HealthStatus
+		if err := json.NewDecoder(recorder.Body).Decode(&status); err != nil {
+			t.Fatalf("failed to decode health status: %v", err)
+		}
+		return recorder.Code, status
+	}
+
+	if code, status := probe(c.ReadyzHandler()); code != http.StatusServiceUnavailable || status.Ready {
+		t.Errorf("expected not ready before the controller starts, got %d %+v", code, status)
+	}
+	if code, _ := probe(c.HealthzHandler()); code != http.StatusOK {
+		t.Errorf("expected healthy before the controller starts, got %d", code)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx)
+	if code, _ := probe(c.ReadyzHandler()); code != http.StatusServiceUnavailable {
+		t.Errorf("expected not ready until the runnables sync, got %d", code)
+	}
+	close(runnable.release)
+	waitFor(t, func() bool { return c.Health().Ready }, "expected ready once the runnables sync")
+
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "default", UID: "my-config"},
+	}
+	c.Lock()
+	c.run([]ResourceEvent{{ConfigMapKind, CreateEvent, nil, configMap}})
+	c.Unlock()
+	code, status := probe(c.ReadyzHandler())
+	if code != http.StatusOK || !status.Runnables["configmaps"] || status.Backlog.RetryEvents != 1 || status.LastReconciled != nil {
+		t.Errorf("expected ready with a failed event to retry, got %d %+v", code, status)
+	}
+
+	failing = false
+	c.Lock()
+	c.run(nil)
+	c.Unlock()
+	if status := c.Health(); status.LastReconciled == nil {
+		t.Errorf("expected the time of the last successful reconciliation, got %+v", status)
+	}
+
+	c.Stop()
+	if code, status := probe(c.ReadyzHandler()); code != http.StatusServiceUnavailable || status.Ready {
+		t.Errorf("expected not ready after shutdown, got %d %+v", code, status)
+	}
+}
+
+func TestControllerHealthProbes(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	c := NewController(WithHealthProbes(address))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx)
+
+	waitFor(t, func() bool {
+		resp, err := http.Get(fmt.Sprintf("http://%s/readyz", address))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, "expected the readiness probe served")
+	resp, err := http.Get(fmt.Sprintf("http://%s/healthz", address))
+	if err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("expected the health probe served, got %v %v", resp, err)
+	}
+	resp.Body.Close()
+
+	c.Stop()
+	if _, err := http.Get(fmt.Sprintf("http://%s/healthz", address)); err == nil {
+		t.Error("expected the server of the probes shut down with the controller")
+	}
+}
+
+func TestControllerDebugHandlers(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := listener.Addr().String()
+	listener.Close()
+
+	rule, err := QueryAssertionRule("gateways", "no gateways allowed", `MATCH (g:Gateway) RETURN g`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := NewController(WithHealthProbes(address), WithDebugHandlers(), WithAssertionRules(rule), WithMutationPlans(false))
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go c.Start(ctx)
+	defer c.Stop()
+
+	waitFor(t, func() bool {
+		resp, err := http.Get(fmt.Sprintf("http://%s/readyz", address))
+		if err != nil {
+			return false
+		}
+		resp.Body.Close()
+		return resp.StatusCode == http.StatusOK
+	}, "expected the readiness probe served")
+
+	for _, path := range []string{"/debug/query?q=MATCH%20(g:Gateway)%20RETURN%20g", "/debug/assertions", "/debug/mutation-plan", "/debug/buildinfo"} {
+		resp, err := http.Get(fmt.Sprintf("http://%s%s", address, path))
+		if err != nil {
+			t.Fatalf("expected %s served, got %v", path, err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "application/json" {
+			t.Errorf("expected %s served as JSON, got %d %s", path, resp.StatusCode, resp.Header.Get("Content-Type"))
+		}
+	}
+}
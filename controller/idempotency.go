@@ -0,0 +1,131 @@
+package controller
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// IdempotencyKey returns a key that identifies an operation with side effects in an external system (e.g. the creation
+// of a DNS record or the request of a certificate) on behalf of a node of a topology, at a given revision of the
+// topology (see TopologyRevision). The key is stable across retries of the reconciliation of the same revision, and
+// changes when the topology changes.
+// The locator identifies the node in the topology, typically its URL.
+func IdempotencyKey(revision, locator, operation string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(revision+"\n"+locator+"\n"+operation)))[:32]
+}
+
+// OperationRecord is the record of an operation with side effects in an external system, completed successfully.
+type OperationRecord struct {
+	Key       string
+	Revision  string
+	Locator   string
+	Operation string
+	// Result is the outcome of the operation, e.g. an identifier of the resource created in the external system.
+	Result    string
+	Timestamp time.Time
+}
+
+// OperationStore keeps the records of the operations with side effects in external systems, indexed by idempotency
+// key. Stores backed by durable storage keep the operations idempotent across restarts of the controller.
+type OperationStore interface {
+	Get(ctx context.Context, key string) (*OperationRecord, bool, error)
+	Put(ctx context.Context, record OperationRecord) error
+}
+
+// Idempotent runs an operation with side effects in an external system on behalf of a node of a topology, unless an
+// operation with the same idempotency key (see IdempotencyKey) was already recorded in the store, in which case the
+// recorded result is returned instead. The idempotency key is passed to the operation, so it can also be forwarded to
+// external systems that support it.
+// Only operations completed successfully are recorded; failed operations run again when the reconciliation is retried.
+func Idempotent(ctx context.Context, store OperationStore, topology *machinery.Topology, locator, operation string, f func(ctx context.Context, key string) (string, error)) (string, error) {
+	revision := TopologyRevision(topology)
+	key := IdempotencyKey(revision, locator, operation)
+
+	record, found, err := store.Get(ctx, key)
+	if err != nil {
+		return "", fmt.Errorf("failed to get the record of operation %s of %s: %w", operation, locator, err)
+	}
+	if found {
+		LoggerFromContext(ctx).V(1).Info("skipping operation already completed", "operation", operation, "locator", locator, "key", key)
+		return record.Result, nil
+	}
+
+	result, err := f(ctx, key)
+	if err != nil {
+		return "", err
+	}
+
+	if err := store.Put(ctx, OperationRecord{
+		Key:       key,
+		Revision:  revision,
+		Locator:   locator,
+		Operation: operation,
+		Result:    result,
+		Timestamp: time.Now(),
+	}); err != nil {
+		return result, fmt.Errorf("failed to record operation %s of %s: %w", operation, locator, err)
+	}
+	return result, nil
+}
+
+// InMemoryOperationStore is an OperationStore that keeps the records in memory, thus idempotency is only guaranteed
+// within the lifetime of the controller.
+type InMemoryOperationStore struct {
+	mu      sync.RWMutex
+	records map[string]OperationRecord
+}
+
+// NewInMemoryOperationStore returns an empty in-memory store of operation records.
+func NewInMemoryOperationStore() *InMemoryOperationStore {
+	return &InMemoryOperationStore{records: make(map[string]OperationRecord)}
+}
+
+func (s *InMemoryOperationStore) Get(_ context.Context, key string) (*OperationRecord, bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	record, found := s.records[key]
+	if !found {
+		return nil, false, nil
+	}
+	return &record, true, nil
+}
+
+func (s *InMemoryOperationStore) Put(_ context.Context, record OperationRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.records[record.Key] = record
+	return nil
+}
+
+// Records returns the records in the store, sorted from the oldest to the latest.
+func (s *InMemoryOperationStore) Records() []OperationRecord {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	records := make([]OperationRecord, 0, len(s.records))
+	for _, record := range s.records {
+		records = append(records, record)
+	}
+	sort.SliceStable(records, func(i, j int) bool { return records[i].Timestamp.Before(records[j].Timestamp) })
+	return records
+}
+
+// Prune deletes the records older than a given time, e.g. of revisions of the topology that will not be reconciled
+// again, and returns the number of records deleted.
+func (s *InMemoryOperationStore) Prune(before time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	pruned := 0
+	for key, record := range s.records {
+		if record.Timestamp.Before(before) {
+			delete(s.records, key)
+			pruned++
+		}
+	}
+	return pruned
+}
@@ -0,0 +1,102 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestIdempotencyKey(t *testing.T) {
+	key := IdempotencyKey("rev1", "gateway.gateway.networking.k8s.io:default/my-gateway", "create-dns-record")
+	if len(key) != 32 {
+		t.Errorf("expected 32-character key, got %s", key)
+	}
+	if k := IdempotencyKey("rev1", "gateway.gateway.networking.k8s.io:default/my-gateway", "create-dns-record"); k != key {
+		t.Errorf("expected stable key, got %s and %s", key, k)
+	}
+	for _, k := range []string{
+		IdempotencyKey("rev2", "gateway.gateway.networking.k8s.io:default/my-gateway", "create-dns-record"),
+		IdempotencyKey("rev1", "gateway.gateway.networking.k8s.io:default/other-gateway", "create-dns-record"),
+		IdempotencyKey("rev1", "gateway.gateway.networking.k8s.io:default/my-gateway", "request-certificate"),
+	} {
+		if k == key {
+			t.Errorf("expected different keys, got %s", k)
+		}
+	}
+}
+
+func TestIdempotent(t *testing.T) {
+	gateway := machinery.BuildGateway()
+	gateway.ResourceVersion = "1"
+	topology := machinery.NewGatewayAPITopology(machinery.WithGateways(gateway))
+	store := NewInMemoryOperationStore()
+	ctx := context.Background()
+	locator := topology.Targetables().Items()[0].GetURL()
+
+	calls := 0
+	var keys []string
+	createRecord := func(_ context.Context, key string) (string, error) {
+		calls++
+		keys = append(keys, key)
+		return "record-1", nil
+	}
+
+	// first reconciliation
+	result, err := Idempotent(ctx, store, topology, locator, "create-dns-record", createRecord)
+	if err != nil || result != "record-1" {
+		t.Fatalf("expected record-1, got %s, %v", result, err)
+	}
+
+	// retry of the same revision of the topology
+	result, err = Idempotent(ctx, store, machinery.NewGatewayAPITopology(machinery.WithGateways(gateway)), locator, "create-dns-record", createRecord)
+	if err != nil || result != "record-1" {
+		t.Fatalf("expected recorded result, got %s, %v", result, err)
+	}
+	if calls != 1 {
+		t.Errorf("expected the operation to run once, ran %d times", calls)
+	}
+
+	// new revision of the topology
+	gateway.ResourceVersion = "2"
+	if _, err := Idempotent(ctx, store, machinery.NewGatewayAPITopology(machinery.WithGateways(gateway)), locator, "create-dns-record", createRecord); err != nil {
+		t.Fatal(err)
+	}
+	if calls != 2 || keys[0] == keys[1] {
+		t.Errorf("expected the operation to run again with a new key, got %d calls with keys %v", calls, keys)
+	}
+
+	records := store.Records()
+	if len(records) != 2 || records[0].Key != keys[0] || records[0].Locator != locator || records[0].Operation != "create-dns-record" || records[0].Result != "record-1" {
+		t.Errorf("unexpected records %+v", records)
+	}
+	if pruned := store.Prune(time.Now().Add(time.Second)); pruned != 2 || len(store.Records()) != 0 {
+		t.Errorf("expected all records pruned, got %d pruned", pruned)
+	}
+}
+
+func TestIdempotentDoesNotRecordFailures(t *testing.T) {
+	topology := machinery.NewGatewayAPITopology(machinery.WithGateways(machinery.BuildGateway()))
+	store := NewInMemoryOperationStore()
+
+	calls := 0
+	failing := func(context.Context, string) (string, error) {
+		calls++
+		return "", errors.New("provider unavailable")
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := Idempotent(context.Background(), store, topology, "locator", "request-certificate", failing); err == nil {
+			t.Error("expected error")
+		}
+	}
+	if calls != 2 {
+		t.Errorf("expected failed operation to run again, ran %d times", calls)
+	}
+	if len(store.Records()) != 0 {
+		t.Errorf("expected no records, got %v", store.Records())
+	}
+}
@@ -0,0 +1,89 @@
+package controller
+
+import (
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Gateway API Inference Extension
+var (
+	InferencePoolKind  = machinery.InferencePoolKind
+	InferenceModelKind = machinery.InferenceModelKind
+
+	InferencePoolsResource  = machinery.InferenceExtensionGroupVersion.WithResource("inferencepools")
+	InferenceModelsResource = machinery.InferenceExtensionGroupVersion.WithResource("inferencemodels")
+)
+
+// WithInferenceExtension adds the InferencePools and InferenceModels of the Gateway API Inference Extension to the
+// topology as targetables, linked from the routes that refer to the pools in their backendRefs (see
+// machinery.WithInferencePools), so policies can attach to them.
+// The resources still need to be watched, e.g. with WithRunnable or WithConditionalRunnable.
+func WithInferenceExtension() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.inferenceExtension = true
+	}
+}
+
+// InferencePoolFromObject converts an InferencePool read from the cluster, typed or unstructured, into the
+// representation of the pool in the topology.
+func InferencePoolFromObject(obj Object) (*machinery.InferencePool, bool) {
+	resource, ok := decodeInferenceExtensionResource[machinery.InferencePoolSpec](obj)
+	if !ok {
+		return nil, false
+	}
+	return &machinery.InferencePool{TypeMeta: resource.TypeMeta, ObjectMeta: resource.ObjectMeta, Spec: resource.Spec}, true
+}
+
+// InferenceModelFromObject converts an InferenceModel read from the cluster, typed or unstructured, into the
+// representation of the model in the topology.
+func InferenceModelFromObject(obj Object) (*machinery.InferenceModel, bool) {
+	resource, ok := decodeInferenceExtensionResource[machinery.InferenceModelSpec](obj)
+	if !ok {
+		return nil, false
+	}
+	return &machinery.InferenceModel{TypeMeta: resource.TypeMeta, ObjectMeta: resource.ObjectMeta, Spec: resource.Spec}, true
+}
+
+// inferenceExtensionResource is a resource of the Gateway API Inference Extension with a spec of a given type
+type inferenceExtensionResource[S any] struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec S `json:"spec"`
+}
+
+func decodeInferenceExtensionResource[S any](obj Object) (*inferenceExtensionResource[S], bool) {
+	var u map[string]interface{}
+	if o, ok := obj.(*unstructured.Unstructured); ok {
+		u = o.Object
+	} else {
+		var err error
+		if u, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, false
+		}
+	}
+	resource := &inferenceExtensionResource[S]{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u, resource); err != nil {
+		return nil, false
+	}
+	return resource, true
+}
+
+// inferenceExtensionTopologyOptions returns the options to add the InferencePools and InferenceModels of a store to a
+// Gateway API topology
+func inferenceExtensionTopologyOptions(objs Store) []machinery.GatewayAPITopologyOptionsFunc {
+	pools := lo.FilterMap(objs.FilterByGroupKind(InferencePoolKind), func(obj Object, _ int) (*machinery.InferencePool, bool) {
+		return InferencePoolFromObject(obj)
+	})
+	models := lo.FilterMap(objs.FilterByGroupKind(InferenceModelKind), func(obj Object, _ int) (*machinery.InferenceModel, bool) {
+		return InferenceModelFromObject(obj)
+	})
+	return []machinery.GatewayAPITopologyOptionsFunc{
+		machinery.WithInferencePools(pools...),
+		machinery.WithInferenceModels(models...),
+	}
+}
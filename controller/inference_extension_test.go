@@ -0,0 +1,73 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func inferenceExtensionUnstructured(kind, name string, spec map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": machinery.InferenceExtensionGroupVersion.String(),
+		"kind":       kind,
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "my-namespace",
+			"uid":       name,
+		},
+		"spec": spec,
+	}}
+}
+
+func TestInferencePoolFromObject(t *testing.T) {
+	pool, ok := InferencePoolFromObject(inferenceExtensionUnstructured(InferencePoolKind.Kind, "my-pool", map[string]interface{}{
+		"selector":         map[string]interface{}{"app": "vllm"},
+		"targetPortNumber": int64(8000),
+	}))
+	if !ok {
+		t.Fatal("expected the inference pool converted")
+	}
+	if pool.GetName() != "my-pool" || pool.Spec.Selector["app"] != "vllm" || pool.Spec.TargetPortNumber != 8000 {
+		t.Errorf("unexpected inference pool: %+v", pool)
+	}
+	if pool.GetURL() != "inferencepool.inference.networking.x-k8s.io:my-namespace/my-pool" {
+		t.Errorf("unexpected URL of the inference pool: %s", pool.GetURL())
+	}
+}
+
+func TestTopologyBuilderWithInferenceExtension(t *testing.T) {
+	route := machinery.BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.Rules[0].BackendRefs[0].Group = ptr.To(gwapiv1.Group(InferencePoolKind.Group))
+		r.Spec.Rules[0].BackendRefs[0].Kind = ptr.To(gwapiv1.Kind(InferencePoolKind.Kind))
+		r.Spec.Rules[0].BackendRefs[0].Name = "my-pool"
+	})
+	store := Store{
+		"route": route,
+		"pool":  inferenceExtensionUnstructured(InferencePoolKind.Kind, "my-pool", map[string]interface{}{}),
+		"model": inferenceExtensionUnstructured(InferenceModelKind.Kind, "my-model", map[string]interface{}{"modelName": "llama", "poolRef": map[string]interface{}{"name": "my-pool"}}),
+	}
+
+	// inference pools watched as object kinds are not added twice
+	c := NewController(WithInferenceExtension(), WithObjectKinds(InferencePoolKind))
+	topology := c.topology.Build(store)
+	pools := machinery.TargetablesOfType[*machinery.InferencePool](topology)
+	if len(pools) != 1 || len(topology.Objects().Items()) != 0 {
+		t.Fatalf("expected 1 inference pool, got %v", pools)
+	}
+	if parents := topology.Targetables().Parents(pools[0]); len(parents) != 1 || parents[0].GetName() != "my-http-route#rule-1" {
+		t.Errorf("expected the inference pool linked from the route rule, got %v", parents)
+	}
+	if children := topology.Targetables().Children(pools[0]); len(children) != 1 || children[0].GetName() != "my-model" {
+		t.Errorf("expected the inference model linked from the pool, got %v", children)
+	}
+
+	if pools := machinery.TargetablesOfType[*machinery.InferencePool](NewController().topology.Build(store)); len(pools) != 0 {
+		t.Errorf("expected no inference pools without the inference extension, got %v", pools)
+	}
+}
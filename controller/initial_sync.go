@@ -0,0 +1,71 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/samber/lo"
+)
+
+// WithInitialSync holds the reconciliations until the caches of all the runnables of the controller have synced, so
+// reconcilers never act on a partially synced state, e.g. deleting downstream resources that are valid but whose
+// owners were not listed yet.
+// The events received before the initial sync only fill the cache. Then the first reconciliation receives one
+// synthetic creation event for each object of the cache, in the order of their kinds, namespaces and names.
+// Runnables added after the controller is started (see AddRunnable) do not hold the reconciliations.
+func WithInitialSync() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.initialSync = true
+	}
+}
+
+// initialSyncEvents returns the synthetic creation events of all the objects of a store.
+func initialSyncEvents(store Store) []ResourceEvent {
+	events := lo.MapToSlice(store, func(_ string, obj Object) ResourceEvent {
+		return ResourceEvent{Kind: obj.GetObjectKind().GroupVersionKind().GroupKind(), EventType: CreateEvent, NewObject: obj}
+	})
+	sort.Slice(events, func(i, j int) bool {
+		a, b := events[i], events[j]
+		if a.Kind != b.Kind {
+			return a.Kind.String() < b.Kind.String()
+		}
+		if a.NewObject.GetNamespace() != b.NewObject.GetNamespace() {
+			return a.NewObject.GetNamespace() < b.NewObject.GetNamespace()
+		}
+		return a.NewObject.GetName() < b.NewObject.GetName()
+	})
+	return events
+}
+
+// completeInitialSync releases the reconciliations held until the initial sync and reconciles the whole state of the
+// cache (see WithInitialSync).
+func (c *Controller) completeInitialSync() {
+	c.Lock()
+	defer c.Unlock()
+
+	if !c.awaitingInitialSync {
+		return
+	}
+	c.awaitingInitialSync = false
+	c.logger.Info("initial sync completed")
+	if events := initialSyncEvents(c.cache.List()); len(events) > 0 {
+		c.run(events)
+	}
+}
+
+type cacheSyncedKey struct{}
+
+// CacheSyncedFromContext returns true if the caches of all the runnables of the controller had synced when the
+// reconciliation started, i.e. the topology reflects the whole state of the world, or if no such information is found
+// in the context.
+// Reconcilers that delete the objects no longer desired should not delete them otherwise, regardless of
+// WithInitialSync, so the owners not listed yet are not mistaken for deleted ones.
+func CacheSyncedFromContext(ctx context.Context) bool {
+	synced, ok := ctx.Value(cacheSyncedKey{}).(bool)
+	return !ok || synced
+}
+
+// CacheSyncedIntoContext returns a new context recording whether the caches of the runnables have synced.
+func CacheSyncedIntoContext(ctx context.Context, synced bool) context.Context {
+	return context.WithValue(ctx, cacheSyncedKey{}, synced)
+}
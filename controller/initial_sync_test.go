@@ -0,0 +1,90 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// gatedRunnable adds a list of objects to the controller when it runs, but only syncs when released
+type gatedRunnable struct {
+	objectsRunnable
+	release chan struct{}
+}
+
+func (r *gatedRunnable) Run(stopCh <-chan struct{}) {
+	for _, obj := range r.objs {
+		r.controller.add(obj)
+	}
+	<-r.release
+	r.mu.Lock()
+	r.synced = true
+	r.mu.Unlock()
+	<-stopCh
+}
+
+func TestControllerInitialSync(t *testing.T) {
+	configMap := func(name string) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+		}
+	}
+
+	var mu sync.Mutex
+	var reconciliations [][]ResourceEvent
+	reconciled := func() [][]ResourceEvent {
+		mu.Lock()
+		defer mu.Unlock()
+		return reconciliations
+	}
+	var runnable *gatedRunnable
+	c := NewController(
+		WithInitialSync(),
+		WithRunnable("configmaps", func(controller *Controller) Runnable {
+			runnable = &gatedRunnable{
+				objectsRunnable: objectsRunnable{controller: controller, objs: []Object{configMap("b"), configMap("a")}},
+				release:         make(chan struct{}),
+			}
+			return runnable
+		}),
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			reconciliations = append(reconciliations, events)
+		}),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		if err := c.Start(ctx); err != nil {
+			t.Errorf("expected no error when starting the controller, got %s", err.Error())
+		}
+	}()
+
+	waitFor(t, func() bool { return len(c.cache.List()) == 2 }, "expected the objects of the runnable to be cached")
+	if r := reconciled(); len(r) != 0 {
+		t.Fatalf("expected no reconciliation before the initial sync, got %v", r)
+	}
+
+	close(runnable.release)
+	waitFor(t, func() bool { return len(reconciled()) > 0 }, "expected a reconciliation after the initial sync")
+	events := reconciled()[0]
+	if len(events) != 2 {
+		t.Fatalf("expected 2 initial sync events, got %v", events)
+	}
+	for i, name := range []string{"a", "b"} {
+		if events[i].EventType != CreateEvent || events[i].NewObject.GetName() != name {
+			t.Errorf("expected creation event of %s, got %v", name, events[i])
+		}
+	}
+}
@@ -0,0 +1,206 @@
+package controller
+
+import (
+	"encoding/json"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/controller/metrics"
+)
+
+const (
+	// eventRateWindow is the period of time over which the event rates of the runnables are averaged
+	eventRateWindow = time.Minute
+	// inventoryMetricsInterval is the minimum period of time between updates of the metrics of the inventory while
+	// reconciling, as sizing the objects is not free
+	inventoryMetricsInterval = 10 * time.Second
+)
+
+// RunnableInventory is the resource accounting of a runnable of the controller, e.g. to predict the sizing of the
+// controller as more kinds of resources are watched.
+type RunnableInventory struct {
+	// Name of the runnable
+	Name string
+	// Resources watched by the runnable
+	Resources []schema.GroupVersionResource
+	// Objects is the number of objects cached of the resources watched by the runnable
+	Objects int
+	// ApproximateBytes is the size of the objects cached of the resources watched by the runnable, as JSON
+	ApproximateBytes int
+	// Events is the number of events of the resources watched by the runnable since the controller was created
+	Events int64
+	// EventsPerSecond is the rate of events of the resources watched by the runnable, averaged over the last minute
+	EventsPerSecond float64
+}
+
+// Inventory returns the resource accounting of the runnables of the controller, sorted by name.
+// The objects and events of a resource watched by more than one runnable are accounted for in each of them.
+// If the controller has metrics (see WithMetrics), they are updated with the numbers and sizes of the objects.
+func (c *Controller) Inventory() []RunnableInventory {
+	return c.inventory.list(c.cache.List(), time.Now(), c.metrics)
+}
+
+// observeInventory updates the metrics of the inventory of the runnables, if the controller has metrics and they were
+// not updated recently.
+func (c *Controller) observeInventory() {
+	if c.metrics == nil {
+		return
+	}
+	now := time.Now()
+	if !c.inventory.observationDue(now) {
+		return
+	}
+	c.inventory.list(c.cache.List(), now, c.metrics)
+}
+
+// inventory accounts for the objects and events of the resources watched by the runnables of a controller.
+// It does not share the mutexes of the controller, so it can be read while reconciling and while adding or removing
+// runnables.
+type inventory struct {
+	sync.Mutex
+	resources map[string][]schema.GroupVersionResource
+	events    map[string]*eventCounter
+	observed  time.Time
+}
+
+func newInventory() *inventory {
+	return &inventory{
+		resources: map[string][]schema.GroupVersionResource{},
+		events:    map[string]*eventCounter{},
+	}
+}
+
+// track starts accounting for a runnable and the resources it watches
+func (i *inventory) track(name string, resources []schema.GroupVersionResource) {
+	i.Lock()
+	defer i.Unlock()
+	i.resources[name] = resources
+	i.events[name] = &eventCounter{}
+}
+
+// untrack stops accounting for a runnable
+func (i *inventory) untrack(name string) {
+	i.Lock()
+	defer i.Unlock()
+	delete(i.resources, name)
+	delete(i.events, name)
+}
+
+// recordEvent records an event of an object of a kind in the runnables that watch the corresponding resource,
+// returning the names of the runnables
+func (i *inventory) recordEvent(gvk schema.GroupVersionKind, now time.Time) []string {
+	i.Lock()
+	defer i.Unlock()
+	runnables := i.runnablesFor(gvk)
+	for _, name := range runnables {
+		i.events[name].add(now)
+	}
+	return runnables
+}
+
+// observationDue tells whether the metrics of the inventory were last updated longer ago than the metrics interval
+func (i *inventory) observationDue(now time.Time) bool {
+	i.Lock()
+	defer i.Unlock()
+	return now.Sub(i.observed) >= inventoryMetricsInterval
+}
+
+// list returns the accounting of the runnables given the objects of the store, recording it in the metrics
+func (i *inventory) list(store Store, now time.Time, m *metrics.Metrics) []RunnableInventory {
+	i.Lock()
+	defer i.Unlock()
+
+	inventories := make(map[string]*RunnableInventory, len(i.resources))
+	for name, resources := range i.resources {
+		events := i.events[name]
+		inventories[name] = &RunnableInventory{
+			Name:            name,
+			Resources:       resources,
+			Events:          events.total,
+			EventsPerSecond: events.rate(now),
+		}
+	}
+	for _, obj := range store {
+		runnables := i.runnablesFor(obj.GetObjectKind().GroupVersionKind())
+		if len(runnables) == 0 {
+			continue
+		}
+		size := approximateSize(obj)
+		for _, name := range runnables {
+			inventories[name].Objects++
+			inventories[name].ApproximateBytes += size
+		}
+	}
+
+	if m != nil {
+		m.ObserveRunnableInventory(
+			lo.MapValues(inventories, func(inventory *RunnableInventory, _ string) int { return inventory.Objects }),
+			lo.MapValues(inventories, func(inventory *RunnableInventory, _ string) int { return inventory.ApproximateBytes }),
+		)
+		i.observed = now
+	}
+
+	list := lo.Map(lo.Values(inventories), func(inventory *RunnableInventory, _ int) RunnableInventory { return *inventory })
+	sort.Slice(list, func(a, b int) bool { return list[a].Name < list[b].Name })
+	return list
+}
+
+// runnablesFor returns the names of the runnables that watch the resource of a kind.
+// The kind is mapped to a resource by guessing its plural (see meta.UnsafeGuessKindToResource), as the resources are
+// not discovered.
+// It must be called with the mutex held.
+func (i *inventory) runnablesFor(gvk schema.GroupVersionKind) []string {
+	resource, _ := meta.UnsafeGuessKindToResource(gvk)
+	var runnables []string
+	for name, resources := range i.resources {
+		if lo.ContainsBy(resources, func(r schema.GroupVersionResource) bool { return r.GroupResource() == resource.GroupResource() }) {
+			runnables = append(runnables, name)
+		}
+	}
+	return runnables
+}
+
+// approximateSize returns the size of an object serialized as JSON
+func approximateSize(obj Object) int {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return 0
+	}
+	return len(b)
+}
+
+// eventCounter counts events in total and per second over the event rate window
+type eventCounter struct {
+	total   int64
+	buckets [int(eventRateWindow / time.Second)]struct {
+		second int64
+		count  int64
+	}
+}
+
+func (e *eventCounter) add(now time.Time) {
+	e.total++
+	second := now.Unix()
+	bucket := &e.buckets[second%int64(len(e.buckets))]
+	if bucket.second != second {
+		bucket.second = second
+		bucket.count = 0
+	}
+	bucket.count++
+}
+
+func (e *eventCounter) rate(now time.Time) float64 {
+	second := now.Unix()
+	var count int64
+	for _, bucket := range e.buckets {
+		if second-bucket.second < int64(len(e.buckets)) {
+			count += bucket.count
+		}
+	}
+	return float64(count) / eventRateWindow.Seconds()
+}
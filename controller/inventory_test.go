@@ -0,0 +1,82 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestInventory(t *testing.T) {
+	configMapsResource := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	watchConfigMaps := func(controller *Controller) Runnable {
+		controller.watching(configMapsResource)
+		return &objectsRunnable{controller: controller}
+	}
+	watchServices := func(controller *Controller) Runnable {
+		controller.watching(ServicesResource)
+		return &objectsRunnable{controller: controller}
+	}
+	c := NewController(WithRunnable("configmaps", watchConfigMaps), WithRunnable("services", watchServices))
+
+	for _, name := range []string{"config-1", "config-2"} {
+		c.add(&corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID("uid-" + name)},
+			Data:       map[string]string{"key": "value"},
+		})
+	}
+
+	inventory := c.Inventory()
+	if len(inventory) != 2 {
+		t.Fatalf("expected 2 runnables, got %d", len(inventory))
+	}
+	configMaps, services := inventory[0], inventory[1]
+	if configMaps.Name != "configmaps" || services.Name != "services" {
+		t.Fatalf("expected the runnables sorted by name, got %s and %s", configMaps.Name, services.Name)
+	}
+	if configMaps.Objects != 2 || configMaps.ApproximateBytes == 0 {
+		t.Errorf("expected 2 config maps accounted for, got %d objects of %d bytes", configMaps.Objects, configMaps.ApproximateBytes)
+	}
+	if configMaps.Events != 2 || configMaps.EventsPerSecond == 0 {
+		t.Errorf("expected 2 recent events of config maps, got %d at %v/s", configMaps.Events, configMaps.EventsPerSecond)
+	}
+	if services.Objects != 0 || services.Events != 0 {
+		t.Errorf("expected no services accounted for, got %d objects and %d events", services.Objects, services.Events)
+	}
+
+	if err := c.RemoveRunnable("services"); err != nil {
+		t.Fatal(err)
+	}
+	if inventory := c.Inventory(); len(inventory) != 1 {
+		t.Errorf("expected 1 runnable after removing one, got %d", len(inventory))
+	}
+}
+
+func TestEventCounter(t *testing.T) {
+	now := time.Unix(1000, 0)
+	counter := &eventCounter{}
+	for i := 0; i < 30; i++ {
+		counter.add(now.Add(time.Duration(i) * time.Second))
+	}
+	counter.add(now.Add(29 * time.Second))
+
+	if counter.total != 31 {
+		t.Errorf("expected 31 events, got %d", counter.total)
+	}
+	if rate := counter.rate(now.Add(29 * time.Second)); rate != 31.0/60 {
+		t.Errorf("expected a rate of 31 events per minute, got %v/s", rate)
+	}
+	// the first 15 seconds fall out of the window
+	if rate := counter.rate(now.Add(74 * time.Second)); rate != 16.0/60 {
+		t.Errorf("expected a rate of 16 events per minute, got %v/s", rate)
+	}
+	if rate := counter.rate(now.Add(10 * time.Minute)); rate != 0 {
+		t.Errorf("expected no recent events, got %v/s", rate)
+	}
+}
@@ -2,10 +2,13 @@ package controller
 
 import (
 	"context"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"github.com/go-logr/zapr"
+	"github.com/samber/lo"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 	"k8s.io/klog/v2"
 
 	ctrlruntime "sigs.k8s.io/controller-runtime"
@@ -24,6 +27,89 @@ func CreateAndSetLogger() logr.Logger {
 	return logger
 }
 
+// LogEncoding is the encoding of the entries of a logger.
+type LogEncoding string
+
+const (
+	// LogEncodingJSON encodes each entry as a JSON object, e.g. to be collected by log aggregators.
+	LogEncodingJSON LogEncoding = "json"
+	// LogEncodingConsole encodes each entry as human-readable text, e.g. for development.
+	LogEncodingConsole LogEncoding = "console"
+)
+
+// NewLogger returns a new structured logger that logs the entries up to a verbosity level in a given encoding.
+// Level 0 logs the info entries and the errors only; higher levels also log the entries of logger.V(level) and below.
+func NewLogger(level int, encoding LogEncoding) logr.Logger {
+	config := zap.NewProductionConfig()
+	config.Level = zap.NewAtomicLevelAt(zapcore.Level(-level))
+	config.Encoding = string(encoding)
+	config.DisableCaller = true
+	zapLogger, err := config.Build()
+	if err != nil {
+		return logr.Discard()
+	}
+	return zapr.NewLogger(zapLogger)
+}
+
+// WithLogLevel sets the verbosity level of the logger of the controller (see NewLogger), instead of the logger set
+// with WithLogger. The entries are encoded as JSON, unless set otherwise with WithLogEncoding.
+func WithLogLevel(level int) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.logLevel = &level
+	}
+}
+
+// WithLogEncoding sets the encoding of the entries of the logger of the controller (see NewLogger), instead of the
+// logger set with WithLogger. The verbosity level is 0, unless set otherwise with WithLogLevel.
+func WithLogEncoding(encoding LogEncoding) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.logEncoding = encoding
+	}
+}
+
+// WithTopologyDumps logs the topology of every reconciliation as a Graphviz DOT graph (see machinery.Topology.ToDot)
+// at a given verbosity level of the logger of the controller, e.g. 2 to dump the topologies only when debugging.
+// The topology is only dumped if the logger is enabled at that level.
+func WithTopologyDumps(level int) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.topologyDumpLevel = &level
+	}
+}
+
+// newControllerLogger returns the logger configured with WithLogLevel and WithLogEncoding, or false if none is.
+func newControllerLogger(level *int, encoding LogEncoding) (logr.Logger, bool) {
+	if level == nil && encoding == "" {
+		return logr.Logger{}, false
+	}
+	if encoding == "" {
+		encoding = LogEncodingJSON
+	}
+	var verbosity int
+	if level != nil {
+		verbosity = *level
+	}
+	return NewLogger(verbosity, encoding), true
+}
+
+// reconcilerLoggerIntoContext returns a new context with a child of the logger of the context named after a
+// reconciler, e.g. a task of a workflow or the reconciliation function of a subscription, so the entries of each
+// reconciler can be told apart. The reconcilers of the library that only dispatch to other reconcilers (workflows and
+// subscriptions) are not named.
+func reconcilerLoggerIntoContext(ctx context.Context, name string) context.Context {
+	name = strings.TrimSuffix(name, "-fm")
+	if lo.Contains(dispatchingReconcilers, name) {
+		return ctx
+	}
+	return LoggerIntoContext(ctx, LoggerFromContext(ctx).WithName(name))
+}
+
+var dispatchingReconcilers = []string{
+	"controller.Subscription.Reconcile",
+	"controller.(*Workflow).Run",
+	"controller.(*ErrorWorkflow).Run",
+	"controller.(*DAGWorkflow).Run",
+}
+
 // LoggerFromContext returns the logger from the context, or a discard logger if
 // no logger is found.
 func LoggerFromContext(ctx context.Context) logr.Logger {
@@ -0,0 +1,119 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"github.com/go-logr/logr/funcr"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// recordingLogger returns a logger that records the prefixes (names) and messages of its entries
+func recordingLogger(verbosity int) (logr.Logger, func() []string) {
+	var mu sync.Mutex
+	var entries []string
+	logger := funcr.New(func(prefix, args string) {
+		mu.Lock()
+		defer mu.Unlock()
+		entries = append(entries, prefix+" "+args)
+	}, funcr.Options{Verbosity: verbosity})
+	return logger, func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return entries
+	}
+}
+
+func reconcileToys(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) {
+	LoggerFromContext(ctx).Info("reconciling toys")
+}
+
+func TestNewLogger(t *testing.T) {
+	for _, encoding := range []LogEncoding{LogEncodingJSON, LogEncodingConsole} {
+		logger := NewLogger(2, encoding)
+		if !logger.V(2).Enabled() || logger.V(3).Enabled() {
+			t.Errorf("expected the %s logger enabled up to level 2", encoding)
+		}
+	}
+	if logger, ok := newControllerLogger(nil, ""); ok {
+		t.Errorf("expected no controller logger without level nor encoding, got %v", logger)
+	}
+	level := 1
+	if logger, ok := newControllerLogger(&level, ""); !ok || !logger.V(1).Enabled() {
+		t.Error("expected a controller logger at level 1")
+	}
+}
+
+func TestReconcilerLoggers(t *testing.T) {
+	logger, entries := recordingLogger(0)
+	c := NewController(
+		WithLogger(logger),
+		WithReconcile((&Workflow{
+			Tasks: []ReconcileFunc{
+				reconcileToys,
+				Subscription{ReconcileFunc: reconcileToys, Predicates: []EventPredicate{func(ResourceEvent) bool { return true }}}.Reconcile,
+			},
+		}).Run),
+	)
+	c.Lock()
+	c.run([]ResourceEvent{{Kind: ConfigMapKind, EventType: CreateEvent, NewObject: shutdownConfigMap("a")}})
+	c.Unlock()
+
+	toys := 0
+	for _, entry := range entries() {
+		if strings.Contains(entry, "reconciling toys") {
+			toys++
+			if !strings.HasPrefix(entry, "controller.reconcileToys ") {
+				t.Errorf("expected the entry of the task named after the reconciliation function, got %q", entry)
+			}
+		}
+	}
+	if toys != 2 {
+		t.Errorf("expected 2 entries of the toys reconciler, got %v", entries())
+	}
+
+	logger, entries = recordingLogger(0)
+	dag := &DAGWorkflow{
+		Tasks: []WorkflowTask{{
+			Name: "dolls",
+			Reconcile: func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) error {
+				LoggerFromContext(ctx).Info("reconciling dolls")
+				return nil
+			},
+		}},
+	}
+	if err := dag.Run(LoggerIntoContext(context.TODO(), logger), nil, nil); err != nil {
+		t.Fatal(err)
+	}
+	if e := entries(); len(e) != 1 || !strings.HasPrefix(e[0], "dolls ") {
+		t.Errorf("expected the entry of the task named after the task, got %v", e)
+	}
+}
+
+func TestTopologyDumps(t *testing.T) {
+	for _, tc := range []struct {
+		verbosity int
+		dumped    bool
+	}{{verbosity: 1, dumped: false}, {verbosity: 2, dumped: true}} {
+		logger, entries := recordingLogger(tc.verbosity)
+		c := NewController(WithLogger(logger), WithTopologyDumps(2))
+		c.Lock()
+		c.run(nil)
+		c.Unlock()
+		dumped := false
+		for _, entry := range entries() {
+			if strings.Contains(entry, `"msg"="topology"`) && strings.Contains(entry, "digraph") {
+				dumped = true
+			}
+		}
+		if dumped != tc.dumped {
+			t.Errorf("expected topology dumped=%t at verbosity %d, got %v", tc.dumped, tc.verbosity, entries())
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// ManagementState is the controller-wide state of management of the resources, in the sense of the management states
+// of the operators built with the operator-sdk.
+type ManagementState string
+
+const (
+	// ManagementStateManaged is the default state, where the controller reconciles the resources.
+	ManagementStateManaged ManagementState = "Managed"
+	// ManagementStateUnmanaged is the state where the controller keeps watching the resources, building topologies
+	// and calling the reconcile functions, but performs no writes: objects applied with ApplyObject are only applied in
+	// dry-run mode, finalizers are neither added nor removed, restore plans are not applied, and neither garbage
+	// collection, status feedback, pause statuses nor finalizations run.
+	ManagementStateUnmanaged ManagementState = "Unmanaged"
+	// ManagementStateRemoved is the state where the controller no longer calls the reconcile functions, deletes the
+	// generated resources of the kinds it garbage collects (see WithGarbageCollection) and removes its finalizers from
+	// the policies (see WithFinalization), and then stops.
+	ManagementStateRemoved ManagementState = "Removed"
+)
+
+// WithManagementState sets the initial management state of the controller. Defaults to ManagementStateManaged.
+// The state can be changed while the controller runs with SetManagementState.
+func WithManagementState(state ManagementState) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.managementState = state
+	}
+}
+
+// ManagementState returns the management state of the controller.
+func (c *Controller) ManagementState() ManagementState {
+	c.managementStateMutex.Lock()
+	defer c.managementStateMutex.Unlock()
+	return c.managementState
+}
+
+// SetManagementState changes the management state of the controller, e.g. after the management state of the custom
+// resource of an operator is changed. If the controller has started, the topology is reconciled in the new state
+// right away; if the new state is ManagementStateRemoved, the controller stops once the cleanup succeeds.
+// SetManagementState can be called from reconcile functions.
+func (c *Controller) SetManagementState(state ManagementState) error {
+	if !lo.Contains([]ManagementState{ManagementStateManaged, ManagementStateUnmanaged, ManagementStateRemoved}, state) {
+		return fmt.Errorf("%w: %q", ErrInvalidManagementState, state)
+	}
+	c.managementStateMutex.Lock()
+	previous := c.managementState
+	c.managementState = state
+	c.managementStateMutex.Unlock()
+
+	if previous == state {
+		return nil
+	}
+	c.logger.Info("management state changed", "from", previous, "to", state)
+
+	c.runnablesMutex.Lock()
+	started := c.stopCh != nil
+	c.runnablesMutex.Unlock()
+	if started {
+		go func() {
+			c.Lock()
+			defer c.Unlock()
+			c.run(nil)
+		}()
+	}
+	return nil
+}
+
+type managementStateKey struct{}
+
+// ManagementStateFromContext returns the management state of the controller from the context, or
+// ManagementStateManaged if no state is found.
+// Reconcilers that write with clients of their own, rather than with the helpers of this package, should check it
+// before writing.
+func ManagementStateFromContext(ctx context.Context) ManagementState {
+	state, ok := ctx.Value(managementStateKey{}).(ManagementState)
+	if !ok {
+		return ManagementStateManaged
+	}
+	return state
+}
+
+// ManagementStateIntoContext returns a new context with the management state set.
+func ManagementStateIntoContext(ctx context.Context, state ManagementState) context.Context {
+	return context.WithValue(ctx, managementStateKey{}, state)
+}
+
+// IsUnmanagedState returns true if the management state of the controller in the context is
+// ManagementStateUnmanaged, i.e. writes must be skipped.
+func IsUnmanagedState(ctx context.Context) bool {
+	return ManagementStateFromContext(ctx) == ManagementStateUnmanaged
+}
+
+// removed signals that the controller cleaned up after being set to ManagementStateRemoved, so it stops
+func (c *Controller) removed() {
+	c.removedOnce.Do(func() { close(c.removedCh) })
+}
+
+// remove cleans up the resources managed by the controller in the removed management state, and stops the
+// controller once the cleanup succeeds.
+// It must be called with the lock held.
+func (c *Controller) remove(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) error {
+	if err := removeManagedResources(ctx, c.resourceClient, c.garbageCollections, c.finalizations, topology, c.cache.List()); err != nil {
+		c.retry(resourceEvents, err)
+		return err
+	}
+	c.removed()
+	return nil
+}
+
+// removeManagedResources deletes the generated resources of the kinds garbage collected and removes the finalizers
+// from the policies, as the cleanup of a controller set to ManagementStateRemoved.
+func removeManagedResources(ctx context.Context, client func(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface, collections []GarbageCollection, finalizations []Finalization, topology *machinery.Topology, store Store) error {
+	logger := LoggerFromContext(ctx).WithName("removal")
+
+	var errs []error
+	for _, collection := range collections {
+		generated := topology.Objects().Items(func(o machinery.Object) bool {
+			return o.GroupVersionKind().GroupKind() == collection.GeneratedKind && (collection.ManagedBy == "" || objectLabels(o)[ManagedByLabel] == collection.ManagedBy)
+		})
+		for _, obj := range ManagedObjects(generated) {
+			err := client(collection.GeneratedResource).Namespace(obj.GetNamespace()).Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
+			if err != nil && !apierrors.IsNotFound(err) {
+				errs = append(errs, fmt.Errorf("failed to delete %s: %w", obj.GetURL(), wrapAPIError(err)))
+				continue
+			}
+			logger.V(1).Info("generated resource deleted", "object", obj.GetURL())
+		}
+	}
+	for _, finalization := range finalizations {
+		for _, policy := range store.FilterByGroupKind(finalization.PolicyKind) {
+			updated, err := RemoveFinalizer(ctx, client(finalization.PolicyResource), policy, finalization.Finalizer)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if updated {
+				logger.V(1).Info("finalizer removed", "policy", namespacedName(policy), "finalizer", finalization.Finalizer)
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
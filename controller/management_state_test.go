@@ -0,0 +1,216 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestUnmanagedStateWrites(t *testing.T) {
+	ctx := ManagementStateIntoContext(context.TODO(), ManagementStateUnmanaged)
+	configMapsResource := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	fakeClient := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{configMapsResource: "ConfigMapList"})
+	client := &applyRecorder{ResourceInterface: fakeClient.Resource(configMapsResource).Namespace("my-namespace")}
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", Namespace: "my-namespace"},
+		Data:       map[string]string{"key": "value"},
+	}
+
+	obj, err := ApplyObject(ctx, client, configMap)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if value, _, _ := unstructured.NestedString(obj.Object, "data", "key"); value != "value" {
+		t.Errorf("expected the object as it would be applied, got %v", obj.Object)
+	}
+	if options := client.options[len(client.options)-1]; len(options.DryRun) == 0 {
+		t.Errorf("expected a dry-run apply, got %+v", options)
+	}
+	if _, err := client.Get(ctx, "my-configmap", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the object not to be created, got %v", err)
+	}
+
+	written := false
+	if err := ReportStatus(ctx, "my-configmap", func(context.Context) error { written = true; return nil }); err != nil || written {
+		t.Errorf("expected no status written, got written=%t, err=%v", written, err)
+	}
+
+	if updated, err := EnsureFinalizer(ctx, fakeClient.Resource(configMapsResource), configMap, "test/finalizer"); err != nil || updated {
+		t.Errorf("expected no finalizer added, got updated=%t, err=%v", updated, err)
+	}
+
+	plan := &RestorePlan{Creates: []ResourceChange{{Resource: configMapsResource, Object: lo.Must(Destruct(*configMap))}}}
+	if err := plan.Apply(ctx, fakeClient); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Get(ctx, "my-configmap", metav1.GetOptions{}); !apierrors.IsNotFound(err) {
+		t.Errorf("expected the restore plan not to be applied, got %v", err)
+	}
+}
+
+func TestRemovedState(t *testing.T) {
+	const finalizer = "test/finalizer"
+	generatedResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "generateds"}
+	generatedKind := schema.GroupKind{Group: "test", Kind: "Generated"}
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+
+	generated := func(name, managedBy string, annotations map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("test/v1")
+		obj.SetKind(generatedKind.Kind)
+		obj.SetNamespace("my-namespace")
+		obj.SetName(name)
+		obj.SetUID(k8stypes.UID(name))
+		obj.SetAnnotations(annotations)
+		SetGeneratedResourceLabels(obj, GeneratedResourceLabels{ManagedBy: managedBy})
+		return obj
+	}
+	objs := []*unstructured.Unstructured{
+		generated("managed", "test", nil),
+		generated("unmanaged", "test", map[string]string{UnmanagedAnnotation: "true"}),
+		generated("other-controller", "other", nil),
+	}
+	policy := &unstructured.Unstructured{}
+	policy.SetAPIVersion("test/v1")
+	policy.SetKind(policyKind.Kind)
+	policy.SetNamespace("my-namespace")
+	policy.SetName("my-policy")
+	policy.SetUID("my-policy")
+	policy.SetFinalizers([]string{finalizer})
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{generatedResource: "GeneratedList", policyResource: "TestPolicyList"},
+		append(lo.Map(objs, func(obj *unstructured.Unstructured, _ int) runtime.Object { return obj.DeepCopy() }), policy.DeepCopy())...,
+	)
+
+	reconciled := false
+	c := NewController(
+		WithClient(client),
+		WithObjectKinds(generatedKind, policyKind),
+		WithGarbageCollection(GarbageCollection{GeneratedKind: generatedKind, GeneratedResource: generatedResource, ManagedBy: "test"}),
+		WithFinalization(Finalization{PolicyKind: policyKind, PolicyResource: policyResource, Finalizer: finalizer}),
+		WithManagementState(ManagementStateUnmanaged),
+		WithReconcile(func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) {
+			reconciled = true
+			if state := ManagementStateFromContext(ctx); state != ManagementStateUnmanaged {
+				t.Errorf("expected the unmanaged state in the context, got %s", state)
+			}
+		}),
+	)
+	for _, obj := range objs {
+		c.cache.Add(obj)
+	}
+	c.cache.Add(policy)
+
+	if err := c.SetManagementState("Deleted"); !errors.Is(err, ErrInvalidManagementState) {
+		t.Errorf("expected an invalid management state error, got %v", err)
+	}
+
+	// unmanaged
+	c.Lock()
+	if err := c.run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Unlock()
+	if !reconciled {
+		t.Error("expected the reconcile function to be called in the unmanaged state")
+	}
+
+	// removed
+	reconciled = false
+	if err := c.SetManagementState(ManagementStateRemoved); err != nil {
+		t.Fatal(err)
+	}
+	c.Lock()
+	if err := c.run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Unlock()
+	if reconciled {
+		t.Error("expected the reconcile function not to be called in the removed state")
+	}
+	for _, obj := range objs {
+		_, err := client.Resource(generatedResource).Namespace("my-namespace").Get(context.TODO(), obj.GetName(), metav1.GetOptions{})
+		deleted := apierrors.IsNotFound(err)
+		if expected := obj.GetName() == "managed"; deleted != expected {
+			t.Errorf("expected %s deleted to be %t, got %t", obj.GetName(), expected, deleted)
+		}
+	}
+	current, err := client.Resource(policyResource).Namespace("my-namespace").Get(context.TODO(), "my-policy", metav1.GetOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if HasFinalizer(current, finalizer) {
+		t.Errorf("expected the finalizer removed, got %v", current.GetFinalizers())
+	}
+	select {
+	case <-c.removedCh:
+	default:
+		t.Error("expected the controller to be stopped")
+	}
+}
+
+func TestUnmanagedStatePolicyHooks(t *testing.T) {
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	gateway := machinery.BuildGateway()
+	gateway.SetGroupVersionKind(gwapiv1.SchemeGroupVersion.WithKind("Gateway"))
+	gateway.UID = "my-gateway"
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: policyKind.Kind},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", UID: "my-policy"},
+		Spec: machinery.TestPolicySpec{
+			TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"},
+			},
+		},
+	}
+
+	var attached []string
+	c := NewController(
+		WithPolicyKinds(policyKind),
+		WithManagementState(ManagementStateUnmanaged),
+		WithPolicyHooks(policyKind, PolicyHooks{
+			OnAttached: func(_ context.Context, policy machinery.Policy, _ []machinery.Targetable, _ *machinery.Topology) {
+				attached = append(attached, policy.GetName())
+			},
+		}),
+	)
+	c.cache.Add(gateway)
+	c.cache.Add(&runtimePolicy{policy})
+
+	c.Lock()
+	c.run(nil)
+	c.Unlock()
+	if len(attached) != 0 {
+		t.Fatalf("expected no hooks invoked in the unmanaged state, got %v", attached)
+	}
+
+	// the changes are reported once managed again
+	if err := c.SetManagementState(ManagementStateManaged); err != nil {
+		t.Fatal(err)
+	}
+	c.Lock()
+	c.run(nil)
+	c.Unlock()
+	if len(attached) != 1 {
+		t.Errorf("expected the policy reported as attached once managed, got %v", attached)
+	}
+}
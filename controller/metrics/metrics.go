@@ -0,0 +1,252 @@
+// Package metrics provides the Prometheus metrics of the reconciliation cycles of a controller and of the size of the
+// topologies it builds.
+package metrics
+
+import (
+	"errors"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Results of reconciliation cycles, as reported in the metrics
+const (
+	ResultSuccess = "success"
+	ResultError   = "error"
+)
+
+// Types of nodes of the topology, as reported in the metrics
+const (
+	NodeTargetable = "targetable"
+	NodePolicy     = "policy"
+	NodeObject     = "object"
+)
+
+// Metrics are the metrics of a controller. The zero value is not usable; use New.
+// Methods of a nil Metrics are no-ops, so controllers can record metrics unconditionally.
+type Metrics struct {
+	reconcileDuration *prometheus.HistogramVec
+	events            *prometheus.CounterVec
+	queueDepth        prometheus.Gauge
+	topologyNodes     *prometheus.GaugeVec
+	topologyEdges     prometheus.Gauge
+	policyAttachments *prometheus.GaugeVec
+	violations        *prometheus.GaugeVec
+	runnableObjects   *prometheus.GaugeVec
+	runnableBytes     *prometheus.GaugeVec
+	runnableEvents    *prometheus.CounterVec
+	apiWarnings       *prometheus.CounterVec
+}
+
+// New returns the metrics of a controller, not registered yet.
+func New() *Metrics {
+	return &Metrics{
+		reconcileDuration: prometheus.NewHistogramVec(
+			prometheus.HistogramOpts{
+				Name:    "policy_machinery_reconcile_duration_seconds",
+				Help:    "Duration of the reconciliation cycles, per result",
+				Buckets: prometheus.ExponentialBuckets(0.001, 2, 16),
+			},
+			[]string{"result"},
+		),
+		events: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "policy_machinery_events_total",
+				Help: "Number of events of the watched resources, per kind of resource and type of event",
+			},
+			[]string{"group", "version", "kind", "type"},
+		),
+		queueDepth: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_queue_depth",
+				Help: "Number of events waiting to be reconciled again after a failed reconciliation",
+			},
+		),
+		topologyNodes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_topology_nodes",
+				Help: "Number of nodes of the last topology built, per type of node and kind",
+			},
+			[]string{"node", "group", "kind"},
+		),
+		topologyEdges: prometheus.NewGauge(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_topology_edges",
+				Help: "Number of links between nodes of the last topology built",
+			},
+		),
+		policyAttachments: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_policy_attachments",
+				Help: "Number of targetables that policies are attached to in the last topology built, per kind of policy",
+			},
+			[]string{"group", "kind"},
+		),
+		violations: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_assertion_violations",
+				Help: "Number of nodes of the last topology built that violate an assertion rule, per rule",
+			},
+			[]string{"rule"},
+		),
+		runnableObjects: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_runnable_objects",
+				Help: "Number of objects cached of the resources watched by each runnable",
+			},
+			[]string{"runnable"},
+		),
+		runnableBytes: prometheus.NewGaugeVec(
+			prometheus.GaugeOpts{
+				Name: "policy_machinery_runnable_approximate_bytes",
+				Help: "Approximate size in bytes of the objects cached of the resources watched by each runnable",
+			},
+			[]string{"runnable"},
+		),
+		runnableEvents: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "policy_machinery_runnable_events_total",
+				Help: "Number of events of the resources watched by each runnable",
+			},
+			[]string{"runnable"},
+		),
+		apiWarnings: prometheus.NewCounterVec(
+			prometheus.CounterOpts{
+				Name: "policy_machinery_api_warnings_total",
+				Help: "Number of warnings (e.g. deprecated API versions) returned by the API server per watched resource",
+			},
+			[]string{"group", "version", "resource"},
+		),
+	}
+}
+
+// Register registers the metrics against a registerer, e.g. the registry of the controller-runtime metrics.
+// Metrics already registered, e.g. by another controller of the same process, are shared.
+func (m *Metrics) Register(registerer prometheus.Registerer) error {
+	var errs []error
+	register := func(collector prometheus.Collector) prometheus.Collector {
+		err := registerer.Register(collector)
+		var alreadyRegistered prometheus.AlreadyRegisteredError
+		if errors.As(err, &alreadyRegistered) {
+			return alreadyRegistered.ExistingCollector
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+		return collector
+	}
+	m.reconcileDuration = register(m.reconcileDuration).(*prometheus.HistogramVec)
+	m.events = register(m.events).(*prometheus.CounterVec)
+	m.queueDepth = register(m.queueDepth).(prometheus.Gauge)
+	m.topologyNodes = register(m.topologyNodes).(*prometheus.GaugeVec)
+	m.topologyEdges = register(m.topologyEdges).(prometheus.Gauge)
+	m.policyAttachments = register(m.policyAttachments).(*prometheus.GaugeVec)
+	m.violations = register(m.violations).(*prometheus.GaugeVec)
+	m.runnableObjects = register(m.runnableObjects).(*prometheus.GaugeVec)
+	m.runnableBytes = register(m.runnableBytes).(*prometheus.GaugeVec)
+	m.runnableEvents = register(m.runnableEvents).(*prometheus.CounterVec)
+	m.apiWarnings = register(m.apiWarnings).(*prometheus.CounterVec)
+	return errors.Join(errs...)
+}
+
+// ObserveReconcile records the duration of a reconciliation cycle and whether it failed.
+func (m *Metrics) ObserveReconcile(duration time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	result := ResultSuccess
+	if err != nil {
+		result = ResultError
+	}
+	m.reconcileDuration.WithLabelValues(result).Observe(duration.Seconds())
+}
+
+// ObserveEvent records an event of a watched resource, e.g. "create", "update" or "delete".
+func (m *Metrics) ObserveEvent(gvk schema.GroupVersionKind, eventType string) {
+	if m == nil {
+		return
+	}
+	m.events.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, eventType).Inc()
+}
+
+// SetQueueDepth records the number of events waiting to be reconciled.
+func (m *Metrics) SetQueueDepth(depth int) {
+	if m == nil {
+		return
+	}
+	m.queueDepth.Set(float64(depth))
+}
+
+// ObserveTopology records the size of a topology and the number of policy attachments in it.
+func (m *Metrics) ObserveTopology(topology *machinery.Topology) {
+	if m == nil || topology == nil {
+		return
+	}
+	m.topologyNodes.Reset()
+	setNodes := func(node string, counts map[schema.GroupKind]int) {
+		for kind, count := range counts {
+			m.topologyNodes.WithLabelValues(node, kind.Group, kind.Kind).Set(float64(count))
+		}
+	}
+	setNodes(NodeTargetable, topology.Targetables().CountByKind())
+	setNodes(NodePolicy, topology.Policies().CountByKind())
+	setNodes(NodeObject, topology.Objects().CountByKind())
+	m.topologyEdges.Set(float64(topology.EdgeCount()))
+
+	attachments := make(map[schema.GroupKind]int)
+	for _, targetable := range topology.Targetables().Items() {
+		for _, policy := range targetable.Policies() {
+			attachments[policy.GroupVersionKind().GroupKind()]++
+		}
+	}
+	m.policyAttachments.Reset()
+	for kind, count := range attachments {
+		m.policyAttachments.WithLabelValues(kind.Group, kind.Kind).Set(float64(count))
+	}
+}
+
+// ObserveAssertionViolations records the number of violations of each assertion rule.
+func (m *Metrics) ObserveAssertionViolations(violations map[string]int) {
+	if m == nil {
+		return
+	}
+	m.violations.Reset()
+	for rule, count := range violations {
+		m.violations.WithLabelValues(rule).Set(float64(count))
+	}
+}
+
+// ObserveRunnableEvent records an event of a resource watched by a runnable.
+func (m *Metrics) ObserveRunnableEvent(runnable string) {
+	if m == nil {
+		return
+	}
+	m.runnableEvents.WithLabelValues(runnable).Inc()
+}
+
+// ObserveRunnableInventory records the number and approximate size in bytes of the objects cached of the resources
+// watched by each runnable.
+func (m *Metrics) ObserveRunnableInventory(objects, bytes map[string]int) {
+	if m == nil {
+		return
+	}
+	m.runnableObjects.Reset()
+	for runnable, count := range objects {
+		m.runnableObjects.WithLabelValues(runnable).Set(float64(count))
+	}
+	m.runnableBytes.Reset()
+	for runnable, size := range bytes {
+		m.runnableBytes.WithLabelValues(runnable).Set(float64(size))
+	}
+}
+
+// ObserveAPIWarning records a warning returned by the API server for a watched resource.
+func (m *Metrics) ObserveAPIWarning(resource schema.GroupVersionResource) {
+	if m == nil {
+		return
+	}
+	m.apiWarnings.WithLabelValues(resource.Group, resource.Version, resource.Resource).Inc()
+}
@@ -0,0 +1,117 @@
+//go:build unit
+
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := New()
+	if err := m.Register(registry); err != nil {
+		t.Fatal(err)
+	}
+
+	m.ObserveReconcile(time.Millisecond, nil)
+	m.ObserveReconcile(time.Millisecond, errors.New("boom"))
+	if count := testutil.CollectAndCount(m.reconcileDuration); count != 2 {
+		t.Errorf("expected 2 reconcile duration series, got %d", count)
+	}
+
+	gvk := schema.GroupVersionKind{Group: gwapiv1.GroupName, Version: "v1", Kind: "Gateway"}
+	m.ObserveEvent(gvk, "create")
+	m.ObserveEvent(gvk, "create")
+	m.ObserveEvent(gvk, "delete")
+	if value := testutil.ToFloat64(m.events.WithLabelValues(gvk.Group, gvk.Version, gvk.Kind, "create")); value != 2 {
+		t.Errorf("expected 2 create events, got %v", value)
+	}
+
+	m.SetQueueDepth(3)
+	if value := testutil.ToFloat64(m.queueDepth); value != 3 {
+		t.Errorf("expected queue depth 3, got %v", value)
+	}
+
+	policy := &machinery.TestPolicy{}
+	policy.SetGroupVersionKind(schema.GroupVersionKind{Group: "test", Version: "v1", Kind: "TestPolicy"})
+	policy.Name = "my-policy"
+	policy.Namespace = "my-namespace"
+	policy.Spec.TargetRef.Group = gwapiv1.GroupName
+	policy.Spec.TargetRef.Kind = "Gateway"
+	policy.Spec.TargetRef.Name = "my-gateway"
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.WithHTTPRoutes(machinery.BuildHTTPRoute()),
+		machinery.WithGatewayAPITopologyPolicies(policy),
+	)
+	m.ObserveTopology(topology)
+	if value := testutil.ToFloat64(m.topologyNodes.WithLabelValues(NodeTargetable, gwapiv1.GroupName, "Gateway")); value != 1 {
+		t.Errorf("expected 1 gateway, got %v", value)
+	}
+	if value := testutil.ToFloat64(m.topologyEdges); value != float64(topology.EdgeCount()) || value == 0 {
+		t.Errorf("expected %d edges, got %v", topology.EdgeCount(), value)
+	}
+	if value := testutil.ToFloat64(m.policyAttachments.WithLabelValues("test", "TestPolicy")); value != 1 {
+		t.Errorf("expected 1 policy attachment, got %v", value)
+	}
+
+	m.ObserveAssertionViolations(map[string]int{"https-listeners": 2, "routes-with-policies": 0})
+	if value := testutil.ToFloat64(m.violations.WithLabelValues("https-listeners")); value != 2 {
+		t.Errorf("expected 2 assertion violations, got %v", value)
+	}
+	if count := testutil.CollectAndCount(m.violations); count != 2 {
+		t.Errorf("expected 2 assertion violations series, got %d", count)
+	}
+	m.ObserveAPIWarning(schema.GroupVersionResource{Group: gwapiv1.GroupName, Version: "v1beta1", Resource: "gateways"})
+	if value := testutil.ToFloat64(m.apiWarnings.WithLabelValues(gwapiv1.GroupName, "v1beta1", "gateways")); value != 1 {
+		t.Errorf("expected 1 api warning, got %v", value)
+	}
+
+	m.ObserveRunnableEvent("gateways")
+	m.ObserveRunnableEvent("gateways")
+	if value := testutil.ToFloat64(m.runnableEvents.WithLabelValues("gateways")); value != 2 {
+		t.Errorf("expected 2 runnable events, got %v", value)
+	}
+
+	m.ObserveRunnableInventory(map[string]int{"gateways": 3, "routes": 0}, map[string]int{"gateways": 1024, "routes": 0})
+	if value := testutil.ToFloat64(m.runnableObjects.WithLabelValues("gateways")); value != 3 {
+		t.Errorf("expected 3 runnable objects, got %v", value)
+	}
+	if value := testutil.ToFloat64(m.runnableBytes.WithLabelValues("gateways")); value != 1024 {
+		t.Errorf("expected 1024 runnable bytes, got %v", value)
+	}
+}
+
+func TestMetricsSharedRegistration(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m1, m2 := New(), New()
+	if err := m1.Register(registry); err != nil {
+		t.Fatal(err)
+	}
+	if err := m2.Register(registry); err != nil {
+		t.Fatalf("expected metrics shared, got %v", err)
+	}
+	m2.SetQueueDepth(5)
+	if value := testutil.ToFloat64(m1.queueDepth); value != 5 {
+		t.Errorf("expected shared queue depth 5, got %v", value)
+	}
+}
+
+func TestNilMetrics(t *testing.T) {
+	var m *Metrics
+	m.ObserveReconcile(time.Second, nil)
+	m.ObserveEvent(schema.GroupVersionKind{}, "create")
+	m.SetQueueDepth(1)
+	m.ObserveTopology(machinery.NewTopology())
+	m.ObserveRunnableEvent("gateways")
+	m.ObserveRunnableInventory(map[string]int{"gateways": 1}, map[string]int{"gateways": 1})
+}
@@ -0,0 +1,287 @@
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DesiredObjects is a set of objects of a resource that a reconciler declares as desired, instead of creating,
+// updating and deleting the objects itself (see DeclareDesiredObjects).
+type DesiredObjects struct {
+	// Resource is the resource of the objects.
+	Resource schema.GroupVersionResource
+	// Selector is the label selector of the live objects of the set, e.g. by ManagedByLabel. Live objects selected
+	// that are not desired are planned for deletion. Empty means no object of the resource is deleted.
+	Selector string
+	// Objects are the desired objects, typed or unstructured, as accepted by ApplyObject.
+	Objects []any
+}
+
+// WithMutationPlans opts in to the desired-set reconciliation of the objects declared by the reconcilers with
+// DeclareDesiredObjects. After every successful reconciliation, the controller compares the desired objects with the
+// live ones and computes a plan of the objects to create, update and delete (see PlanMutations), which is logged and
+// exposed with Controller.MutationPlan and Controller.MutationPlanHandler.
+// The plan is applied if so configured, with the given apply options, except in the ManagementStateUnmanaged
+// management state and in one-shot mode (see Controller.RunOnce), where the plan is only reported, as a dry-run of the
+// reconciliation.
+// No plan is computed after failed reconciliations, whose sets of desired objects may be incomplete.
+func WithMutationPlans(apply bool, opts ...ApplyOption) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.mutationPlans = &mutationPlans{apply: apply, opts: opts}
+	}
+}
+
+// Mutation is a change to an object of a mutation plan.
+type Mutation struct {
+	Resource  schema.GroupVersionResource `json:"resource"`
+	Namespace string                      `json:"namespace,omitempty"`
+	Name      string                      `json:"name"`
+	// Fields are the paths of the fields that change, e.g. "data.key", sorted. Empty for creations and deletions.
+	Fields []string `json:"fields,omitempty"`
+	// Current is the live object; nil for creations.
+	Current *unstructured.Unstructured `json:"current,omitempty"`
+	// Desired is the object as it would be after applying the mutation, as computed by the API server; nil for
+	// deletions.
+	Desired *unstructured.Unstructured `json:"desired,omitempty"`
+
+	configuration *unstructured.Unstructured
+}
+
+// MutationPlan is the list of changes to apply to the live objects for them to match the desired ones.
+// Plans are json-serializable, e.g. to be exported for review.
+type MutationPlan struct {
+	Creates []Mutation `json:"creates,omitempty"`
+	Updates []Mutation `json:"updates,omitempty"`
+	Deletes []Mutation `json:"deletes,omitempty"`
+
+	opts []ApplyOption
+}
+
+// Empty returns true if the plan has no changes to apply.
+func (p *MutationPlan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0 && len(p.Deletes) == 0
+}
+
+// String returns a human-readable summary of the plan, one line per change, e.g. "~ configmaps my-ns/my-config:
+// data.key".
+func (p *MutationPlan) String() string {
+	var lines []string
+	format := func(prefix string, mutation Mutation) string {
+		line := fmt.Sprintf("%s %s %s", prefix, mutation.Resource.GroupResource().String(), mutationObjectName(mutation))
+		if len(mutation.Fields) > 0 {
+			line += ": " + strings.Join(mutation.Fields, ", ")
+		}
+		return line
+	}
+	for _, mutation := range p.Creates {
+		lines = append(lines, format("+", mutation))
+	}
+	for _, mutation := range p.Updates {
+		lines = append(lines, format("~", mutation))
+	}
+	for _, mutation := range p.Deletes {
+		lines = append(lines, format("-", mutation))
+	}
+	return strings.Join(lines, "\n")
+}
+
+func mutationObjectName(mutation Mutation) string {
+	if mutation.Namespace == "" {
+		return mutation.Name
+	}
+	return fmt.Sprintf("%s/%s", mutation.Namespace, mutation.Name)
+}
+
+// PlanMutations compares sets of desired objects with the live objects and returns the changes required for the live
+// objects to match the desired ones, without persisting any change. The desired objects are applied with server-side
+// apply in dry-run mode (see DiffObject), so the plan only holds the changes of the fields owned by the field manager.
+// Live objects that are annotated as unmanaged (see UnmanagedAnnotation) are neither updated nor deleted.
+// The apply options are the ones the plan is applied with (see MutationPlan.Apply).
+func PlanMutations(ctx context.Context, client dynamic.Interface, desired []DesiredObjects, opts ...ApplyOption) (*MutationPlan, error) {
+	plan := &MutationPlan{opts: opts}
+	for _, set := range desired {
+		desiredNames := map[string]struct{}{}
+		for _, obj := range set.Objects {
+			configuration, _, err := applyConfiguration(obj, opts...)
+			if err != nil {
+				return nil, err
+			}
+			desiredNames[namespacedName(configuration)] = struct{}{}
+			diff, err := DiffObject(ctx, client.Resource(set.Resource).Namespace(configuration.GetNamespace()), configuration, opts...)
+			if err != nil {
+				return nil, err
+			}
+			mutation := Mutation{
+				Resource:      set.Resource,
+				Namespace:     configuration.GetNamespace(),
+				Name:          configuration.GetName(),
+				Current:       diff.Current,
+				Desired:       diff.Desired,
+				configuration: configuration,
+			}
+			switch {
+			case diff.Created():
+				plan.Creates = append(plan.Creates, mutation)
+			case diff.Empty() || IsUnmanaged(diff.Current):
+			default:
+				mutation.Fields = diff.Fields
+				plan.Updates = append(plan.Updates, mutation)
+			}
+		}
+
+		if set.Selector == "" {
+			continue
+		}
+		list, err := client.Resource(set.Resource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: set.Selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", set.Resource.String(), err)
+		}
+		strays := lo.Filter(list.Items, func(obj unstructured.Unstructured, _ int) bool {
+			_, ok := desiredNames[namespacedName(&obj)]
+			return !ok && !IsUnmanaged(&obj)
+		})
+		sort.Slice(strays, func(i, j int) bool { return namespacedName(&strays[i]) < namespacedName(&strays[j]) })
+		for i := range strays {
+			plan.Deletes = append(plan.Deletes, Mutation{
+				Resource:  set.Resource,
+				Namespace: strays[i].GetNamespace(),
+				Name:      strays[i].GetName(),
+				Current:   strays[i].DeepCopy(),
+			})
+		}
+	}
+	return plan, nil
+}
+
+// Apply applies the changes of the plan to the live objects, with server-side apply for the creations and updates.
+// It stops at the first error. No change is applied in the ManagementStateUnmanaged management state.
+func (p *MutationPlan) Apply(ctx context.Context, client dynamic.Interface) error {
+	if IsUnmanagedState(ctx) {
+		LoggerFromContext(ctx).V(1).Info("skipping mutation plan in the unmanaged management state")
+		return nil
+	}
+	for _, mutation := range append(append([]Mutation{}, p.Creates...), p.Updates...) {
+		if _, err := ApplyObject(ctx, client.Resource(mutation.Resource).Namespace(mutation.Namespace), mutation.configuration, p.opts...); err != nil {
+			return err
+		}
+	}
+	for _, mutation := range p.Deletes {
+		err := client.Resource(mutation.Resource).Namespace(mutation.Namespace).Delete(ctx, mutation.Name, metav1.DeleteOptions{})
+		if err != nil && !apierrors.IsNotFound(err) {
+			return fmt.Errorf("failed to delete %s %s: %w", mutation.Resource.String(), mutationObjectName(mutation), wrapAPIError(err))
+		}
+	}
+	return nil
+}
+
+// mutationPlans computes the plans of the sets of desired objects declared in the reconciliations (see
+// WithMutationPlans).
+type mutationPlans struct {
+	apply  bool
+	opts   []ApplyOption
+	latest atomic.Pointer[MutationPlan]
+}
+
+// desiredObjects collects the sets of desired objects declared in a reconciliation, possibly concurrently by the
+// reconcilers of a parallel workflow.
+type desiredObjects struct {
+	sync.Mutex
+	sets []DesiredObjects
+}
+
+type desiredObjectsKey struct{}
+
+// DeclareDesiredObjects declares sets of desired objects in the reconciliation of the context, for the controller to
+// reconcile the live objects with them (see WithMutationPlans). Sets of the same resource and selector declared
+// multiple times in a reconciliation are merged.
+// It does nothing if the controller does not plan mutations.
+func DeclareDesiredObjects(ctx context.Context, desired ...DesiredObjects) {
+	collector, ok := ctx.Value(desiredObjectsKey{}).(*desiredObjects)
+	if !ok {
+		return
+	}
+	collector.Lock()
+	defer collector.Unlock()
+	for _, set := range desired {
+		_, i, ok := lo.FindIndexOf(collector.sets, func(s DesiredObjects) bool {
+			return s.Resource == set.Resource && s.Selector == set.Selector
+		})
+		if !ok {
+			collector.sets = append(collector.sets, DesiredObjects{Resource: set.Resource, Selector: set.Selector})
+			i = len(collector.sets) - 1
+		}
+		collector.sets[i].Objects = append(collector.sets[i].Objects, set.Objects...)
+	}
+}
+
+// MutationPlan returns the plan of the latest successful reconciliation, or nil if none was computed yet (see
+// WithMutationPlans).
+func (c *Controller) MutationPlan() *MutationPlan {
+	if c.mutationPlans == nil {
+		return nil
+	}
+	return c.mutationPlans.latest.Load()
+}
+
+// MutationPlanHandler returns an HTTP handler that serves the plan of the latest successful reconciliation as JSON,
+// e.g. to review the changes the controller would make in the ManagementStateUnmanaged management state.
+func (c *Controller) MutationPlanHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		plan := c.MutationPlan()
+		if plan == nil {
+			plan = &MutationPlan{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(plan); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
+
+// planMutations computes the plan of the sets of desired objects declared in a reconciliation and applies it, if so
+// configured. It must be called with the lock held.
+func (c *Controller) planMutations(ctx context.Context, collector *desiredObjects, unmanaged bool) error {
+	logger := LoggerFromContext(ctx).WithName("mutation plan")
+
+	collector.Lock()
+	desired := collector.sets
+	collector.Unlock()
+	if c.client == nil {
+		if len(desired) > 0 {
+			return errors.New("failed to plan mutations: the controller has no client")
+		}
+		return nil
+	}
+	plan, err := PlanMutations(ctx, c.client, desired, c.mutationPlans.opts...)
+	if err != nil {
+		return fmt.Errorf("failed to plan mutations: %w", err)
+	}
+	c.mutationPlans.latest.Store(plan)
+	if plan.Empty() {
+		logger.V(1).Info("no mutations")
+		return nil
+	}
+	logger.Info("mutations planned", "creates", len(plan.Creates), "updates", len(plan.Updates), "deletes", len(plan.Deletes))
+	logger.V(1).Info("mutation plan", "plan", plan.String())
+	if !c.mutationPlans.apply || unmanaged || c.oneShot {
+		return nil
+	}
+	if err := plan.Apply(ctx, c.client); err != nil {
+		return fmt.Errorf("failed to apply mutation plan: %w", err)
+	}
+	return nil
+}
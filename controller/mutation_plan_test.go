@@ -0,0 +1,171 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// applyingClient emulates server-side apply on top of the fake dynamic client (see applyRecorder)
+type applyingClient struct {
+	dynamic.Interface
+}
+
+func (c *applyingClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &applyingResourceClient{c.Interface.Resource(resource)}
+}
+
+type applyingResourceClient struct {
+	dynamic.NamespaceableResourceInterface
+}
+
+func (c *applyingResourceClient) Namespace(namespace string) dynamic.ResourceInterface {
+	return &applyRecorder{ResourceInterface: c.NamespaceableResourceInterface.Namespace(namespace)}
+}
+
+func mutationPlanConfigMap(name, value string, annotations ...string) *corev1.ConfigMap {
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace", Labels: map[string]string{ManagedByLabel: "my-controller"}},
+		Data:       map[string]string{"key": value},
+	}
+	if len(annotations) > 0 {
+		configMap.Annotations = map[string]string{annotations[0]: "true"}
+	}
+	return configMap
+}
+
+func newMutationPlanClient(t *testing.T, configMaps ...*corev1.ConfigMap) dynamic.Interface {
+	objects := make([]runtime.Object, 0, len(configMaps))
+	for _, configMap := range configMaps {
+		obj, err := Destruct(configMap)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		objects = append(objects, obj)
+	}
+	return &applyingClient{dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{ConfigMapsResource: "ConfigMapList"}, objects...)}
+}
+
+func TestPlanMutations(t *testing.T) {
+	ctx := context.TODO()
+	other := mutationPlanConfigMap("other", "1")
+	other.Labels = nil
+	client := newMutationPlanClient(t,
+		mutationPlanConfigMap("unchanged", "1"),
+		mutationPlanConfigMap("changed", "1"),
+		mutationPlanConfigMap("stray", "1"),
+		mutationPlanConfigMap("hotfixed", "1", UnmanagedAnnotation),
+		other,
+	)
+	desired := []DesiredObjects{{
+		Resource: ConfigMapsResource,
+		Selector: ManagedByLabel + "=my-controller",
+		Objects: []any{
+			mutationPlanConfigMap("unchanged", "1"),
+			mutationPlanConfigMap("changed", "2"),
+			mutationPlanConfigMap("new", "1"),
+		},
+	}}
+
+	plan, err := PlanMutations(ctx, client, desired)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := "+ configmaps my-namespace/new\n~ configmaps my-namespace/changed: data.key\n- configmaps my-namespace/stray"; plan.String() != expected {
+		t.Errorf("expected plan:\n%s\ngot:\n%s", expected, plan.String())
+	}
+	if plan.Updates[0].Current == nil || plan.Updates[0].Desired == nil || plan.Deletes[0].Desired != nil {
+		t.Errorf("expected the live and desired objects in the plan, got %+v", plan)
+	}
+	if _, err := json.Marshal(plan); err != nil {
+		t.Errorf("expected the plan to be json-serializable: %v", err)
+	}
+
+	// nothing applied in the unmanaged management state
+	if err := plan.Apply(ManagementStateIntoContext(ctx, ManagementStateUnmanaged), client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := client.Resource(ConfigMapsResource).Namespace("my-namespace").Get(ctx, "new", metav1.GetOptions{}); err == nil {
+		t.Errorf("expected no object created in the unmanaged management state")
+	}
+
+	if err := plan.Apply(ctx, client); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err := client.Resource(ConfigMapsResource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	data := map[string]any{}
+	for _, obj := range list.Items {
+		data[obj.GetName()] = obj.Object["data"].(map[string]any)["key"]
+	}
+	if expected := map[string]any{"unchanged": "1", "changed": "2", "new": "1", "hotfixed": "1", "other": "1"}; !reflect.DeepEqual(data, expected) {
+		t.Errorf("expected objects %v after applying the plan, got %v", expected, data)
+	}
+
+	if plan, err := PlanMutations(ctx, client, desired); err != nil || !plan.Empty() {
+		t.Errorf("expected no mutations once the plan is applied, got %v %v", plan, err)
+	}
+}
+
+func TestControllerMutationPlans(t *testing.T) {
+	for _, state := range []ManagementState{ManagementStateManaged, ManagementStateUnmanaged} {
+		t.Run(string(state), func(t *testing.T) {
+			client := newMutationPlanClient(t, mutationPlanConfigMap("stray", "1"))
+			c := NewController(
+				WithClient(client),
+				WithManagementState(state),
+				WithMutationPlans(true),
+				WithReconcile(func(ctx context.Context, _ []ResourceEvent, _ *machinery.Topology) {
+					DeclareDesiredObjects(ctx, DesiredObjects{Resource: ConfigMapsResource, Selector: ManagedByLabel, Objects: []any{mutationPlanConfigMap("first", "1")}})
+					DeclareDesiredObjects(ctx, DesiredObjects{Resource: ConfigMapsResource, Selector: ManagedByLabel, Objects: []any{mutationPlanConfigMap("second", "1")}})
+				}),
+			)
+			if c.MutationPlan() != nil {
+				t.Errorf("expected no plan before the first reconciliation")
+			}
+
+			c.Lock()
+			err := c.run(nil)
+			c.Unlock()
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if plan := c.MutationPlan(); plan == nil || plan.String() != "+ configmaps my-namespace/first\n+ configmaps my-namespace/second\n- configmaps my-namespace/stray" {
+				t.Errorf("expected the sets of desired objects to be merged into one plan, got %v", plan)
+			}
+
+			recorder := httptest.NewRecorder()
+			c.MutationPlanHandler().ServeHTTP(recorder, httptest.NewRequest("GET", "/", nil))
+			var served MutationPlan
+			if err := json.NewDecoder(recorder.Body).Decode(&served); err != nil || len(served.Creates) != 2 || len(served.Deletes) != 1 {
+				t.Errorf("expected the plan to be served as JSON, got %s %v", recorder.Body.String(), err)
+			}
+
+			list, _ := client.Resource(ConfigMapsResource).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+			names := map[string]bool{}
+			for _, obj := range list.Items {
+				names[obj.GetName()] = true
+			}
+			applied := state == ManagementStateManaged
+			if names["first"] != applied || names["second"] != applied || names["stray"] == applied {
+				t.Errorf("expected the plan applied: %v, got objects %v", applied, names)
+			}
+		})
+	}
+}
@@ -0,0 +1,59 @@
+package controller
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// MaxGeneratedNameLength is the maximum length of the names of generated resources, i.e. the maximum length of a
+// DNS-1123 subdomain.
+const MaxGeneratedNameLength = validation.DNS1123SubdomainMaxLength
+
+// generatedNameHashLength is the length of the hash suffix of the generated names that are not valid verbatim.
+const generatedNameHashLength = 10
+
+var (
+	invalidNameChars   = regexp.MustCompile(`[^a-z0-9.-]`)
+	repeatedSeparators = regexp.MustCompile(`[.-]{2,}`)
+)
+
+// GeneratedName returns a deterministic name for a resource generated out of one or more parts, e.g. a prefix and the
+// name of a topology node, joined by '-'. See GeneratedNameWithMaxLength.
+func GeneratedName(parts ...string) string {
+	return GeneratedNameWithMaxLength(MaxGeneratedNameLength, parts...)
+}
+
+// GeneratedNameWithMaxLength returns a deterministic name for a resource generated out of one or more parts, joined by
+// '-', limited to a maximum length, e.g. validation.DNS1123LabelMaxLength for resources whose names must be DNS labels.
+// Names that are valid DNS-1123 subdomains within the limit are returned verbatim, so resources named after a
+// gateway keep the name of the gateway. Otherwise, e.g. names of sections that contain the section name separator or
+// names that exceed the limit, the name is sanitized, truncated and suffixed with a hash of the parts, so distinct
+// parts never collapse into the same name.
+func GeneratedNameWithMaxLength(maxLength int, parts ...string) string {
+	name := strings.Join(parts, "-")
+	if len(name) <= maxLength && len(validation.IsDNS1123Subdomain(name)) == 0 {
+		return name
+	}
+	hash := fmt.Sprintf("%x", sha256.Sum256([]byte(strings.Join(parts, "\x00"))))[:generatedNameHashLength]
+	sanitized := invalidNameChars.ReplaceAllString(strings.ToLower(name), "-")
+	sanitized = strings.Trim(repeatedSeparators.ReplaceAllString(sanitized, "-"), ".-")
+	if limit := maxLength - generatedNameHashLength - 1; len(sanitized) > limit {
+		sanitized = strings.TrimRight(sanitized[:max(limit, 0)], ".-")
+	}
+	if sanitized == "" {
+		return hash
+	}
+	return sanitized + "-" + hash
+}
+
+// GeneratedNameFor returns a deterministic name for a resource generated out of a node of the topology, such as a
+// gateway or a listener, optionally prefixed, e.g. GeneratedNameFor(listener, "kuadrant-wasm"). See GeneratedName.
+func GeneratedNameFor(node machinery.Object, prefixes ...string) string {
+	return GeneratedName(append(prefixes, node.GetName())...)
+}
@@ -0,0 +1,88 @@
+//go:build unit
+
+package controller
+
+import (
+	"strings"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestGeneratedName(t *testing.T) {
+	testCases := []struct {
+		name      string
+		maxLength int
+		parts     []string
+		expected  string
+	}{
+		{
+			name:      "valid name is kept verbatim",
+			maxLength: MaxGeneratedNameLength,
+			parts:     []string{"my-gateway"},
+			expected:  "my-gateway",
+		},
+		{
+			name:      "valid parts are joined",
+			maxLength: MaxGeneratedNameLength,
+			parts:     []string{"kuadrant-wasm", "my-gateway"},
+			expected:  "kuadrant-wasm-my-gateway",
+		},
+		{
+			name:      "section separator is sanitized",
+			maxLength: MaxGeneratedNameLength,
+			parts:     []string{"my-gateway#my-listener"},
+			expected:  "my-gateway-my-listener-",
+		},
+		{
+			name:      "invalid characters are sanitized",
+			maxLength: MaxGeneratedNameLength,
+			parts:     []string{"My_Gateway..#"},
+			expected:  "my-gateway-",
+		},
+		{
+			name:      "long name is truncated",
+			maxLength: 20,
+			parts:     []string{"a-very-long-gateway-name"},
+			expected:  "a-very-lo-",
+		},
+		{
+			name:      "only invalid characters",
+			maxLength: MaxGeneratedNameLength,
+			parts:     []string{"#"},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := GeneratedNameWithMaxLength(tc.maxLength, tc.parts...)
+			if !strings.HasPrefix(name, tc.expected) {
+				t.Errorf("expected name with prefix %q, got %q", tc.expected, name)
+			}
+			if len(name) > tc.maxLength {
+				t.Errorf("expected name of at most %d characters, got %d", tc.maxLength, len(name))
+			}
+			if errs := validation.IsDNS1123Subdomain(name); len(errs) > 0 {
+				t.Errorf("expected valid name, got %q: %v", name, errs)
+			}
+			if again := GeneratedNameWithMaxLength(tc.maxLength, tc.parts...); again != name {
+				t.Errorf("expected deterministic name %q, got %q", name, again)
+			}
+		})
+	}
+
+	// names that sanitize or truncate to the same prefix do not collide
+	if a, b := GeneratedName("my-gateway#my-listener"), GeneratedName("my-gateway-my-listener#"); a == b {
+		t.Errorf("expected distinct names, got %q", a)
+	}
+	long := strings.Repeat("a", MaxGeneratedNameLength)
+	if a, b := GeneratedName(long, "x"), GeneratedName(long, "y"); a == b {
+		t.Errorf("expected distinct names, got %q", a)
+	}
+
+	listener := &machinery.Listener{Listener: &machinery.BuildGateway().Spec.Listeners[0], Gateway: &machinery.Gateway{Gateway: machinery.BuildGateway()}}
+	if name := GeneratedNameFor(listener, "kuadrant-wasm"); !strings.HasPrefix(name, "kuadrant-wasm-my-gateway-my-listener-") {
+		t.Errorf("unexpected name for listener: %q", name)
+	}
+}
@@ -0,0 +1,106 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// ObjectSetLabel is the label set on the objects reconciled by an ObjectSetReconciler with the name of their set.
+const ObjectSetLabel = "policy-machinery.kuadrant.io/object-set"
+
+// ObjectSetProvider returns the full set of desired objects for a topology, typed or unstructured, as accepted by
+// ApplyObject, e.g. one SecurityPolicy per HTTPRoute targeted by an effective policy.
+type ObjectSetProvider func(ctx context.Context, topology *machinery.Topology) ([]any, error)
+
+// ObjectSetReconciler reconciles the objects of a resource with the full set of desired objects returned by its
+// provider for every topology, so integrations only declare the downstream objects they need instead of creating,
+// updating and deleting them:
+//   - desired objects that do not exist are created, and those that differ from the live objects are updated, with
+//     server-side apply, forcing the ownership of the fields set;
+//   - the objects of the set that are no longer desired, i.e. the strays, are deleted.
+//
+// The objects of a set are labeled with ManagedByLabel and ObjectSetLabel, which select the live objects of the set,
+// so multiple sets of the same resource can be reconciled by the same controller with different names. Live objects
+// annotated as unmanaged (see UnmanagedAnnotation) are neither updated nor deleted.
+// No object is deleted if the provider fails, nor until the caches of the runnables of the controller have synced (see
+// CacheSyncedFromContext). No change is made in the ManagementStateUnmanaged management state.
+type ObjectSetReconciler struct {
+	Client dynamic.Interface
+	// Resource is the resource of the objects of the set.
+	Resource schema.GroupVersionResource
+	// Name is the name of the set, unique among the sets of the same resource managed by the same controller.
+	Name string
+	// ManagedBy is the value of the managed-by label of the objects. Defaults to DefaultFieldManager.
+	ManagedBy string
+	// FieldManager is the field manager of the objects. Defaults to DefaultFieldManager.
+	FieldManager string
+	// Provider returns the desired objects.
+	Provider ObjectSetProvider
+}
+
+func (r *ObjectSetReconciler) Reconcile(ctx context.Context, _ []ResourceEvent, topology *machinery.Topology) error {
+	logger := LoggerFromContext(ctx).WithName("object set").WithValues("resource", r.Resource.GroupResource().String(), "set", r.Name)
+
+	objs, err := r.Provider(ctx, topology)
+	if err != nil {
+		return fmt.Errorf("failed to get the desired objects of the set %s: %w", r.Name, err)
+	}
+	desired, err := r.desiredObjects(objs)
+	if err != nil {
+		return err
+	}
+
+	fieldManager := r.FieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	plan, err := PlanMutations(ctx, r.Client, []DesiredObjects{desired}, WithFieldManager(fieldManager), WithForceOwnership())
+	if err != nil {
+		return fmt.Errorf("failed to plan the objects of the set %s: %w", r.Name, err)
+	}
+	if !CacheSyncedFromContext(ctx) && len(plan.Deletes) > 0 {
+		logger.V(1).Info("skipping deletion of objects until the caches have synced", "objects", len(plan.Deletes))
+		plan.Deletes = nil
+	}
+	if plan.Empty() {
+		return nil
+	}
+	logger.V(1).Info("reconciling objects", "plan", plan.String())
+	if err := plan.Apply(ctx, r.Client); err != nil {
+		return fmt.Errorf("failed to reconcile the objects of the set %s: %w", r.Name, err)
+	}
+	return nil
+}
+
+// desiredObjects labels the objects of the set and returns them with the selector of the live objects of the set.
+func (r *ObjectSetReconciler) desiredObjects(objs []any) (DesiredObjects, error) {
+	managedBy := r.ManagedBy
+	if managedBy == "" {
+		managedBy = DefaultFieldManager
+	}
+	setLabels := labels.Set{ManagedByLabel: managedBy, ObjectSetLabel: r.Name}
+
+	desired := DesiredObjects{Resource: r.Resource, Selector: setLabels.String()}
+	for _, obj := range objs {
+		u, ok := obj.(*unstructured.Unstructured)
+		if ok {
+			u = u.DeepCopy()
+		} else {
+			var err error
+			if u, err = Destruct(obj); err != nil {
+				return DesiredObjects{}, err
+			}
+		}
+		u.SetLabels(lo.Assign(u.GetLabels(), setLabels))
+		desired.Objects = append(desired.Objects, u)
+	}
+	return desired, nil
+}
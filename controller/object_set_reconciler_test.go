@@ -0,0 +1,95 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestObjectSetReconciler(t *testing.T) {
+	ctx := context.TODO()
+	setMember := func(name, set string) *corev1.ConfigMap {
+		configMap := mutationPlanConfigMap(name, "1")
+		configMap.Labels = map[string]string{ManagedByLabel: DefaultFieldManager, ObjectSetLabel: set}
+		return configMap
+	}
+	client := newMutationPlanClient(t,
+		setMember("stray", "my-set"),
+		setMember("other-set", "other-set"),
+		mutationPlanConfigMap("unlabeled", "1"),
+	)
+
+	var desired []any
+	var providerErr error
+	reconciler := &ObjectSetReconciler{
+		Client:   client,
+		Resource: ConfigMapsResource,
+		Name:     "my-set",
+		Provider: func(context.Context, *machinery.Topology) ([]any, error) {
+			return desired, providerErr
+		},
+	}
+	liveObjects := func() map[string]string {
+		list, err := client.Resource(ConfigMapsResource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		objects := map[string]string{}
+		for _, obj := range list.Items {
+			objects[obj.GetName()] = obj.GetLabels()[ObjectSetLabel] + ":" + obj.Object["data"].(map[string]any)["key"].(string)
+		}
+		return objects
+	}
+	reconcile := func(ctx context.Context) error {
+		return reconciler.Reconcile(ctx, nil, machinery.NewTopology())
+	}
+
+	desired = []any{mutationPlanConfigMap("first", "1"), mutationPlanConfigMap("second", "1")}
+	if err := reconcile(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]string{"first": "my-set:1", "second": "my-set:1", "other-set": "other-set:1", "unlabeled": ":1"}; !reflect.DeepEqual(liveObjects(), expected) {
+		t.Errorf("expected the desired objects created and the strays deleted, got %v", liveObjects())
+	}
+
+	// provider failures delete nothing
+	desired, providerErr = nil, errors.New("failure")
+	if err := reconcile(ctx); err == nil {
+		t.Errorf("expected the error of the provider")
+	}
+	if len(liveObjects()) != 4 {
+		t.Errorf("expected no object deleted on failure, got %v", liveObjects())
+	}
+
+	// nothing changes in the unmanaged management state
+	desired, providerErr = []any{mutationPlanConfigMap("first", "2")}, nil
+	if err := reconcile(ManagementStateIntoContext(ctx, ManagementStateUnmanaged)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if objects := liveObjects(); objects["first"] != "my-set:1" || objects["second"] == "" {
+		t.Errorf("expected no change in the unmanaged management state, got %v", objects)
+	}
+
+	// strays are not deleted until the caches have synced
+	if err := reconcile(CacheSyncedIntoContext(ctx, false)); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if objects := liveObjects(); objects["first"] != "my-set:2" || objects["second"] == "" {
+		t.Errorf("expected the desired object updated and no object deleted before the caches have synced, got %v", objects)
+	}
+
+	if err := reconcile(ctx); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if expected := map[string]string{"first": "my-set:2", "other-set": "other-set:1", "unlabeled": ":1"}; !reflect.DeepEqual(liveObjects(), expected) {
+		t.Errorf("expected the desired object updated and the object no longer desired deleted, got %v", liveObjects())
+	}
+}
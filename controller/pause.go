@@ -0,0 +1,104 @@
+package controller
+
+import (
+	"context"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// PausedAnnotation is the annotation that operators set to "true" on a watched resource, such as a gateway or a
+// policy, to pause the reconciliation affecting the resource, e.g. during migrations or incident response. Removing
+// the annotation resumes the reconciliation.
+const PausedAnnotation = "policy-machinery.kuadrant.io/paused"
+
+// Paused condition type and reason
+const (
+	PolicyConditionPaused = "Paused"
+	PolicyReasonPaused    = "Paused"
+)
+
+// WithPauseAnnotation enables pausing the reconciliation of resources annotated with PausedAnnotation.
+// Events of paused resources are not reconciled, and reconcilers are expected to skip the paths of the topology that
+// contain paused resources (see IsPaused and PathPaused). Paused policies of the kinds mapped to a resource are
+// reported with a Paused condition in their status, which is removed when they are resumed.
+func WithPauseAnnotation(policyResources map[schema.GroupKind]schema.GroupVersionResource) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.pause = &pauseOptions{policyResources: policyResources}
+	}
+}
+
+type pauseOptions struct {
+	policyResources map[schema.GroupKind]schema.GroupVersionResource
+}
+
+// IsPaused returns true if a resource is annotated as paused.
+// Both cluster runtime objects and topology objects that wrap them are supported.
+func IsPaused(obj any) bool {
+	return objectAnnotations(obj)[PausedAnnotation] == "true"
+}
+
+// PathPaused returns true if any of the targetables in a path of the topology, or any of the policies attached to
+// them, is paused.
+func PathPaused(path []machinery.Targetable) bool {
+	return lo.ContainsBy(path, func(targetable machinery.Targetable) bool {
+		return IsPaused(targetable) || lo.ContainsBy(targetable.Policies(), func(policy machinery.Policy) bool {
+			return IsPaused(policy)
+		})
+	})
+}
+
+// PausedCondition returns a true Paused condition for a policy.
+func PausedCondition(generation int64) metav1.Condition {
+	return metav1.Condition{
+		Type:               PolicyConditionPaused,
+		Status:             metav1.ConditionTrue,
+		Reason:             PolicyReasonPaused,
+		Message:            "Reconciliation is paused by the " + PausedAnnotation + " annotation",
+		ObservedGeneration: generation,
+	}
+}
+
+// pauseChanged returns true if a resource was paused or resumed.
+func pauseChanged(oldObj, newObj Object) bool {
+	return IsPaused(oldObj) != IsPaused(newObj)
+}
+
+// unpausedEvents filters out the events of paused resources.
+func unpausedEvents(resourceEvents []ResourceEvent) []ResourceEvent {
+	return lo.Reject(resourceEvents, func(event ResourceEvent, _ int) bool {
+		if event.NewObject != nil {
+			return IsPaused(event.NewObject)
+		}
+		return IsPaused(event.OldObject)
+	})
+}
+
+// reconcilePausedStatus sets the Paused condition in the status of the paused policies, and removes it from the
+// status of the resumed ones.
+func reconcilePausedStatus(ctx context.Context, client func(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface, policyResources map[schema.GroupKind]schema.GroupVersionResource, topology *machinery.Topology) {
+	logger := LoggerFromContext(ctx).WithName("pause")
+
+	for _, policy := range topology.Policies().ByKind(lo.Keys(policyResources)...) {
+		resource := policyResources[policy.GroupVersionKind().GroupKind()]
+		paused := IsPaused(policy)
+		updated, err := updatePolicyStatusConditions(ctx, client(resource), policy, func(generation int64, conditions *[]metav1.Condition) bool {
+			if paused {
+				return meta.SetStatusCondition(conditions, PausedCondition(generation))
+			}
+			return meta.RemoveStatusCondition(conditions, PolicyConditionPaused)
+		})
+		if err != nil {
+			logger.Error(err, "failed to update policy status", "policy", policy.GetURL())
+			continue
+		}
+		if updated {
+			logger.V(1).Info("policy status updated", "policy", policy.GetURL(), "paused", paused)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestPathPaused(t *testing.T) {
+	gateway := machinery.BuildGateway()
+	route := machinery.BuildHTTPRoute()
+	topology := machinery.NewGatewayAPITopology(machinery.WithGateways(gateway), machinery.WithHTTPRoutes(route))
+	path := []machinery.Targetable{
+		machinery.TargetablesOfType[*machinery.Gateway](topology)[0],
+		machinery.TargetablesOfType[*machinery.HTTPRoute](topology)[0],
+	}
+	if PathPaused(path) {
+		t.Error("expected path not paused")
+	}
+	gateway.Annotations = map[string]string{PausedAnnotation: "true"}
+	if !PathPaused(path) {
+		t.Error("expected path paused")
+	}
+}
+
+func TestControllerPause(t *testing.T) {
+	var reconciled []ResourceEvent
+	c := NewController(
+		WithPauseAnnotation(nil),
+		WithReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+			reconciled = append(reconciled, events...)
+		}),
+	)
+
+	configMap := func(generation int64, paused bool) *corev1.ConfigMap {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", UID: "my-configmap", Generation: generation}}
+		if paused {
+			cm.Annotations = map[string]string{PausedAnnotation: "true"}
+		}
+		cm.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+		return cm
+	}
+
+	c.add(configMap(1, true))
+	c.update(configMap(1, true), configMap(2, true))
+	if len(reconciled) != 0 {
+		t.Errorf("expected events of paused resource not reconciled, got %v", reconciled)
+	}
+
+	// resumed without a change of generation
+	c.update(configMap(2, true), configMap(2, false))
+	if len(reconciled) != 1 || reconciled[0].EventType != UpdateEvent {
+		t.Errorf("expected resumed resource reconciled, got %v", reconciled)
+	}
+
+	// status-only changes are still ignored
+	c.update(configMap(2, false), configMap(2, false))
+	if len(reconciled) != 1 {
+		t.Errorf("expected no reconciliation, got %v", reconciled)
+	}
+}
+
+func TestReconcilePausedStatus(t *testing.T) {
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", Generation: 3, Annotations: map[string]string{PausedAnnotation: "true"}},
+	}
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"},
+		&unstructured.Unstructured{Object: content},
+	)
+
+	topology := machinery.NewTopology(machinery.WithPolicies(policy))
+	reconcilePausedStatus(context.TODO(), client.Resource, map[schema.GroupKind]schema.GroupVersionResource{policyKind: policyResource}, topology)
+
+	obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(context.TODO(), "my-policy", metav1.GetOptions{})
+	if err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	var parsed []metav1.Condition
+	for _, c := range conditions {
+		condition := metav1.Condition{}
+		_ = runtime.DefaultUnstructuredConverter.FromUnstructured(c.(map[string]any), &condition)
+		parsed = append(parsed, condition)
+	}
+	if paused := meta.FindStatusCondition(parsed, PolicyConditionPaused); paused == nil || paused.Status != metav1.ConditionTrue || paused.ObservedGeneration != 3 {
+		t.Errorf("expected Paused condition, got %v", parsed)
+	}
+}
@@ -0,0 +1,141 @@
+package controller
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// MaxPolicyAncestors is the maximum number of ancestors in the status of a Gateway API policy.
+const MaxPolicyAncestors = 16
+
+// PolicyAncestorRef returns the reference to a targetable as an ancestor in the status of a policy.
+// Sections of resources, such as listeners and route rules, are referred by the resource they belong to and the name
+// of the section.
+func PolicyAncestorRef(ancestor machinery.Targetable) gwapiv1.ParentReference {
+	gk := ancestor.GroupVersionKind().GroupKind()
+	switch ancestor.(type) {
+	case *machinery.Listener:
+		gk = GatewayKind
+	case *machinery.HTTPRouteRule:
+		gk = HTTPRouteKind
+	case *machinery.TLSRouteRule:
+		gk = TLSRouteKind
+	case *machinery.UDPRouteRule:
+		gk = UDPRouteKind
+	case *machinery.ServicePort:
+		gk = ServiceKind
+	}
+	ref := gwapiv1.ParentReference{
+		Group:     ptr.To(gwapiv1.Group(gk.Group)),
+		Kind:      ptr.To(gwapiv1.Kind(gk.Kind)),
+		Namespace: ptr.To(gwapiv1.Namespace(ancestor.GetNamespace())),
+		Name:      gwapiv1.ObjectName(ancestor.GetName()),
+	}
+	if name, section, found := machinery.SectionNameSeparatorOf(ancestor).ParseSectionName(ancestor.GetName()); found {
+		ref.Name = gwapiv1.ObjectName(name)
+		ref.SectionName = ptr.To(section)
+	}
+	return ref
+}
+
+// AncestorsFromPaths returns the targetables of given kinds in a set of topology paths, e.g. the gateways in the
+// paths to the targets of a policy, without duplicates and sorted by URL.
+func AncestorsFromPaths(paths [][]machinery.Targetable, kinds ...schema.GroupKind) []machinery.Targetable {
+	ancestors := lo.UniqBy(lo.Filter(lo.Flatten(paths), func(t machinery.Targetable, _ int) bool {
+		return lo.Contains(kinds, t.GroupVersionKind().GroupKind())
+	}), func(t machinery.Targetable) string {
+		return t.GetURL()
+	})
+	sort.Slice(ancestors, func(i, j int) bool { return ancestors[i].GetURL() < ancestors[j].GetURL() })
+	return ancestors
+}
+
+// PolicyAncestorStatusesOf returns the ancestor entries of a policy status set by a given controller.
+func PolicyAncestorStatusesOf(status gwapiv1alpha2.PolicyStatus, controllerName gwapiv1.GatewayController) []gwapiv1alpha2.PolicyAncestorStatus {
+	return lo.Filter(status.Ancestors, func(a gwapiv1alpha2.PolicyAncestorStatus, _ int) bool {
+		return a.ControllerName == controllerName
+	})
+}
+
+// SyncPolicyAncestorStatuses reconciles the ancestor entries of a policy status set by a given controller with the
+// desired ones, following the Gateway API policy status contract:
+//   - the conditions of the desired ancestors are set in the existing entries, so transition times are preserved;
+//   - entries of the controller whose ancestors are not desired anymore (e.g. a route detached from a gateway) are
+//     removed;
+//   - entries of other controllers are left untouched;
+//   - at most MaxPolicyAncestors entries are kept in total; desired ancestors exceeding the limit are dropped, in order.
+//
+// The controller name of the desired entries is ignored. It returns true if the status changed, and the references to
+// the ancestors dropped for exceeding the limit.
+func SyncPolicyAncestorStatuses(status *gwapiv1alpha2.PolicyStatus, controllerName gwapiv1.GatewayController, desired []gwapiv1alpha2.PolicyAncestorStatus) (bool, []gwapiv1.ParentReference) {
+	others := lo.Filter(status.Ancestors, func(a gwapiv1alpha2.PolicyAncestorStatus, _ int) bool {
+		return a.ControllerName != controllerName
+	})
+	current := PolicyAncestorStatusesOf(*status, controllerName)
+
+	desired = lo.UniqBy(desired, func(a gwapiv1alpha2.PolicyAncestorStatus) string { return parentRefKey(a.AncestorRef) })
+	var dropped []gwapiv1.ParentReference
+	if limit := max(MaxPolicyAncestors-len(others), 0); len(desired) > limit {
+		dropped = lo.Map(desired[limit:], func(a gwapiv1alpha2.PolicyAncestorStatus, _ int) gwapiv1.ParentReference { return a.AncestorRef })
+		desired = desired[:limit]
+		statusGuardsTotal.WithLabelValues(StatusGuardAncestorsDropped).Add(float64(len(dropped)))
+	}
+
+	changed := len(current) != len(desired)
+	ancestors := others
+	for i, d := range desired {
+		entry, found := lo.Find(current, func(a gwapiv1alpha2.PolicyAncestorStatus) bool {
+			return parentRefKey(a.AncestorRef) == parentRefKey(d.AncestorRef)
+		})
+		if !found {
+			changed = true
+			entry = gwapiv1alpha2.PolicyAncestorStatus{AncestorRef: d.AncestorRef, ControllerName: controllerName}
+		} else if i >= len(current) || parentRefKey(current[i].AncestorRef) != parentRefKey(d.AncestorRef) {
+			changed = true // reordered
+		}
+		conditions := append([]metav1.Condition(nil), entry.Conditions...)
+		for _, condition := range GuardConditions(d.Conditions) {
+			changed = meta.SetStatusCondition(&conditions, condition) || changed
+		}
+		for _, condition := range entry.Conditions {
+			if meta.FindStatusCondition(d.Conditions, condition.Type) == nil {
+				changed = meta.RemoveStatusCondition(&conditions, condition.Type) || changed
+			}
+		}
+		entry.Conditions = conditions
+		ancestors = append(ancestors, entry)
+	}
+
+	if changed {
+		status.Ancestors = ancestors
+	}
+	return changed, dropped
+}
+
+// parentRefKey returns a key of a parent reference, with the defaults of the Gateway API applied.
+func parentRefKey(ref gwapiv1.ParentReference) string {
+	group := gwapiv1.GroupName
+	if ref.Group != nil {
+		group = string(*ref.Group)
+	}
+	kind := "Gateway"
+	if ref.Kind != nil {
+		kind = string(*ref.Kind)
+	}
+	key := strings.Join([]string{group, kind, string(ptr.Deref(ref.Namespace, "")), string(ref.Name), string(ptr.Deref(ref.SectionName, ""))}, "/")
+	if ref.Port != nil {
+		key += ":" + strconv.Itoa(int(*ref.Port))
+	}
+	return key
+}
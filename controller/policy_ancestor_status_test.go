@@ -0,0 +1,117 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestPolicyAncestorRef(t *testing.T) {
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.ExpandGatewayListeners(),
+	)
+	gateway := machinery.TargetablesOfType[*machinery.Gateway](topology)[0]
+	listener := machinery.TargetablesOfType[*machinery.Listener](topology)[0]
+
+	if ref := PolicyAncestorRef(gateway); string(*ref.Kind) != "Gateway" || ref.Name != "my-gateway" || string(*ref.Namespace) != "my-namespace" || ref.SectionName != nil {
+		t.Errorf("unexpected ancestor ref of the gateway: %v", ref)
+	}
+	if ref := PolicyAncestorRef(listener); string(*ref.Group) != gwapiv1.GroupName || string(*ref.Kind) != "Gateway" || ref.Name != "my-gateway" || ref.SectionName == nil || *ref.SectionName != "my-listener" {
+		t.Errorf("unexpected ancestor ref of the listener: %v", ref)
+	}
+
+	topology = machinery.NewGatewayAPITopology(
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.ExpandGatewayListeners(),
+		machinery.WithGatewayAPITopologySectionNameSeparator('~'),
+	)
+	if ref := PolicyAncestorRef(machinery.TargetablesOfType[*machinery.Listener](topology)[0]); ref.Name != "my-gateway" || ref.SectionName == nil || *ref.SectionName != "my-listener" {
+		t.Errorf("unexpected ancestor ref of the listener with another section name separator: %v", ref)
+	}
+
+	ancestors := AncestorsFromPaths([][]machinery.Targetable{{gateway, listener}, {gateway}}, GatewayKind)
+	if len(ancestors) != 1 || ancestors[0] != gateway {
+		t.Errorf("expected the gateway as the only ancestor, got %v", ancestors)
+	}
+}
+
+func TestSyncPolicyAncestorStatuses(t *testing.T) {
+	const controllerName = gwapiv1.GatewayController("example.com/my-controller")
+	const otherControllerName = gwapiv1.GatewayController("example.com/other-controller")
+
+	gatewayRef := func(name string) gwapiv1.ParentReference {
+		return gwapiv1.ParentReference{Name: gwapiv1.ObjectName(name), Namespace: ptr.To(gwapiv1.Namespace("my-namespace"))}
+	}
+	accepted := func(status metav1.ConditionStatus) []metav1.Condition {
+		return []metav1.Condition{{Type: PolicyConditionAccepted, Status: status, Reason: PolicyReasonAccepted}}
+	}
+	desired := func(names ...string) []gwapiv1alpha2.PolicyAncestorStatus {
+		return lo.Map(names, func(name string, _ int) gwapiv1alpha2.PolicyAncestorStatus {
+			return gwapiv1alpha2.PolicyAncestorStatus{AncestorRef: gatewayRef(name), Conditions: accepted(metav1.ConditionTrue)}
+		})
+	}
+
+	status := &gwapiv1alpha2.PolicyStatus{
+		Ancestors: []gwapiv1alpha2.PolicyAncestorStatus{
+			{AncestorRef: gatewayRef("other"), ControllerName: otherControllerName, Conditions: accepted(metav1.ConditionTrue)},
+		},
+	}
+
+	// new ancestors
+	if changed, dropped := SyncPolicyAncestorStatuses(status, controllerName, desired("gw-1", "gw-2")); !changed || len(dropped) != 0 {
+		t.Fatalf("expected status changed without dropped ancestors, got %v, %v", changed, dropped)
+	}
+	if len(status.Ancestors) != 3 || len(PolicyAncestorStatusesOf(*status, controllerName)) != 2 {
+		t.Fatalf("expected 3 ancestors, 2 of the controller, got %v", status.Ancestors)
+	}
+	transitionTime := metav1.NewTime(time.Now().Add(-time.Hour))
+	status.Ancestors[1].Conditions[0].LastTransitionTime = transitionTime
+
+	// nothing changed
+	if changed, _ := SyncPolicyAncestorStatuses(status, controllerName, desired("gw-1", "gw-2")); changed {
+		t.Errorf("expected status not changed, got %v", status.Ancestors)
+	}
+
+	// route detached from gw-2
+	if changed, _ := SyncPolicyAncestorStatuses(status, controllerName, desired("gw-1")); !changed {
+		t.Errorf("expected status changed")
+	}
+	if len(status.Ancestors) != 2 || status.Ancestors[0].ControllerName != otherControllerName || status.Ancestors[1].AncestorRef.Name != "gw-1" {
+		t.Errorf("expected stale ancestor removed, got %v", status.Ancestors)
+	}
+	if !status.Ancestors[1].Conditions[0].LastTransitionTime.Equal(&transitionTime) {
+		t.Errorf("expected transition time preserved, got %v", status.Ancestors[1].Conditions[0].LastTransitionTime)
+	}
+
+	// condition changed
+	if changed, _ := SyncPolicyAncestorStatuses(status, controllerName, []gwapiv1alpha2.PolicyAncestorStatus{{AncestorRef: gatewayRef("gw-1"), Conditions: accepted(metav1.ConditionFalse)}}); !changed {
+		t.Errorf("expected status changed")
+	}
+	if meta.IsStatusConditionTrue(status.Ancestors[1].Conditions, PolicyConditionAccepted) {
+		t.Errorf("expected condition updated, got %v", status.Ancestors[1].Conditions)
+	}
+
+	// limit of ancestors
+	names := lo.Map(lo.Range(20), func(i int, _ int) string { return "gw-" + string(rune('a'+i)) })
+	changed, dropped := SyncPolicyAncestorStatuses(status, controllerName, desired(names...))
+	if !changed || len(status.Ancestors) != MaxPolicyAncestors {
+		t.Errorf("expected %d ancestors, got %d", MaxPolicyAncestors, len(status.Ancestors))
+	}
+	if len(dropped) != 5 || dropped[0].Name != "gw-p" {
+		t.Errorf("expected the last 5 ancestors dropped, got %v", dropped)
+	}
+	if status.Ancestors[0].ControllerName != otherControllerName {
+		t.Errorf("expected ancestors of other controllers kept, got %v", status.Ancestors[0])
+	}
+}
@@ -0,0 +1,263 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// Standard policy attachment reasons, as per GEP-713
+const (
+	PolicyReasonTargetNotFound = string(gwapiv1alpha2.PolicyReasonTargetNotFound)
+	PolicyReasonInvalid        = string(gwapiv1alpha2.PolicyReasonInvalid)
+	PolicyReasonOverridden     = "Overridden"
+)
+
+// PolicyConditionReasons are the reasons set in the policy attachment conditions. Controllers can replace them with
+// reasons of their own, e.g. to keep the reasons of an existing API.
+type PolicyConditionReasons struct {
+	Accepted       string
+	Conflicted     string
+	TargetNotFound string
+	Invalid        string
+	Enforced       string
+	Overridden     string
+}
+
+// DefaultPolicyConditionReasons are the standard reasons of the policy attachment conditions.
+var DefaultPolicyConditionReasons = PolicyConditionReasons{
+	Accepted:       PolicyReasonAccepted,
+	Conflicted:     PolicyReasonConflicted,
+	TargetNotFound: PolicyReasonTargetNotFound,
+	Invalid:        PolicyReasonInvalid,
+	Enforced:       PolicyReasonEnforced,
+	Overridden:     PolicyReasonOverridden,
+}
+
+// TargetRefStatus is the attachment status of a policy to one of its target references.
+// Target is the targetable the target reference resolves to, or nil if the target reference is not supported by the
+// kind of policy or does not resolve to a targetable of the topology, in which case Diagnostic tells why. Enforced is
+// only set if the policy is accepted for the target reference.
+type TargetRefStatus struct {
+	TargetRef  machinery.PolicyTargetReference
+	Target     machinery.Targetable
+	Diagnostic machinery.TargetRefDiagnostic
+	Accepted   metav1.Condition
+	Enforced   *metav1.Condition
+}
+
+// PolicyAttachmentOption configures the computation of the policy attachment status.
+type PolicyAttachmentOption func(*policyAttachmentOptions)
+
+type policyAttachmentOptions struct {
+	reasons      PolicyConditionReasons
+	overriddenBy func(policy machinery.Policy, target machinery.Targetable, topology *machinery.Topology) []machinery.Policy
+	dependencies func(policy machinery.Policy, target machinery.Targetable, topology *machinery.Topology) []Dependency
+}
+
+// WithPolicyConditionReasons sets the reasons of the policy attachment conditions.
+func WithPolicyConditionReasons(reasons PolicyConditionReasons) PolicyAttachmentOption {
+	return func(o *policyAttachmentOptions) {
+		o.reasons = reasons
+	}
+}
+
+// WithOverriddenBy sets a function that returns the policies that fully override a policy attached to a target, e.g.
+// policies with overrides attached to less specific targetables. A policy overridden for all its targets is not
+// enforced. By default, policies are never overridden.
+func WithOverriddenBy(f func(policy machinery.Policy, target machinery.Targetable, topology *machinery.Topology) []machinery.Policy) PolicyAttachmentOption {
+	return func(o *policyAttachmentOptions) {
+		o.overriddenBy = f
+	}
+}
+
+// WithEnforcementDependencies sets a function that returns the resources a policy attached to a target depends on to
+// be enforced. The policy is enforced when all its dependencies are ready. By default, accepted policies are enforced.
+func WithEnforcementDependencies(f func(policy machinery.Policy, target machinery.Targetable, topology *machinery.Topology) []Dependency) PolicyAttachmentOption {
+	return func(o *policyAttachmentOptions) {
+		o.dependencies = f
+	}
+}
+
+func newPolicyAttachmentOptions(options []PolicyAttachmentOption) *policyAttachmentOptions {
+	o := &policyAttachmentOptions{reasons: DefaultPolicyConditionReasons}
+	for _, f := range options {
+		f(o)
+	}
+	return o
+}
+
+// ComputeTargetRefStatuses computes the attachment status of a policy to each of its target references, following the
+// semantics of GEP-713: a policy is not accepted for a target reference not supported by its kind (Invalid), that does
+// not resolve to a targetable of the topology (TargetNotFound), or whose targetable has another policy of the same kind
+// attached that takes precedence and cannot be merged (Conflicted); an accepted policy is not enforced if fully
+// overridden by other policies (Overridden) or while waiting on dependencies.
+func ComputeTargetRefStatuses(topology *machinery.Topology, policy machinery.Policy, options ...PolicyAttachmentOption) []TargetRefStatus {
+	o := newPolicyAttachmentOptions(options)
+	generation := policyGeneration(policy)
+
+	conflicts := machinery.ConflictsOf(topology.Conflicts(func(obj machinery.Object) bool {
+		return obj.GroupVersionKind().GroupKind() == policy.GroupVersionKind().GroupKind()
+	}), policy)
+
+	return lo.Map(policy.GetTargetRefs(), func(targetRef machinery.PolicyTargetReference, _ int) TargetRefStatus {
+		diagnostic := topology.DiagnoseTargetRef(policy, targetRef)
+		status := TargetRefStatus{TargetRef: targetRef, Diagnostic: diagnostic}
+
+		switch {
+		case diagnostic.Reason == machinery.TargetRefReasonUnsupported:
+			status.Accepted = notAcceptedCondition(generation, o.reasons.Invalid, fmt.Sprintf("Policy target %s is not supported: %s", objectString(targetRef), diagnostic.Message))
+			return status
+		case !diagnostic.Resolved():
+			status.Accepted = notAcceptedCondition(generation, o.reasons.TargetNotFound, fmt.Sprintf("Policy target %s was not found: %s", objectString(targetRef), diagnostic.Message))
+			return status
+		}
+		target := diagnostic.Target
+		status.Target = target
+
+		if conflict, lost := lo.Find(conflicts, func(c machinery.Conflict) bool {
+			return c.Target.GetURL() == target.GetURL() && c.Reason == machinery.ConflictReasonOverridden && c.Winner.GetURL() != policy.GetURL()
+		}); lost {
+			status.Accepted = ConflictedCondition(generation, conflict)
+			status.Accepted.Reason = o.reasons.Conflicted
+			return status
+		}
+
+		status.Accepted = AcceptedCondition(generation)
+		status.Accepted.Reason = o.reasons.Accepted
+
+		var enforced metav1.Condition
+		if o.overriddenBy != nil {
+			if overriding := o.overriddenBy(policy, target, topology); len(overriding) > 0 {
+				enforced = OverriddenCondition(generation, overriding...)
+				enforced.Reason = o.reasons.Overridden
+				status.Enforced = &enforced
+				return status
+			}
+		}
+		var dependencies []Dependency
+		if o.dependencies != nil {
+			dependencies = o.dependencies(policy, target, topology)
+		}
+		enforced = EnforcedCondition(generation, dependencies...)
+		if enforced.Status == metav1.ConditionTrue {
+			enforced.Reason = o.reasons.Enforced
+		}
+		status.Enforced = &enforced
+		return status
+	})
+}
+
+// PolicyAttachmentConditions aggregates the attachment statuses of a policy to its target references into the Accepted
+// and Enforced conditions of the policy.
+// The policy is accepted if accepted for at least one target reference; otherwise, the Accepted condition is the one of
+// the first target reference. The policy is enforced if enforced for at least one of the target references it is
+// accepted for; otherwise, it is overridden if overridden for all of them, or else waiting on the dependencies of all
+// of them. The Enforced condition is omitted if the policy is not accepted.
+func PolicyAttachmentConditions(generation int64, statuses []TargetRefStatus, options ...PolicyAttachmentOption) []metav1.Condition {
+	o := newPolicyAttachmentOptions(options)
+
+	if len(statuses) == 0 {
+		return []metav1.Condition{notAcceptedCondition(generation, o.reasons.TargetNotFound, "Policy has no target")}
+	}
+
+	accepted := lo.Filter(statuses, func(s TargetRefStatus, _ int) bool {
+		return s.Accepted.Status == metav1.ConditionTrue
+	})
+	if len(accepted) == 0 {
+		return []metav1.Condition{statuses[0].Accepted}
+	}
+	acceptedCondition := accepted[0].Accepted
+
+	enforced := lo.FilterMap(accepted, func(s TargetRefStatus, _ int) (metav1.Condition, bool) {
+		return lo.FromPtr(s.Enforced), s.Enforced != nil
+	})
+	if len(enforced) == 0 {
+		return []metav1.Condition{acceptedCondition}
+	}
+	if condition, ok := lo.Find(enforced, func(c metav1.Condition) bool { return c.Status == metav1.ConditionTrue }); ok {
+		return []metav1.Condition{acceptedCondition, condition}
+	}
+	if condition, ok := lo.Find(enforced, func(c metav1.Condition) bool { return c.Reason != o.reasons.Overridden }); ok {
+		return []metav1.Condition{acceptedCondition, condition}
+	}
+	return []metav1.Condition{acceptedCondition, enforced[0]}
+}
+
+// SetPolicyAttachmentConditions computes the Accepted and Enforced conditions of a policy out of the topology and sets
+// them in the status of the policy in the cluster, removing the Enforced condition if the policy is not accepted.
+// The policy must be a pointer to a struct with status conditions at 'status.conditions'.
+// The status is only updated if the conditions changed; it returns true if the status was updated.
+func SetPolicyAttachmentConditions(ctx context.Context, client dynamic.NamespaceableResourceInterface, topology *machinery.Topology, policy machinery.Policy, options ...PolicyAttachmentOption) (bool, error) {
+	statuses := ComputeTargetRefStatuses(topology, policy, options...)
+	return updatePolicyStatusConditions(ctx, client, policy, func(generation int64, conditions *[]metav1.Condition) bool {
+		changed := false
+		attachmentConditions := PolicyAttachmentConditions(generation, statuses, options...)
+		for _, condition := range attachmentConditions {
+			changed = meta.SetStatusCondition(conditions, condition) || changed
+		}
+		if len(attachmentConditions) < 2 {
+			changed = meta.RemoveStatusCondition(conditions, PolicyConditionEnforced) || changed
+		}
+		return changed
+	})
+}
+
+// PolicyAttachmentStatusReconciler returns a reconcile function that sets the Accepted and Enforced conditions of all
+// the policies in the topology whose kinds are mapped to a resource, used to update their status.
+func PolicyAttachmentStatusReconciler(client dynamic.Interface, resources map[schema.GroupKind]schema.GroupVersionResource, options ...PolicyAttachmentOption) ReconcileFunc {
+	return func(ctx context.Context, _ []ResourceEvent, topology *machinery.Topology) {
+		logger := LoggerFromContext(ctx).WithName("policy attachment status")
+
+		for _, policy := range topology.Policies().ByKind(lo.Keys(resources)...) {
+			resource := resources[policy.GroupVersionKind().GroupKind()]
+			updated, err := SetPolicyAttachmentConditions(ctx, client.Resource(resource), topology, policy, options...)
+			if err != nil {
+				logger.Error(err, "failed to update policy status", "policy", policy.GetURL())
+				continue
+			}
+			if updated {
+				logger.V(1).Info("policy status updated", "policy", policy.GetURL())
+			}
+		}
+	}
+}
+
+// OverriddenCondition returns a false Enforced condition for a policy fully overridden by other policies.
+func OverriddenCondition(generation int64, overriding ...machinery.Policy) metav1.Condition {
+	return metav1.Condition{
+		Type:   PolicyConditionEnforced,
+		Status: metav1.ConditionFalse,
+		Reason: PolicyReasonOverridden,
+		Message: fmt.Sprintf("Policy is overridden by %s", strings.Join(lo.Map(overriding, func(p machinery.Policy, _ int) string {
+			return objectString(p)
+		}), ", ")),
+		ObservedGeneration: generation,
+	}
+}
+
+func notAcceptedCondition(generation int64, reason, message string) metav1.Condition {
+	return metav1.Condition{
+		Type:               PolicyConditionAccepted,
+		Status:             metav1.ConditionFalse,
+		Reason:             reason,
+		Message:            message,
+		ObservedGeneration: generation,
+	}
+}
+
+func policyGeneration(policy machinery.Policy) int64 {
+	if obj, ok := policy.(metav1.Object); ok {
+		return obj.GetGeneration()
+	}
+	return 0
+}
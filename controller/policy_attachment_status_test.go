@@ -0,0 +1,190 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func buildAttachmentTestPolicy(name string, age time.Duration, kind, targetName string) *machinery.TestPolicy {
+	return &machinery.TestPolicy{
+		TypeMeta: metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              name,
+			Namespace:         "my-namespace",
+			Generation:        2,
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-age)),
+		},
+		Spec: machinery.TestPolicySpec{
+			TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  gwapiv1.Kind(kind),
+					Name:  gwapiv1.ObjectName(targetName),
+				},
+			},
+		},
+	}
+}
+
+func TestComputeTargetRefStatuses(t *testing.T) {
+	older := buildAttachmentTestPolicy("older", time.Hour, "Gateway", "my-gateway")
+	newer := buildAttachmentTestPolicy("newer", time.Minute, "Gateway", "my-gateway")
+	missing := buildAttachmentTestPolicy("missing", time.Minute, "Gateway", "other-gateway")
+	unsupported := buildAttachmentTestPolicy("unsupported", time.Minute, "HTTPRoute", "my-route")
+
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(machinery.BuildGatewayClass()),
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.WithHTTPRoutes(machinery.BuildHTTPRoute()),
+		machinery.WithGatewayAPITopologyPolicies(older, newer, missing, unsupported),
+	)
+
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	machinery.RegisterPolicyCapabilities(policyKind, machinery.PolicyCapabilities{
+		TargetKinds: []schema.GroupKind{{Group: gwapiv1.GroupName, Kind: "Gateway"}},
+	})
+	defer machinery.UnregisterPolicyCapabilities(policyKind)
+
+	testCases := []struct {
+		name             string
+		policy           machinery.Policy
+		options          []PolicyAttachmentOption
+		expectedAccepted string
+		expectedEnforced string
+	}{
+		{name: "accepted and enforced", policy: older, expectedAccepted: PolicyReasonAccepted, expectedEnforced: PolicyReasonEnforced},
+		{name: "conflicted", policy: newer, expectedAccepted: PolicyReasonConflicted},
+		{name: "target not found", policy: missing, expectedAccepted: PolicyReasonTargetNotFound},
+		{name: "unsupported target", policy: unsupported, expectedAccepted: PolicyReasonInvalid},
+		{
+			name:   "overridden",
+			policy: older,
+			options: []PolicyAttachmentOption{WithOverriddenBy(func(_ machinery.Policy, _ machinery.Targetable, _ *machinery.Topology) []machinery.Policy {
+				return []machinery.Policy{newer}
+			})},
+			expectedAccepted: PolicyReasonAccepted,
+			expectedEnforced: PolicyReasonOverridden,
+		},
+		{
+			name:   "waiting on dependency",
+			policy: older,
+			options: []PolicyAttachmentOption{WithEnforcementDependencies(func(_ machinery.Policy, _ machinery.Targetable, _ *machinery.Topology) []Dependency {
+				return []Dependency{{Object: newer, ConditionType: "Programmed"}}
+			})},
+			expectedAccepted: PolicyReasonAccepted,
+			expectedEnforced: PolicyReasonWaitingOnDependency,
+		},
+		{
+			name:             "custom reasons",
+			policy:           missing,
+			options:          []PolicyAttachmentOption{WithPolicyConditionReasons(PolicyConditionReasons{TargetNotFound: "NoTarget"})},
+			expectedAccepted: "NoTarget",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			statuses := ComputeTargetRefStatuses(topology, tc.policy, tc.options...)
+			if len(statuses) != 1 {
+				t.Fatalf("expected 1 target ref status, got %d", len(statuses))
+			}
+			if statuses[0].Accepted.Reason != tc.expectedAccepted {
+				t.Errorf("expected Accepted reason %s, got %s (%s)", tc.expectedAccepted, statuses[0].Accepted.Reason, statuses[0].Accepted.Message)
+			}
+			conditions := PolicyAttachmentConditions(2, statuses, tc.options...)
+			enforced := meta.FindStatusCondition(conditions, PolicyConditionEnforced)
+			if tc.expectedEnforced == "" {
+				if enforced != nil {
+					t.Errorf("expected no Enforced condition, got %v", enforced)
+				}
+				return
+			}
+			if enforced == nil || enforced.Reason != tc.expectedEnforced {
+				t.Errorf("expected Enforced reason %s, got %v", tc.expectedEnforced, enforced)
+			}
+			if accepted := meta.FindStatusCondition(conditions, PolicyConditionAccepted); accepted == nil || accepted.ObservedGeneration != 2 {
+				t.Errorf("expected Accepted condition for generation 2, got %v", accepted)
+			}
+		})
+	}
+}
+
+func TestComputeTargetRefStatusesDiagnostics(t *testing.T) {
+	missing := buildAttachmentTestPolicy("missing", time.Minute, "Gateway", "other-gateway")
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(machinery.BuildGatewayClass()),
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.WithGatewayAPITopologyPolicies(missing),
+	)
+
+	statuses := ComputeTargetRefStatuses(topology, missing)
+	if len(statuses) != 1 || statuses[0].Diagnostic.Reason != machinery.TargetRefReasonNotFound {
+		t.Fatalf("expected the target ref diagnosed as not found, got %+v", statuses)
+	}
+	if expected := "Policy target Gateway my-namespace/other-gateway was not found: Gateway.gateway.networking.k8s.io my-namespace/other-gateway not found"; statuses[0].Accepted.Message != expected {
+		t.Errorf("expected Accepted message %q, got %q", expected, statuses[0].Accepted.Message)
+	}
+}
+
+func TestSetPolicyAttachmentConditions(t *testing.T) {
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := buildAttachmentTestPolicy("my-policy", time.Minute, "Gateway", "my-gateway")
+
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"},
+		&unstructured.Unstructured{Object: content},
+	)
+	conditions := func() []metav1.Condition {
+		obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(context.TODO(), "my-policy", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get policy: %v", err)
+		}
+		unstructuredConditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		var parsed []metav1.Condition
+		for _, c := range unstructuredConditions {
+			condition := metav1.Condition{}
+			_ = runtime.DefaultUnstructuredConverter.FromUnstructured(c.(map[string]any), &condition)
+			parsed = append(parsed, condition)
+		}
+		return parsed
+	}
+
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.WithGatewayAPITopologyPolicies(policy),
+	)
+	reconcile := PolicyAttachmentStatusReconciler(client, map[schema.GroupKind]schema.GroupVersionResource{{Group: "test", Kind: "TestPolicy"}: policyResource})
+	reconcile(context.TODO(), nil, topology)
+	if c := conditions(); !meta.IsStatusConditionTrue(c, PolicyConditionAccepted) || !meta.IsStatusConditionTrue(c, PolicyConditionEnforced) {
+		t.Errorf("expected policy accepted and enforced, got %v", c)
+	}
+
+	// the target is gone
+	topology = machinery.NewGatewayAPITopology(machinery.WithGatewayAPITopologyPolicies(policy))
+	updated, err := SetPolicyAttachmentConditions(context.TODO(), client.Resource(policyResource), topology, policy)
+	if err != nil || !updated {
+		t.Fatalf("expected status updated, got %v (%v)", updated, err)
+	}
+	c := conditions()
+	if accepted := meta.FindStatusCondition(c, PolicyConditionAccepted); accepted == nil || accepted.Reason != PolicyReasonTargetNotFound {
+		t.Errorf("expected policy not accepted due to target not found, got %v", accepted)
+	}
+	if meta.FindStatusCondition(c, PolicyConditionEnforced) != nil {
+		t.Errorf("expected Enforced condition removed, got %v", c)
+	}
+
+}
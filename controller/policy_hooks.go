@@ -0,0 +1,130 @@
+package controller
+
+import (
+	"context"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// PolicyHooks are callbacks invoked by the controller on changes to the policies of a kind, as an alternative to
+// writing full workflow reconcilers for simple integrations. Any of the hooks can be nil.
+type PolicyHooks struct {
+	// OnAttached is invoked with a policy and the targetables the policy got attached to, e.g. because the policy was
+	// created or its target references changed.
+	OnAttached func(ctx context.Context, policy machinery.Policy, targetables []machinery.Targetable, topology *machinery.Topology)
+	// OnDetached is invoked with a policy and the targetables the policy got detached from, e.g. because the policy
+	// or the targetables were deleted. The policy and the targetables are the last versions seen by the controller.
+	OnDetached func(ctx context.Context, policy machinery.Policy, targetables []machinery.Targetable, topology *machinery.Topology)
+	// OnEffectiveChanged is invoked with the targetables whose effective policy of the kind may have changed, i.e.
+	// targetables for which any of the policies of the kind attached to them or to their ancestors changed, sorted by
+	// URL. Targetables no longer in the topology are not reported.
+	OnEffectiveChanged func(ctx context.Context, targetables []machinery.Targetable, topology *machinery.Topology)
+}
+
+// WithPolicyHooks registers lifecycle hooks of the policies of a kind.
+// Every time the topology is reconciled, the controller compares it with the previous one and invokes the hooks with
+// the affected targetables. On the first reconciliation, all policies are reported as attached and all targetables
+// with policies of the kind as changed. The hooks are not invoked in the ManagementStateUnmanaged management state;
+// the changes are reported once the controller is managed again.
+func WithPolicyHooks(kind schema.GroupKind, hooks PolicyHooks) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.policyHooks = append(o.policyHooks, policyHooks{kind: kind, hooks: hooks})
+	}
+}
+
+type policyHooks struct {
+	kind  schema.GroupKind
+	hooks PolicyHooks
+
+	// state of the previous topology
+	policies    map[string]machinery.Policy
+	attachments map[string]map[string]machinery.Targetable // policy URL → targetable URL → targetable
+	effective   map[string]string                          // targetable URL → key of the policies of the kind that apply
+}
+
+// invoke compares a topology with the previous one and invokes the hooks with the affected targetables.
+func (h *policyHooks) invoke(ctx context.Context, topology *machinery.Topology) {
+	policies := lo.SliceToMap(topology.Policies().ByKind(h.kind), func(policy machinery.Policy) (string, machinery.Policy) {
+		return policy.GetURL(), policy
+	})
+	attachments := make(map[string]map[string]machinery.Targetable, len(policies))
+	effective := make(map[string]string)
+	for _, targetable := range topology.Targetables().Items() {
+		for _, policy := range targetable.Policies() {
+			if _, ok := policies[policy.GetURL()]; !ok {
+				continue
+			}
+			if attachments[policy.GetURL()] == nil {
+				attachments[policy.GetURL()] = make(map[string]machinery.Targetable)
+			}
+			attachments[policy.GetURL()][targetable.GetURL()] = targetable
+		}
+		if key := h.effectiveKey(topology, targetable); key != "" {
+			effective[targetable.GetURL()] = key
+		}
+	}
+
+	for _, url := range sortedKeys(policies) {
+		if attached := newTargetables(attachments[url], h.attachments[url]); len(attached) > 0 && h.hooks.OnAttached != nil {
+			h.hooks.OnAttached(ctx, policies[url], attached, topology)
+		}
+	}
+	for _, url := range sortedKeys(h.policies) {
+		policy, ok := policies[url]
+		if !ok {
+			policy = h.policies[url]
+		}
+		if detached := newTargetables(h.attachments[url], attachments[url]); len(detached) > 0 && h.hooks.OnDetached != nil {
+			h.hooks.OnDetached(ctx, policy, detached, topology)
+		}
+	}
+	if h.hooks.OnEffectiveChanged != nil {
+		changed := lo.Filter(topology.Targetables().Items(), func(targetable machinery.Targetable, _ int) bool {
+			return effective[targetable.GetURL()] != h.effective[targetable.GetURL()]
+		})
+		sort.Slice(changed, func(i, j int) bool { return changed[i].GetURL() < changed[j].GetURL() })
+		if len(changed) > 0 {
+			h.hooks.OnEffectiveChanged(ctx, changed, topology)
+		}
+	}
+
+	h.policies = policies
+	h.attachments = attachments
+	h.effective = effective
+}
+
+// effectiveKey returns a key of the versions of the policies of the kind attached to a targetable or to its ancestors.
+func (h *policyHooks) effectiveKey(topology *machinery.Topology, targetable machinery.Targetable) string {
+	var keys []string
+	for _, t := range append(topology.Targetables().Ancestors(targetable), targetable) {
+		for _, policy := range t.Policies() {
+			if policy.GroupVersionKind().GroupKind() == h.kind {
+				keys = append(keys, policy.GetURL()+"@"+resourceVersion(policy))
+			}
+		}
+	}
+	sort.Strings(keys)
+	return strings.Join(lo.Uniq(keys), ",")
+}
+
+// newTargetables returns the targetables in a set that are not in another, sorted by URL.
+func newTargetables(set, other map[string]machinery.Targetable) []machinery.Targetable {
+	var targetables []machinery.Targetable
+	for _, url := range sortedKeys(set) {
+		if _, ok := other[url]; !ok {
+			targetables = append(targetables, set[url])
+		}
+	}
+	return targetables
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := lo.Keys(m)
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,115 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestPolicyHooks(t *testing.T) {
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	policy := func(resourceVersion, target string) *machinery.TestPolicy {
+		return &machinery.TestPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: policyKind.Kind},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", ResourceVersion: resourceVersion},
+			Spec: machinery.TestPolicySpec{
+				TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+					LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+						Group: gwapiv1.GroupName,
+						Kind:  "Gateway",
+						Name:  gwapiv1.ObjectName(target),
+					},
+				},
+			},
+		}
+	}
+	topology := func(policies ...*machinery.TestPolicy) *machinery.Topology {
+		return machinery.NewGatewayAPITopology(
+			machinery.WithGateways(machinery.BuildGateway(), machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "other-gateway" })),
+			machinery.WithHTTPRoutes(machinery.BuildHTTPRoute()),
+			machinery.WithGatewayAPITopologyPolicies(lo.Map(policies, func(p *machinery.TestPolicy, _ int) machinery.Policy { return p })...),
+		)
+	}
+	urls := func(targetables []machinery.Targetable) []string {
+		return lo.Map(targetables, machinery.MapTargetableToURLFunc)
+	}
+
+	var attached, detached, changed []string
+	hooks := &policyHooks{kind: policyKind, hooks: PolicyHooks{
+		OnAttached: func(_ context.Context, _ machinery.Policy, targetables []machinery.Targetable, _ *machinery.Topology) {
+			attached = append(attached, urls(targetables)...)
+		},
+		OnDetached: func(_ context.Context, _ machinery.Policy, targetables []machinery.Targetable, _ *machinery.Topology) {
+			detached = append(detached, urls(targetables)...)
+		},
+		OnEffectiveChanged: func(_ context.Context, targetables []machinery.Targetable, _ *machinery.Topology) {
+			changed = append(changed, urls(targetables)...)
+		},
+	}}
+	gateway := "gateway.gateway.networking.k8s.io:my-namespace/my-gateway"
+	otherGateway := "gateway.gateway.networking.k8s.io:my-namespace/other-gateway"
+	route := "httproute.gateway.networking.k8s.io:my-namespace/my-http-route"
+
+	testCases := []struct {
+		name             string
+		topology         *machinery.Topology
+		expectedAttached []string
+		expectedDetached []string
+		expectedChanged  []string
+	}{
+		{
+			name:             "policy created",
+			topology:         topology(policy("1", "my-gateway")),
+			expectedAttached: []string{gateway},
+			expectedChanged:  []string{gateway, route},
+		},
+		{
+			name:     "nothing changed",
+			topology: topology(policy("1", "my-gateway")),
+		},
+		{
+			name:            "policy updated",
+			topology:        topology(policy("2", "my-gateway")),
+			expectedChanged: []string{gateway, route},
+		},
+		{
+			name:             "policy retargeted",
+			topology:         topology(policy("3", "other-gateway")),
+			expectedAttached: []string{otherGateway},
+			expectedDetached: []string{gateway},
+			expectedChanged:  []string{gateway, otherGateway, route},
+		},
+		{
+			name:             "policy deleted",
+			topology:         topology(),
+			expectedDetached: []string{otherGateway},
+			expectedChanged:  []string{otherGateway},
+		},
+	}
+	for _, tc := range testCases {
+		attached, detached, changed = nil, nil, nil
+		hooks.invoke(context.TODO(), tc.topology)
+		if !reflect.DeepEqual(attached, tc.expectedAttached) {
+			t.Errorf("%s: expected attached %v, got %v", tc.name, tc.expectedAttached, attached)
+		}
+		if !reflect.DeepEqual(detached, tc.expectedDetached) {
+			t.Errorf("%s: expected detached %v, got %v", tc.name, tc.expectedDetached, detached)
+		}
+		if changed = lo.Uniq(changed); len(changed) == 0 {
+			changed = nil
+		}
+		if !reflect.DeepEqual(changed, tc.expectedChanged) {
+			t.Errorf("%s: expected changed %v, got %v", tc.name, tc.expectedChanged, changed)
+		}
+	}
+}
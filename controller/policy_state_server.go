@@ -0,0 +1,330 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/kuadrant/policy-machinery/controller/policystate"
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// PolicyStateResponse is a versioned update of the effective policy state sent to a subscribed client.
+// Resources are the encoded effective policies added or changed since the last version acknowledged by the client,
+// indexed by key; Removed are the keys of the effective policies deleted since then. The first response of a stream
+// is a full snapshot of the state.
+type PolicyStateResponse struct {
+	Version   string
+	Nonce     string
+	Snapshot  bool
+	Resources map[string][]byte
+	Removed   []string
+}
+
+// PolicyStateServer streams the state of the effective policies to subscribed clients, such as data-plane agents or
+// sidecars, following the xDS protocol flow: every response must be acknowledged (ACK) or rejected (NACK) by the
+// client, identified by its nonce, before the next one is sent; responses are deltas relative to the last version
+// acknowledged by the client, and a rejected version is not sent again to the client.
+// The server implements the gRPC PolicyStateService (see RegisterPolicyStateServiceServer of the policystate package),
+// binding each bidirectional stream to a PolicyStateStream; other transports can use Subscribe directly.
+type PolicyStateServer struct {
+	policystate.UnimplementedPolicyStateServiceServer
+
+	mu        sync.Mutex
+	version   int64
+	resources map[string][]byte
+	streams   map[*PolicyStateStream]struct{}
+}
+
+var _ policystate.PolicyStateServiceServer = &PolicyStateServer{}
+
+func NewPolicyStateServer() *PolicyStateServer {
+	return &PolicyStateServer{
+		resources: make(map[string][]byte),
+		streams:   make(map[*PolicyStateStream]struct{}),
+	}
+}
+
+// Version returns the current version of the state, or an empty string if no state was published yet.
+func (s *PolicyStateServer) Version() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.versionString()
+}
+
+// Publish sets a new version of the state and sends it to the subscribed clients that are not waiting on the
+// acknowledgement of a previous response. It returns false if the state did not change.
+func (s *PolicyStateServer) Publish(resources map[string][]byte) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if updated, removed := policyStateDelta(s.resources, resources); len(updated) == 0 && len(removed) == 0 && s.version > 0 {
+		return false
+	}
+
+	s.resources = make(map[string][]byte, len(resources))
+	for key, value := range resources {
+		s.resources[key] = value
+	}
+	s.version++
+
+	for stream := range s.streams {
+		s.push(stream)
+	}
+	return true
+}
+
+// Subscribe opens a stream to a new client. The client receives the current state right away, if any.
+// The stream is closed when the context is done.
+func (s *PolicyStateServer) Subscribe(ctx context.Context) *PolicyStateStream {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stream := &PolicyStateStream{
+		server:    s,
+		responses: make(chan PolicyStateResponse, 1),
+		acked:     make(map[string][]byte),
+	}
+	s.streams[stream] = struct{}{}
+	s.push(stream)
+
+	go func() {
+		<-ctx.Done()
+		stream.Close()
+	}()
+
+	return stream
+}
+
+// StreamPolicyState serves a bidirectional gRPC stream of the state to a client. The responses of the stream are sent
+// as they are published; each request of the client acknowledges (ACK) the response whose nonce it carries, or rejects
+// it (NACK) if it carries an error detail. The initial request of the client, without a nonce, is ignored. A request
+// with an unknown nonce aborts the stream with an InvalidArgument error.
+func (s *PolicyStateServer) StreamPolicyState(grpcStream policystate.PolicyStateService_StreamPolicyStateServer) error {
+	ctx := grpcStream.Context()
+	stream := s.Subscribe(ctx)
+	defer stream.Close()
+
+	requests := make(chan *policystate.PolicyStateRequest)
+	errs := make(chan error, 1)
+	go func() {
+		for {
+			request, err := grpcStream.Recv()
+			if err != nil {
+				errs <- err
+				return
+			}
+			select {
+			case requests <- request:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	for {
+		select {
+		case response, ok := <-stream.Responses():
+			if !ok {
+				return ctx.Err()
+			}
+			if err := grpcStream.Send(&policystate.PolicyStateResponse{
+				VersionInfo:      response.Version,
+				Nonce:            response.Nonce,
+				Snapshot:         response.Snapshot,
+				Resources:        response.Resources,
+				RemovedResources: response.Removed,
+			}); err != nil {
+				return err
+			}
+		case request := <-requests:
+			if request.GetResponseNonce() == "" {
+				continue
+			}
+			var err error
+			if detail := request.GetErrorDetail(); detail != "" {
+				err = stream.Nack(request.GetResponseNonce(), errors.New(detail))
+			} else {
+				err = stream.Ack(request.GetResponseNonce())
+			}
+			if err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+		case err := <-errs:
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+	}
+}
+
+// Reconciler returns a reconcile function that publishes the effective policies computed out of the topology,
+// encoded with a given codec.
+func (s *PolicyStateServer) Reconciler(effectivePolicies func(*machinery.Topology) map[string]machinery.Policy, codec machinery.Codec) ReconcileFunc {
+	return func(ctx context.Context, _ []ResourceEvent, topology *machinery.Topology) {
+		logger := LoggerFromContext(ctx).WithName("policy state server")
+
+		resources := make(map[string][]byte)
+		for key, policy := range effectivePolicies(topology) {
+			data, err := codec.Marshal(policy)
+			if err != nil {
+				logger.Error(err, "failed to encode effective policy", "key", key)
+				return
+			}
+			resources[key] = data
+		}
+
+		if s.Publish(resources) {
+			logger.V(1).Info("published effective policies", "version", s.Version())
+		}
+	}
+}
+
+func (s *PolicyStateServer) versionString() string {
+	if s.version == 0 {
+		return ""
+	}
+	return strconv.FormatInt(s.version, 10)
+}
+
+// push sends the state to a client, unless the client is waiting on a response or rejected the current version.
+// It must be called with the lock held.
+func (s *PolicyStateServer) push(stream *PolicyStateStream) {
+	version := s.versionString()
+	if stream.closed || stream.pending != nil || s.version == 0 || version == stream.ackedVersion || version == stream.nackedVersion {
+		return
+	}
+
+	updated, removed := policyStateDelta(stream.acked, s.resources)
+	stream.nonce++
+	response := PolicyStateResponse{
+		Version:   version,
+		Nonce:     strconv.FormatInt(stream.nonce, 10),
+		Snapshot:  stream.ackedVersion == "",
+		Resources: updated,
+		Removed:   removed,
+	}
+	stream.pending = &response
+	stream.responses <- response // never blocks: there is at most one response pending acknowledgement
+}
+
+// PolicyStateStream is the stream of responses of the PolicyStateServer to a subscribed client.
+type PolicyStateStream struct {
+	server        *PolicyStateServer
+	responses     chan PolicyStateResponse
+	nonce         int64
+	pending       *PolicyStateResponse
+	acked         map[string][]byte
+	ackedVersion  string
+	nackedVersion string
+	lastError     error
+	closed        bool
+}
+
+// Responses returns the channel of responses to the client. The channel is closed when the stream is closed.
+func (s *PolicyStateStream) Responses() <-chan PolicyStateResponse {
+	return s.responses
+}
+
+// Ack acknowledges the response with a given nonce, i.e. the client accepted the version of the state.
+func (s *PolicyStateStream) Ack(nonce string) error {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+
+	response, err := s.resolve(nonce)
+	if err != nil {
+		return err
+	}
+	for key, value := range response.Resources {
+		s.acked[key] = value
+	}
+	for _, key := range response.Removed {
+		delete(s.acked, key)
+	}
+	s.ackedVersion = response.Version
+	s.lastError = nil
+	s.server.push(s)
+	return nil
+}
+
+// Nack rejects the response with a given nonce, i.e. the client failed to apply the version of the state and keeps
+// the last version it acknowledged. The rejected version is not sent again to the client.
+func (s *PolicyStateStream) Nack(nonce string, reason error) error {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+
+	response, err := s.resolve(nonce)
+	if err != nil {
+		return err
+	}
+	s.nackedVersion = response.Version
+	s.lastError = reason
+	s.server.push(s)
+	return nil
+}
+
+// AckedVersion returns the last version of the state acknowledged by the client.
+func (s *PolicyStateStream) AckedVersion() string {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+	return s.ackedVersion
+}
+
+// LastError returns the reason of the last rejection by the client, if the client has not acknowledged any version
+// since then.
+func (s *PolicyStateStream) LastError() error {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+	return s.lastError
+}
+
+// Close unsubscribes the client and closes the channel of responses.
+func (s *PolicyStateStream) Close() {
+	s.server.mu.Lock()
+	defer s.server.mu.Unlock()
+
+	if s.closed {
+		return
+	}
+	s.closed = true
+	delete(s.server.streams, s)
+	close(s.responses)
+}
+
+// resolve returns the pending response with a given nonce and clears it. It must be called with the lock held.
+func (s *PolicyStateStream) resolve(nonce string) (*PolicyStateResponse, error) {
+	if s.pending == nil || s.pending.Nonce != nonce {
+		return nil, fmt.Errorf("unknown nonce %q", nonce)
+	}
+	response := s.pending
+	s.pending = nil
+	return response, nil
+}
+
+// policyStateDelta returns the resources added or changed from one state to another, and the sorted keys of the
+// resources removed.
+func policyStateDelta(from, to map[string][]byte) (map[string][]byte, []string) {
+	updated := make(map[string][]byte)
+	for key, value := range to {
+		if current, ok := from[key]; !ok || !bytes.Equal(current, value) {
+			updated[key] = value
+		}
+	}
+	var removed []string
+	for key := range from {
+		if _, ok := to[key]; !ok {
+			removed = append(removed, key)
+		}
+	}
+	sort.Strings(removed)
+	return updated, removed
+}
@@ -0,0 +1,201 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/kuadrant/policy-machinery/controller/policystate"
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func receive(t *testing.T, stream *PolicyStateStream) PolicyStateResponse {
+	t.Helper()
+	select {
+	case response := <-stream.Responses():
+		return response
+	default:
+		t.Fatalf("expected a response")
+	}
+	return PolicyStateResponse{}
+}
+
+func expectNoResponse(t *testing.T, stream *PolicyStateStream) {
+	t.Helper()
+	select {
+	case response := <-stream.Responses():
+		t.Fatalf("expected no response, got %+v", response)
+	default:
+	}
+}
+
+func TestPolicyStateServer(t *testing.T) {
+	server := NewPolicyStateServer()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	stream := server.Subscribe(ctx)
+	expectNoResponse(t, stream)
+
+	server.Publish(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	response := receive(t, stream)
+	if !response.Snapshot || response.Version != "1" || !reflect.DeepEqual(response.Resources, map[string][]byte{"a": []byte("1"), "b": []byte("2")}) {
+		t.Fatalf("unexpected snapshot: %+v", response)
+	}
+
+	// no response is sent until the previous one is acknowledged
+	server.Publish(map[string][]byte{"a": []byte("1"), "b": []byte("3")})
+	expectNoResponse(t, stream)
+
+	if err := stream.Ack("unknown"); err == nil {
+		t.Errorf("expected error acknowledging an unknown nonce")
+	}
+	if err := stream.Ack(response.Nonce); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response = receive(t, stream)
+	if response.Snapshot || response.Version != "2" || !reflect.DeepEqual(response.Resources, map[string][]byte{"b": []byte("3")}) || len(response.Removed) != 0 {
+		t.Fatalf("unexpected delta: %+v", response)
+	}
+
+	// a rejected version is not sent again
+	if err := stream.Nack(response.Nonce, errors.New("invalid config")); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	expectNoResponse(t, stream)
+	if stream.AckedVersion() != "1" || stream.LastError() == nil {
+		t.Errorf("expected client to keep version 1 with an error, got %s, %v", stream.AckedVersion(), stream.LastError())
+	}
+
+	// the next version is a delta relative to the last acknowledged version
+	server.Publish(map[string][]byte{"b": []byte("4")})
+	response = receive(t, stream)
+	if response.Version != "3" || !reflect.DeepEqual(response.Resources, map[string][]byte{"b": []byte("4")}) || !reflect.DeepEqual(response.Removed, []string{"a"}) {
+		t.Fatalf("unexpected delta: %+v", response)
+	}
+	if err := stream.Ack(response.Nonce); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if stream.AckedVersion() != "3" || stream.LastError() != nil {
+		t.Errorf("expected client at version 3 without errors, got %s, %v", stream.AckedVersion(), stream.LastError())
+	}
+
+	if server.Publish(map[string][]byte{"b": []byte("4")}) {
+		t.Errorf("expected unchanged state not to be published")
+	}
+
+	// new clients receive the current state right away
+	other := server.Subscribe(ctx)
+	response = receive(t, other)
+	if !response.Snapshot || response.Version != "3" || !reflect.DeepEqual(response.Resources, map[string][]byte{"b": []byte("4")}) {
+		t.Fatalf("unexpected snapshot: %+v", response)
+	}
+
+	other.Close()
+	if _, ok := <-other.Responses(); ok {
+		t.Errorf("expected closed stream")
+	}
+	server.Publish(map[string][]byte{"c": []byte("5")})
+	receive(t, stream)
+}
+
+func TestPolicyStateServerReconciler(t *testing.T) {
+	server := NewPolicyStateServer()
+	stream := server.Subscribe(context.Background())
+
+	topology := machinery.NewTopology(machinery.WithTargetables(&machinery.Orange{Name: "my-orange"}))
+	reconcile := server.Reconciler(func(topology *machinery.Topology) map[string]machinery.Policy {
+		policies := make(map[string]machinery.Policy)
+		for _, targetable := range topology.Targetables().Items() {
+			policies[targetable.GetURL()] = &machinery.FruitPolicy{}
+		}
+		return policies
+	}, machinery.JSONCodec)
+	reconcile(context.Background(), nil, topology)
+
+	response := receive(t, stream)
+	if len(response.Resources) != 1 {
+		t.Errorf("expected 1 effective policy, got %+v", response)
+	}
+}
+
+func TestPolicyStateServerGRPC(t *testing.T) {
+	server := NewPolicyStateServer()
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	policystate.RegisterPolicyStateServiceServer(grpcServer, server)
+	go grpcServer.Serve(listener)
+	defer grpcServer.Stop()
+
+	conn, err := grpc.NewClient("passthrough:///bufconn",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	defer conn.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	stream, err := policystate.NewPolicyStateServiceClient(conn).StreamPolicyState(ctx)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := stream.Send(&policystate.PolicyStateRequest{NodeId: "my-agent"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	server.Publish(map[string][]byte{"a": []byte("1"), "b": []byte("2")})
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !response.GetSnapshot() || response.GetVersionInfo() != "1" || !reflect.DeepEqual(response.GetResources(), map[string][]byte{"a": []byte("1"), "b": []byte("2")}) {
+		t.Fatalf("unexpected snapshot: %+v", response)
+	}
+
+	// ACK
+	server.Publish(map[string][]byte{"a": []byte("1"), "b": []byte("3")})
+	if err := stream.Send(&policystate.PolicyStateRequest{NodeId: "my-agent", VersionInfo: "1", ResponseNonce: response.GetNonce()}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	response, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.GetSnapshot() || response.GetVersionInfo() != "2" || !reflect.DeepEqual(response.GetResources(), map[string][]byte{"b": []byte("3")}) {
+		t.Fatalf("unexpected delta: %+v", response)
+	}
+
+	// NACK: the delta of the next version is relative to the last version acknowledged
+	if err := stream.Send(&policystate.PolicyStateRequest{NodeId: "my-agent", VersionInfo: "1", ResponseNonce: response.GetNonce(), ErrorDetail: "invalid policy b"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	server.Publish(map[string][]byte{"a": []byte("1")})
+	response, err = stream.Recv()
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if response.GetVersionInfo() != "3" || len(response.GetResources()) != 0 || !reflect.DeepEqual(response.GetRemovedResources(), []string{"b"}) {
+		t.Fatalf("unexpected delta: %+v", response)
+	}
+
+	// unknown nonce
+	if err := stream.Send(&policystate.PolicyStateRequest{NodeId: "my-agent", ResponseNonce: "unknown"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if _, err := stream.Recv(); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("expected an InvalidArgument error, got %v", err)
+	}
+}
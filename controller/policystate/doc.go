@@ -0,0 +1,5 @@
+// Package policystate is the gRPC API of the policy state server (see controller.PolicyStateServer), which streams the
+// state of the effective policies to data-plane agents and sidecars.
+package policystate
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative policystate.proto
@@ -0,0 +1,253 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.6
+// 	protoc        (unknown)
+// source: policystate.proto
+
+package policystate
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// PolicyStateRequest is a request of a client, acknowledging or rejecting a response of the server.
+type PolicyStateRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Identifier of the client, for diagnostics.
+	NodeId string `protobuf:"bytes,1,opt,name=node_id,json=nodeId,proto3" json:"node_id,omitempty"`
+	// Version of the state last acknowledged by the client.
+	VersionInfo string `protobuf:"bytes,2,opt,name=version_info,json=versionInfo,proto3" json:"version_info,omitempty"`
+	// Nonce of the response acknowledged or rejected by the request. Empty in the initial request of a stream.
+	ResponseNonce string `protobuf:"bytes,3,opt,name=response_nonce,json=responseNonce,proto3" json:"response_nonce,omitempty"`
+	// Reason why the client rejected the response (NACK). Empty if the client acknowledges the response (ACK).
+	ErrorDetail   string `protobuf:"bytes,4,opt,name=error_detail,json=errorDetail,proto3" json:"error_detail,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *PolicyStateRequest) Reset() {
+	*x = PolicyStateRequest{}
+	mi := &file_policystate_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyStateRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyStateRequest) ProtoMessage() {}
+
+func (x *PolicyStateRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_policystate_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyStateRequest.ProtoReflect.Descriptor instead.
+func (*PolicyStateRequest) Descriptor() ([]byte, []int) {
+	return file_policystate_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *PolicyStateRequest) GetNodeId() string {
+	if x != nil {
+		return x.NodeId
+	}
+	return ""
+}
+
+func (x *PolicyStateRequest) GetVersionInfo() string {
+	if x != nil {
+		return x.VersionInfo
+	}
+	return ""
+}
+
+func (x *PolicyStateRequest) GetResponseNonce() string {
+	if x != nil {
+		return x.ResponseNonce
+	}
+	return ""
+}
+
+func (x *PolicyStateRequest) GetErrorDetail() string {
+	if x != nil {
+		return x.ErrorDetail
+	}
+	return ""
+}
+
+// PolicyStateResponse is a versioned update of the state of the effective policies sent to a client.
+type PolicyStateResponse struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Version of the state.
+	VersionInfo string `protobuf:"bytes,1,opt,name=version_info,json=versionInfo,proto3" json:"version_info,omitempty"`
+	// Nonce of the response, to be sent back by the client in the request that acknowledges or rejects it.
+	Nonce string `protobuf:"bytes,2,opt,name=nonce,proto3" json:"nonce,omitempty"`
+	// Whether the response is a full snapshot of the state, rather than a delta relative to the last version
+	// acknowledged by the client.
+	Snapshot bool `protobuf:"varint,3,opt,name=snapshot,proto3" json:"snapshot,omitempty"`
+	// Encoded effective policies added or changed since the last version acknowledged by the client, indexed by key.
+	Resources map[string][]byte `protobuf:"bytes,4,rep,name=resources,proto3" json:"resources,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	// Keys of the effective policies removed since the last version acknowledged by the client.
+	RemovedResources []string `protobuf:"bytes,5,rep,name=removed_resources,json=removedResources,proto3" json:"removed_resources,omitempty"`
+	unknownFields    protoimpl.UnknownFields
+	sizeCache        protoimpl.SizeCache
+}
+
+func (x *PolicyStateResponse) Reset() {
+	*x = PolicyStateResponse{}
+	mi := &file_policystate_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *PolicyStateResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*PolicyStateResponse) ProtoMessage() {}
+
+func (x *PolicyStateResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_policystate_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use PolicyStateResponse.ProtoReflect.Descriptor instead.
+func (*PolicyStateResponse) Descriptor() ([]byte, []int) {
+	return file_policystate_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *PolicyStateResponse) GetVersionInfo() string {
+	if x != nil {
+		return x.VersionInfo
+	}
+	return ""
+}
+
+func (x *PolicyStateResponse) GetNonce() string {
+	if x != nil {
+		return x.Nonce
+	}
+	return ""
+}
+
+func (x *PolicyStateResponse) GetSnapshot() bool {
+	if x != nil {
+		return x.Snapshot
+	}
+	return false
+}
+
+func (x *PolicyStateResponse) GetResources() map[string][]byte {
+	if x != nil {
+		return x.Resources
+	}
+	return nil
+}
+
+func (x *PolicyStateResponse) GetRemovedResources() []string {
+	if x != nil {
+		return x.RemovedResources
+	}
+	return nil
+}
+
+var File_policystate_proto protoreflect.FileDescriptor
+
+const file_policystate_proto_rawDesc = "" +
+	"\n" +
+	"\x11policystate.proto\x12'kuadrant.policymachinery.policystate.v1\"\x9a\x01\n" +
+	"\x12PolicyStateRequest\x12\x17\n" +
+	"\anode_id\x18\x01 \x01(\tR\x06nodeId\x12!\n" +
+	"\fversion_info\x18\x02 \x01(\tR\vversionInfo\x12%\n" +
+	"\x0eresponse_nonce\x18\x03 \x01(\tR\rresponseNonce\x12!\n" +
+	"\ferror_detail\x18\x04 \x01(\tR\verrorDetail\"\xc0\x02\n" +
+	"\x13PolicyStateResponse\x12!\n" +
+	"\fversion_info\x18\x01 \x01(\tR\vversionInfo\x12\x14\n" +
+	"\x05nonce\x18\x02 \x01(\tR\x05nonce\x12\x1a\n" +
+	"\bsnapshot\x18\x03 \x01(\bR\bsnapshot\x12i\n" +
+	"\tresources\x18\x04 \x03(\v2K.kuadrant.policymachinery.policystate.v1.PolicyStateResponse.ResourcesEntryR\tresources\x12+\n" +
+	"\x11removed_resources\x18\x05 \x03(\tR\x10removedResources\x1a<\n" +
+	"\x0eResourcesEntry\x12\x10\n" +
+	"\x03key\x18\x01 \x01(\tR\x03key\x12\x14\n" +
+	"\x05value\x18\x02 \x01(\fR\x05value:\x028\x012\xa9\x01\n" +
+	"\x12PolicyStateService\x12\x92\x01\n" +
+	"\x11StreamPolicyState\x12;.kuadrant.policymachinery.policystate.v1.PolicyStateRequest\x1a<.kuadrant.policymachinery.policystate.v1.PolicyStateResponse(\x010\x01B=Z;github.com/kuadrant/policy-machinery/controller/policystateb\x06proto3"
+
+var (
+	file_policystate_proto_rawDescOnce sync.Once
+	file_policystate_proto_rawDescData []byte
+)
+
+func file_policystate_proto_rawDescGZIP() []byte {
+	file_policystate_proto_rawDescOnce.Do(func() {
+		file_policystate_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_policystate_proto_rawDesc), len(file_policystate_proto_rawDesc)))
+	})
+	return file_policystate_proto_rawDescData
+}
+
+var file_policystate_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_policystate_proto_goTypes = []any{
+	(*PolicyStateRequest)(nil),  // 0: kuadrant.policymachinery.policystate.v1.PolicyStateRequest
+	(*PolicyStateResponse)(nil), // 1: kuadrant.policymachinery.policystate.v1.PolicyStateResponse
+	nil,                         // 2: kuadrant.policymachinery.policystate.v1.PolicyStateResponse.ResourcesEntry
+}
+var file_policystate_proto_depIdxs = []int32{
+	2, // 0: kuadrant.policymachinery.policystate.v1.PolicyStateResponse.resources:type_name -> kuadrant.policymachinery.policystate.v1.PolicyStateResponse.ResourcesEntry
+	0, // 1: kuadrant.policymachinery.policystate.v1.PolicyStateService.StreamPolicyState:input_type -> kuadrant.policymachinery.policystate.v1.PolicyStateRequest
+	1, // 2: kuadrant.policymachinery.policystate.v1.PolicyStateService.StreamPolicyState:output_type -> kuadrant.policymachinery.policystate.v1.PolicyStateResponse
+	2, // [2:3] is the sub-list for method output_type
+	1, // [1:2] is the sub-list for method input_type
+	1, // [1:1] is the sub-list for extension type_name
+	1, // [1:1] is the sub-list for extension extendee
+	0, // [0:1] is the sub-list for field type_name
+}
+
+func init() { file_policystate_proto_init() }
+func file_policystate_proto_init() {
+	if File_policystate_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_policystate_proto_rawDesc), len(file_policystate_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_policystate_proto_goTypes,
+		DependencyIndexes: file_policystate_proto_depIdxs,
+		MessageInfos:      file_policystate_proto_msgTypes,
+	}.Build()
+	File_policystate_proto = out.File
+	file_policystate_proto_goTypes = nil
+	file_policystate_proto_depIdxs = nil
+}
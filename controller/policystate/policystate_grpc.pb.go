@@ -0,0 +1,127 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: policystate.proto
+
+package policystate
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	PolicyStateService_StreamPolicyState_FullMethodName = "/kuadrant.policymachinery.policystate.v1.PolicyStateService/StreamPolicyState"
+)
+
+// PolicyStateServiceClient is the client API for PolicyStateService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// PolicyStateService streams the state of the effective policies to data-plane agents and sidecars, following the
+// xDS protocol flow: every response must be acknowledged (ACK) or rejected (NACK) by the client, identified by its
+// nonce, before the next one is sent.
+type PolicyStateServiceClient interface {
+	// StreamPolicyState opens a bidirectional stream of the state of the effective policies. The server sends the
+	// current state right away, if any; the client sends a request per response, acknowledging or rejecting it.
+	StreamPolicyState(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PolicyStateRequest, PolicyStateResponse], error)
+}
+
+type policyStateServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewPolicyStateServiceClient(cc grpc.ClientConnInterface) PolicyStateServiceClient {
+	return &policyStateServiceClient{cc}
+}
+
+func (c *policyStateServiceClient) StreamPolicyState(ctx context.Context, opts ...grpc.CallOption) (grpc.BidiStreamingClient[PolicyStateRequest, PolicyStateResponse], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &PolicyStateService_ServiceDesc.Streams[0], PolicyStateService_StreamPolicyState_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[PolicyStateRequest, PolicyStateResponse]{ClientStream: stream}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PolicyStateService_StreamPolicyStateClient = grpc.BidiStreamingClient[PolicyStateRequest, PolicyStateResponse]
+
+// PolicyStateServiceServer is the server API for PolicyStateService service.
+// All implementations must embed UnimplementedPolicyStateServiceServer
+// for forward compatibility.
+//
+// PolicyStateService streams the state of the effective policies to data-plane agents and sidecars, following the
+// xDS protocol flow: every response must be acknowledged (ACK) or rejected (NACK) by the client, identified by its
+// nonce, before the next one is sent.
+type PolicyStateServiceServer interface {
+	// StreamPolicyState opens a bidirectional stream of the state of the effective policies. The server sends the
+	// current state right away, if any; the client sends a request per response, acknowledging or rejecting it.
+	StreamPolicyState(grpc.BidiStreamingServer[PolicyStateRequest, PolicyStateResponse]) error
+	mustEmbedUnimplementedPolicyStateServiceServer()
+}
+
+// UnimplementedPolicyStateServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedPolicyStateServiceServer struct{}
+
+func (UnimplementedPolicyStateServiceServer) StreamPolicyState(grpc.BidiStreamingServer[PolicyStateRequest, PolicyStateResponse]) error {
+	return status.Errorf(codes.Unimplemented, "method StreamPolicyState not implemented")
+}
+func (UnimplementedPolicyStateServiceServer) mustEmbedUnimplementedPolicyStateServiceServer() {}
+func (UnimplementedPolicyStateServiceServer) testEmbeddedByValue()                            {}
+
+// UnsafePolicyStateServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to PolicyStateServiceServer will
+// result in compilation errors.
+type UnsafePolicyStateServiceServer interface {
+	mustEmbedUnimplementedPolicyStateServiceServer()
+}
+
+func RegisterPolicyStateServiceServer(s grpc.ServiceRegistrar, srv PolicyStateServiceServer) {
+	// If the following call pancis, it indicates UnimplementedPolicyStateServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&PolicyStateService_ServiceDesc, srv)
+}
+
+func _PolicyStateService_StreamPolicyState_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(PolicyStateServiceServer).StreamPolicyState(&grpc.GenericServerStream[PolicyStateRequest, PolicyStateResponse]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type PolicyStateService_StreamPolicyStateServer = grpc.BidiStreamingServer[PolicyStateRequest, PolicyStateResponse]
+
+// PolicyStateService_ServiceDesc is the grpc.ServiceDesc for PolicyStateService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var PolicyStateService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "kuadrant.policymachinery.policystate.v1.PolicyStateService",
+	HandlerType: (*PolicyStateServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamPolicyState",
+			Handler:       _PolicyStateService_StreamPolicyState_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "policystate.proto",
+}
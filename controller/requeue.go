@@ -0,0 +1,223 @@
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// ErrorReconcileFunc is a reconciliation function that can fail or ask for the reconciliation to be retried later.
+// A RequeueError (see Requeue and RequeueAfter) schedules the retry after a given time; any other error schedules the
+// retry with exponential backoff.
+type ErrorReconcileFunc func(context.Context, []ResourceEvent, *machinery.Topology) error
+
+// WithErrorReconcile sets a reconciliation function that can return errors, instead of the one set with WithReconcile.
+// Events whose reconciliation failed are reconciled again, along with any other event that failed meanwhile, until the
+// reconciliation succeeds.
+func WithErrorReconcile(reconcile ErrorReconcileFunc) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.errorReconcile = reconcile
+	}
+}
+
+// WithRetryBackoff sets the initial and maximum delays between retries of failed reconciliations that did not ask
+// for a specific delay. The delay doubles after each consecutive failure. Defaults to 1s and 5m.
+func WithRetryBackoff(initial, max time.Duration) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.retryBackoff = retryBackoff{initial: initial, max: max}
+	}
+}
+
+// RequeueError asks for the reconciliation to be retried after a given time. The wrapped error, if any, is the
+// reason of the failure; a RequeueError without an error is not a failure, e.g. polling for an external resource to
+// be ready.
+type RequeueError struct {
+	After time.Duration
+	Err   error
+}
+
+func (e *RequeueError) Error() string {
+	if e.Err == nil {
+		return fmt.Sprintf("requeue after %s", e.After)
+	}
+	return fmt.Sprintf("requeue after %s: %v", e.After, e.Err)
+}
+
+func (e *RequeueError) Unwrap() error {
+	return e.Err
+}
+
+// Requeue returns an error that asks for the reconciliation to be retried after a given time, without a failure.
+func Requeue(after time.Duration) error {
+	return &RequeueError{After: after}
+}
+
+// RequeueAfter returns an error that asks for the reconciliation that failed with a given error to be retried after
+// a given time.
+func RequeueAfter(after time.Duration, err error) error {
+	return &RequeueError{After: after, Err: err}
+}
+
+// RequeueAfterOf returns the shortest time to retry a reconciliation asked by the RequeueErrors in the tree of an
+// error, e.g. of errors aggregated with errors.Join. It returns false if the error has no RequeueError.
+func RequeueAfterOf(err error) (time.Duration, bool) {
+	var after time.Duration
+	var found bool
+	walkErrors(err, func(err error) {
+		if requeue, ok := err.(*RequeueError); ok && (!found || requeue.After < after) {
+			after, found = requeue.After, true
+		}
+	})
+	return after, found
+}
+
+// IsFailure returns false if an error only asks for the reconciliation to be retried, without a failure.
+func IsFailure(err error) bool {
+	switch e := err.(type) {
+	case nil:
+		return false
+	case *RequeueError:
+		return IsFailure(e.Err)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if IsFailure(err) {
+				return true
+			}
+		}
+		return false
+	case interface{ Unwrap() error }:
+		if inner := e.Unwrap(); inner != nil {
+			return IsFailure(inner)
+		}
+	}
+	return true
+}
+
+func walkErrors(err error, f func(error)) {
+	if err == nil {
+		return
+	}
+	f(err)
+	switch e := err.(type) {
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			walkErrors(err, f)
+		}
+	case interface{ Unwrap() error }:
+		walkErrors(e.Unwrap(), f)
+	}
+}
+
+// ErrorWorkflow is a Workflow whose reconciliation functions can return errors.
+// A failed precondition skips the tasks and the postcondition; errors of the tasks do not prevent the postcondition
+// from running. The errors of all the functions run are aggregated with errors.Join.
+type ErrorWorkflow struct {
+	Precondition  ErrorReconcileFunc
+	Tasks         []ErrorReconcileFunc
+	Postcondition ErrorReconcileFunc
+}
+
+func (d *ErrorWorkflow) Run(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) error {
+	// run precondition reconcile function
+	if d.Precondition != nil {
+		if err := tracedErrorReconcileFunc(d.Precondition)(ctx, resourceEvents, topology); err != nil {
+			return err
+		}
+	}
+
+	// dispatch the event to concurrent tasks
+	errs := make([]error, len(d.Tasks)+1)
+	waitGroup := &sync.WaitGroup{}
+	waitGroup.Add(len(d.Tasks))
+	for i, f := range d.Tasks {
+		go func() {
+			defer waitGroup.Done()
+			errs[i] = tracedErrorReconcileFunc(f)(ctx, resourceEvents, topology)
+		}()
+	}
+	waitGroup.Wait()
+
+	// run postcondition reconcile function
+	if d.Postcondition != nil {
+		errs[len(d.Tasks)] = tracedErrorReconcileFunc(d.Postcondition)(ctx, resourceEvents, topology)
+	}
+
+	return errors.Join(errs...)
+}
+
+// IgnoreErrors adapts a reconciliation function that can return errors to one that does not, logging the errors.
+func IgnoreErrors(reconcile ErrorReconcileFunc) ReconcileFunc {
+	return func(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) {
+		if err := reconcile(ctx, resourceEvents, topology); IsFailure(err) {
+			LoggerFromContext(ctx).Error(err, "reconciliation failed")
+		}
+	}
+}
+
+// WithoutErrors adapts a reconciliation function to one that returns errors, which never fails.
+func WithoutErrors(reconcile ReconcileFunc) ErrorReconcileFunc {
+	return func(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) error {
+		reconcile(ctx, resourceEvents, topology)
+		return nil
+	}
+}
+
+type retryBackoff struct {
+	initial time.Duration
+	max     time.Duration
+}
+
+var defaultRetryBackoff = retryBackoff{initial: time.Second, max: 5 * time.Minute}
+
+// delay returns the delay before the retry after a number of consecutive failures.
+func (b retryBackoff) delay(failures int) time.Duration {
+	delay := b.initial
+	for i := 1; i < failures && delay < b.max; i++ {
+		delay *= 2
+	}
+	return min(delay, b.max)
+}
+
+// retries tracks the events whose reconciliation is to be retried.
+type retries struct {
+	backoff  retryBackoff
+	events   []ResourceEvent
+	failures int
+	timer    *time.Timer
+	due      time.Time
+}
+
+// schedule adds events to be retried after the reconciliation failed with a given error, and returns the delay
+// until the retry. It must be called with the lock of the controller held.
+func (r *retries) schedule(resourceEvents []ResourceEvent, err error, retry func()) time.Duration {
+	r.events = append(r.events, resourceEvents...)
+	if IsFailure(err) {
+		r.failures++
+	}
+	delay, ok := RequeueAfterOf(err)
+	if !ok {
+		delay = r.backoff.delay(r.failures)
+	}
+	due := time.Now().Add(delay)
+	if r.timer != nil {
+		if !due.Before(r.due) {
+			return time.Until(r.due)
+		}
+		r.timer.Stop()
+	}
+	r.due = due
+	r.timer = time.AfterFunc(delay, retry)
+	return delay
+}
+
+// take returns the events to be retried and clears them. It must be called with the lock of the controller held.
+func (r *retries) take() []ResourceEvent {
+	events := r.events
+	r.events = nil
+	r.timer = nil
+	return events
+}
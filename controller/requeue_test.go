@@ -0,0 +1,169 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestRequeueAfterOf(t *testing.T) {
+	testCases := []struct {
+		name          string
+		err           error
+		expectedAfter time.Duration
+		expectedFound bool
+		expectFailure bool
+	}{
+		{
+			name: "nil",
+		},
+		{
+			name:          "plain error",
+			err:           errors.New("boom"),
+			expectFailure: true,
+		},
+		{
+			name:          "requeue",
+			err:           Requeue(time.Minute),
+			expectedAfter: time.Minute,
+			expectedFound: true,
+		},
+		{
+			name:          "wrapped requeue",
+			err:           fmt.Errorf("waiting for the load balancer: %w", Requeue(time.Minute)),
+			expectedAfter: time.Minute,
+			expectedFound: true,
+		},
+		{
+			name:          "requeue after failure",
+			err:           RequeueAfter(time.Minute, errors.New("boom")),
+			expectedAfter: time.Minute,
+			expectedFound: true,
+			expectFailure: true,
+		},
+		{
+			name:          "joined",
+			err:           errors.Join(Requeue(time.Minute), errors.New("boom"), RequeueAfter(time.Second, errors.New("bang"))),
+			expectedAfter: time.Second,
+			expectedFound: true,
+			expectFailure: true,
+		},
+		{
+			name:          "joined requeues",
+			err:           errors.Join(Requeue(time.Minute), nil, Requeue(time.Second)),
+			expectedAfter: time.Second,
+			expectedFound: true,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			after, found := RequeueAfterOf(tc.err)
+			if after != tc.expectedAfter || found != tc.expectedFound {
+				t.Errorf("expected (%s, %t), got (%s, %t)", tc.expectedAfter, tc.expectedFound, after, found)
+			}
+			if failure := IsFailure(tc.err); failure != tc.expectFailure {
+				t.Errorf("expected failure %t, got %t", tc.expectFailure, failure)
+			}
+		})
+	}
+}
+
+func TestErrorWorkflow(t *testing.T) {
+	var mu sync.Mutex
+	var calls []string
+	call := func(name string, err error) ErrorReconcileFunc {
+		return func(context.Context, []ResourceEvent, *machinery.Topology) error {
+			mu.Lock()
+			defer mu.Unlock()
+			calls = append(calls, name)
+			return err
+		}
+	}
+
+	errA := errors.New("a failed")
+	errPost := errors.New("postcondition failed")
+	workflow := &ErrorWorkflow{
+		Precondition:  call("pre", nil),
+		Tasks:         []ErrorReconcileFunc{call("a", errA), call("b", Requeue(time.Second))},
+		Postcondition: call("post", errPost),
+	}
+	err := workflow.Run(context.TODO(), nil, nil)
+	if len(calls) != 4 || calls[len(calls)-1] != "post" {
+		t.Errorf("expected all functions called, got %v", calls)
+	}
+	if !errors.Is(err, errA) || !errors.Is(err, errPost) {
+		t.Errorf("expected aggregated errors, got %v", err)
+	}
+	if after, _ := RequeueAfterOf(err); after != time.Second {
+		t.Errorf("expected requeue after 1s, got %s", after)
+	}
+
+	calls = nil
+	errPre := errors.New("precondition failed")
+	workflow.Precondition = call("pre", errPre)
+	if err := workflow.Run(context.TODO(), nil, nil); !errors.Is(err, errPre) || errors.Is(err, errA) {
+		t.Errorf("expected precondition error only, got %v", err)
+	}
+	if len(calls) != 1 {
+		t.Errorf("expected tasks skipped, got %v", calls)
+	}
+}
+
+func TestRetryBackoff(t *testing.T) {
+	backoff := retryBackoff{initial: time.Second, max: 5 * time.Second}
+	for failures, expected := range []time.Duration{time.Second, time.Second, 2 * time.Second, 4 * time.Second, 5 * time.Second, 5 * time.Second} {
+		if delay := backoff.delay(failures); delay != expected {
+			t.Errorf("expected delay %s after %d failures, got %s", expected, failures, delay)
+		}
+	}
+}
+
+func TestControllerRetriesFailedReconciliation(t *testing.T) {
+	attempts := make(chan []ResourceEvent, 10)
+	var failures int
+	c := NewController(
+		WithRetryBackoff(10*time.Millisecond, 10*time.Millisecond),
+		WithErrorReconcile(func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) error {
+			attempts <- events
+			failures++
+			switch failures {
+			case 1:
+				return errors.New("boom")
+			case 2:
+				return Requeue(10 * time.Millisecond)
+			}
+			return nil
+		}),
+	)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", UID: "my-configmap"}}
+	configMap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	c.add(configMap)
+
+	for i := 0; i < 3; i++ {
+		select {
+		case events := <-attempts:
+			if len(events) != 1 || events[0].NewObject.GetName() != "my-configmap" {
+				t.Errorf("expected the failed event to be retried, got %v", events)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("expected attempt %d", i+1)
+		}
+	}
+	select {
+	case events := <-attempts:
+		t.Errorf("expected no more retries after success, got %v", events)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,196 @@
+package controller
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"text/template"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// ResourceTemplateData is the data a resource template is rendered with, once per path of targetables.
+type ResourceTemplateData struct {
+	// Target is the targetable the resources are rendered for, i.e. the last targetable of the path.
+	Target machinery.Targetable
+	// Path is the path of targetables from a root of the topology to the target.
+	Path []machinery.Targetable
+	// PathID is the identifier of the path (see machinery.PathID).
+	PathID string
+	// EffectivePolicy is the effective policy of the path, if the template computes one (see
+	// ResourceTemplate.EffectivePolicy).
+	EffectivePolicy machinery.Policy
+	// Topology is the topology the path belongs to.
+	Topology *machinery.Topology
+}
+
+// ResourceRenderer renders the manifests of the desired resources for a path of targetables.
+type ResourceRenderer interface {
+	Render(data ResourceTemplateData) ([]*unstructured.Unstructured, error)
+}
+
+// ResourceTemplate renders the desired downstream resources of the paths of targetables of a topology, e.g. an
+// EnvoyFilter per Gateway with an effective policy, so simple integrations do not require writing a reconciler (see
+// TemplateProvider).
+type ResourceTemplate struct {
+	// Targets are the predicates of the targetables to render the resources for, e.g. machinery.IsKind(GatewayKind).
+	// Empty means all the targetables.
+	Targets []machinery.FilterFunc
+	// RootKinds are the kinds of the targetables the paths start at (see machinery.RootsOf). Empty means the roots of
+	// the topology.
+	RootKinds []schema.GroupKind
+	// EffectivePolicy returns the effective policy of a path, e.g. with machinery.EffectivePolicyForPath. Paths
+	// without an effective policy render no resource. Leave it nil to render the resources of every path.
+	EffectivePolicy func(topology *machinery.Topology, path []machinery.Targetable) (machinery.Policy, bool)
+	// Renderer renders the resources of a path, e.g. a Go template (see NewGoTemplateRenderer).
+	Renderer ResourceRenderer
+}
+
+// render renders the resources of all the paths of the template in a topology, in order of the URLs of the targets.
+func (t ResourceTemplate) render(topology *machinery.Topology) ([]*unstructured.Unstructured, error) {
+	var resources []*unstructured.Unstructured
+	targetables := topology.Targetables()
+	byURL := func(items []machinery.Targetable) []machinery.Targetable {
+		sort.Slice(items, func(i, j int) bool { return items[i].GetURL() < items[j].GetURL() })
+		return items
+	}
+	roots := byURL(targetables.RootsOf(t.RootKinds...))
+	for _, target := range byURL(targetables.Items(t.Targets...)) {
+		for _, root := range roots {
+			for _, path := range targetables.Paths(root, target) {
+				data := ResourceTemplateData{Target: target, Path: path, PathID: machinery.PathID(path), Topology: topology}
+				if t.EffectivePolicy != nil {
+					policy, ok := t.EffectivePolicy(topology, path)
+					if !ok {
+						continue
+					}
+					data.EffectivePolicy = policy
+				}
+				rendered, err := t.Renderer.Render(data)
+				if err != nil {
+					return nil, fmt.Errorf("failed to render the resources of %s: %w", target.GetURL(), err)
+				}
+				resources = append(resources, rendered...)
+			}
+		}
+	}
+	return resources, nil
+}
+
+// TemplateProvider returns a provider of the set of desired objects rendered by resource templates, e.g. to reconcile
+// them with an ObjectSetReconciler (see NewTemplateReconciler).
+// Templates rendered for multiple paths must render resources with different names per path, e.g. using the hash
+// of the path; rendering different resources with the same name fails, whereas identical ones are rendered once.
+func TemplateProvider(templates ...ResourceTemplate) ObjectSetProvider {
+	return func(_ context.Context, topology *machinery.Topology) ([]any, error) {
+		var objs []any
+		rendered := map[string]*unstructured.Unstructured{}
+		for _, t := range templates {
+			resources, err := t.render(topology)
+			if err != nil {
+				return nil, err
+			}
+			for _, resource := range resources {
+				key := fmt.Sprintf("%s#%s", resource.GroupVersionKind().GroupKind().String(), namespacedName(resource))
+				if previous, ok := rendered[key]; ok {
+					if !reflect.DeepEqual(previous.Object, resource.Object) {
+						return nil, fmt.Errorf("different resources rendered with the same name: %s %s", resource.GetKind(), namespacedName(resource))
+					}
+					continue
+				}
+				rendered[key] = resource
+				objs = append(objs, resource)
+			}
+		}
+		return objs, nil
+	}
+}
+
+// NewTemplateReconciler returns an ObjectSetReconciler of the resources of a given resource rendered by resource
+// templates. The rendered resources must all be of the given resource.
+func NewTemplateReconciler(client dynamic.Interface, resource schema.GroupVersionResource, name string, templates ...ResourceTemplate) *ObjectSetReconciler {
+	return &ObjectSetReconciler{
+		Client:   client,
+		Resource: resource,
+		Name:     name,
+		Provider: TemplateProvider(templates...),
+	}
+}
+
+// goTemplateRenderer renders the resources of a path with a Go template.
+type goTemplateRenderer struct {
+	template *template.Template
+}
+
+// NewGoTemplateRenderer parses a Go template (see text/template) of the YAML or JSON manifests of the resources of a
+// path, separated by "---", that is rendered with a ResourceTemplateData, e.g.:
+//
+//	apiVersion: networking.istio.io/v1alpha3
+//	kind: EnvoyFilter
+//	metadata:
+//	  name: {{ .Target.GetName }}-{{ hash .PathID }}
+//	  namespace: {{ .Target.GetNamespace }}
+//	spec:
+//	  config: {{ toJson .EffectivePolicy.Spec }}
+//
+// Besides the functions of text/template, the template can use toJson and toYaml to serialize a value, indent to
+// indent every line of a text by a number of spaces, and hash for a short hash of a text. Empty manifests are
+// skipped, so templates can render no resource for some paths.
+func NewGoTemplateRenderer(name, text string) (ResourceRenderer, error) {
+	t, err := template.New(name).Option("missingkey=error").Funcs(template.FuncMap{
+		"toJson": func(v any) (string, error) {
+			b, err := json.Marshal(v)
+			return string(b), err
+		},
+		"toYaml": func(v any) (string, error) {
+			b, err := yaml.Marshal(v)
+			return strings.TrimSuffix(string(b), "\n"), err
+		},
+		"indent": func(spaces int, text string) string {
+			padding := strings.Repeat(" ", spaces)
+			return padding + strings.ReplaceAll(text, "\n", "\n"+padding)
+		},
+		"hash": func(text string) string {
+			return fmt.Sprintf("%x", sha256.Sum256([]byte(text)))[:8]
+		},
+	}).Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	return &goTemplateRenderer{template: t}, nil
+}
+
+var manifestSeparator = regexp.MustCompile(`(?m)^---\s*$`)
+
+func (r *goTemplateRenderer) Render(data ResourceTemplateData) ([]*unstructured.Unstructured, error) {
+	var out bytes.Buffer
+	if err := r.template.Execute(&out, data); err != nil {
+		return nil, fmt.Errorf("failed to execute template %s: %w", r.template.Name(), err)
+	}
+	var resources []*unstructured.Unstructured
+	for _, manifest := range manifestSeparator.Split(out.String(), -1) {
+		if strings.TrimSpace(manifest) == "" {
+			continue
+		}
+		resource := &unstructured.Unstructured{}
+		if err := yaml.Unmarshal([]byte(manifest), &resource.Object); err != nil {
+			return nil, fmt.Errorf("failed to decode the manifest rendered by template %s: %w", r.template.Name(), err)
+		}
+		if len(resource.Object) == 0 {
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
@@ -0,0 +1,126 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestResourceTemplates(t *testing.T) {
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(machinery.BuildGatewayClass()),
+		machinery.WithGateways(
+			machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-1" }),
+			machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "gateway-2" }),
+		),
+		machinery.WithGatewayAPITopologyPolicies(&machinery.TestPolicy{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace"},
+			Spec: machinery.TestPolicySpec{
+				TargetRef: gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+					LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "gateway-2"},
+				},
+			},
+		}),
+	)
+
+	renderer := func(text string) ResourceRenderer {
+		r, err := NewGoTemplateRenderer("test", text)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		return r
+	}
+	render := func(templates ...ResourceTemplate) ([]string, error) {
+		objs, err := TemplateProvider(templates...)(context.TODO(), topology)
+		return lo.Map(objs, func(obj any, _ int) string {
+			u := obj.(*unstructured.Unstructured)
+			data, _, _ := unstructured.NestedString(u.Object, "data", "target")
+			return u.GetKind() + ":" + namespacedName(u) + "=" + data
+		}), err
+	}
+
+	// one resource per gateway with an effective policy
+	perGateway := ResourceTemplate{
+		Targets: []machinery.FilterFunc{machinery.IsKind(GatewayKind)},
+		EffectivePolicy: func(topology *machinery.Topology, path []machinery.Targetable) (machinery.Policy, bool) {
+			return machinery.EffectivePolicyForPath[*machinery.TestPolicy](topology, path)
+		},
+		Renderer: renderer(`apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Target.GetName }}-{{ hash .PathID }}
+  namespace: {{ .Target.GetNamespace }}
+data:
+  target: {{ .EffectivePolicy.Spec.TargetRef.Name }}
+  spec: {{ toJson .EffectivePolicy.Spec | printf "%q" }}
+`),
+	}
+	resources, err := render(perGateway)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(resources) != 1 || !strings.HasPrefix(resources[0], "ConfigMap:my-namespace/gateway-2-") || !strings.HasSuffix(resources[0], "=gateway-2") {
+		t.Errorf("expected one resource rendered for the gateway with an effective policy, got %v", resources)
+	}
+
+	// multiple and empty manifests, identical resources rendered once
+	perPath := ResourceTemplate{
+		Renderer: renderer(`{{ if eq .Target.GroupVersionKind.Kind "Gateway" }}
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: {{ .Target.GetName }}
+  namespace: my-namespace
+---
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: shared
+  namespace: my-namespace
+{{ end }}
+---
+`),
+	}
+	if resources, err := render(perPath); err != nil || strings.Join(resources, ",") != "ConfigMap:my-namespace/gateway-1=,ConfigMap:my-namespace/shared=,ConfigMap:my-namespace/gateway-2=" {
+		t.Errorf("unexpected resources: %v %v", resources, err)
+	}
+
+	// different resources with the same name
+	conflicting := ResourceTemplate{
+		Targets:  []machinery.FilterFunc{machinery.IsKind(GatewayKind)},
+		Renderer: renderer(`{"apiVersion": "v1", "kind": "ConfigMap", "metadata": {"name": "shared", "namespace": "my-namespace"}, "data": {"target": "{{ .Target.GetName }}"}}`),
+	}
+	if _, err := render(conflicting); err == nil {
+		t.Errorf("expected an error rendering different resources with the same name")
+	}
+
+	// missing fields fail
+	if _, err := render(ResourceTemplate{Renderer: renderer(`{{ .Missing }}`)}); err == nil {
+		t.Errorf("expected an error rendering a missing field")
+	}
+	if _, err := NewGoTemplateRenderer("invalid", `{{ .Target`); err == nil {
+		t.Errorf("expected an error parsing an invalid template")
+	}
+
+	// reconciled as an object set
+	client := newMutationPlanClient(t)
+	reconciler := NewTemplateReconciler(client, ConfigMapsResource, "per-gateway", perGateway)
+	if err := reconciler.Reconcile(context.TODO(), nil, topology); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, err := client.Resource(ConfigMapsResource).Namespace(metav1.NamespaceAll).List(context.TODO(), metav1.ListOptions{})
+	if err != nil || len(list.Items) != 1 || list.Items[0].GetLabels()[ObjectSetLabel] != "per-gateway" {
+		t.Errorf("expected the rendered resource to be created, got %v %v", list, err)
+	}
+}
@@ -2,18 +2,29 @@ package controller
 
 import (
 	core "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 // GroupKinds
 var (
 	// core
-	ServiceKind = core.SchemeGroupVersion.WithKind("Service").GroupKind()
+	ServiceKind   = core.SchemeGroupVersion.WithKind("Service").GroupKind()
+	ConfigMapKind = core.SchemeGroupVersion.WithKind("ConfigMap").GroupKind()
+	NamespaceKind = core.SchemeGroupVersion.WithKind("Namespace").GroupKind()
+	SecretKind    = core.SchemeGroupVersion.WithKind("Secret").GroupKind()
+	PodKind       = core.SchemeGroupVersion.WithKind("Pod").GroupKind()
+
+	// discovery
+	EndpointSliceKind = discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice").GroupKind()
 
 	// gateway api
 	GatewayClassKind = gwapiv1.SchemeGroupVersion.WithKind("GatewayClass").GroupKind()
 	GatewayKind      = gwapiv1.SchemeGroupVersion.WithKind("Gateway").GroupKind()
 	HTTPRouteKind    = gwapiv1.SchemeGroupVersion.WithKind("HTTPRoute").GroupKind()
+	TLSRouteKind     = gwapiv1alpha2.SchemeGroupVersion.WithKind("TLSRoute").GroupKind()
+	UDPRouteKind     = gwapiv1alpha2.SchemeGroupVersion.WithKind("UDPRoute").GroupKind()
 )
 
 // API Resources
@@ -21,9 +32,17 @@ var (
 	// core
 	ServicesResource   = core.SchemeGroupVersion.WithResource("services")
 	ConfigMapsResource = core.SchemeGroupVersion.WithResource("configmaps")
+	NamespacesResource = core.SchemeGroupVersion.WithResource("namespaces")
+	SecretsResource    = core.SchemeGroupVersion.WithResource("secrets")
+	PodsResource       = core.SchemeGroupVersion.WithResource("pods")
+
+	// discovery
+	EndpointSlicesResource = discoveryv1.SchemeGroupVersion.WithResource("endpointslices")
 
 	// gateway api
 	GatewayClassesResource = gwapiv1.SchemeGroupVersion.WithResource("gatewayclasses")
 	GatewaysResource       = gwapiv1.SchemeGroupVersion.WithResource("gateways")
 	HTTPRoutesResource     = gwapiv1.SchemeGroupVersion.WithResource("httproutes")
+	TLSRoutesResource      = gwapiv1alpha2.SchemeGroupVersion.WithResource("tlsroutes")
+	UDPRoutesResource      = gwapiv1alpha2.SchemeGroupVersion.WithResource("udproutes")
 )
@@ -0,0 +1,151 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// ResourceSnapshot is a labeled copy of the generated resources managed by a controller, that can be restored later.
+// Snapshots are json-serializable, so they can be persisted outside of the controller.
+type ResourceSnapshot struct {
+	Label     string                  `json:"label"`
+	Timestamp time.Time               `json:"timestamp"`
+	Selector  string                  `json:"selector,omitempty"`
+	Resources []ResourceSnapshotEntry `json:"resources"`
+}
+
+type ResourceSnapshotEntry struct {
+	Resource schema.GroupVersionResource `json:"resource"`
+	Objects  []unstructured.Unstructured `json:"objects"`
+}
+
+// TakeResourceSnapshot lists all objects of the given resources in all namespaces that match a label selector, and
+// returns a snapshot of them identified by a label.
+// Use the label selector to restrict the snapshot to the resources managed by the controller.
+func TakeResourceSnapshot(ctx context.Context, client dynamic.Interface, label, selector string, resources ...schema.GroupVersionResource) (*ResourceSnapshot, error) {
+	snapshot := &ResourceSnapshot{
+		Label:     label,
+		Timestamp: time.Now(),
+		Selector:  selector,
+	}
+	for _, resource := range resources {
+		list, err := client.Resource(resource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", resource.String(), err)
+		}
+		snapshot.Resources = append(snapshot.Resources, ResourceSnapshotEntry{
+			Resource: resource,
+			Objects:  lo.Map(list.Items, func(obj unstructured.Unstructured, _ int) unstructured.Unstructured { return *sanitize(&obj) }),
+		})
+	}
+	return snapshot, nil
+}
+
+// ResourceChange is an operation to apply to an object to restore a snapshot.
+type ResourceChange struct {
+	Resource schema.GroupVersionResource
+	Object   *unstructured.Unstructured
+}
+
+// RestorePlan is the list of changes to apply to the live resources to restore a snapshot.
+type RestorePlan struct {
+	Creates []ResourceChange
+	Updates []ResourceChange
+	Deletes []ResourceChange
+}
+
+// Empty returns true if the plan has no changes to apply.
+func (p *RestorePlan) Empty() bool {
+	return len(p.Creates) == 0 && len(p.Updates) == 0 && len(p.Deletes) == 0
+}
+
+// Plan compares the snapshot with the live resources and returns the changes required to restore the snapshot.
+// Live objects matching the selector of the snapshot that are not in the snapshot are planned for deletion.
+func (s *ResourceSnapshot) Plan(ctx context.Context, client dynamic.Interface) (*RestorePlan, error) {
+	plan := &RestorePlan{}
+	for _, entry := range s.Resources {
+		list, err := client.Resource(entry.Resource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{LabelSelector: s.Selector})
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", entry.Resource.String(), err)
+		}
+		live := lo.SliceToMap(list.Items, func(obj unstructured.Unstructured) (string, unstructured.Unstructured) {
+			return namespacedName(&obj), obj
+		})
+		for i := range entry.Objects {
+			desired := entry.Objects[i].DeepCopy()
+			current, exists := live[namespacedName(desired)]
+			if !exists {
+				plan.Creates = append(plan.Creates, ResourceChange{Resource: entry.Resource, Object: desired})
+				continue
+			}
+			delete(live, namespacedName(desired))
+			if reflect.DeepEqual(sanitize(&current).Object, desired.Object) {
+				continue
+			}
+			desired.SetResourceVersion(current.GetResourceVersion())
+			plan.Updates = append(plan.Updates, ResourceChange{Resource: entry.Resource, Object: desired})
+		}
+		for _, obj := range live {
+			plan.Deletes = append(plan.Deletes, ResourceChange{Resource: entry.Resource, Object: obj.DeepCopy()})
+		}
+	}
+	return plan, nil
+}
+
+// Apply applies the changes of the plan to the live resources.
+// It stops at the first error. No change is applied in the ManagementStateUnmanaged management state.
+func (p *RestorePlan) Apply(ctx context.Context, client dynamic.Interface) error {
+	if IsUnmanagedState(ctx) {
+		LoggerFromContext(ctx).V(1).Info("skipping restore plan in the unmanaged management state")
+		return nil
+	}
+	for _, change := range p.Creates {
+		if _, err := client.Resource(change.Resource).Namespace(change.Object.GetNamespace()).Create(ctx, change.Object, metav1.CreateOptions{}); err != nil {
+			return fmt.Errorf("failed to create %s %s: %w", change.Resource.String(), namespacedName(change.Object), wrapAPIError(err))
+		}
+	}
+	for _, change := range p.Updates {
+		if _, err := client.Resource(change.Resource).Namespace(change.Object.GetNamespace()).Update(ctx, change.Object, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update %s %s: %w", change.Resource.String(), namespacedName(change.Object), wrapAPIError(err))
+		}
+	}
+	for _, change := range p.Deletes {
+		if err := client.Resource(change.Resource).Namespace(change.Object.GetNamespace()).Delete(ctx, change.Object.GetName(), metav1.DeleteOptions{}); err != nil {
+			return fmt.Errorf("failed to delete %s %s: %w", change.Resource.String(), namespacedName(change.Object), wrapAPIError(err))
+		}
+	}
+	return nil
+}
+
+// Restore plans and applies the changes to restore the snapshot, returning the applied plan.
+func (s *ResourceSnapshot) Restore(ctx context.Context, client dynamic.Interface) (*RestorePlan, error) {
+	plan, err := s.Plan(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+	return plan, plan.Apply(ctx, client)
+}
+
+// sanitize returns a copy of an object without the fields set by the API server
+func sanitize(obj *unstructured.Unstructured) *unstructured.Unstructured {
+	o := obj.DeepCopy()
+	o.SetResourceVersion("")
+	o.SetUID("")
+	o.SetGeneration(0)
+	o.SetCreationTimestamp(metav1.Time{})
+	o.SetManagedFields(nil)
+	unstructured.RemoveNestedField(o.Object, "status")
+	return o
+}
+
+func namespacedName(obj metav1.Object) string {
+	return fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+}
@@ -0,0 +1,103 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+func TestResourceSnapshotRestore(t *testing.T) {
+	resource := schema.GroupVersionResource{Group: "", Version: "v1", Resource: "configmaps"}
+	configMap := func(name, value string, labels map[string]string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("v1")
+		obj.SetKind("ConfigMap")
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetLabels(labels)
+		_ = unstructured.SetNestedField(obj.Object, value, "data", "key")
+		return obj
+	}
+	managed := map[string]string{"app.kubernetes.io/managed-by": "test"}
+
+	ctx := context.TODO()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{resource: "ConfigMapList"},
+		configMap("a", "1", managed),
+		configMap("b", "1", managed),
+		configMap("unmanaged", "1", nil),
+	)
+
+	snapshot, err := TakeResourceSnapshot(ctx, client, "before-rollout", "app.kubernetes.io/managed-by=test", resource)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(snapshot.Resources) != 1 || len(snapshot.Resources[0].Objects) != 2 {
+		t.Fatalf("expected 2 objects in the snapshot, got %v", snapshot.Resources)
+	}
+
+	plan, err := snapshot.Plan(ctx, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Fatalf("expected empty plan, got %+v", plan)
+	}
+
+	// bad rollout: a is changed, b is deleted, c is created; unmanaged objects are changed too
+	cms := client.Resource(resource).Namespace("default")
+	if _, err := cms.Update(ctx, configMap("a", "2", managed), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if err := cms.Delete(ctx, "b", metav1.DeleteOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cms.Create(ctx, configMap("c", "2", managed), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := cms.Update(ctx, configMap("unmanaged", "2", nil), metav1.UpdateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+
+	plan, err = snapshot.Restore(ctx, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(plan.Creates) != 1 || plan.Creates[0].Object.GetName() != "b" {
+		t.Errorf("expected b to be created, got %v", plan.Creates)
+	}
+	if len(plan.Updates) != 1 || plan.Updates[0].Object.GetName() != "a" {
+		t.Errorf("expected a to be updated, got %v", plan.Updates)
+	}
+	if len(plan.Deletes) != 1 || plan.Deletes[0].Object.GetName() != "c" {
+		t.Errorf("expected c to be deleted, got %v", plan.Deletes)
+	}
+
+	for name, expected := range map[string]string{"a": "1", "b": "1", "unmanaged": "2"} {
+		obj, err := cms.Get(ctx, name, metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("unexpected error getting %s: %v", name, err)
+		}
+		if value, _, _ := unstructured.NestedString(obj.Object, "data", "key"); value != expected {
+			t.Errorf("expected %s to have value %s, got %s", name, expected, value)
+		}
+	}
+	if _, err := cms.Get(ctx, "c", metav1.GetOptions{}); err == nil {
+		t.Errorf("expected c to be deleted")
+	}
+
+	plan, err = snapshot.Plan(ctx, client)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !plan.Empty() {
+		t.Errorf("expected empty plan after restore, got %+v", plan)
+	}
+}
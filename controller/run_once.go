@@ -0,0 +1,77 @@
+package controller
+
+import (
+	"context"
+	"time"
+)
+
+// RunOnceReport is the outcome of a one-shot reconciliation (see Controller.RunOnce).
+type RunOnceReport struct {
+	// Duration is the time taken to list the resources and reconcile them.
+	Duration time.Duration `json:"duration"`
+	// Objects is the number of objects listed.
+	Objects int `json:"objects"`
+	// Targetables, Policies and Edges are the number of targetables, policies and edges of the topology reconciled.
+	Targetables int `json:"targetables"`
+	Policies    int `json:"policies"`
+	Edges       int `json:"edges"`
+	// Error is the error of the reconciliation, if any. Errors are reported instead of retried.
+	Error error `json:"-"`
+	// AssertionViolations are the violations of the assertion rules of the controller (see WithAssertionRules).
+	AssertionViolations []AssertionViolation `json:"assertionViolations,omitempty"`
+	// MutationPlan is the plan of the objects to create, update and delete, not applied in one-shot mode (see
+	// WithMutationPlans).
+	MutationPlan *MutationPlan `json:"mutationPlan,omitempty"`
+}
+
+// Failed returns true if the reconciliation failed or any assertion rule was violated.
+func (r *RunOnceReport) Failed() bool {
+	return r.Error != nil || len(r.AssertionViolations) > 0
+}
+
+// RunOnce lists all the watched resources once, builds the topology and reconciles it a single time, as in a
+// reconciliation without events, then stops the runnables and returns a report. It enables the same reconcilers to
+// run as batch jobs, e.g. a nightly refresh of the status of the policies, without a long-running process.
+// The events of the resources listed only fill the cache; errors of the reconciliation are reported, not retried.
+// RunOnce does not start the controller manager, and the controller cannot be started afterwards.
+// It returns an error if the caches of the runnables fail to sync, e.g. because the context is cancelled.
+func (c *Controller) RunOnce(ctx context.Context) (*RunOnceReport, error) {
+	start := time.Now()
+
+	c.Lock()
+	c.oneShot = true
+	c.Unlock()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // stops the runnables
+
+	if c.discoveries != nil {
+		c.discoverRunnables()
+	}
+	if err := c.startRunnables(ctx.Done()); err != nil {
+		return nil, err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	if len(c.listFuncs) > 0 {
+		c.listState()
+	}
+	c.logger.Info("reconciling state of the world once")
+	err := c.run(nil)
+
+	store := c.cache.List()
+	topology := c.topology.Build(store)
+	report := &RunOnceReport{
+		Duration:            time.Since(start),
+		Objects:             len(store),
+		Targetables:         len(topology.Targetables().Items()),
+		Policies:            len(topology.Policies().Items()),
+		Edges:               topology.EdgeCount(),
+		Error:               err,
+		AssertionViolations: c.AssertionViolations(),
+		MutationPlan:        c.MutationPlan(),
+	}
+	return report, nil
+}
@@ -0,0 +1,77 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestRunOnce(t *testing.T) {
+	routesWithoutPolicies, err := QueryAssertionRule("routes-without-policies", "routes must have a TestPolicy", `MATCH (r:HTTPRoute) WHERE NOT (r)<-[:TARGETS]-(:TestPolicy) RETURN r`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var calls, events int
+	reconcileErr := errors.New("failed to reconcile")
+	builder := func(controller *Controller) Runnable {
+		gateway := machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.UID = "0a1b2c3d-4e5f-4a6b-8c7d-9e0f1a2b3c4d" })
+		route := machinery.BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) { r.UID = "1b2c3d4e-5f6a-4b7c-9d8e-0f1a2b3c4d5e" })
+		return &objectsRunnable{controller: controller, objs: []Object{gateway, route}}
+	}
+	c := NewController(
+		WithRunnable("gateway api", builder),
+		WithErrorReconcile(func(_ context.Context, resourceEvents []ResourceEvent, _ *machinery.Topology) error {
+			calls++
+			events += len(resourceEvents)
+			return reconcileErr
+		}),
+		WithAssertionRules(routesWithoutPolicies),
+	)
+
+	report, err := c.RunOnce(context.Background())
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if calls != 1 || events != 0 {
+		t.Errorf("expected a single reconciliation without events, got %d reconciliations with %d events", calls, events)
+	}
+	if report.Objects != 2 {
+		t.Errorf("expected 2 objects, got %d", report.Objects)
+	}
+	if report.Targetables != 4 || report.Edges != 3 {
+		t.Errorf("expected 4 targetables and 3 edges, got %d targetables and %d edges", report.Targetables, report.Edges)
+	}
+	if !errors.Is(report.Error, reconcileErr) {
+		t.Errorf("expected error %v, got %v", reconcileErr, report.Error)
+	}
+	if len(report.AssertionViolations) != 1 || report.AssertionViolations[0].Rule != "routes-without-policies" {
+		t.Errorf("expected 1 violation of rule routes-without-policies, got %v", report.AssertionViolations)
+	}
+	if !report.Failed() {
+		t.Error("expected the report to be failed")
+	}
+	if len(c.retries.events) != 0 {
+		t.Errorf("expected no retries, got %d events requeued", len(c.retries.events))
+	}
+}
+
+func TestRunOnceCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	c := NewController(WithRunnable("never synced", func(*Controller) Runnable { return &neverSyncedRunnable{} }))
+	if _, err := c.RunOnce(ctx); !errors.Is(err, ErrNotSynced) {
+		t.Errorf("expected error %v, got %v", ErrNotSynced, err)
+	}
+}
+
+type neverSyncedRunnable struct{}
+
+func (r *neverSyncedRunnable) Run(stopCh <-chan struct{}) { <-stopCh }
+func (r *neverSyncedRunnable) HasSynced() bool            { return false }
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/samber/lo"
@@ -33,9 +35,11 @@ type Runnable interface {
 type RunnableBuilder func(controller *Controller) Runnable
 
 type RunnableBuilderOptions[T Object] struct {
-	LabelSelector string
-	FieldSelector string
-	Builder       func(obj T, resource schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[T]) RunnableBuilder
+	LabelSelector      string
+	FieldSelector      string
+	Builder            func(obj T, resource schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[T]) RunnableBuilder
+	Namespaces         []string
+	ExcludedNamespaces []string
 }
 
 type RunnableBuilderOption[T Object] func(*RunnableBuilderOptions[T])
@@ -58,6 +62,22 @@ func Builder[T Object](builder func(obj T, resource schema.GroupVersionResource,
 	}
 }
 
+// WatchNamespaces watches the resources in a set of namespaces, with one runnable per namespace, e.g. for controllers
+// restricted by RBAC to specific namespaces. The namespace passed to Watch, if not empty, is added to the set.
+func WatchNamespaces[T Object](namespaces ...string) RunnableBuilderOption[T] {
+	return func(o *RunnableBuilderOptions[T]) {
+		o.Namespaces = append(o.Namespaces, namespaces...)
+	}
+}
+
+// ExcludeNamespaces filters out the resources in the given namespaces, e.g. when watching all namespaces.
+// The namespaces are excluded with a field selector, combined with the one set with FilterResourcesByField, if any.
+func ExcludeNamespaces[T Object](namespaces ...string) RunnableBuilderOption[T] {
+	return func(o *RunnableBuilderOptions[T]) {
+		o.ExcludedNamespaces = append(o.ExcludedNamespaces, namespaces...)
+	}
+}
+
 func Watch[T Object](obj T, resource schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[T]) RunnableBuilder {
 	o := &RunnableBuilderOptions[T]{
 		Builder: StateReconciler[T],
@@ -65,7 +85,58 @@ func Watch[T Object](obj T, resource schema.GroupVersionResource, namespace stri
 	for _, f := range options {
 		f(o)
 	}
-	return o.Builder(obj, resource, namespace, options...)
+	if len(o.ExcludedNamespaces) > 0 {
+		options = append(options, FilterResourcesByField[T](excludedNamespacesSelector(o.FieldSelector, o.ExcludedNamespaces)))
+	}
+	if len(o.Namespaces) == 0 {
+		return o.Builder(obj, resource, namespace, options...)
+	}
+	namespaces := o.Namespaces
+	if namespace != "" {
+		namespaces = append(namespaces, namespace)
+	}
+	namespaces = lo.Without(lo.Uniq(namespaces), o.ExcludedNamespaces...)
+	sort.Strings(namespaces)
+	return func(controller *Controller) Runnable {
+		return &multiNamespaceRunnable{
+			runnables: lo.Map(namespaces, func(namespace string, _ int) Runnable {
+				return o.Builder(obj, resource, namespace, options...)(controller)
+			}),
+		}
+	}
+}
+
+// excludedNamespacesSelector returns a field selector that excludes the resources in the given namespaces, combined
+// with another field selector
+func excludedNamespacesSelector(selector string, namespaces []string) string {
+	requirements := lo.Map(lo.Uniq(namespaces), func(namespace string, _ int) string {
+		return "metadata.namespace!=" + namespace
+	})
+	if selector != "" {
+		requirements = append([]string{selector}, requirements...)
+	}
+	return strings.Join(requirements, ",")
+}
+
+// multiNamespaceRunnable runs the runnables of the resources of a set of namespaces
+type multiNamespaceRunnable struct {
+	runnables []Runnable
+}
+
+func (r *multiNamespaceRunnable) Run(stopCh <-chan struct{}) {
+	var wg sync.WaitGroup
+	for _, runnable := range r.runnables {
+		wg.Add(1)
+		go func(runnable Runnable) {
+			defer wg.Done()
+			runnable.Run(stopCh)
+		}(runnable)
+	}
+	wg.Wait()
+}
+
+func (r *multiNamespaceRunnable) HasSynced() bool {
+	return lo.EveryBy(r.runnables, func(runnable Runnable) bool { return runnable.HasSynced() })
 }
 
 func IncrementalInformer[T Object](obj T, resource schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[T]) RunnableBuilder {
@@ -74,6 +145,7 @@ func IncrementalInformer[T Object](obj T, resource schema.GroupVersionResource,
 		f(o)
 	}
 	return func(controller *Controller) Runnable {
+		controller.watching(resource)
 		informer := cache.NewSharedInformer(
 			&cache.ListWatch{
 				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
@@ -83,7 +155,7 @@ func IncrementalInformer[T Object](obj T, resource schema.GroupVersionResource,
 					if o.FieldSelector != "" {
 						options.FieldSelector = o.FieldSelector
 					}
-					return controller.client.Resource(resource).Namespace(namespace).List(context.Background(), options)
+					return controller.resourceClient(resource).Namespace(namespace).List(context.Background(), options)
 				},
 				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
 					if o.LabelSelector != "" {
@@ -92,7 +164,7 @@ func IncrementalInformer[T Object](obj T, resource schema.GroupVersionResource,
 					if o.FieldSelector != "" {
 						options.FieldSelector = o.FieldSelector
 					}
-					return controller.client.Resource(resource).Namespace(namespace).Watch(context.Background(), options)
+					return controller.resourceClient(resource).Namespace(namespace).Watch(context.Background(), options)
 				},
 			},
 			&unstructured.Unstructured{},
@@ -130,6 +202,7 @@ func StateReconciler[T Object](obj T, resource schema.GroupVersionResource, name
 	kind = kind[strings.LastIndex(kind, ".")+1:]
 
 	return func(controller *Controller) Runnable {
+		controller.watching(resource)
 		return &stateReconciler{
 			controller: controller,
 			listFunc: func() []Object {
@@ -140,7 +213,7 @@ func StateReconciler[T Object](obj T, resource schema.GroupVersionResource, name
 				if o.FieldSelector != "" {
 					listOptions.FieldSelector = o.FieldSelector
 				}
-				objs, err := controller.client.Resource(resource).Namespace(namespace).List(context.Background(), listOptions)
+				objs, err := controller.resourceClient(resource).Namespace(namespace).List(context.Background(), listOptions)
 				if err != nil {
 					controller.logger.Error(err, "failed to list resources", "kind", kind)
 					return nil
@@ -189,8 +262,18 @@ type stateReconciler struct {
 	synced     bool
 }
 
-func (r *stateReconciler) Run(_ <-chan struct{}) {
-	r.controller.listAndWatch(r.listFunc, r.watchFunc)
+func (r *stateReconciler) Run(stopCh <-chan struct{}) {
+	// the watches of the controller manager cannot be stopped, but the resources are no longer listed once the runnable
+	// is stopped (see RemoveRunnable)
+	listFunc := func() []Object {
+		select {
+		case <-stopCh:
+			return nil
+		default:
+			return r.listFunc()
+		}
+	}
+	r.controller.listAndWatch(listFunc, r.watchFunc)
 	r.synced = true
 }
 
@@ -201,11 +284,11 @@ func (r *stateReconciler) HasSynced() bool {
 func Restructure[T any](obj any) (any, error) {
 	unstructuredObj, ok := obj.(*unstructured.Unstructured)
 	if !ok {
-		return nil, fmt.Errorf("unexpected object type: %T", obj)
+		return nil, fmt.Errorf("%w: unexpected object type: %T", ErrConversion, obj)
 	}
 	o := *new(T)
 	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(unstructuredObj.UnstructuredContent(), &o); err != nil {
-		return nil, err
+		return nil, conversionError(err)
 	}
 	return o, nil
 }
@@ -213,7 +296,7 @@ func Restructure[T any](obj any) (any, error) {
 func Destruct[T any](obj T) (*unstructured.Unstructured, error) {
 	u, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&obj)
 	if err != nil {
-		return nil, err
+		return nil, conversionError(err)
 	}
 	return &unstructured.Unstructured{Object: u}, nil
 }
@@ -0,0 +1,120 @@
+//go:build unit
+
+package controller
+
+import (
+	"slices"
+	"sync"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+type fakeRunnable struct {
+	mu        sync.Mutex
+	namespace string
+	selector  string
+	ran       bool
+}
+
+func (r *fakeRunnable) Run(stopCh <-chan struct{}) {
+	r.mu.Lock()
+	r.ran = true
+	r.mu.Unlock()
+	<-stopCh
+}
+
+func (r *fakeRunnable) HasSynced() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.ran
+}
+
+func TestWatchNamespaces(t *testing.T) {
+	var runnables []*fakeRunnable
+	builder := func(_ *corev1.ConfigMap, _ schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[*corev1.ConfigMap]) RunnableBuilder {
+		o := &RunnableBuilderOptions[*corev1.ConfigMap]{}
+		for _, f := range options {
+			f(o)
+		}
+		return func(*Controller) Runnable {
+			runnable := &fakeRunnable{namespace: namespace, selector: o.FieldSelector}
+			runnables = append(runnables, runnable)
+			return runnable
+		}
+	}
+
+	runnable := Watch(&corev1.ConfigMap{}, ConfigMapsResource, "ns-a",
+		Builder(builder),
+		WatchNamespaces[*corev1.ConfigMap]("ns-c", "ns-b", "ns-a", "kube-system"),
+		ExcludeNamespaces[*corev1.ConfigMap]("kube-system"),
+	)(NewController())
+
+	namespaces := make([]string, len(runnables))
+	for i, r := range runnables {
+		namespaces[i] = r.namespace
+	}
+	if expected := []string{"ns-a", "ns-b", "ns-c"}; !slices.Equal(namespaces, expected) {
+		t.Fatalf("expected runnables of namespaces %v, got %v", expected, namespaces)
+	}
+
+	stopCh := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		runnable.Run(stopCh)
+		close(done)
+	}()
+	if !cache.WaitForCacheSync(stopCh, runnable.HasSynced) {
+		t.Fatal("expected runnables synced")
+	}
+	close(stopCh)
+	<-done
+	for _, r := range runnables {
+		if !r.HasSynced() {
+			t.Errorf("expected runnable of namespace %s to run", r.namespace)
+		}
+	}
+}
+
+func TestExcludeNamespaces(t *testing.T) {
+	var namespace, selector string
+	builder := func(_ *corev1.ConfigMap, _ schema.GroupVersionResource, ns string, options ...RunnableBuilderOption[*corev1.ConfigMap]) RunnableBuilder {
+		o := &RunnableBuilderOptions[*corev1.ConfigMap]{}
+		for _, f := range options {
+			f(o)
+		}
+		namespace, selector = ns, o.FieldSelector
+		return func(*Controller) Runnable { return &fakeRunnable{} }
+	}
+
+	Watch(&corev1.ConfigMap{}, ConfigMapsResource, metav1.NamespaceAll,
+		Builder(builder),
+		FilterResourcesByField[*corev1.ConfigMap]("metadata.name=foo"),
+		ExcludeNamespaces[*corev1.ConfigMap]("kube-system", "kube-public", "kube-system"),
+	)
+
+	if namespace != metav1.NamespaceAll {
+		t.Errorf("expected all namespaces, got %q", namespace)
+	}
+	if expected := "metadata.name=foo,metadata.namespace!=kube-system,metadata.namespace!=kube-public"; selector != expected {
+		t.Errorf("expected field selector %q, got %q", expected, selector)
+	}
+	for _, tc := range []struct {
+		namespace string
+		expected  bool
+	}{
+		{namespace: "default", expected: true},
+		{namespace: "kube-system", expected: false},
+	} {
+		cm := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "foo", Namespace: tc.namespace}}
+		fieldSelector := ToFieldSelector(selector)
+		fieldValues := FieldsFromObject(cm, []string{"metadata.name", "metadata.namespace"})
+		if got := fieldSelector.Matches(fields.Set(fieldValues)); got != tc.expected {
+			t.Errorf("expected configmap in namespace %s to match %v, got %v", tc.namespace, tc.expected, got)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestRunScaleTest(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	resources := machinery.BuildScaledGatewayAPITopology(machinery.GatewayAPITopologyScale{
+		GatewayClasses:       2,
+		GatewaysPerClass:     3,
+		ListenersPerGateway:  2,
+		HTTPRoutesPerGateway: 4,
+		RulesPerHTTPRoute:    2,
+	})
+
+	report, err := RunScaleTest(ctx, resources, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	t.Log(report)
+
+	// 2 gatewayclasses + 6 gateways + 24 httproutes + 48 services
+	if report.Objects != 80 {
+		t.Errorf("expected 80 objects, got %d", report.Objects)
+	}
+	if report.Events < report.Objects {
+		t.Errorf("expected at least %d events, got %d", report.Objects, report.Events)
+	}
+	// plus 12 listeners, 48 httproute rules and 48 service ports
+	if report.Targetables != 188 {
+		t.Errorf("expected 188 targetables, got %d", report.Targetables)
+	}
+	if report.Duration <= 0 || report.EventsPerSecond <= 0 {
+		t.Errorf("expected throughput to be measured, got %s", report)
+	}
+}
+
+func BenchmarkScaleTest(b *testing.B) {
+	resources := machinery.BuildScaledGatewayAPITopology(machinery.GatewayAPITopologyScale{
+		GatewayClasses:       2,
+		GatewaysPerClass:     5,
+		ListenersPerGateway:  2,
+		HTTPRoutesPerGateway: 10,
+		RulesPerHTTPRoute:    2,
+	})
+	for i := 0; i < b.N; i++ {
+		report, err := RunScaleTest(context.Background(), resources, nil)
+		if err != nil {
+			b.Fatalf("unexpected error: %v", err)
+		}
+		b.ReportMetric(report.EventsPerSecond, "events/s")
+		b.ReportMetric(float64(report.HeapAllocBytes), "heap-bytes")
+	}
+}
@@ -0,0 +1,165 @@
+//go:build unit || integration
+
+package controller
+
+import (
+	"context"
+	"fmt"
+	"runtime"
+	"sync"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// ScaleTestReport is the outcome of a scale test.
+type ScaleTestReport struct {
+	// Objects is the number of objects in the synthetic cluster.
+	Objects int
+	// Reconciliations is the number of times the reconcile function was called until all objects were reconciled.
+	Reconciliations int
+	// Events is the number of resource events reconciled until all objects were reconciled.
+	Events int
+	// Duration is the time from the start of the controller until all objects were reconciled.
+	Duration time.Duration
+	// EventsPerSecond is the throughput of the controller.
+	EventsPerSecond float64
+	// TotalAllocBytes is the memory allocated during the test.
+	TotalAllocBytes uint64
+	// HeapAllocBytes is the memory in use by the heap after all objects were reconciled.
+	HeapAllocBytes uint64
+	// Targetables is the number of targetables in the last topology reconciled.
+	Targetables int
+}
+
+func (r ScaleTestReport) String() string {
+	return fmt.Sprintf("objects=%d targetables=%d reconciliations=%d events=%d duration=%s events/s=%.0f total-alloc=%dKiB heap=%dKiB",
+		r.Objects, r.Targetables, r.Reconciliations, r.Events, r.Duration, r.EventsPerSecond, r.TotalAllocBytes/1024, r.HeapAllocBytes/1024)
+}
+
+// RunScaleTest runs a controller against a synthetic in-memory cluster populated with a given set of Gateway API
+// resources, e.g. built with machinery.BuildScaledGatewayAPITopology, until all resources are reconciled, and reports
+// the throughput and memory usage of the controller.
+// The reconcile function, if any, runs on every reconciliation, so the performance of reconcilers can be measured too.
+func RunScaleTest(ctx context.Context, resources machinery.GatewayAPIResources, reconcile ReconcileFunc, options ...ControllerOption) (*ScaleTestReport, error) {
+	type resourceObject struct {
+		resource schema.GroupVersionResource
+		object   k8sruntime.Object
+	}
+	var objects []resourceObject
+	for _, o := range resources.GatewayClasses {
+		objects = append(objects, resourceObject{GatewayClassesResource, o})
+	}
+	for _, o := range resources.Gateways {
+		objects = append(objects, resourceObject{GatewaysResource, o})
+	}
+	for _, o := range resources.HTTPRoutes {
+		objects = append(objects, resourceObject{HTTPRoutesResource, o})
+	}
+	for _, o := range resources.Services {
+		objects = append(objects, resourceObject{ServicesResource, o})
+	}
+
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(k8sruntime.NewScheme(), map[schema.GroupVersionResource]string{
+		GatewayClassesResource: "GatewayClassList",
+		GatewaysResource:       "GatewayList",
+		HTTPRoutesResource:     "HTTPRouteList",
+		ServicesResource:       "ServiceList",
+	})
+	// objects are created unstructured, by resource, because the fake client cannot guess the resource of some kinds
+	// (e.g. gateways), and with a uid, by which the controller cache indexes them
+	for _, o := range objects {
+		content, err := k8sruntime.DefaultUnstructuredConverter.ToUnstructured(o.object)
+		if err != nil {
+			return nil, err
+		}
+		obj := &unstructured.Unstructured{Object: content}
+		obj.SetUID(types.UID(fmt.Sprintf("%s/%s/%s", o.resource.Resource, obj.GetNamespace(), obj.GetName())))
+		if _, err := client.Resource(o.resource).Namespace(obj.GetNamespace()).Create(ctx, obj, metav1.CreateOptions{}); err != nil {
+			return nil, err
+		}
+	}
+
+	report := &ScaleTestReport{Objects: len(objects)}
+	reconciled := make(map[string]struct{}, len(objects))
+	done := make(chan struct{})
+	var once sync.Once
+	var mutex sync.Mutex
+
+	var memStats runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&memStats)
+	totalAllocBefore := memStats.TotalAlloc
+
+	start := time.Now()
+
+	measure := func(ctx context.Context, events []ResourceEvent, topology *machinery.Topology) {
+		if reconcile != nil {
+			reconcile(ctx, events, topology)
+		}
+		mutex.Lock()
+		defer mutex.Unlock()
+		report.Reconciliations++
+		report.Events += len(events)
+		report.Targetables = len(topology.Targetables().Items())
+		for _, event := range events {
+			if event.NewObject != nil {
+				reconciled[string(event.NewObject.GetUID())] = struct{}{}
+			}
+		}
+		if len(reconciled) >= len(objects) {
+			once.Do(func() {
+				report.Duration = time.Since(start)
+				close(done)
+			})
+		}
+	}
+
+	controller := NewController(append(options,
+		WithClient(client),
+		WithRunnable("gatewayclass watcher", IncrementalInformer(&gwapiv1.GatewayClass{}, GatewayClassesResource, metav1.NamespaceAll)),
+		WithRunnable("gateway watcher", IncrementalInformer(&gwapiv1.Gateway{}, GatewaysResource, metav1.NamespaceAll)),
+		WithRunnable("httproute watcher", IncrementalInformer(&gwapiv1.HTTPRoute{}, HTTPRoutesResource, metav1.NamespaceAll)),
+		WithRunnable("service watcher", IncrementalInformer(&core.Service{}, ServicesResource, metav1.NamespaceAll)),
+		WithReconcile(measure),
+	)...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- controller.Start(ctx)
+	}()
+
+	select {
+	case <-done:
+	case err := <-errCh:
+		if err == nil {
+			err = ctx.Err()
+		}
+		return nil, fmt.Errorf("controller stopped before reconciling all objects: %w", err)
+	case <-ctx.Done():
+		return nil, fmt.Errorf("timed out reconciling all objects: %w", ctx.Err())
+	}
+
+	runtime.GC()
+	runtime.ReadMemStats(&memStats)
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	report.TotalAllocBytes = memStats.TotalAlloc - totalAllocBefore
+	report.HeapAllocBytes = memStats.HeapAlloc
+	if seconds := report.Duration.Seconds(); seconds > 0 {
+		report.EventsPerSecond = float64(report.Events) / seconds
+	}
+	return report, nil
+}
@@ -0,0 +1,26 @@
+package controller
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WithSelectableKinds declares the kinds of resources selected by label by selector-based policies (see
+// machinery.SelectorPolicy), e.g. Services, and Namespaces for namespace selectors.
+// Label changes of resources of these kinds are reconciled even though they do not change the generation of the
+// resources, so the selected targets of the policies are kept up to date as resources are relabeled. Namespaces must
+// be watched for their labels to be available to the namespace selectors.
+func WithSelectableKinds(kinds ...schema.GroupKind) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.selectableKinds = append(o.selectableKinds, kinds...)
+	}
+}
+
+// selectableLabelsChanged returns true if the labels of a resource of a selectable kind changed.
+func selectableLabelsChanged(selectableKinds []schema.GroupKind, oldObj, newObj Object) bool {
+	if !lo.Contains(selectableKinds, newObj.GetObjectKind().GroupVersionKind().GroupKind()) {
+		return false
+	}
+	return !labels.Equals(oldObj.GetLabels(), newObj.GetLabels())
+}
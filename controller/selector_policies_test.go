@@ -0,0 +1,29 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSelectableLabelsChanged(t *testing.T) {
+	service := func(labels map[string]string) *core.Service {
+		return &core.Service{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "Service"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-service", Namespace: "my-namespace", Labels: labels},
+		}
+	}
+	if !selectableLabelsChanged([]schema.GroupKind{ServiceKind}, service(nil), service(map[string]string{"tier": "frontend"})) {
+		t.Error("expected label change of a selectable kind to be detected")
+	}
+	if selectableLabelsChanged([]schema.GroupKind{ServiceKind}, service(map[string]string{"tier": "frontend"}), service(map[string]string{"tier": "frontend"})) {
+		t.Error("expected unchanged labels not to be detected as changed")
+	}
+	if selectableLabelsChanged([]schema.GroupKind{NamespaceKind}, service(nil), service(map[string]string{"tier": "frontend"})) {
+		t.Error("expected label change of a non-selectable kind to be ignored")
+	}
+}
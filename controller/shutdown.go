@@ -0,0 +1,92 @@
+package controller
+
+import (
+	"context"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+)
+
+const defaultShutdownTimeout = 30 * time.Second
+
+// WithShutdownTimeout sets the maximum time the controller waits on shutdown for the reconciliation in flight, if any,
+// and for the final status writes (see WithStatusFlushOnShutdown), e.g. within the termination grace period of the
+// pods of the controller. Defaults to 30s.
+func WithShutdownTimeout(timeout time.Duration) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.shutdownTimeout = timeout
+	}
+}
+
+// WithStatusFlushOnShutdown opts in to write the statuses queued in the status reporter (see WithStatusReporter) on
+// shutdown, within the shutdown timeout (see WithShutdownTimeout), so rolling out a new version of the controller
+// does not lose the status writes of the last reconciliations.
+func WithStatusFlushOnShutdown() ControllerOption {
+	return func(o *ControllerOptions) {
+		o.statusFlushOnShutdown = true
+	}
+}
+
+// Stop stops the controller started with Start, as if the context of Start was cancelled, and blocks until the
+// controller is shut down: the runnables are stopped, the reconciliation in flight, if any, is drained, and the
+// statuses queued are flushed if enabled (see WithStatusFlushOnShutdown), within the shutdown timeout.
+// Stop does nothing if the controller is not started.
+func (c *Controller) Stop() {
+	c.lifecycleMutex.Lock()
+	cancel, done := c.cancel, c.done
+	c.lifecycleMutex.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+// started records the functions to stop the controller and to wait for its shutdown (see Stop).
+func (c *Controller) started(cancel context.CancelFunc) (done func()) {
+	c.lifecycleMutex.Lock()
+	defer c.lifecycleMutex.Unlock()
+
+	doneCh := make(chan struct{})
+	c.cancel, c.done = cancel, doneCh
+	return func() { close(doneCh) }
+}
+
+// shutdown stops the reconciliations, waiting for the one in flight, if any, and flushes the statuses queued in the
+// status reporter if enabled, within the shutdown timeout. It must be called once the runnables are stopped.
+func (c *Controller) shutdown(stopStatusReporter context.CancelFunc) {
+	deadline := time.Now().Add(c.shutdownTimeout)
+
+	// no reconciliation starts from now on; the one in flight holds the lock until it finishes
+	c.stopped.Store(true)
+	drained := make(chan struct{})
+	go func() {
+		c.Lock()
+		defer c.Unlock()
+		close(drained)
+	}()
+	select {
+	case <-drained:
+		c.logger.V(1).Info("reconciliations drained")
+	case <-time.After(time.Until(deadline)):
+		c.logger.Info("timed out waiting for the reconciliation in flight to finish", "timeout", c.shutdownTimeout.String())
+	}
+
+	if c.statusReporter != nil && c.flushStatuses {
+		if pending := c.statusReporter.drain(time.Until(deadline)); pending > 0 {
+			c.logger.Info("timed out flushing the status writes", "pending", pending)
+		}
+	}
+	stopStatusReporter()
+}
+
+// drain waits until all the status writes are written or the timeout expires, returning the number of writes left.
+func (r *StatusReporter) drain(timeout time.Duration) int {
+	if timeout <= 0 {
+		return r.Len()
+	}
+	_ = wait.PollUntilContextTimeout(context.Background(), 10*time.Millisecond, timeout, true, func(context.Context) (bool, error) {
+		return r.Len() == 0, nil
+	})
+	return r.Len()
+}
@@ -0,0 +1,137 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func shutdownConfigMap(name string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: types.UID(name)},
+	}
+}
+
+func startController(t *testing.T, c *Controller) <-chan struct{} {
+	t.Helper()
+	returned := make(chan struct{})
+	go func() {
+		defer close(returned)
+		if err := c.Start(context.Background()); err != nil {
+			t.Errorf("expected no error when starting the controller, got %s", err.Error())
+		}
+	}()
+	waitFor(t, func() bool {
+		c.lifecycleMutex.Lock()
+		defer c.lifecycleMutex.Unlock()
+		return c.cancel != nil
+	}, "expected the controller to be started")
+	return returned
+}
+
+func TestControllerStopDrainsReconciliation(t *testing.T) {
+	inFlight := make(chan struct{})
+	release := make(chan struct{})
+	var reconciliations atomic.Int32
+	c := NewController(WithReconcile(func(context.Context, []ResourceEvent, *machinery.Topology) {
+		if reconciliations.Add(1) == 1 {
+			close(inFlight)
+			<-release
+		}
+	}))
+	c.Stop() // not started
+
+	returned := startController(t, c)
+	go c.add(shutdownConfigMap("a"))
+	<-inFlight
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("expected Stop to wait for the reconciliation in flight")
+	case <-time.After(100 * time.Millisecond):
+	}
+	close(release)
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Stop to return once the reconciliation in flight finished")
+	}
+	<-returned
+
+	c.add(shutdownConfigMap("b"))
+	if n := reconciliations.Load(); n != 1 {
+		t.Errorf("expected no reconciliation after shutdown, got %d reconciliations", n)
+	}
+}
+
+func TestControllerStopTimeout(t *testing.T) {
+	inFlight := make(chan struct{})
+	var once sync.Once
+	release := make(chan struct{})
+	defer close(release)
+	c := NewController(
+		WithShutdownTimeout(50*time.Millisecond),
+		WithReconcile(func(context.Context, []ResourceEvent, *machinery.Topology) {
+			once.Do(func() { close(inFlight) })
+			<-release
+		}),
+	)
+
+	startController(t, c)
+	go c.add(shutdownConfigMap("a"))
+	<-inFlight
+
+	stopped := make(chan struct{})
+	go func() {
+		c.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected Stop to return after the shutdown timeout")
+	}
+}
+
+func TestControllerStopFlushesStatuses(t *testing.T) {
+	var attempts, written atomic.Int32
+	c := NewController(
+		WithStatusReporter(1),
+		WithStatusFlushOnShutdown(),
+		WithRetryBackoff(20*time.Millisecond, 20*time.Millisecond),
+	)
+
+	startController(t, c)
+	c.statusReporter.Report("key", func(context.Context) error {
+		if attempts.Add(1) < 3 {
+			return errors.New("transient failure")
+		}
+		written.Add(1)
+		return nil
+	})
+	c.Stop()
+
+	if written.Load() != 1 {
+		t.Errorf("expected the status flushed on shutdown, got %d attempts", attempts.Load())
+	}
+	if c.statusReporter.Len() != 0 {
+		t.Errorf("expected no pending status writes, got %d", c.statusReporter.Len())
+	}
+}
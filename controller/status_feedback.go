@@ -0,0 +1,204 @@
+package controller
+
+import (
+	"context"
+	"sort"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// StatusFeedback maps a kind of resource generated out of policies to the policies that own the generated resources,
+// so the conditions reported in the status of the generated resources are rolled up into the Enforced condition of
+// the owning policies.
+type StatusFeedback struct {
+	// GeneratedKind is the kind of the generated resources. The kind must be watched by the controller.
+	GeneratedKind schema.GroupKind
+	// PolicyResource is the resource of the owning policies, used to update their status.
+	PolicyResource schema.GroupVersionResource
+	// Owners returns the policies that own a generated resource.
+	Owners func(generated machinery.Object, topology *machinery.Topology) []machinery.Policy
+	// Dependency returns a generated resource as a dependency whose readiness is given by its conditions.
+	Dependency func(generated machinery.Object) Dependency
+}
+
+// WithStatusFeedback registers mappings of generated kinds to owning policies.
+// The controller rolls up the conditions of the generated resources into the Enforced condition of the owning
+// policies every time the topology is reconciled, as well as when the status of a generated resource changes.
+// Policies that do not own any generated resource are left untouched.
+func WithStatusFeedback(feedbacks ...StatusFeedback) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.statusFeedbacks = append(o.statusFeedbacks, feedbacks...)
+	}
+}
+
+func isStatusFeedbackKind(feedbacks []StatusFeedback, kind schema.GroupKind) bool {
+	return lo.ContainsBy(feedbacks, func(f StatusFeedback) bool { return f.GeneratedKind == kind })
+}
+
+type statusFeedbackOwner struct {
+	policy       machinery.Policy
+	resource     schema.GroupVersionResource
+	dependencies []Dependency
+}
+
+// reconcileStatusFeedback rolls up the conditions of the generated resources in the topology into the Enforced
+// condition of the policies that own them.
+func reconcileStatusFeedback(ctx context.Context, client func(schema.GroupVersionResource) dynamic.NamespaceableResourceInterface, feedbacks []StatusFeedback, topology *machinery.Topology) {
+	logger := LoggerFromContext(ctx).WithName("status feedback")
+
+	owners := make(map[string]*statusFeedbackOwner)
+	for _, feedback := range feedbacks {
+		generated := topology.Objects().Items(func(o machinery.Object) bool {
+			return o.GroupVersionKind().GroupKind() == feedback.GeneratedKind
+		})
+		for _, obj := range generated {
+			dependency := feedback.Dependency(obj)
+			for _, policy := range feedback.Owners(obj, topology) {
+				owner, ok := owners[policy.GetURL()]
+				if !ok {
+					owner = &statusFeedbackOwner{policy: policy, resource: feedback.PolicyResource}
+					owners[policy.GetURL()] = owner
+				}
+				owner.dependencies = append(owner.dependencies, dependency)
+			}
+		}
+	}
+
+	urls := lo.Keys(owners)
+	sort.Strings(urls)
+	for _, url := range urls {
+		owner := owners[url]
+		sort.SliceStable(owner.dependencies, func(i, j int) bool {
+			return owner.dependencies[i].Object.GetURL() < owner.dependencies[j].Object.GetURL()
+		})
+		updated, err := SetPolicyStatusCondition(ctx, client(owner.resource), owner.policy, func(generation int64) metav1.Condition {
+			return EnforcedCondition(generation, owner.dependencies...)
+		})
+		if err != nil {
+			logger.Error(err, "failed to update policy status", "policy", url)
+			continue
+		}
+		if updated {
+			logger.V(1).Info("policy status updated", "policy", url)
+		}
+	}
+}
+
+// SetPolicyStatusCondition sets a condition in the status of a policy in the cluster, built for the current generation
+// of the policy. The policy must be a pointer to a struct with status conditions at 'status.conditions'.
+// The status is only updated if the condition changed, and never in the ManagementStateUnmanaged management state; it
+// returns true if the status was updated.
+func SetPolicyStatusCondition(ctx context.Context, client dynamic.NamespaceableResourceInterface, policy machinery.Policy, conditionFunc func(generation int64) metav1.Condition) (bool, error) {
+	return updatePolicyStatusConditions(ctx, client, policy, func(generation int64, conditions *[]metav1.Condition) bool {
+		return meta.SetStatusCondition(conditions, conditionFunc(generation))
+	})
+}
+
+// updatePolicyStatusConditions mutates the status conditions of a policy in the cluster. The mutate function returns
+// true if the conditions changed, in which case the status of the policy is updated, or queued to be updated by the
+// status reporter in the context (see ReportStatus). Queued updates of a policy are keyed by the URL of the policy, so
+// the latest update replaces any other not written yet and updates of a policy are written one at a time; retried
+// updates refresh the policy first.
+func updatePolicyStatusConditions(ctx context.Context, client dynamic.NamespaceableResourceInterface, policy machinery.Policy, mutate func(generation int64, conditions *[]metav1.Condition) bool) (bool, error) {
+	content, err := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	if err != nil {
+		return false, conversionError(err)
+	}
+	obj := &unstructured.Unstructured{Object: content}
+
+	changedTypes, err := mutateStatusConditions(obj, mutate)
+	if err != nil || len(changedTypes) == 0 || IsUnmanagedState(ctx) {
+		return false, err
+	}
+
+	refresh := false
+	write := func(ctx context.Context) error {
+		if refresh {
+			latest, err := client.Namespace(obj.GetNamespace()).Get(ctx, obj.GetName(), metav1.GetOptions{})
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			if err != nil {
+				return wrapAPIError(err)
+			}
+			if changedTypes, err := mutateStatusConditions(latest, mutate); err != nil || len(changedTypes) == 0 {
+				return err
+			}
+			obj = latest
+		}
+		refresh = true
+		if _, err := client.Namespace(obj.GetNamespace()).UpdateStatus(ctx, obj, metav1.UpdateOptions{}); err != nil {
+			return wrapAPIError(err)
+		}
+		return nil
+	}
+	if err := ReportStatus(ctx, policy.GetURL(), write); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// mutateStatusConditions mutates the status conditions of an object, returning the sorted types of the conditions
+// that changed, if any.
+func mutateStatusConditions(obj *unstructured.Unstructured, mutate func(generation int64, conditions *[]metav1.Condition) bool) ([]string, error) {
+	status := struct {
+		Status struct {
+			Conditions []metav1.Condition `json:"conditions,omitempty"`
+		} `json:"status,omitempty"`
+	}{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, &status); err != nil {
+		return nil, conversionError(err)
+	}
+
+	conditions := status.Status.Conditions
+	current := append([]metav1.Condition(nil), conditions...)
+	if !mutate(obj.GetGeneration(), &conditions) {
+		return nil, nil
+	}
+	// conditions are guarded before comparing them to the current ones, which were guarded when set
+	conditions = GuardConditions(conditions)
+	changedTypes := changedConditionTypes(current, conditions)
+	if len(changedTypes) == 0 {
+		return nil, nil
+	}
+
+	unstructuredConditions := make([]any, 0, len(conditions))
+	for i := range conditions {
+		c, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&conditions[i])
+		if err != nil {
+			return nil, conversionError(err)
+		}
+		unstructuredConditions = append(unstructuredConditions, c)
+	}
+	if err := unstructured.SetNestedSlice(obj.Object, unstructuredConditions, "status", "conditions"); err != nil {
+		return nil, conversionError(err)
+	}
+	return changedTypes, nil
+}
+
+// changedConditionTypes returns the sorted types of the conditions added, removed or modified between two lists
+func changedConditionTypes(current, desired []metav1.Condition) []string {
+	var types []string
+	for _, condition := range desired {
+		if existing := meta.FindStatusCondition(current, condition.Type); existing == nil || !equality.Semantic.DeepEqual(*existing, condition) {
+			types = append(types, condition.Type)
+		}
+	}
+	for _, condition := range current {
+		if meta.FindStatusCondition(desired, condition.Type) == nil {
+			types = append(types, condition.Type)
+		}
+	}
+	sort.Strings(types)
+	return lo.Uniq(types)
+}
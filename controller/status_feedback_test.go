@@ -0,0 +1,106 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestStatusFeedback(t *testing.T) {
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", Generation: 2},
+	}
+	generatedKind := schema.GroupKind{Group: "test", Kind: "Generated"}
+	generated := func(name string, status metav1.ConditionStatus) machinery.Object {
+		obj := &unstructured.Unstructured{}
+		obj.SetAPIVersion("test/v1")
+		obj.SetKind(generatedKind.Kind)
+		obj.SetNamespace("my-namespace")
+		obj.SetName(name)
+		_ = unstructured.SetNestedSlice(obj.Object, []any{map[string]any{"type": "Programmed", "status": string(status)}}, "status", "conditions")
+		return &RuntimeObject{obj}
+	}
+	feedback := StatusFeedback{
+		GeneratedKind:  generatedKind,
+		PolicyResource: policyResource,
+		Owners: func(_ machinery.Object, topology *machinery.Topology) []machinery.Policy {
+			return topology.Policies().Items()
+		},
+		Dependency: func(obj machinery.Object) Dependency {
+			conditions, _, _ := unstructured.NestedSlice(obj.(*RuntimeObject).Object.(*unstructured.Unstructured).Object, "status", "conditions")
+			return Dependency{
+				Object:        obj,
+				ConditionType: "Programmed",
+				Conditions: []metav1.Condition{{
+					Type:   conditions[0].(map[string]any)["type"].(string),
+					Status: metav1.ConditionStatus(conditions[0].(map[string]any)["status"].(string)),
+				}},
+			}
+		},
+	}
+
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"},
+		&unstructured.Unstructured{Object: content},
+	)
+
+	enforcedCondition := func() *metav1.Condition {
+		obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(context.TODO(), "my-policy", metav1.GetOptions{})
+		if err != nil {
+			t.Fatalf("failed to get policy: %v", err)
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		var parsed []metav1.Condition
+		for _, c := range conditions {
+			condition := metav1.Condition{}
+			_ = runtime.DefaultUnstructuredConverter.FromUnstructured(c.(map[string]any), &condition)
+			parsed = append(parsed, condition)
+		}
+		return meta.FindStatusCondition(parsed, PolicyConditionEnforced)
+	}
+
+	// one of the generated resources is not programmed yet
+	topology := machinery.NewTopology(
+		machinery.WithPolicies(policy),
+		machinery.WithObjects(generated("a", metav1.ConditionTrue), generated("b", metav1.ConditionFalse)),
+	)
+	reconcileStatusFeedback(context.TODO(), client.Resource, []StatusFeedback{feedback}, topology)
+
+	condition := enforcedCondition()
+	if condition == nil {
+		t.Fatalf("expected policy to have the Enforced condition")
+	}
+	if condition.Status != metav1.ConditionFalse || condition.Reason != PolicyReasonWaitingOnDependency || condition.ObservedGeneration != 2 {
+		t.Errorf("expected policy to be waiting on dependency, got %v", condition)
+	}
+	if expected := "Waiting on Generated my-namespace/b to be Programmed"; condition.Message != expected {
+		t.Errorf("expected message %q, got %q", expected, condition.Message)
+	}
+
+	// all generated resources programmed
+	obj, _ := client.Resource(policyResource).Namespace("my-namespace").Get(context.TODO(), "my-policy", metav1.GetOptions{})
+	policy.ResourceVersion = obj.GetResourceVersion()
+	topology = machinery.NewTopology(
+		machinery.WithPolicies(policy),
+		machinery.WithObjects(generated("a", metav1.ConditionTrue), generated("b", metav1.ConditionTrue)),
+	)
+	reconcileStatusFeedback(context.TODO(), client.Resource, []StatusFeedback{feedback}, topology)
+
+	condition = enforcedCondition()
+	if condition == nil || condition.Status != metav1.ConditionTrue || condition.Reason != PolicyReasonEnforced {
+		t.Errorf("expected policy to be enforced, got %v", condition)
+	}
+}
@@ -0,0 +1,105 @@
+package controller
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/prometheus/client_golang/prometheus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrlruntimemetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
+)
+
+// Limits of the status of the resources, as validated by the API server
+const (
+	// MaxConditionMessageLength is the maximum length of the message of a condition.
+	MaxConditionMessageLength = 32768
+	// MaxConditionReasonLength is the maximum length of the reason of a condition.
+	MaxConditionReasonLength = 1024
+)
+
+// MaxStatusConditionsBytes is the budget of the messages of all conditions set in the status of a resource at once.
+// Requests to the API server are limited in size, thus conditions that add up to more than the budget have their
+// messages truncated evenly.
+var MaxStatusConditionsBytes = 256 * 1024
+
+// TruncatedIndicator is appended to the messages truncated to fit in the limits of the status.
+const TruncatedIndicator = "... (truncated)"
+
+// Status guards, as reported in the metrics
+const (
+	StatusGuardMessageTruncated = "message_truncated"
+	StatusGuardReasonTruncated  = "reason_truncated"
+	StatusGuardAncestorsDropped = "ancestors_dropped"
+	StatusGuardItemsAggregated  = "items_aggregated"
+)
+
+var statusGuardsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "policy_machinery_status_guards_total",
+		Help: "Number of times the status of a resource was degraded to fit in the limits of the API server, per guard",
+	},
+	[]string{"guard"},
+)
+
+func init() {
+	ctrlruntimemetrics.Registry.MustRegister(statusGuardsTotal)
+}
+
+// GuardConditions returns the conditions with their messages and reasons truncated to fit in the limits of the status
+// of a resource, so a huge message (e.g. a long diff) degrades the condition instead of failing the whole status
+// update. Truncated messages end with TruncatedIndicator.
+func GuardConditions(conditions []metav1.Condition) []metav1.Condition {
+	guarded := make([]metav1.Condition, len(conditions))
+	total := 0
+	for i, condition := range conditions {
+		guarded[i] = guardCondition(condition, MaxConditionMessageLength)
+		total += len(guarded[i].Message)
+	}
+	if total <= MaxStatusConditionsBytes || len(guarded) == 0 {
+		return guarded
+	}
+	budget := MaxStatusConditionsBytes / len(guarded)
+	for i := range guarded {
+		guarded[i] = guardCondition(guarded[i], budget)
+	}
+	return guarded
+}
+
+// AggregateItems returns a human-readable list of items, listing at most a given number of them followed by the number
+// of items left out, e.g. "a, b, c and 7 more".
+func AggregateItems(items []string, limit int) string {
+	if len(items) <= limit || limit < 1 {
+		return strings.Join(items, ", ")
+	}
+	statusGuardsTotal.WithLabelValues(StatusGuardItemsAggregated).Inc()
+	return fmt.Sprintf("%s and %d more", strings.Join(items[:limit], ", "), len(items)-limit)
+}
+
+func guardCondition(condition metav1.Condition, maxMessageLength int) metav1.Condition {
+	if truncated, ok := truncate(condition.Message, maxMessageLength, TruncatedIndicator); ok {
+		condition.Message = truncated
+		statusGuardsTotal.WithLabelValues(StatusGuardMessageTruncated).Inc()
+	}
+	if truncated, ok := truncate(condition.Reason, MaxConditionReasonLength, ""); ok {
+		condition.Reason = truncated
+		statusGuardsTotal.WithLabelValues(StatusGuardReasonTruncated).Inc()
+	}
+	return condition
+}
+
+// truncate cuts a string to a maximum length in bytes, at a rune boundary, ending it with a given indicator.
+// It returns false if the string fits in the maximum length.
+func truncate(s string, maxLength int, indicator string) (string, bool) {
+	if len(s) <= maxLength {
+		return s, false
+	}
+	cut := maxLength - len(indicator)
+	if cut < 0 {
+		return indicator[:maxLength], true
+	}
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + indicator, true
+}
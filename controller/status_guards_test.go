@@ -0,0 +1,49 @@
+//go:build unit
+
+package controller
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGuardConditions(t *testing.T) {
+	huge := strings.Repeat("ä", MaxConditionMessageLength) // 2 bytes per rune
+	conditions := GuardConditions([]metav1.Condition{
+		{Type: "Small", Reason: "Small", Message: "small"},
+		{Type: "Huge", Reason: strings.Repeat("R", MaxConditionReasonLength+1), Message: huge},
+	})
+	if conditions[0].Message != "small" {
+		t.Errorf("expected small message untouched, got %s", conditions[0].Message)
+	}
+	message := conditions[1].Message
+	if len(message) > MaxConditionMessageLength || !strings.HasSuffix(message, TruncatedIndicator) || !utf8.ValidString(message) {
+		t.Errorf("expected valid message truncated with indicator, got %d bytes", len(message))
+	}
+	if len(conditions[1].Reason) != MaxConditionReasonLength {
+		t.Errorf("expected reason truncated, got %d bytes", len(conditions[1].Reason))
+	}
+
+	// budget of all conditions
+	defer func(max int) { MaxStatusConditionsBytes = max }(MaxStatusConditionsBytes)
+	MaxStatusConditionsBytes = 100
+	conditions = GuardConditions([]metav1.Condition{
+		{Type: "A", Message: strings.Repeat("a", 80)},
+		{Type: "B", Message: strings.Repeat("b", 80)},
+	})
+	if len(conditions[0].Message) > 50 || len(conditions[1].Message) > 50 {
+		t.Errorf("expected messages truncated to fit in the budget, got %d and %d bytes", len(conditions[0].Message), len(conditions[1].Message))
+	}
+}
+
+func TestAggregateItems(t *testing.T) {
+	if s := AggregateItems([]string{"a", "b"}, 3); s != "a, b" {
+		t.Errorf("unexpected aggregation: %s", s)
+	}
+	if s := AggregateItems([]string{"a", "b", "c", "d", "e"}, 3); s != "a, b, c and 2 more" {
+		t.Errorf("unexpected aggregation: %s", s)
+	}
+}
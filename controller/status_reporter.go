@@ -0,0 +1,160 @@
+package controller
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/workqueue"
+)
+
+// StatusWriteFunc writes the status of a resource to the cluster. It is called again if it fails, thus it must be
+// safe to retry, e.g. by refreshing the resource on conflict.
+type StatusWriteFunc func(ctx context.Context) error
+
+// WithStatusReporter moves the writes of the statuses of the policies (and any other status reported with
+// ReportStatus) out of the reconciliation, into an asynchronous reporter with a number of workers, so slow status
+// updates do not delay the writes that affect the data plane.
+// The reporter keeps the latest write of each resource only, and retries failed writes with the backoff of the
+// retries of the controller (see WithRetryBackoff), without reconciling the topology again.
+// Statuses are written synchronously until the controller starts, and in one-shot mode (see RunOnce).
+func WithStatusReporter(workers int) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.statusReporterWorkers = max(workers, 1)
+	}
+}
+
+// StatusReporter writes statuses asynchronously, deduplicating the writes of the same key and retrying the failed
+// ones with backoff.
+type StatusReporter struct {
+	logger  logr.Logger
+	workers int
+	queue   workqueue.RateLimitingInterface
+	skip    func() bool
+	running atomic.Bool
+
+	mutex   sync.Mutex
+	pending map[string]StatusWriteFunc
+}
+
+func newStatusReporter(workers int, backoff retryBackoff, logger logr.Logger, skip func() bool) *StatusReporter {
+	return &StatusReporter{
+		logger:  logger.WithName("status reporter"),
+		workers: workers,
+		queue:   workqueue.NewRateLimitingQueue(workqueue.NewItemExponentialFailureRateLimiter(backoff.initial, backoff.max)),
+		skip:    skip,
+		pending: map[string]StatusWriteFunc{},
+	}
+}
+
+// Report queues a status write, replacing any write of the same key not written yet.
+func (r *StatusReporter) Report(key string, write StatusWriteFunc) {
+	r.mutex.Lock()
+	r.pending[key] = write
+	r.mutex.Unlock()
+	r.queue.Add(key)
+}
+
+// Len returns the number of status writes not written yet, including the ones waiting to be retried.
+func (r *StatusReporter) Len() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return len(r.pending)
+}
+
+// start starts the workers of the reporter, which stop when the context is done
+func (r *StatusReporter) start(ctx context.Context) {
+	for i := 0; i < r.workers; i++ {
+		go wait.UntilWithContext(ctx, func(ctx context.Context) {
+			for r.processNext(ctx) {
+			}
+		}, time.Second)
+	}
+	r.running.Store(true)
+	go func() {
+		<-ctx.Done()
+		r.running.Store(false)
+		r.queue.ShutDown()
+	}()
+}
+
+// processNext writes the next status in the queue, returning false once the queue is shut down
+func (r *StatusReporter) processNext(ctx context.Context) bool {
+	item, shutdown := r.queue.Get()
+	if shutdown {
+		return false
+	}
+	defer r.queue.Done(item)
+	key := item.(string)
+
+	r.mutex.Lock()
+	write, ok := r.pending[key]
+	delete(r.pending, key)
+	r.mutex.Unlock()
+
+	if !ok || r.skip() {
+		r.queue.Forget(key)
+		return true
+	}
+
+	if err := write(ctx); err != nil {
+		r.mutex.Lock()
+		_, newer := r.pending[key]
+		if !newer {
+			r.pending[key] = write
+		}
+		r.mutex.Unlock()
+		if newer {
+			// the newer write is queued already
+			r.queue.Forget(key)
+			return true
+		}
+		r.logger.Error(err, "failed to write status", "key", key, "retries", r.queue.NumRequeues(key))
+		r.queue.AddRateLimited(key)
+		return true
+	}
+	r.queue.Forget(key)
+	r.logger.V(1).Info("status written", "key", key)
+	return true
+}
+
+// statusReporterIntoContext returns a new context with the status reporter of the controller set, if the controller
+// has one and it is running, except in one-shot mode
+func (c *Controller) statusReporterIntoContext(ctx context.Context) context.Context {
+	if c.statusReporter == nil || !c.statusReporter.running.Load() || c.oneShot {
+		return ctx
+	}
+	return StatusReporterIntoContext(ctx, c.statusReporter)
+}
+
+type statusReporterKey struct{}
+
+// StatusReporterFromContext returns the status reporter of the controller from the context, if any.
+func StatusReporterFromContext(ctx context.Context) (*StatusReporter, bool) {
+	reporter, ok := ctx.Value(statusReporterKey{}).(*StatusReporter)
+	return reporter, ok
+}
+
+// StatusReporterIntoContext returns a new context with the status reporter set.
+func StatusReporterIntoContext(ctx context.Context, reporter *StatusReporter) context.Context {
+	return context.WithValue(ctx, statusReporterKey{}, reporter)
+}
+
+// ReportStatus queues a status write in the status reporter of the context (see WithStatusReporter), or writes the
+// status right away if there is none, returning the error of the write.
+// Keys identify the writes that replace each other, e.g. the URL of the resource whose whole status is written.
+// Nothing is written in the ManagementStateUnmanaged management state.
+func ReportStatus(ctx context.Context, key string, write StatusWriteFunc) error {
+	if IsUnmanagedState(ctx) {
+		LoggerFromContext(ctx).V(1).Info("skipping status write in the unmanaged management state", "key", key)
+		return nil
+	}
+	if reporter, ok := StatusReporterFromContext(ctx); ok {
+		reporter.Report(key, write)
+		return nil
+	}
+	return write(ctx)
+}
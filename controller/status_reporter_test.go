@@ -0,0 +1,154 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestReportStatus(t *testing.T) {
+	failure := errors.New("failure")
+	written := false
+	err := ReportStatus(context.TODO(), "key", func(context.Context) error {
+		written = true
+		return failure
+	})
+	if !written || !errors.Is(err, failure) {
+		t.Errorf("expected the status written right away without a reporter, got written=%t, err=%v", written, err)
+	}
+}
+
+func TestStatusReporter(t *testing.T) {
+	reporter := newStatusReporter(2, retryBackoff{initial: time.Millisecond, max: 10 * time.Millisecond}, logr.Discard(), func() bool { return false })
+
+	var replaced, latest, retried atomic.Int32
+	reporter.Report("a", func(context.Context) error {
+		replaced.Add(1)
+		return nil
+	})
+	reporter.Report("a", func(context.Context) error {
+		latest.Add(1)
+		return nil
+	})
+	reporter.Report("b", func(context.Context) error {
+		if retried.Add(1) < 3 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	if reporter.Len() != 2 {
+		t.Fatalf("expected 2 pending writes, got %d", reporter.Len())
+	}
+
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	reporter.start(ctx)
+
+	if err := wait.PollUntilContextTimeout(ctx, time.Millisecond, 5*time.Second, true, func(context.Context) (bool, error) {
+		return reporter.Len() == 0 && latest.Load() == 1 && retried.Load() == 3, nil
+	}); err != nil {
+		t.Fatalf("expected all writes done, got %d pending", reporter.Len())
+	}
+	if replaced.Load() != 0 || latest.Load() != 1 {
+		t.Errorf("expected only the latest write of a key, got %d replaced and %d latest writes", replaced.Load(), latest.Load())
+	}
+	if retried.Load() != 3 {
+		t.Errorf("expected the failed write to be retried until it succeeds, got %d attempts", retried.Load())
+	}
+}
+
+func TestStatusReporterPolicyConditions(t *testing.T) {
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", Generation: 1},
+	}
+	content, _ := runtime.DefaultUnstructuredConverter.ToUnstructured(policy)
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"},
+		&unstructured.Unstructured{Object: content},
+	)
+	// the first update conflicts, e.g. because the policy changed in the meantime
+	var conflicts, gets atomic.Int32
+	client.PrependReactor("update", "testpolicies", func(action clienttesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() == "status" && conflicts.Add(1) == 1 {
+			return true, nil, apierrors.NewConflict(policyResource.GroupResource(), "my-policy", errors.New("conflict"))
+		}
+		return false, nil, nil
+	})
+	client.PrependReactor("get", "testpolicies", func(clienttesting.Action) (bool, runtime.Object, error) {
+		gets.Add(1)
+		return false, nil, nil
+	})
+
+	reporter := newStatusReporter(1, retryBackoff{initial: time.Millisecond, max: 10 * time.Millisecond}, logr.Discard(), func() bool { return false })
+	ctx, cancel := context.WithCancel(context.TODO())
+	defer cancel()
+	reporter.start(ctx)
+
+	updated, err := SetPolicyStatusCondition(StatusReporterIntoContext(ctx, reporter), client.Resource(policyResource), policy, func(generation int64) metav1.Condition {
+		return metav1.Condition{Type: PolicyConditionEnforced, Status: metav1.ConditionTrue, Reason: "Enforced", ObservedGeneration: generation}
+	})
+	if err != nil || !updated {
+		t.Fatalf("expected the status update queued, got updated=%t, err=%v", updated, err)
+	}
+
+	if err := wait.PollUntilContextTimeout(ctx, time.Millisecond, 5*time.Second, true, func(ctx context.Context) (bool, error) {
+		obj, err := client.Resource(policyResource).Namespace("my-namespace").Get(ctx, "my-policy", metav1.GetOptions{})
+		if err != nil {
+			return false, err
+		}
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		var parsed []metav1.Condition
+		for _, c := range conditions {
+			condition := metav1.Condition{}
+			_ = runtime.DefaultUnstructuredConverter.FromUnstructured(c.(map[string]any), &condition)
+			parsed = append(parsed, condition)
+		}
+		return meta.IsStatusConditionTrue(parsed, PolicyConditionEnforced), nil
+	}); err != nil {
+		t.Fatalf("expected the status updated asynchronously: %v", err)
+	}
+	if conflicts.Load() != 2 || gets.Load() < 2 {
+		t.Errorf("expected the conflicting update retried after refreshing the policy, got %d updates and %d gets", conflicts.Load(), gets.Load())
+	}
+}
+
+func TestStatusReporterPolicyConditionsKeyedByPolicy(t *testing.T) {
+	policyResource := schema.GroupVersionResource{Group: "test", Version: "v1", Resource: "testpolicies"}
+	policy := &machinery.TestPolicy{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "test/v1", Kind: "TestPolicy"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-policy", Namespace: "my-namespace", Generation: 1},
+	}
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{policyResource: "TestPolicyList"})
+
+	reporter := newStatusReporter(1, retryBackoff{initial: time.Millisecond, max: 10 * time.Millisecond}, logr.Discard(), func() bool { return false })
+	ctx := StatusReporterIntoContext(context.TODO(), reporter)
+	for _, conditionType := range []string{PolicyConditionEnforced, "Accepted"} {
+		if _, err := SetPolicyStatusCondition(ctx, client.Resource(policyResource), policy, func(generation int64) metav1.Condition {
+			return metav1.Condition{Type: conditionType, Status: metav1.ConditionTrue, Reason: conditionType, ObservedGeneration: generation}
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if reporter.Len() != 1 {
+		t.Errorf("expected the latest update of the policy to replace the previous one, got %d pending writes", reporter.Len())
+	}
+}
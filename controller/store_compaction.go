@@ -0,0 +1,175 @@
+package controller
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// GroupKindStats is the memory usage of the objects of a kind in a store.
+type GroupKindStats struct {
+	// Objects is the number of objects of the kind.
+	Objects int
+	// Bytes is an estimate of the memory used by the objects of the kind, based on the size of their JSON
+	// representation.
+	Bytes int
+}
+
+// StoreStats is the memory usage of a store, per kind of object.
+type StoreStats map[schema.GroupKind]GroupKindStats
+
+// Total returns the memory usage of all the objects of the store.
+func (s StoreStats) Total() GroupKindStats {
+	var total GroupKindStats
+	for _, stats := range s {
+		total.Objects += stats.Objects
+		total.Bytes += stats.Bytes
+	}
+	return total
+}
+
+// Stats returns the number of objects and an estimate of the memory used by them, per kind of object.
+func (s Store) Stats() StoreStats {
+	stats := make(StoreStats)
+	for _, obj := range s {
+		gk := obj.GetObjectKind().GroupVersionKind().GroupKind()
+		kindStats := stats[gk]
+		kindStats.Objects++
+		if b, err := json.Marshal(obj); err == nil {
+			kindStats.Bytes += len(b)
+		}
+		stats[gk] = kindStats
+	}
+	return stats
+}
+
+// FieldMask is a list of the fields of the objects of a kind needed by the reconcilers, e.g. "spec.parentRefs" or
+// "status". Fields are dot-separated paths of nested objects; lists are kept or dropped as a whole.
+// The kind, the API version and the metadata of the objects, except the managed fields, are always kept.
+type FieldMask []string
+
+// Compact returns a copy of the store with the fields of the objects not in the masks of their kinds dropped.
+// Objects of kinds without a mask are kept as they are.
+func (s Store) Compact(masks map[schema.GroupKind]FieldMask) (Store, error) {
+	compacted := make(Store, len(s))
+	var errs []error
+	for uid, obj := range s {
+		mask, ok := masks[obj.GetObjectKind().GroupVersionKind().GroupKind()]
+		if !ok {
+			compacted[uid] = obj
+			continue
+		}
+		compactedObj, err := CompactObject(obj, mask)
+		if err != nil {
+			errs = append(errs, err)
+			compacted[uid] = obj
+			continue
+		}
+		compacted[uid] = compactedObj
+	}
+	if len(errs) > 0 {
+		return compacted, fmt.Errorf("failed to compact %d objects: %w", len(errs), errs[0])
+	}
+	return compacted, nil
+}
+
+// CompactObject returns a copy of an object with only the fields in the mask, the kind, the API version and the
+// metadata, except the managed fields. The copy is of the same type as the object.
+func CompactObject(obj Object, mask FieldMask) (Object, error) {
+	var content map[string]any
+	if u, ok := obj.(*unstructured.Unstructured); ok {
+		content = u.UnstructuredContent()
+	} else {
+		var err error
+		if content, err = runtime.DefaultUnstructuredConverter.ToUnstructured(obj); err != nil {
+			return nil, conversionError(err)
+		}
+	}
+
+	compacted := map[string]any{}
+	for _, path := range append([]string{"apiVersion", "kind", "metadata"}, mask...) {
+		copyField(content, compacted, strings.Split(path, "."))
+	}
+	if metadata, ok := compacted["metadata"].(map[string]any); ok {
+		metadata = lo.OmitByKeys(metadata, []string{"managedFields"})
+		compacted["metadata"] = metadata
+	}
+
+	if _, ok := obj.(*unstructured.Unstructured); ok {
+		return &unstructured.Unstructured{Object: runtime.DeepCopyJSON(compacted)}, nil
+	}
+	t := reflect.TypeOf(obj)
+	if t.Kind() != reflect.Pointer {
+		return nil, fmt.Errorf("%w: unexpected object type: %T", ErrConversion, obj)
+	}
+	compactedObj := reflect.New(t.Elem()).Interface()
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(runtime.DeepCopyJSON(compacted), compactedObj); err != nil {
+		return nil, conversionError(err)
+	}
+	return compactedObj.(Object), nil
+}
+
+// copyField copies the field at a path of an unstructured object to another, creating the parent fields as needed
+func copyField(from, to map[string]any, path []string) {
+	value, ok := from[path[0]]
+	if !ok {
+		return
+	}
+	if len(path) == 1 {
+		to[path[0]] = value
+		return
+	}
+	nested, ok := value.(map[string]any)
+	if !ok {
+		to[path[0]] = value
+		return
+	}
+	if _, ok := to[path[0]].(map[string]any); !ok {
+		to[path[0]] = map[string]any{}
+	}
+	copyField(nested, to[path[0]].(map[string]any), path[1:])
+}
+
+// WithFieldMask declares the fields of the objects of a kind needed by the reconcilers. The fields not declared are
+// dropped from the objects before they are cached, to reduce the memory used by the controller on very large
+// clusters. Masks declared multiple times for the same kind are merged; kinds without a mask are cached whole.
+func WithFieldMask(kind schema.GroupKind, fields ...string) ControllerOption {
+	return func(o *ControllerOptions) {
+		if o.fieldMasks == nil {
+			o.fieldMasks = make(map[schema.GroupKind]FieldMask)
+		}
+		mask := lo.Uniq(append(o.fieldMasks[kind], fields...))
+		sort.Strings(mask)
+		o.fieldMasks[kind] = mask
+	}
+}
+
+// StoreStats returns the number of objects cached by the controller and an estimate of the memory used by them, per
+// kind of object.
+func (c *Controller) StoreStats() StoreStats {
+	return c.cache.List().Stats()
+}
+
+// compact drops the fields of an object not in the mask of its kind, if any
+func (c *Controller) compact(obj Object) Object {
+	if obj == nil {
+		return nil
+	}
+	mask, ok := c.fieldMasks[obj.GetObjectKind().GroupVersionKind().GroupKind()]
+	if !ok {
+		return obj
+	}
+	compacted, err := CompactObject(obj, mask)
+	if err != nil {
+		c.logger.Error(err, "failed to compact object", "kind", obj.GetObjectKind().GroupVersionKind().Kind, "namespace", obj.GetNamespace(), "name", obj.GetName())
+		return obj
+	}
+	return compacted
+}
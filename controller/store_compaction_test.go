@@ -0,0 +1,162 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var configMapKind = schema.GroupKind{Kind: "ConfigMap"}
+
+func compactionTestConfigMap(uid string) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{
+			UID:           types.UID(uid),
+			Name:          uid,
+			Namespace:     "default",
+			Labels:        map[string]string{"app": "test"},
+			ManagedFields: []metav1.ManagedFieldsEntry{{Manager: "kubectl"}},
+		},
+		Data:       map[string]string{"config": "some large configuration"},
+		BinaryData: map[string][]byte{"blob": []byte("some large blob")},
+	}
+}
+
+func TestStoreStats(t *testing.T) {
+	store := Store{
+		"a": compactionTestConfigMap("a"),
+		"b": compactionTestConfigMap("b"),
+		"c": &corev1.Secret{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: metav1.ObjectMeta{UID: "c", Name: "c"}},
+	}
+	stats := store.Stats()
+	if len(stats) != 2 {
+		t.Fatalf("expected stats of 2 kinds, got %d", len(stats))
+	}
+	if stats[configMapKind].Objects != 2 {
+		t.Errorf("expected 2 configmaps, got %d", stats[configMapKind].Objects)
+	}
+	if secrets := stats[schema.GroupKind{Kind: "Secret"}]; secrets.Objects != 1 || secrets.Bytes == 0 {
+		t.Errorf("expected 1 secret with a size estimate, got %+v", secrets)
+	}
+	if stats[configMapKind].Bytes <= stats[schema.GroupKind{Kind: "Secret"}].Bytes {
+		t.Errorf("expected configmaps to use more memory than the secret, got %+v", stats)
+	}
+	if total := stats.Total(); total.Objects != 3 || total.Bytes != stats[configMapKind].Bytes+stats[schema.GroupKind{Kind: "Secret"}].Bytes {
+		t.Errorf("unexpected total %+v", total)
+	}
+}
+
+func TestCompactObject(t *testing.T) {
+	obj, err := CompactObject(compactionTestConfigMap("a"), FieldMask{"data"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	configMap, ok := obj.(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected a configmap, got %T", obj)
+	}
+	if configMap.Name != "a" || configMap.Namespace != "default" || configMap.UID != "a" || configMap.Labels["app"] != "test" {
+		t.Errorf("expected metadata to be kept, got %+v", configMap.ObjectMeta)
+	}
+	if configMap.Kind != "ConfigMap" {
+		t.Errorf("expected kind to be kept, got %q", configMap.Kind)
+	}
+	if len(configMap.ManagedFields) != 0 {
+		t.Errorf("expected managed fields to be dropped, got %v", configMap.ManagedFields)
+	}
+	if configMap.Data["config"] != "some large configuration" {
+		t.Errorf("expected data to be kept, got %v", configMap.Data)
+	}
+	if len(configMap.BinaryData) != 0 {
+		t.Errorf("expected binary data to be dropped, got %v", configMap.BinaryData)
+	}
+}
+
+func TestCompactUnstructuredObject(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]any{
+		"apiVersion": "example.com/v1",
+		"kind":       "Widget",
+		"metadata":   map[string]any{"name": "w", "uid": "w"},
+		"spec": map[string]any{
+			"targetRef": map[string]any{"name": "gateway"},
+			"rules":     []any{"a", "b"},
+			"large":     "dropped",
+		},
+		"status": map[string]any{"conditions": []any{}},
+	}}
+	compacted, err := CompactObject(obj, FieldMask{"spec.targetRef", "spec.rules"})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	u := compacted.(*unstructured.Unstructured)
+	if name, _, _ := unstructured.NestedString(u.Object, "spec", "targetRef", "name"); name != "gateway" {
+		t.Errorf("expected spec.targetRef to be kept, got %v", u.Object)
+	}
+	if rules, _, _ := unstructured.NestedSlice(u.Object, "spec", "rules"); len(rules) != 2 {
+		t.Errorf("expected spec.rules to be kept, got %v", u.Object)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(u.Object, "spec", "large"); found {
+		t.Errorf("expected spec.large to be dropped, got %v", u.Object)
+	}
+	if _, found := u.Object["status"]; found {
+		t.Errorf("expected status to be dropped, got %v", u.Object)
+	}
+	if _, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "spec", "large"); !found {
+		t.Error("expected the original object not to be modified")
+	}
+}
+
+func TestStoreCompact(t *testing.T) {
+	store := Store{
+		"a": compactionTestConfigMap("a"),
+		"b": &corev1.Secret{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Secret"}, ObjectMeta: metav1.ObjectMeta{UID: "b", Name: "b"}, Data: map[string][]byte{"key": []byte("value")}},
+	}
+	compacted, err := store.Compact(map[schema.GroupKind]FieldMask{configMapKind: nil})
+	if err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+	if len(compacted["a"].(*corev1.ConfigMap).Data) != 0 {
+		t.Errorf("expected the configmap to be compacted, got %+v", compacted["a"])
+	}
+	if len(compacted["b"].(*corev1.Secret).Data) != 1 {
+		t.Errorf("expected the secret to be kept as is, got %+v", compacted["b"])
+	}
+	if len(store["a"].(*corev1.ConfigMap).Data) != 1 {
+		t.Error("expected the original store not to be modified")
+	}
+	if compacted.Stats().Total().Bytes >= store.Stats().Total().Bytes {
+		t.Error("expected the compacted store to use less memory")
+	}
+}
+
+func TestControllerWithFieldMask(t *testing.T) {
+	c := NewController(
+		WithFieldMask(configMapKind, "data"),
+		WithFieldMask(configMapKind, "data", "immutable"),
+	)
+	if mask := c.fieldMasks[configMapKind]; len(mask) != 2 || mask[0] != "data" || mask[1] != "immutable" {
+		t.Errorf("expected merged field mask, got %v", mask)
+	}
+
+	c.add(compactionTestConfigMap("a"))
+	cached, ok := c.cache.List()["a"].(*corev1.ConfigMap)
+	if !ok {
+		t.Fatalf("expected the configmap to be cached, got %v", c.cache.List())
+	}
+	if len(cached.BinaryData) != 0 || len(cached.ManagedFields) != 0 {
+		t.Errorf("expected the cached configmap to be compacted, got %+v", cached)
+	}
+	if cached.Data["config"] == "" {
+		t.Errorf("expected the data of the cached configmap to be kept, got %+v", cached)
+	}
+	if stats := c.StoreStats(); stats[configMapKind].Objects != 1 {
+		t.Errorf("expected 1 configmap in the store stats, got %+v", stats)
+	}
+}
@@ -2,22 +2,36 @@ package controller
 
 import (
 	"context"
+	"reflect"
 
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 
 	"github.com/kuadrant/policy-machinery/machinery"
 )
 
 // Subscription runs a reconciliation function when the list of events has at least one event in common with
-// the list of event matchers. The list of events then propagated to the reconciliation function is filtered
-// to the ones the match only.
+// the list of event matchers that also satisfies all the predicates. The list of events then propagated to the
+// reconciliation function is filtered to the ones the match only. If no event matcher is given but predicates are,
+// the events are matched by the predicates only.
+// The subgraph of the topology affected by the matching events is available to the reconciliation function with
+// AffectedSubgraphFromContext.
 type Subscription struct {
 	ReconcileFunc ReconcileFunc
 	Events        []ResourceEventMatcher
+	Predicates    []EventPredicate
 }
 
 func (s Subscription) Reconcile(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) {
 	matchingEvents := lo.Filter(resourceEvents, func(resourceEvent ResourceEvent, _ int) bool {
+		if !lo.EveryBy(s.Predicates, func(p EventPredicate) bool { return p(resourceEvent) }) {
+			return false
+		}
+		if len(s.Events) == 0 {
+			return len(s.Predicates) > 0
+		}
 		return lo.ContainsBy(s.Events, func(m ResourceEventMatcher) bool {
 			obj := resourceEvent.OldObject
 			if obj == nil {
@@ -30,6 +44,64 @@ func (s Subscription) Reconcile(ctx context.Context, resourceEvents []ResourceEv
 		})
 	})
 	if len(matchingEvents) > 0 && s.ReconcileFunc != nil {
-		s.ReconcileFunc(ctx, matchingEvents, topology)
+		ctx = AffectedSubgraphIntoContext(ctx, matchingEvents, topology)
+		tracedReconcileFunc(s.ReconcileFunc)(ctx, matchingEvents, topology)
 	}
 }
+
+// EventPredicate selects the events a subscription is interested in.
+type EventPredicate func(ResourceEvent) bool
+
+// EventKindIn selects the events of objects of any of the given kinds.
+func EventKindIn(kinds ...schema.GroupKind) EventPredicate {
+	return func(event ResourceEvent) bool {
+		return lo.Contains(kinds, event.Kind)
+	}
+}
+
+// EventNamespaceIn selects the events of objects in any of the given namespaces.
+func EventNamespaceIn(namespaces ...string) EventPredicate {
+	return func(event ResourceEvent) bool {
+		return lo.ContainsBy(eventObjects(event), func(obj Object) bool { return lo.Contains(namespaces, obj.GetNamespace()) })
+	}
+}
+
+// EventLabelsMatch selects the events of objects whose labels match a selector. Updates are selected if either the
+// old or the new version of the object matches, so subscribers are told about objects no longer selected.
+func EventLabelsMatch(selector labels.Selector) EventPredicate {
+	return func(event ResourceEvent) bool {
+		return lo.ContainsBy(eventObjects(event), func(obj Object) bool { return selector.Matches(labels.Set(obj.GetLabels())) })
+	}
+}
+
+// GenerationChanged selects the creations and deletions of objects, and the updates that change the generation of
+// the objects, i.e. filters out updates of the status and of the metadata.
+func GenerationChanged(event ResourceEvent) bool {
+	if event.EventType != UpdateEvent || event.OldObject == nil || event.NewObject == nil {
+		return true
+	}
+	return event.OldObject.GetGeneration() != event.NewObject.GetGeneration()
+}
+
+// SpecChanged selects the creations and deletions of objects, and the updates that change the spec of the objects.
+// Unlike GenerationChanged, it also works for kinds whose generation is not incremented by the API server on changes
+// of the spec, at the cost of comparing the objects.
+func SpecChanged(event ResourceEvent) bool {
+	if event.EventType != UpdateEvent || event.OldObject == nil || event.NewObject == nil {
+		return true
+	}
+	oldObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event.OldObject)
+	if err != nil {
+		return true
+	}
+	newObj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(event.NewObject)
+	if err != nil {
+		return true
+	}
+	return !reflect.DeepEqual(oldObj["spec"], newObj["spec"])
+}
+
+// eventObjects returns the versions of the object of an event that are set
+func eventObjects(event ResourceEvent) []Object {
+	return lo.Filter([]Object{event.OldObject, event.NewObject}, func(obj Object, _ int) bool { return obj != nil })
+}
@@ -0,0 +1,108 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"testing"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func subscriptionTestService(namespace, name string, generation int64, selector string, labels map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, Generation: generation, Labels: labels},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": selector}},
+	}
+}
+
+func TestSubscriptionPredicates(t *testing.T) {
+	serviceKind := schema.GroupKind{Kind: "Service"}
+	configMapKind := schema.GroupKind{Kind: "ConfigMap"}
+
+	created := ResourceEvent{Kind: serviceKind, EventType: CreateEvent, NewObject: subscriptionTestService("ns1", "created", 1, "a", map[string]string{"team": "a"})}
+	specChanged := ResourceEvent{Kind: serviceKind, EventType: UpdateEvent,
+		OldObject: subscriptionTestService("ns1", "spec-changed", 1, "a", nil),
+		NewObject: subscriptionTestService("ns1", "spec-changed", 1, "b", nil),
+	}
+	generationChanged := ResourceEvent{Kind: serviceKind, EventType: UpdateEvent,
+		OldObject: subscriptionTestService("ns2", "generation-changed", 1, "a", map[string]string{"team": "a"}),
+		NewObject: subscriptionTestService("ns2", "generation-changed", 2, "a", map[string]string{"team": "b"}),
+	}
+	metadataChanged := ResourceEvent{Kind: serviceKind, EventType: UpdateEvent,
+		OldObject: subscriptionTestService("ns1", "metadata-changed", 1, "a", nil),
+		NewObject: subscriptionTestService("ns1", "metadata-changed", 1, "a", map[string]string{"team": "b"}),
+	}
+	configMapDeleted := ResourceEvent{Kind: configMapKind, EventType: DeleteEvent, OldObject: &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Namespace: "ns1", Name: "deleted"}}}
+	events := []ResourceEvent{created, specChanged, generationChanged, metadataChanged, configMapDeleted}
+
+	testCases := []struct {
+		name       string
+		events     []ResourceEventMatcher
+		predicates []EventPredicate
+		expected   []string
+	}{
+		{
+			name:     "no matchers nor predicates",
+			expected: nil,
+		},
+		{
+			name:       "kinds",
+			predicates: []EventPredicate{EventKindIn(configMapKind)},
+			expected:   []string{"deleted"},
+		},
+		{
+			name:       "namespaces",
+			predicates: []EventPredicate{EventNamespaceIn("ns2")},
+			expected:   []string{"generation-changed"},
+		},
+		{
+			name:       "label selector",
+			predicates: []EventPredicate{EventLabelsMatch(labels.SelectorFromSet(labels.Set{"team": "b"}))},
+			expected:   []string{"generation-changed", "metadata-changed"},
+		},
+		{
+			name:       "generation changed",
+			predicates: []EventPredicate{GenerationChanged},
+			expected:   []string{"created", "generation-changed", "deleted"},
+		},
+		{
+			name:       "spec changed",
+			predicates: []EventPredicate{SpecChanged},
+			expected:   []string{"created", "spec-changed", "deleted"},
+		},
+		{
+			name:       "predicates combined",
+			predicates: []EventPredicate{EventKindIn(serviceKind), EventNamespaceIn("ns1"), SpecChanged},
+			expected:   []string{"created", "spec-changed"},
+		},
+		{
+			name:       "predicates combined with matchers",
+			events:     []ResourceEventMatcher{{EventType: lo.ToPtr(UpdateEvent)}},
+			predicates: []EventPredicate{EventNamespaceIn("ns1")},
+			expected:   []string{"spec-changed", "metadata-changed"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			var reconciled []string
+			Subscription{
+				ReconcileFunc: func(_ context.Context, events []ResourceEvent, _ *machinery.Topology) {
+					reconciled = lo.Map(events, func(event ResourceEvent, _ int) string { return eventObjects(event)[0].GetName() })
+				},
+				Events:     tc.events,
+				Predicates: tc.predicates,
+			}.Reconcile(context.Background(), events, nil)
+			if !lo.Every(tc.expected, reconciled) || len(reconciled) != len(tc.expected) {
+				t.Errorf("expected events of %v, got %v", tc.expected, reconciled)
+			}
+		})
+	}
+}
@@ -54,9 +54,9 @@ func init() {
 			testLogger.Info("reconcile",
 				"kind", event.Kind,
 				"event", event.EventType.String(),
-				"targetables", len(topology.Targetables().Items()),
-				"policies", len(topology.Policies().Items()),
-				"objects", len(topology.Objects().Items()),
+				"targetables", topology.Targetables().Count(),
+				"policies", topology.Policies().Count(),
+				"objects", topology.Objects().Count(),
 			)
 		}
 	}
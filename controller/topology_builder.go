@@ -1,33 +1,58 @@
 package controller
 
 import (
+	"fmt"
+	"sort"
+
 	"github.com/samber/lo"
 	core "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
 	"github.com/kuadrant/policy-machinery/machinery"
 )
 
-func newGatewayAPITopologyBuilder(policyKinds, objectKinds []schema.GroupKind, objectLinks []LinkFunc) *gatewayAPITopologyBuilder {
+func newGatewayAPITopologyBuilder(policyKinds, objectKinds []schema.GroupKind, objectLinks []LinkFunc, configKind *schema.GroupKind, configuredKinds []schema.GroupKind) *gatewayAPITopologyBuilder {
 	return &gatewayAPITopologyBuilder{
-		policyKinds: policyKinds,
-		objectKinds: objectKinds,
-		objectLinks: objectLinks,
+		policyKinds:     policyKinds,
+		objectKinds:     objectKinds,
+		objectLinks:     objectLinks,
+		configKind:      configKind,
+		configuredKinds: configuredKinds,
 	}
 }
 
 type gatewayAPITopologyBuilder struct {
-	policyKinds []schema.GroupKind
-	objectKinds []schema.GroupKind
-	objectLinks []LinkFunc
+	policyKinds     []schema.GroupKind
+	objectKinds     []schema.GroupKind
+	objectLinks     []LinkFunc
+	configKind      *schema.GroupKind
+	configuredKinds []schema.GroupKind
+	warmingHints    []machinery.WarmingHint
+	externalEntries []ExternalServiceEntriesFunc
+	gatewayMerging  []machinery.GatewayMergingFunc
+	objectWrappers  []ObjectWrapperFunc
+
+	pruneEmptySections bool
+	backendRefTypes    bool
+	gatewaySections    bool
+	sectionSeparator   machinery.SectionNameSeparator
+	endpoints          bool
+	namespaces         bool
+	gatewayClassParams []schema.GroupKind
+	inferenceExtension bool
 }
 
 func (t *gatewayAPITopologyBuilder) Build(objs Store) *machinery.Topology {
 	gatewayClasses := lo.Map(objs.FilterByGroupKind(GatewayClassKind), ObjectAs[*gwapiv1.GatewayClass])
 	gateways := lo.Map(objs.FilterByGroupKind(GatewayKind), ObjectAs[*gwapiv1.Gateway])
 	httpRoutes := lo.Map(objs.FilterByGroupKind(HTTPRouteKind), ObjectAs[*gwapiv1.HTTPRoute])
+	tlsRoutes := lo.Map(objs.FilterByGroupKind(TLSRouteKind), ObjectAs[*gwapiv1alpha2.TLSRoute])
+	udpRoutes := lo.Map(objs.FilterByGroupKind(UDPRouteKind), ObjectAs[*gwapiv1alpha2.UDPRoute])
 	services := lo.Map(objs.FilterByGroupKind(ServiceKind), ObjectAs[*core.Service])
+	secrets := lo.Map(objs.FilterByGroupKind(SecretKind), ObjectAs[*core.Secret])
 
 	linkFuncs := lo.Map(t.objectLinks, func(f LinkFunc, _ int) machinery.LinkFunc {
 		return f(objs)
@@ -37,13 +62,67 @@ func (t *gatewayAPITopologyBuilder) Build(objs Store) *machinery.Topology {
 		machinery.WithGatewayClasses(gatewayClasses...),
 		machinery.WithGateways(gateways...),
 		machinery.WithHTTPRoutes(httpRoutes...),
+		machinery.WithTLSRoutes(tlsRoutes...),
+		machinery.WithUDPRoutes(udpRoutes...),
 		machinery.WithServices(services...),
+		machinery.WithSecrets(secrets...),
 		machinery.ExpandGatewayListeners(),
 		machinery.ExpandHTTPRouteRules(),
+		machinery.ExpandTLSRouteRules(),
+		machinery.ExpandUDPRouteRules(),
 		machinery.ExpandServicePorts(),
 		machinery.WithGatewayAPITopologyLinks(linkFuncs...),
 	}
 
+	if len(t.gatewayMerging) > 0 {
+		opts = append(opts, machinery.WithGatewayMerging(t.gatewayMerging...))
+	}
+
+	if t.pruneEmptySections {
+		opts = append(opts, machinery.PruneEmptyExpansions())
+	}
+
+	if t.backendRefTypes {
+		opts = append(opts, machinery.WithBackendRefTypes())
+	}
+
+	if t.sectionSeparator != 0 {
+		opts = append(opts, machinery.WithGatewayAPITopologySectionNameSeparator(t.sectionSeparator))
+	}
+
+	if t.gatewaySections {
+		opts = append(opts, machinery.ExpandGatewayInfrastructure(), machinery.ExpandGatewayAddresses())
+	}
+
+	if t.endpoints {
+		opts = append(opts,
+			machinery.WithEndpointSlices(lo.Map(objs.FilterByGroupKind(EndpointSliceKind), ObjectAs[*discoveryv1.EndpointSlice])...),
+			machinery.WithPods(lo.Map(objs.FilterByGroupKind(PodKind), ObjectAs[*core.Pod])...),
+		)
+	}
+
+	if len(t.gatewayClassParams) > 0 {
+		opts = append(opts, machinery.WithGatewayClassParameters(t.gatewayClassParams...))
+	}
+
+	if t.namespaces {
+		opts = append(opts, machinery.WithNamespaces(lo.Map(objs.FilterByGroupKind(NamespaceKind), ObjectAs[*core.Namespace])...))
+	}
+
+	if t.inferenceExtension {
+		opts = append(opts, inferenceExtensionTopologyOptions(objs)...)
+	}
+
+	if namespaces := objs.FilterByGroupKind(NamespaceKind); len(namespaces) > 0 {
+		opts = append(opts, machinery.WithGatewayAPITopologyNamespaceLabels(lo.SliceToMap(namespaces, func(namespace Object) (string, map[string]string) {
+			return namespace.GetName(), namespace.GetLabels()
+		})))
+	}
+
+	for _, entries := range t.externalEntries {
+		opts = append(opts, machinery.WithExternalServiceEntries(entries()...))
+	}
+
 	for i := range t.policyKinds {
 		policyKind := t.policyKinds[i]
 		policies := lo.Map(objs.FilterByGroupKind(policyKind), ObjectAs[machinery.Policy])
@@ -52,7 +131,24 @@ func (t *gatewayAPITopologyBuilder) Build(objs Store) *machinery.Topology {
 
 	for i := range t.objectKinds {
 		objectKind := t.objectKinds[i]
+		if objectKind == SecretKind {
+			continue // secrets are added to the topology linked to the gateways that refer to them
+		}
+		if t.namespaces && objectKind == NamespaceKind {
+			continue // added to the topology as targetables
+		}
+		if t.endpoints && (objectKind == EndpointSliceKind || objectKind == PodKind) {
+			continue // added to the topology as targetables
+		}
+		if t.inferenceExtension && (objectKind == InferencePoolKind || objectKind == InferenceModelKind) {
+			continue // added to the topology as targetables
+		}
 		objects := lo.FilterMap(objs.FilterByGroupKind(objectKind), func(obj Object, _ int) (machinery.Object, bool) {
+			for _, wrap := range t.objectWrappers {
+				if object, ok := wrap(obj); ok {
+					return object, true
+				}
+			}
 			object, ok := obj.(machinery.Object)
 			if ok {
 				return object, ok
@@ -62,5 +158,31 @@ func (t *gatewayAPITopologyBuilder) Build(objs Store) *machinery.Topology {
 		opts = append(opts, machinery.WithGatewayAPITopologyObjects(objects...))
 	}
 
-	return machinery.NewGatewayAPITopology(opts...)
+	if t.configKind != nil {
+		if config := singletonConfig(objs.FilterByGroupKind(*t.configKind)); config != nil {
+			opts = append(opts, machinery.WithGatewayAPITopologyConfig(config, t.configuredKinds...))
+		}
+	}
+
+	topology := machinery.NewGatewayAPITopology(opts...)
+	topology.Warm(t.warmingHints...)
+	return topology
+}
+
+// singletonConfig returns the oldest of the given objects as a topology object, or nil if there are none.
+func singletonConfig(objs []Object) machinery.Object {
+	if len(objs) == 0 {
+		return nil
+	}
+	sort.Slice(objs, func(i, j int) bool {
+		ti, tj := objs[i].GetCreationTimestamp(), objs[j].GetCreationTimestamp()
+		if !ti.Equal(&tj) {
+			return ti.Before(&tj)
+		}
+		return fmt.Sprintf("%s/%s", objs[i].GetNamespace(), objs[i].GetName()) < fmt.Sprintf("%s/%s", objs[j].GetNamespace(), objs[j].GetName())
+	})
+	if object, ok := objs[0].(machinery.Object); ok {
+		return object
+	}
+	return &RuntimeObject{objs[0]}
 }
@@ -0,0 +1,256 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+	"time"
+
+	"github.com/samber/lo"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestTopologyBuilderWithSingletonConfig(t *testing.T) {
+	now := time.Now()
+	configMap := func(name string, created time.Time) *corev1.ConfigMap {
+		return &corev1.ConfigMap{
+			TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "my-namespace", UID: k8stypes.UID("uid-" + name), CreationTimestamp: metav1.NewTime(created)},
+		}
+	}
+	gatewayClass := &gwapiv1.GatewayClass{
+		TypeMeta:   metav1.TypeMeta{APIVersion: gwapiv1.GroupVersion.String(), Kind: "GatewayClass"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-gateway-class", UID: "uid-gateway-class"},
+	}
+
+	store := Store{
+		"uid-newer":         configMap("newer", now),
+		"uid-older":         configMap("older", now.Add(-time.Hour)),
+		"uid-gateway-class": gatewayClass,
+	}
+
+	builder := newGatewayAPITopologyBuilder(nil, nil, nil, &ConfigMapKind, []schema.GroupKind{GatewayClassKind})
+	topology := builder.Build(store)
+
+	config, ok := machinery.ConfigAs[*RuntimeObject](topology)
+	if !ok {
+		t.Fatalf("expected topology to have a config object, got %v", topology.Config())
+	}
+	if config.GetName() != "older" {
+		t.Errorf("expected the oldest config object, got %s", config.GetName())
+	}
+	children := topology.Targetables().Children(config)
+	if len(children) != 1 || children[0].GetName() != "my-gateway-class" {
+		t.Errorf("expected config to be linked to the gateway class, got %v", children)
+	}
+	if roots := topology.Targetables().Roots(); len(roots) != 1 || roots[0].GetName() != "my-gateway-class" {
+		t.Errorf("expected gateway class to remain a root targetable, got %v", roots)
+	}
+
+	// no config object
+	topology = builder.Build(Store{"uid-gateway-class": gatewayClass})
+	if topology.Config() != nil {
+		t.Errorf("expected topology without config object, got %v", topology.Config())
+	}
+}
+
+func TestTopologyBuilderWithCacheWarming(t *testing.T) {
+	var warmed *machinery.Topology
+	c := NewController(WithCacheWarming(func(topology *machinery.Topology) {
+		warmed = topology
+	}))
+	topology := c.topology.Build(Store{})
+	if warmed == nil || warmed != topology {
+		t.Errorf("expected the built topology to be warmed")
+	}
+}
+
+func TestTopologyBuilderWithExternalServiceEntries(t *testing.T) {
+	c := NewController(WithExternalServiceEntries(func() []*machinery.ExternalServiceEntry {
+		return []*machinery.ExternalServiceEntry{{Namespace: "my-namespace", Name: "api.example.com", Source: "consul"}}
+	}))
+	topology := c.topology.Build(Store{})
+	if backends := machinery.TargetablesOfType[*machinery.ExternalBackend](topology); len(backends) != 1 || backends[0].Source != "consul" {
+		t.Errorf("expected 1 external backend, got %v", backends)
+	}
+}
+
+func TestTopologyBuilderWithGatewayMerging(t *testing.T) {
+	parent := machinery.BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "parent"; g.UID = "parent" })
+	child := machinery.BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Name = "child"
+		g.UID = "child"
+		g.Annotations = map[string]string{machinery.MergedIntoGatewayAnnotation: "parent"}
+	})
+	c := NewController(WithGatewayMerging(machinery.GatewayMergedByAnnotation(machinery.MergedIntoGatewayAnnotation)))
+	topology := c.topology.Build(Store{"parent": parent, "child": child})
+	roots := topology.Targetables().Roots()
+	if len(roots) != 1 || roots[0].GetName() != "parent" {
+		t.Errorf("expected the child gateway merged into the parent, got roots %v", roots)
+	}
+}
+
+func TestTopologyBuilderWithEmptySectionsPruned(t *testing.T) {
+	gateway := machinery.BuildGateway()
+	listeners := func(c *Controller) int {
+		return len(c.topology.Build(Store{"gateway": gateway}).Targetables().Items(func(o machinery.Object) bool {
+			return o.GroupVersionKind().Kind == "Listener"
+		}))
+	}
+	if n := listeners(NewController()); n != 1 {
+		t.Errorf("expected 1 listener, got %d", n)
+	}
+	if n := listeners(NewController(WithEmptySectionsPruned())); n != 0 {
+		t.Errorf("expected the listener without routes to be pruned, got %d listeners", n)
+	}
+}
+
+func TestTopologyBuilderWithGatewaySections(t *testing.T) {
+	gateway := machinery.BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Addresses = []gwapiv1.GatewayAddress{{Value: "10.0.0.1"}}
+	})
+	sections := func(c *Controller) int {
+		topology := c.topology.Build(Store{"gateway": gateway})
+		return len(topology.Targetables().Children(topology.Targetables().Roots()[0]))
+	}
+	if n := sections(NewController()); n != 1 {
+		t.Errorf("expected only the listener as section of the gateway, got %d sections", n)
+	}
+	if n := sections(NewController(WithGatewaySections())); n != 3 {
+		t.Errorf("expected the listener, the infrastructure and the address as sections of the gateway, got %d sections", n)
+	}
+}
+
+func TestTopologyBuilderWithEndpoints(t *testing.T) {
+	store := Store{
+		"service":       machinery.BuildService(),
+		"endpointslice": machinery.BuildEndpointSlice(),
+		"pod":           machinery.BuildPod(),
+	}
+	targetablePods := func(c *Controller) []machinery.Targetable {
+		return c.topology.Build(store).Targetables().Items(machinery.IsKind(PodKind))
+	}
+	if pods := targetablePods(NewController(WithObjectKinds(EndpointSliceKind, PodKind))); len(pods) != 0 {
+		t.Errorf("expected no pods as targetables without the option, got %v", pods)
+	}
+	// endpoint slices and pods watched as object kinds are not added twice
+	c := NewController(WithEndpoints(), WithObjectKinds(EndpointSliceKind, PodKind))
+	topology := c.topology.Build(store)
+	if objects := topology.Objects().Items(); len(objects) != 0 {
+		t.Errorf("expected no endpoint slices or pods as objects, got %v", objects)
+	}
+	pods := topology.Targetables().Items(machinery.IsKind(PodKind))
+	if len(pods) != 1 {
+		t.Fatalf("expected 1 pod, got %d", len(pods))
+	}
+	if paths := topology.Targetables().AncestorPaths(pods[0]); len(paths) != 1 || paths[0][0].GetName() != "my-service" {
+		t.Errorf("expected the pod below the service through the endpoint slice, got %v", paths)
+	}
+}
+
+func TestTopologyBuilderWithNamespaces(t *testing.T) {
+	store := Store{
+		"namespace": &corev1.Namespace{TypeMeta: metav1.TypeMeta{APIVersion: "v1", Kind: "Namespace"}, ObjectMeta: metav1.ObjectMeta{Name: "my-namespace"}},
+		"gateway":   machinery.BuildGateway(),
+	}
+	if namespaces := NewController().topology.Build(store).Targetables().Items(machinery.IsKind(NamespaceKind)); len(namespaces) != 0 {
+		t.Errorf("expected no namespaces as targetables without the option, got %v", namespaces)
+	}
+	topology := NewController(WithNamespaces(), WithObjectKinds(NamespaceKind)).topology.Build(store)
+	if objects := topology.Objects().Items(); len(objects) != 0 {
+		t.Errorf("expected the namespace not added as object, got %v", objects)
+	}
+	roots := topology.Targetables().Roots()
+	if len(roots) != 1 || roots[0].GroupVersionKind().GroupKind() != NamespaceKind {
+		t.Fatalf("expected the namespace as root, got %v", lo.Map(roots, func(r machinery.Targetable, _ int) string { return r.GetURL() }))
+	}
+	if children := topology.Targetables().Children(roots[0]); len(children) != 1 || children[0].GetName() != "my-gateway" {
+		t.Errorf("expected the gateway linked from the namespace, got %v", children)
+	}
+}
+
+func TestTopologyBuilderWithGatewayClassParameters(t *testing.T) {
+	gatewayClass := machinery.BuildGatewayClass(func(gc *gwapiv1.GatewayClass) {
+		gc.Spec.ParametersRef = &gwapiv1.ParametersReference{Kind: "ConfigMap", Name: "my-params", Namespace: ptr.To(gwapiv1.Namespace("my-namespace"))}
+	})
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-params", Namespace: "my-namespace"},
+	}
+	c := NewController(WithGatewayClassParameters())
+	topology := c.topology.Build(Store{"gatewayclass": gatewayClass, "configmap": configMap})
+	gatewayClasses := topology.Targetables().Roots()
+	if len(gatewayClasses) != 1 {
+		t.Fatalf("expected 1 gateway class, got %d", len(gatewayClasses))
+	}
+	params, found := machinery.GatewayClassParameters(topology, gatewayClasses[0].(*machinery.GatewayClass))
+	if !found || params.GetName() != "my-params" {
+		t.Errorf("expected the configmap linked from the gateway class, got %v", params)
+	}
+}
+
+func TestTopologyBuilderWithSecrets(t *testing.T) {
+	gateway := machinery.BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Listeners[0].TLS = &gwapiv1.GatewayTLSConfig{
+			CertificateRefs: []gwapiv1.SecretObjectReference{{Name: "my-secret"}},
+		}
+	})
+	secret := machinery.BuildSecret()
+	// secrets watched as object kinds are not added twice
+	c := NewController(WithObjectKinds(SecretKind))
+	topology := c.topology.Build(Store{"gateway": gateway, "secret": secret})
+	secrets := topology.Objects().Items()
+	if len(secrets) != 1 {
+		t.Fatalf("expected 1 secret, got %d", len(secrets))
+	}
+	parents := topology.Targetables().Parents(secrets[0])
+	if len(parents) != 1 || parents[0].GetName() != "my-gateway#my-listener" {
+		t.Errorf("expected the secret linked from the listener, got %v", parents)
+	}
+}
+
+type configMapObject struct {
+	*corev1.ConfigMap
+}
+
+func (c *configMapObject) GetURL() string {
+	return machinery.UrlFromObject(c)
+}
+
+func TestTopologyBuilderWithObjectWrappers(t *testing.T) {
+	configMap := &corev1.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-config", Namespace: "my-namespace"},
+	}
+	wrapper := func(obj Object) (machinery.Object, bool) {
+		configMap, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			return nil, false
+		}
+		return &configMapObject{ConfigMap: configMap}, true
+	}
+	store := Store{"config": configMap}
+
+	objects := NewController(WithObjectKinds(configMapKind)).topology.Build(store).Objects().Items()
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+	if _, ok := objects[0].(*RuntimeObject); !ok {
+		t.Errorf("expected a RuntimeObject, got %T", objects[0])
+	}
+
+	objects = NewController(WithObjectKinds(configMapKind), WithObjectWrappers(wrapper)).topology.Build(store).Objects().Items()
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+	if _, ok := objects[0].(*configMapObject); !ok {
+		t.Errorf("expected a wrapped ConfigMap, got %T", objects[0])
+	}
+}
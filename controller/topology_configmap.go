@@ -0,0 +1,178 @@
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// TopologyFormat is the format of the topology persisted by the TopologyConfigMapReconciler.
+type TopologyFormat string
+
+const (
+	// TopologyFormatDot persists the topology as a Graphviz DOT graph (see machinery.Topology.ToDot).
+	TopologyFormatDot TopologyFormat = "dot"
+	// TopologyFormatJSON persists the snapshot of the topology as JSON (see machinery.Topology.Snapshot).
+	TopologyFormatJSON TopologyFormat = "json"
+)
+
+const (
+	// DefaultTopologyConfigMapMaxSize is the default maximum size of the topology persisted in a ConfigMap, which
+	// leaves room for the metadata of the ConfigMap within the 1 MiB limit of the objects stored in etcd.
+	DefaultTopologyConfigMapMaxSize = 900 * 1024
+
+	// TopologyCompressedAnnotation is the annotation set on the topology ConfigMap when the topology is stored
+	// gzip-compressed in the binary data of the ConfigMap because it exceeds the maximum size.
+	TopologyCompressedAnnotation = "policy-machinery.kuadrant.io/topology-compressed"
+
+	// TopologyTruncatedAnnotation is the annotation set on the topology ConfigMap when the topology exceeds the
+	// maximum size even compressed, and only its first bytes are stored.
+	TopologyTruncatedAnnotation = "policy-machinery.kuadrant.io/topology-truncated"
+)
+
+// TopologyConfigMapReconciler persists the topology to a ConfigMap after each reconciliation, so users can inspect the
+// live topology with kubectl, e.g.:
+//
+//	kubectl get configmap topology -o jsonpath='{.data.topology\.dot}' | dot -Tsvg > topology.svg
+//
+// The topology is stored in the data key "topology.<format>". Topologies larger than the maximum size are stored
+// gzip-compressed in the binary data key "topology.<format>.gz" instead, and topologies larger than the maximum
+// size even compressed are truncated. The ConfigMap is only applied when the topology changes.
+// To avoid reconciling the ConfigMap over and over, exclude it from the ConfigMaps watched by the controller.
+type TopologyConfigMapReconciler struct {
+	Client    dynamic.Interface
+	Namespace string
+	Name      string
+	// Format of the topology. Defaults to TopologyFormatDot.
+	Format TopologyFormat
+	// MaxSize is the maximum size in bytes of the topology stored. Defaults to DefaultTopologyConfigMapMaxSize.
+	MaxSize int
+	// FieldManager is the field manager of the ConfigMap. Defaults to DefaultFieldManager.
+	FieldManager string
+
+	mu   sync.Mutex
+	hash string
+}
+
+func (r *TopologyConfigMapReconciler) Reconcile(ctx context.Context, _ []ResourceEvent, topology *machinery.Topology) {
+	logger := LoggerFromContext(ctx).WithName("topology configmap")
+
+	configMap, err := r.configMap(topology)
+	if err != nil {
+		logger.Error(err, "failed to serialize the topology")
+		return
+	}
+
+	hash := r.contentHash(configMap)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if hash == r.hash {
+		return
+	}
+
+	fieldManager := r.FieldManager
+	if fieldManager == "" {
+		fieldManager = DefaultFieldManager
+	}
+	if _, err := ApplyObject(ctx, r.Client.Resource(ConfigMapsResource).Namespace(r.Namespace), configMap, WithFieldManager(fieldManager), WithForceOwnership()); err != nil {
+		logger.Error(err, "failed to persist the topology")
+		return
+	}
+	r.hash = hash
+	logger.V(1).Info("topology persisted", "configmap", namespacedName(configMap), "compressed", configMap.Annotations[TopologyCompressedAnnotation], "truncated", configMap.Annotations[TopologyTruncatedAnnotation])
+}
+
+// configMap returns the ConfigMap that holds the topology, compressed or truncated to fit the maximum size.
+func (r *TopologyConfigMapReconciler) configMap(topology *machinery.Topology) (*corev1.ConfigMap, error) {
+	format := r.Format
+	if format == "" {
+		format = TopologyFormatDot
+	}
+	maxSize := r.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultTopologyConfigMapMaxSize
+	}
+
+	var content string
+	switch format {
+	case TopologyFormatDot:
+		content = topology.ToDot()
+	case TopologyFormatJSON:
+		b, err := topology.MarshalJSON()
+		if err != nil {
+			return nil, err
+		}
+		content = string(b)
+	default:
+		return nil, fmt.Errorf("unknown topology format %q", format)
+	}
+
+	key := "topology." + string(format)
+	configMap := &corev1.ConfigMap{
+		TypeMeta: metav1.TypeMeta{APIVersion: corev1.SchemeGroupVersion.String(), Kind: ConfigMapKind.Kind},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.Name,
+			Namespace: r.Namespace,
+			Annotations: map[string]string{
+				TopologyCompressedAnnotation: "false",
+				TopologyTruncatedAnnotation:  "false",
+			},
+		},
+	}
+
+	if len(content) <= maxSize {
+		configMap.Data = map[string]string{key: content}
+		return configMap, nil
+	}
+
+	compressed, err := gzipContent(content)
+	if err != nil {
+		return nil, err
+	}
+	if len(compressed) <= maxSize {
+		configMap.BinaryData = map[string][]byte{key + ".gz": compressed}
+		configMap.Annotations[TopologyCompressedAnnotation] = "true"
+		return configMap, nil
+	}
+
+	truncated, _ := truncate(content, maxSize, "\n...\n")
+	configMap.Data = map[string]string{key: truncated}
+	configMap.Annotations[TopologyTruncatedAnnotation] = "true"
+	return configMap, nil
+}
+
+// contentHash returns a hash of the content of a topology ConfigMap
+func (r *TopologyConfigMapReconciler) contentHash(configMap *corev1.ConfigMap) string {
+	h := sha256.New()
+	for key, value := range configMap.Data {
+		h.Write([]byte(key))
+		h.Write([]byte(value))
+	}
+	for key, value := range configMap.BinaryData {
+		h.Write([]byte(key))
+		h.Write(value)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+func gzipContent(content string) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write([]byte(content)); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,144 @@
+//go:build unit
+
+package controller
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// applyRecorderClient is a dynamic client whose resource clients emulate server-side apply (see applyRecorder)
+type applyRecorderClient struct {
+	dynamic.Interface
+	recorders []*applyRecorder
+}
+
+func (c *applyRecorderClient) Resource(resource schema.GroupVersionResource) dynamic.NamespaceableResourceInterface {
+	return &applyRecorderResource{NamespaceableResourceInterface: c.Interface.Resource(resource), client: c}
+}
+
+type applyRecorderResource struct {
+	dynamic.NamespaceableResourceInterface
+	client *applyRecorderClient
+}
+
+func (r *applyRecorderResource) Namespace(namespace string) dynamic.ResourceInterface {
+	recorder := &applyRecorder{ResourceInterface: r.NamespaceableResourceInterface.Namespace(namespace)}
+	r.client.recorders = append(r.client.recorders, recorder)
+	return recorder
+}
+
+func (c *applyRecorderClient) applies() int {
+	var n int
+	for _, r := range c.recorders {
+		n += len(r.options)
+	}
+	return n
+}
+
+func TestTopologyConfigMapReconciler(t *testing.T) {
+	ctx := context.TODO()
+	topology := machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(machinery.BuildGatewayClass()),
+		machinery.WithGateways(machinery.BuildGateway()),
+		machinery.WithHTTPRoutes(machinery.BuildHTTPRoute()),
+	)
+	dot := topology.ToDot()
+
+	testCases := []struct {
+		name              string
+		format            TopologyFormat
+		maxSize           int
+		expectedKey       string
+		expectedData      string
+		expectCompressed  bool
+		expectedTruncated string
+	}{
+		{
+			name:         "dot",
+			expectedKey:  "topology.dot",
+			expectedData: dot,
+		},
+		{
+			name:        "json",
+			format:      TopologyFormatJSON,
+			expectedKey: "topology.json",
+		},
+		{
+			name:             "compressed",
+			maxSize:          len(dot) - 1,
+			expectedKey:      "topology.dot.gz",
+			expectedData:     dot,
+			expectCompressed: true,
+		},
+		{
+			name:              "truncated",
+			maxSize:           32,
+			expectedKey:       "topology.dot",
+			expectedTruncated: "true",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &applyRecorderClient{Interface: dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[schema.GroupVersionResource]string{ConfigMapsResource: "ConfigMapList"})}
+			reconciler := &TopologyConfigMapReconciler{Client: client, Namespace: "my-namespace", Name: "topology", Format: tc.format, MaxSize: tc.maxSize}
+
+			reconciler.Reconcile(ctx, nil, topology)
+			reconciler.Reconcile(ctx, nil, topology)
+			if applies := client.applies(); applies != 1 {
+				t.Errorf("expected the configmap to be applied once, got %d", applies)
+			}
+
+			obj, err := client.Interface.Resource(ConfigMapsResource).Namespace("my-namespace").Get(ctx, "topology", metav1.GetOptions{})
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			o, err := Restructure[corev1.ConfigMap](obj)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			configMap := o.(corev1.ConfigMap)
+
+			var data string
+			if tc.expectCompressed {
+				r, err := gzip.NewReader(bytes.NewReader(configMap.BinaryData[tc.expectedKey]))
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				b, _ := io.ReadAll(r)
+				data = string(b)
+				if configMap.Annotations[TopologyCompressedAnnotation] != "true" {
+					t.Errorf("expected the configmap to be annotated as compressed")
+				}
+			} else {
+				var ok bool
+				if data, ok = configMap.Data[tc.expectedKey]; !ok {
+					t.Fatalf("expected key %s in the configmap, got %v", tc.expectedKey, configMap.Data)
+				}
+			}
+			if tc.expectedData != "" && data != tc.expectedData {
+				t.Errorf("expected %q, got %q", tc.expectedData, data)
+			}
+			if tc.expectedTruncated != "" {
+				if configMap.Annotations[TopologyTruncatedAnnotation] != tc.expectedTruncated {
+					t.Errorf("expected the configmap to be annotated as truncated")
+				}
+				if len(data) > tc.maxSize {
+					t.Errorf("expected at most %d bytes, got %d", tc.maxSize, len(data))
+				}
+			}
+		})
+	}
+}
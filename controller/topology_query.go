@@ -0,0 +1,65 @@
+package controller
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// TopologyQueryResponse is the response of the topology query handler of the controller.
+type TopologyQueryResponse struct {
+	// Columns are the variables returned by the query.
+	Columns []string `json:"columns"`
+	// Rows are the URLs of the nodes bound to the variables returned, for each match of the query.
+	Rows [][]string `json:"rows"`
+	// Error is the error of the query, if any.
+	Error string `json:"error,omitempty"`
+}
+
+// QueryHandler returns an HTTP handler that runs read-only queries of the current topology of the controller (see
+// machinery.Query) and serves the results as JSON, e.g. to be mounted in the debug endpoint of the binary.
+// The queries run on the topology of the latest reconciliation (see Controller.Topology), or on a topology built from
+// the cache before the first reconciliation.
+// The query is read from the "q" parameter of GET requests, or from the body of POST requests.
+func (c *Controller) QueryHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+
+		query := r.URL.Query().Get("q")
+		if r.Method == http.MethodPost {
+			body, err := io.ReadAll(r.Body)
+			if err != nil {
+				writeTopologyQueryResponse(w, http.StatusBadRequest, TopologyQueryResponse{Error: err.Error()})
+				return
+			}
+			query = string(body)
+		}
+
+		q, err := machinery.ParseQuery(query)
+		if err != nil {
+			writeTopologyQueryResponse(w, http.StatusBadRequest, TopologyQueryResponse{Error: err.Error()})
+			return
+		}
+		topology := c.Topology()
+		if topology == nil {
+			c.Lock()
+			topology = c.topology.Build(c.cache.List())
+			c.Unlock()
+		}
+		result := q.Run(topology)
+		rows := result.URLs()
+		if rows == nil {
+			rows = [][]string{}
+		}
+		writeTopologyQueryResponse(w, http.StatusOK, TopologyQueryResponse{Columns: result.Columns, Rows: rows})
+	})
+}
+
+func writeTopologyQueryResponse(w http.ResponseWriter, status int, response TopologyQueryResponse) {
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(response); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
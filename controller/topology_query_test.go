@@ -0,0 +1,111 @@
+//go:build unit
+
+package controller
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestQueryHandler(t *testing.T) {
+	c := NewController()
+	c.cache.Replace(Store{
+		"uid-gateway-class": &gwapiv1.GatewayClass{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gwapiv1.GroupVersion.String(), Kind: "GatewayClass"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-gateway-class", UID: "uid-gateway-class"},
+		},
+		"uid-gateway": &gwapiv1.Gateway{
+			TypeMeta:   metav1.TypeMeta{APIVersion: gwapiv1.GroupVersion.String(), Kind: "Gateway"},
+			ObjectMeta: metav1.ObjectMeta{Name: "my-gateway", Namespace: "my-namespace", UID: "uid-gateway"},
+			Spec:       gwapiv1.GatewaySpec{GatewayClassName: "my-gateway-class"},
+		},
+	})
+
+	testCases := []struct {
+		name           string
+		request        *http.Request
+		expectedStatus int
+		expected       TopologyQueryResponse
+	}{
+		{
+			name:           "get",
+			request:        httptest.NewRequest("GET", "/query?q="+url.QueryEscape(`MATCH (c:GatewayClass)-->(g:Gateway) RETURN c, g`), nil),
+			expectedStatus: http.StatusOK,
+			expected: TopologyQueryResponse{
+				Columns: []string{"c", "g"},
+				Rows:    [][]string{{"gatewayclass.gateway.networking.k8s.io:my-gateway-class", "gateway.gateway.networking.k8s.io:my-namespace/my-gateway"}},
+			},
+		},
+		{
+			name:           "post",
+			request:        httptest.NewRequest("POST", "/query", strings.NewReader(`MATCH (r:HTTPRoute) RETURN r`)),
+			expectedStatus: http.StatusOK,
+			expected:       TopologyQueryResponse{Columns: []string{"r"}, Rows: [][]string{}},
+		},
+		{
+			name:           "invalid query",
+			request:        httptest.NewRequest("GET", "/query?q=RETURN", nil),
+			expectedStatus: http.StatusBadRequest,
+			expected:       TopologyQueryResponse{Error: `invalid query: expected MATCH at position 0, got "RETURN"`},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			recorder := httptest.NewRecorder()
+			c.QueryHandler().ServeHTTP(recorder, tc.request)
+			if recorder.Code != tc.expectedStatus {
+				t.Errorf("expected status %d, got %d", tc.expectedStatus, recorder.Code)
+			}
+			var response TopologyQueryResponse
+			if err := json.NewDecoder(recorder.Body).Decode(&response); err != nil {
+				t.Fatal(err)
+			}
+			expected, _ := json.Marshal(tc.expected)
+			got, _ := json.Marshal(response)
+			if string(expected) != string(got) {
+				t.Errorf("expected response %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestControllerTopology(t *testing.T) {
+	c := NewController()
+	if topology := c.Topology(); topology != nil {
+		t.Fatalf("expected no topology before the first reconciliation, got %v", topology)
+	}
+
+	c.cache.Add(&gwapiv1.GatewayClass{
+		TypeMeta:   metav1.TypeMeta{APIVersion: gwapiv1.GroupVersion.String(), Kind: "GatewayClass"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-gateway-class", UID: "uid-gateway-class"},
+	})
+	c.Lock()
+	if err := c.run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Unlock()
+
+	topology := c.Topology()
+	if topology == nil {
+		t.Fatal("expected the topology of the reconciliation")
+	}
+	if roots := topology.Targetables().Roots(); len(roots) != 1 || roots[0].GetName() != "my-gateway-class" {
+		t.Errorf("expected the gateway class as root of the topology, got %v", roots)
+	}
+
+	c.Lock()
+	if err := c.run(nil); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	c.Unlock()
+	if c.Topology() == topology {
+		t.Error("expected a new topology for every reconciliation")
+	}
+}
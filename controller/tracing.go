@@ -0,0 +1,125 @@
+package controller
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+	"k8s.io/client-go/rest"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// TracerName is the name of the tracer of the controller, as the instrumentation scope of the spans.
+const TracerName = "github.com/kuadrant/policy-machinery/controller"
+
+// WithTracerProvider enables tracing of the reconciliation cycles with an OpenTelemetry TracerProvider. Each cycle
+// creates a span, with child spans per task of a Workflow and per Subscription, named after their reconciliation
+// functions. The context of the spans is propagated to the reconciliation functions, and the transport of the rest
+// config (see WithRestConfig) is instrumented with otelhttp, so the requests of the clients created from it are traced
+// as part of the cycle.
+func WithTracerProvider(provider trace.TracerProvider) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.tracerProvider = provider
+	}
+}
+
+// tracedRestConfig returns a copy of a rest config whose transport creates a span per request, with the tracers of
+// the provider.
+func tracedRestConfig(config *rest.Config, provider trace.TracerProvider) *rest.Config {
+	config = rest.CopyConfig(config)
+	config.Wrap(func(rt http.RoundTripper) http.RoundTripper {
+		return otelhttp.NewTransport(rt, otelhttp.WithTracerProvider(provider))
+	})
+	return config
+}
+
+type tracerKey struct{}
+
+// TracerFromContext returns the tracer from the context, or nil if no tracer is found.
+func TracerFromContext(ctx context.Context) trace.Tracer {
+	tracer, ok := ctx.Value(tracerKey{}).(trace.Tracer)
+	if !ok {
+		return nil
+	}
+	return tracer
+}
+
+// TracerIntoContext returns a new context with the tracer set.
+func TracerIntoContext(ctx context.Context, tracer trace.Tracer) context.Context {
+	return context.WithValue(ctx, tracerKey{}, tracer)
+}
+
+// StartSpan starts a span with the tracer from the context. If no tracer is found, the span is a no-op.
+func StartSpan(ctx context.Context, spanName string) (context.Context, trace.Span) {
+	tracer := TracerFromContext(ctx)
+	if tracer == nil {
+		return ctx, noop.Span{}
+	}
+	return tracer.Start(ctx, spanName)
+}
+
+// recordSpanError records an error in a span, and sets the status of the span to error.
+func recordSpanError(span trace.Span, err error) {
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
+
+// tracedReconcileFunc wraps a reconciliation function in a span named after the function, and passes it a logger
+// named after the function too (see reconcilerLoggerIntoContext).
+func tracedReconcileFunc(f ReconcileFunc) ReconcileFunc {
+	return func(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) {
+		name := reconcileFuncName(f)
+		ctx = reconcilerLoggerIntoContext(ctx, name)
+		tracer := TracerFromContext(ctx)
+		if tracer == nil {
+			f(ctx, resourceEvents, topology)
+			return
+		}
+		ctx, span := tracer.Start(ctx, name)
+		defer span.End()
+		f(ctx, resourceEvents, topology)
+	}
+}
+
+// tracedErrorReconcileFunc wraps a reconciliation function that can fail in a span named after the function, and
+// passes it a logger named after the function too (see reconcilerLoggerIntoContext).
+func tracedErrorReconcileFunc(f ErrorReconcileFunc) ErrorReconcileFunc {
+	return tracedNamedErrorReconcileFunc(reconcileFuncName(f), f)
+}
+
+// tracedNamedErrorReconcileFunc wraps a reconciliation function that can fail in a span with a given name, and passes
+// it a logger with the same name, e.g. the name of a task of a DAGWorkflow.
+func tracedNamedErrorReconcileFunc(name string, f ErrorReconcileFunc) ErrorReconcileFunc {
+	return func(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) error {
+		ctx = reconcilerLoggerIntoContext(ctx, name)
+		tracer := TracerFromContext(ctx)
+		if tracer == nil {
+			return f(ctx, resourceEvents, topology)
+		}
+		ctx, span := tracer.Start(ctx, name)
+		defer span.End()
+		err := f(ctx, resourceEvents, topology)
+		if IsFailure(err) {
+			recordSpanError(span, err)
+		}
+		return err
+	}
+}
+
+// reconcileFuncName returns the name of a function, without the path of its package, e.g.
+// "reconcilers.(*GatewayReconciler).Reconcile-fm".
+func reconcileFuncName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "reconcile"
+	}
+	name := fn.Name()
+	return name[strings.LastIndex(name, "/")+1:]
+}
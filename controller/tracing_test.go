@@ -0,0 +1,135 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/rest"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func endedSpan(recorder *tracetest.SpanRecorder, name string) sdktrace.ReadOnlySpan {
+	for _, span := range recorder.Ended() {
+		if strings.HasSuffix(span.Name(), name) {
+			return span
+		}
+	}
+	return nil
+}
+
+func tracedTask(context.Context, []ResourceEvent, *machinery.Topology) {}
+
+func failingTask(context.Context, []ResourceEvent, *machinery.Topology) error {
+	return errors.New("boom")
+}
+
+func TestControllerTracing(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	workflow := &Workflow{
+		Tasks: []ReconcileFunc{
+			tracedTask,
+			IgnoreErrors((&ErrorWorkflow{Tasks: []ErrorReconcileFunc{failingTask}}).Run),
+		},
+	}
+	c := NewController(
+		WithName("test"),
+		WithTracerProvider(provider),
+		WithReconcile(func(ctx context.Context, events []ResourceEvent, topology *machinery.Topology) {
+			workflow.Run(ctx, events, topology)
+		}),
+	)
+
+	configMap := &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", UID: "my-configmap"}}
+	configMap.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("ConfigMap"))
+	c.add(configMap)
+
+	root := endedSpan(recorder, "reconcile")
+	if root == nil || root.Parent().IsValid() {
+		t.Fatalf("expected root reconcile span, got %v", recorder.Ended())
+	}
+	if root.InstrumentationScope().Name != TracerName {
+		t.Errorf("expected the spans of tracer %s, got %s", TracerName, root.InstrumentationScope().Name)
+	}
+	attributes := attribute.NewSet(root.Attributes()...)
+	if v, _ := attributes.Value("controller"); v.AsString() != "test" {
+		t.Errorf("unexpected attributes %v", root.Attributes())
+	}
+	if v, _ := attributes.Value("events"); v.AsInt64() != 1 {
+		t.Errorf("unexpected attributes %v", root.Attributes())
+	}
+	task := endedSpan(recorder, "controller.tracedTask")
+	if task == nil || task.Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("expected task span child of the reconcile span, got %v", task)
+	}
+	failing := endedSpan(recorder, "controller.failingTask")
+	if failing == nil || failing.Parent().SpanID() == root.SpanContext().SpanID() || failing.SpanContext().TraceID() != root.SpanContext().TraceID() {
+		t.Fatalf("expected nested failing task span in the trace of the reconcile span, got %v", failing)
+	}
+	if failing.Status().Code != codes.Error || len(failing.Events()) != 1 {
+		t.Errorf("expected failing task span with error, got %v %v", failing.Status(), failing.Events())
+	}
+}
+
+func TestControllerTracingRestConfig(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	config := &rest.Config{Host: server.URL}
+	c := NewController(WithRestConfig(config), WithTracerProvider(provider))
+	if config.WrapTransport != nil {
+		t.Error("expected the rest config given to the controller unmodified")
+	}
+
+	transport, err := rest.TransportFor(c.restConfig)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	ctx, span := StartSpan(TracerIntoContext(context.TODO(), c.tracer), "reconcile")
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/version", nil)
+	resp, err := transport.RoundTrip(req)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	resp.Body.Close()
+	span.End()
+
+	root := endedSpan(recorder, "reconcile")
+	if root == nil {
+		t.Fatalf("expected root reconcile span, got %v", recorder.Ended())
+	}
+	requests := lo.Filter(recorder.Ended(), func(s sdktrace.ReadOnlySpan, _ int) bool {
+		return s.SpanKind() == trace.SpanKindClient
+	})
+	if len(requests) != 1 || requests[0].Parent().SpanID() != root.SpanContext().SpanID() {
+		t.Errorf("expected a client span of the request child of the reconcile span, got %v", requests)
+	}
+}
+
+func TestStartSpanWithoutTracer(t *testing.T) {
+	ctx, span := StartSpan(context.TODO(), "test")
+	span.SetAttributes(attribute.String("key", "value"))
+	span.End()
+	if TracerFromContext(ctx) != nil {
+		t.Error("expected no tracer")
+	}
+}
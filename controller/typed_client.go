@@ -0,0 +1,142 @@
+package controller
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+
+	ctrlruntimeclient "sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+)
+
+// WithScheme sets the scheme of the typed objects watched with TypedInformer and handled by the typed client.
+// If no typed client is provided with WithTypedClient, a default one is created from the rest config set with
+// WithRestConfig.
+func WithScheme(scheme *runtime.Scheme) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.scheme = scheme
+	}
+}
+
+// WithTypedClient sets the typed client used to watch the resources with TypedInformer, and set in the context of the
+// reconciliation functions (see TypedClientFromContext), so typed objects are read and written without the
+// round-trip through unstructured objects of the dynamic client.
+func WithTypedClient(client ctrlruntimeclient.WithWatch) ControllerOption {
+	return func(o *ControllerOptions) {
+		o.typedClient = client
+	}
+}
+
+// TypedClient returns the typed client of the controller, or nil if the controller has none.
+func (c *Controller) TypedClient() ctrlruntimeclient.WithWatch {
+	return c.typedClient
+}
+
+type typedClientKey struct{}
+
+// TypedClientFromContext returns the typed client from the context, or nil if no client is found.
+func TypedClientFromContext(ctx context.Context) ctrlruntimeclient.Client {
+	client, ok := ctx.Value(typedClientKey{}).(ctrlruntimeclient.Client)
+	if !ok {
+		return nil
+	}
+	return client
+}
+
+// TypedClientIntoContext returns a new context with the typed client set.
+func TypedClientIntoContext(ctx context.Context, client ctrlruntimeclient.Client) context.Context {
+	return context.WithValue(ctx, typedClientKey{}, client)
+}
+
+// TypedInformer is a runnable builder that watches the resources with the typed client of the controller (see
+// WithTypedClient and WithScheme), instead of the dynamic client, so the objects are decoded straight into T.
+// The type T must be registered in the scheme of the typed client. If the controller has no typed client, it falls
+// back to an IncrementalInformer of the given resource.
+func TypedInformer[T Object](obj T, resource schema.GroupVersionResource, namespace string, options ...RunnableBuilderOption[T]) RunnableBuilder {
+	o := &RunnableBuilderOptions[T]{}
+	for _, f := range options {
+		f(o)
+	}
+	return func(controller *Controller) Runnable {
+		controller.watching(resource)
+		client := controller.typedClient
+		if client == nil {
+			controller.logger.Error(fmt.Errorf("no typed client"), "falling back to the dynamic client", "resource", resource.String())
+			return IncrementalInformer(obj, resource, namespace, options...)(controller)
+		}
+		gvk, list, err := typedList(client.Scheme(), obj)
+		if err != nil {
+			controller.logger.Error(err, "falling back to the dynamic client", "resource", resource.String())
+			return IncrementalInformer(obj, resource, namespace, options...)(controller)
+		}
+		listOptions := func(options metav1.ListOptions) *ctrlruntimeclient.ListOptions {
+			if o.LabelSelector != "" {
+				options.LabelSelector = o.LabelSelector
+			}
+			if o.FieldSelector != "" {
+				options.FieldSelector = o.FieldSelector
+			}
+			return &ctrlruntimeclient.ListOptions{Namespace: namespace, Raw: &options}
+		}
+		informer := cache.NewSharedInformer(
+			&cache.ListWatch{
+				ListFunc: func(options metav1.ListOptions) (runtime.Object, error) {
+					objs := list.DeepCopyObject().(ctrlruntimeclient.ObjectList)
+					err := client.List(context.Background(), objs, listOptions(options))
+					return objs, err
+				},
+				WatchFunc: func(options metav1.ListOptions) (watch.Interface, error) {
+					return client.Watch(context.Background(), list.DeepCopyObject().(ctrlruntimeclient.ObjectList), listOptions(options))
+				},
+			},
+			obj,
+			time.Minute*10,
+		)
+		informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+			AddFunc: func(o any) {
+				controller.add(o.(T))
+			},
+			UpdateFunc: func(o, newO any) {
+				controller.update(o.(T), newO.(T))
+			},
+			DeleteFunc: func(o any) {
+				obj, ok := o.(T)
+				if !ok {
+					return // tombstone of an object deleted while the watch was down; the next list reconciles it
+				}
+				controller.delete(obj)
+			},
+		})
+		// typed objects are decoded without their kind, which the controller relies on to tell the kind of the events
+		informer.SetTransform(func(o any) (any, error) {
+			if obj, ok := o.(T); ok {
+				obj.GetObjectKind().SetGroupVersionKind(gvk)
+			}
+			return o, nil
+		})
+		return informer
+	}
+}
+
+// typedList returns the kind of a typed object and an empty list of objects of the kind, as registered in a scheme.
+func typedList(scheme *runtime.Scheme, obj runtime.Object) (schema.GroupVersionKind, ctrlruntimeclient.ObjectList, error) {
+	gvk, err := apiutil.GVKForObject(obj, scheme)
+	if err != nil {
+		return gvk, nil, err
+	}
+	list, err := scheme.New(gvk.GroupVersion().WithKind(gvk.Kind + "List"))
+	if err != nil {
+		return gvk, nil, err
+	}
+	objectList, ok := list.(ctrlruntimeclient.ObjectList)
+	if !ok {
+		return gvk, nil, fmt.Errorf("%w: unexpected list type: %T", ErrConversion, list)
+	}
+	return gvk, objectList, nil
+}
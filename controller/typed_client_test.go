@@ -0,0 +1,91 @@
+//go:build unit
+
+package controller
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	ctrlruntimefake "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+func TestTypedInformer(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	client := ctrlruntimefake.NewClientBuilder().WithScheme(scheme).WithObjects(
+		&corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "my-configmap", Namespace: "my-namespace", UID: "my-configmap"}},
+	).Build()
+
+	var mu sync.Mutex
+	var events []ResourceEvent
+	var typedClientInContext bool
+	c := NewController(
+		WithTypedClient(client),
+		WithReconcile(func(ctx context.Context, resourceEvents []ResourceEvent, _ *machinery.Topology) {
+			mu.Lock()
+			defer mu.Unlock()
+			events = append(events, resourceEvents...)
+			typedClientInContext = TypedClientFromContext(ctx) != nil
+		}),
+	)
+	if c.TypedClient() == nil {
+		t.Fatal("expected typed client")
+	}
+
+	runnable := TypedInformer(&corev1.ConfigMap{}, ConfigMapsResource, "my-namespace")(c)
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	go runnable.Run(stopCh)
+	if !cache.WaitForCacheSync(stopCh, runnable.HasSynced) {
+		t.Fatal("expected informer synced")
+	}
+
+	if err := client.Create(context.TODO(), &corev1.ConfigMap{ObjectMeta: metav1.ObjectMeta{Name: "other-configmap", Namespace: "my-namespace", UID: "other-configmap"}}); err != nil {
+		t.Fatal(err)
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		mu.Lock()
+		n := len(events)
+		mu.Unlock()
+		if n >= 2 || time.Now().After(deadline) {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(events) != 2 {
+		t.Fatalf("expected 2 events, got %d", len(events))
+	}
+	for _, event := range events {
+		if event.Kind != ConfigMapKind || event.EventType != CreateEvent {
+			t.Errorf("expected create event of a configmap, got %v %v", event.Kind, event.EventType.String())
+		}
+		if _, ok := event.NewObject.(*corev1.ConfigMap); !ok {
+			t.Errorf("expected typed object, got %T", event.NewObject)
+		}
+	}
+	if !typedClientInContext {
+		t.Error("expected typed client in the context of the reconcile function")
+	}
+}
+
+func TestTypedInformerFallsBackToDynamicClient(t *testing.T) {
+	c := NewController(WithClient(testClient))
+	if _, ok := TypedInformer(&corev1.ConfigMap{}, ConfigMapsResource, "my-namespace")(c).(cache.SharedInformer); !ok {
+		t.Error("expected an informer of the dynamic client")
+	}
+}
@@ -0,0 +1,86 @@
+package controller
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/rest"
+
+	"github.com/kuadrant/policy-machinery/controller/metrics"
+)
+
+// APIWarnings records the warnings returned by the API server for each watched resource, such as the ones
+// announcing that a resource version is deprecated.
+// If the controller has metrics (see WithMetrics), the warnings are counted in the policy_machinery_api_warnings_total
+// metric.
+type APIWarnings struct {
+	mu       sync.RWMutex
+	warnings map[schema.GroupVersionResource][]string
+	metrics  *metrics.Metrics
+}
+
+// HandlerFor returns a rest.WarningHandler that records the warnings for a given resource.
+func (w *APIWarnings) HandlerFor(resource schema.GroupVersionResource) rest.WarningHandler {
+	return warningHandlerFunc(func(code int, _ string, message string) {
+		if code != 299 || len(message) == 0 {
+			return
+		}
+		w.metrics.ObserveAPIWarning(resource)
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if w.warnings == nil {
+			w.warnings = make(map[schema.GroupVersionResource][]string)
+		}
+		if !lo.Contains(w.warnings[resource], message) {
+			w.warnings[resource] = append(w.warnings[resource], message)
+		}
+	})
+}
+
+// List returns a copy of the recorded warnings by resource.
+func (w *APIWarnings) List() map[schema.GroupVersionResource][]string {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	warnings := make(map[schema.GroupVersionResource][]string, len(w.warnings))
+	for resource, messages := range w.warnings {
+		warnings[resource] = append([]string{}, messages...)
+	}
+	return warnings
+}
+
+type warningHandlerFunc func(code int, agent string, message string)
+
+func (f warningHandlerFunc) HandleWarningHeader(code int, agent string, message string) {
+	f(code, agent, message)
+}
+
+// PreferredVersionFor returns the resource in the version preferred by the API server for the group of the resource.
+// If the preferred version does not serve the resource, the resource is returned unchanged.
+func PreferredVersionFor(client discovery.DiscoveryInterface, resource schema.GroupVersionResource) (schema.GroupVersionResource, error) {
+	groups, err := client.ServerGroups()
+	if err != nil {
+		return resource, err
+	}
+	group, found := lo.Find(groups.Groups, func(g metav1.APIGroup) bool {
+		return g.Name == resource.Group
+	})
+	if !found {
+		return resource, fmt.Errorf("api group %q not found", resource.Group)
+	}
+	preferred := resource.GroupResource().WithVersion(group.PreferredVersion.Version)
+	if preferred == resource {
+		return resource, nil
+	}
+	resources, err := client.ServerResourcesForGroupVersion(preferred.GroupVersion().String())
+	if err != nil {
+		return resource, err
+	}
+	if !lo.ContainsBy(resources.APIResources, func(r metav1.APIResource) bool { return r.Name == resource.Resource }) {
+		return resource, nil
+	}
+	return preferred, nil
+}
@@ -0,0 +1,28 @@
+//go:build unit
+
+package controller
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAPIWarnings(t *testing.T) {
+	warnings := &APIWarnings{}
+	services := warnings.HandlerFor(ServicesResource)
+	configMaps := warnings.HandlerFor(ConfigMapsResource)
+
+	services.HandleWarningHeader(299, "", "v1 Service is deprecated")
+	services.HandleWarningHeader(299, "", "v1 Service is deprecated")
+	services.HandleWarningHeader(200, "", "not a warning")
+	configMaps.HandleWarningHeader(299, "", "")
+
+	list := warnings.List()
+	if len(list) != 1 {
+		t.Fatalf("expected warnings for 1 resource, got %d", len(list))
+	}
+	if messages := list[corev1.SchemeGroupVersion.WithResource("services")]; len(messages) != 1 || messages[0] != "v1 Service is deprecated" {
+		t.Errorf("expected 1 deduplicated warning for services, got %v", messages)
+	}
+}
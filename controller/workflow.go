@@ -18,7 +18,7 @@ type Workflow struct {
 func (d *Workflow) Run(ctx context.Context, resourceEvents []ResourceEvent, topology *machinery.Topology) {
 	// run precondition reconcile function
 	if d.Precondition != nil {
-		d.Precondition(ctx, resourceEvents, topology)
+		tracedReconcileFunc(d.Precondition)(ctx, resourceEvents, topology)
 	}
 
 	// dispatch the event to concurrent tasks
@@ -28,13 +28,13 @@ func (d *Workflow) Run(ctx context.Context, resourceEvents []ResourceEvent, topo
 	for _, f := range funcs {
 		go func() {
 			defer waitGroup.Done()
-			f(ctx, resourceEvents, topology)
+			tracedReconcileFunc(f)(ctx, resourceEvents, topology)
 		}()
 	}
 	waitGroup.Wait()
 
 	// run precondition reconcile function
 	if d.Postcondition != nil {
-		d.Postcondition(ctx, resourceEvents, topology)
+		tracedReconcileFunc(d.Postcondition)(ctx, resourceEvents, topology)
 	}
 }
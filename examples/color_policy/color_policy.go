@@ -21,12 +21,7 @@ func (p *ColorPolicy) GetURL() string {
 }
 
 func (p *ColorPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *ColorPolicy) GetMergeStrategy() machinery.MergeStrategy {
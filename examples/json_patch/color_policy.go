@@ -1,12 +1,9 @@
 package json_patch
 
 import (
-	"encoding/json"
-
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	gwapi "sigs.k8s.io/gateway-api/apis/v1alpha2"
 
-	jsonpatch "github.com/evanphx/json-patch"
 	"github.com/kuadrant/policy-machinery/machinery"
 )
 
@@ -24,59 +21,31 @@ func (p *ColorPolicy) GetURL() string {
 }
 
 func (p *ColorPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *ColorPolicy) GetMergeStrategy() machinery.MergeStrategy {
-	return func(parent, child machinery.Policy) machinery.Policy {
-		colorParent, okSource := parent.(*ColorPolicy)
-		colorChild, okTarget := child.(*ColorPolicy)
-
-		if !okSource || !okTarget {
-			return nil
-		}
-
-		parentSpecJSON, err := json.Marshal(colorParent.Spec.Proper())
-		if err != nil {
-			return nil
-		}
-
-		childSpecJSON, err := json.Marshal(colorChild.Spec.Proper())
-		if err != nil {
-			return nil
-		}
-
-		var resultSpecJSON []byte
-
-		if overrides := colorParent.Spec.Overrides; overrides != nil {
-			resultSpecJSON, err = jsonpatch.MergePatch(childSpecJSON, parentSpecJSON)
-		} else {
-			resultSpecJSON, err = jsonpatch.MergePatch(parentSpecJSON, childSpecJSON)
-		}
-
-		if err != nil {
-			return nil
-		}
-
-		result := ColorSpecProper{}
-		if err := json.Unmarshal(resultSpecJSON, &result); err != nil {
-			return nil
-		}
-
-		return &ColorPolicy{
-			TypeMeta:   colorChild.TypeMeta,
-			ObjectMeta: colorChild.ObjectMeta,
-			Spec: ColorSpec{
-				TargetRef:       colorChild.Spec.TargetRef,
-				ColorSpecProper: result,
-			},
-		}
-	}
+	return machinery.JSONMergePatchStrategy(
+		func(policy *ColorPolicy) *ColorSpecProper {
+			return policy.Spec.Proper()
+		},
+		func(policy *ColorPolicy, spec *ColorSpecProper) *ColorPolicy {
+			return &ColorPolicy{
+				TypeMeta:   policy.TypeMeta,
+				ObjectMeta: policy.ObjectMeta,
+				Spec: ColorSpec{
+					TargetRef:       policy.Spec.TargetRef,
+					ColorSpecProper: *spec,
+				},
+			}
+		},
+		func(policy *ColorPolicy) machinery.MergePatchDirection {
+			if policy.Spec.Overrides != nil {
+				return machinery.MergePatchOverrides
+			}
+			return machinery.MergePatchDefaults
+		},
+	)
 }
 
 func (p *ColorPolicy) Merge(policy machinery.Policy) machinery.Policy {
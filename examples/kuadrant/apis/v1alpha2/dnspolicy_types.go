@@ -49,16 +49,11 @@ func (p *DNSPolicy) GetURL() string {
 }
 
 func (p *DNSPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *DNSPolicy) GetMergeStrategy() machinery.MergeStrategy {
-	return machinery.DefaultMergeStrategy
+	return machinery.MergeStrategyFor(DNSPolicyKind)
 }
 
 func (p *DNSPolicy) Merge(other machinery.Policy) machinery.Policy {
@@ -51,16 +51,11 @@ func (p *TLSPolicy) GetURL() string {
 }
 
 func (p *TLSPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *TLSPolicy) GetMergeStrategy() machinery.MergeStrategy {
-	return machinery.DefaultMergeStrategy
+	return machinery.MergeStrategyFor(TLSPolicyKind)
 }
 
 func (p *TLSPolicy) Merge(other machinery.Policy) machinery.Policy {
@@ -54,12 +54,7 @@ func (p *AuthPolicy) GetURL() string {
 }
 
 func (p *AuthPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *AuthPolicy) GetMergeStrategy() machinery.MergeStrategy {
@@ -61,12 +61,7 @@ func (p *RateLimitPolicy) GetURL() string {
 }
 
 func (p *RateLimitPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
-	return []machinery.PolicyTargetReference{
-		machinery.LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return machinery.ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *RateLimitPolicy) GetMergeStrategy() machinery.MergeStrategy {
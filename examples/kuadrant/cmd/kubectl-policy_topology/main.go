@@ -0,0 +1,443 @@
+// kubectl-policy_topology is a kubectl plugin that inspects the topology of Gateway API resources and Kuadrant
+// policies, as modeled by the policy machinery.
+//
+// The resources are read from the cluster of the current kubeconfig context, or from a file of resources previously
+// dumped in YAML or JSON (e.g. `kubectl get gateways,httproutes,authpolicies -A -o yaml > dump.yaml`).
+//
+// Usage:
+//
+//	kubectl policy-topology tree [--file <path>]
+//	kubectl policy-topology effective <namespace>/<httproute> [--file <path>]
+//	kubectl policy-topology why <policy-kind> <namespace>/<name> [--file <path>]
+//	kubectl policy-topology conflicts [--file <path>]
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/clientcmd"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+
+	kuadrantapis "github.com/kuadrant/policy-machinery/examples/kuadrant/apis"
+	kuadrantv1alpha2 "github.com/kuadrant/policy-machinery/examples/kuadrant/apis/v1alpha2"
+	kuadrantv1beta3 "github.com/kuadrant/policy-machinery/examples/kuadrant/apis/v1beta3"
+	"github.com/kuadrant/policy-machinery/examples/kuadrant/reconcilers"
+)
+
+const usage = `Inspect the topology of Gateway API resources and Kuadrant policies.
+
+Usage:
+  kubectl policy-topology tree [--file <path>]
+  kubectl policy-topology effective <namespace>/<httproute> [--file <path>]
+  kubectl policy-topology why <policy-kind> <namespace>/<name> [--file <path>]
+  kubectl policy-topology conflicts [--file <path>]
+
+Flags:
+  -f, --file   read the resources from a YAML or JSON file instead of the cluster
+`
+
+var (
+	scheme = runtime.NewScheme()
+
+	resources = []schema.GroupVersionResource{
+		controller.GatewayClassesResource,
+		controller.GatewaysResource,
+		controller.HTTPRoutesResource,
+		controller.ServicesResource,
+		kuadrantv1alpha2.DNSPoliciesResource,
+		kuadrantv1alpha2.TLSPoliciesResource,
+		kuadrantv1beta3.AuthPoliciesResource,
+		kuadrantv1beta3.RateLimitPoliciesResource,
+	}
+)
+
+// policyKind tells how to compute the effective policies of a kind of policy
+type policyKind struct {
+	kind            schema.GroupKind
+	effectivePolicy machinery.EffectivePolicyFunc
+	paths           machinery.PathsFunc
+}
+
+var policyKinds = []policyKind{
+	{kuadrantv1alpha2.DNSPolicyKind, reconcilers.EffectivePolicyFuncFor[*kuadrantv1alpha2.DNSPolicy](), pathsFromGatewaysTo[*machinery.Listener]},
+	{kuadrantv1alpha2.TLSPolicyKind, reconcilers.EffectivePolicyFuncFor[*kuadrantv1alpha2.TLSPolicy](), pathsFromGatewaysTo[*machinery.Listener]},
+	{kuadrantv1beta3.AuthPolicyKind, reconcilers.EffectivePolicyFuncFor[*kuadrantv1beta3.AuthPolicy](), pathsFromGatewaysTo[*machinery.HTTPRouteRule]},
+	{kuadrantv1beta3.RateLimitPolicyKind, reconcilers.EffectivePolicyFuncFor[*kuadrantv1beta3.RateLimitPolicy](), pathsFromGatewaysTo[*machinery.HTTPRouteRule]},
+}
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(kuadrantv1alpha2.AddToScheme(scheme))
+	utilruntime.Must(kuadrantv1beta3.AddToScheme(scheme))
+	utilruntime.Must(gwapiv1.AddToScheme(scheme))
+}
+
+func main() {
+	log.SetFlags(0)
+
+	// parse command-line args
+	var file string
+	var args []string
+	for i := 1; i < len(os.Args); i++ {
+		switch os.Args[i] {
+		case "-f", "--file":
+			if i+1 >= len(os.Args) {
+				log.Fatalf("Missing value for %s\n\n%s", os.Args[i], usage)
+			}
+			file = os.Args[i+1]
+			i++
+		case "-h", "--help":
+			fmt.Print(usage)
+			return
+		default:
+			args = append(args, os.Args[i])
+		}
+	}
+	if len(args) == 0 {
+		log.Fatal(usage)
+	}
+
+	// load the resources
+	var objs []runtime.Object
+	var err error
+	if file != "" {
+		objs, err = loadFromFile(file)
+	} else {
+		objs, err = loadFromCluster(context.Background())
+	}
+	if err != nil {
+		log.Fatalf("Error loading resources: %v", err)
+	}
+	topology := buildTopology(objs)
+
+	// run the subcommand
+	switch cmd := args[0]; {
+	case cmd == "tree" && len(args) == 1:
+		err = tree(os.Stdout, topology)
+	case cmd == "effective" && len(args) == 2:
+		err = effective(os.Stdout, topology, args[1])
+	case cmd == "why" && len(args) == 3:
+		err = why(os.Stdout, topology, args[1], args[2])
+	case cmd == "conflicts" && len(args) == 1:
+		err = conflicts(os.Stdout, topology)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// tree prints the targetables of the topology as trees from the roots, with the policies attached to each targetable
+func tree(w io.Writer, topology *machinery.Topology) error {
+	targetables := topology.Targetables()
+	var printTargetable func(machinery.Targetable, int)
+	printTargetable = func(targetable machinery.Targetable, depth int) {
+		policies := lo.Map(targetable.Policies(), func(p machinery.Policy, _ int) string {
+			return fmt.Sprintf("%s %s", p.GroupVersionKind().Kind, objectName(p))
+		})
+		annotations := ""
+		if len(policies) > 0 {
+			annotations = fmt.Sprintf(" [%s]", strings.Join(policies, ", "))
+		}
+		fmt.Fprintf(w, "%s%s %s%s\n", strings.Repeat("  ", depth), targetable.GroupVersionKind().Kind, objectName(targetable), annotations)
+		for _, child := range sortedByURL(targetables.Children(targetable)) {
+			printTargetable(child, depth+1)
+		}
+	}
+	for _, root := range sortedByURL(targetables.Roots()) {
+		printTargetable(root, 0)
+	}
+	return nil
+}
+
+// effective prints the effective policies for all paths from the gateways to the rules of an HTTPRoute
+func effective(w io.Writer, topology *machinery.Topology, route string) error {
+	namespace, name, err := splitNamespacedName(route)
+	if err != nil {
+		return err
+	}
+	targetables := topology.Targetables()
+	rules := sortedByURL(targetables.Items(func(o machinery.Object) bool {
+		rule, ok := o.(*machinery.HTTPRouteRule)
+		return ok && rule.HTTPRoute.Namespace == namespace && rule.HTTPRoute.Name == name
+	}))
+	if len(rules) == 0 {
+		return fmt.Errorf("httproute %s not found or has no rules", route)
+	}
+	gateways := sortedByURL(targetables.Items(isA[*machinery.Gateway]))
+	pathsByKind := lo.Map(policyKinds, func(pk policyKind, _ int) []string {
+		return lo.Map(pk.paths(topology), func(path []machinery.Targetable, _ int) string { return pathString(path) })
+	})
+	for _, rule := range rules {
+		for _, gateway := range gateways {
+			for _, path := range targetables.Paths(gateway, rule) {
+				fmt.Fprintln(w, pathString(path))
+				for i, pk := range policyKinds {
+					if !lo.Contains(pathsByKind[i], pathString(path)) {
+						continue
+					}
+					policy := pk.effectivePolicy(path)
+					if policy == nil {
+						fmt.Fprintf(w, "  %s: none\n", pk.kind.Kind)
+						continue
+					}
+					fmt.Fprintf(w, "  %s:\n%s\n", pk.kind.Kind, indent(policyRules(policy), "    "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// why prints the targets of a policy and the paths whose effective policies depend on it
+func why(w io.Writer, topology *machinery.Topology, kind, policyName string) error {
+	namespace, name, err := splitNamespacedName(policyName)
+	if err != nil {
+		return err
+	}
+	pk, found := lo.Find(policyKinds, func(pk policyKind) bool {
+		return strings.EqualFold(pk.kind.Kind, kind)
+	})
+	if !found {
+		return fmt.Errorf("unknown policy kind %s", kind)
+	}
+	policies := lo.Filter(topology.Policies().ByKind(pk.kind), func(p machinery.Policy, _ int) bool {
+		return p.GetNamespace() == namespace && p.GetName() == name
+	})
+	if len(policies) == 0 {
+		return fmt.Errorf("%s %s not found", pk.kind.Kind, policyName)
+	}
+	policy := policies[0]
+
+	fmt.Fprintf(w, "%s %s targets:\n", pk.kind.Kind, policyName)
+	for _, targetRef := range policy.GetTargetRefs() {
+		status := "found"
+		if _, found := topology.Targetables().Get(targetRef.GetURL()); !found {
+			status = "not found"
+		}
+		fmt.Fprintf(w, "  %s (%s)\n", targetRef.GetURL(), status)
+	}
+
+	impacts := machinery.NewWhatIf(topology, pk.effectivePolicy, machinery.WithWhatIfPaths(pk.paths)).SimulateDelete(policy)
+	if len(impacts) == 0 {
+		fmt.Fprintln(w, "The policy does not contribute to any effective policy.")
+		return nil
+	}
+	fmt.Fprintln(w, "Contributes to the effective policies of:")
+	for _, impact := range impacts {
+		effect := "changes the effective policy if deleted"
+		if impact.Unprotected() {
+			effect = "left without effective policy if deleted"
+		}
+		fmt.Fprintf(w, "  %s (%s)\n", pathString(impact.Path), effect)
+	}
+	unprotected := lo.CountBy(impacts, func(impact machinery.PolicyImpact) bool { return impact.Unprotected() })
+	fmt.Fprintf(w, "Deleting this %s leaves %d path(s) without an effective %s.\n", pk.kind.Kind, unprotected, pk.kind.Kind)
+	return nil
+}
+
+// conflicts prints the targetables with more than one policy of the same kind attached
+func conflicts(w io.Writer, topology *machinery.Topology) error {
+	found := false
+	for _, targetable := range sortedByURL(topology.Targetables().Items()) {
+		policiesByKind := lo.GroupBy(targetable.Policies(), func(p machinery.Policy) string {
+			return p.GroupVersionKind().Kind
+		})
+		kinds := lo.Keys(policiesByKind)
+		sort.Strings(kinds)
+		for _, kind := range kinds {
+			policies := policiesByKind[kind]
+			if len(policies) < 2 {
+				continue
+			}
+			found = true
+			fmt.Fprintf(w, "%s %s is targeted by %d policies of kind %s: %s\n", targetable.GroupVersionKind().Kind, objectName(targetable), len(policies), kind, strings.Join(lo.Map(policies, func(p machinery.Policy, _ int) string { return objectName(p) }), ", "))
+		}
+	}
+	if !found {
+		fmt.Fprintln(w, "No conflicts found.")
+	}
+	return nil
+}
+
+func loadFromCluster(ctx context.Context) ([]runtime.Object, error) {
+	kubeconfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(clientcmd.NewDefaultClientConfigLoadingRules(), &clientcmd.ConfigOverrides{})
+	config, err := kubeconfig.ClientConfig()
+	if err != nil {
+		return nil, err
+	}
+	client, err := dynamic.NewForConfig(config)
+	if err != nil {
+		return nil, err
+	}
+	var objs []runtime.Object
+	for _, resource := range resources {
+		list, err := client.Resource(resource).Namespace(metav1.NamespaceAll).List(ctx, metav1.ListOptions{})
+		if apierrors.IsNotFound(err) {
+			continue // resource not installed in the cluster
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to list %s: %w", resource.String(), err)
+		}
+		for i := range list.Items {
+			if obj, ok := toTyped(&list.Items[i]); ok {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs, nil
+}
+
+func loadFromFile(path string) ([]runtime.Object, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var objs []runtime.Object
+	decoder := yaml.NewYAMLOrJSONDecoder(file, 4096)
+	for {
+		u := &unstructured.Unstructured{}
+		if err := decoder.Decode(&u.Object); err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+		if len(u.Object) == 0 {
+			continue
+		}
+		if !u.IsList() {
+			if obj, ok := toTyped(u); ok {
+				objs = append(objs, obj)
+			}
+			continue
+		}
+		list, err := u.ToList()
+		if err != nil {
+			return nil, err
+		}
+		for i := range list.Items {
+			if obj, ok := toTyped(&list.Items[i]); ok {
+				objs = append(objs, obj)
+			}
+		}
+	}
+	return objs, nil
+}
+
+// toTyped converts an unstructured object to its concrete type, if known
+func toTyped(u *unstructured.Unstructured) (runtime.Object, bool) {
+	obj, err := scheme.New(u.GroupVersionKind())
+	if err != nil {
+		return nil, false
+	}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.Object, obj); err != nil {
+		return nil, false
+	}
+	return obj, true
+}
+
+func buildTopology(objs []runtime.Object) *machinery.Topology {
+	return machinery.NewGatewayAPITopology(
+		machinery.WithGatewayClasses(ofType[*gwapiv1.GatewayClass](objs)...),
+		machinery.WithGateways(ofType[*gwapiv1.Gateway](objs)...),
+		machinery.WithHTTPRoutes(ofType[*gwapiv1.HTTPRoute](objs)...),
+		machinery.WithServices(ofType[*core.Service](objs)...),
+		machinery.WithGatewayAPITopologyPolicies(ofType[machinery.Policy](objs)...),
+		machinery.ExpandGatewayListeners(),
+		machinery.ExpandHTTPRouteRules(),
+		machinery.ExpandServicePorts(),
+	)
+}
+
+func pathsFromGatewaysTo[T machinery.Targetable](topology *machinery.Topology) [][]machinery.Targetable {
+	targetables := topology.Targetables()
+	gateways := targetables.Items(isA[*machinery.Gateway])
+	ends := targetables.Items(isA[T])
+	return lo.FlatMap(gateways, func(gateway machinery.Targetable, _ int) [][]machinery.Targetable {
+		return lo.FlatMap(ends, func(end machinery.Targetable, _ int) [][]machinery.Targetable {
+			return targetables.Paths(gateway, end)
+		})
+	})
+}
+
+func ofType[T any](objs []runtime.Object) []T {
+	return lo.FilterMap(objs, func(obj runtime.Object, _ int) (T, bool) {
+		t, ok := obj.(T)
+		return t, ok
+	})
+}
+
+func isA[T machinery.Object](o machinery.Object) bool {
+	_, ok := o.(T)
+	return ok
+}
+
+func sortedByURL[T machinery.Object](objs []T) []T {
+	sort.Slice(objs, func(i, j int) bool {
+		return objs[i].GetURL() < objs[j].GetURL()
+	})
+	return objs
+}
+
+func objectName(obj machinery.Object) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+}
+
+func pathString(path []machinery.Targetable) string {
+	return strings.Join(lo.Map(path, func(t machinery.Targetable, _ int) string {
+		return fmt.Sprintf("%s %s", t.GroupVersionKind().Kind, objectName(t))
+	}), " -> ")
+}
+
+func policyRules(policy machinery.Policy) string {
+	var rules any = policy
+	if mergeablePolicy, ok := policy.(kuadrantapis.MergeablePolicy); ok {
+		rules = mergeablePolicy.Rules()
+	}
+	b, err := json.MarshalIndent(rules, "", "  ")
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func indent(s, prefix string) string {
+	return prefix + strings.ReplaceAll(s, "\n", "\n"+prefix)
+}
+
+func splitNamespacedName(s string) (string, string, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", "", fmt.Errorf("invalid name %q, expected <namespace>/<name>", s)
+	}
+	return parts[0], parts[1], nil
+}
@@ -9,7 +9,10 @@ import (
 	egv1alpha1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/google/go-cmp/cmp"
 	"github.com/samber/lo"
+	istioextensionsv1alpha1 "istio.io/client-go/pkg/apis/extensions/v1alpha1"
+	istionetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	istiov1 "istio.io/client-go/pkg/apis/security/v1"
+	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -56,6 +59,8 @@ func init() {
 	utilruntime.Must(gwapiv1.AddToScheme(scheme))
 	utilruntime.Must(egv1alpha1.AddToScheme(scheme))
 	utilruntime.Must(istiov1.AddToScheme(scheme))
+	utilruntime.Must(istionetworkingv1alpha3.AddToScheme(scheme))
+	utilruntime.Must(istioextensionsv1alpha1.AddToScheme(scheme))
 }
 
 func main() {
@@ -115,23 +120,54 @@ func main() {
 		os.Exit(1)
 	}
 
+	// assertion rules of the topology, whose violations are served by the debug endpoints
+	httpsListenersRule, err := controller.CELAssertionRule(
+		"https-listeners-with-certificates",
+		"HTTPS listeners must refer to a TLS certificate",
+		`self.protocol != "HTTPS" || (has(self.tls) && size(self.tls.certificateRefs) > 0)`,
+		nil,
+		schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"},
+	)
+	if err != nil {
+		logger.Error(err, "error creating assertion rules")
+		os.Exit(1)
+	}
+
 	// base controller options
 	controllerOpts := []controller.ControllerOption{
 		controller.WithLogger(logger),
 		controller.WithClient(client),
+		controller.WithRestConfig(config),
 		controller.WithRunnable("gateway watcher", buildWatcher(&gwapiv1.Gateway{}, controller.GatewaysResource, metav1.NamespaceAll)),
 		controller.WithRunnable("httproute watcher", buildWatcher(&gwapiv1.HTTPRoute{}, controller.HTTPRoutesResource, metav1.NamespaceAll)),
 		controller.WithRunnable("dnspolicy watcher", buildWatcher(&kuadrantv1alpha2.DNSPolicy{}, kuadrantv1alpha2.DNSPoliciesResource, metav1.NamespaceAll)),
 		controller.WithRunnable("tlspolicy watcher", buildWatcher(&kuadrantv1alpha2.TLSPolicy{}, kuadrantv1alpha2.TLSPoliciesResource, metav1.NamespaceAll)),
 		controller.WithRunnable("authpolicy watcher", buildWatcher(&kuadrantv1beta3.AuthPolicy{}, kuadrantv1beta3.AuthPoliciesResource, metav1.NamespaceAll)),
 		controller.WithRunnable("ratelimitpolicy watcher", buildWatcher(&kuadrantv1beta3.RateLimitPolicy{}, kuadrantv1beta3.RateLimitPoliciesResource, metav1.NamespaceAll)),
+		controller.WithRunnable("wasm config watcher", buildWatcher(&core.ConfigMap{}, controller.ConfigMapsResource, metav1.NamespaceAll, controller.FilterResourcesByLabel[*core.ConfigMap](reconcilers.WasmConfigLabelSelector))),
 		controller.WithPolicyKinds(
 			kuadrantv1alpha2.DNSPolicyKind,
 			kuadrantv1alpha2.TLSPolicyKind,
 			kuadrantv1beta3.AuthPolicyKind,
 			kuadrantv1beta3.RateLimitPolicyKind,
 		),
+		controller.WithObjectKinds(controller.ConfigMapKind),
+		controller.WithObjectLinks(reconcilers.LinkGatewayToWasmConfigMapFunc),
+		controller.WithPauseAnnotation(map[schema.GroupKind]schema.GroupVersionResource{
+			kuadrantv1alpha2.DNSPolicyKind:      kuadrantv1alpha2.DNSPoliciesResource,
+			kuadrantv1alpha2.TLSPolicyKind:      kuadrantv1alpha2.TLSPoliciesResource,
+			kuadrantv1beta3.AuthPolicyKind:      kuadrantv1beta3.AuthPoliciesResource,
+			kuadrantv1beta3.RateLimitPolicyKind: kuadrantv1beta3.RateLimitPoliciesResource,
+		}),
+		controller.WithCacheWarming(
+			machinery.WarmPaths(controller.GatewayKind, schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"}),
+			machinery.WarmPaths(controller.GatewayKind, schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRouteRule"}),
+		),
 		controller.WithReconcile(buildReconciler(gatewayProviders, client)),
+		controller.WithAssertionRules(httpsListenersRule),
+		// health probes and debug endpoints (/debug/query, /debug/assertions, /debug/mutation-plan, /debug/buildinfo)
+		controller.WithHealthProbes(":8082"),
+		controller.WithDebugHandlers(),
 	}
 
   // gateway provider specific controller options
@@ -181,13 +217,18 @@ func controllerOptionsFor(gatewayProviders []string) []controller.ControllerOpti
 	for _, gatewayProvider := range gatewayProviders {
 		switch gatewayProvider {
 		case reconcilers.EnvoyGatewayProviderName:
-			opts = append(opts, controller.WithRunnable("envoygateway/securitypolicy watcher", buildWatcher(&egv1alpha1.SecurityPolicy{}, reconcilers.EnvoyGatewaySecurityPoliciesResource, metav1.NamespaceAll)))
-			opts = append(opts, controller.WithObjectKinds(reconcilers.EnvoyGatewaySecurityPolicyKind))
-			opts = append(opts, controller.WithObjectLinks(reconcilers.LinkGatewayToEnvoyGatewaySecurityPolicyFunc))
+			opts = append(opts, controller.WithRunnable("envoygateway/securitypolicy watcher", buildWatcher(&egv1alpha1.SecurityPolicy{}, controller.SecurityPoliciesResource, metav1.NamespaceAll)))
+			opts = append(opts, controller.WithEnvoyGatewayPolicies(controller.GatewayKind))
+			opts = append(opts, controller.WithStatusFeedback(reconcilers.EnvoyGatewaySecurityPolicyStatusFeedback))
+			opts = append(opts, controller.WithGarbageCollection(controller.GarbageCollection{GeneratedKind: controller.SecurityPolicyKind, GeneratedResource: controller.SecurityPoliciesResource, ManagedBy: reconcilers.ControllerName}))
 		case reconcilers.IstioGatewayProviderName:
 			opts = append(opts, controller.WithRunnable("istio/authorizationpolicy watcher", buildWatcher(&istiov1.AuthorizationPolicy{}, reconcilers.IstioAuthorizationPoliciesResource, metav1.NamespaceAll)))
-			opts = append(opts, controller.WithObjectKinds(reconcilers.IstioAuthorizationPolicyKind))
-			opts = append(opts, controller.WithObjectLinks(reconcilers.LinkGatewayToIstioAuthorizationPolicyFunc))
+			opts = append(opts, controller.WithRunnable("istio/envoyfilter watcher", buildWatcher(&istionetworkingv1alpha3.EnvoyFilter{}, reconcilers.IstioEnvoyFiltersResource, metav1.NamespaceAll)))
+			opts = append(opts, controller.WithRunnable("istio/wasmplugin watcher", buildWatcher(&istioextensionsv1alpha1.WasmPlugin{}, reconcilers.IstioWasmPluginsResource, metav1.NamespaceAll)))
+			opts = append(opts, controller.WithObjectKinds(reconcilers.IstioAuthorizationPolicyKind, reconcilers.IstioEnvoyFilterKind, reconcilers.IstioWasmPluginKind))
+			opts = append(opts, controller.WithObjectWrappers(reconcilers.WrapIstioObject))
+			opts = append(opts, controller.WithObjectLinks(reconcilers.LinkGatewayToIstioAuthorizationPolicyFunc, reconcilers.LinkGatewayToIstioEnvoyFilterFunc, reconcilers.LinkGatewayToIstioWasmPluginFunc))
+			opts = append(opts, controller.WithGarbageCollection(controller.GarbageCollection{GeneratedKind: reconcilers.IstioAuthorizationPolicyKind, GeneratedResource: reconcilers.IstioAuthorizationPoliciesResource, ManagedBy: reconcilers.ControllerName}))
 		}
 	}
 
@@ -198,10 +239,24 @@ func controllerOptionsFor(gatewayProviders []string) []controller.ControllerOpti
 //  1. log event
 //  2. save topology to file
 //  2. effective policies
-//  3. (gateway deleted) delete SecurityPolicy / (other events) reconcile SecurityPolicies
-//  3. (gateway deleted) delete AuthorizationPolicy / (other events) reconcile AuthorizationPolicies
+//  3. wasm plugin config from the effective RateLimitPolicies
+//  3. reconcile SecurityPolicies (deleted gateways are garbage collected by the controller)
+//  3. reconcile AuthorizationPolicies (deleted gateways are garbage collected by the controller)
 func buildReconciler(gatewayProviders []string, client *dynamic.DynamicClient) controller.ReconcileFunc {
-	effectivePolicyReconciler := &reconcilers.EffectivePoliciesReconciler{Client: client}
+	effectivePolicyReconciler := &reconcilers.EffectivePoliciesReconciler{
+		Client:  client,
+		History: controller.NewEffectivePolicyHistory(controller.DefaultEffectivePolicyHistoryRevisions),
+	}
+
+	effectivePolicyReconciler.ReconcileFuncs = append(effectivePolicyReconciler.ReconcileFuncs, (&controller.Subscription{
+		ReconcileFunc: (&reconcilers.WasmConfigReconciler{Client: client}).Reconcile,
+		Events: []controller.ResourceEventMatcher{
+			{Kind: ptr.To(controller.GatewayKind)},
+			{Kind: ptr.To(controller.HTTPRouteKind)},
+			{Kind: ptr.To(kuadrantv1beta3.RateLimitPolicyKind)},
+			{Kind: ptr.To(controller.ConfigMapKind)},
+		},
+	}).Reconcile)
 
 	commonAuthPolicyResourceEventMatchers := []controller.ResourceEventMatcher{
 		{Kind: ptr.To(controller.GatewayClassKind)},
@@ -217,13 +272,7 @@ func buildReconciler(gatewayProviders []string, client *dynamic.DynamicClient) c
 			envoyGatewayProvider := &reconcilers.EnvoyGatewayProvider{Client: client}
 			effectivePolicyReconciler.ReconcileFuncs = append(effectivePolicyReconciler.ReconcileFuncs, (&controller.Subscription{
 				ReconcileFunc: envoyGatewayProvider.ReconcileSecurityPolicies,
-				Events:        append(commonAuthPolicyResourceEventMatchers, controller.ResourceEventMatcher{Kind: ptr.To(reconcilers.EnvoyGatewaySecurityPolicyKind)}),
-			}).Reconcile)
-			effectivePolicyReconciler.ReconcileFuncs = append(effectivePolicyReconciler.ReconcileFuncs, (&controller.Subscription{
-				ReconcileFunc: envoyGatewayProvider.DeleteSecurityPolicy,
-				Events: []controller.ResourceEventMatcher{
-					{Kind: ptr.To(controller.GatewayKind), EventType: ptr.To(controller.DeleteEvent)},
-				},
+				Events:        append(commonAuthPolicyResourceEventMatchers, controller.ResourceEventMatcher{Kind: ptr.To(controller.SecurityPolicyKind)}),
 			}).Reconcile)
 		case reconcilers.IstioGatewayProviderName:
 			istioGatewayProvider := &reconcilers.IstioGatewayProvider{Client: client}
@@ -231,12 +280,6 @@ func buildReconciler(gatewayProviders []string, client *dynamic.DynamicClient) c
 				ReconcileFunc: istioGatewayProvider.ReconcileAuthorizationPolicies,
 				Events:        append(commonAuthPolicyResourceEventMatchers, controller.ResourceEventMatcher{Kind: ptr.To(reconcilers.IstioAuthorizationPolicyKind)}),
 			}).Reconcile)
-			effectivePolicyReconciler.ReconcileFuncs = append(effectivePolicyReconciler.ReconcileFuncs, (&controller.Subscription{
-				ReconcileFunc: istioGatewayProvider.DeleteAuthorizationPolicy,
-				Events: []controller.ResourceEventMatcher{
-					{Kind: ptr.To(controller.GatewayKind), EventType: ptr.To(controller.DeleteEvent)},
-				},
-			}).Reconcile)
 		}
 	}
 
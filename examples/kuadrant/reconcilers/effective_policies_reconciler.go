@@ -3,11 +3,14 @@ package reconcilers
 import (
 	"context"
 	"encoding/json"
+	"fmt"
 	"reflect"
 	"sort"
+	"strings"
 	"sync"
 
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 
 	"github.com/kuadrant/policy-machinery/controller"
@@ -18,13 +21,24 @@ import (
 	kuadrantv1beta3 "github.com/kuadrant/policy-machinery/examples/kuadrant/apis/v1beta3"
 )
 
-const authPathsKey = "authPaths"
+// ControllerName is the name of the controller, set in the managed-by label of the resources it generates
+const ControllerName = "kuadrant-policy-machinery-example"
+
+const (
+	authPathsKey                  = "authPaths"
+	authEffectivePoliciesKey      = "authEffectivePolicies"
+	rateLimitPathsKey             = "rateLimitPaths"
+	rateLimitEffectivePoliciesKey = "rateLimitEffectivePolicies"
+)
 
 // EffectivePoliciesReconciler works exactly like a controller.Workflow where the precondition reconcile function
 // reconciles the effective policies for the given topology paths, occasionally modifying the context that is passed
 // as argument to the subsequent concurrent reconcilers.
+// If a history is provided, the effective policies are recorded in it by topology path. The removal of the effective
+// policy of a path is recorded as a tombstone, and paths that leave the topology are forgotten.
 type EffectivePoliciesReconciler struct {
 	Client         *dynamic.DynamicClient
+	History        *controller.EffectivePolicyHistory
 	ReconcileFuncs []controller.ReconcileFunc
 }
 
@@ -32,30 +46,29 @@ func (r *EffectivePoliciesReconciler) Reconcile(ctx context.Context, resourceEve
 	targetables := topology.Targetables()
 
 	// reconcile policies
-	gateways := targetables.Items(func(o machinery.Object) bool {
-		_, ok := o.(*machinery.Gateway)
-		return ok
-	})
+	gateways := machinery.TargetablesOfType[*machinery.Gateway](topology)
 
-	listeners := targetables.Items(func(o machinery.Object) bool {
-		_, ok := o.(*machinery.Listener)
-		return ok
-	})
+	listeners := machinery.TargetablesOfType[*machinery.Listener](topology)
 
-	httpRouteRules := targetables.Items(func(o machinery.Object) bool {
-		_, ok := o.(*machinery.HTTPRouteRule)
-		return ok
-	})
+	httpRouteRules := machinery.TargetablesOfType[*machinery.HTTPRouteRule](topology)
+
+	// history keys of the paths of the topology, and of the effective policies recorded in this reconciliation
+	pathKeys := make(map[string]struct{})
+	recordedKeys := make(map[string]struct{})
 
 	for _, gateway := range gateways {
 		// reconcile Gateway -> Listener policies
 		for _, listener := range listeners {
 			paths := targetables.Paths(gateway, listener)
 			for i := range paths {
-				if p := effectivePolicyForPath[*kuadrantv1alpha2.DNSPolicy](ctx, paths[i]); p != nil {
+				pathKeys[historyKey(kuadrantv1alpha2.DNSPolicyKind, paths[i])] = struct{}{}
+				pathKeys[historyKey(kuadrantv1alpha2.TLSPolicyKind, paths[i])] = struct{}{}
+				if p := effectivePolicyForPath[*kuadrantv1alpha2.DNSPolicy](ctx, topology, paths[i]); p != nil {
+					recordedKeys[r.record(ctx, kuadrantv1alpha2.DNSPolicyKind, paths[i], *p)] = struct{}{}
 					// TODO: reconcile dns effective policy (i.e. create the DNSRecords for it)
 				}
-				if p := effectivePolicyForPath[*kuadrantv1alpha2.TLSPolicy](ctx, paths[i]); p != nil {
+				if p := effectivePolicyForPath[*kuadrantv1alpha2.TLSPolicy](ctx, topology, paths[i]); p != nil {
+					recordedKeys[r.record(ctx, kuadrantv1alpha2.TLSPolicyKind, paths[i], *p)] = struct{}{}
 					// TODO: reconcile tls effective policy (i.e. create the certificate request for it)
 				}
 			}
@@ -65,17 +78,26 @@ func (r *EffectivePoliciesReconciler) Reconcile(ctx context.Context, resourceEve
 		for _, httpRouteRule := range httpRouteRules {
 			paths := targetables.Paths(gateway, httpRouteRule)
 			for i := range paths {
-				if p := effectivePolicyForPath[*kuadrantv1beta3.AuthPolicy](ctx, paths[i]); p != nil {
+				pathKeys[historyKey(kuadrantv1beta3.AuthPolicyKind, paths[i])] = struct{}{}
+				pathKeys[historyKey(kuadrantv1beta3.RateLimitPolicyKind, paths[i])] = struct{}{}
+				if p := effectivePolicyForPath[*kuadrantv1beta3.AuthPolicy](ctx, topology, paths[i]); p != nil {
 					ctx = pathIntoContext(ctx, authPathsKey, paths[i])
+					ctx = effectivePolicyIntoContext(ctx, authEffectivePoliciesKey, paths[i], *p)
+					recordedKeys[r.record(ctx, kuadrantv1beta3.AuthPolicyKind, paths[i], *p)] = struct{}{}
 					// TODO: reconcile auth effective policy (i.e. create the Authorino AuthConfig)
 				}
-				if p := effectivePolicyForPath[*kuadrantv1beta3.RateLimitPolicy](ctx, paths[i]); p != nil {
+				if p := effectivePolicyForPath[*kuadrantv1beta3.RateLimitPolicy](ctx, topology, paths[i]); p != nil {
+					ctx = pathIntoContext(ctx, rateLimitPathsKey, paths[i])
+					ctx = effectivePolicyIntoContext(ctx, rateLimitEffectivePoliciesKey, paths[i], *p)
+					recordedKeys[r.record(ctx, kuadrantv1beta3.RateLimitPolicyKind, paths[i], *p)] = struct{}{}
 					// TODO: reconcile rate-limit effective policy (i.e. create the Limitador limits config)
 				}
 			}
 		}
 	}
 
+	r.prune(pathKeys, recordedKeys)
+
 	// dispatch the event to subsequent reconcilers
 	funcs := r.ReconcileFuncs
 	waitGroup := &sync.WaitGroup{}
@@ -89,37 +111,65 @@ func (r *EffectivePoliciesReconciler) Reconcile(ctx context.Context, resourceEve
 	}
 }
 
-func effectivePolicyForPath[T machinery.Policy](ctx context.Context, path []machinery.Targetable) *T {
-	logger := controller.LoggerFromContext(ctx).WithName("effective policy")
+// record records an effective policy in the history, if any, returning the key of the history it was recorded under
+func (r *EffectivePoliciesReconciler) record(ctx context.Context, kind schema.GroupKind, path []machinery.Targetable, effectivePolicy machinery.Policy) string {
+	key := historyKey(kind, path)
+	if r.History == nil {
+		return key
+	}
+	if _, err := r.History.Record(key, effectivePolicy); err != nil {
+		controller.LoggerFromContext(ctx).Error(err, "failed to record effective policy", "kind", kind.String())
+	}
+	return key
+}
 
-	// gather all policies in the path sorted from the least specific to the most specific
-	policies := lo.FlatMap(path, func(targetable machinery.Targetable, _ int) []machinery.Policy {
-		policies := lo.FilterMap(targetable.Policies(), func(p machinery.Policy, _ int) (kuadrantapis.MergeablePolicy, bool) {
-			_, ok := p.(T)
-			mergeablePolicy, mergeable := p.(kuadrantapis.MergeablePolicy)
-			return mergeablePolicy, mergeable && ok
-		})
-		sort.Sort(kuadrantapis.PolicyByCreationTimestamp(policies))
-		return lo.Map(policies, func(p kuadrantapis.MergeablePolicy, _ int) machinery.Policy { return p })
-	})
+// prune forgets the history of the paths no longer in the topology, and records the removal of the effective policies
+// of the paths still in the topology for which no effective policy was recorded
+func (r *EffectivePoliciesReconciler) prune(pathKeys, recordedKeys map[string]struct{}) {
+	if r.History == nil {
+		return
+	}
+	for _, key := range r.History.Keys() {
+		if _, ok := pathKeys[key]; !ok {
+			r.History.Forget(key)
+			continue
+		}
+		if _, ok := recordedKeys[key]; !ok {
+			r.History.RecordRemoval(key)
+		}
+	}
+}
+
+func historyKey(kind schema.GroupKind, path []machinery.Targetable) string {
+	return fmt.Sprintf("%s/%s", strings.ToLower(kind.String()), machinery.PathID(path))
+}
+
+func effectivePolicyForPath[T machinery.Policy](ctx context.Context, topology *machinery.Topology, path []machinery.Targetable) *T {
+	logger := controller.LoggerFromContext(ctx).WithName("effective policy")
 
 	pathURLs := lo.Map(path, machinery.MapTargetableToURLFunc)
 
-	if len(policies) == 0 {
+	effectivePolicy, found := machinery.EffectivePolicyForPath[T](topology, path)
+	if !found {
 		logger.Info("no policies for path", "kind", reflect.TypeOf(new(T)), "path", pathURLs)
 		return nil
 	}
 
-	// map reduces the policies from most specific to least specific, merging them into one effective policy
-	effectivePolicy := lo.ReduceRight(policies, func(effectivePolicy machinery.Policy, policy machinery.Policy, _ int) machinery.Policy {
-		return effectivePolicy.Merge(policy)
-	}, policies[len(policies)-1])
-
 	jsonEffectivePolicy, _ := json.Marshal(effectivePolicy)
 	logger.Info("effective policy", "kind", reflect.TypeOf(new(T)), "path", pathURLs, "effectivePolicy", string(jsonEffectivePolicy))
 
-	concreteEffectivePolicy, _ := effectivePolicy.(T)
-	return &concreteEffectivePolicy
+	return &effectivePolicy
+}
+
+// EffectivePolicyFuncFor returns a machinery.EffectivePolicyFunc that computes the effective policy of kind T for a
+// path, the same way the EffectivePoliciesReconciler does.
+func EffectivePolicyFuncFor[T machinery.Policy]() machinery.EffectivePolicyFunc {
+	return func(path []machinery.Targetable) machinery.Policy {
+		if p, found := machinery.ComputeEffectivePolicy[T](path); found {
+			return p
+		}
+		return nil
+	}
 }
 
 func pathIntoContext(ctx context.Context, key string, path []machinery.Targetable) context.Context {
@@ -136,3 +186,35 @@ func pathsFromContext(ctx context.Context, key string) [][]machinery.Targetable
 	}
 	return paths
 }
+
+func effectivePolicyIntoContext(ctx context.Context, key string, path []machinery.Targetable, policy machinery.Policy) context.Context {
+	policies := make(map[string]machinery.Policy)
+	for k, v := range effectivePoliciesFromContext(ctx, key) {
+		policies[k] = v
+	}
+	policies[machinery.PathID(path)] = policy
+	return context.WithValue(ctx, key, policies)
+}
+
+func effectivePoliciesFromContext(ctx context.Context, key string) map[string]machinery.Policy {
+	var policies map[string]machinery.Policy
+	if p := ctx.Value(key); p != nil {
+		policies = p.(map[string]machinery.Policy)
+	}
+	return policies
+}
+
+// effectivePolicyHashForPaths returns a hash of the rules of the effective policies computed for the given paths
+func effectivePolicyHashForPaths(ctx context.Context, key string, paths [][]machinery.Targetable) (string, error) {
+	effectivePolicies := effectivePoliciesFromContext(ctx, key)
+	ids := lo.Map(paths, func(path []machinery.Targetable, _ int) string { return machinery.PathID(path) })
+	sort.Strings(ids)
+	rules := lo.FilterMap(ids, func(id string, _ int) (map[string]any, bool) {
+		policy, ok := effectivePolicies[id].(kuadrantapis.MergeablePolicy)
+		if !ok {
+			return nil, false
+		}
+		return policy.Rules(), true
+	})
+	return controller.HashEffectivePolicy(rules)
+}
@@ -6,8 +6,8 @@ import (
 
 	egv1alpha1 "github.com/envoyproxy/gateway/api/v1alpha1"
 	"github.com/samber/lo"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/utils/ptr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -15,14 +15,35 @@ import (
 
 	"github.com/kuadrant/policy-machinery/controller"
 	"github.com/kuadrant/policy-machinery/machinery"
+
+	kuadrantv1beta3 "github.com/kuadrant/policy-machinery/examples/kuadrant/apis/v1beta3"
 )
 
 const EnvoyGatewayProviderName = "envoygateway"
 
-var (
-	EnvoyGatewaySecurityPolicyKind       = schema.GroupKind{Group: egv1alpha1.GroupName, Kind: "SecurityPolicy"}
-	EnvoyGatewaySecurityPoliciesResource = egv1alpha1.SchemeBuilder.GroupVersion.WithResource("securitypolicies")
-)
+// EnvoyGatewaySecurityPolicyStatusFeedback feeds the status of the SecurityPolicies generated for the gateways back
+// into the Enforced condition of the AuthPolicies whose effective policies they implement.
+// An AuthPolicy is not Enforced while any of those SecurityPolicies is not yet Accepted.
+var EnvoyGatewaySecurityPolicyStatusFeedback = controller.StatusFeedback{
+	GeneratedKind:  controller.SecurityPolicyKind,
+	PolicyResource: kuadrantv1beta3.AuthPoliciesResource,
+	Owners: func(generated machinery.Object, topology *machinery.Topology) []machinery.Policy {
+		gateways := machinery.ParentsOfType[*machinery.Gateway](topology, generated)
+		authPolicies := lo.Filter(machinery.PoliciesOfType[*kuadrantv1beta3.AuthPolicy](topology), func(authPolicy *kuadrantv1beta3.AuthPolicy, _ int) bool {
+			return lo.ContainsBy(authPolicy.GetTargetRefs(), func(ref machinery.PolicyTargetReference) bool {
+				target, found := topology.Targetables().Get(ref.GetURL())
+				return found && lo.ContainsBy(gateways, func(gateway *machinery.Gateway) bool {
+					return len(topology.Targetables().Paths(gateway, target)) > 0
+				})
+			})
+		})
+		return lo.Map(authPolicies, func(authPolicy *kuadrantv1beta3.AuthPolicy, _ int) machinery.Policy { return authPolicy })
+	},
+	Dependency: func(generated machinery.Object) controller.Dependency {
+		securityPolicy := generated.(*controller.EnvoyGatewayPolicy).Object.(*egv1alpha1.SecurityPolicy)
+		return controller.DependencyFromPolicyStatus(generated, securityPolicy.Status, controller.PolicyConditionAccepted)
+	},
+}
 
 type EnvoyGatewayProvider struct {
 	Client *dynamic.DynamicClient
@@ -34,10 +55,7 @@ func (p *EnvoyGatewayProvider) ReconcileSecurityPolicies(ctx context.Context, _
 
 	authPaths := pathsFromContext(ctx, authPathsKey)
 	targetables := topology.Targetables()
-	gateways := targetables.Items(func(o machinery.Object) bool {
-		_, ok := o.(*machinery.Gateway)
-		return ok
-	})
+	gateways := machinery.TargetablesOfType[*machinery.Gateway](topology)
 	for _, gateway := range gateways {
 		paths := lo.Filter(authPaths, func(path []machinery.Targetable, _ int) bool {
 			if len(path) != 4 { // should never happen
@@ -49,31 +67,27 @@ func (p *EnvoyGatewayProvider) ReconcileSecurityPolicies(ctx context.Context, _
 				return ok && gc.Spec.ControllerName == "gateway.envoyproxy.io/gatewayclass-controller"
 			})
 		})
+		if controller.IsPaused(gateway) || lo.ContainsBy(paths, controller.PathPaused) {
+			continue
+		}
 		if len(paths) > 0 {
-			p.createSecurityPolicy(ctx, topology, gateway)
+			p.createSecurityPolicy(ctx, gateway, paths)
 			continue
 		}
-		p.deleteSecurityPolicy(ctx, topology, gateway.GetNamespace(), gateway.GetName(), gateway)
+		p.deleteSecurityPolicy(ctx, gateway)
 	}
 }
 
-func (p *EnvoyGatewayProvider) DeleteSecurityPolicy(ctx context.Context, resourceEvents []controller.ResourceEvent, topology *machinery.Topology) {
-	for _, resourceEvent := range resourceEvents {
-		gateway := resourceEvent.OldObject
-		p.deleteSecurityPolicy(ctx, topology, gateway.GetNamespace(), gateway.GetName(), nil)
-	}
-}
-
-func (p *EnvoyGatewayProvider) createSecurityPolicy(ctx context.Context, topology *machinery.Topology, gateway machinery.Targetable) {
+func (p *EnvoyGatewayProvider) createSecurityPolicy(ctx context.Context, gateway machinery.Targetable, paths [][]machinery.Targetable) {
 	logger := controller.LoggerFromContext(ctx)
 
 	desiredSecurityPolicy := &egv1alpha1.SecurityPolicy{
 		TypeMeta: metav1.TypeMeta{
 			APIVersion: egv1alpha1.GroupVersion.String(),
-			Kind:       EnvoyGatewaySecurityPolicyKind.Kind,
+			Kind:       controller.SecurityPolicyKind.Kind,
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      gateway.GetName(),
+			Name:      controller.GeneratedNameFor(gateway),
 			Namespace: gateway.GetNamespace(),
 		},
 		Spec: egv1alpha1.SecurityPolicySpec{
@@ -97,94 +111,54 @@ func (p *EnvoyGatewayProvider) createSecurityPolicy(ctx context.Context, topolog
 			},
 		},
 	}
-
-	resource := p.Client.Resource(EnvoyGatewaySecurityPoliciesResource).Namespace(gateway.GetNamespace())
-
-	obj, found := lo.Find(topology.Objects().Children(gateway), func(o machinery.Object) bool {
-		return o.GroupVersionKind().GroupKind() == EnvoyGatewaySecurityPolicyKind && o.GetNamespace() == gateway.GetNamespace() && o.GetName() == gateway.GetName()
-	})
-
-	if !found {
-		o, _ := controller.Destruct(desiredSecurityPolicy)
-		_, err := resource.Create(ctx, o, metav1.CreateOptions{})
-		if err != nil {
-			logger.Error(err, "failed to create SecurityPolicy")
-		}
+	hash, err := effectivePolicyHashForPaths(ctx, authEffectivePoliciesKey, paths)
+	if err != nil {
+		logger.Error(err, "failed to hash effective policies")
 		return
 	}
+	controller.SetEffectivePolicyHash(desiredSecurityPolicy, hash)
+	controller.SetGeneratedResourceLabels(desiredSecurityPolicy, controller.GeneratedResourceLabels{ManagedBy: ControllerName, OwningTargetables: []machinery.Targetable{gateway}})
 
-	securityPolicy := obj.(*controller.RuntimeObject).Object.(*egv1alpha1.SecurityPolicy)
+	resource := p.Client.Resource(controller.SecurityPoliciesResource).Namespace(gateway.GetNamespace())
 
-	if securityPolicy.Spec.ExtAuth != nil &&
-		securityPolicy.Spec.ExtAuth.GRPC != nil &&
-		securityPolicy.Spec.ExtAuth.GRPC.BackendRef != nil &&
-		securityPolicy.Spec.ExtAuth.GRPC.BackendRef.Namespace != nil &&
-		*securityPolicy.Spec.ExtAuth.GRPC.BackendRef.Namespace == *desiredSecurityPolicy.Spec.ExtAuth.GRPC.BackendRef.Namespace &&
-		securityPolicy.Spec.ExtAuth.GRPC.BackendRef.Name == desiredSecurityPolicy.Spec.ExtAuth.GRPC.BackendRef.Name &&
-		securityPolicy.Spec.ExtAuth.GRPC.BackendRef.Port != nil &&
-		*securityPolicy.Spec.ExtAuth.GRPC.BackendRef.Port == *desiredSecurityPolicy.Spec.ExtAuth.GRPC.BackendRef.Port {
+	obj, err := p.securityPolicy(ctx, gateway)
+	if err != nil {
+		logger.Error(err, "failed to get SecurityPolicy")
+		return
+	}
+	if obj != nil && controller.IsUnmanaged(obj) {
 		return
 	}
 
-	securityPolicy.Spec = desiredSecurityPolicy.Spec
-	o, _ := controller.Destruct(securityPolicy)
-	_, err := resource.Update(ctx, o, metav1.UpdateOptions{})
-	if err != nil {
-		logger.Error(err, "failed to update SecurityPolicy")
+	if _, err := controller.ApplyObject(ctx, resource, desiredSecurityPolicy, controller.WithFieldManager(ControllerName), controller.WithForceOwnership()); err != nil {
+		logger.Error(err, "failed to apply SecurityPolicy")
 	}
 }
 
-func (p *EnvoyGatewayProvider) deleteSecurityPolicy(ctx context.Context, topology *machinery.Topology, namespace, name string, parent machinery.Targetable) {
-	var objs []machinery.Object
-	if parent != nil {
-		objs = topology.Objects().Children(parent)
-	} else {
-		objs = topology.Objects().Items()
+func (p *EnvoyGatewayProvider) deleteSecurityPolicy(ctx context.Context, gateway machinery.Targetable) {
+	obj, err := p.securityPolicy(ctx, gateway)
+	if err != nil {
+		controller.LoggerFromContext(ctx).Error(err, "failed to get SecurityPolicy")
+		return
 	}
-	_, found := lo.Find(objs, func(o machinery.Object) bool {
-		return o.GroupVersionKind().GroupKind() == EnvoyGatewaySecurityPolicyKind && o.GetNamespace() == namespace && o.GetName() == name
-	})
-	if !found {
+	if obj == nil || controller.IsUnmanaged(obj) {
 		return
 	}
-	resource := p.Client.Resource(EnvoyGatewaySecurityPoliciesResource).Namespace(namespace)
-	err := resource.Delete(ctx, name, metav1.DeleteOptions{})
-	if err != nil {
+	if controller.IsUnmanagedState(ctx) {
+		return
+	}
+	resource := p.Client.Resource(controller.SecurityPoliciesResource).Namespace(gateway.GetNamespace())
+	if err := resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{}); err != nil {
 		controller.LoggerFromContext(ctx).Error(err, "failed to delete SecurityPolicy")
 	}
 }
 
-func LinkGatewayToEnvoyGatewaySecurityPolicyFunc(objs controller.Store) machinery.LinkFunc {
-	gateways := lo.Map(objs.FilterByGroupKind(controller.GatewayKind), controller.ObjectAs[*gwapiv1.Gateway])
-
-	return machinery.LinkFunc{
-		From: controller.GatewayKind,
-		To:   EnvoyGatewaySecurityPolicyKind,
-		Func: func(child machinery.Object) []machinery.Object {
-			o := child.(*controller.RuntimeObject)
-			sp := o.Object.(*egv1alpha1.SecurityPolicy)
-			refs := sp.Spec.PolicyTargetReferences.TargetRefs
-			if ref := sp.Spec.PolicyTargetReferences.TargetRef; ref != nil {
-				refs = append(refs, *ref)
-			}
-			refs = lo.Filter(refs, func(ref gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName, _ int) bool {
-				return ref.Group == gwapiv1.GroupName && ref.Kind == gwapiv1.Kind(controller.GatewayKind.Kind)
-			})
-			if len(refs) == 0 {
-				return nil
-			}
-			gateway, ok := lo.Find(gateways, func(g *gwapiv1.Gateway) bool {
-				if g.GetNamespace() != sp.GetNamespace() {
-					return false
-				}
-				return lo.ContainsBy(refs, func(ref gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName) bool {
-					return ref.Name == gwapiv1.ObjectName(g.GetName())
-				})
-			})
-			if ok {
-				return []machinery.Object{&machinery.Gateway{Gateway: gateway}}
-			}
-			return nil
-		},
+// securityPolicy returns the SecurityPolicy generated for a gateway, if any, read from the cache of the controller.
+// SecurityPolicies are watched, so the cache is authoritative once synced, and missing ones do not hit the API server.
+func (p *EnvoyGatewayProvider) securityPolicy(ctx context.Context, gateway machinery.Targetable) (controller.Object, error) {
+	obj, err := controller.CachedClientFromContext(ctx).Get(ctx, controller.SecurityPoliciesResource, controller.SecurityPolicyKind, gateway.GetNamespace(), controller.GeneratedNameFor(gateway))
+	if apierrors.IsNotFound(err) {
+		return nil, nil
 	}
+	return obj, err
 }
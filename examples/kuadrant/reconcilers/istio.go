@@ -37,10 +37,7 @@ func (p *IstioGatewayProvider) ReconcileAuthorizationPolicies(ctx context.Contex
 
 	authPaths := pathsFromContext(ctx, authPathsKey)
 	targetables := topology.Targetables()
-	gateways := targetables.Items(func(o machinery.Object) bool {
-		_, ok := o.(*machinery.Gateway)
-		return ok
-	})
+	gateways := machinery.TargetablesOfType[*machinery.Gateway](topology)
 	for _, gateway := range gateways {
 		paths := lo.Filter(authPaths, func(path []machinery.Targetable, _ int) bool {
 			if len(path) != 4 { // should never happen
@@ -52,18 +49,14 @@ func (p *IstioGatewayProvider) ReconcileAuthorizationPolicies(ctx context.Contex
 				return ok && gc.Spec.ControllerName == "istio.io/gateway-controller"
 			})
 		})
+		if controller.IsPaused(gateway) || lo.ContainsBy(paths, controller.PathPaused) {
+			continue
+		}
 		if len(paths) > 0 {
 			p.createAuthorizationPolicy(ctx, topology, gateway, paths)
 			continue
 		}
-		p.deleteAuthorizationPolicy(ctx, topology, gateway.GetNamespace(), gateway.GetName(), gateway)
-	}
-}
-
-func (p *IstioGatewayProvider) DeleteAuthorizationPolicy(ctx context.Context, resourceEvents []controller.ResourceEvent, topology *machinery.Topology) {
-	for _, resourceEvent := range resourceEvents {
-		gateway := resourceEvent.OldObject
-		p.deleteAuthorizationPolicy(ctx, topology, gateway.GetNamespace(), gateway.GetName(), nil)
+		p.deleteAuthorizationPolicy(ctx, topology, gateway)
 	}
 }
 
@@ -76,7 +69,7 @@ func (p *IstioGatewayProvider) createAuthorizationPolicy(ctx context.Context, to
 			Kind:       IstioAuthorizationPolicyKind.Kind,
 		},
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      gateway.GetName(),
+			Name:      controller.GeneratedNameFor(gateway),
 			Namespace: gateway.GetNamespace(),
 		},
 		Spec: istioapiv1.AuthorizationPolicy{
@@ -106,57 +99,41 @@ func (p *IstioGatewayProvider) createAuthorizationPolicy(ctx context.Context, to
 		rules := istioAuthorizationPolicyRulesFromHTTPRouteRule(routeRule.HTTPRouteRule, hostnames)
 		desiredAuthorizationPolicy.Spec.Rules = append(desiredAuthorizationPolicy.Spec.Rules, rules...)
 	}
+	hash, err := effectivePolicyHashForPaths(ctx, authEffectivePoliciesKey, paths)
+	if err != nil {
+		logger.Error(err, "failed to hash effective policies")
+		return
+	}
+	controller.SetEffectivePolicyHash(desiredAuthorizationPolicy, hash)
+	controller.SetGeneratedResourceLabels(desiredAuthorizationPolicy, controller.GeneratedResourceLabels{ManagedBy: ControllerName, OwningTargetables: []machinery.Targetable{gateway}})
 
 	resource := p.Client.Resource(IstioAuthorizationPoliciesResource).Namespace(gateway.GetNamespace())
 
 	obj, found := lo.Find(topology.Objects().Children(gateway), func(o machinery.Object) bool {
-		return o.GroupVersionKind().GroupKind() == IstioAuthorizationPolicyKind && o.GetNamespace() == gateway.GetNamespace() && o.GetName() == gateway.GetName()
+		return o.GroupVersionKind().GroupKind() == IstioAuthorizationPolicyKind && o.GetNamespace() == gateway.GetNamespace() && o.GetName() == controller.GeneratedNameFor(gateway)
 	})
 
-	if !found {
-		o, _ := controller.Destruct(desiredAuthorizationPolicy)
-		_, err := resource.Create(ctx, o, metav1.CreateOptions{})
-		if err != nil {
-			logger.Error(err, "failed to create AuthorizationPolicy")
-		}
-		return
-	}
-
-	authorizationPolicy := obj.(*controller.RuntimeObject).Object.(*istiov1.AuthorizationPolicy)
-
-	if authorizationPolicy.Spec.Action == desiredAuthorizationPolicy.Spec.Action &&
-		authorizationPolicy.Spec.GetProvider() != nil &&
-		authorizationPolicy.Spec.GetProvider().Name == desiredAuthorizationPolicy.Spec.GetProvider().Name &&
-		len(authorizationPolicy.Spec.Rules) == len(desiredAuthorizationPolicy.Spec.Rules) &&
-		lo.Every(authorizationPolicy.Spec.Rules, desiredAuthorizationPolicy.Spec.Rules) {
+	if found && controller.IsUnmanaged(obj) {
 		return
 	}
 
-	authorizationPolicy.Spec.Action = desiredAuthorizationPolicy.Spec.Action
-	authorizationPolicy.Spec.ActionDetail = desiredAuthorizationPolicy.Spec.ActionDetail
-	authorizationPolicy.Spec.Rules = desiredAuthorizationPolicy.Spec.Rules
-	o, _ := controller.Destruct(authorizationPolicy)
-	_, err := resource.Update(ctx, o, metav1.UpdateOptions{})
-	if err != nil {
-		logger.Error(err, "failed to update AuthorizationPolicy")
+	if _, err := controller.ApplyObject(ctx, resource, desiredAuthorizationPolicy, controller.WithFieldManager(ControllerName), controller.WithForceOwnership()); err != nil {
+		logger.Error(err, "failed to apply AuthorizationPolicy")
 	}
 }
 
-func (p *IstioGatewayProvider) deleteAuthorizationPolicy(ctx context.Context, topology *machinery.Topology, namespace, name string, parent machinery.Targetable) {
-	var objs []machinery.Object
-	if parent != nil {
-		objs = topology.Objects().Children(parent)
-	} else {
-		objs = topology.Objects().Items()
-	}
-	_, found := lo.Find(objs, func(o machinery.Object) bool {
-		return o.GroupVersionKind().GroupKind() == IstioAuthorizationPolicyKind && o.GetNamespace() == namespace && o.GetName() == name
+func (p *IstioGatewayProvider) deleteAuthorizationPolicy(ctx context.Context, topology *machinery.Topology, gateway machinery.Targetable) {
+	obj, found := lo.Find(topology.Objects().Children(gateway), func(o machinery.Object) bool {
+		return o.GroupVersionKind().GroupKind() == IstioAuthorizationPolicyKind && o.GetNamespace() == gateway.GetNamespace() && o.GetName() == controller.GeneratedNameFor(gateway)
 	})
-	if !found {
+	if !found || controller.IsUnmanaged(obj) {
+		return
+	}
+	if controller.IsUnmanagedState(ctx) {
 		return
 	}
-	resource := p.Client.Resource(IstioAuthorizationPoliciesResource).Namespace(namespace)
-	err := resource.Delete(ctx, name, metav1.DeleteOptions{})
+	resource := p.Client.Resource(IstioAuthorizationPoliciesResource).Namespace(gateway.GetNamespace())
+	err := resource.Delete(ctx, obj.GetName(), metav1.DeleteOptions{})
 	if err != nil {
 		controller.LoggerFromContext(ctx).Error(err, "failed to delete AuthorizationPolicy")
 	}
@@ -292,38 +269,3 @@ func hostSubsetOf(superset gwapiv1.Hostname) func(gwapiv1.Hostname, int) bool {
 		return hostname == superset
 	}
 }
-
-func LinkGatewayToIstioAuthorizationPolicyFunc(objs controller.Store) machinery.LinkFunc {
-	gateways := lo.Map(objs.FilterByGroupKind(controller.GatewayKind), controller.ObjectAs[*gwapiv1.Gateway])
-
-	return machinery.LinkFunc{
-		From: controller.GatewayKind,
-		To:   IstioAuthorizationPolicyKind,
-		Func: func(child machinery.Object) []machinery.Object {
-			o := child.(*controller.RuntimeObject)
-			ap := o.Object.(*istiov1.AuthorizationPolicy)
-			refs := ap.Spec.TargetRefs
-			if ref := ap.Spec.TargetRef; ref != nil {
-				refs = append(refs, ref)
-			}
-			refs = lo.Filter(refs, func(ref *istiov1beta1.PolicyTargetReference, _ int) bool {
-				return ref.Group == gwapiv1.GroupName && ref.Kind == controller.GatewayKind.Kind
-			})
-			if len(refs) == 0 {
-				return nil
-			}
-			gateway, ok := lo.Find(gateways, func(g *gwapiv1.Gateway) bool {
-				if g.GetNamespace() != ap.GetNamespace() {
-					return false
-				}
-				return lo.ContainsBy(refs, func(ref *istiov1beta1.PolicyTargetReference) bool {
-					return ref.Name == g.GetName()
-				})
-			})
-			if ok {
-				return []machinery.Object{&machinery.Gateway{Gateway: gateway}}
-			}
-			return nil
-		},
-	}
-}
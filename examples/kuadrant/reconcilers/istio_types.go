@@ -0,0 +1,138 @@
+package reconcilers
+
+import (
+	"github.com/samber/lo"
+	istiov1beta1 "istio.io/api/type/v1beta1"
+	istioextensionsv1alpha1 "istio.io/client-go/pkg/apis/extensions/v1alpha1"
+	istionetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiov1 "istio.io/client-go/pkg/apis/security/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+)
+
+// istioGatewayNameLabel is the label set by Istio to the pods of the deployments of the gateways
+const istioGatewayNameLabel = "gateway.networking.k8s.io/gateway-name"
+
+var (
+	IstioEnvoyFilterKind      = schema.GroupKind{Group: istionetworkingv1alpha3.SchemeGroupVersion.Group, Kind: "EnvoyFilter"}
+	IstioEnvoyFiltersResource = istionetworkingv1alpha3.SchemeGroupVersion.WithResource("envoyfilters")
+	IstioWasmPluginKind       = schema.GroupKind{Group: istioextensionsv1alpha1.SchemeGroupVersion.Group, Kind: "WasmPlugin"}
+	IstioWasmPluginsResource  = istioextensionsv1alpha1.SchemeGroupVersion.WithResource("wasmplugins")
+)
+
+// IstioAuthorizationPolicy is an Istio AuthorizationPolicy that is a node of the topology.
+type IstioAuthorizationPolicy struct {
+	*istiov1.AuthorizationPolicy
+}
+
+var _ machinery.Object = &IstioAuthorizationPolicy{}
+
+func (p *IstioAuthorizationPolicy) GetURL() string {
+	return machinery.UrlFromObject(p)
+}
+
+// IstioEnvoyFilter is an Istio EnvoyFilter that is a node of the topology.
+type IstioEnvoyFilter struct {
+	*istionetworkingv1alpha3.EnvoyFilter
+}
+
+var _ machinery.Object = &IstioEnvoyFilter{}
+
+func (f *IstioEnvoyFilter) GetURL() string {
+	return machinery.UrlFromObject(f)
+}
+
+// IstioWasmPlugin is an Istio WasmPlugin that is a node of the topology.
+type IstioWasmPlugin struct {
+	*istioextensionsv1alpha1.WasmPlugin
+}
+
+var _ machinery.Object = &IstioWasmPlugin{}
+
+func (p *IstioWasmPlugin) GetURL() string {
+	return machinery.UrlFromObject(p)
+}
+
+// LinkGatewayToIstioAuthorizationPolicyFunc links the gateways to the AuthorizationPolicies that target them, either
+// with a target reference or with a selector of the pods of the gateway.
+func LinkGatewayToIstioAuthorizationPolicyFunc(objs controller.Store) machinery.LinkFunc {
+	return linkGatewayToIstioObjectFunc(objs, IstioAuthorizationPolicyKind, func(p *IstioAuthorizationPolicy) ([]*istiov1beta1.PolicyTargetReference, map[string]string) {
+		refs := p.Spec.TargetRefs
+		if ref := p.Spec.TargetRef; ref != nil {
+			refs = append(refs, ref)
+		}
+		return refs, p.Spec.GetSelector().GetMatchLabels()
+	})
+}
+
+// LinkGatewayToIstioEnvoyFilterFunc links the gateways to the EnvoyFilters that target them, either with a target
+// reference or with a selector of the pods of the gateway.
+func LinkGatewayToIstioEnvoyFilterFunc(objs controller.Store) machinery.LinkFunc {
+	return linkGatewayToIstioObjectFunc(objs, IstioEnvoyFilterKind, func(f *IstioEnvoyFilter) ([]*istiov1beta1.PolicyTargetReference, map[string]string) {
+		return f.Spec.TargetRefs, f.Spec.GetWorkloadSelector().GetLabels()
+	})
+}
+
+// LinkGatewayToIstioWasmPluginFunc links the gateways to the WasmPlugins that target them, either with a target
+// reference or with a selector of the pods of the gateway.
+func LinkGatewayToIstioWasmPluginFunc(objs controller.Store) machinery.LinkFunc {
+	return linkGatewayToIstioObjectFunc(objs, IstioWasmPluginKind, func(p *IstioWasmPlugin) ([]*istiov1beta1.PolicyTargetReference, map[string]string) {
+		refs := p.Spec.TargetRefs
+		if ref := p.Spec.TargetRef; ref != nil {
+			refs = append(refs, ref)
+		}
+		return refs, p.Spec.GetSelector().GetMatchLabels()
+	})
+}
+
+// linkGatewayToIstioObjectFunc links the gateways to the Istio objects of a kind in the same namespace that target
+// them, given the target references and the selector of the pods of the objects.
+// The objects must be wrapped into their topology types (see WrapIstioObject).
+func linkGatewayToIstioObjectFunc[T machinery.Object](objs controller.Store, kind schema.GroupKind, targets func(T) ([]*istiov1beta1.PolicyTargetReference, map[string]string)) machinery.LinkFunc {
+	gateways := lo.Map(objs.FilterByGroupKind(controller.GatewayKind), controller.ObjectAs[*gwapiv1.Gateway])
+
+	return machinery.LinkFunc{
+		From: controller.GatewayKind,
+		To:   kind,
+		Func: func(child machinery.Object) []machinery.Object {
+			obj, ok := child.(T)
+			if !ok {
+				return nil
+			}
+			refs, selector := targets(obj)
+			refs = lo.Filter(refs, func(ref *istiov1beta1.PolicyTargetReference, _ int) bool {
+				return ref.Group == gwapiv1.GroupName && ref.Kind == controller.GatewayKind.Kind
+			})
+			return lo.FilterMap(gateways, func(g *gwapiv1.Gateway, _ int) (machinery.Object, bool) {
+				if g.GetNamespace() != child.GetNamespace() {
+					return nil, false
+				}
+				targeted := lo.ContainsBy(refs, func(ref *istiov1beta1.PolicyTargetReference) bool {
+					return ref.Name == g.GetName()
+				})
+				selected := len(refs) == 0 && selector[istioGatewayNameLabel] == g.GetName()
+				return &machinery.Gateway{Gateway: g}, targeted || selected
+			})
+		},
+	}
+}
+
+// WrapIstioObject wraps the Istio AuthorizationPolicies, EnvoyFilters and WasmPlugins into their topology types.
+// See controller.WithObjectWrappers.
+func WrapIstioObject(obj controller.Object) (machinery.Object, bool) {
+	switch o := obj.(type) {
+	case *istiov1.AuthorizationPolicy:
+		return &IstioAuthorizationPolicy{AuthorizationPolicy: o}, true
+	case *istionetworkingv1alpha3.EnvoyFilter:
+		return &IstioEnvoyFilter{EnvoyFilter: o}, true
+	case *istioextensionsv1alpha1.WasmPlugin:
+		return &IstioWasmPlugin{WasmPlugin: o}, true
+	default:
+		return nil, false
+	}
+}
+
+var _ controller.ObjectWrapperFunc = WrapIstioObject
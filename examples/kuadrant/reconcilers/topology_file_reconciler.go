@@ -21,7 +21,7 @@ func (r *TopologyFileReconciler) Reconcile(ctx context.Context, _ []controller.R
 		return
 	}
 	defer file.Close()
-	_, err = file.WriteString(topology.ToDot())
+	_, err = file.WriteString(topology.ToGraphviz())
 	if err != nil {
 		logger.Error(err, "failed to write to topology file")
 		return
@@ -0,0 +1,217 @@
+package reconcilers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+
+	"github.com/kuadrant/policy-machinery/controller"
+	"github.com/kuadrant/policy-machinery/machinery"
+
+	kuadrantv1beta3 "github.com/kuadrant/policy-machinery/examples/kuadrant/apis/v1beta3"
+)
+
+const (
+	WasmConfigLabel        = "kuadrant.io/wasm-config"
+	WasmConfigGatewayLabel = "kuadrant.io/gateway"
+	WasmConfigDataKey      = "config.json"
+)
+
+// WasmConfigLabelSelector selects the ConfigMaps that store the wasm plugin configs generated by the controller
+var WasmConfigLabelSelector = fmt.Sprintf("%s=true", WasmConfigLabel)
+
+// WasmConfig is the configuration of the data-plane wasm plugin for a gateway, compiled from the effective
+// RateLimitPolicies of all paths from the gateway to the HTTPRouteRules
+type WasmConfig struct {
+	Policies []WasmPolicy `json:"policies"`
+}
+
+type WasmPolicy struct {
+	Name      string                           `json:"name"`
+	Hostnames []string                         `json:"hostnames"`
+	Limits    map[string]kuadrantv1beta3.Limit `json:"limits"`
+}
+
+// WasmConfigReconciler projects the effective RateLimitPolicies into one wasm plugin config per gateway and publishes
+// it in a ConfigMap. The ConfigMap is annotated with the hash of the config, so it is only written when the effective
+// policies change.
+type WasmConfigReconciler struct {
+	Client *dynamic.DynamicClient
+}
+
+func (r *WasmConfigReconciler) Reconcile(ctx context.Context, _ []controller.ResourceEvent, topology *machinery.Topology) {
+	logger := controller.LoggerFromContext(ctx).WithName("wasm config")
+	ctx = controller.LoggerIntoContext(ctx, logger)
+
+	rateLimitPaths := pathsFromContext(ctx, rateLimitPathsKey)
+	effectivePolicies := effectivePoliciesFromContext(ctx, rateLimitEffectivePoliciesKey)
+
+	gateways := machinery.TargetablesOfType[*machinery.Gateway](topology)
+
+	for _, gateway := range gateways {
+		paths := lo.Filter(rateLimitPaths, func(path []machinery.Targetable, _ int) bool {
+			return len(path) == 4 && path[0].GetURL() == gateway.GetURL()
+		})
+
+		existingConfigMap, found := lo.Find(topology.Objects().Children(gateway), func(o machinery.Object) bool {
+			return o.GroupVersionKind().GroupKind() == controller.ConfigMapKind && o.GetNamespace() == gateway.GetNamespace() && o.GetName() == wasmConfigMapName(gateway.GetName())
+		})
+
+		if controller.IsPaused(gateway) || lo.ContainsBy(paths, controller.PathPaused) {
+			logger.V(1).Info("skipping paused gateway", "gateway", gateway.GetURL())
+			continue
+		}
+
+		if found && controller.IsUnmanaged(existingConfigMap) {
+			logger.V(1).Info("skipping unmanaged wasm config", "name", existingConfigMap.GetName(), "namespace", existingConfigMap.GetNamespace())
+			continue
+		}
+
+		if len(paths) == 0 {
+			if found {
+				r.deleteConfigMap(ctx, existingConfigMap.GetNamespace(), existingConfigMap.GetName())
+			}
+			continue
+		}
+
+		wasmConfig := buildWasmConfig(paths, effectivePolicies)
+		hash, err := controller.HashEffectivePolicy(wasmConfig)
+		if err != nil {
+			logger.Error(err, "failed to hash wasm config", "gateway", gateway.GetURL())
+			continue
+		}
+
+		if found {
+			if currentHash, _ := controller.EffectivePolicyHash(existingConfigMap); currentHash == hash {
+				continue
+			}
+		}
+
+		r.applyConfigMap(ctx, gateway, wasmConfig, hash, owningPolicies(paths))
+	}
+}
+
+func (r *WasmConfigReconciler) applyConfigMap(ctx context.Context, gateway machinery.Targetable, wasmConfig WasmConfig, hash string, owners []machinery.Policy) {
+	logger := controller.LoggerFromContext(ctx)
+
+	data, err := json.Marshal(wasmConfig)
+	if err != nil {
+		logger.Error(err, "failed to marshal wasm config")
+		return
+	}
+
+	configMap := &core.ConfigMap{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: core.SchemeGroupVersion.String(),
+			Kind:       controller.ConfigMapKind.Kind,
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      wasmConfigMapName(gateway.GetName()),
+			Namespace: gateway.GetNamespace(),
+			Labels: map[string]string{
+				WasmConfigLabel:        "true",
+				WasmConfigGatewayLabel: gateway.GetName(),
+			},
+		},
+		Data: map[string]string{
+			WasmConfigDataKey: string(data),
+		},
+	}
+	controller.SetEffectivePolicyHash(configMap, hash)
+	controller.SetGeneratedResourceLabels(configMap, controller.GeneratedResourceLabels{ManagedBy: ControllerName, OwningPolicies: owners})
+
+	resource := r.Client.Resource(controller.ConfigMapsResource).Namespace(gateway.GetNamespace())
+	if _, err := controller.ApplyObject(ctx, resource, configMap, controller.WithFieldManager(ControllerName), controller.WithForceOwnership()); err != nil {
+		logger.Error(err, "failed to apply wasm config")
+	}
+}
+
+func (r *WasmConfigReconciler) deleteConfigMap(ctx context.Context, namespace, name string) {
+	if controller.IsUnmanagedState(ctx) {
+		return
+	}
+	resource := r.Client.Resource(controller.ConfigMapsResource).Namespace(namespace)
+	if err := resource.Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+		controller.LoggerFromContext(ctx).Error(err, "failed to delete wasm config")
+	}
+}
+
+// buildWasmConfig projects the effective policies of a set of Gateway -> Listener -> HTTPRoute -> HTTPRouteRule
+// paths into a wasm plugin config. The policies are sorted by path so the config is stable across reconciliations.
+func buildWasmConfig(paths [][]machinery.Targetable, effectivePolicies map[string]machinery.Policy) WasmConfig {
+	wasmConfig := WasmConfig{}
+
+	for _, path := range paths {
+		effectivePolicy, ok := effectivePolicies[machinery.PathID(path)].(*kuadrantv1beta3.RateLimitPolicy)
+		if !ok {
+			continue
+		}
+
+		listener := path[1].(*machinery.Listener)
+		httpRoute := path[2].(*machinery.HTTPRoute)
+		hostname := ptr.Deref(listener.Hostname, gwapiv1.Hostname("*"))
+		hostnames := []gwapiv1.Hostname{hostname}
+		if len(httpRoute.Spec.Hostnames) > 0 {
+			hostnames = lo.Filter(httpRoute.Spec.Hostnames, hostSubsetOf(hostname))
+		}
+
+		wasmConfig.Policies = append(wasmConfig.Policies, WasmPolicy{
+			Name:      machinery.PathID(path),
+			Hostnames: lo.Map(hostnames, func(h gwapiv1.Hostname, _ int) string { return string(h) }),
+			Limits:    effectivePolicy.Spec.Proper().Limits,
+		})
+	}
+
+	sort.Slice(wasmConfig.Policies, func(i, j int) bool {
+		return wasmConfig.Policies[i].Name < wasmConfig.Policies[j].Name
+	})
+
+	return wasmConfig
+}
+
+func wasmConfigMapName(gatewayName string) string {
+	return controller.GeneratedName("kuadrant-wasm", gatewayName)
+}
+
+func LinkGatewayToWasmConfigMapFunc(objs controller.Store) machinery.LinkFunc {
+	gateways := lo.Map(objs.FilterByGroupKind(controller.GatewayKind), controller.ObjectAs[*gwapiv1.Gateway])
+
+	return machinery.LinkFunc{
+		From: controller.GatewayKind,
+		To:   controller.ConfigMapKind,
+		Func: func(child machinery.Object) []machinery.Object {
+			o := child.(*controller.RuntimeObject)
+			gatewayName, ok := o.GetLabels()[WasmConfigGatewayLabel]
+			if !ok {
+				return nil
+			}
+			gateway, ok := lo.Find(gateways, func(g *gwapiv1.Gateway) bool {
+				return g.GetNamespace() == o.GetNamespace() && g.GetName() == gatewayName
+			})
+			if ok {
+				return []machinery.Object{&machinery.Gateway{Gateway: gateway}}
+			}
+			return nil
+		},
+	}
+}
+
+// owningPolicies returns the RateLimitPolicies attached to the targetables of a set of paths, i.e. the policies out of
+// which the wasm config of the paths is generated.
+func owningPolicies(paths [][]machinery.Targetable) []machinery.Policy {
+	policies := lo.FlatMap(lo.Flatten(paths), func(targetable machinery.Targetable, _ int) []machinery.Policy {
+		return lo.Filter(targetable.Policies(), func(p machinery.Policy, _ int) bool {
+			_, ok := p.(*kuadrantv1beta3.RateLimitPolicy)
+			return ok
+		})
+	})
+	return lo.UniqBy(policies, func(p machinery.Policy) string { return p.GetURL() })
+}
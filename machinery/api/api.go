@@ -0,0 +1,92 @@
+// Package api is the read-only public API of the topology model of policy machinery: the interfaces of the objects,
+// targetables and policies, and read-only views of a topology.
+//
+// The package is covered by semantic versioning stability guarantees, independently from the implementation in the
+// machinery package: within a major version, the types of this package are only ever extended in backward compatible
+// ways, i.e. no type or function is removed or renamed, and no method is added to the interfaces that downstream
+// projects are expected to implement (Object, Targetable, Policy, PolicyTargetReference). Methods can be added to
+// the views, which are only implemented by the library.
+//
+// The types of the machinery package with the same names are aliases of the types of this package, so the
+// implementation and the API cannot drift apart. Downstream projects that only read the model should depend on this
+// package rather than on the machinery package, whose exported functions and types can change between minor versions.
+package api
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Object is a node of the topology, identified by its kind, namespace and name.
+type Object interface {
+	schema.ObjectKind
+
+	GetNamespace() string
+	GetName() string
+	// GetURL returns a string that uniquely identifies the object in the topology, in the form
+	// <lowercase kind>.<group>:<namespace>/<name>.
+	GetURL() string
+}
+
+// Targetable is an object that can be targeted by policies.
+type Targetable interface {
+	Object
+
+	SetPolicies([]Policy)
+	Policies() []Policy
+}
+
+// Policy targets objects and can be merged with another Policy based on a given MergeStrategy.
+type Policy interface {
+	Object
+
+	GetTargetRefs() []PolicyTargetReference
+	GetMergeStrategy() MergeStrategy
+
+	Merge(Policy) Policy
+}
+
+// PolicyTargetReference is a generic interface for all kinds of Gateway API policy target references.
+// It implements the Object interface for the referent.
+type PolicyTargetReference interface {
+	Object
+}
+
+// MergeStrategy is a function that merges two Policy objects into a new Policy object.
+type MergeStrategy func(Policy, Policy) Policy
+
+// FilterFunc selects the objects of a collection of a topology.
+type FilterFunc func(Object) bool
+
+// Collection is a read-only view of the nodes of a kind of a topology, e.g. the targetables or the policies.
+type Collection[T Object] interface {
+	// Items returns the nodes in the collection that match all the filters.
+	Items(filters ...FilterFunc) []T
+	// Get returns the node in the collection with a given URL.
+	Get(url string) (T, bool)
+	// Roots returns the nodes in the collection without parents.
+	Roots() []T
+	// Parents returns the nodes in the collection that are parents of a given object.
+	Parents(item Object) []T
+	// Children returns the nodes in the collection that are children of a given object.
+	Children(item Object) []T
+	// Paths returns all paths between two objects, made of nodes in the collection.
+	Paths(from, to Object) [][]T
+	// AncestorPaths returns all paths from the roots of the collection to a given object.
+	AncestorPaths(item Object) [][]T
+	// Ancestors returns the nodes in the collection in any path from the roots to a given object.
+	Ancestors(item Object) []T
+}
+
+// TopologyView is a read-only view of a topology.
+type TopologyView interface {
+	// Targetables returns the targetables of the topology.
+	Targetables() Collection[Targetable]
+	// Policies returns the policies of the topology.
+	Policies() Collection[Policy]
+	// Objects returns the objects of the topology that are neither targetables nor policies.
+	Objects() Collection[Object]
+	// Targets returns the targetables targeted by a policy of the topology.
+	Targets(policy Policy) ([]Targetable, error)
+	// ToDot returns the topology as a Graphviz DOT graph.
+	ToDot() string
+}
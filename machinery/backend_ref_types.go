@@ -0,0 +1,185 @@
+package machinery
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// BackendRefType is the type of a reference from an HTTP route rule to a backend.
+type BackendRefType string
+
+const (
+	// BackendRefTypeBackend is a backendRef of a rule that receives traffic.
+	BackendRefTypeBackend BackendRefType = "Backend"
+	// BackendRefTypeZeroWeightBackend is a backendRef of a rule with weight 0, thus that receives no traffic.
+	BackendRefTypeZeroWeightBackend BackendRefType = "ZeroWeightBackend"
+	// BackendRefTypeMirror is the backendRef of a RequestMirror filter, of the rule or of one of its backendRefs.
+	BackendRefTypeMirror BackendRefType = "Mirror"
+)
+
+const (
+	// BackendRefTypesEdgeAttribute is the attribute of the edges from HTTP route rules (or routes) to services that
+	// holds the sorted, comma-separated types of the backend references between them (see WithBackendRefTypes).
+	BackendRefTypesEdgeAttribute = "backendRefTypes"
+	// BackendRefWeightEdgeAttribute is the attribute of the edges from HTTP route rules (or routes) to services that
+	// holds the total weight of the backend references between them, other than mirrors (see WithBackendRefTypes).
+	BackendRefWeightEdgeAttribute = "weight"
+)
+
+// WithBackendRefTypes distinguishes the types of the backend references from HTTP routes and route rules to services
+// in a new Gateway API topology: the backends of RequestMirror filters, of the rules as well as of their backendRefs,
+// are linked in addition to the regular backends, and the edges hold the types of the backend references and their
+// weight as attributes (see BackendRefTypesEdgeAttribute, BackendRefWeightEdgeAttribute and Topology.EdgeAttributes).
+func WithBackendRefTypes() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.BackendRefTypes = true
+	}
+}
+
+// typedBackendRef is a backend reference of an HTTP route rule, of any type
+type typedBackendRef struct {
+	gwapiv1.BackendRef
+	Type BackendRefType
+}
+
+// typedHTTPBackendRefs returns the backend references of an HTTP route rule, including the ones of the RequestMirror
+// filters
+func typedHTTPBackendRefs(rule gwapiv1.HTTPRouteRule) []typedBackendRef {
+	var refs []typedBackendRef
+	mirrors := func(filters []gwapiv1.HTTPRouteFilter) {
+		for _, filter := range filters {
+			if filter.Type == gwapiv1.HTTPRouteFilterRequestMirror && filter.RequestMirror != nil {
+				refs = append(refs, typedBackendRef{BackendRef: gwapiv1.BackendRef{BackendObjectReference: filter.RequestMirror.BackendRef}, Type: BackendRefTypeMirror})
+			}
+		}
+	}
+	mirrors(rule.Filters)
+	for _, backendRef := range rule.BackendRefs {
+		refType := BackendRefTypeBackend
+		if ptr.Deref(backendRef.Weight, 1) == 0 {
+			refType = BackendRefTypeZeroWeightBackend
+		}
+		refs = append(refs, typedBackendRef{BackendRef: backendRef.BackendRef, Type: refType})
+		mirrors(backendRef.Filters)
+	}
+	return refs
+}
+
+// LinkHTTPRouteToServiceWithTypesFunc returns a link function that teaches a topology how to link Services from known
+// HTTPRoutes, based on the HTTPRoute's `backendRefs` fields and on the backendRefs of their RequestMirror filters.
+// The edges hold the types and the weight of the backend references as attributes.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkHTTPRouteToServiceWithTypesFunc(httpRoutes []*HTTPRoute, strict bool) LinkFunc {
+	return linkHTTPBackendRefsFunc(
+		schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		schema.GroupKind{Kind: "Service"},
+		lo.Map(httpRoutes, func(httpRoute *HTTPRoute, _ int) Object { return httpRoute }),
+		func(parent Object) ([]gwapiv1.HTTPRouteRule, string) {
+			httpRoute := parent.(*HTTPRoute)
+			return httpRoute.Spec.Rules, httpRoute.Namespace
+		},
+		serviceBackendRefFunc(strict),
+	)
+}
+
+// LinkHTTPRouteToServicePortWithTypesFunc returns a link function that teaches a topology how to link services ports
+// from known HTTPRoutes, based on the HTTPRoute's `backendRefs` fields and on the backendRefs of their RequestMirror
+// filters. The edges hold the types and the weight of the backend references as attributes.
+// The link function disregards backend references that do not specify a port number.
+func LinkHTTPRouteToServicePortWithTypesFunc(httpRoutes []*HTTPRoute) LinkFunc {
+	return linkHTTPBackendRefsFunc(
+		schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		schema.GroupKind{Kind: "ServicePort"},
+		lo.Map(httpRoutes, func(httpRoute *HTTPRoute, _ int) Object { return httpRoute }),
+		func(parent Object) ([]gwapiv1.HTTPRouteRule, string) {
+			httpRoute := parent.(*HTTPRoute)
+			return httpRoute.Spec.Rules, httpRoute.Namespace
+		},
+		servicePortBackendRef,
+	)
+}
+
+// LinkHTTPRouteRuleToServiceWithTypesFunc returns a link function that teaches a topology how to link Services from
+// known HTTPRouteRules, based on the HTTPRouteRule's `backendRefs` field and on the backendRefs of its RequestMirror
+// filters. The edges hold the types and the weight of the backend references as attributes.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkHTTPRouteRuleToServiceWithTypesFunc(httpRouteRules []*HTTPRouteRule, strict bool) LinkFunc {
+	return linkHTTPBackendRefsFunc(
+		schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		schema.GroupKind{Kind: "Service"},
+		lo.Map(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) Object { return httpRouteRule }),
+		func(parent Object) ([]gwapiv1.HTTPRouteRule, string) {
+			httpRouteRule := parent.(*HTTPRouteRule)
+			return []gwapiv1.HTTPRouteRule{*httpRouteRule.HTTPRouteRule}, httpRouteRule.HTTPRoute.Namespace
+		},
+		serviceBackendRefFunc(strict),
+	)
+}
+
+// LinkHTTPRouteRuleToServicePortWithTypesFunc returns a link function that teaches a topology how to link services
+// ports from known HTTPRouteRules, based on the HTTPRouteRule's `backendRefs` field and on the backendRefs of its
+// RequestMirror filters. The edges hold the types and the weight of the backend references as attributes.
+// The link function disregards backend references that do not specify a port number.
+func LinkHTTPRouteRuleToServicePortWithTypesFunc(httpRouteRules []*HTTPRouteRule) LinkFunc {
+	return linkHTTPBackendRefsFunc(
+		schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		schema.GroupKind{Kind: "ServicePort"},
+		lo.Map(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) Object { return httpRouteRule }),
+		func(parent Object) ([]gwapiv1.HTTPRouteRule, string) {
+			httpRouteRule := parent.(*HTTPRouteRule)
+			return []gwapiv1.HTTPRouteRule{*httpRouteRule.HTTPRouteRule}, httpRouteRule.HTTPRoute.Namespace
+		},
+		servicePortBackendRef,
+	)
+}
+
+// linkHTTPBackendRefsFunc returns a link function from parents with HTTP route rules to the backends referred in the
+// rules, with the types and the weight of the backend references as attributes of the edges
+func linkHTTPBackendRefsFunc(from, to schema.GroupKind, parents []Object, rulesOf func(parent Object) ([]gwapiv1.HTTPRouteRule, string), refersTo func(backendRef gwapiv1.BackendRef, child Object, defaultNamespace string) bool) LinkFunc {
+	backendRefs := func(parent, child Object) []typedBackendRef {
+		rules, namespace := rulesOf(parent)
+		return lo.Filter(lo.FlatMap(rules, func(rule gwapiv1.HTTPRouteRule, _ int) []typedBackendRef {
+			return typedHTTPBackendRefs(rule)
+		}), func(ref typedBackendRef, _ int) bool {
+			return refersTo(ref.BackendRef, child, namespace)
+		})
+	}
+	return LinkFunc{
+		From:         from,
+		To:           to,
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			return lo.Filter(parents, func(parent Object, _ int) bool {
+				return len(backendRefs(parent, child)) > 0
+			})
+		},
+		Attributes: func(parent, child Object) map[string]string {
+			refs := backendRefs(parent, child)
+			types := lo.Uniq(lo.Map(refs, func(ref typedBackendRef, _ int) string { return string(ref.Type) }))
+			sort.Strings(types)
+			attributes := map[string]string{BackendRefTypesEdgeAttribute: strings.Join(types, ",")}
+			if backends := lo.Filter(refs, func(ref typedBackendRef, _ int) bool { return ref.Type != BackendRefTypeMirror }); len(backends) > 0 {
+				weight := lo.SumBy(backends, func(ref typedBackendRef) int32 { return ptr.Deref(ref.Weight, 1) })
+				attributes[BackendRefWeightEdgeAttribute] = strconv.Itoa(int(weight))
+			}
+			return attributes
+		},
+	}
+}
+
+func serviceBackendRefFunc(strict bool) func(gwapiv1.BackendRef, Object, string) bool {
+	return func(backendRef gwapiv1.BackendRef, child Object, defaultNamespace string) bool {
+		return (!strict || backendRef.Port == nil) && backendRefEqualToService(backendRef, child.(*Service), defaultNamespace)
+	}
+}
+
+func servicePortBackendRef(backendRef gwapiv1.BackendRef, child Object, defaultNamespace string) bool {
+	servicePort := child.(*ServicePort)
+	return backendRef.Port != nil && int32(*backendRef.Port) == servicePort.Port && backendRefEqualToService(backendRef, servicePort.Service, defaultNamespace)
+}
@@ -0,0 +1,86 @@
+//go:build unit
+
+package machinery
+
+import (
+	"reflect"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestGatewayAPITopologyWithBackendRefTypes(t *testing.T) {
+	service := func(name string) *core.Service {
+		return BuildService(func(s *core.Service) { s.Name = name })
+	}
+	mirror := func(name string) gwapiv1.HTTPRouteFilter {
+		return gwapiv1.HTTPRouteFilter{
+			Type:          gwapiv1.HTTPRouteFilterRequestMirror,
+			RequestMirror: &gwapiv1.HTTPRequestMirrorFilter{BackendRef: gwapiv1.BackendObjectReference{Name: gwapiv1.ObjectName(name)}},
+		}
+	}
+	httpRoute := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.Rules = []gwapiv1.HTTPRouteRule{
+			{
+				Filters: []gwapiv1.HTTPRouteFilter{mirror("service-a")},
+				BackendRefs: []gwapiv1.HTTPBackendRef{
+					func() gwapiv1.HTTPBackendRef {
+						ref := BuildHTTPBackendRef(func(ref *gwapiv1.BackendObjectReference) { ref.Name = "service-a" })
+						ref.Weight = ptr.To(int32(3))
+						return ref
+					}(),
+					func() gwapiv1.HTTPBackendRef {
+						ref := BuildHTTPBackendRef(func(ref *gwapiv1.BackendObjectReference) { ref.Name = "service-b" })
+						ref.Weight = ptr.To(int32(0))
+						ref.Filters = []gwapiv1.HTTPRouteFilter{mirror("service-c")}
+						return ref
+					}(),
+				},
+			},
+		}
+	})
+
+	buildTopology := func(options ...GatewayAPITopologyOptionsFunc) *Topology {
+		return NewGatewayAPITopology(append([]GatewayAPITopologyOptionsFunc{
+			WithGatewayClasses(BuildGatewayClass()),
+			WithGateways(BuildGateway()),
+			WithHTTPRoutes(httpRoute),
+			WithServices(service("service-a"), service("service-b"), service("service-c")),
+			ExpandHTTPRouteRules(),
+		}, options...)...)
+	}
+
+	// without backendRef types, mirrors are not linked
+	topology := buildTopology()
+	rule := topology.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "HTTPRouteRule" })[0]
+	if children := topology.Targetables().Children(rule); len(children) != 2 {
+		t.Errorf("expected 2 services linked to the rule, got %d", len(children))
+	}
+	if _, ok := topology.EdgeAttributes(rule, topology.Targetables().Children(rule)[0]); ok {
+		t.Error("expected no edge attributes without backendRef types")
+	}
+
+	topology = buildTopology(WithBackendRefTypes())
+	rule = topology.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "HTTPRouteRule" })[0]
+	children := topology.Targetables().Children(rule)
+	if len(children) != 3 {
+		t.Fatalf("expected 3 services linked to the rule, got %d", len(children))
+	}
+	expected := map[string]map[string]string{
+		"service-a": {BackendRefTypesEdgeAttribute: "Backend,Mirror", BackendRefWeightEdgeAttribute: "3"},
+		"service-b": {BackendRefTypesEdgeAttribute: "ZeroWeightBackend", BackendRefWeightEdgeAttribute: "0"},
+		"service-c": {BackendRefTypesEdgeAttribute: "Mirror"},
+	}
+	for _, child := range children {
+		attributes, ok := topology.EdgeAttributes(rule, child)
+		if !ok {
+			t.Errorf("expected edge attributes for %s", child.GetName())
+			continue
+		}
+		if !reflect.DeepEqual(attributes, expected[child.GetName()]) {
+			t.Errorf("expected edge attributes %v for %s, got %v", expected[child.GetName()], child.GetName(), attributes)
+		}
+	}
+}
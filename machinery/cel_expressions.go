@@ -0,0 +1,78 @@
+package machinery
+
+import (
+	"fmt"
+
+	"github.com/google/cel-go/cel"
+)
+
+// CELVariables declares the variables of the nodes of a topology (see NodeVariables) in a CEL environment, as dynamic
+// values:
+//   - self: the targetable;
+//   - ancestors: the targetables from which the targetable can be reached;
+//   - policies: the policies attached to the targetable;
+//   - effectivePolicies: the effective policies of the paths from the roots of the topology to the targetable.
+func CELVariables() []cel.EnvOption {
+	return []cel.EnvOption{
+		cel.Variable("self", cel.DynType),
+		cel.Variable("ancestors", cel.ListType(cel.DynType)),
+		cel.Variable("policies", cel.ListType(cel.DynType)),
+		cel.Variable("effectivePolicies", cel.ListType(cel.DynType)),
+	}
+}
+
+// CELExpressionCompiler is an ExpressionCompiler of CEL expressions, whose environment declares the variables of the
+// nodes of a topology (see CELVariables). The compiled expressions are CEL programs; wrap the compiler in an
+// ExpressionCache to compile each expression once (see NewCELExpressionCache).
+type CELExpressionCompiler struct {
+	env *cel.Env
+}
+
+var _ ExpressionCompiler = &CELExpressionCompiler{}
+
+// NewCELExpressionCompiler returns a compiler of CEL expressions evaluated against the variables of the nodes of a
+// topology. Additional options of the CEL environment, e.g. extension libraries, can be given.
+func NewCELExpressionCompiler(options ...cel.EnvOption) (*CELExpressionCompiler, error) {
+	env, err := cel.NewEnv(append(CELVariables(), options...)...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the CEL environment: %w", err)
+	}
+	return &CELExpressionCompiler{env: env}, nil
+}
+
+// Compile parses and checks a CEL expression, and plans the CEL program that evaluates it.
+func (c *CELExpressionCompiler) Compile(expression string) (Expression, error) {
+	ast, issues := c.env.Compile(expression)
+	if issues.Err() != nil {
+		return nil, issues.Err()
+	}
+	program, err := c.env.Program(ast)
+	if err != nil {
+		return nil, err
+	}
+	return &celExpression{program: program}, nil
+}
+
+// NewCELExpressionCache returns a cache of the CEL programs compiled by a CEL expression compiler (see
+// NewExpressionCache).
+func NewCELExpressionCache(maxSize int, options ...cel.EnvOption) (*ExpressionCache, error) {
+	compiler, err := NewCELExpressionCompiler(options...)
+	if err != nil {
+		return nil, err
+	}
+	return NewExpressionCache(compiler, maxSize), nil
+}
+
+// celExpression is a compiled CEL expression.
+type celExpression struct {
+	program cel.Program
+}
+
+// Eval evaluates the CEL program, returning the native Go value of the result, e.g. a bool for predicates.
+func (e *celExpression) Eval(variables map[string]any) (any, error) {
+	result, _, err := e.program.Eval(variables)
+	if err != nil {
+		return nil, err
+	}
+	return result.Value(), nil
+}
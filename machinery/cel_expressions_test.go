@@ -0,0 +1,83 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestCELExpressions(t *testing.T) {
+	cache, err := NewCELExpressionCache(0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"},
+		}
+	})
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithGatewayAPITopologyPolicies(policy),
+	)
+	route := TargetablesOfType[*HTTPRoute](topology)[0]
+	effectivePolicy := func(topology *Topology, path []Targetable) (Policy, bool) {
+		return EffectivePolicyForPath[*TestPolicy](topology, path)
+	}
+	variables, err := NodeVariables(topology, route, effectivePolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	testCases := []struct {
+		expression string
+		expected   any
+	}{
+		{expression: `self.metadata.name`, expected: "my-http-route"},
+		{expression: `self.kind == "HTTPRoute"`, expected: true},
+		{expression: `ancestors.exists(a, a.kind == "Gateway" && a.metadata.name == "my-gateway")`, expected: true},
+		{expression: `size(policies) == 0`, expected: true},
+		{expression: `effectivePolicies.all(p, p.spec.targetRef.name == "my-gateway")`, expected: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expression, func(t *testing.T) {
+			result, err := cache.Eval(tc.expression, variables)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if result != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, result)
+			}
+		})
+	}
+	if cache.Len() != len(testCases) {
+		t.Errorf("expected the CEL programs cached, got %d", cache.Len())
+	}
+
+	// undeclared variables fail to compile
+	if _, err := cache.Compile(`route.metadata.name`); err == nil {
+		t.Error("expected a compilation error of an undeclared variable")
+	}
+	if _, err := WhenExpression(topology, cache, `self.metadata.name ==`, nil, nil); err == nil {
+		t.Error("expected a compilation error of an invalid predicate")
+	}
+
+	var errs []error
+	when, err := WhenExpression(topology, cache, `self.spec.missing == "x"`, nil, func(_ Object, err error) { errs = append(errs, err) })
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if matched := topology.Targetables().Items(when); len(matched) != 0 {
+		t.Errorf("expected no targetable to satisfy the predicate, got %v", lo.Map(matched, MapTargetableToURLFunc))
+	}
+	if len(errs) == 0 {
+		t.Error("expected the evaluation errors reported")
+	}
+}
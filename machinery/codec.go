@@ -0,0 +1,135 @@
+package machinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/structpb"
+	"sigs.k8s.io/yaml"
+)
+
+// Codec encodes and decodes exported representations of the topology and of the policies, such as topology snapshots
+// and effective policies, so exporters can pick a compact binary encoding over JSON.
+// Values are encoded according to their JSON tags, whatever the wire format.
+type Codec interface {
+	// ContentType is the media type of the encoded data, used to look up the codec.
+	ContentType() string
+	Marshal(v any) ([]byte, error)
+	Unmarshal(data []byte, v any) error
+}
+
+// Built-in codecs
+var (
+	JSONCodec     Codec = jsonCodec{}
+	YAMLCodec     Codec = yamlCodec{}
+	ProtobufCodec Codec = protobufCodec{}
+)
+
+var codecs = struct {
+	sync.RWMutex
+	byContentType map[string]Codec
+}{
+	byContentType: map[string]Codec{
+		JSONCodec.ContentType():     JSONCodec,
+		YAMLCodec.ContentType():     YAMLCodec,
+		ProtobufCodec.ContentType(): ProtobufCodec,
+	},
+}
+
+// RegisterCodec makes a codec available by content type, e.g. a CBOR codec backed by a third-party library, replacing
+// any codec previously registered for the same content type.
+func RegisterCodec(codec Codec) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.byContentType[codec.ContentType()] = codec
+}
+
+// CodecFor returns the codec registered for a content type.
+func CodecFor(contentType string) (Codec, error) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	codec, ok := codecs.byContentType[contentType]
+	if !ok {
+		return nil, fmt.Errorf("no codec registered for content type %q", contentType)
+	}
+	return codec, nil
+}
+
+// Encode returns the snapshot of the topology encoded with a given codec.
+func (t *Topology) Encode(codec Codec) ([]byte, error) {
+	return codec.Marshal(t.Snapshot())
+}
+
+// DecodeTopology decodes a snapshot of a topology with a given codec and rebuilds the topology out of it.
+func DecodeTopology(codec Codec, data []byte) (*Topology, error) {
+	snapshot := &TopologySnapshot{}
+	if err := codec.Unmarshal(data, snapshot); err != nil {
+		return nil, err
+	}
+	return RebuildFromSnapshot(snapshot), nil
+}
+
+type jsonCodec struct{}
+
+func (jsonCodec) ContentType() string {
+	return "application/json"
+}
+
+func (jsonCodec) Marshal(v any) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v any) error {
+	return json.Unmarshal(data, v)
+}
+
+type yamlCodec struct{}
+
+func (yamlCodec) ContentType() string {
+	return "application/yaml"
+}
+
+func (yamlCodec) Marshal(v any) ([]byte, error) {
+	return yaml.Marshal(v)
+}
+
+func (yamlCodec) Unmarshal(data []byte, v any) error {
+	return yaml.Unmarshal(data, v)
+}
+
+// protobufCodec encodes the JSON representation of values as a google.protobuf.Value message.
+type protobufCodec struct{}
+
+func (protobufCodec) ContentType() string {
+	return "application/x-protobuf"
+}
+
+func (protobufCodec) Marshal(v any) ([]byte, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var generic any
+	if err := json.Unmarshal(b, &generic); err != nil {
+		return nil, err
+	}
+	value, err := structpb.NewValue(generic)
+	if err != nil {
+		return nil, err
+	}
+	return proto.Marshal(value)
+}
+
+func (protobufCodec) Unmarshal(data []byte, v any) error {
+	value := &structpb.Value{}
+	if err := proto.Unmarshal(data, value); err != nil {
+		return err
+	}
+	b, err := json.Marshal(value.AsInterface())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
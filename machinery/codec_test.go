@@ -0,0 +1,80 @@
+//go:build unit
+
+package machinery
+
+import (
+	"reflect"
+	"testing"
+)
+
+type testCBORCodec struct {
+	Codec
+}
+
+func (testCBORCodec) ContentType() string {
+	return "application/cbor"
+}
+
+func TestTopologyCodecs(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(buildPolicy()),
+	)
+
+	for _, contentType := range []string{"application/json", "application/yaml", "application/x-protobuf"} {
+		t.Run(contentType, func(t *testing.T) {
+			codec, err := CodecFor(contentType)
+			if err != nil {
+				t.Fatalf("expected codec for %s: %v", contentType, err)
+			}
+			data, err := topology.Encode(codec)
+			if err != nil {
+				t.Fatalf("failed to encode topology: %v", err)
+			}
+			rebuilt, err := DecodeTopology(codec, data)
+			if err != nil {
+				t.Fatalf("failed to decode topology: %v", err)
+			}
+			if expected, actual := topology.ToGraphviz(), rebuilt.ToGraphviz(); expected != actual {
+				t.Errorf("expected decoded topology to be equal to the original one\nexpected:\n%s\ngot:\n%s", expected, actual)
+			}
+			if expected, actual := topology.Snapshot(), rebuilt.Snapshot(); !reflect.DeepEqual(expected, actual) {
+				t.Errorf("expected snapshots to be equal\nexpected:\n%v\ngot:\n%v", expected, actual)
+			}
+		})
+	}
+}
+
+func TestRegisterCodec(t *testing.T) {
+	if _, err := CodecFor("application/cbor"); err == nil {
+		t.Fatalf("expected no codec for application/cbor")
+	}
+
+	RegisterCodec(testCBORCodec{Codec: JSONCodec})
+	defer func() {
+		codecs.Lock()
+		defer codecs.Unlock()
+		delete(codecs.byContentType, "application/cbor")
+	}()
+
+	codec, err := CodecFor("application/cbor")
+	if err != nil {
+		t.Fatalf("expected codec for application/cbor: %v", err)
+	}
+	data, err := codec.Marshal(buildPolicy())
+	if err != nil {
+		t.Fatalf("failed to marshal policy: %v", err)
+	}
+	policy := &TestPolicy{}
+	if err := codec.Unmarshal(data, policy); err != nil {
+		t.Fatalf("failed to unmarshal policy: %v", err)
+	}
+	if policy.GetName() != "my-policy" {
+		t.Errorf("expected policy my-policy, got %s", policy.GetName())
+	}
+}
@@ -0,0 +1,80 @@
+package machinery
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Reasons of policy conflicts
+const (
+	// ConflictReasonOverridden means the policies cannot be merged, thus the winner overrides the losers entirely.
+	ConflictReasonOverridden = "Overridden"
+	// ConflictReasonMerged means the policies are merged with the merge strategy registered for their kind, and the
+	// winner takes precedence over the losers on conflicting rules.
+	ConflictReasonMerged = "Merged"
+)
+
+// Conflict is a set of policies of the same kind attached to the same targetable, e.g. two policies targeting the
+// same listener of a gateway. The winner is the policy that takes precedence, i.e. the oldest one, as per the
+// Gateway API conflict resolution rules; the losers are sorted by precedence too.
+type Conflict struct {
+	Target Targetable
+	Kind   schema.GroupKind
+	Winner Policy
+	Losers []Policy
+	Reason string
+}
+
+func (c Conflict) String() string {
+	return fmt.Sprintf("%d policies of kind %s target %s; %s wins (%s)", len(c.Losers)+1, c.Kind, c.Target.GetURL(), c.Winner.GetURL(), c.Reason)
+}
+
+// Conflicts returns the conflicts between policies of the same kind attached to the same targetables of the topology,
+// sorted by URL of the targetable and by kind. The list can be filtered by providing one or more filter functions,
+// applied to the policies.
+func (t *Topology) Conflicts(filters ...FilterFunc) []Conflict {
+	var conflicts []Conflict
+	for _, target := range sortedByURL(lo.Values(t.targetables)) {
+		policies := lo.Filter(target.Policies(), func(p Policy, _ int) bool {
+			return matchesAll(p, filters)
+		})
+		byKind := lo.GroupBy(policies, func(p Policy) schema.GroupKind {
+			return p.GroupVersionKind().GroupKind()
+		})
+		kinds := lo.Keys(byKind)
+		sort.Slice(kinds, func(i, j int) bool { return kinds[i].String() < kinds[j].String() })
+		for _, kind := range kinds {
+			policies := byKind[kind]
+			if len(policies) < 2 {
+				continue
+			}
+			sort.SliceStable(policies, func(i, j int) bool {
+				return policyOlderThan(policies[i], policies[j])
+			})
+			reason := ConflictReasonOverridden
+			if _, mergeable := MergeStrategies.Lookup(kind); mergeable {
+				reason = ConflictReasonMerged
+			}
+			conflicts = append(conflicts, Conflict{
+				Target: target,
+				Kind:   kind,
+				Winner: policies[0],
+				Losers: policies[1:],
+				Reason: reason,
+			})
+		}
+	}
+	return conflicts
+}
+
+// ConflictsOf returns the conflicts in which a given policy is involved, either as the winner or as a loser.
+func ConflictsOf(conflicts []Conflict, policy Policy) []Conflict {
+	return lo.Filter(conflicts, func(c Conflict, _ int) bool {
+		return c.Winner.GetURL() == policy.GetURL() || lo.ContainsBy(c.Losers, func(p Policy) bool {
+			return p.GetURL() == policy.GetURL()
+		})
+	})
+}
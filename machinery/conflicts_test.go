@@ -0,0 +1,82 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestTopologyConflicts(t *testing.T) {
+	now := time.Now()
+	listenerPolicy := func(name string, age time.Duration) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.CreationTimestamp = metav1.NewTime(now.Add(-age))
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "Gateway",
+					Name:  "my-gateway",
+				},
+				SectionName: ptr.To(gwapiv1.SectionName("my-listener")),
+			}
+		})
+	}
+	newer := listenerPolicy("newer-policy", time.Minute)
+	older := listenerPolicy("older-policy", time.Hour)
+	newest := listenerPolicy("newest-policy", time.Second)
+	servicePolicy := buildPolicy()
+
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(newer, older, newest, servicePolicy),
+	)
+
+	conflicts := topology.Conflicts()
+	if len(conflicts) != 1 {
+		t.Fatalf("expected 1 conflict, got %d: %v", len(conflicts), conflicts)
+	}
+	conflict := conflicts[0]
+	if conflict.Target.GetName() != "my-gateway#my-listener" {
+		t.Errorf("expected conflict on the listener, got %s", conflict.Target.GetURL())
+	}
+	if conflict.Kind != (schema.GroupKind{Group: "test", Kind: "TestPolicy"}) {
+		t.Errorf("expected conflict between TestPolicies, got %s", conflict.Kind)
+	}
+	if conflict.Winner != older {
+		t.Errorf("expected the oldest policy to win, got %s", conflict.Winner.GetName())
+	}
+	if len(conflict.Losers) != 2 || conflict.Losers[0] != newer || conflict.Losers[1] != newest {
+		t.Errorf("expected losers sorted by precedence, got %v", conflict.Losers)
+	}
+	if conflict.Reason != ConflictReasonOverridden {
+		t.Errorf("expected reason %s, got %s", ConflictReasonOverridden, conflict.Reason)
+	}
+
+	if c := ConflictsOf(conflicts, newest); len(c) != 1 {
+		t.Errorf("expected newest policy to be involved in 1 conflict, got %d", len(c))
+	}
+	if c := ConflictsOf(conflicts, servicePolicy); len(c) != 0 {
+		t.Errorf("expected service policy not to be involved in conflicts, got %d", len(c))
+	}
+
+	if c := topology.Conflicts(func(o Object) bool { return o.GetName() != "older-policy" }); len(c) != 1 || c[0].Winner != newer {
+		t.Errorf("expected filtered conflict won by the newer policy, got %v", c)
+	}
+
+	RegisterMergeStrategy(conflict.Kind, NoMergeStrategy)
+	defer MergeStrategies.Unregister(conflict.Kind)
+	if c := topology.Conflicts(); len(c) != 1 || c[0].Reason != ConflictReasonMerged {
+		t.Errorf("expected conflict of mergeable policies, got %v", c)
+	}
+}
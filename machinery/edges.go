@@ -0,0 +1,148 @@
+package machinery
+
+import (
+	"sort"
+
+	"github.com/emicklei/dot"
+	"github.com/samber/lo"
+)
+
+// relationshipEdgeAttribute is the attribute of the edges of the graph that holds the kind of relationship
+const relationshipEdgeAttribute = "relationship"
+
+// EdgeRelationship is the kind of relationship between the ends of an edge of a topology.
+type EdgeRelationship string
+
+const (
+	// EdgeRelationshipParentRef is the relationship between a parent and a child that refers to it, e.g. in the
+	// `parentRefs` of a route.
+	EdgeRelationshipParentRef EdgeRelationship = "parentRef"
+	// EdgeRelationshipBackendRef is the relationship between a route (or route rule) and a backend it refers to, e.g.
+	// in its `backendRefs`.
+	EdgeRelationshipBackendRef EdgeRelationship = "backendRef"
+	// EdgeRelationshipPolicyTargetRef is the relationship between a policy and a target it refers to in its target
+	// references, or between a target and a policy linked to it.
+	EdgeRelationshipPolicyTargetRef EdgeRelationship = "policyTargetRef"
+	// EdgeRelationshipOwnerRef is the relationship between an owner and an object that refers to it in its owner
+	// references, e.g. a generated resource.
+	EdgeRelationshipOwnerRef EdgeRelationship = "ownerRef"
+	// EdgeRelationshipSection is the relationship between an object and one of its sections, e.g. a gateway and one of
+	// its listeners.
+	EdgeRelationshipSection EdgeRelationship = "section"
+	// EdgeRelationshipEndpoint is the relationship between a service and one of its endpoint slices, or between an
+	// endpoint slice and a pod of one of its endpoints.
+	EdgeRelationshipEndpoint EdgeRelationship = "endpoint"
+	// EdgeRelationshipNamespace is the relationship between a namespace and a resource in it.
+	EdgeRelationshipNamespace EdgeRelationship = "namespace"
+	// EdgeRelationshipParametersRef is the relationship between a gateway class and the object with the configuration
+	// of the provider it refers to in its `parametersRef`.
+	EdgeRelationshipParametersRef EdgeRelationship = "parametersRef"
+)
+
+// Edge is a link between a parent and a child of a topology.
+type Edge struct {
+	// Name of the link that originated the edge, e.g. "Gateway -> HTTPRoute"
+	Name string
+	// Relationship between the ends of the edge, if known
+	Relationship EdgeRelationship
+	// From is the parent end of the edge
+	From Object
+	// To is the child end of the edge
+	To Object
+	// Attributes are the metadata of the edge (see LinkFunc)
+	Attributes map[string]string
+}
+
+// EdgePredicate is a predicate on an edge of the topology, to filter the edges of a topology (see Topology.Edges).
+type EdgePredicate func(Edge) bool
+
+// EdgesWithRelationship returns a filter of the edges of any of the given kinds of relationships.
+func EdgesWithRelationship(relationships ...EdgeRelationship) EdgePredicate {
+	return func(edge Edge) bool {
+		return lo.Contains(relationships, edge.Relationship)
+	}
+}
+
+// EdgesWithName returns a filter of the edges originated by any of the given links.
+func EdgesWithName(names ...string) EdgePredicate {
+	return func(edge Edge) bool {
+		return lo.Contains(names, edge.Name)
+	}
+}
+
+// EdgesFrom returns a filter of the edges whose parent is a given object.
+func EdgesFrom(parent Object) EdgePredicate {
+	return func(edge Edge) bool {
+		return edge.From.GetURL() == parent.GetURL()
+	}
+}
+
+// EdgesTo returns a filter of the edges whose child is a given object.
+func EdgesTo(child Object) EdgePredicate {
+	return func(edge Edge) bool {
+		return edge.To.GetURL() == child.GetURL()
+	}
+}
+
+// Edges returns the edges of the topology, including the ones between policies and their targets, sorted by URL of
+// their ends. The list can be filtered by providing one or more predicates.
+func (t *Topology) Edges(filters ...EdgePredicate) []Edge {
+	var edges []Edge
+	for _, edgesFrom := range t.graph.EdgesMap() {
+		for _, e := range edgesFrom {
+			edge, ok := t.edgeFrom(e)
+			if !ok {
+				continue
+			}
+			if lo.EveryBy(filters, func(f EdgePredicate) bool { return f(edge) }) {
+				edges = append(edges, edge)
+			}
+		}
+	}
+	sort.SliceStable(edges, func(i, j int) bool {
+		if edges[i].From.GetURL() != edges[j].From.GetURL() {
+			return edges[i].From.GetURL() < edges[j].From.GetURL()
+		}
+		if edges[i].To.GetURL() != edges[j].To.GetURL() {
+			return edges[i].To.GetURL() < edges[j].To.GetURL()
+		}
+		return edges[i].Name < edges[j].Name
+	})
+	return edges
+}
+
+// EdgeAttributes returns the metadata of the edge between a parent and a child, as returned by the Attributes function
+// of the link function that established the edge, if any.
+func (t *Topology) EdgeAttributes(parent, child Object) (map[string]string, bool) {
+	attributes, ok := t.edgeAttributes[edgeKey(parent, child)]
+	return attributes, ok
+}
+
+// edgeFrom returns the edge of the topology for an edge of the graph, if both ends are nodes of the topology
+func (t *Topology) edgeFrom(e dot.Edge) (Edge, bool) {
+	from, foundFrom := t.node(e.From().ID())
+	to, foundTo := t.node(e.To().ID())
+	if !foundFrom || !foundTo {
+		return Edge{}, false
+	}
+	name, _ := e.GetAttr("comment").(string)
+	relationship, _ := e.GetAttr(relationshipEdgeAttribute).(string)
+	attributes, _ := t.EdgeAttributes(from, to)
+	return Edge{Name: name, Relationship: EdgeRelationship(relationship), From: from, To: to, Attributes: attributes}, true
+}
+
+// node returns the node of the topology of any type with a given URL
+func (t *Topology) node(url string) (Object, bool) {
+	if targetable, ok := t.targetables[url]; ok {
+		return targetable, true
+	}
+	if policy, ok := t.policies[url]; ok {
+		return policy, true
+	}
+	object, ok := t.objects[url]
+	return object, ok
+}
+
+func edgeKey(parent, child Object) string {
+	return parent.GetURL() + " -> " + child.GetURL()
+}
@@ -0,0 +1,153 @@
+//go:build unit
+
+package machinery
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestTopologyEdges(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(buildPolicy()),
+	)
+
+	edgeNames := func(edges []Edge) []string {
+		return lo.Map(edges, func(edge Edge, _ int) string {
+			return edge.From.GetName() + " -> " + edge.To.GetName()
+		})
+	}
+
+	testCases := []struct {
+		name     string
+		filters  []EdgePredicate
+		expected []string
+	}{
+		{
+			name:     "parentRefs",
+			filters:  []EdgePredicate{EdgesWithRelationship(EdgeRelationshipParentRef)},
+			expected: []string{"my-gateway#my-listener -> my-http-route", "my-gateway-class -> my-gateway"},
+		},
+		{
+			name:     "sections",
+			filters:  []EdgePredicate{EdgesWithRelationship(EdgeRelationshipSection)},
+			expected: []string{"my-gateway -> my-gateway#my-listener", "my-http-route -> my-http-route#rule-1"},
+		},
+		{
+			name:     "backendRefs",
+			filters:  []EdgePredicate{EdgesWithRelationship(EdgeRelationshipBackendRef)},
+			expected: []string{"my-http-route#rule-1 -> my-service"},
+		},
+		{
+			name:     "policy target refs",
+			filters:  []EdgePredicate{EdgesWithRelationship(EdgeRelationshipPolicyTargetRef)},
+			expected: []string{"my-policy -> my-service"},
+		},
+		{
+			name:     "by name",
+			filters:  []EdgePredicate{EdgesWithName("Gateway -> Listener", "Policy -> Target")},
+			expected: []string{"my-gateway -> my-gateway#my-listener", "my-policy -> my-service"},
+		},
+		{
+			name: "from and to",
+			filters: []EdgePredicate{
+				EdgesFrom(topology.Targetables().Items(IsKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"}))[0]),
+				EdgesTo(topology.Targetables().Items(IsKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"}))[0]),
+			},
+			expected: []string{"my-gateway#my-listener -> my-http-route"},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := edgeNames(topology.Edges(tc.filters...)); !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected edges %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+
+	if edges := topology.Edges(); len(edges) != 6 {
+		t.Errorf("expected 6 edges, got %d: %v", len(edges), edgeNames(edges))
+	}
+}
+
+func TestTopologyEdgesWithAttributes(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService()),
+		WithBackendRefTypes(),
+	)
+
+	edges := topology.Edges(EdgesWithRelationship(EdgeRelationshipBackendRef))
+	if len(edges) != 1 {
+		t.Fatalf("expected 1 backendRef edge, got %d", len(edges))
+	}
+	if expected := map[string]string{BackendRefTypesEdgeAttribute: "Backend", BackendRefWeightEdgeAttribute: "1"}; !reflect.DeepEqual(edges[0].Attributes, expected) {
+		t.Errorf("expected edge attributes %v, got %v", expected, edges[0].Attributes)
+	}
+	if edges[0].Name != "HTTPRouteRule -> Service" {
+		t.Errorf("expected edge name HTTPRouteRule -> Service, got %s", edges[0].Name)
+	}
+}
+
+func TestTopologyEdgesRelationshipInSnapshotAndGraphviz(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+	)
+
+	if graphviz := topology.ToGraphviz(); !strings.Contains(graphviz, `Gateway -> HTTPRoute\n(parentRef)`) {
+		t.Errorf("expected the relationship in the label of the edge, got:\n%s", graphviz)
+	}
+
+	data, err := json.Marshal(topology)
+	if err != nil {
+		t.Fatalf("failed to marshal topology: %v", err)
+	}
+	rebuilt := &Topology{}
+	if err := json.Unmarshal(data, rebuilt); err != nil {
+		t.Fatalf("failed to unmarshal topology: %v", err)
+	}
+	if edges := rebuilt.Edges(EdgesWithRelationship(EdgeRelationshipParentRef)); len(edges) != 2 {
+		t.Errorf("expected 2 parentRef edges in the rebuilt topology, got %d", len(edges))
+	}
+}
+
+func TestPathQueryWhereRelationship(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute()),
+	)
+
+	paths := func(relationships ...EdgeRelationship) [][]Targetable {
+		return topology.Targetables().PathQuery().
+			FromKinds(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}).
+			To(IsKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"})).
+			WhereRelationship(relationships...).
+			Paths()
+	}
+	if p := paths(EdgeRelationshipSection, EdgeRelationshipParentRef); len(p) != 1 || len(p[0]) != 3 {
+		t.Errorf("expected the path from the gateway to the route through the listener, got %v", p)
+	}
+	if p := paths(EdgeRelationshipParentRef); len(p) != 0 {
+		t.Errorf("expected no path from the gateway to the route without the sections, got %v", p)
+	}
+}
@@ -0,0 +1,155 @@
+package machinery
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// EffectivePolicy is the effective policy computed for a path of targetables.
+type EffectivePolicy[T Policy] struct {
+	Path   []Targetable
+	Policy T
+}
+
+// EffectivePolicyForPath returns the effective policy of type T for a path of targetables, i.e. the policies of type T
+// attached to the targetables in the path merged from the most specific to the least specific one.
+// Policies attached to the same targetable are sorted by creation timestamp, then by namespace and name, the oldest
+// being the least specific. Each policy is merged by calling its Merge method, which honors the merge strategies
+// registered for the kind of the policy (see MergeStrategyFor). Policies that implement NormalizablePolicy are
+// normalized before merging, and so is the effective policy.
+// The result is cached in the topology, thus computed once per reconciliation cycle.
+// It returns false if no policy of type T is attached to the targetables in the path.
+func EffectivePolicyForPath[T Policy](topology *Topology, path []Targetable) (T, bool) {
+	key := fmt.Sprintf("%s|%s", reflect.TypeOf((*T)(nil)).Elem().String(), PathID(path))
+	policy, found := topology.effectivePolicies.getOrCompute(key, func() (Policy, bool) {
+		return effectivePolicyForPath[T](path)
+	})
+	if !found {
+		var zero T
+		return zero, false
+	}
+	return policy.(T), true
+}
+
+// ComputeEffectivePolicy computes the effective policy of type T for a path of targetables like EffectivePolicyForPath,
+// without caching the result. Use it for paths whose targetables are not the ones of a topology, e.g. simulated paths.
+func ComputeEffectivePolicy[T Policy](path []Targetable) (T, bool) {
+	policy, found := effectivePolicyForPath[T](path)
+	if !found {
+		var zero T
+		return zero, false
+	}
+	return policy.(T), true
+}
+
+// EffectivePoliciesByTarget returns the effective policies of type T for all the paths from the roots of the topology
+// to the targetables that satisfy the given predicates (all targetables if none), indexed by URL of the targetable.
+// Paths without an effective policy are omitted.
+func EffectivePoliciesByTarget[T Policy](topology *Topology, filters ...FilterFunc) map[string][]EffectivePolicy[T] {
+	return EffectivePoliciesByTargetFrom[T](topology, nil, filters...)
+}
+
+// EffectivePoliciesByTargetFrom returns the effective policies of type T like EffectivePoliciesByTarget, for the paths
+// starting at the targetables of any of the given root kinds (see RootsOf), e.g. IngressRootKinds or MeshRootKinds.
+// If no root kind is given, the paths start at the roots of the topology.
+func EffectivePoliciesByTargetFrom[T Policy](topology *Topology, rootKinds []schema.GroupKind, filters ...FilterFunc) map[string][]EffectivePolicy[T] {
+	targetables := topology.Targetables()
+	roots := sortedByURL(targetables.RootsOf(rootKinds...))
+	effectivePolicies := make(map[string][]EffectivePolicy[T])
+	for _, target := range sortedByURL(targetables.Items(filters...)) {
+		for _, root := range roots {
+			for _, path := range targetables.Paths(root, target) {
+				if policy, found := EffectivePolicyForPath[T](topology, path); found {
+					effectivePolicies[target.GetURL()] = append(effectivePolicies[target.GetURL()], EffectivePolicy[T]{Path: path, Policy: policy})
+				}
+			}
+		}
+	}
+	return effectivePolicies
+}
+
+// PathID returns an identifier of a path of targetables made of the URLs of the targetables in the path.
+func PathID(path []Targetable) string {
+	return strings.Join(lo.Map(path, MapTargetableToURLFunc), "|")
+}
+
+func effectivePolicyForPath[T Policy](path []Targetable) (Policy, bool) {
+	// gather all policies in the path sorted from the least specific to the most specific
+	policies := lo.FlatMap(path, func(targetable Targetable, _ int) []Policy {
+		policies := lo.Filter(targetable.Policies(), func(p Policy, _ int) bool {
+			_, ok := p.(T)
+			return ok
+		})
+		sort.SliceStable(policies, func(i, j int) bool {
+			return policyOlderThan(policies[i], policies[j])
+		})
+		return lo.Map(policies, func(p Policy, _ int) Policy { return NormalizePolicy(p) })
+	})
+
+	if len(policies) == 0 {
+		return nil, false
+	}
+
+	// reduces the policies from the most specific to the least specific, merging them into one effective policy
+	effectivePolicy := lo.ReduceRight(policies, func(effectivePolicy Policy, policy Policy, _ int) Policy {
+		return effectivePolicy.Merge(policy)
+	}, policies[len(policies)-1])
+	effectivePolicy = NormalizePolicy(effectivePolicy)
+
+	if _, ok := effectivePolicy.(T); !ok {
+		return nil, false
+	}
+	return effectivePolicy, true
+}
+
+type creationTimestamped interface {
+	GetCreationTimestamp() metav1.Time
+}
+
+func policyOlderThan(p1, p2 Policy) bool {
+	t1, ok1 := p1.(creationTimestamped)
+	t2, ok2 := p2.(creationTimestamped)
+	if ok1 && ok2 {
+		if time1, time2 := t1.GetCreationTimestamp(), t2.GetCreationTimestamp(); !time1.Equal(&time2) {
+			return time1.Before(&time2)
+		}
+	}
+	return namespacedName(p1.GetNamespace(), p1.GetName()) < namespacedName(p2.GetNamespace(), p2.GetName())
+}
+
+type effectivePolicyResult struct {
+	policy Policy
+	found  bool
+}
+
+// effectivePolicyCache memoizes effective policies computed for a topology.
+type effectivePolicyCache struct {
+	sync.Mutex
+	results map[string]effectivePolicyResult
+}
+
+func newEffectivePolicyCache() *effectivePolicyCache {
+	return &effectivePolicyCache{results: make(map[string]effectivePolicyResult)}
+}
+
+// getOrCompute returns the cached result for a key, computing it if not cached yet. A nil cache computes every time.
+func (c *effectivePolicyCache) getOrCompute(key string, compute func() (Policy, bool)) (Policy, bool) {
+	if c == nil {
+		return compute()
+	}
+	c.Lock()
+	defer c.Unlock()
+	if result, ok := c.results[key]; ok {
+		return result.policy, result.found
+	}
+	policy, found := compute()
+	c.results[key] = effectivePolicyResult{policy: policy, found: found}
+	return policy, found
+}
@@ -0,0 +1,86 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestEffectivePolicies(t *testing.T) {
+	gatewayPolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "gateway-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "Gateway",
+				Name:  "gateway-1",
+			},
+		}
+	})
+	routePolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "route-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "HTTPRoute",
+				Name:  "route-1",
+			},
+		}
+	})
+
+	resources := BuildComplexGatewayAPITopology()
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(resources.GatewayClasses...),
+		WithGateways(resources.Gateways...),
+		WithHTTPRoutes(resources.HTTPRoutes...),
+		WithServices(resources.Services...),
+		WithGatewayAPITopologyPolicies(gatewayPolicy, routePolicy),
+	)
+
+	// TestPolicy merges into a policy with the spec of the most specific policy
+	effectivePolicies := EffectivePoliciesByTarget[*TestPolicy](topology, IsKind(schema.GroupKind{Kind: "Service"}))
+	serviceURL := func(name string) string {
+		return topology.Targetables().Items(func(o Object) bool {
+			return o.GroupVersionKind().Kind == "Service" && o.GetName() == name
+		})[0].GetURL()
+	}
+	expected := map[string]string{
+		serviceURL("service-1"): "route-1",
+		serviceURL("service-2"): "route-1",
+		serviceURL("service-3"): "gateway-1",
+	}
+	if len(effectivePolicies) != len(expected) {
+		t.Fatalf("expected effective policies for %d services, got %d: %v", len(expected), len(effectivePolicies), effectivePolicies)
+	}
+	for url, targetName := range expected {
+		policies, ok := effectivePolicies[url]
+		if !ok || len(policies) != 1 {
+			t.Errorf("expected 1 effective policy for %s, got %v", url, policies)
+			continue
+		}
+		if name := string(policies[0].Policy.Spec.TargetRef.Name); name != targetName {
+			t.Errorf("expected effective policy for %s with the spec of the policy targeting %s, got %s", url, targetName, name)
+		}
+		if last := policies[0].Path[len(policies[0].Path)-1]; last.GetURL() != url {
+			t.Errorf("expected path to %s, got path to %s", url, last.GetURL())
+		}
+	}
+
+	path := effectivePolicies[serviceURL("service-1")][0].Path
+	cached, found := EffectivePolicyForPath[*TestPolicy](topology, path)
+	if !found || cached != effectivePolicies[serviceURL("service-1")][0].Policy {
+		t.Errorf("expected cached effective policy, got %v", cached)
+	}
+	computed, found := ComputeEffectivePolicy[*TestPolicy](path)
+	if !found || computed == cached || computed.Spec.TargetRef.Name != "route-1" {
+		t.Errorf("expected newly computed effective policy, got %v", computed)
+	}
+
+	if _, found := EffectivePolicyForPath[*TestPolicy](topology, topology.Targetables().Roots()[:1]); found {
+		t.Errorf("expected no effective policy for a path without policies")
+	}
+}
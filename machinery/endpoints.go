@@ -0,0 +1,140 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	EndpointSliceKind = discoveryv1.SchemeGroupVersion.WithKind("EndpointSlice").GroupKind()
+	PodKind           = core.SchemeGroupVersion.WithKind("Pod").GroupKind()
+)
+
+// EndpointSlice is a slice of the endpoints of a service, that tells where the workloads behind the service actually
+// run.
+type EndpointSlice struct {
+	*discoveryv1.EndpointSlice
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &EndpointSlice{}
+
+func (s *EndpointSlice) GroupVersionKind() schema.GroupVersionKind {
+	return discoveryv1.SchemeGroupVersion.WithKind(EndpointSliceKind.Kind)
+}
+
+func (s *EndpointSlice) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (s *EndpointSlice) GetURL() string {
+	return UrlFromObject(s)
+}
+
+func (s *EndpointSlice) SetPolicies(policies []Policy) {
+	s.attachedPolicies = policies
+}
+
+func (s *EndpointSlice) Policies() []Policy {
+	return s.attachedPolicies
+}
+
+// Pod is a pod that is the target of an endpoint of a service.
+type Pod struct {
+	*core.Pod
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &Pod{}
+
+func (p *Pod) GroupVersionKind() schema.GroupVersionKind {
+	return core.SchemeGroupVersion.WithKind(PodKind.Kind)
+}
+
+func (p *Pod) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (p *Pod) GetURL() string {
+	return UrlFromObject(p)
+}
+
+func (p *Pod) SetPolicies(policies []Policy) {
+	p.attachedPolicies = policies
+}
+
+func (p *Pod) Policies() []Policy {
+	return p.attachedPolicies
+}
+
+// WithEndpointSlices adds endpoint slices to the options to initialize a new Gateway API topology, expanding the
+// services below to the endpoint slices that belong to them, based on the `kubernetes.io/service-name` label of the
+// endpoint slices.
+func WithEndpointSlices(endpointSlices ...*discoveryv1.EndpointSlice) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.EndpointSlices = append(o.EndpointSlices, lo.Map(endpointSlices, func(endpointSlice *discoveryv1.EndpointSlice, _ int) *EndpointSlice {
+			return &EndpointSlice{EndpointSlice: endpointSlice}
+		})...)
+	}
+}
+
+// WithPods adds pods to the options to initialize a new Gateway API topology, expanding the endpoint slices below to
+// the pods that their endpoints refer to in their `targetRef`. Pods are only linked along with endpoint slices (see
+// WithEndpointSlices).
+func WithPods(pods ...*core.Pod) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.Pods = append(o.Pods, lo.Map(pods, func(pod *core.Pod, _ int) *Pod {
+			return &Pod{Pod: pod}
+		})...)
+	}
+}
+
+// LinkServiceToEndpointSliceFunc returns a link function that teaches a topology how to link EndpointSlices from known
+// Services, based on the EndpointSlice's `kubernetes.io/service-name` label.
+func LinkServiceToEndpointSliceFunc(services []*Service) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Kind: "Service"},
+		To:           EndpointSliceKind,
+		Relationship: EdgeRelationshipEndpoint,
+		Func: func(child Object) []Object {
+			endpointSlice := child.(*EndpointSlice)
+			serviceName, ok := endpointSlice.Labels[discoveryv1.LabelServiceName]
+			if !ok {
+				return nil
+			}
+			return lo.FilterMap(services, func(service *Service, _ int) (Object, bool) {
+				return service, service.Namespace == endpointSlice.Namespace && service.Name == serviceName
+			})
+		},
+	}
+}
+
+// LinkEndpointSliceToPodFunc returns a link function that teaches a topology how to link Pods from known
+// EndpointSlices, based on the `targetRef` field of the endpoints of the EndpointSlices.
+func LinkEndpointSliceToPodFunc(endpointSlices []*EndpointSlice) LinkFunc {
+	return LinkFunc{
+		From:         EndpointSliceKind,
+		To:           PodKind,
+		Relationship: EdgeRelationshipEndpoint,
+		Func: func(child Object) []Object {
+			pod := child.(*Pod)
+			return lo.FilterMap(endpointSlices, func(endpointSlice *EndpointSlice, _ int) (Object, bool) {
+				return endpointSlice, lo.ContainsBy(endpointSlice.Endpoints, func(endpoint discoveryv1.Endpoint) bool {
+					return endpointTargetsPod(endpoint, pod, endpointSlice.Namespace)
+				})
+			})
+		},
+	}
+}
+
+func endpointTargetsPod(endpoint discoveryv1.Endpoint, pod *Pod, defaultNamespace string) bool {
+	targetRef := endpoint.TargetRef
+	if targetRef == nil || targetRef.Kind != PodKind.Kind {
+		return false
+	}
+	namespace, _ := lo.Coalesce(targetRef.Namespace, defaultNamespace)
+	if targetRef.UID != "" && pod.UID != "" {
+		return targetRef.UID == pod.UID
+	}
+	return namespace == pod.Namespace && targetRef.Name == pod.Name
+}
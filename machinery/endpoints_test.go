@@ -0,0 +1,104 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestEndpoints(t *testing.T) {
+	service := BuildService()
+	otherService := BuildService(func(s *core.Service) { s.Name = "other-service" })
+	endpointSlice := BuildEndpointSlice(func(s *discoveryv1.EndpointSlice) {
+		s.Endpoints = append(s.Endpoints, discoveryv1.Endpoint{
+			Addresses: []string{"10.0.0.2"},
+			TargetRef: &core.ObjectReference{Kind: "Pod", Name: "my-other-pod", UID: "my-other-pod"},
+		})
+	})
+	orphanEndpointSlice := BuildEndpointSlice(func(s *discoveryv1.EndpointSlice) {
+		s.Name = "orphan"
+		s.Labels = nil
+	})
+	pod := BuildPod()
+	otherPod := BuildPod(func(p *core.Pod) {
+		p.Name = "my-other-pod"
+		p.UID = k8stypes.UID("my-other-pod")
+		p.Spec.NodeName = "my-other-node"
+	})
+	unrelatedPod := BuildPod(func(p *core.Pod) { p.Name = "unrelated" })
+
+	// pods are not linked without endpoint slices
+	topology := NewGatewayAPITopology(WithServices(service), WithPods(pod))
+	if pods := topology.Targetables().Items(IsKind(PodKind)); len(pods) != 0 {
+		t.Errorf("expected no pods without endpoint slices, got %v", pods)
+	}
+
+	topology = NewGatewayAPITopology(
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithServices(service, otherService),
+		WithEndpointSlices(endpointSlice, orphanEndpointSlice),
+		WithPods(pod, otherPod, unrelatedPod),
+		ExpandHTTPRouteRules(),
+	)
+
+	targetables := lo.KeyBy(topology.Targetables().Items(), func(t Targetable) string { return t.GetURL() })
+	serviceNode := targetables[UrlFromObject(&Service{Service: service})]
+	children := topology.Targetables().Children(serviceNode)
+	if len(children) != 1 || children[0].GetName() != "my-service-abcde" {
+		t.Fatalf("expected the endpoint slice of the service, got %v", children)
+	}
+	if children := topology.Targetables().Children(targetables[UrlFromObject(&Service{Service: otherService})]); len(children) != 0 {
+		t.Errorf("expected no endpoint slices of the other service, got %v", children)
+	}
+	if parents := topology.Targetables().Parents(targetables[UrlFromObject(&EndpointSlice{EndpointSlice: orphanEndpointSlice})]); len(parents) != 0 {
+		t.Errorf("expected the endpoint slice without service name not linked, got %v", parents)
+	}
+
+	pods := topology.Targetables().Children(children[0])
+	nodes := lo.Map(pods, func(p Targetable, _ int) string { return p.(*Pod).Spec.NodeName })
+	if len(nodes) != 2 || !lo.Contains(nodes, "my-node") || !lo.Contains(nodes, "my-other-node") {
+		t.Errorf("expected the pods of the endpoints of the endpoint slice, got %v", pods)
+	}
+	if parents := topology.Targetables().Parents(targetables[UrlFromObject(&Pod{Pod: unrelatedPod})]); len(parents) != 0 {
+		t.Errorf("expected the unrelated pod not linked, got %v", parents)
+	}
+
+	// traverse from the route down to the workloads
+	paths := topology.Targetables().Paths(targetables[UrlFromObject(&HTTPRoute{HTTPRoute: BuildHTTPRoute()})], pods[0])
+	if len(paths) != 1 || len(paths[0]) != 5 || paths[0][1].GroupVersionKind().Kind != "HTTPRouteRule" {
+		t.Errorf("expected a path from the route to the pod through the rule, the service and the endpoint slice, got %v", paths)
+	}
+
+	edges := topology.Edges(EdgesWithRelationship(EdgeRelationshipEndpoint))
+	if len(edges) != 4 { // service -> slice, slice -> 2 pods, orphan slice -> pod
+		t.Errorf("expected 4 endpoint edges, got %v", lo.Map(edges, func(e Edge, _ int) string { return e.From.GetName() + " -> " + e.To.GetName() }))
+	}
+}
+
+func TestEndpointTargetsPod(t *testing.T) {
+	pod := &Pod{Pod: BuildPod(func(p *core.Pod) { p.UID = "my-pod" })}
+	testCases := []struct {
+		name      string
+		targetRef *core.ObjectReference
+		expected  bool
+	}{
+		{name: "no target ref"},
+		{name: "not a pod", targetRef: &core.ObjectReference{Kind: "Node", Name: "my-pod"}},
+		{name: "by name in the namespace of the slice", targetRef: &core.ObjectReference{Kind: "Pod", Name: "my-pod"}, expected: true},
+		{name: "by name in another namespace", targetRef: &core.ObjectReference{Kind: "Pod", Name: "my-pod", Namespace: "other-namespace"}},
+		{name: "by uid", targetRef: &core.ObjectReference{Kind: "Pod", Name: "my-pod", UID: "my-pod"}, expected: true},
+		{name: "recreated pod", targetRef: &core.ObjectReference{Kind: "Pod", Name: "my-pod", UID: "old-pod"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if actual := endpointTargetsPod(discoveryv1.Endpoint{TargetRef: tc.targetRef}, pod, "my-namespace"); actual != tc.expected {
+				t.Errorf("expected %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,69 @@
+package machinery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// ErrTargetNotFound is returned when a target reference of a policy does not resolve to a targetable of the topology.
+var ErrTargetNotFound = errors.New("target not found")
+
+// TargetNotFoundError is returned for each target reference of a policy that does not resolve to a targetable of the
+// topology. It matches ErrTargetNotFound with errors.Is.
+type TargetNotFoundError struct {
+	Policy    Policy
+	TargetRef PolicyTargetReference
+}
+
+func (e *TargetNotFoundError) Error() string {
+	return fmt.Sprintf("%s: %s (policy %s)", ErrTargetNotFound, e.TargetRef.GetURL(), e.Policy.GetURL())
+}
+
+func (e *TargetNotFoundError) Unwrap() error {
+	return ErrTargetNotFound
+}
+
+// ErrUnsupportedTarget is returned when a target reference of a policy is not supported by the kind of policy, as
+// declared with RegisterPolicyCapabilities.
+var ErrUnsupportedTarget = errors.New("unsupported target")
+
+// UnsupportedTargetError is returned for each target reference of a policy whose kind of target is not supported by
+// the kind of policy. It matches ErrUnsupportedTarget with errors.Is.
+type UnsupportedTargetError struct {
+	Policy    Policy
+	TargetRef PolicyTargetReference
+	Reason    string
+}
+
+func (e *UnsupportedTargetError) Error() string {
+	return fmt.Sprintf("%s: %s (policy %s): %s", ErrUnsupportedTarget, e.TargetRef.GetURL(), e.Policy.GetURL(), e.Reason)
+}
+
+func (e *UnsupportedTargetError) Unwrap() error {
+	return ErrUnsupportedTarget
+}
+
+// ErrInvalidSectionName is returned when a target reference of a policy refers to a section that the target does not
+// have, e.g. a listener name with a typo.
+var ErrInvalidSectionName = errors.New("invalid section name")
+
+// InvalidSectionNameError is returned for each target reference of a policy to a section that the target does not
+// have, listing the names of the sections of the target. It matches both ErrInvalidSectionName and ErrTargetNotFound
+// with errors.Is.
+type InvalidSectionNameError struct {
+	Policy        Policy
+	TargetRef     PolicyTargetReference
+	SectionName   gwapiv1.SectionName
+	ValidSections []string
+}
+
+func (e *InvalidSectionNameError) Error() string {
+	return fmt.Sprintf("%s %q: %s (policy %s): valid sections are [%s]", ErrInvalidSectionName, e.SectionName, e.TargetRef.GetURL(), e.Policy.GetURL(), strings.Join(e.ValidSections, ", "))
+}
+
+func (e *InvalidSectionNameError) Unwrap() []error {
+	return []error{ErrInvalidSectionName, ErrTargetNotFound}
+}
@@ -0,0 +1,216 @@
+package machinery
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+)
+
+// Expression is a compiled expression, e.g. a CEL program, evaluated against the variables of a node of a topology
+// (see NodeVariables).
+type Expression interface {
+	Eval(variables map[string]any) (any, error)
+}
+
+// ExpressionCompiler compiles the source of expressions evaluated against the variables of the nodes of a topology
+// (see NodeVariables). CEL expressions are compiled with a CELExpressionCompiler; other expression languages can be
+// plugged by the controllers that evaluate expressions.
+type ExpressionCompiler interface {
+	Compile(expression string) (Expression, error)
+}
+
+// ExpressionCompilerFunc is a function that implements ExpressionCompiler.
+type ExpressionCompilerFunc func(expression string) (Expression, error)
+
+func (f ExpressionCompilerFunc) Compile(expression string) (Expression, error) {
+	return f(expression)
+}
+
+// NodeVariables returns the variables of the expressions evaluated against a targetable of a topology, as the
+// json-like values that expression languages such as CEL evaluate against:
+//   - self: the targetable;
+//   - ancestors: the targetables from which the targetable can be reached, sorted by URL;
+//   - policies: the policies attached to the targetable;
+//   - effectivePolicies: the effective policies of the paths from the roots of the topology to the targetable, if a
+//     function to compute them is given, e.g. with EffectivePolicyForPath.
+func NodeVariables(topology *Topology, node Targetable, effectivePolicy func(topology *Topology, path []Targetable) (Policy, bool)) (map[string]any, error) {
+	self, err := expressionValue(node)
+	if err != nil {
+		return nil, err
+	}
+	ancestors, err := expressionValues(topology.Targetables().Ancestors(node))
+	if err != nil {
+		return nil, err
+	}
+	policies, err := expressionValues(node.Policies())
+	if err != nil {
+		return nil, err
+	}
+	var effectivePolicies []Policy
+	if effectivePolicy != nil {
+		for _, path := range topology.Targetables().AncestorPaths(node) {
+			if policy, ok := effectivePolicy(topology, path); ok {
+				effectivePolicies = append(effectivePolicies, policy)
+			}
+		}
+	}
+	effective, err := expressionValues(effectivePolicies)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"self":              self,
+		"ancestors":         ancestors,
+		"policies":          policies,
+		"effectivePolicies": effective,
+	}, nil
+}
+
+// expressionValue returns the json-like value of an object, with its apiVersion and kind set.
+func expressionValue(obj Object) (map[string]any, error) {
+	b, err := json.Marshal(obj)
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", obj.GetURL(), err)
+	}
+	value := map[string]any{}
+	if err := json.Unmarshal(b, &value); err != nil {
+		return nil, fmt.Errorf("failed to serialize %s: %w", obj.GetURL(), err)
+	}
+	gvk := obj.GroupVersionKind()
+	if _, ok := value["apiVersion"]; !ok {
+		value["apiVersion"] = gvk.GroupVersion().String()
+	}
+	if _, ok := value["kind"]; !ok {
+		value["kind"] = gvk.Kind
+	}
+	return value, nil
+}
+
+func expressionValues[T Object](objs []T) ([]any, error) {
+	values := make([]any, 0, len(objs))
+	for _, obj := range objs {
+		value, err := expressionValue(obj)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	return values, nil
+}
+
+// ExpressionCache compiles expressions once and caches the compiled expressions (or the compilation errors) by
+// source, so evaluating the same expressions, e.g. the "when" clauses of the policies, in every reconciliation does
+// not compile them over and over.
+// The cache is cleared whenever it reaches its maximum size, if any, so expressions of deleted policies do not pile
+// up. The cache is safe for concurrent use.
+type ExpressionCache struct {
+	compiler ExpressionCompiler
+	maxSize  int
+	mu       sync.RWMutex
+	compiled map[string]compiledExpression
+}
+
+type compiledExpression struct {
+	expression Expression
+	err        error
+}
+
+// NewExpressionCache returns a cache of the expressions compiled by a compiler. A maximum size of 0 or less leaves
+// the cache unbounded.
+func NewExpressionCache(compiler ExpressionCompiler, maxSize int) *ExpressionCache {
+	return &ExpressionCache{
+		compiler: compiler,
+		maxSize:  maxSize,
+		compiled: map[string]compiledExpression{},
+	}
+}
+
+// Compile returns the compiled expression of a source, compiling it if not cached yet.
+func (c *ExpressionCache) Compile(expression string) (Expression, error) {
+	c.mu.RLock()
+	compiled, ok := c.compiled[expression]
+	c.mu.RUnlock()
+	if ok {
+		return compiled.expression, compiled.err
+	}
+
+	compiled.expression, compiled.err = c.compiler.Compile(expression)
+	if compiled.err != nil {
+		compiled.err = fmt.Errorf("failed to compile expression %q: %w", expression, compiled.err)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.maxSize > 0 && len(c.compiled) >= c.maxSize {
+		c.compiled = map[string]compiledExpression{}
+	}
+	c.compiled[expression] = compiled
+	return compiled.expression, compiled.err
+}
+
+// Len returns the number of expressions cached.
+func (c *ExpressionCache) Len() int {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return len(c.compiled)
+}
+
+// Eval evaluates an expression against a set of variables, e.g. the variables of a node of a topology (see
+// NodeVariables).
+func (c *ExpressionCache) Eval(expression string, variables map[string]any) (any, error) {
+	compiled, err := c.Compile(expression)
+	if err != nil {
+		return nil, err
+	}
+	result, err := compiled.Eval(variables)
+	if err != nil {
+		return nil, fmt.Errorf("failed to evaluate expression %q: %w", expression, err)
+	}
+	return result, nil
+}
+
+// EvalBool evaluates a boolean expression, e.g. a predicate, against a set of variables. It fails if the expression
+// does not evaluate to a boolean.
+func (c *ExpressionCache) EvalBool(expression string, variables map[string]any) (bool, error) {
+	result, err := c.Eval(expression, variables)
+	if err != nil {
+		return false, err
+	}
+	b, ok := result.(bool)
+	if !ok {
+		return false, fmt.Errorf("expression %q evaluated to %T, not bool", expression, result)
+	}
+	return b, nil
+}
+
+// ExpressionErrorFunc handles the errors of evaluating an expression against an object, e.g. by logging them or by
+// reporting them in the status of the policy the expression belongs to.
+type ExpressionErrorFunc func(obj Object, err error)
+
+// WhenExpression returns a predicate of the targetables of a topology for which a boolean expression evaluates to
+// true against their variables (see NodeVariables), e.g. the "when" clause of a policy.
+// The expression is compiled upfront, so an invalid expression is reported as an error instead of never satisfying
+// the predicate. The errors of evaluating the expression against a targetable, e.g. an expression that does not
+// evaluate to a boolean, are handled by the error function, if any. Objects that are not targetables of the topology,
+// and targetables the expression fails to evaluate against, do not satisfy the predicate.
+func WhenExpression(topology *Topology, cache *ExpressionCache, expression string, effectivePolicy func(topology *Topology, path []Targetable) (Policy, bool), onError ExpressionErrorFunc) (FilterFunc, error) {
+	if _, err := cache.Compile(expression); err != nil {
+		return nil, err
+	}
+	return func(obj Object) bool {
+		node, ok := topology.Targetables().Get(obj.GetURL())
+		if !ok {
+			return false
+		}
+		variables, err := NodeVariables(topology, node, effectivePolicy)
+		if err == nil {
+			var result bool
+			if result, err = cache.EvalBool(expression, variables); err == nil {
+				return result
+			}
+		}
+		if onError != nil {
+			onError(obj, err)
+		}
+		return false
+	}, nil
+}
@@ -0,0 +1,140 @@
+//go:build unit
+
+package machinery
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// pathExpression is a test expression that evaluates to the value at a dotted path of the variables, e.g.
+// "self.metadata.name", or to whether the value equals a string, e.g. "self.metadata.name == my-gateway"
+type pathExpression struct {
+	path  []string
+	value *string
+}
+
+func (e *pathExpression) Eval(variables map[string]any) (any, error) {
+	var current any = variables
+	for _, key := range e.path {
+		switch v := current.(type) {
+		case map[string]any:
+			current = v[key]
+		case []any:
+			if key != "0" || len(v) == 0 {
+				return nil, errors.New("index out of range")
+			}
+			current = v[0]
+		default:
+			return nil, errors.New("no such key")
+		}
+	}
+	if e.value != nil {
+		return current == *e.value, nil
+	}
+	return current, nil
+}
+
+func TestExpressions(t *testing.T) {
+	compilations := 0
+	compiler := ExpressionCompilerFunc(func(expression string) (Expression, error) {
+		compilations++
+		if expression == "" {
+			return nil, errors.New("empty expression")
+		}
+		path, value, isComparison := strings.Cut(expression, " == ")
+		e := &pathExpression{path: strings.Split(path, ".")}
+		if isComparison {
+			e.value = &value
+		}
+		return e, nil
+	})
+	cache := NewExpressionCache(compiler, 3)
+
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"},
+		}
+	})
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithGatewayAPITopologyPolicies(policy),
+	)
+	route, _ := lo.Find(topology.Targetables().Items(), func(t Targetable) bool {
+		return t.GroupVersionKind().GroupKind() == schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"}
+	})
+	effectivePolicy := func(topology *Topology, path []Targetable) (Policy, bool) {
+		return EffectivePolicyForPath[*TestPolicy](topology, path)
+	}
+
+	variables, err := NodeVariables(topology, route, effectivePolicy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if name, err := cache.Eval("self.metadata.name", variables); err != nil || name != route.GetName() {
+		t.Errorf("expected the name of the route, got %v %v", name, err)
+	}
+	if kind, err := cache.Eval("self.kind", variables); err != nil || kind != "HTTPRoute" {
+		t.Errorf("expected the kind of the route, got %v %v", kind, err)
+	}
+	if ancestors := variables["ancestors"].([]any); len(ancestors) != 2 {
+		t.Errorf("expected the gateway class and the gateway as ancestors of the route, got %v", ancestors)
+	}
+	if len(variables["policies"].([]any)) != 0 {
+		t.Errorf("expected no policy attached to the route, got %v", variables["policies"])
+	}
+	if target, err := cache.EvalBool("effectivePolicies.0.spec.targetRef.name == my-gateway", variables); err != nil || !target {
+		t.Errorf("expected the effective policy of the gateway, got %v %v", target, err)
+	}
+
+	// compiled once
+	if _, err := cache.Eval("self.metadata.name", variables); err != nil || compilations != 3 || cache.Len() != 3 {
+		t.Errorf("expected the compiled expressions to be cached, got %d compilations", compilations)
+	}
+	// compilation errors are cached too
+	for range 2 {
+		if _, err := cache.Eval("", variables); err == nil {
+			t.Errorf("expected a compilation error")
+		}
+	}
+	if compilations != 4 || cache.Len() != 1 {
+		t.Errorf("expected the cache to be cleared on reaching its maximum size, got %d compilations and %d expressions", compilations, cache.Len())
+	}
+	if _, err := cache.EvalBool("self.kind", variables); err == nil {
+		t.Errorf("expected an error evaluating a non-boolean expression as a boolean")
+	}
+
+	// predicates
+	when, err := WhenExpression(topology, cache, "self.metadata.name == my-gateway", nil, nil)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	matched := lo.Map(topology.Targetables().Items(when), func(t Targetable, _ int) string { return t.GroupVersionKind().Kind })
+	if len(matched) != 1 || matched[0] != "Gateway" {
+		t.Errorf("expected the gateway to satisfy the predicate, got %v", matched)
+	}
+	var failures []string
+	failing, err := WhenExpression(topology, cache, "self.status.missing.key == x", nil, func(obj Object, err error) {
+		failures = append(failures, obj.GetURL())
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if failing(route) {
+		t.Errorf("expected a failing expression not to satisfy the predicate")
+	}
+	if len(failures) != 1 || failures[0] != route.GetURL() {
+		t.Errorf("expected the evaluation error reported for the route, got %v", failures)
+	}
+	if _, err := WhenExpression(topology, cache, "", nil, nil); err == nil {
+		t.Errorf("expected a compilation error")
+	}
+}
@@ -0,0 +1,133 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// DefaultExternalBackendKind is the kind of the backendRefs that refer to external backends by hostname, when the
+// entry of the external service catalog does not specify one.
+var DefaultExternalBackendKind = schema.GroupKind{Group: "networking.istio.io", Kind: "Hostname"}
+
+// ExternalServiceEntry is an entry of an external service catalog, such as Consul or the service registries of cloud
+// providers, for a backend that is not a Kubernetes Service.
+// Routes refer to the backend with backendRefs of the same group, kind, namespace and name, e.g. a hostname.
+type ExternalServiceEntry struct {
+	// Group and Kind of the backendRefs that refer to the backend. Defaults to DefaultExternalBackendKind.
+	Group string
+	Kind  string
+	// Namespace of the backend, i.e. of the backendRefs that refer to it, defaulted to the namespace of the route.
+	Namespace string
+	// Name of the backend, e.g. the hostname.
+	Name string
+	// Source is the name of the catalog the entry was ingested from, e.g. "consul".
+	Source    string
+	Addresses []string
+	Ports     []int32
+	Labels    map[string]string
+}
+
+// ExternalBackend is a targetable backend ingested from an external service catalog, so policies can attach to
+// backends outside of the cluster.
+type ExternalBackend struct {
+	*ExternalServiceEntry
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &ExternalBackend{}
+
+func (b *ExternalBackend) GroupVersionKind() schema.GroupVersionKind {
+	if b.Kind == "" {
+		return DefaultExternalBackendKind.WithVersion("")
+	}
+	return schema.GroupVersionKind{Group: b.Group, Kind: b.Kind}
+}
+
+func (b *ExternalBackend) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (b *ExternalBackend) GetNamespace() string {
+	return b.Namespace
+}
+
+func (b *ExternalBackend) GetName() string {
+	return b.Name
+}
+
+func (b *ExternalBackend) GetURL() string {
+	return UrlFromObject(b)
+}
+
+func (b *ExternalBackend) SetPolicies(policies []Policy) {
+	b.attachedPolicies = policies
+}
+
+func (b *ExternalBackend) Policies() []Policy {
+	return b.attachedPolicies
+}
+
+// WithExternalServiceEntries adds backends ingested from external service catalogs to the options to initialize a new
+// Gateway API topology. The backends are linked from the HTTPRoutes, or from the HTTPRouteRules if expanded, that refer
+// to them in their backendRefs.
+func WithExternalServiceEntries(entries ...*ExternalServiceEntry) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExternalBackends = append(o.ExternalBackends, lo.Map(entries, func(entry *ExternalServiceEntry, _ int) *ExternalBackend {
+			return &ExternalBackend{ExternalServiceEntry: entry}
+		})...)
+	}
+}
+
+// LinkHTTPRouteToExternalBackendFunc returns a link function that teaches a topology how to link external backends of
+// a given kind from known HTTPRoutes, based on the HTTPRoute's `backendRefs` fields.
+func LinkHTTPRouteToExternalBackendFunc(httpRoutes []*HTTPRoute, kind schema.GroupKind) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		To:           kind,
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			backend := child.(*ExternalBackend)
+			return lo.FilterMap(httpRoutes, func(httpRoute *HTTPRoute, _ int) (Object, bool) {
+				return httpRoute, lo.ContainsBy(httpRoute.Spec.Rules, func(rule gwapiv1.HTTPRouteRule) bool {
+					return lo.ContainsBy(rule.BackendRefs, func(backendRef gwapiv1.HTTPBackendRef) bool {
+						return backendRefEqualToExternalBackend(backendRef.BackendRef, backend, httpRoute.Namespace)
+					})
+				})
+			})
+		},
+	}
+}
+
+// LinkHTTPRouteRuleToExternalBackendFunc returns a link function that teaches a topology how to link external backends
+// of a given kind from known HTTPRouteRules, based on the HTTPRouteRule's `backendRefs` field.
+func LinkHTTPRouteRuleToExternalBackendFunc(httpRouteRules []*HTTPRouteRule, kind schema.GroupKind) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		To:           kind,
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			backend := child.(*ExternalBackend)
+			return lo.FilterMap(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) (Object, bool) {
+				return httpRouteRule, lo.ContainsBy(httpRouteRule.BackendRefs, func(backendRef gwapiv1.HTTPBackendRef) bool {
+					return backendRefEqualToExternalBackend(backendRef.BackendRef, backend, httpRouteRule.HTTPRoute.Namespace)
+				})
+			})
+		},
+	}
+}
+
+// externalBackendKinds returns the distinct kinds of a list of external backends.
+func externalBackendKinds(backends []*ExternalBackend) []schema.GroupKind {
+	return lo.Uniq(lo.Map(backends, func(backend *ExternalBackend, _ int) schema.GroupKind {
+		return backend.GroupVersionKind().GroupKind()
+	}))
+}
+
+func backendRefEqualToExternalBackend(backendRef gwapiv1.BackendRef, backend *ExternalBackend, defaultNamespace string) bool {
+	kind := backend.GroupVersionKind()
+	backendRefGroup := string(ptr.Deref(backendRef.Group, gwapiv1.Group("")))
+	backendRefKind := string(ptr.Deref(backendRef.Kind, gwapiv1.Kind("Service")))
+	backendRefNamespace := string(ptr.Deref(backendRef.Namespace, gwapiv1.Namespace(defaultNamespace)))
+	return backendRefGroup == kind.Group && backendRefKind == kind.Kind && backendRefNamespace == backend.Namespace && string(backendRef.Name) == backend.Name
+}
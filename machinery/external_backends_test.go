@@ -0,0 +1,81 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestExternalBackends(t *testing.T) {
+	hostnameRef := BuildHTTPBackendRef(func(ref *gwapiv1.BackendObjectReference) {
+		ref.Group = ptr.To(gwapiv1.Group("networking.istio.io"))
+		ref.Kind = ptr.To(gwapiv1.Kind("Hostname"))
+		ref.Name = "api.example.com"
+	})
+	route := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.Rules = append(r.Spec.Rules, gwapiv1.HTTPRouteRule{BackendRefs: []gwapiv1.HTTPBackendRef{hostnameRef}})
+	})
+	entries := []*ExternalServiceEntry{
+		{Namespace: "my-namespace", Name: "api.example.com", Source: "consul"},
+		{Namespace: "other-namespace", Name: "api.example.com", Source: "consul"},
+		{Group: "example.com", Kind: "CloudMapService", Namespace: "my-namespace", Name: "api.example.com", Source: "aws"},
+	}
+	policy := buildPolicy(func(p *TestPolicy) {
+		p.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: "networking.istio.io",
+				Kind:  "Hostname",
+				Name:  "api.example.com",
+			},
+		}
+	})
+
+	testCases := []struct {
+		name       string
+		expandRule bool
+		parentKind string
+	}{
+		{name: "routes", parentKind: "HTTPRoute"},
+		{name: "route rules", expandRule: true, parentKind: "HTTPRouteRule"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []GatewayAPITopologyOptionsFunc{
+				WithHTTPRoutes(route),
+				WithExternalServiceEntries(entries...),
+				WithGatewayAPITopologyPolicies(policy),
+			}
+			if tc.expandRule {
+				opts = append(opts, ExpandHTTPRouteRules())
+			}
+			topology := NewGatewayAPITopology(opts...)
+
+			backends := TargetablesOfType[*ExternalBackend](topology)
+			if len(backends) != 3 {
+				t.Fatalf("expected 3 external backends, got %d", len(backends))
+			}
+			backend, found := topology.Targetables().Get("hostname.networking.istio.io:my-namespace/api.example.com")
+			if !found {
+				t.Fatal("expected external backend found")
+			}
+			parents := topology.Targetables().Parents(backend)
+			if len(parents) != 1 || parents[0].GroupVersionKind().Kind != tc.parentKind {
+				t.Errorf("expected external backend linked from one %s, got %v", tc.parentKind, parents)
+			}
+			if policies := backend.Policies(); len(policies) != 1 || policies[0].GetURL() != policy.GetURL() {
+				t.Errorf("expected policy attached to the external backend, got %v", policies)
+			}
+			for _, other := range []string{"hostname.networking.istio.io:other-namespace/api.example.com", "cloudmapservice.example.com:my-namespace/api.example.com"} {
+				backend, _ := topology.Targetables().Get(other)
+				if parents := topology.Targetables().Parents(backend); len(parents) != 0 {
+					t.Errorf("expected %s not linked, got %v", other, parents)
+				}
+			}
+		})
+	}
+}
@@ -3,7 +3,10 @@
 package machinery
 
 import (
+	"fmt"
+
 	core "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/utils/ptr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
@@ -101,6 +104,68 @@ func BuildHTTPBackendRef(f ...func(*gwapiv1.BackendObjectReference)) gwapiv1.HTT
 	}
 }
 
+func BuildTLSRoute(f ...func(*gwapiv1alpha2.TLSRoute)) *gwapiv1alpha2.TLSRoute {
+	r := &gwapiv1alpha2.TLSRoute{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: gwapiv1alpha2.GroupVersion.String(),
+			Kind:       "TLSRoute",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-tls-route",
+			Namespace: "my-namespace",
+		},
+		Spec: gwapiv1alpha2.TLSRouteSpec{
+			CommonRouteSpec: gwapiv1.CommonRouteSpec{
+				ParentRefs: []gwapiv1.ParentReference{
+					{
+						Name: "my-gateway",
+					},
+				},
+			},
+			Rules: []gwapiv1alpha2.TLSRouteRule{
+				{
+					BackendRefs: []gwapiv1.BackendRef{BuildHTTPBackendRef().BackendRef},
+				},
+			},
+		},
+	}
+	for _, fn := range f {
+		fn(r)
+	}
+	return r
+}
+
+func BuildUDPRoute(f ...func(*gwapiv1alpha2.UDPRoute)) *gwapiv1alpha2.UDPRoute {
+	r := &gwapiv1alpha2.UDPRoute{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: gwapiv1alpha2.GroupVersion.String(),
+			Kind:       "UDPRoute",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-udp-route",
+			Namespace: "my-namespace",
+		},
+		Spec: gwapiv1alpha2.UDPRouteSpec{
+			CommonRouteSpec: gwapiv1.CommonRouteSpec{
+				ParentRefs: []gwapiv1.ParentReference{
+					{
+						Name: "my-gateway",
+					},
+				},
+			},
+			Rules: []gwapiv1alpha2.UDPRouteRule{
+				{
+					BackendRefs: []gwapiv1.BackendRef{BuildHTTPBackendRef().BackendRef},
+				},
+			},
+		},
+	}
+	for _, fn := range f {
+		fn(r)
+	}
+	return r
+}
+
 func BuildService(f ...func(*core.Service)) *core.Service {
 	s := &core.Service{
 		TypeMeta: metav1.TypeMeta{
@@ -129,10 +194,86 @@ func BuildService(f ...func(*core.Service)) *core.Service {
 	return s
 }
 
+func BuildSecret(f ...func(*core.Secret)) *core.Secret {
+	s := &core.Secret{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: core.SchemeGroupVersion.String(),
+			Kind:       "Secret",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-secret",
+			Namespace: "my-namespace",
+		},
+		Type: core.SecretTypeTLS,
+	}
+	for _, fn := range f {
+		fn(s)
+	}
+	return s
+}
+
+func BuildEndpointSlice(f ...func(*discoveryv1.EndpointSlice)) *discoveryv1.EndpointSlice {
+	s := &discoveryv1.EndpointSlice{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: discoveryv1.SchemeGroupVersion.String(),
+			Kind:       "EndpointSlice",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-service-abcde",
+			Namespace: "my-namespace",
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: "my-service",
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Endpoints: []discoveryv1.Endpoint{
+			{
+				Addresses: []string{"10.0.0.1"},
+				TargetRef: &core.ObjectReference{Kind: "Pod", Name: "my-pod", Namespace: "my-namespace"},
+			},
+		},
+		Ports: []discoveryv1.EndpointPort{
+			{
+				Name: ptr.To("http"),
+				Port: ptr.To(int32(8080)),
+			},
+		},
+	}
+	for _, fn := range f {
+		fn(s)
+	}
+	return s
+}
+
+func BuildPod(f ...func(*core.Pod)) *core.Pod {
+	p := &core.Pod{
+		TypeMeta: metav1.TypeMeta{
+			APIVersion: core.SchemeGroupVersion.String(),
+			Kind:       "Pod",
+		},
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "my-pod",
+			Namespace: "my-namespace",
+			Labels: map[string]string{
+				"app": "my-app",
+			},
+		},
+		Spec: core.PodSpec{
+			NodeName: "my-node",
+		},
+	}
+	for _, fn := range f {
+		fn(p)
+	}
+	return p
+}
+
 type GatewayAPIResources struct {
 	GatewayClasses []*gwapiv1.GatewayClass
 	Gateways       []*gwapiv1.Gateway
 	HTTPRoutes     []*gwapiv1.HTTPRoute
+	TLSRoutes      []*gwapiv1alpha2.TLSRoute
+	UDPRoutes      []*gwapiv1alpha2.UDPRoute
 	Services       []*core.Service
 }
 
@@ -394,16 +535,11 @@ func (p *TestPolicy) GetURL() string {
 }
 
 func (p *TestPolicy) GetTargetRefs() []PolicyTargetReference {
-	return []PolicyTargetReference{
-		LocalPolicyTargetReferenceWithSectionName{
-			LocalPolicyTargetReferenceWithSectionName: p.Spec.TargetRef,
-			PolicyNamespace: p.Namespace,
-		},
-	}
+	return ToPolicyTargetReferences(p.Namespace, p.Spec.TargetRef)
 }
 
 func (p *TestPolicy) GetMergeStrategy() MergeStrategy {
-	return DefaultMergeStrategy
+	return MergeStrategyFor(p.GroupVersionKind().GroupKind())
 }
 
 func (p *TestPolicy) Merge(policy Policy) Policy {
@@ -437,3 +573,55 @@ func buildPolicy(f ...func(*TestPolicy)) *TestPolicy {
 	}
 	return p
 }
+
+// GatewayAPITopologyScale is the size of a synthetic Gateway API topology built with BuildScaledGatewayAPITopology.
+type GatewayAPITopologyScale struct {
+	GatewayClasses       int
+	GatewaysPerClass     int
+	ListenersPerGateway  int
+	HTTPRoutesPerGateway int
+	RulesPerHTTPRoute    int
+}
+
+// BuildScaledGatewayAPITopology builds a synthetic Gateway API topology of a given scale, for performance tests.
+// Every HTTPRoute rule has a backend reference to its own Service.
+func BuildScaledGatewayAPITopology(scale GatewayAPITopologyScale) GatewayAPIResources {
+	t := GatewayAPIResources{}
+	for c := 0; c < scale.GatewayClasses; c++ {
+		gatewayClassName := fmt.Sprintf("gatewayclass-%d", c)
+		t.GatewayClasses = append(t.GatewayClasses, BuildGatewayClass(func(gc *gwapiv1.GatewayClass) { gc.Name = gatewayClassName }))
+		for g := 0; g < scale.GatewaysPerClass; g++ {
+			gatewayName := fmt.Sprintf("gateway-%d-%d", c, g)
+			t.Gateways = append(t.Gateways, BuildGateway(func(gateway *gwapiv1.Gateway) {
+				gateway.Name = gatewayName
+				gateway.Spec.GatewayClassName = gwapiv1.ObjectName(gatewayClassName)
+				gateway.Spec.Listeners = nil
+				for l := 0; l < scale.ListenersPerGateway; l++ {
+					gateway.Spec.Listeners = append(gateway.Spec.Listeners, gwapiv1.Listener{
+						Name:     gwapiv1.SectionName(fmt.Sprintf("listener-%d", l)),
+						Port:     gwapiv1.PortNumber(8000 + l),
+						Protocol: "HTTP",
+					})
+				}
+			}))
+			for r := 0; r < scale.HTTPRoutesPerGateway; r++ {
+				routeName := fmt.Sprintf("route-%d-%d-%d", c, g, r)
+				t.HTTPRoutes = append(t.HTTPRoutes, BuildHTTPRoute(func(route *gwapiv1.HTTPRoute) {
+					route.Name = routeName
+					route.Spec.ParentRefs[0].Name = gwapiv1.ObjectName(gatewayName)
+					route.Spec.Rules = nil
+					for i := 0; i < scale.RulesPerHTTPRoute; i++ {
+						serviceName := fmt.Sprintf("service-%d-%d-%d-%d", c, g, r, i)
+						route.Spec.Rules = append(route.Spec.Rules, gwapiv1.HTTPRouteRule{
+							BackendRefs: []gwapiv1.HTTPBackendRef{BuildHTTPBackendRef(func(backendRef *gwapiv1.BackendObjectReference) {
+								backendRef.Name = gwapiv1.ObjectName(serviceName)
+							})},
+						})
+						t.Services = append(t.Services, BuildService(func(s *core.Service) { s.Name = serviceName }))
+					}
+				}))
+			}
+		}
+	}
+	return t
+}
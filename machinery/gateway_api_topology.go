@@ -8,20 +8,44 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/ptr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
 type GatewayAPITopologyOptions struct {
-	GatewayClasses []*GatewayClass
-	Gateways       []*Gateway
-	HTTPRoutes     []*HTTPRoute
-	Services       []*Service
-	Policies       []Policy
-	Objects        []Object
-	Links          []LinkFunc
+	GatewayClasses   []*GatewayClass
+	Gateways         []*Gateway
+	HTTPRoutes       []*HTTPRoute
+	TLSRoutes        []*TLSRoute
+	UDPRoutes        []*UDPRoute
+	Services         []*Service
+	EndpointSlices   []*EndpointSlice
+	Pods             []*Pod
+	Namespaces       []*Namespace
+	Secrets          []*Secret
+	ExternalBackends []*ExternalBackend
+	InferencePools   []*InferencePool
+	InferenceModels  []*InferenceModel
+	Policies         []Policy
+	Objects          []Object
+	Links            []LinkFunc
+	Config           Object
+	ConfigKinds      []schema.GroupKind
 
-	ExpandGatewayListeners bool
-	ExpandHTTPRouteRules   bool
-	ExpandServicePorts     bool
+	GatewayMerging              []GatewayMergingFunc
+	NamespaceLabels             map[string]map[string]string
+	GatewayClassParametersKinds []schema.GroupKind
+
+	ExpandGatewayListeners      bool
+	ExpandGatewayInfrastructure bool
+	ExpandGatewayAddresses      bool
+	ExpandHTTPRouteRules        bool
+	ExpandHTTPRouteMatches      bool
+	ExpandTLSRouteRules         bool
+	ExpandUDPRouteRules         bool
+	ExpandServicePorts          bool
+	PruneEmptyExpansions        bool
+	BackendRefTypes             bool
+	SectionNameSeparator        SectionNameSeparator
 }
 
 type GatewayAPITopologyOptionsFunc func(*GatewayAPITopologyOptions)
@@ -53,6 +77,24 @@ func WithHTTPRoutes(httpRoutes ...*gwapiv1.HTTPRoute) GatewayAPITopologyOptionsF
 	}
 }
 
+// WithTLSRoutes adds TLS routes to the options to initialize a new Gateway API topology.
+func WithTLSRoutes(tlsRoutes ...*gwapiv1alpha2.TLSRoute) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.TLSRoutes = append(o.TLSRoutes, lo.Map(tlsRoutes, func(tlsRoute *gwapiv1alpha2.TLSRoute, _ int) *TLSRoute {
+			return &TLSRoute{TLSRoute: tlsRoute}
+		})...)
+	}
+}
+
+// WithUDPRoutes adds UDP routes to the options to initialize a new Gateway API topology.
+func WithUDPRoutes(udpRoutes ...*gwapiv1alpha2.UDPRoute) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.UDPRoutes = append(o.UDPRoutes, lo.Map(udpRoutes, func(udpRoute *gwapiv1alpha2.UDPRoute, _ int) *UDPRoute {
+			return &UDPRoute{UDPRoute: udpRoute}
+		})...)
+	}
+}
+
 // WithServices adds services to the options to initialize a new Gateway API topology.
 func WithServices(services ...*core.Service) GatewayAPITopologyOptionsFunc {
 	return func(o *GatewayAPITopologyOptions) {
@@ -62,6 +104,17 @@ func WithServices(services ...*core.Service) GatewayAPITopologyOptionsFunc {
 	}
 }
 
+// WithSecrets adds secrets to the options to initialize a new Gateway API topology.
+// The secrets are linked to the gateways, or to the gateway listeners if expanded, whose TLS configuration refers to
+// them.
+func WithSecrets(secrets ...*core.Secret) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.Secrets = append(o.Secrets, lo.Map(secrets, func(secret *core.Secret, _ int) *Secret {
+			return &Secret{Secret: secret}
+		})...)
+	}
+}
+
 // WithGatewayAPITopologyPolicies adds policies to the options to initialize a new Gateway API topology.
 func WithGatewayAPITopologyPolicies(policies ...Policy) GatewayAPITopologyOptionsFunc {
 	return func(o *GatewayAPITopologyOptions) {
@@ -85,6 +138,23 @@ func WithGatewayAPITopologyLinks(links ...LinkFunc) GatewayAPITopologyOptionsFun
 	}
 }
 
+// WithGatewayAPITopologyConfig adds a singleton configuration object to the options to initialize a new Gateway API
+// topology, linked to all nodes of the kinds it configures.
+func WithGatewayAPITopologyConfig(config Object, configures ...schema.GroupKind) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.Config = config
+		o.ConfigKinds = configures
+	}
+}
+
+// WithGatewayAPITopologySectionNameSeparator sets the separator between the names of resources and the names of their
+// sections in the locators of a new Gateway API topology (see WithSectionNameSeparator).
+func WithGatewayAPITopologySectionNameSeparator(separator SectionNameSeparator) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.SectionNameSeparator = separator
+	}
+}
+
 // ExpandGatewayListeners adds targetable gateway listeners to the options to initialize a new Gateway API topology.
 func ExpandGatewayListeners() GatewayAPITopologyOptionsFunc {
 	return func(o *GatewayAPITopologyOptions) {
@@ -99,6 +169,20 @@ func ExpandHTTPRouteRules() GatewayAPITopologyOptionsFunc {
 	}
 }
 
+// ExpandTLSRouteRules adds targetable TLS route rules to the options to initialize a new Gateway API topology.
+func ExpandTLSRouteRules() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExpandTLSRouteRules = true
+	}
+}
+
+// ExpandUDPRouteRules adds targetable UDP route rules to the options to initialize a new Gateway API topology.
+func ExpandUDPRouteRules() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExpandUDPRouteRules = true
+	}
+}
+
 // ExpandServicePorts adds targetable service ports to the options to initialize a new Gateway API topology.
 func ExpandServicePorts() GatewayAPITopologyOptionsFunc {
 	return func(o *GatewayAPITopologyOptions) {
@@ -106,17 +190,85 @@ func ExpandServicePorts() GatewayAPITopologyOptionsFunc {
 	}
 }
 
+// PruneEmptyExpansions removes from a new Gateway API topology the expanded sections (listeners, route rules, service
+// ports) that have no children, no attached policies and no links other than the one from the object they belong to,
+// e.g. listeners without routes or service ports not referred by any route, to keep path enumeration and exports
+// manageable on very wide topologies.
+func PruneEmptyExpansions() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.PruneEmptyExpansions = true
+	}
+}
+
 // NewGatewayAPITopology returns a topology of Gateway API objects and attached policies.
 //
 // The links between the targetables are established based on the relationships defined by Gateway API.
 //
-// Principal objects like Gateways, HTTPRoutes and Services can be expanded to automatically include their targetable
-// sections (listeners, route rules, service ports) as independent objects in the topology, by supplying the
-// corresponding options ExpandGatewayListeners(), ExpandHTTPRouteRules(), and ExpandServicePorts().
+// Principal objects like Gateways, HTTPRoutes, TLSRoutes, UDPRoutes and Services can be expanded to automatically
+// include their targetable sections (listeners, route rules, service ports) as independent objects in the topology, by
+// supplying the corresponding options ExpandGatewayListeners(), ExpandHTTPRouteRules(), ExpandTLSRouteRules(),
+// ExpandUDPRouteRules(), and ExpandServicePorts().
 // The links will then be established accordingly. E.g.:
 //   - Without expanding Gateway listeners (default): Gateway -> HTTPRoute links.
 //   - Expanding Gateway listeners: Gateway -> Listener and Listener -> HTTPRoute links.
+//
+// The infrastructure and the addresses of the Gateways can also be expanded as sections of the Gateways, so policies
+// can target them by section name like listeners, with ExpandGatewayInfrastructure() and ExpandGatewayAddresses().
+//
+// The matches of the HTTP route rules can be further expanded beneath the rules with ExpandHTTPRouteMatches(), as
+// sections of the HTTP routes named after the rule and the position of the match, e.g. "rule-1-match-2".
+//
+// Services can be expanded below to the EndpointSlices that belong to them and the Pods of their endpoints, with
+// WithEndpointSlices() and WithPods(), to traverse the topology down to the actual workloads.
+//
+// The objects that the GatewayClasses refer to in their `parametersRef` can be linked from the GatewayClasses with
+// WithGatewayClassParameters().
+//
+// Namespaces can be added as ancestors of the Gateways, routes and Services in them with WithNamespaces(), so policies
+// attached to a namespace apply by default to its resources.
+//
+// Expanded sections that are empty can be pruned from the topology with PruneEmptyExpansions().
+//
+// The backend references of HTTP routes and route rules to services can be distinguished by type (regular, zero-weight
+// and mirror backends) with WithBackendRefTypes().
+//
+// InferencePools and InferenceModels of the Gateway API Inference Extension supplied with WithInferencePools and
+// WithInferenceModels are added as targetables, linked from the HTTPRoutes, or from the HTTPRouteRules if expanded, that
+// refer to the pools in their backendRefs, and from the pools to the models that refer to them.
+//
+// Secrets supplied with WithSecrets are added as objects linked from the Gateways, or from the Listeners if expanded,
+// that refer to them in their TLS `certificateRefs`.
+//
+// To model other domains in the same topology, e.g. DNS records or certificate chains, compose the Gateway API preset
+// with generic topology options in NewTopology instead (see WithGatewayAPI).
 func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
+	return NewTopology(WithGatewayAPI(options...))
+}
+
+// WithGatewayAPI adds the Gateway API preset to the options to initialize a new topology, i.e. the targetables,
+// policies, objects and links of a topology of Gateway API resources built from the given Gateway API topology
+// options, as NewGatewayAPITopology does. It composes with the generic topology options, to add targetables, objects
+// and links of other domains linked to the Gateway API resources. E.g.:
+//
+//	topology := machinery.NewTopology(
+//		machinery.WithGatewayAPI(
+//			machinery.WithGateways(gateways...),
+//			machinery.WithHTTPRoutes(httpRoutes...),
+//			machinery.ExpandGatewayListeners(),
+//		),
+//		machinery.WithTargetables(dnsRecords...),
+//		machinery.WithLinks(linkListenerToDNSRecord),
+//	)
+func WithGatewayAPI(options ...GatewayAPITopologyOptionsFunc) TopologyOptionsFunc {
+	return func(to *TopologyOptions) {
+		for _, f := range gatewayAPITopologyOptions(options...) {
+			f(to)
+		}
+	}
+}
+
+// gatewayAPITopologyOptions translates Gateway API topology options into generic topology options
+func gatewayAPITopologyOptions(options ...GatewayAPITopologyOptionsFunc) []TopologyOptionsFunc {
 	o := &GatewayAPITopologyOptions{}
 	for _, f := range options {
 		f(o)
@@ -124,13 +276,44 @@ func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
 
 	opts := []TopologyOptionsFunc{
 		WithObjects(o.Objects...),
+		WithObjects(o.Secrets...),
 		WithPolicies(o.Policies...),
 		WithTargetables(o.GatewayClasses...),
 		WithTargetables(o.Gateways...),
 		WithTargetables(o.HTTPRoutes...),
+		WithTargetables(o.TLSRoutes...),
+		WithTargetables(o.UDPRoutes...),
 		WithTargetables(o.Services...),
+		WithTargetables(o.ExternalBackends...),
+		WithTargetables(o.InferencePools...),
+		WithTargetables(o.InferenceModels...),
 		WithLinks(o.Links...),
 		WithLinks(LinkGatewayClassToGatewayFunc(o.GatewayClasses)), // GatewayClass -> Gateway
+		WithConfig(o.Config, o.ConfigKinds...),
+		WithNamespaceLabels(o.NamespaceLabels),
+	}
+
+	if o.SectionNameSeparator != 0 {
+		opts = append(opts, WithSectionNameSeparator(o.SectionNameSeparator))
+	}
+
+	for _, kind := range o.GatewayClassParametersKinds {
+		opts = append(opts, WithLinks(LinkGatewayClassToParametersFunc(o.GatewayClasses, kind))) // GatewayClass -> parameters
+	}
+
+	if len(o.Namespaces) > 0 {
+		opts = append(opts, WithTargetables(o.Namespaces...))
+		for _, kind := range NamespacedKinds {
+			opts = append(opts, WithLinks(LinkNamespaceToNamespacedFunc(o.Namespaces, kind))) // Namespace -> Gateway/HTTPRoute/TLSRoute/UDPRoute/Service
+		}
+	}
+
+	if len(o.InferenceModels) > 0 {
+		opts = append(opts, WithLinks(LinkInferencePoolToInferenceModelFunc(o.InferencePools))) // InferencePool -> InferenceModel
+	}
+
+	if len(o.GatewayMerging) > 0 {
+		opts = append(opts, WithLinks(LinkGatewayToMergedGatewayFunc(o.Gateways, o.GatewayMerging...))) // Gateway -> Gateway
 	}
 
 	if o.ExpandGatewayListeners {
@@ -139,9 +322,31 @@ func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
 		opts = append(opts, WithLinks(
 			LinkGatewayToListenerFunc(),                        // Gateway -> Listener
 			LinkListenerToHTTPRouteFunc(o.Gateways, listeners), // Listener -> HTTPRoute
+			LinkListenerToTLSRouteFunc(o.Gateways, listeners),  // Listener -> TLSRoute
+			LinkListenerToUDPRouteFunc(o.Gateways, listeners),  // Listener -> UDPRoute
 		))
+		if len(o.Secrets) > 0 {
+			opts = append(opts, WithLinks(LinkListenerToSecretFunc(listeners))) // Listener -> Secret
+		}
 	} else {
-		opts = append(opts, WithLinks(LinkGatewayToHTTPRouteFunc(o.Gateways))) // Gateway -> HTTPRoute
+		opts = append(opts, WithLinks(
+			LinkGatewayToHTTPRouteFunc(o.Gateways), // Gateway -> HTTPRoute
+			LinkGatewayToTLSRouteFunc(o.Gateways),  // Gateway -> TLSRoute
+			LinkGatewayToUDPRouteFunc(o.Gateways),  // Gateway -> UDPRoute
+		))
+		if len(o.Secrets) > 0 {
+			opts = append(opts, WithLinks(LinkGatewayToSecretFunc(o.Gateways))) // Gateway -> Secret
+		}
+	}
+
+	if o.ExpandGatewayInfrastructure {
+		opts = append(opts, WithTargetables(lo.FlatMap(o.Gateways, GatewayInfrastructureFromGatewayFunc)...))
+		opts = append(opts, WithLinks(LinkGatewayToGatewayInfrastructureFunc())) // Gateway -> GatewayInfrastructure
+	}
+
+	if o.ExpandGatewayAddresses {
+		opts = append(opts, WithTargetables(lo.FlatMap(o.Gateways, GatewayAddressesFromGatewayFunc)...))
+		opts = append(opts, WithLinks(LinkGatewayToGatewayAddressFunc())) // Gateway -> GatewayAddress
 	}
 
 	if o.ExpandHTTPRouteRules {
@@ -149,9 +354,27 @@ func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
 		opts = append(opts, WithTargetables(httpRouteRules...))
 		opts = append(opts, WithLinks(LinkHTTPRouteToHTTPRouteRuleFunc())) // HTTPRoute -> HTTPRouteRule
 
-		if o.ExpandServicePorts {
-			servicePorts := lo.FlatMap(o.Services, ServicePortsFromBackendFunc)
-			opts = append(opts, WithTargetables(servicePorts...))
+		if o.ExpandHTTPRouteMatches {
+			opts = append(opts, WithTargetables(lo.FlatMap(httpRouteRules, HTTPRouteMatchesFromHTTPRouteRuleFunc)...))
+			opts = append(opts, WithLinks(LinkHTTPRouteRuleToHTTPRouteMatchFunc())) // HTTPRouteRule -> HTTPRouteMatch
+		}
+
+		for _, kind := range externalBackendKinds(o.ExternalBackends) {
+			opts = append(opts, WithLinks(LinkHTTPRouteRuleToExternalBackendFunc(httpRouteRules, kind))) // HTTPRouteRule -> ExternalBackend
+		}
+
+		if len(o.InferencePools) > 0 {
+			opts = append(opts, WithLinks(LinkHTTPRouteRuleToInferencePoolFunc(httpRouteRules))) // HTTPRouteRule -> InferencePool
+		}
+
+		if o.BackendRefTypes && o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkHTTPRouteRuleToServicePortWithTypesFunc(httpRouteRules),   // HTTPRouteRule -> ServicePort
+				LinkHTTPRouteRuleToServiceWithTypesFunc(httpRouteRules, true), // HTTPRouteRule -> Service
+			))
+		} else if o.BackendRefTypes {
+			opts = append(opts, WithLinks(LinkHTTPRouteRuleToServiceWithTypesFunc(httpRouteRules, false))) // HTTPRouteRule -> Service
+		} else if o.ExpandServicePorts {
 			opts = append(opts, WithLinks(
 				LinkHTTPRouteRuleToServicePortFunc(httpRouteRules),   // HTTPRouteRule -> ServicePort
 				LinkHTTPRouteRuleToServiceFunc(httpRouteRules, true), // HTTPRouteRule -> Service
@@ -160,7 +383,22 @@ func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
 			opts = append(opts, WithLinks(LinkHTTPRouteRuleToServiceFunc(httpRouteRules, false))) // HTTPRouteRule -> Service
 		}
 	} else {
-		if o.ExpandServicePorts {
+		for _, kind := range externalBackendKinds(o.ExternalBackends) {
+			opts = append(opts, WithLinks(LinkHTTPRouteToExternalBackendFunc(o.HTTPRoutes, kind))) // HTTPRoute -> ExternalBackend
+		}
+
+		if len(o.InferencePools) > 0 {
+			opts = append(opts, WithLinks(LinkHTTPRouteToInferencePoolFunc(o.HTTPRoutes))) // HTTPRoute -> InferencePool
+		}
+
+		if o.BackendRefTypes && o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkHTTPRouteToServicePortWithTypesFunc(o.HTTPRoutes),   // HTTPRoute -> ServicePort
+				LinkHTTPRouteToServiceWithTypesFunc(o.HTTPRoutes, true), // HTTPRoute -> Service
+			))
+		} else if o.BackendRefTypes {
+			opts = append(opts, WithLinks(LinkHTTPRouteToServiceWithTypesFunc(o.HTTPRoutes, false))) // HTTPRoute -> Service
+		} else if o.ExpandServicePorts {
 			opts = append(opts, WithLinks(
 				LinkHTTPRouteToServicePortFunc(o.HTTPRoutes),   // HTTPRoute -> ServicePort
 				LinkHTTPRouteToServiceFunc(o.HTTPRoutes, true), // HTTPRoute -> Service
@@ -170,11 +408,83 @@ func NewGatewayAPITopology(options ...GatewayAPITopologyOptionsFunc) *Topology {
 		}
 	}
 
+	if o.ExpandTLSRouteRules {
+		tlsRouteRules := lo.FlatMap(o.TLSRoutes, TLSRouteRulesFromTLSRouteFunc)
+		opts = append(opts, WithTargetables(tlsRouteRules...))
+		opts = append(opts, WithLinks(LinkTLSRouteToTLSRouteRuleFunc())) // TLSRoute -> TLSRouteRule
+
+		if o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkTLSRouteRuleToServicePortFunc(tlsRouteRules),   // TLSRouteRule -> ServicePort
+				LinkTLSRouteRuleToServiceFunc(tlsRouteRules, true), // TLSRouteRule -> Service
+			))
+		} else {
+			opts = append(opts, WithLinks(LinkTLSRouteRuleToServiceFunc(tlsRouteRules, false))) // TLSRouteRule -> Service
+		}
+	} else {
+		if o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkTLSRouteToServicePortFunc(o.TLSRoutes),   // TLSRoute -> ServicePort
+				LinkTLSRouteToServiceFunc(o.TLSRoutes, true), // TLSRoute -> Service
+			))
+		} else {
+			opts = append(opts, WithLinks(LinkTLSRouteToServiceFunc(o.TLSRoutes, false))) // TLSRoute -> Service
+		}
+	}
+
+	if o.ExpandUDPRouteRules {
+		udpRouteRules := lo.FlatMap(o.UDPRoutes, UDPRouteRulesFromUDPRouteFunc)
+		opts = append(opts, WithTargetables(udpRouteRules...))
+		opts = append(opts, WithLinks(LinkUDPRouteToUDPRouteRuleFunc())) // UDPRoute -> UDPRouteRule
+
+		if o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkUDPRouteRuleToServicePortFunc(udpRouteRules),   // UDPRouteRule -> ServicePort
+				LinkUDPRouteRuleToServiceFunc(udpRouteRules, true), // UDPRouteRule -> Service
+			))
+		} else {
+			opts = append(opts, WithLinks(LinkUDPRouteRuleToServiceFunc(udpRouteRules, false))) // UDPRouteRule -> Service
+		}
+	} else {
+		if o.ExpandServicePorts {
+			opts = append(opts, WithLinks(
+				LinkUDPRouteToServicePortFunc(o.UDPRoutes),   // UDPRoute -> ServicePort
+				LinkUDPRouteToServiceFunc(o.UDPRoutes, true), // UDPRoute -> Service
+			))
+		} else {
+			opts = append(opts, WithLinks(LinkUDPRouteToServiceFunc(o.UDPRoutes, false))) // UDPRoute -> Service
+		}
+	}
+
+	if len(o.EndpointSlices) > 0 {
+		opts = append(opts, WithTargetables(o.EndpointSlices...))
+		opts = append(opts, WithLinks(LinkServiceToEndpointSliceFunc(o.Services))) // Service -> EndpointSlice
+
+		if len(o.Pods) > 0 {
+			opts = append(opts, WithTargetables(o.Pods...))
+			opts = append(opts, WithLinks(LinkEndpointSliceToPodFunc(o.EndpointSlices))) // EndpointSlice -> Pod
+		}
+	}
+
 	if o.ExpandServicePorts {
+		servicePorts := lo.FlatMap(o.Services, ServicePortsFromBackendFunc)
+		opts = append(opts, WithTargetables(servicePorts...))
 		opts = append(opts, WithLinks(LinkServiceToServicePortFunc())) // Service -> ServicePort
 	}
 
-	return NewTopology(opts...)
+	if o.PruneEmptyExpansions {
+		opts = append(opts, WithEmptyNodesPruned(
+			(&Listener{}).GroupVersionKind().GroupKind(),
+			(&GatewayInfrastructure{}).GroupVersionKind().GroupKind(),
+			(&GatewayAddress{}).GroupVersionKind().GroupKind(),
+			(&HTTPRouteRule{}).GroupVersionKind().GroupKind(),
+			(&TLSRouteRule{}).GroupVersionKind().GroupKind(),
+			(&UDPRouteRule{}).GroupVersionKind().GroupKind(),
+			(&ServicePort{}).GroupVersionKind().GroupKind(),
+		))
+	}
+
+	return opts
 }
 
 // ListenersFromGatewayFunc returns a list of targetable listeners from a targetable gateway.
@@ -187,13 +497,56 @@ func ListenersFromGatewayFunc(gateway *Gateway, _ int) []*Listener {
 	})
 }
 
+// RouteRuleSectionName returns the positional name of the section of a route for the rule at a given position
+// (starting at 0) of the `rules` of the route, i.e. "rule-1" for the first rule.
+//
+// Route rules are named after the `name` field of the rules, if set, falling back to their positional name (see
+// routeRuleSectionName). Named rules can still be located by their positional name (see AliasedTargetable).
+func RouteRuleSectionName(i int) gwapiv1.SectionName {
+	return gwapiv1.SectionName(fmt.Sprintf("rule-%d", i+1))
+}
+
+// routeRuleSectionName returns the name of the section of a route for a rule at a given position of the `rules` of
+// the route, i.e. the `name` of the rule, if set, or else its positional name (see RouteRuleSectionName).
+func routeRuleSectionName(name *gwapiv1.SectionName, i int) gwapiv1.SectionName {
+	if name != nil && *name != "" {
+		return *name
+	}
+	return RouteRuleSectionName(i)
+}
+
 // HTTPRouteRulesFromHTTPRouteFunc returns a list of targetable HTTPRouteRules from a targetable HTTPRoute.
 func HTTPRouteRulesFromHTTPRouteFunc(httpRoute *HTTPRoute, _ int) []*HTTPRouteRule {
 	return lo.Map(httpRoute.Spec.Rules, func(rule gwapiv1.HTTPRouteRule, i int) *HTTPRouteRule {
 		return &HTTPRouteRule{
-			HTTPRouteRule: &rule,
-			HTTPRoute:     httpRoute,
-			Name:          gwapiv1.SectionName(fmt.Sprintf("rule-%d", i+1)),
+			HTTPRouteRule:  &rule,
+			HTTPRoute:      httpRoute,
+			Name:           routeRuleSectionName(rule.Name, i),
+			positionalName: RouteRuleSectionName(i),
+		}
+	})
+}
+
+// TLSRouteRulesFromTLSRouteFunc returns a list of targetable TLSRouteRules from a targetable TLSRoute.
+func TLSRouteRulesFromTLSRouteFunc(tlsRoute *TLSRoute, _ int) []*TLSRouteRule {
+	return lo.Map(tlsRoute.Spec.Rules, func(rule gwapiv1alpha2.TLSRouteRule, i int) *TLSRouteRule {
+		return &TLSRouteRule{
+			TLSRouteRule:   &rule,
+			TLSRoute:       tlsRoute,
+			Name:           routeRuleSectionName(rule.Name, i),
+			positionalName: RouteRuleSectionName(i),
+		}
+	})
+}
+
+// UDPRouteRulesFromUDPRouteFunc returns a list of targetable UDPRouteRules from a targetable UDPRoute.
+func UDPRouteRulesFromUDPRouteFunc(udpRoute *UDPRoute, _ int) []*UDPRouteRule {
+	return lo.Map(udpRoute.Spec.Rules, func(rule gwapiv1alpha2.UDPRouteRule, i int) *UDPRouteRule {
+		return &UDPRouteRule{
+			UDPRouteRule:   &rule,
+			UDPRoute:       udpRoute,
+			Name:           routeRuleSectionName(rule.Name, i),
+			positionalName: RouteRuleSectionName(i),
 		}
 	})
 }
@@ -212,8 +565,9 @@ func ServicePortsFromBackendFunc(service *Service, _ int) []*ServicePort {
 // GatewayClasses, based on the Gateway's `gatewayClassName` field.
 func LinkGatewayClassToGatewayFunc(gatewayClasses []*GatewayClass) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "GatewayClass"},
-		To:   schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "GatewayClass"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		Relationship: EdgeRelationshipParentRef,
 		Func: func(child Object) []Object {
 			gateway := child.(*Gateway)
 			gatewayClass, ok := lo.Find(gatewayClasses, func(gc *GatewayClass) bool {
@@ -231,8 +585,9 @@ func LinkGatewayClassToGatewayFunc(gatewayClasses []*GatewayClass) LinkFunc {
 // Gateways, based on the HTTPRoute's `parentRefs` field.
 func LinkGatewayToHTTPRouteFunc(gateways []*Gateway) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
-		To:   schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		Relationship: EdgeRelationshipParentRef,
 		Func: func(child Object) []Object {
 			httpRoute := child.(*HTTPRoute)
 			return lo.FilterMap(httpRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) (Object, bool) {
@@ -254,8 +609,9 @@ func LinkGatewayToHTTPRouteFunc(gateways []*Gateway) LinkFunc {
 // Gateways they are strongly related to.
 func LinkGatewayToListenerFunc() LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
-		To:   schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		Relationship: EdgeRelationshipSection,
 		Func: func(child Object) []Object {
 			listener := child.(*Listener)
 			return []Object{listener.Gateway}
@@ -269,8 +625,9 @@ func LinkGatewayToListenerFunc() LinkFunc {
 // reference is present, otherwise all Listeners of the parent Gateway are linked to the HTTPRoute.
 func LinkListenerToHTTPRouteFunc(gateways []*Gateway, listeners []*Listener) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
-		To:   schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		Relationship: EdgeRelationshipParentRef,
 		Func: func(child Object) []Object {
 			httpRoute := child.(*HTTPRoute)
 			return lo.FlatMap(httpRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) []Object {
@@ -303,12 +660,61 @@ func LinkListenerToHTTPRouteFunc(gateways []*Gateway, listeners []*Listener) Lin
 	}
 }
 
+// LinkGatewayToSecretFunc returns a link function that teaches a topology how to link Secrets from known Gateways,
+// based on the `certificateRefs` field of the TLS configuration of the Gateway's listeners.
+// Cross-namespace references are linked regardless of the ReferenceGrants that allow them.
+func LinkGatewayToSecretFunc(gateways []*Gateway) LinkFunc {
+	return LinkFunc{
+		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:   schema.GroupKind{Kind: "Secret"},
+		Func: func(child Object) []Object {
+			secret := child.(*Secret)
+			return lo.FilterMap(gateways, func(gateway *Gateway, _ int) (Object, bool) {
+				return gateway, lo.ContainsBy(gateway.Spec.Listeners, func(listener gwapiv1.Listener) bool {
+					return listenerRefersToSecret(listener, gateway.Namespace, secret)
+				})
+			})
+		},
+	}
+}
+
+// LinkListenerToSecretFunc returns a link function that teaches a topology how to link Secrets from known gateway
+// Listeners, based on the `certificateRefs` field of the Listener's TLS configuration.
+// Cross-namespace references are linked regardless of the ReferenceGrants that allow them.
+func LinkListenerToSecretFunc(listeners []*Listener) LinkFunc {
+	return LinkFunc{
+		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		To:   schema.GroupKind{Kind: "Secret"},
+		Func: func(child Object) []Object {
+			secret := child.(*Secret)
+			return lo.FilterMap(listeners, func(listener *Listener, _ int) (Object, bool) {
+				return listener, listenerRefersToSecret(*listener.Listener, listener.Gateway.Namespace, secret)
+			})
+		},
+	}
+}
+
+// listenerRefersToSecret returns true if the TLS configuration of a listener of a gateway in a given namespace refers
+// to a secret.
+func listenerRefersToSecret(listener gwapiv1.Listener, gatewayNamespace string, secret *Secret) bool {
+	if listener.TLS == nil {
+		return false
+	}
+	return lo.ContainsBy(listener.TLS.CertificateRefs, func(ref gwapiv1.SecretObjectReference) bool {
+		group := ptr.Deref(ref.Group, gwapiv1.Group(core.GroupName))
+		kind := ptr.Deref(ref.Kind, gwapiv1.Kind("Secret"))
+		namespace := string(ptr.Deref(ref.Namespace, gwapiv1.Namespace(gatewayNamespace)))
+		return group == core.GroupName && kind == "Secret" && namespace == secret.Namespace && string(ref.Name) == secret.Name
+	})
+}
+
 // LinkHTTPRouteToHTTPRouteRuleFunc returns a link function that teaches a topology how to link HTTPRouteRules from the
 // HTTPRoute they are strongly related to.
 func LinkHTTPRouteToHTTPRouteRuleFunc() LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
-		To:   schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		Relationship: EdgeRelationshipSection,
 		Func: func(child Object) []Object {
 			httpRouteRule := child.(*HTTPRouteRule)
 			return []Object{httpRouteRule.HTTPRoute}
@@ -321,8 +727,9 @@ func LinkHTTPRouteToHTTPRouteRuleFunc() LinkFunc {
 // Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
 func LinkHTTPRouteToServiceFunc(httpRoutes []*HTTPRoute, strict bool) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
-		To:   schema.GroupKind{Kind: "Service"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
 		Func: func(child Object) []Object {
 			service := child.(*Service)
 			return lo.FilterMap(httpRoutes, func(httpRoute *HTTPRoute, _ int) (Object, bool) {
@@ -342,8 +749,9 @@ func LinkHTTPRouteToServiceFunc(httpRoutes []*HTTPRoute, strict bool) LinkFunc {
 // The link function disregards backend references that do not specify a port number.
 func LinkHTTPRouteToServicePortFunc(httpRoutes []*HTTPRoute) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
-		To:   schema.GroupKind{Kind: "ServicePort"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
 		Func: func(child Object) []Object {
 			servicePort := child.(*ServicePort)
 			return lo.FilterMap(httpRoutes, func(httpRoute *HTTPRoute, _ int) (Object, bool) {
@@ -363,8 +771,9 @@ func LinkHTTPRouteToServicePortFunc(httpRoutes []*HTTPRoute) LinkFunc {
 // Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
 func LinkHTTPRouteRuleToServiceFunc(httpRouteRules []*HTTPRouteRule, strict bool) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
-		To:   schema.GroupKind{Kind: "Service"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
 		Func: func(child Object) []Object {
 			service := child.(*Service)
 			return lo.FilterMap(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) (Object, bool) {
@@ -382,8 +791,9 @@ func LinkHTTPRouteRuleToServiceFunc(httpRouteRules []*HTTPRouteRule, strict bool
 // The link function disregards backend references that do not specify a port number.
 func LinkHTTPRouteRuleToServicePortFunc(httpRouteRules []*HTTPRouteRule) LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
-		To:   schema.GroupKind{Kind: "ServicePort"},
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
 		Func: func(child Object) []Object {
 			servicePort := child.(*ServicePort)
 			return lo.FilterMap(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) (Object, bool) {
@@ -396,12 +806,305 @@ func LinkHTTPRouteRuleToServicePortFunc(httpRouteRules []*HTTPRouteRule) LinkFun
 	}
 }
 
+// LinkGatewayToTLSRouteFunc returns a link function that teaches a topology how to link TLSRoutes from known
+// Gateways, based on the TLSRoute's `parentRefs` field.
+// Only Gateways with at least one TLS listener matching the parent reference are linked to the TLSRoute.
+func LinkGatewayToTLSRouteFunc(gateways []*Gateway) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRoute"},
+		Relationship: EdgeRelationshipParentRef,
+		Func: func(child Object) []Object {
+			tlsRoute := child.(*TLSRoute)
+			return lo.FilterMap(tlsRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) (Object, bool) {
+				gateway, ok := findParentGateway(gateways, parentRef, tlsRoute.Namespace)
+				if !ok {
+					return nil, false
+				}
+				return gateway, lo.ContainsBy(gateway.Spec.Listeners, func(l gwapiv1.Listener) bool {
+					return l.Protocol == gwapiv1.TLSProtocolType && (parentRef.SectionName == nil || l.Name == *parentRef.SectionName)
+				})
+			})
+		},
+	}
+}
+
+// LinkListenerToTLSRouteFunc returns a link function that teaches a topology how to link TLSRoutes from known
+// Gateways and gateway Listeners, based on the TLSRoute's `parentRefs` field.
+// Only TLS listeners are linked to the TLSRoute. The function links a specific Listener of a Gateway to the TLSRoute
+// when the `sectionName` field of the parent reference is present, otherwise all TLS Listeners of the parent Gateway
+// are linked to the TLSRoute.
+func LinkListenerToTLSRouteFunc(gateways []*Gateway, listeners []*Listener) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRoute"},
+		Relationship: EdgeRelationshipParentRef,
+		Func: func(child Object) []Object {
+			tlsRoute := child.(*TLSRoute)
+			return lo.FlatMap(tlsRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) []Object {
+				gateway, ok := findParentGateway(gateways, parentRef, tlsRoute.Namespace)
+				if !ok {
+					return nil
+				}
+				return lo.FilterMap(listeners, func(l *Listener, _ int) (Object, bool) {
+					return l, l.Gateway.GetURL() == gateway.GetURL() && l.Protocol == gwapiv1.TLSProtocolType && (parentRef.SectionName == nil || l.Name == *parentRef.SectionName)
+				})
+			})
+		},
+	}
+}
+
+// LinkTLSRouteToTLSRouteRuleFunc returns a link function that teaches a topology how to link TLSRouteRules from the
+// TLSRoute they are strongly related to.
+func LinkTLSRouteToTLSRouteRuleFunc() LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRoute"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRouteRule"},
+		Relationship: EdgeRelationshipSection,
+		Func: func(child Object) []Object {
+			tlsRouteRule := child.(*TLSRouteRule)
+			return []Object{tlsRouteRule.TLSRoute}
+		},
+	}
+}
+
+// LinkTLSRouteToServiceFunc returns a link function that teaches a topology how to link Services from known
+// TLSRoutes, based on the TLSRoute's `backendRefs` fields.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkTLSRouteToServiceFunc(tlsRoutes []*TLSRoute, strict bool) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRoute"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			service := child.(*Service)
+			return lo.FilterMap(tlsRoutes, func(tlsRoute *TLSRoute, _ int) (Object, bool) {
+				return tlsRoute, lo.ContainsBy(tlsRoute.Spec.Rules, func(rule gwapiv1alpha2.TLSRouteRule) bool {
+					backendRefs := lo.Filter(rule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+						return !strict || backendRef.Port == nil
+					})
+					return lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(service, tlsRoute.Namespace))
+				})
+			})
+		},
+	}
+}
+
+// LinkTLSRouteToServicePortFunc returns a link function that teaches a topology how to link services ports from known
+// TLSRoutes, based on the TLSRoute's `backendRefs` fields.
+// The link function disregards backend references that do not specify a port number.
+func LinkTLSRouteToServicePortFunc(tlsRoutes []*TLSRoute) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRoute"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			servicePort := child.(*ServicePort)
+			return lo.FilterMap(tlsRoutes, func(tlsRoute *TLSRoute, _ int) (Object, bool) {
+				return tlsRoute, lo.ContainsBy(tlsRoute.Spec.Rules, func(rule gwapiv1alpha2.TLSRouteRule) bool {
+					backendRefs := lo.Filter(rule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+						return backendRef.Port != nil && int32(*backendRef.Port) == servicePort.Port
+					})
+					return lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(servicePort.Service, tlsRoute.Namespace))
+				})
+			})
+		},
+	}
+}
+
+// LinkTLSRouteRuleToServiceFunc returns a link function that teaches a topology how to link Services from known
+// TLSRouteRules, based on the TLSRouteRule's `backendRefs` field.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkTLSRouteRuleToServiceFunc(tlsRouteRules []*TLSRouteRule, strict bool) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRouteRule"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			service := child.(*Service)
+			return lo.FilterMap(tlsRouteRules, func(tlsRouteRule *TLSRouteRule, _ int) (Object, bool) {
+				backendRefs := lo.Filter(tlsRouteRule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+					return !strict || backendRef.Port == nil
+				})
+				return tlsRouteRule, lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(service, tlsRouteRule.TLSRoute.Namespace))
+			})
+		},
+	}
+}
+
+// LinkTLSRouteRuleToServicePortFunc returns a link function that teaches a topology how to link services ports from
+// known TLSRouteRules, based on the TLSRouteRule's `backendRefs` field.
+// The link function disregards backend references that do not specify a port number.
+func LinkTLSRouteRuleToServicePortFunc(tlsRouteRules []*TLSRouteRule) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "TLSRouteRule"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			servicePort := child.(*ServicePort)
+			return lo.FilterMap(tlsRouteRules, func(tlsRouteRule *TLSRouteRule, _ int) (Object, bool) {
+				backendRefs := lo.Filter(tlsRouteRule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+					return backendRef.Port != nil && int32(*backendRef.Port) == servicePort.Port
+				})
+				return tlsRouteRule, lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(servicePort.Service, tlsRouteRule.TLSRoute.Namespace))
+			})
+		},
+	}
+}
+
+// LinkGatewayToUDPRouteFunc returns a link function that teaches a topology how to link UDPRoutes from known
+// Gateways, based on the UDPRoute's `parentRefs` field.
+// Only Gateways with at least one UDP listener matching the parent reference are linked to the UDPRoute.
+func LinkGatewayToUDPRouteFunc(gateways []*Gateway) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRoute"},
+		Relationship: EdgeRelationshipParentRef,
+		Func: func(child Object) []Object {
+			udpRoute := child.(*UDPRoute)
+			return lo.FilterMap(udpRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) (Object, bool) {
+				gateway, ok := findParentGateway(gateways, parentRef, udpRoute.Namespace)
+				if !ok {
+					return nil, false
+				}
+				return gateway, lo.ContainsBy(gateway.Spec.Listeners, func(l gwapiv1.Listener) bool {
+					return l.Protocol == gwapiv1.UDPProtocolType && (parentRef.SectionName == nil || l.Name == *parentRef.SectionName)
+				})
+			})
+		},
+	}
+}
+
+// LinkListenerToUDPRouteFunc returns a link function that teaches a topology how to link UDPRoutes from known
+// Gateways and gateway Listeners, based on the UDPRoute's `parentRefs` field.
+// Only UDP listeners are linked to the UDPRoute. The function links a specific Listener of a Gateway to the UDPRoute
+// when the `sectionName` field of the parent reference is present, otherwise all UDP Listeners of the parent Gateway
+// are linked to the UDPRoute.
+func LinkListenerToUDPRouteFunc(gateways []*Gateway, listeners []*Listener) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Listener"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRoute"},
+		Relationship: EdgeRelationshipParentRef,
+		Func: func(child Object) []Object {
+			udpRoute := child.(*UDPRoute)
+			return lo.FlatMap(udpRoute.Spec.ParentRefs, func(parentRef gwapiv1.ParentReference, _ int) []Object {
+				gateway, ok := findParentGateway(gateways, parentRef, udpRoute.Namespace)
+				if !ok {
+					return nil
+				}
+				return lo.FilterMap(listeners, func(l *Listener, _ int) (Object, bool) {
+					return l, l.Gateway.GetURL() == gateway.GetURL() && l.Protocol == gwapiv1.UDPProtocolType && (parentRef.SectionName == nil || l.Name == *parentRef.SectionName)
+				})
+			})
+		},
+	}
+}
+
+// LinkUDPRouteToUDPRouteRuleFunc returns a link function that teaches a topology how to link UDPRouteRules from the
+// UDPRoute they are strongly related to.
+func LinkUDPRouteToUDPRouteRuleFunc() LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRoute"},
+		To:           schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRouteRule"},
+		Relationship: EdgeRelationshipSection,
+		Func: func(child Object) []Object {
+			udpRouteRule := child.(*UDPRouteRule)
+			return []Object{udpRouteRule.UDPRoute}
+		},
+	}
+}
+
+// LinkUDPRouteToServiceFunc returns a link function that teaches a topology how to link Services from known
+// UDPRoutes, based on the UDPRoute's `backendRefs` fields.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkUDPRouteToServiceFunc(udpRoutes []*UDPRoute, strict bool) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRoute"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			service := child.(*Service)
+			return lo.FilterMap(udpRoutes, func(udpRoute *UDPRoute, _ int) (Object, bool) {
+				return udpRoute, lo.ContainsBy(udpRoute.Spec.Rules, func(rule gwapiv1alpha2.UDPRouteRule) bool {
+					backendRefs := lo.Filter(rule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+						return !strict || backendRef.Port == nil
+					})
+					return lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(service, udpRoute.Namespace))
+				})
+			})
+		},
+	}
+}
+
+// LinkUDPRouteToServicePortFunc returns a link function that teaches a topology how to link services ports from known
+// UDPRoutes, based on the UDPRoute's `backendRefs` fields.
+// The link function disregards backend references that do not specify a port number.
+func LinkUDPRouteToServicePortFunc(udpRoutes []*UDPRoute) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRoute"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			servicePort := child.(*ServicePort)
+			return lo.FilterMap(udpRoutes, func(udpRoute *UDPRoute, _ int) (Object, bool) {
+				return udpRoute, lo.ContainsBy(udpRoute.Spec.Rules, func(rule gwapiv1alpha2.UDPRouteRule) bool {
+					backendRefs := lo.Filter(rule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+						return backendRef.Port != nil && int32(*backendRef.Port) == servicePort.Port
+					})
+					return lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(servicePort.Service, udpRoute.Namespace))
+				})
+			})
+		},
+	}
+}
+
+// LinkUDPRouteRuleToServiceFunc returns a link function that teaches a topology how to link Services from known
+// UDPRouteRules, based on the UDPRouteRule's `backendRefs` field.
+// Set the `strict` parameter to `true` to link only to services that have no port specified in the backendRefs.
+func LinkUDPRouteRuleToServiceFunc(udpRouteRules []*UDPRouteRule, strict bool) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRouteRule"},
+		To:           schema.GroupKind{Kind: "Service"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			service := child.(*Service)
+			return lo.FilterMap(udpRouteRules, func(udpRouteRule *UDPRouteRule, _ int) (Object, bool) {
+				backendRefs := lo.Filter(udpRouteRule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+					return !strict || backendRef.Port == nil
+				})
+				return udpRouteRule, lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(service, udpRouteRule.UDPRoute.Namespace))
+			})
+		},
+	}
+}
+
+// LinkUDPRouteRuleToServicePortFunc returns a link function that teaches a topology how to link services ports from
+// known UDPRouteRules, based on the UDPRouteRule's `backendRefs` field.
+// The link function disregards backend references that do not specify a port number.
+func LinkUDPRouteRuleToServicePortFunc(udpRouteRules []*UDPRouteRule) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1alpha2.GroupVersion.Group, Kind: "UDPRouteRule"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			servicePort := child.(*ServicePort)
+			return lo.FilterMap(udpRouteRules, func(udpRouteRule *UDPRouteRule, _ int) (Object, bool) {
+				backendRefs := lo.Filter(udpRouteRule.BackendRefs, func(backendRef gwapiv1.BackendRef, _ int) bool {
+					return backendRef.Port != nil && int32(*backendRef.Port) == servicePort.Port
+				})
+				return udpRouteRule, lo.ContainsBy(backendRefs, backendRefContainsServiceFunc(servicePort.Service, udpRouteRule.UDPRoute.Namespace))
+			})
+		},
+	}
+}
+
 // LinkServiceToServicePortFunc returns a link function that teaches a topology how to link service ports from the
 // Serviceg they are strongly related to.
 func LinkServiceToServicePortFunc() LinkFunc {
 	return LinkFunc{
-		From: schema.GroupKind{Kind: "Service"},
-		To:   schema.GroupKind{Kind: "ServicePort"},
+		From:         schema.GroupKind{Kind: "Service"},
+		To:           schema.GroupKind{Kind: "ServicePort"},
+		Relationship: EdgeRelationshipSection,
 		Func: func(child Object) []Object {
 			servicePort := child.(*ServicePort)
 			return []Object{servicePort.Service}
@@ -409,6 +1112,19 @@ func LinkServiceToServicePortFunc() LinkFunc {
 	}
 }
 
+// findParentGateway returns the Gateway a parent reference of a route points to, if known.
+func findParentGateway(gateways []*Gateway, parentRef gwapiv1.ParentReference, defaultNamespace string) (*Gateway, bool) {
+	parentRefGroup := ptr.Deref(parentRef.Group, gwapiv1.Group(gwapiv1.GroupName))
+	parentRefKind := ptr.Deref(parentRef.Kind, gwapiv1.Kind("Gateway"))
+	if parentRefGroup != gwapiv1.GroupName || parentRefKind != "Gateway" {
+		return nil, false
+	}
+	gatewayNamespace := string(ptr.Deref(parentRef.Namespace, gwapiv1.Namespace(defaultNamespace)))
+	return lo.Find(gateways, func(g *Gateway) bool {
+		return g.Namespace == gatewayNamespace && g.Name == string(parentRef.Name)
+	})
+}
+
 func backendRefContainsServiceFunc(service *Service, defaultNamespace string) func(backendRef gwapiv1.BackendRef) bool {
 	return func(backendRef gwapiv1.BackendRef) bool {
 		return backendRefEqualToService(backendRef, service, defaultNamespace)
@@ -8,6 +8,7 @@ import (
 
 	"github.com/samber/lo"
 	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/ptr"
 	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
 	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
@@ -261,3 +262,370 @@ func TestGatewayAPITopologyWithSectionNames(t *testing.T) {
 		})
 	}
 }
+
+// TestGatewayAPITopologyWithNamedRouteRules tests for a topology of Gateway API resources with HTTPRoute rules named
+// after the `name` field of the rules, which can also be located by their position in the route.
+func TestGatewayAPITopologyWithNamedRouteRules(t *testing.T) {
+	httpRoute := BuildHTTPRoute(func(route *gwapiv1.HTTPRoute) {
+		route.Spec.Rules[0].Name = ptr.To(gwapiv1.SectionName("my-rule"))
+		route.Spec.Rules = append(route.Spec.Rules, gwapiv1.HTTPRouteRule{BackendRefs: route.Spec.Rules[0].BackendRefs})
+	})
+	policyTargeting := func(name, sectionName string) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "HTTPRoute",
+					Name:  gwapiv1.ObjectName(httpRoute.Name),
+				},
+				SectionName: ptr.To(gwapiv1.SectionName(sectionName)),
+			}
+		})
+	}
+
+	topology := NewGatewayAPITopology(
+		WithHTTPRoutes(httpRoute),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(
+			policyTargeting("by-name", "my-rule"),
+			policyTargeting("by-position", "rule-1"),
+			policyTargeting("unnamed", "rule-2"),
+		),
+	)
+
+	links := make(map[string][]string)
+	for _, root := range topology.Targetables().Roots() {
+		linksFromTargetable(topology, root, links)
+	}
+	if expected := []string{"my-http-route#my-rule", "my-http-route#rule-2"}; !slices.Equal(links["my-http-route"], expected) {
+		t.Errorf("expected links from my-http-route to be %v, got %v", expected, links["my-http-route"])
+	}
+
+	routeURL := UrlFromObject(&HTTPRoute{HTTPRoute: httpRoute})
+	testCases := []struct {
+		locator          string
+		expectedName     gwapiv1.SectionName
+		expectedPolicies []string
+	}{
+		{locator: SectionName(routeURL, "my-rule"), expectedName: "my-rule", expectedPolicies: []string{"by-name", "by-position"}},
+		{locator: SectionName(routeURL, "rule-1"), expectedName: "my-rule", expectedPolicies: []string{"by-name", "by-position"}},
+		{locator: SectionName(routeURL, "rule-2"), expectedName: "rule-2", expectedPolicies: []string{"unnamed"}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.locator, func(t *testing.T) {
+			target, found := topology.Targetables().Get(tc.locator)
+			if !found {
+				t.Fatalf("expected to find the route rule located by %s", tc.locator)
+			}
+			rule, ok := target.(*HTTPRouteRule)
+			if !ok || rule.Name != tc.expectedName {
+				t.Fatalf("expected the route rule %s, got %v", tc.expectedName, target)
+			}
+			policies := lo.Map(rule.Policies(), func(policy Policy, _ int) string { return policy.GetName() })
+			slices.Sort(policies)
+			if !slices.Equal(policies, tc.expectedPolicies) {
+				t.Errorf("expected policies %v, got %v", tc.expectedPolicies, policies)
+			}
+			if parents := topology.Targetables().Parents(rule); len(parents) != 1 || parents[0].GetURL() != routeURL {
+				t.Errorf("expected the route as the parent of the rule, got %v", parents)
+			}
+		})
+	}
+
+	for _, policy := range topology.Policies().Items() {
+		if err := topology.DiagnoseTargetRefs(policy).Err(); err != nil {
+			t.Errorf("expected the target refs of %s resolved, got %v", policy.GetName(), err)
+		}
+	}
+
+	restored := RebuildFromSnapshot(topology.Snapshot())
+	if rule, found := restored.Targetables().Get(SectionName(routeURL, "rule-1")); !found || rule.GetURL() != SectionName(routeURL, "my-rule") {
+		t.Errorf("expected the restored topology to resolve the positional locator of the named rule, got %v", rule)
+	}
+}
+
+// TestGatewayAPITopologyWithTLSRoutes tests for a topology of Gateway API resources including TLSRoutes, with and
+// without expanding the sections of the targetables. TLSRoutes are only linked to TLS listeners of the parent Gateways.
+func TestGatewayAPITopologyWithTLSRoutes(t *testing.T) {
+	gateways := []*gwapiv1.Gateway{
+		BuildGateway(func(gateway *gwapiv1.Gateway) {
+			gateway.Spec.Listeners = []gwapiv1.Listener{
+				{Name: "http", Port: 80, Protocol: "HTTP"},
+				{Name: "tls", Port: 443, Protocol: "TLS"},
+			}
+		}),
+		BuildGateway(func(gateway *gwapiv1.Gateway) {
+			gateway.Name = "my-http-gateway"
+		}),
+	}
+	tlsRoute := BuildTLSRoute(func(r *gwapiv1alpha2.TLSRoute) {
+		r.Spec.ParentRefs[0].SectionName = ptr.To(gwapiv1.SectionName("tls"))
+		r.Spec.ParentRefs = append(r.Spec.ParentRefs, gwapiv1.ParentReference{Name: "my-http-gateway"})
+		r.Spec.Rules = append(r.Spec.Rules, gwapiv1alpha2.TLSRouteRule{
+			BackendRefs: []gwapiv1.BackendRef{BuildHTTPBackendRef(func(backendRef *gwapiv1.BackendObjectReference) {
+				backendRef.Port = ptr.To(gwapiv1.PortNumber(80))
+			}).BackendRef},
+		})
+	})
+
+	testCases := []struct {
+		name          string
+		options       []GatewayAPITopologyOptionsFunc
+		expectedLinks map[string][]string
+	}{
+		{
+			name: "without sections",
+			expectedLinks: map[string][]string{
+				"my-gateway-class": {"my-gateway", "my-http-gateway"},
+				"my-gateway":       {"my-http-route", "my-tls-route"},
+				"my-http-route":    {"my-service"},
+				"my-tls-route":     {"my-service"},
+			},
+		},
+		{
+			name: "with sections",
+			options: []GatewayAPITopologyOptionsFunc{
+				ExpandGatewayListeners(),
+				ExpandHTTPRouteRules(),
+				ExpandTLSRouteRules(),
+				ExpandServicePorts(),
+			},
+			expectedLinks: map[string][]string{
+				"my-gateway-class":     {"my-gateway", "my-http-gateway"},
+				"my-gateway":           {"my-gateway#http", "my-gateway#tls"},
+				"my-http-gateway":      {"my-http-gateway#my-listener"},
+				"my-gateway#http":      {"my-http-route"},
+				"my-gateway#tls":       {"my-http-route", "my-tls-route"},
+				"my-http-route":        {"my-http-route#rule-1"},
+				"my-tls-route":         {"my-tls-route#rule-1", "my-tls-route#rule-2"},
+				"my-http-route#rule-1": {"my-service"},
+				"my-tls-route#rule-1":  {"my-service"},
+				"my-tls-route#rule-2":  {"my-service#http"},
+				"my-service":           {"my-service#http"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topology := NewGatewayAPITopology(append([]GatewayAPITopologyOptionsFunc{
+				WithGatewayClasses(BuildGatewayClass()),
+				WithGateways(gateways...),
+				WithHTTPRoutes(BuildHTTPRoute()),
+				WithTLSRoutes(tlsRoute),
+				WithServices(BuildService()),
+				WithGatewayAPITopologyPolicies(buildPolicy(func(policy *TestPolicy) {
+					policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+						LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+							Group: gwapiv1alpha2.GroupName,
+							Kind:  "TLSRoute",
+							Name:  "my-tls-route",
+						},
+					}
+				})),
+			}, tc.options...)...)
+
+			links := make(map[string][]string)
+			for _, root := range topology.Targetables().Roots() {
+				linksFromTargetable(topology, root, links)
+			}
+			for from, tos := range links {
+				expectedTos := tc.expectedLinks[from]
+				slices.Sort(expectedTos)
+				slices.Sort(tos)
+				if !slices.Equal(expectedTos, tos) {
+					t.Errorf("expected links from %s to be %v, got %v", from, expectedTos, tos)
+				}
+			}
+
+			tlsRoutes := topology.Targetables().Items(func(o Object) bool {
+				return o.GroupVersionKind().Kind == "TLSRoute"
+			})
+			if len(tlsRoutes) != 1 || len(tlsRoutes[0].Policies()) != 1 {
+				t.Errorf("expected 1 TLSRoute with 1 policy attached, got %v", tlsRoutes)
+			}
+		})
+	}
+}
+
+// TestGatewayAPITopologyWithUDPRoutes tests for a topology of Gateway API resources including UDPRoutes, which are
+// only linked to UDP listeners of the parent Gateways.
+func TestGatewayAPITopologyWithUDPRoutes(t *testing.T) {
+	gateways := []*gwapiv1.Gateway{
+		BuildGateway(func(gateway *gwapiv1.Gateway) {
+			gateway.Spec.Listeners = []gwapiv1.Listener{
+				{Name: "http", Port: 80, Protocol: gwapiv1.HTTPProtocolType},
+				{Name: "dns", Port: 53, Protocol: gwapiv1.UDPProtocolType},
+			}
+		}),
+		BuildGateway(func(gateway *gwapiv1.Gateway) {
+			gateway.Name = "my-http-gateway"
+		}),
+	}
+	udpRoute := BuildUDPRoute(func(r *gwapiv1alpha2.UDPRoute) {
+		r.Spec.ParentRefs = append(r.Spec.ParentRefs, gwapiv1.ParentReference{Name: "my-http-gateway"})
+	})
+
+	testCases := []struct {
+		name          string
+		options       []GatewayAPITopologyOptionsFunc
+		expectedLinks map[string][]string
+	}{
+		{
+			name: "without sections",
+			expectedLinks: map[string][]string{
+				"my-gateway-class": {"my-gateway", "my-http-gateway"},
+				"my-gateway":       {"my-udp-route"},
+				"my-udp-route":     {"my-service"},
+			},
+		},
+		{
+			name: "with sections",
+			options: []GatewayAPITopologyOptionsFunc{
+				ExpandGatewayListeners(),
+				ExpandUDPRouteRules(),
+				ExpandServicePorts(),
+			},
+			expectedLinks: map[string][]string{
+				"my-gateway-class":    {"my-gateway", "my-http-gateway"},
+				"my-gateway":          {"my-gateway#http", "my-gateway#dns"},
+				"my-http-gateway":     {"my-http-gateway#my-listener"},
+				"my-gateway#dns":      {"my-udp-route"},
+				"my-udp-route":        {"my-udp-route#rule-1"},
+				"my-udp-route#rule-1": {"my-service"},
+				"my-service":          {"my-service#http"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topology := NewGatewayAPITopology(append([]GatewayAPITopologyOptionsFunc{
+				WithGatewayClasses(BuildGatewayClass()),
+				WithGateways(gateways...),
+				WithUDPRoutes(udpRoute),
+				WithServices(BuildService()),
+			}, tc.options...)...)
+
+			links := make(map[string][]string)
+			for _, root := range topology.Targetables().Roots() {
+				linksFromTargetable(topology, root, links)
+			}
+			for from, tos := range links {
+				expectedTos := tc.expectedLinks[from]
+				slices.Sort(expectedTos)
+				slices.Sort(tos)
+				if !slices.Equal(expectedTos, tos) {
+					t.Errorf("expected links from %s to be %v, got %v", from, expectedTos, tos)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayAPITopologyWithSecrets(t *testing.T) {
+	certificateRef := func(name string, namespace *string) gwapiv1.SecretObjectReference {
+		return gwapiv1.SecretObjectReference{Name: gwapiv1.ObjectName(name), Namespace: (*gwapiv1.Namespace)(namespace)}
+	}
+	gateway := BuildGateway(func(gateway *gwapiv1.Gateway) {
+		gateway.Spec.Listeners = []gwapiv1.Listener{
+			{Name: "http", Port: 80, Protocol: gwapiv1.HTTPProtocolType},
+			{Name: "https", Port: 443, Protocol: gwapiv1.HTTPSProtocolType, TLS: &gwapiv1.GatewayTLSConfig{
+				CertificateRefs: []gwapiv1.SecretObjectReference{certificateRef("my-secret", nil)},
+			}},
+			{Name: "https-shared", Port: 8443, Protocol: gwapiv1.HTTPSProtocolType, TLS: &gwapiv1.GatewayTLSConfig{
+				CertificateRefs: []gwapiv1.SecretObjectReference{certificateRef("shared-secret", ptr.To("shared"))},
+			}},
+		}
+	})
+	secrets := []*core.Secret{
+		BuildSecret(),
+		BuildSecret(func(s *core.Secret) { s.Name = "shared-secret"; s.Namespace = "shared" }),
+		BuildSecret(func(s *core.Secret) { s.Name = "unreferenced-secret" }),
+	}
+
+	testCases := []struct {
+		name            string
+		options         []GatewayAPITopologyOptionsFunc
+		expectedParents map[string][]string
+	}{
+		{
+			name: "without sections",
+			expectedParents: map[string][]string{
+				"my-secret":           {"my-gateway"},
+				"shared-secret":       {"my-gateway"},
+				"unreferenced-secret": nil,
+			},
+		},
+		{
+			name:    "with sections",
+			options: []GatewayAPITopologyOptionsFunc{ExpandGatewayListeners()},
+			expectedParents: map[string][]string{
+				"my-secret":           {"my-gateway#https"},
+				"shared-secret":       {"my-gateway#https-shared"},
+				"unreferenced-secret": nil,
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topology := NewGatewayAPITopology(append([]GatewayAPITopologyOptionsFunc{
+				WithGateways(gateway),
+				WithSecrets(secrets...),
+			}, tc.options...)...)
+
+			objects := topology.Objects().Items()
+			if len(objects) != len(secrets) {
+				t.Fatalf("expected %d secrets, got %d", len(secrets), len(objects))
+			}
+			for _, secret := range objects {
+				parents := lo.Map(topology.Targetables().Parents(secret), func(p Targetable, _ int) string { return p.GetName() })
+				if expected := tc.expectedParents[secret.GetName()]; !slices.Equal(expected, parents) {
+					t.Errorf("expected parents of %s to be %v, got %v", secret.GetName(), expected, parents)
+				}
+			}
+		})
+	}
+}
+
+func TestGatewayAPITopologyComposition(t *testing.T) {
+	// apples stand for the objects of another domain, e.g. DNS records, one per listener of the gateways
+	apples := []*Apple{{Name: "my-listener"}, {Name: "unrelated"}}
+	gatewayAPIOptions := []GatewayAPITopologyOptionsFunc{
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+	}
+	preset := NewGatewayAPITopology(gatewayAPIOptions...)
+	listeners := preset.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "Listener" })
+	linkListenerToApple := LinkFunc{
+		From: schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"},
+		To:   schema.GroupKind{Group: TestGroupName, Kind: "Apple"},
+		Func: func(child Object) []Object {
+			return lo.FilterMap(listeners, func(listener Targetable, _ int) (Object, bool) {
+				return listener, string(listener.(*Listener).Name) == child.GetName()
+			})
+		},
+	}
+
+	topology := NewTopology(
+		WithGatewayAPI(gatewayAPIOptions...),
+		WithTargetables(apples...),
+		WithLinks(linkListenerToApple),
+	)
+
+	// the preset is the same as the one of NewGatewayAPITopology
+	presetURLs := lo.Map(preset.Targetables().Items(), MapTargetableToURLFunc)
+	urls := lo.Map(topology.Targetables().Items(), MapTargetableToURLFunc)
+	if len(urls) != len(presetURLs)+len(apples) || len(lo.Intersect(urls, presetURLs)) != len(presetURLs) {
+		t.Errorf("expected the targetables of the preset and the apples, got %v", urls)
+	}
+	gatewayClass := topology.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "GatewayClass" })[0]
+	paths := topology.Targetables().Paths(gatewayClass, apples[0])
+	if len(paths) != 1 || len(paths[0]) != 4 {
+		t.Errorf("expected a path from the gateway class down to the apple through the gateway and the listener, got %v", paths)
+	}
+	if parents := topology.Targetables().Parents(apples[1]); len(parents) != 0 {
+		t.Errorf("expected the unrelated apple not linked, got %v", parents)
+	}
+}
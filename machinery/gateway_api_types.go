@@ -1,8 +1,6 @@
 package machinery
 
 import (
-	"fmt"
-
 	core "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/utils/ptr"
@@ -10,8 +8,6 @@ import (
 	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
 )
 
-const nameSectionNameURLSeparator = '#'
-
 // These are wrappers for Gateway API types so instances can be used as targetables in the topology.
 // Targateables typically store back references to the policies that are attached to them.
 // The implementation of GetURL() must return a unique identifier for the wrapped object that matches the one
@@ -62,6 +58,8 @@ type Listener struct {
 
 	Gateway          *Gateway
 	attachedPolicies []Policy
+
+	sectionLocator
 }
 
 var _ Targetable = &Listener{}
@@ -77,7 +75,7 @@ func (l *Listener) GroupVersionKind() schema.GroupVersionKind {
 func (l *Listener) SetGroupVersionKind(schema.GroupVersionKind) {}
 
 func (l *Listener) GetURL() string {
-	return namespacedSectionName(UrlFromObject(l.Gateway), l.Name)
+	return l.sectionNameSeparator().SectionName(UrlFromObject(l.Gateway), l.Name)
 }
 
 func (l *Listener) GetNamespace() string {
@@ -85,7 +83,7 @@ func (l *Listener) GetNamespace() string {
 }
 
 func (l *Listener) GetName() string {
-	return namespacedSectionName(l.Gateway.GetName(), l.Name)
+	return l.sectionNameSeparator().SectionName(l.Gateway.GetName(), l.Name)
 }
 
 func (l *Listener) SetPolicies(policies []Policy) {
@@ -120,11 +118,14 @@ type HTTPRouteRule struct {
 	*gwapiv1.HTTPRouteRule
 
 	HTTPRoute        *HTTPRoute
-	Name             gwapiv1.SectionName // TODO(guicassolato): Use the `name` field of the HTTPRouteRule once it's implemented - https://github.com/kubernetes-sigs/gateway-api/pull/2985
+	Name             gwapiv1.SectionName
+	positionalName   gwapiv1.SectionName
 	attachedPolicies []Policy
+
+	sectionLocator
 }
 
-var _ Targetable = &HTTPRouteRule{}
+var _ AliasedTargetable = &HTTPRouteRule{}
 
 func (r *HTTPRouteRule) GroupVersionKind() schema.GroupVersionKind {
 	return schema.GroupVersionKind{
@@ -137,7 +138,12 @@ func (r *HTTPRouteRule) GroupVersionKind() schema.GroupVersionKind {
 func (r *HTTPRouteRule) SetGroupVersionKind(schema.GroupVersionKind) {}
 
 func (r *HTTPRouteRule) GetURL() string {
-	return namespacedSectionName(UrlFromObject(r.HTTPRoute), r.Name)
+	return r.sectionNameSeparator().SectionName(UrlFromObject(r.HTTPRoute), r.Name)
+}
+
+// LocatorAliases returns the positional locator of a named rule, e.g. when targeted by policies by position.
+func (r *HTTPRouteRule) LocatorAliases() []string {
+	return routeRuleLocatorAliases(r.HTTPRoute, r.Name, r.positionalName, r.sectionNameSeparator())
 }
 
 func (r *HTTPRouteRule) GetNamespace() string {
@@ -145,7 +151,7 @@ func (r *HTTPRouteRule) GetNamespace() string {
 }
 
 func (r *HTTPRouteRule) GetName() string {
-	return namespacedSectionName(r.HTTPRoute.Name, r.Name)
+	return r.sectionNameSeparator().SectionName(r.HTTPRoute.Name, r.Name)
 }
 
 func (r *HTTPRouteRule) SetPolicies(policies []Policy) {
@@ -156,6 +162,142 @@ func (r *HTTPRouteRule) Policies() []Policy {
 	return r.attachedPolicies
 }
 
+type TLSRoute struct {
+	*gwapiv1alpha2.TLSRoute
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &TLSRoute{}
+
+func (r *TLSRoute) GetURL() string {
+	return UrlFromObject(r)
+}
+
+func (r *TLSRoute) SetPolicies(policies []Policy) {
+	r.attachedPolicies = policies
+}
+
+func (r *TLSRoute) Policies() []Policy {
+	return r.attachedPolicies
+}
+
+type TLSRouteRule struct {
+	*gwapiv1alpha2.TLSRouteRule
+
+	TLSRoute         *TLSRoute
+	Name             gwapiv1.SectionName
+	positionalName   gwapiv1.SectionName
+	attachedPolicies []Policy
+
+	sectionLocator
+}
+
+var _ AliasedTargetable = &TLSRouteRule{}
+
+func (r *TLSRouteRule) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gwapiv1alpha2.GroupName,
+		Version: gwapiv1alpha2.GroupVersion.Version,
+		Kind:    "TLSRouteRule",
+	}
+}
+
+func (r *TLSRouteRule) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (r *TLSRouteRule) GetURL() string {
+	return r.sectionNameSeparator().SectionName(UrlFromObject(r.TLSRoute), r.Name)
+}
+
+// LocatorAliases returns the positional locator of a named rule, e.g. when targeted by policies by position.
+func (r *TLSRouteRule) LocatorAliases() []string {
+	return routeRuleLocatorAliases(r.TLSRoute, r.Name, r.positionalName, r.sectionNameSeparator())
+}
+
+func (r *TLSRouteRule) GetNamespace() string {
+	return r.TLSRoute.GetNamespace()
+}
+
+func (r *TLSRouteRule) GetName() string {
+	return r.sectionNameSeparator().SectionName(r.TLSRoute.Name, r.Name)
+}
+
+func (r *TLSRouteRule) SetPolicies(policies []Policy) {
+	r.attachedPolicies = policies
+}
+
+func (r *TLSRouteRule) Policies() []Policy {
+	return r.attachedPolicies
+}
+
+type UDPRoute struct {
+	*gwapiv1alpha2.UDPRoute
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &UDPRoute{}
+
+func (r *UDPRoute) GetURL() string {
+	return UrlFromObject(r)
+}
+
+func (r *UDPRoute) SetPolicies(policies []Policy) {
+	r.attachedPolicies = policies
+}
+
+func (r *UDPRoute) Policies() []Policy {
+	return r.attachedPolicies
+}
+
+type UDPRouteRule struct {
+	*gwapiv1alpha2.UDPRouteRule
+
+	UDPRoute         *UDPRoute
+	Name             gwapiv1.SectionName
+	positionalName   gwapiv1.SectionName
+	attachedPolicies []Policy
+
+	sectionLocator
+}
+
+var _ AliasedTargetable = &UDPRouteRule{}
+
+func (r *UDPRouteRule) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gwapiv1alpha2.GroupName,
+		Version: gwapiv1alpha2.GroupVersion.Version,
+		Kind:    "UDPRouteRule",
+	}
+}
+
+func (r *UDPRouteRule) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (r *UDPRouteRule) GetURL() string {
+	return r.sectionNameSeparator().SectionName(UrlFromObject(r.UDPRoute), r.Name)
+}
+
+// LocatorAliases returns the positional locator of a named rule, e.g. when targeted by policies by position.
+func (r *UDPRouteRule) LocatorAliases() []string {
+	return routeRuleLocatorAliases(r.UDPRoute, r.Name, r.positionalName, r.sectionNameSeparator())
+}
+
+func (r *UDPRouteRule) GetNamespace() string {
+	return r.UDPRoute.GetNamespace()
+}
+
+func (r *UDPRouteRule) GetName() string {
+	return r.sectionNameSeparator().SectionName(r.UDPRoute.Name, r.Name)
+}
+
+func (r *UDPRouteRule) SetPolicies(policies []Policy) {
+	r.attachedPolicies = policies
+}
+
+func (r *UDPRouteRule) Policies() []Policy {
+	return r.attachedPolicies
+}
+
 type Service struct {
 	*core.Service
 
@@ -181,6 +323,8 @@ type ServicePort struct {
 
 	Service          *Service
 	attachedPolicies []Policy
+
+	sectionLocator
 }
 
 var _ Targetable = &ServicePort{}
@@ -194,7 +338,7 @@ func (p *ServicePort) GroupVersionKind() schema.GroupVersionKind {
 func (p *ServicePort) SetGroupVersionKind(schema.GroupVersionKind) {}
 
 func (p *ServicePort) GetURL() string {
-	return namespacedSectionName(UrlFromObject(p.Service), gwapiv1.SectionName(p.Name))
+	return p.sectionNameSeparator().SectionName(UrlFromObject(p.Service), gwapiv1.SectionName(p.Name))
 }
 
 func (p *ServicePort) GetNamespace() string {
@@ -202,7 +346,7 @@ func (p *ServicePort) GetNamespace() string {
 }
 
 func (p *ServicePort) GetName() string {
-	return namespacedSectionName(p.Service.Name, gwapiv1.SectionName(p.Name))
+	return p.sectionNameSeparator().SectionName(p.Service.Name, gwapiv1.SectionName(p.Name))
 }
 
 func (p *ServicePort) SetPolicies(policies []Policy) {
@@ -213,6 +357,18 @@ func (p *ServicePort) Policies() []Policy {
 	return p.attachedPolicies
 }
 
+// Secret is a Secret referred by the gateways, e.g. in the TLS configuration of the listeners. Secrets are not
+// targetable.
+type Secret struct {
+	*core.Secret
+}
+
+var _ Object = &Secret{}
+
+func (s *Secret) GetURL() string {
+	return UrlFromObject(s)
+}
+
 // These are Gateway API target reference types that implement the PolicyTargetReference interface, so policies'
 // targetRef instances can be treated as Objects whose GetURL() functions return the unique identifier of the
 // corresponding targetable the reference points to.
@@ -312,9 +468,13 @@ func (t LocalPolicyTargetReferenceWithSectionName) GetName() string {
 	if t.SectionName == nil {
 		return string(t.LocalPolicyTargetReference.Name)
 	}
-	return namespacedSectionName(string(t.LocalPolicyTargetReference.Name), *t.SectionName)
+	return SectionName(string(t.LocalPolicyTargetReference.Name), *t.SectionName)
 }
 
-func namespacedSectionName(namespace string, sectionName gwapiv1.SectionName) string {
-	return fmt.Sprintf("%s%s%s", namespace, string(nameSectionNameURLSeparator), sectionName)
+// routeRuleLocatorAliases returns the positional locator of a rule of a route, if the rule is named otherwise.
+func routeRuleLocatorAliases(route Object, name, positionalName gwapiv1.SectionName, separator SectionNameSeparator) []string {
+	if positionalName == "" || positionalName == name {
+		return nil
+	}
+	return []string{separator.SectionName(UrlFromObject(route), positionalName)}
 }
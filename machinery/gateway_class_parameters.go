@@ -0,0 +1,64 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// WithGatewayClassParameters links the objects of the topology of the given kinds, e.g. ConfigMaps or the custom
+// resources of a provider, from the GatewayClasses that refer to them in their `parametersRef`, so reconcilers can read
+// the configuration of the providers through the topology (see GatewayClassParameters).
+// The objects must be added to the topology with WithGatewayAPITopologyObjects. If no kind is given, the
+// parameters of kind ConfigMap are linked.
+func WithGatewayClassParameters(kinds ...schema.GroupKind) GatewayAPITopologyOptionsFunc {
+	if len(kinds) == 0 {
+		kinds = []schema.GroupKind{{Kind: "ConfigMap"}}
+	}
+	return func(o *GatewayAPITopologyOptions) {
+		o.GatewayClassParametersKinds = lo.Uniq(append(o.GatewayClassParametersKinds, kinds...))
+	}
+}
+
+// LinkGatewayClassToParametersFunc returns a link function that teaches a topology how to link objects of a given kind
+// from known GatewayClasses, based on the GatewayClass's `parametersRef` field.
+// Parameters of cluster-scoped kinds are referred to without namespace.
+func LinkGatewayClassToParametersFunc(gatewayClasses []*GatewayClass, kind schema.GroupKind) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "GatewayClass"},
+		To:           kind,
+		Relationship: EdgeRelationshipParametersRef,
+		Func: func(child Object) []Object {
+			return lo.FilterMap(gatewayClasses, func(gatewayClass *GatewayClass, _ int) (Object, bool) {
+				return gatewayClass, parametersRefEqualToObject(gatewayClass.Spec.ParametersRef, child)
+			})
+		},
+	}
+}
+
+// GatewayClassParameters returns the object a GatewayClass refers to in its `parametersRef`, if linked from the
+// GatewayClass in the topology (see WithGatewayClassParameters).
+func GatewayClassParameters(topology *Topology, gatewayClass *GatewayClass) (Object, bool) {
+	return lo.Find(topology.Objects().Children(gatewayClass), func(child Object) bool {
+		return parametersRefEqualToObject(gatewayClass.Spec.ParametersRef, child)
+	})
+}
+
+func parametersRefEqualToObject(parametersRef *gwapiv1.ParametersReference, obj Object) bool {
+	if parametersRef == nil {
+		return false
+	}
+	gk := obj.GroupVersionKind().GroupKind()
+	group := string(parametersRef.Group)
+	if group == "" {
+		group = core.GroupName
+	}
+	if group != gk.Group || string(parametersRef.Kind) != gk.Kind || parametersRef.Name != obj.GetName() {
+		return false
+	}
+	if parametersRef.Namespace == nil {
+		return obj.GetNamespace() == ""
+	}
+	return string(*parametersRef.Namespace) == obj.GetNamespace()
+}
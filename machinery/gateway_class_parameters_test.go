@@ -0,0 +1,87 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type testConfigMap struct {
+	*core.ConfigMap
+}
+
+func (c *testConfigMap) GetURL() string {
+	return UrlFromObject(c)
+}
+
+func TestGatewayClassParameters(t *testing.T) {
+	configMap := &testConfigMap{&core.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-params", Namespace: "my-namespace"},
+		Data:       map[string]string{"replicas": "2"},
+	}}
+	sameNameConfigMap := &testConfigMap{&core.ConfigMap{
+		TypeMeta:   metav1.TypeMeta{APIVersion: "v1", Kind: "ConfigMap"},
+		ObjectMeta: metav1.ObjectMeta{Name: "my-params", Namespace: "other-namespace"},
+	}}
+	info := &Info{Name: "my-provider-params"}
+
+	withConfigMap := BuildGatewayClass(func(gc *gwapiv1.GatewayClass) {
+		gc.Name = "with-configmap"
+		gc.Spec.ParametersRef = &gwapiv1.ParametersReference{Kind: "ConfigMap", Name: "my-params", Namespace: ptr.To(gwapiv1.Namespace("my-namespace"))}
+	})
+	withProviderParams := BuildGatewayClass(func(gc *gwapiv1.GatewayClass) {
+		gc.Name = "with-provider-params"
+		gc.Spec.ParametersRef = &gwapiv1.ParametersReference{Group: TestGroupName, Kind: "Info", Name: "my-provider-params"}
+	})
+	withoutParams := BuildGatewayClass(func(gc *gwapiv1.GatewayClass) { gc.Name = "without-params" })
+
+	gatewayClasses := func(topology *Topology) map[string]*GatewayClass {
+		return lo.SliceToMap(topology.Targetables().Items(IsKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "GatewayClass"})), func(t Targetable) (string, *GatewayClass) {
+			return t.GetName(), t.(*GatewayClass)
+		})
+	}
+
+	// not linked without the option
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(withConfigMap),
+		WithGatewayAPITopologyObjects(configMap),
+	)
+	if _, found := GatewayClassParameters(topology, gatewayClasses(topology)["with-configmap"]); found {
+		t.Errorf("expected the parameters not linked without the option")
+	}
+
+	topology = NewGatewayAPITopology(
+		WithGatewayClasses(withConfigMap, withProviderParams, withoutParams),
+		WithGatewayAPITopologyObjects(configMap, sameNameConfigMap, info),
+		WithGatewayClassParameters(),
+		WithGatewayClassParameters(schema.GroupKind{Group: TestGroupName, Kind: "Info"}),
+	)
+	classes := gatewayClasses(topology)
+
+	params, found := GatewayClassParameters(topology, classes["with-configmap"])
+	if !found || params.(*testConfigMap).Data["replicas"] != "2" {
+		t.Errorf("expected the configmap linked from the gateway class, got %v", params)
+	}
+	if params, found := GatewayClassParameters(topology, classes["with-provider-params"]); !found || params.GetName() != "my-provider-params" {
+		t.Errorf("expected the cluster-scoped provider parameters linked from the gateway class, got %v", params)
+	}
+	if params, found := GatewayClassParameters(topology, classes["without-params"]); found {
+		t.Errorf("expected no parameters of the gateway class without parametersRef, got %v", params)
+	}
+	if parents := topology.Targetables().Parents(sameNameConfigMap); len(parents) != 0 {
+		t.Errorf("expected the configmap in another namespace not linked, got %v", parents)
+	}
+
+	edges := topology.Edges(EdgesWithRelationship(EdgeRelationshipParametersRef))
+	if len(edges) != 2 {
+		t.Errorf("expected 2 parametersRef edges, got %d", len(edges))
+	}
+}
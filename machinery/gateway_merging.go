@@ -0,0 +1,76 @@
+package machinery
+
+import (
+	"strings"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// MergedIntoGatewayAnnotation is the annotation of a gateway whose value is the gateway it is merged into, as
+// "name" (same namespace) or "namespace/name".
+const MergedIntoGatewayAnnotation = "policy-machinery.kuadrant.io/merged-into"
+
+// GatewayMergingFunc returns the gateway a given gateway is merged into, if any, e.g. the parent of a child gateway
+// merged into it, or the representative of a group of gateways that share the same infrastructure.
+type GatewayMergingFunc func(gateway *Gateway) (parent k8stypes.NamespacedName, merged bool)
+
+// GatewayMergedByAnnotation returns a gateway merging function that reads the gateway a gateway is merged into from an
+// annotation, such as MergedIntoGatewayAnnotation, whose value is "name" (same namespace) or "namespace/name".
+func GatewayMergedByAnnotation(annotation string) GatewayMergingFunc {
+	return func(gateway *Gateway) (k8stypes.NamespacedName, bool) {
+		return parseGatewayRef(gateway.GetAnnotations()[annotation], gateway.Namespace)
+	}
+}
+
+// GatewayMergedByInfrastructureLabel returns a gateway merging function that reads the name of the gateway a gateway
+// is merged into, in the same namespace, from a label of the gateway's infrastructure (spec.infrastructure.labels).
+func GatewayMergedByInfrastructureLabel(key string) GatewayMergingFunc {
+	return func(gateway *Gateway) (k8stypes.NamespacedName, bool) {
+		if gateway.Spec.Infrastructure == nil {
+			return k8stypes.NamespacedName{}, false
+		}
+		return parseGatewayRef(string(gateway.Spec.Infrastructure.Labels[gwapiv1.LabelKey(key)]), gateway.Namespace)
+	}
+}
+
+// WithGatewayMerging opts in to link gateways merged into other gateways, as told by the given gateway merging
+// functions, to the gateways they are merged into. Policies attached to the parent gateways then flow to the merged
+// gateways in the calculation of effective policies.
+func WithGatewayMerging(merging ...GatewayMergingFunc) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.GatewayMerging = append(o.GatewayMerging, merging...)
+	}
+}
+
+// LinkGatewayToMergedGatewayFunc returns a link function that teaches a topology how to link gateways merged into
+// known gateways, as told by the given gateway merging functions. Gateways merged into themselves are ignored.
+func LinkGatewayToMergedGatewayFunc(gateways []*Gateway, merging ...GatewayMergingFunc) LinkFunc {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"}
+	return LinkFunc{
+		From: gatewayKind,
+		To:   gatewayKind,
+		Func: func(child Object) []Object {
+			gateway := child.(*Gateway)
+			parents := lo.FilterMap(merging, func(f GatewayMergingFunc, _ int) (k8stypes.NamespacedName, bool) {
+				parent, merged := f(gateway)
+				return parent, merged && (parent.Namespace != gateway.Namespace || parent.Name != gateway.Name)
+			})
+			return lo.FilterMap(gateways, func(g *Gateway, _ int) (Object, bool) {
+				return g, lo.Contains(parents, k8stypes.NamespacedName{Namespace: g.Namespace, Name: g.Name})
+			})
+		},
+	}
+}
+
+func parseGatewayRef(ref, defaultNamespace string) (k8stypes.NamespacedName, bool) {
+	if ref == "" {
+		return k8stypes.NamespacedName{}, false
+	}
+	if namespace, name, found := strings.Cut(ref, string(k8stypes.Separator)); found {
+		return k8stypes.NamespacedName{Namespace: namespace, Name: name}, true
+	}
+	return k8stypes.NamespacedName{Namespace: defaultNamespace, Name: ref}, true
+}
@@ -0,0 +1,72 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestGatewayMerging(t *testing.T) {
+	parent := BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "parent" })
+	annotated := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Name = "annotated"
+		g.Annotations = map[string]string{MergedIntoGatewayAnnotation: "my-namespace/parent"}
+	})
+	labeled := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Name = "labeled"
+		g.Spec.Infrastructure = &gwapiv1.GatewayInfrastructure{Labels: map[gwapiv1.LabelKey]gwapiv1.LabelValue{"example.com/group": "parent"}}
+	})
+	self := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Name = "self"
+		g.Annotations = map[string]string{MergedIntoGatewayAnnotation: "self"}
+	})
+	route := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) { r.Spec.ParentRefs[0].Name = "labeled" })
+	policy := buildPolicy(func(p *TestPolicy) {
+		p.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "parent"},
+		}
+	})
+
+	gateways := []*gwapiv1.Gateway{parent, annotated, labeled, self}
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+
+	// not opted in
+	topology := NewGatewayAPITopology(WithGateways(gateways...), WithHTTPRoutes(route))
+	if roots := topology.Targetables().Roots(); len(roots) != len(gateways) {
+		t.Errorf("expected gateways not linked without merging, got %d roots", len(roots))
+	}
+
+	topology = NewGatewayAPITopology(
+		WithGateways(gateways...),
+		WithHTTPRoutes(route),
+		WithGatewayAPITopologyPolicies(policy),
+		WithGatewayMerging(GatewayMergedByAnnotation(MergedIntoGatewayAnnotation), GatewayMergedByInfrastructureLabel("example.com/group")),
+	)
+	gatewaysByName := make(map[string]Targetable)
+	for _, g := range topology.Targetables().ByKind(gatewayKind) {
+		gatewaysByName[g.GetName()] = g
+	}
+	for _, name := range []string{"annotated", "labeled"} {
+		parents := topology.Targetables().Parents(gatewaysByName[name])
+		if len(parents) != 1 || parents[0].GetName() != "parent" {
+			t.Errorf("expected %s merged into parent, got %v", name, parents)
+		}
+	}
+	if parents := topology.Targetables().Parents(gatewaysByName["self"]); len(parents) != 0 {
+		t.Errorf("expected gateway merged into itself ignored, got %v", parents)
+	}
+
+	routes := topology.Targetables().ByKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"})
+	paths := topology.Targetables().Paths(gatewaysByName["parent"], routes[0])
+	if len(paths) != 1 {
+		t.Fatalf("expected 1 path from the parent gateway to the route, got %d", len(paths))
+	}
+	effective, found := EffectivePolicyForPath[*TestPolicy](topology, paths[0])
+	if !found || effective.Spec.TargetRef.Name != "parent" {
+		t.Errorf("expected the policy of the parent gateway to flow to the merged gateway, got %v", effective)
+	}
+}
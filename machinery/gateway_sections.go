@@ -0,0 +1,181 @@
+package machinery
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// GatewayInfrastructureSectionName is the name of the section of a gateway for its infrastructure, e.g. to target the
+// load balancer of a gateway with a policy whose target reference has this section name.
+const GatewayInfrastructureSectionName gwapiv1.SectionName = "infrastructure"
+
+// GatewayAddressSectionName returns the name of the section of a gateway for the address at a given position (starting
+// at 0) of the `addresses` of the gateway, i.e. "address-1" for the first address.
+func GatewayAddressSectionName(i int) gwapiv1.SectionName {
+	return gwapiv1.SectionName(fmt.Sprintf("address-%d", i+1))
+}
+
+// GatewayInfrastructure is the infrastructure of a gateway (`spec.infrastructure`), as a section of the gateway, so
+// policies that configure the load balancer or other provider-specific settings of a gateway can target it.
+// It exists for every gateway, whether the gateway specifies infrastructure attributes or not.
+type GatewayInfrastructure struct {
+	*gwapiv1.GatewayInfrastructure
+
+	Gateway          *Gateway
+	attachedPolicies []Policy
+
+	sectionLocator
+}
+
+var _ Targetable = &GatewayInfrastructure{}
+
+func (i *GatewayInfrastructure) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gwapiv1.GroupName,
+		Version: gwapiv1.GroupVersion.Version,
+		Kind:    "GatewayInfrastructure",
+	}
+}
+
+func (i *GatewayInfrastructure) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (i *GatewayInfrastructure) GetURL() string {
+	return i.sectionNameSeparator().SectionName(UrlFromObject(i.Gateway), GatewayInfrastructureSectionName)
+}
+
+func (i *GatewayInfrastructure) GetNamespace() string {
+	return i.Gateway.GetNamespace()
+}
+
+func (i *GatewayInfrastructure) GetName() string {
+	return i.sectionNameSeparator().SectionName(i.Gateway.GetName(), GatewayInfrastructureSectionName)
+}
+
+func (i *GatewayInfrastructure) SetPolicies(policies []Policy) {
+	i.attachedPolicies = policies
+}
+
+func (i *GatewayInfrastructure) Policies() []Policy {
+	return i.attachedPolicies
+}
+
+// GatewayAddress is one of the `addresses` of a gateway, as a section of the gateway named after its position (see
+// GatewayAddressSectionName), so policies can target the addresses of a gateway individually.
+type GatewayAddress struct {
+	*gwapiv1.GatewayAddress
+
+	Gateway          *Gateway
+	Name             gwapiv1.SectionName
+	attachedPolicies []Policy
+
+	sectionLocator
+}
+
+var _ Targetable = &GatewayAddress{}
+
+func (a *GatewayAddress) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gwapiv1.GroupName,
+		Version: gwapiv1.GroupVersion.Version,
+		Kind:    "GatewayAddress",
+	}
+}
+
+func (a *GatewayAddress) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (a *GatewayAddress) GetURL() string {
+	return a.sectionNameSeparator().SectionName(UrlFromObject(a.Gateway), a.Name)
+}
+
+func (a *GatewayAddress) GetNamespace() string {
+	return a.Gateway.GetNamespace()
+}
+
+func (a *GatewayAddress) GetName() string {
+	return a.sectionNameSeparator().SectionName(a.Gateway.GetName(), a.Name)
+}
+
+func (a *GatewayAddress) SetPolicies(policies []Policy) {
+	a.attachedPolicies = policies
+}
+
+func (a *GatewayAddress) Policies() []Policy {
+	return a.attachedPolicies
+}
+
+// ExpandGatewayInfrastructure adds the targetable infrastructure of the gateways to the options to initialize a new
+// Gateway API topology, as sections of the gateways named "infrastructure" (see GatewayInfrastructureSectionName).
+func ExpandGatewayInfrastructure() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExpandGatewayInfrastructure = true
+	}
+}
+
+// ExpandGatewayAddresses adds the targetable addresses of the gateways to the options to initialize a new Gateway API
+// topology, as sections of the gateways named after their position, e.g. "address-1" (see GatewayAddressSectionName).
+func ExpandGatewayAddresses() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExpandGatewayAddresses = true
+	}
+}
+
+// GatewayInfrastructureFromGatewayFunc returns the targetable infrastructure of a targetable gateway, unless the
+// gateway has a listener with the same name as the section of the infrastructure, which takes precedence.
+func GatewayInfrastructureFromGatewayFunc(gateway *Gateway, _ int) []*GatewayInfrastructure {
+	if gatewayHasListener(gateway, GatewayInfrastructureSectionName) {
+		return nil
+	}
+	infrastructure := lo.FromPtrOr(gateway.Spec.Infrastructure, gwapiv1.GatewayInfrastructure{})
+	return []*GatewayInfrastructure{{
+		GatewayInfrastructure: &infrastructure,
+		Gateway:               gateway,
+	}}
+}
+
+// GatewayAddressesFromGatewayFunc returns a list of targetable addresses from a targetable gateway, except the ones
+// whose section names are also names of listeners of the gateway, which take precedence.
+func GatewayAddressesFromGatewayFunc(gateway *Gateway, _ int) []*GatewayAddress {
+	return lo.FilterMap(gateway.Spec.Addresses, func(address gwapiv1.GatewayAddress, i int) (*GatewayAddress, bool) {
+		name := GatewayAddressSectionName(i)
+		return &GatewayAddress{
+			GatewayAddress: &address,
+			Gateway:        gateway,
+			Name:           name,
+		}, !gatewayHasListener(gateway, name)
+	})
+}
+
+// LinkGatewayToGatewayInfrastructureFunc returns a link function that teaches a topology how to link the
+// infrastructure of the gateways from the gateways.
+func LinkGatewayToGatewayInfrastructureFunc() LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "GatewayInfrastructure"},
+		Relationship: EdgeRelationshipSection,
+		Func: func(child Object) []Object {
+			infrastructure := child.(*GatewayInfrastructure)
+			return []Object{infrastructure.Gateway}
+		},
+	}
+}
+
+// LinkGatewayToGatewayAddressFunc returns a link function that teaches a topology how to link the addresses of the
+// gateways from the gateways.
+func LinkGatewayToGatewayAddressFunc() LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "Gateway"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "GatewayAddress"},
+		Relationship: EdgeRelationshipSection,
+		Func: func(child Object) []Object {
+			address := child.(*GatewayAddress)
+			return []Object{address.Gateway}
+		},
+	}
+}
+
+func gatewayHasListener(gateway *Gateway, name gwapiv1.SectionName) bool {
+	return lo.ContainsBy(gateway.Spec.Listeners, func(listener gwapiv1.Listener) bool { return listener.Name == name })
+}
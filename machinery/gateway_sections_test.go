@@ -0,0 +1,101 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestGatewaySections(t *testing.T) {
+	gateway := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Infrastructure = &gwapiv1.GatewayInfrastructure{Labels: map[gwapiv1.LabelKey]gwapiv1.LabelValue{"example.com/lb": "internal"}}
+		g.Spec.Addresses = []gwapiv1.GatewayAddress{
+			{Type: ptr.To(gwapiv1.IPAddressType), Value: "10.0.0.1"},
+			{Type: ptr.To(gwapiv1.HostnameAddressType), Value: "gateway.example.com"},
+		}
+	})
+	sectionPolicy := func(name string, sectionName gwapiv1.SectionName) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"},
+				SectionName:                ptr.To(sectionName),
+			}
+		})
+	}
+	infrastructurePolicy := sectionPolicy("infrastructure-policy", GatewayInfrastructureSectionName)
+	addressPolicy := sectionPolicy("address-policy", GatewayAddressSectionName(1))
+
+	// not expanded
+	topology := NewGatewayAPITopology(WithGateways(gateway), ExpandGatewayListeners())
+	if sections := topology.Targetables().Children(topology.Targetables().Roots()[0]); len(sections) != 1 {
+		t.Errorf("expected only the listener as section of the gateway, got %v", sections)
+	}
+
+	topology = NewGatewayAPITopology(
+		WithGateways(gateway),
+		WithGatewayAPITopologyPolicies(infrastructurePolicy, addressPolicy),
+		ExpandGatewayListeners(),
+		ExpandGatewayInfrastructure(),
+		ExpandGatewayAddresses(),
+	)
+	sections := topology.Targetables().Children(topology.Targetables().Roots()[0])
+	names := lo.Map(sections, func(s Targetable, _ int) string { return s.GetName() })
+	for _, expected := range []string{"my-gateway#my-listener", "my-gateway#infrastructure", "my-gateway#address-1", "my-gateway#address-2"} {
+		if !lo.Contains(names, expected) {
+			t.Errorf("expected section %s of the gateway, got %v", expected, names)
+		}
+	}
+
+	byName := lo.KeyBy(sections, func(s Targetable) string { return s.GetName() })
+	infrastructure, ok := byName["my-gateway#infrastructure"].(*GatewayInfrastructure)
+	if !ok || infrastructure.Labels["example.com/lb"] != "internal" {
+		t.Errorf("expected the infrastructure of the gateway, got %v", byName["my-gateway#infrastructure"])
+	} else if policies := infrastructure.Policies(); len(policies) != 1 || policies[0].GetName() != "infrastructure-policy" {
+		t.Errorf("expected the infrastructure policy attached to the infrastructure, got %v", policies)
+	}
+	address, ok := byName["my-gateway#address-2"].(*GatewayAddress)
+	if !ok || address.Value != "gateway.example.com" {
+		t.Errorf("expected the second address of the gateway, got %v", byName["my-gateway#address-2"])
+	} else if policies := address.Policies(); len(policies) != 1 || policies[0].GetName() != "address-policy" {
+		t.Errorf("expected the address policy attached to the second address, got %v", policies)
+	}
+	if policies := byName["my-gateway#address-1"].Policies(); len(policies) != 0 {
+		t.Errorf("expected no policy attached to the first address, got %v", policies)
+	}
+
+	edges := topology.Edges(EdgesWithRelationship(EdgeRelationshipSection), EdgesTo(infrastructure))
+	if len(edges) != 1 || edges[0].From.GetName() != "my-gateway" {
+		t.Errorf("expected a section edge from the gateway to the infrastructure, got %v", edges)
+	}
+}
+
+func TestGatewaySectionsNamedAfterListeners(t *testing.T) {
+	gateway := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Listeners[0].Name = GatewayInfrastructureSectionName
+		g.Spec.Listeners = append(g.Spec.Listeners, gwapiv1.Listener{Name: GatewayAddressSectionName(0), Protocol: gwapiv1.HTTPProtocolType, Port: 8080})
+		g.Spec.Addresses = []gwapiv1.GatewayAddress{{Value: "10.0.0.1"}, {Value: "10.0.0.2"}}
+	})
+	if infrastructure := GatewayInfrastructureFromGatewayFunc(&Gateway{Gateway: gateway}, 0); len(infrastructure) != 0 {
+		t.Errorf("expected the listener to take precedence over the infrastructure, got %v", infrastructure)
+	}
+	addresses := GatewayAddressesFromGatewayFunc(&Gateway{Gateway: gateway}, 0)
+	if len(addresses) != 1 || addresses[0].Name != "address-2" || addresses[0].Value != "10.0.0.2" {
+		t.Errorf("expected the listener to take precedence over the first address, got %v", addresses)
+	}
+}
+
+func TestGatewayInfrastructureWithoutSpec(t *testing.T) {
+	infrastructure := GatewayInfrastructureFromGatewayFunc(&Gateway{Gateway: BuildGateway()}, 0)
+	if len(infrastructure) != 1 || infrastructure[0].GatewayInfrastructure == nil {
+		t.Fatalf("expected the infrastructure of a gateway without infrastructure attributes, got %v", infrastructure)
+	}
+	if url := infrastructure[0].GetURL(); url != "gateway.gateway.networking.k8s.io:my-namespace/my-gateway#infrastructure" {
+		t.Errorf("unexpected url of the infrastructure: %s", url)
+	}
+}
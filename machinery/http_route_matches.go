@@ -0,0 +1,96 @@
+package machinery
+
+import (
+	"fmt"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// HTTPRouteMatchSectionName returns the name of the section of an HTTPRoute for the match at a given position (starting
+// at 0) of the `matches` of a rule of the route, after the name of the section of the rule, i.e. "rule-1-match-1" for
+// the first match of the first rule.
+func HTTPRouteMatchSectionName(ruleSectionName gwapiv1.SectionName, i int) gwapiv1.SectionName {
+	return gwapiv1.SectionName(fmt.Sprintf("%s-match-%d", ruleSectionName, i+1))
+}
+
+// HTTPRouteMatch is one of the `matches` of a rule of an HTTPRoute, as a section of the route (see
+// HTTPRouteMatchSectionName), so policies can target a specific combination of path, method, headers and query
+// parameters within a rule.
+type HTTPRouteMatch struct {
+	*gwapiv1.HTTPRouteMatch
+
+	HTTPRouteRule    *HTTPRouteRule
+	Name             gwapiv1.SectionName
+	attachedPolicies []Policy
+
+	sectionLocator
+}
+
+var _ Targetable = &HTTPRouteMatch{}
+
+func (m *HTTPRouteMatch) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{
+		Group:   gwapiv1.GroupName,
+		Version: gwapiv1.GroupVersion.Version,
+		Kind:    "HTTPRouteMatch",
+	}
+}
+
+func (m *HTTPRouteMatch) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (m *HTTPRouteMatch) GetURL() string {
+	return m.sectionNameSeparator().SectionName(UrlFromObject(m.HTTPRouteRule.HTTPRoute), m.Name)
+}
+
+func (m *HTTPRouteMatch) GetNamespace() string {
+	return m.HTTPRouteRule.GetNamespace()
+}
+
+func (m *HTTPRouteMatch) GetName() string {
+	return m.sectionNameSeparator().SectionName(m.HTTPRouteRule.HTTPRoute.Name, m.Name)
+}
+
+func (m *HTTPRouteMatch) SetPolicies(policies []Policy) {
+	m.attachedPolicies = policies
+}
+
+func (m *HTTPRouteMatch) Policies() []Policy {
+	return m.attachedPolicies
+}
+
+// ExpandHTTPRouteMatches adds targetable HTTP route matches to the options to initialize a new Gateway API topology,
+// beneath the HTTP route rules. The matches are only expanded along with the rules (see ExpandHTTPRouteRules).
+// Rules without matches have no targetable matches; policies that apply to all the requests of such rule target the
+// rule instead.
+func ExpandHTTPRouteMatches() GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.ExpandHTTPRouteMatches = true
+	}
+}
+
+// HTTPRouteMatchesFromHTTPRouteRuleFunc returns a list of targetable HTTPRouteMatches from a targetable HTTPRouteRule.
+func HTTPRouteMatchesFromHTTPRouteRuleFunc(httpRouteRule *HTTPRouteRule, _ int) []*HTTPRouteMatch {
+	return lo.Map(httpRouteRule.Matches, func(match gwapiv1.HTTPRouteMatch, i int) *HTTPRouteMatch {
+		return &HTTPRouteMatch{
+			HTTPRouteMatch: &match,
+			HTTPRouteRule:  httpRouteRule,
+			Name:           HTTPRouteMatchSectionName(httpRouteRule.Name, i),
+		}
+	})
+}
+
+// LinkHTTPRouteRuleToHTTPRouteMatchFunc returns a link function that teaches a topology how to link HTTPRouteMatches
+// from the HTTPRouteRules they belong to.
+func LinkHTTPRouteRuleToHTTPRouteMatchFunc() LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		To:           schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteMatch"},
+		Relationship: EdgeRelationshipSection,
+		Func: func(child Object) []Object {
+			httpRouteMatch := child.(*HTTPRouteMatch)
+			return []Object{httpRouteMatch.HTTPRouteRule}
+		},
+	}
+}
@@ -0,0 +1,69 @@
+//go:build unit
+
+package machinery
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestHTTPRouteMatches(t *testing.T) {
+	route := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.Rules[0].Matches = []gwapiv1.HTTPRouteMatch{
+			{Path: &gwapiv1.HTTPPathMatch{Type: ptr.To(gwapiv1.PathMatchPathPrefix), Value: ptr.To("/toys")}},
+			{Method: ptr.To(gwapiv1.HTTPMethodPost)},
+		}
+		r.Spec.Rules = append(r.Spec.Rules, gwapiv1.HTTPRouteRule{BackendRefs: r.Spec.Rules[0].BackendRefs})
+	})
+	matchPolicy := func(name string, sectionName gwapiv1.SectionName) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "HTTPRoute", Name: "my-http-route"},
+				SectionName:                ptr.To(sectionName),
+			}
+		})
+	}
+	policy := matchPolicy("match-policy", HTTPRouteMatchSectionName("rule-1", 1))
+	typoPolicy := matchPolicy("typo-policy", "rule-1-match-3")
+
+	// not expanded without the rules
+	topology := NewGatewayAPITopology(WithHTTPRoutes(route), ExpandHTTPRouteMatches())
+	if matches := topology.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "HTTPRouteMatch" }); len(matches) != 0 {
+		t.Errorf("expected no matches without expanding the rules, got %v", matches)
+	}
+
+	topology = NewGatewayAPITopology(
+		WithHTTPRoutes(route),
+		WithGatewayAPITopologyPolicies(policy, typoPolicy),
+		ExpandHTTPRouteRules(),
+		ExpandHTTPRouteMatches(),
+	)
+	rules := lo.KeyBy(topology.Targetables().Items(func(o Object) bool { return o.GroupVersionKind().Kind == "HTTPRouteRule" }), func(r Targetable) string { return r.GetName() })
+	matches := topology.Targetables().Children(rules["my-http-route#rule-1"])
+	names := lo.Map(matches, func(m Targetable, _ int) string { return m.GetName() })
+	if len(names) != 2 || !lo.Contains(names, "my-http-route#rule-1-match-1") || !lo.Contains(names, "my-http-route#rule-1-match-2") {
+		t.Errorf("expected 2 matches beneath the first rule, got %v", names)
+	}
+	if children := topology.Targetables().Children(rules["my-http-route#rule-2"]); lo.ContainsBy(children, func(c Targetable) bool { return c.GroupVersionKind().Kind == "HTTPRouteMatch" }) {
+		t.Errorf("expected no matches beneath the rule without matches, got %v", children)
+	}
+
+	match, ok := lo.Find(matches, func(m Targetable) bool { return m.GetName() == "my-http-route#rule-1-match-2" })
+	if !ok || ptr.Deref(match.(*HTTPRouteMatch).Method, "") != gwapiv1.HTTPMethodPost {
+		t.Fatalf("expected the second match of the first rule, got %v", match)
+	}
+	if policies := match.Policies(); len(policies) != 1 || policies[0].GetName() != "match-policy" {
+		t.Errorf("expected the policy attached to the match, got %v", policies)
+	}
+
+	var invalidSection *InvalidSectionNameError
+	if err := ValidateSectionNames(topology, typoPolicy); !errors.As(err, &invalidSection) || !lo.Contains(invalidSection.ValidSections, "rule-1-match-2") {
+		t.Errorf("expected the matches among the valid sections of the route, got %v", err)
+	}
+}
@@ -0,0 +1,199 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Gateway API Inference Extension
+//
+// The types below mirror the fields of the InferencePool and InferenceModel resources of the Gateway API Inference
+// Extension that matter to the topology, so the API of the extension is not a dependency of this package. They decode
+// from the JSON (or unstructured) representation of the resources.
+var (
+	InferenceExtensionGroupVersion = schema.GroupVersion{Group: "inference.networking.x-k8s.io", Version: "v1alpha2"}
+
+	InferencePoolKind  = InferenceExtensionGroupVersion.WithKind("InferencePool").GroupKind()
+	InferenceModelKind = InferenceExtensionGroupVersion.WithKind("InferenceModel").GroupKind()
+)
+
+// InferencePool is a pool of model servers of the Gateway API Inference Extension, that HTTPRoutes refer to in their
+// backendRefs instead of Services.
+type InferencePool struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec InferencePoolSpec `json:"spec"`
+
+	attachedPolicies []Policy
+}
+
+// InferencePoolSpec is the specification of an InferencePool.
+type InferencePoolSpec struct {
+	// Selector of the pods of the model servers of the pool
+	Selector map[string]string `json:"selector,omitempty"`
+	// TargetPortNumber is the port number of the model servers
+	TargetPortNumber int32 `json:"targetPortNumber,omitempty"`
+}
+
+var _ Targetable = &InferencePool{}
+
+func (p *InferencePool) GroupVersionKind() schema.GroupVersionKind {
+	return InferencePoolKind.WithVersion(inferenceExtensionVersion(p.TypeMeta))
+}
+
+func (p *InferencePool) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (p *InferencePool) GetURL() string {
+	return UrlFromObject(p)
+}
+
+func (p *InferencePool) SetPolicies(policies []Policy) {
+	p.attachedPolicies = policies
+}
+
+func (p *InferencePool) Policies() []Policy {
+	return p.attachedPolicies
+}
+
+// InferenceModel is a model served by an InferencePool of the Gateway API Inference Extension.
+type InferenceModel struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec InferenceModelSpec `json:"spec"`
+
+	attachedPolicies []Policy
+}
+
+// InferenceModelSpec is the specification of an InferenceModel.
+type InferenceModelSpec struct {
+	// ModelName is the name of the model as requested by the clients
+	ModelName string `json:"modelName"`
+	// Criticality of the requests for the model, e.g. Critical or Sheddable
+	Criticality *string `json:"criticality,omitempty"`
+	// PoolRef is the reference to the InferencePool that serves the model, in the namespace of the model
+	PoolRef InferencePoolReference `json:"poolRef"`
+}
+
+// InferencePoolReference is a reference from an InferenceModel to an InferencePool.
+type InferencePoolReference struct {
+	Group string `json:"group,omitempty"`
+	Kind  string `json:"kind,omitempty"`
+	Name  string `json:"name"`
+}
+
+var _ Targetable = &InferenceModel{}
+
+func (m *InferenceModel) GroupVersionKind() schema.GroupVersionKind {
+	return InferenceModelKind.WithVersion(inferenceExtensionVersion(m.TypeMeta))
+}
+
+func (m *InferenceModel) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (m *InferenceModel) GetURL() string {
+	return UrlFromObject(m)
+}
+
+func (m *InferenceModel) SetPolicies(policies []Policy) {
+	m.attachedPolicies = policies
+}
+
+func (m *InferenceModel) Policies() []Policy {
+	return m.attachedPolicies
+}
+
+// WithInferencePools adds the InferencePools of the Gateway API Inference Extension to the options to initialize a new
+// Gateway API topology. The pools are linked from the HTTPRoutes, or from the HTTPRouteRules if expanded, that refer
+// to them in their backendRefs.
+func WithInferencePools(pools ...*InferencePool) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.InferencePools = append(o.InferencePools, pools...)
+	}
+}
+
+// WithInferenceModels adds the InferenceModels of the Gateway API Inference Extension to the options to initialize a
+// new Gateway API topology. The models are linked from the InferencePools they refer to in their `poolRef`.
+func WithInferenceModels(models ...*InferenceModel) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.InferenceModels = append(o.InferenceModels, models...)
+	}
+}
+
+// LinkHTTPRouteToInferencePoolFunc returns a link function that teaches a topology how to link InferencePools from
+// known HTTPRoutes, based on the HTTPRoute's `backendRefs` fields.
+func LinkHTTPRouteToInferencePoolFunc(httpRoutes []*HTTPRoute) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRoute"},
+		To:           InferencePoolKind,
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			pool := child.(*InferencePool)
+			return lo.FilterMap(httpRoutes, func(httpRoute *HTTPRoute, _ int) (Object, bool) {
+				return httpRoute, lo.ContainsBy(httpRoute.Spec.Rules, func(rule gwapiv1.HTTPRouteRule) bool {
+					return lo.ContainsBy(rule.BackendRefs, func(backendRef gwapiv1.HTTPBackendRef) bool {
+						return backendRefEqualToInferencePool(backendRef.BackendRef, pool, httpRoute.Namespace)
+					})
+				})
+			})
+		},
+	}
+}
+
+// LinkHTTPRouteRuleToInferencePoolFunc returns a link function that teaches a topology how to link InferencePools from
+// known HTTPRouteRules, based on the HTTPRouteRule's `backendRefs` field.
+func LinkHTTPRouteRuleToInferencePoolFunc(httpRouteRules []*HTTPRouteRule) LinkFunc {
+	return LinkFunc{
+		From:         schema.GroupKind{Group: gwapiv1.GroupVersion.Group, Kind: "HTTPRouteRule"},
+		To:           InferencePoolKind,
+		Relationship: EdgeRelationshipBackendRef,
+		Func: func(child Object) []Object {
+			pool := child.(*InferencePool)
+			return lo.FilterMap(httpRouteRules, func(httpRouteRule *HTTPRouteRule, _ int) (Object, bool) {
+				return httpRouteRule, lo.ContainsBy(httpRouteRule.BackendRefs, func(backendRef gwapiv1.HTTPBackendRef) bool {
+					return backendRefEqualToInferencePool(backendRef.BackendRef, pool, httpRouteRule.HTTPRoute.Namespace)
+				})
+			})
+		},
+	}
+}
+
+// LinkInferencePoolToInferenceModelFunc returns a link function that teaches a topology how to link InferenceModels
+// from known InferencePools, based on the InferenceModel's `poolRef` field.
+func LinkInferencePoolToInferenceModelFunc(pools []*InferencePool) LinkFunc {
+	return LinkFunc{
+		From:         InferencePoolKind,
+		To:           InferenceModelKind,
+		Relationship: EdgeRelationshipParentRef,
+		Func: func(child Object) []Object {
+			model := child.(*InferenceModel)
+			ref := model.Spec.PoolRef
+			if group, _ := lo.Coalesce(ref.Group, InferencePoolKind.Group); group != InferencePoolKind.Group {
+				return nil
+			}
+			if kind, _ := lo.Coalesce(ref.Kind, InferencePoolKind.Kind); kind != InferencePoolKind.Kind {
+				return nil
+			}
+			return lo.FilterMap(pools, func(pool *InferencePool, _ int) (Object, bool) {
+				return pool, pool.Namespace == model.Namespace && pool.Name == ref.Name
+			})
+		},
+	}
+}
+
+// inferenceExtensionVersion returns the version of the API of a resource of the Gateway API Inference Extension,
+// defaulting to the version mirrored by this package
+func inferenceExtensionVersion(typeMeta metav1.TypeMeta) string {
+	version, _ := lo.Coalesce(typeMeta.GroupVersionKind().Version, InferenceExtensionGroupVersion.Version)
+	return version
+}
+
+func backendRefEqualToInferencePool(backendRef gwapiv1.BackendRef, pool *InferencePool, defaultNamespace string) bool {
+	backendRefGroup := string(ptr.Deref(backendRef.Group, gwapiv1.Group("")))
+	backendRefKind := string(ptr.Deref(backendRef.Kind, gwapiv1.Kind("Service")))
+	backendRefNamespace := string(ptr.Deref(backendRef.Namespace, gwapiv1.Namespace(defaultNamespace)))
+	return backendRefGroup == InferencePoolKind.Group && backendRefKind == InferencePoolKind.Kind && backendRefNamespace == pool.Namespace && string(backendRef.Name) == pool.Name
+}
@@ -0,0 +1,87 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestInferencePools(t *testing.T) {
+	poolRef := BuildHTTPBackendRef(func(ref *gwapiv1.BackendObjectReference) {
+		ref.Group = ptr.To(gwapiv1.Group(InferencePoolKind.Group))
+		ref.Kind = ptr.To(gwapiv1.Kind(InferencePoolKind.Kind))
+		ref.Name = "my-pool"
+		ref.Port = nil
+	})
+	route := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.Rules = append(r.Spec.Rules, gwapiv1.HTTPRouteRule{BackendRefs: []gwapiv1.HTTPBackendRef{poolRef}})
+	})
+	pools := []*InferencePool{
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-pool", Namespace: "my-namespace"}, Spec: InferencePoolSpec{Selector: map[string]string{"app": "vllm"}, TargetPortNumber: 8000}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-pool", Namespace: "other-namespace"}},
+	}
+	models := []*InferenceModel{
+		{ObjectMeta: metav1.ObjectMeta{Name: "my-model", Namespace: "my-namespace"}, Spec: InferenceModelSpec{ModelName: "llama", PoolRef: InferencePoolReference{Name: "my-pool"}}},
+		{ObjectMeta: metav1.ObjectMeta{Name: "other-model", Namespace: "my-namespace"}, Spec: InferenceModelSpec{ModelName: "mistral", PoolRef: InferencePoolReference{Kind: "Service", Name: "my-pool"}}},
+	}
+	policy := buildPolicy(func(p *TestPolicy) {
+		p.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.Group(InferencePoolKind.Group),
+				Kind:  gwapiv1.Kind(InferencePoolKind.Kind),
+				Name:  "my-pool",
+			},
+		}
+	})
+
+	testCases := []struct {
+		name       string
+		expandRule bool
+		parentKind string
+	}{
+		{name: "routes", parentKind: "HTTPRoute"},
+		{name: "route rules", expandRule: true, parentKind: "HTTPRouteRule"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			opts := []GatewayAPITopologyOptionsFunc{
+				WithHTTPRoutes(route),
+				WithInferencePools(pools...),
+				WithInferenceModels(models...),
+				WithGatewayAPITopologyPolicies(policy),
+			}
+			if tc.expandRule {
+				opts = append(opts, ExpandHTTPRouteRules())
+			}
+			topology := NewGatewayAPITopology(opts...)
+
+			pool, found := topology.Targetables().Get("inferencepool.inference.networking.x-k8s.io:my-namespace/my-pool")
+			if !found {
+				t.Fatal("expected inference pool found")
+			}
+			parents := topology.Targetables().Parents(pool)
+			if len(parents) != 1 || parents[0].GroupVersionKind().Kind != tc.parentKind {
+				t.Errorf("expected inference pool linked from one %s, got %v", tc.parentKind, parents)
+			}
+			if policies := pool.Policies(); len(policies) != 1 || policies[0].GetURL() != policy.GetURL() {
+				t.Errorf("expected policy attached to the inference pool, got %v", policies)
+			}
+			if children := topology.Targetables().Children(pool); len(children) != 1 || children[0].GetName() != "my-model" {
+				t.Errorf("expected the inference model linked from the pool, got %v", children)
+			}
+			other, _ := topology.Targetables().Get("inferencepool.inference.networking.x-k8s.io:other-namespace/my-pool")
+			if parents := topology.Targetables().Parents(other); len(parents) != 0 {
+				t.Errorf("expected the pool of another namespace not linked, got %v", parents)
+			}
+			if edges := topology.Edges(EdgesTo(pool), EdgesWithRelationship(EdgeRelationshipBackendRef)); len(edges) != 1 {
+				t.Errorf("expected a backendRef edge to the pool, got %v", edges)
+			}
+		})
+	}
+}
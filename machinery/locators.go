@@ -0,0 +1,179 @@
+package machinery
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// SectionNameSeparator is the separator between the name of a resource and the name of one of its sections (e.g. a
+// listener of a gateway) in locators, such as the URLs and names of the targetables of the topology.
+// A topology can be built with a separator other than the default one (see WithSectionNameSeparator), e.g. '~' to
+// avoid clashes with the fragments of URLs when locators are used in HTTP endpoints. The zero value is the default
+// separator.
+type SectionNameSeparator rune
+
+// DefaultSectionNameSeparator is the default separator between the name of a resource and the name of one of its
+// sections in locators.
+const DefaultSectionNameSeparator SectionNameSeparator = '#'
+
+// reservedLocatorRunes are the characters that cannot be used as section name separator.
+const reservedLocatorRunes = string(kindNameURLSeparator) + "/%-._"
+
+// Validate returns an error if the separator is valid in the names of kubernetes resources, or clashes with the rest of
+// the locators.
+func (s SectionNameSeparator) Validate() error {
+	r := rune(s)
+	if r > unicode.MaxASCII || !(unicode.IsPunct(r) || unicode.IsSymbol(r)) || strings.ContainsRune(reservedLocatorRunes, r) {
+		return fmt.Errorf("invalid section name separator %q", r)
+	}
+	return nil
+}
+
+// orDefault returns the separator, or the default one if unset or invalid.
+func (s SectionNameSeparator) orDefault() SectionNameSeparator {
+	if s == 0 || s.Validate() != nil {
+		return DefaultSectionNameSeparator
+	}
+	return s
+}
+
+// EscapeSectionName percent-encodes the characters of a section name that would otherwise be mistaken for the
+// separator of the section name or for the fragment of a URL.
+func (s SectionNameSeparator) EscapeSectionName(sectionName string) string {
+	separator := rune(s.orDefault())
+	var b strings.Builder
+	for _, r := range sectionName {
+		if r == '%' || r == separator || r == rune(DefaultSectionNameSeparator) {
+			fmt.Fprintf(&b, "%%%02X", r)
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// SectionName returns the name of a section of a resource, as in the locators of the section.
+func (s SectionNameSeparator) SectionName(name string, sectionName gwapiv1.SectionName) string {
+	return name + string(rune(s.orDefault())) + s.EscapeSectionName(string(sectionName))
+}
+
+// ParseSectionName splits a name or locator of a section of a resource, as returned by SectionName, into the name
+// or locator of the resource and the name of the section. Names with the default separator are also parsed, so
+// locators persisted before the separator was changed keep resolving.
+// It returns false if the name does not refer to a section.
+func (s SectionNameSeparator) ParseSectionName(name string) (string, gwapiv1.SectionName, bool) {
+	// names of resources cannot contain any of the separators, so the name of the section starts after the first one
+	separator := rune(s.orDefault())
+	i := strings.IndexFunc(name, func(r rune) bool { return r == separator || r == rune(DefaultSectionNameSeparator) })
+	if i < 0 {
+		return name, "", false
+	}
+	resource, section := name[:i], name[i+1:]
+	if unescaped, err := UnescapeSectionName(section); err == nil {
+		section = unescaped
+	}
+	return resource, gwapiv1.SectionName(section), true
+}
+
+// NormalizeLocator rewrites a locator to the separator and escaping, e.g. a locator with the default separator
+// persisted before the separator was changed.
+func (s SectionNameSeparator) NormalizeLocator(locator string) string {
+	resource, section, found := s.ParseSectionName(locator)
+	if !found {
+		return locator
+	}
+	return s.SectionName(resource, section)
+}
+
+// EscapeSectionName percent-encodes the characters of a section name that would otherwise be mistaken for the
+// default separator of the section name or for the fragment of a URL.
+func EscapeSectionName(sectionName string) string {
+	return DefaultSectionNameSeparator.EscapeSectionName(sectionName)
+}
+
+// UnescapeSectionName decodes a section name escaped with EscapeSectionName.
+func UnescapeSectionName(escaped string) (string, error) {
+	if !strings.ContainsRune(escaped, '%') {
+		return escaped, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(escaped); i++ {
+		if escaped[i] != '%' {
+			b.WriteByte(escaped[i])
+			continue
+		}
+		if i+2 >= len(escaped) || !isHex(escaped[i+1]) || !isHex(escaped[i+2]) {
+			return "", errors.New("invalid escape sequence in section name " + escaped)
+		}
+		b.WriteByte(unhex(escaped[i+1])<<4 | unhex(escaped[i+2]))
+		i += 2
+	}
+	return b.String(), nil
+}
+
+// SectionName returns the name of a section of a resource with the default separator.
+func SectionName(name string, sectionName gwapiv1.SectionName) string {
+	return DefaultSectionNameSeparator.SectionName(name, sectionName)
+}
+
+// ParseSectionName splits a name or locator of a section of a resource with the default separator into the name or
+// locator of the resource and the name of the section. See SectionNameSeparatorOf for the separator of the sections of
+// a topology built with another one.
+// It returns false if the name does not refer to a section.
+func ParseSectionName(name string) (string, gwapiv1.SectionName, bool) {
+	return DefaultSectionNameSeparator.ParseSectionName(name)
+}
+
+// SectionNameSeparatorOf returns the separator of the locators of an object, i.e. the separator of the topology the
+// section was added to (see WithSectionNameSeparator), or the default separator.
+func SectionNameSeparatorOf(obj Object) SectionNameSeparator {
+	if section, ok := obj.(sectionObject); ok {
+		return section.sectionNameSeparator()
+	}
+	return DefaultSectionNameSeparator
+}
+
+// sectionObject is an object of the topology that is a section of a resource, whose locators depend on the section
+// name separator of the topology.
+type sectionObject interface {
+	sectionNameSeparator() SectionNameSeparator
+	setSectionNameSeparator(SectionNameSeparator)
+}
+
+// sectionLocator is embedded in the sections of resources to build their locators with the section name separator of
+// the topology.
+type sectionLocator struct {
+	separator SectionNameSeparator
+}
+
+func (l *sectionLocator) sectionNameSeparator() SectionNameSeparator {
+	return l.separator.orDefault()
+}
+
+func (l *sectionLocator) setSectionNameSeparator(separator SectionNameSeparator) {
+	l.separator = separator
+}
+
+// isSectionName returns true if a name or locator refers to a section of a resource.
+func isSectionName(name string) bool {
+	_, _, found := ParseSectionName(name)
+	return found
+}
+
+func isHex(c byte) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func unhex(c byte) byte {
+	switch {
+	case '0' <= c && c <= '9':
+		return c - '0'
+	case 'a' <= c && c <= 'f':
+		return c - 'a' + 10
+	}
+	return c - 'A' + 10
+}
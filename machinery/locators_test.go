@@ -0,0 +1,130 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestSectionName(t *testing.T) {
+	testCases := []struct {
+		name            string
+		sectionName     gwapiv1.SectionName
+		expected        string
+		expectedWithSep string
+	}{
+		{
+			name:            "plain",
+			sectionName:     "my-listener",
+			expected:        "my-gateway#my-listener",
+			expectedWithSep: "my-gateway~my-listener",
+		},
+		{
+			name:            "with separators",
+			sectionName:     "a#b~c%d",
+			expected:        "my-gateway#a%23b~c%25d",
+			expectedWithSep: "my-gateway~a%23b%7Ec%25d",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			name := SectionName("my-gateway", tc.sectionName)
+			if name != tc.expected {
+				t.Errorf("expected %s, got %s", tc.expected, name)
+			}
+			separator := SectionNameSeparator('~')
+			if name := separator.SectionName("my-gateway", tc.sectionName); name != tc.expectedWithSep {
+				t.Errorf("expected %s, got %s", tc.expectedWithSep, name)
+			}
+			for _, n := range []string{tc.expectedWithSep, tc.expected} {
+				resource, section, found := separator.ParseSectionName(n)
+				if !found || resource != "my-gateway" || section != tc.sectionName {
+					t.Errorf("expected (my-gateway, %s, true) parsing %s, got (%s, %s, %t)", tc.sectionName, n, resource, section, found)
+				}
+			}
+			resource, section, found := ParseSectionName(name)
+			if !found || resource != "my-gateway" || section != tc.sectionName {
+				t.Errorf("expected (my-gateway, %s, true), got (%s, %s, %t)", tc.sectionName, resource, section, found)
+			}
+		})
+	}
+
+	if _, _, found := ParseSectionName("my-gateway"); found {
+		t.Error("expected name without section")
+	}
+}
+
+func TestSectionNameSeparatorValidate(t *testing.T) {
+	for _, separator := range []SectionNameSeparator{':', '/', '%', '-', '.', 'a', '1', ' ', 'é'} {
+		if err := separator.Validate(); err == nil {
+			t.Errorf("expected separator %q to be rejected", separator)
+		}
+		if name := separator.SectionName("my-gateway", "my-listener"); name != "my-gateway#my-listener" {
+			t.Errorf("expected the default separator instead of %q, got %s", separator, name)
+		}
+	}
+	if err := SectionNameSeparator('~').Validate(); err != nil {
+		t.Errorf("expected separator '~' to be valid, got %v", err)
+	}
+}
+
+func TestLegacyLocatorsResolve(t *testing.T) {
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "Gateway",
+				Name:  "my-gateway",
+			},
+			SectionName: ptr.To(gwapiv1.SectionName("my-listener")),
+		}
+	})
+	topology := NewGatewayAPITopology(
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithGatewayAPITopologyPolicies(policy),
+		WithGatewayAPITopologySectionNameSeparator('~'),
+	)
+	legacy := "gateway.gateway.networking.k8s.io:my-namespace/my-gateway#my-listener"
+	listener, found := topology.Targetables().Get(legacy)
+	if !found {
+		t.Fatalf("expected listener found by legacy locator")
+	}
+	if url := listener.GetURL(); url != "gateway.gateway.networking.k8s.io:my-namespace/my-gateway~my-listener" {
+		t.Errorf("unexpected locator %s", url)
+	}
+	if normalized := topology.SectionNameSeparator().NormalizeLocator(legacy); normalized != listener.GetURL() {
+		t.Errorf("expected legacy locator normalized to %s, got %s", listener.GetURL(), normalized)
+	}
+	if policies := listener.Policies(); len(policies) != 1 {
+		t.Errorf("expected the policy attached to the listener, got %v", policies)
+	}
+	if err := topology.DiagnoseTargetRefs(policy).Err(); err != nil {
+		t.Errorf("expected the target ref of the policy resolved, got %v", err)
+	}
+	if name, section, found := SectionNameSeparatorOf(listener).ParseSectionName(listener.GetName()); !found || name != "my-gateway" || section != "my-listener" {
+		t.Errorf("expected (my-gateway, my-listener, true), got (%s, %s, %t)", name, section, found)
+	}
+
+	// the separator is scoped to the topology
+	other := NewGatewayAPITopology(WithGateways(BuildGateway()), ExpandGatewayListeners())
+	if _, found := other.Targetables().Get(legacy); !found {
+		t.Error("expected listener found with the default separator in another topology")
+	}
+	if other.SectionNameSeparator() != DefaultSectionNameSeparator {
+		t.Errorf("expected default separator, got %q", other.SectionNameSeparator())
+	}
+
+	restored := RebuildFromSnapshot(topology.Snapshot())
+	if restored.SectionNameSeparator() != '~' {
+		t.Errorf("expected the separator restored from the snapshot, got %q", restored.SectionNameSeparator())
+	}
+	if _, found := restored.Targetables().Get(legacy); !found {
+		t.Error("expected listener found by legacy locator in the restored topology")
+	}
+}
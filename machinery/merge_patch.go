@@ -0,0 +1,79 @@
+package machinery
+
+import (
+	"encoding/json"
+
+	jsonpatch "github.com/evanphx/json-patch"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+)
+
+// MergePatchDirection tells which of two policies merged with JSON Merge Patch wins when both set the same field.
+type MergePatchDirection int
+
+const (
+	// MergePatchDefaults means the fields set in the target policy win over the ones set in the source policy.
+	MergePatchDefaults MergePatchDirection = iota
+	// MergePatchOverrides means the fields set in the source policy win over the ones set in the target policy.
+	MergePatchOverrides
+)
+
+// JSONMergePatchStrategy returns a merge strategy that merges the specs of two policies of type T according to
+// RFC 7386 JSON Merge Patch semantics, so policies whose rules are not keyed by name can be merged without custom code.
+// Objects are merged recursively, whereas any other value set in the winning spec replaces the one in the other spec,
+// and null values in the winning spec remove fields.
+//
+// The spec function extracts the mergeable part of a policy, and withSpec returns a copy of the target policy with a
+// merged spec. The direction function tells, for a given source policy, whether its spec works as defaults or overrides.
+// The strategy returns the target policy unchanged if any of the policies is not of type T or if the specs fail to merge.
+func JSONMergePatchStrategy[T Policy, S any](spec func(T) S, withSpec func(T, S) T, direction func(source T) MergePatchDirection) MergeStrategy {
+	return patchStrategy(spec, withSpec, direction, jsonpatch.MergePatch)
+}
+
+// StrategicMergePatchStrategy returns a merge strategy that merges the specs of two policies of type T according to
+// Kubernetes strategic merge patch semantics. Lists whose fields are tagged with `patchStrategy:"merge"` and
+// `patchMergeKey:"<key>"` in the spec type S are merged item by item, matching the items by key, instead of being
+// replaced atomically; the items of the winning spec replace the ones with the same key in the other spec.
+// Other fields are merged as in JSONMergePatchStrategy, with which this strategy shares the arguments.
+func StrategicMergePatchStrategy[T Policy, S any](spec func(T) S, withSpec func(T, S) T, direction func(source T) MergePatchDirection) MergeStrategy {
+	var dataStruct S
+	return patchStrategy(spec, withSpec, direction, func(doc, patch []byte) ([]byte, error) {
+		return strategicpatch.StrategicMergePatch(doc, patch, dataStruct)
+	})
+}
+
+// patchStrategy returns a merge strategy that merges the JSON representations of the specs of two policies of type T
+// with a patch function, patching the losing spec with the winning one.
+func patchStrategy[T Policy, S any](spec func(T) S, withSpec func(T, S) T, direction func(source T) MergePatchDirection, patch func(doc, patch []byte) ([]byte, error)) MergeStrategy {
+	return func(source, target Policy) Policy {
+		sourcePolicy, okSource := source.(T)
+		targetPolicy, okTarget := target.(T)
+		if !okSource || !okTarget {
+			return target
+		}
+
+		sourceJSON, err := json.Marshal(spec(sourcePolicy))
+		if err != nil {
+			return target
+		}
+		targetJSON, err := json.Marshal(spec(targetPolicy))
+		if err != nil {
+			return target
+		}
+
+		var resultJSON []byte
+		if direction(sourcePolicy) == MergePatchOverrides {
+			resultJSON, err = patch(targetJSON, sourceJSON)
+		} else {
+			resultJSON, err = patch(sourceJSON, targetJSON)
+		}
+		if err != nil {
+			return target
+		}
+
+		var result S
+		if err := json.Unmarshal(resultJSON, &result); err != nil {
+			return target
+		}
+		return withSpec(targetPolicy, result)
+	}
+}
@@ -0,0 +1,173 @@
+//go:build unit
+
+package machinery
+
+import (
+	"reflect"
+	"testing"
+)
+
+type mergePatchTestSpec struct {
+	Rules  []string          `json:"rules,omitempty"`
+	Limits map[string]int    `json:"limits,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+type mergePatchTestPolicy struct {
+	*TestPolicy
+	overrides bool
+	spec      mergePatchTestSpec
+}
+
+var mergePatchTestStrategy = JSONMergePatchStrategy(
+	func(p *mergePatchTestPolicy) mergePatchTestSpec { return p.spec },
+	func(p *mergePatchTestPolicy, spec mergePatchTestSpec) *mergePatchTestPolicy {
+		return &mergePatchTestPolicy{TestPolicy: p.TestPolicy, spec: spec}
+	},
+	func(p *mergePatchTestPolicy) MergePatchDirection {
+		if p.overrides {
+			return MergePatchOverrides
+		}
+		return MergePatchDefaults
+	},
+)
+
+func TestJSONMergePatchStrategy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   *mergePatchTestPolicy
+		target   *mergePatchTestPolicy
+		expected mergePatchTestSpec
+	}{
+		{
+			name:     "defaults into empty policy",
+			source:   &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"a"}, Limits: map[string]int{"x": 1}}},
+			target:   &mergePatchTestPolicy{},
+			expected: mergePatchTestSpec{Rules: []string{"a"}, Limits: map[string]int{"x": 1}},
+		},
+		{
+			name:     "defaults with conflicting fields",
+			source:   &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"a", "b"}, Limits: map[string]int{"x": 1, "y": 2}}},
+			target:   &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"c"}, Limits: map[string]int{"x": 3}}},
+			expected: mergePatchTestSpec{Rules: []string{"c"}, Limits: map[string]int{"x": 3, "y": 2}},
+		},
+		{
+			name:     "overrides with conflicting fields",
+			source:   &mergePatchTestPolicy{overrides: true, spec: mergePatchTestSpec{Rules: []string{"a", "b"}, Limits: map[string]int{"x": 1, "y": 2}}},
+			target:   &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"c"}, Limits: map[string]int{"x": 3, "z": 4}}},
+			expected: mergePatchTestSpec{Rules: []string{"a", "b"}, Limits: map[string]int{"x": 1, "y": 2, "z": 4}},
+		},
+		{
+			name:     "overrides without conflicting fields",
+			source:   &mergePatchTestPolicy{overrides: true, spec: mergePatchTestSpec{Labels: map[string]string{"team": "a"}}},
+			target:   &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"c"}}},
+			expected: mergePatchTestSpec{Rules: []string{"c"}, Labels: map[string]string{"team": "a"}},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.target.TestPolicy = buildPolicy(func(p *TestPolicy) { p.Name = "target" })
+			merged, ok := mergePatchTestStrategy(tc.source, tc.target).(*mergePatchTestPolicy)
+			if !ok {
+				t.Fatalf("expected merged policy of type %T", tc.target)
+			}
+			if merged.GetName() != "target" {
+				t.Errorf("expected merged policy to keep the metadata of the target, got %s", merged.GetName())
+			}
+			if !reflect.DeepEqual(merged.spec, tc.expected) {
+				t.Errorf("expected spec %+v, got %+v", tc.expected, merged.spec)
+			}
+		})
+	}
+}
+
+func TestJSONMergePatchStrategyWithOtherPolicyTypes(t *testing.T) {
+	source := &mergePatchTestPolicy{spec: mergePatchTestSpec{Rules: []string{"a"}}}
+	target := buildPolicy()
+	if merged := mergePatchTestStrategy(source, target); merged != target {
+		t.Errorf("expected the target policy unchanged, got %v", merged)
+	}
+}
+
+type strategicMergePatchTestLimit struct {
+	Name  string `json:"name"`
+	Value int    `json:"value"`
+}
+
+type strategicMergePatchTestSpec struct {
+	Limits []strategicMergePatchTestLimit `json:"limits,omitempty" patchStrategy:"merge" patchMergeKey:"name"`
+	Hosts  []string                       `json:"hosts,omitempty"`
+}
+
+type strategicMergePatchTestPolicy struct {
+	*TestPolicy
+	overrides bool
+	spec      strategicMergePatchTestSpec
+}
+
+var strategicMergePatchTestStrategy = StrategicMergePatchStrategy(
+	func(p *strategicMergePatchTestPolicy) strategicMergePatchTestSpec { return p.spec },
+	func(p *strategicMergePatchTestPolicy, spec strategicMergePatchTestSpec) *strategicMergePatchTestPolicy {
+		return &strategicMergePatchTestPolicy{TestPolicy: p.TestPolicy, spec: spec}
+	},
+	func(p *strategicMergePatchTestPolicy) MergePatchDirection {
+		if p.overrides {
+			return MergePatchOverrides
+		}
+		return MergePatchDefaults
+	},
+)
+
+func TestStrategicMergePatchStrategy(t *testing.T) {
+	testCases := []struct {
+		name     string
+		source   *strategicMergePatchTestPolicy
+		target   *strategicMergePatchTestPolicy
+		expected strategicMergePatchTestSpec
+	}{
+		{
+			name: "defaults merge lists by key",
+			source: &strategicMergePatchTestPolicy{spec: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "a", Value: 1}, {Name: "b", Value: 2}},
+				Hosts:  []string{"example.com"},
+			}},
+			target: &strategicMergePatchTestPolicy{spec: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "b", Value: 3}, {Name: "c", Value: 4}},
+				Hosts:  []string{"other.com"},
+			}},
+			expected: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "a", Value: 1}, {Name: "b", Value: 3}, {Name: "c", Value: 4}},
+				Hosts:  []string{"other.com"},
+			},
+		},
+		{
+			name: "overrides merge lists by key",
+			source: &strategicMergePatchTestPolicy{overrides: true, spec: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "a", Value: 1}, {Name: "b", Value: 2}},
+			}},
+			target: &strategicMergePatchTestPolicy{spec: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "b", Value: 3}, {Name: "c", Value: 4}},
+				Hosts:  []string{"other.com"},
+			}},
+			expected: strategicMergePatchTestSpec{
+				Limits: []strategicMergePatchTestLimit{{Name: "a", Value: 1}, {Name: "b", Value: 2}, {Name: "c", Value: 4}},
+				Hosts:  []string{"other.com"},
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.target.TestPolicy = buildPolicy(func(p *TestPolicy) { p.Name = "target" })
+			merged, ok := strategicMergePatchTestStrategy(tc.source, tc.target).(*strategicMergePatchTestPolicy)
+			if !ok {
+				t.Fatalf("expected merged policy of type %T", tc.target)
+			}
+			if merged.GetName() != "target" {
+				t.Errorf("expected merged policy to keep the metadata of the target, got %s", merged.GetName())
+			}
+			if !reflect.DeepEqual(merged.spec, tc.expected) {
+				t.Errorf("expected spec %+v, got %+v", tc.expected, merged.spec)
+			}
+		})
+	}
+}
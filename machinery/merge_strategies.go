@@ -0,0 +1,63 @@
+package machinery
+
+import (
+	"sync"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MergeStrategyRegistry holds merge strategies registered by policy kind, so policies can resolve the strategy to
+// merge with dynamically instead of having it hardwired.
+type MergeStrategyRegistry struct {
+	mu         sync.RWMutex
+	strategies map[schema.GroupKind]MergeStrategy
+}
+
+func NewMergeStrategyRegistry() *MergeStrategyRegistry {
+	return &MergeStrategyRegistry{strategies: make(map[schema.GroupKind]MergeStrategy)}
+}
+
+// Register sets the merge strategy for a kind of policy, replacing any strategy previously registered for the kind.
+func (r *MergeStrategyRegistry) Register(kind schema.GroupKind, strategy MergeStrategy) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.strategies[kind] = strategy
+}
+
+// Unregister removes the merge strategy registered for a kind of policy.
+func (r *MergeStrategyRegistry) Unregister(kind schema.GroupKind) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.strategies, kind)
+}
+
+// Lookup returns the merge strategy registered for a kind of policy, if any.
+func (r *MergeStrategyRegistry) Lookup(kind schema.GroupKind) (MergeStrategy, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	strategy, ok := r.strategies[kind]
+	return strategy, ok
+}
+
+// StrategyFor returns the merge strategy registered for a kind of policy, or DefaultMergeStrategy if none is.
+func (r *MergeStrategyRegistry) StrategyFor(kind schema.GroupKind) MergeStrategy {
+	if strategy, ok := r.Lookup(kind); ok {
+		return strategy
+	}
+	return DefaultMergeStrategy
+}
+
+// MergeStrategies is the registry of merge strategies used by MergeStrategyFor.
+// Integrators register their strategies at startup, e.g. atomic defaults for a kind of wrapped policy.
+var MergeStrategies = NewMergeStrategyRegistry()
+
+// RegisterMergeStrategy sets the merge strategy for a kind of policy in the MergeStrategies registry.
+func RegisterMergeStrategy(kind schema.GroupKind, strategy MergeStrategy) {
+	MergeStrategies.Register(kind, strategy)
+}
+
+// MergeStrategyFor returns the merge strategy registered for a kind of policy in the MergeStrategies registry, or
+// DefaultMergeStrategy if none is.
+func MergeStrategyFor(kind schema.GroupKind) MergeStrategy {
+	return MergeStrategies.StrategyFor(kind)
+}
@@ -0,0 +1,47 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestMergeStrategyRegistry(t *testing.T) {
+	kind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	source := buildPolicy(func(p *TestPolicy) { p.Name = "source" })
+	target := buildPolicy(func(p *TestPolicy) { p.Name = "target" })
+	overrides := func(source, _ Policy) Policy { return source }
+
+	registry := NewMergeStrategyRegistry()
+	if _, ok := registry.Lookup(kind); ok {
+		t.Errorf("expected no strategy registered for %s", kind)
+	}
+	if merged := registry.StrategyFor(kind)(source, target); merged != target {
+		t.Errorf("expected default merge strategy to return the target, got %v", merged)
+	}
+
+	registry.Register(kind, overrides)
+	if _, ok := registry.Lookup(kind); !ok {
+		t.Errorf("expected strategy registered for %s", kind)
+	}
+	if merged := registry.StrategyFor(kind)(source, target); merged != source {
+		t.Errorf("expected registered merge strategy to return the source, got %v", merged)
+	}
+	if merged := registry.StrategyFor(schema.GroupKind{Group: "test", Kind: "OtherPolicy"})(source, target); merged != target {
+		t.Errorf("expected default merge strategy for other kinds, got %v", merged)
+	}
+
+	registry.Unregister(kind)
+	if _, ok := registry.Lookup(kind); ok {
+		t.Errorf("expected strategy for %s to be unregistered", kind)
+	}
+
+	// policies resolve their strategies dynamically from the global registry
+	RegisterMergeStrategy(kind, overrides)
+	defer MergeStrategies.Unregister(kind)
+	if merged := target.GetMergeStrategy()(source, target); merged != source {
+		t.Errorf("expected policy to resolve the registered merge strategy, got %v", merged)
+	}
+}
@@ -0,0 +1,92 @@
+package machinery
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+var NamespaceKind = core.SchemeGroupVersion.WithKind("Namespace").GroupKind()
+
+// NamespacedKinds are the kinds of targetables of a Gateway API topology linked from their namespaces, when the
+// namespaces are added to the topology (see WithNamespaces).
+var NamespacedKinds = []schema.GroupKind{
+	{Group: gwapiv1.GroupName, Kind: "Gateway"},
+	{Group: gwapiv1.GroupName, Kind: "HTTPRoute"},
+	{Group: gwapiv1alpha2.GroupName, Kind: "TLSRoute"},
+	{Group: gwapiv1alpha2.GroupName, Kind: "UDPRoute"},
+	{Kind: "Service"},
+}
+
+// Namespace is a namespace of the cluster, as an ancestor of the Gateways, routes and Services in it, so cluster
+// operators can attach policies that apply by default to all the resources of a namespace.
+//
+// Namespaces are cluster-scoped, but a namespace is located in itself, so the policies of a namespace target it with a
+// local target reference of kind Namespace and the name of the namespace, e.g.:
+//
+//	targetRef:
+//	  group: ""
+//	  kind: Namespace
+//	  name: my-namespace
+type Namespace struct {
+	*core.Namespace
+
+	attachedPolicies []Policy
+}
+
+var _ Targetable = &Namespace{}
+
+func (n *Namespace) GroupVersionKind() schema.GroupVersionKind {
+	return core.SchemeGroupVersion.WithKind(NamespaceKind.Kind)
+}
+
+func (n *Namespace) SetGroupVersionKind(schema.GroupVersionKind) {}
+
+func (n *Namespace) GetURL() string {
+	return fmt.Sprintf("%s%s%s", strings.ToLower(NamespaceKind.String()), string(kindNameURLSeparator), namespacedName(n.Name, n.Name))
+}
+
+func (n *Namespace) SetPolicies(policies []Policy) {
+	n.attachedPolicies = policies
+}
+
+func (n *Namespace) Policies() []Policy {
+	return n.attachedPolicies
+}
+
+// WithNamespaces adds namespaces to the options to initialize a new Gateway API topology, linked to the targetables of
+// the NamespacedKinds in them. The labels of the namespaces are added too (see WithGatewayAPITopologyNamespaceLabels).
+//
+// With the namespaces, the paths of the topology start at the namespaces of the Gateways (or at the namespaces of the
+// Services, for the paths that start at the Services), thus the effective policies of the paths include the policies
+// attached to those namespaces. Use NamespaceRootKinds to start the paths at the namespaces explicitly.
+func WithNamespaces(namespaces ...*core.Namespace) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		o.Namespaces = append(o.Namespaces, lo.Map(namespaces, func(namespace *core.Namespace, _ int) *Namespace {
+			return &Namespace{Namespace: namespace}
+		})...)
+		WithGatewayAPITopologyNamespaceLabels(lo.SliceToMap(namespaces, func(namespace *core.Namespace) (string, map[string]string) {
+			return namespace.Name, namespace.Labels
+		}))(o)
+	}
+}
+
+// LinkNamespaceToNamespacedFunc returns a link function that teaches a topology how to link targetables of a given kind
+// from known Namespaces, based on the namespace of the targetables.
+func LinkNamespaceToNamespacedFunc(namespaces []*Namespace, kind schema.GroupKind) LinkFunc {
+	return LinkFunc{
+		From:         NamespaceKind,
+		To:           kind,
+		Relationship: EdgeRelationshipNamespace,
+		Func: func(child Object) []Object {
+			return lo.FilterMap(namespaces, func(namespace *Namespace, _ int) (Object, bool) {
+				return namespace, namespace.Name == child.GetNamespace()
+			})
+		},
+	}
+}
@@ -0,0 +1,74 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestNamespaces(t *testing.T) {
+	namespace := &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "my-namespace", Labels: map[string]string{"env": "prod"}}}
+	otherNamespace := &core.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "other-namespace"}}
+	otherService := BuildService(func(s *core.Service) { s.Namespace = "other-namespace" })
+	namespacePolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "namespace-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Kind: "Namespace", Name: "my-namespace"},
+		}
+	})
+	routePolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "route-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "HTTPRoute", Name: "my-http-route"},
+		}
+	})
+	// a policy cannot target another namespace with a local target reference
+	foreignPolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "foreign-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Kind: "Namespace", Name: "other-namespace"},
+		}
+	})
+
+	topology := NewGatewayAPITopology(
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithServices(BuildService(), otherService),
+		WithNamespaces(namespace, otherNamespace),
+		WithGatewayAPITopologyPolicies(namespacePolicy, routePolicy, foreignPolicy),
+	)
+
+	namespaces := lo.KeyBy(topology.Targetables().Items(IsKind(NamespaceKind)), func(t Targetable) string { return t.GetName() })
+	if len(namespaces) != 2 {
+		t.Fatalf("expected 2 namespaces, got %v", namespaces)
+	}
+	if policies := namespaces["my-namespace"].Policies(); len(policies) != 1 || policies[0].GetName() != "namespace-policy" {
+		t.Errorf("expected the namespace policy attached to the namespace, got %v", policies)
+	}
+	if policies := namespaces["other-namespace"].Policies(); len(policies) != 0 {
+		t.Errorf("expected no policy attached to the other namespace, got %v", policies)
+	}
+	children := lo.Map(topology.Targetables().Children(namespaces["my-namespace"]), func(t Targetable, _ int) string { return t.GroupVersionKind().Kind })
+	if len(children) != 3 || !lo.Contains(children, "Gateway") || !lo.Contains(children, "HTTPRoute") || !lo.Contains(children, "Service") {
+		t.Errorf("expected the gateway, the route and the service linked from their namespace, got %v", children)
+	}
+	if roots := topology.Targetables().Roots(); len(roots) != 2 || lo.ContainsBy(roots, func(r Targetable) bool { return r.GroupVersionKind().GroupKind() != NamespaceKind }) {
+		t.Errorf("expected the namespaces as roots, got %v", roots)
+	}
+
+	// the namespace policy is the default of the paths from the namespace
+	serviceURL := UrlFromObject(&Service{Service: BuildService()})
+	effectivePolicies := EffectivePoliciesByTargetFrom[*TestPolicy](topology, NamespaceRootKinds, func(o Object) bool { return o.GetURL() == serviceURL })
+	targets := lo.Map(effectivePolicies[serviceURL], func(p EffectivePolicy[*TestPolicy], _ int) string {
+		return string(p.Policy.Spec.TargetRef.Name)
+	})
+	if len(targets) != 3 || lo.Count(targets, "my-http-route") != 2 || lo.Count(targets, "my-namespace") != 1 {
+		t.Errorf("expected the route policy overriding the namespace policy on the paths through the route, got %v", targets)
+	}
+}
@@ -0,0 +1,29 @@
+package machinery
+
+import "reflect"
+
+// NormalizablePolicy is a Policy whose spec can be normalized, e.g. by sorting lists, setting default values or
+// trimming strings, so semantically equal policies merge and compare equal.
+// Policies are normalized before they are merged into effective policies, and effective policies are normalized after
+// merging (see EffectivePolicyForPath), as well as before they are compared (see PoliciesEqual).
+type NormalizablePolicy interface {
+	Policy
+	// Normalize returns a normalized copy of the policy, of the same type. It must not modify the policy.
+	Normalize() Policy
+}
+
+// NormalizePolicy returns a normalized copy of a policy if it implements NormalizablePolicy, or the policy otherwise.
+func NormalizePolicy(policy Policy) Policy {
+	if isNilPolicy(policy) {
+		return policy
+	}
+	if normalizable, ok := policy.(NormalizablePolicy); ok {
+		return normalizable.Normalize()
+	}
+	return policy
+}
+
+// PoliciesEqual returns true if two policies are deeply equal once normalized.
+func PoliciesEqual(p1, p2 Policy) bool {
+	return reflect.DeepEqual(NormalizePolicy(p1), NormalizePolicy(p2))
+}
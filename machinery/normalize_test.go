@@ -0,0 +1,79 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+// listPolicy is a policy whose values are merged as a list, normalized by trimming, deduplicating and sorting them
+type listPolicy struct {
+	*TestPolicy
+	Values []string
+}
+
+func (p *listPolicy) Merge(other Policy) Policy {
+	source, ok := other.(*listPolicy)
+	if !ok {
+		return p
+	}
+	return &listPolicy{TestPolicy: p.TestPolicy, Values: append(append([]string{}, source.Values...), p.Values...)}
+}
+
+func (p *listPolicy) Normalize() Policy {
+	values := lo.Uniq(lo.Map(p.Values, func(v string, _ int) string { return strings.TrimSpace(v) }))
+	sort.Strings(values)
+	return &listPolicy{TestPolicy: p.TestPolicy, Values: values}
+}
+
+var _ NormalizablePolicy = &listPolicy{}
+
+func TestNormalizePolicy(t *testing.T) {
+	policy := buildPolicy()
+	if NormalizePolicy(policy) != policy {
+		t.Errorf("expected policies that are not normalizable to be returned as they are")
+	}
+	if NormalizePolicy(nil) != nil {
+		t.Errorf("expected nil policy")
+	}
+	var nilPolicy *listPolicy
+	if NormalizePolicy(nilPolicy) != Policy(nilPolicy) {
+		t.Errorf("expected nil policy")
+	}
+
+	unsorted := &listPolicy{TestPolicy: policy, Values: []string{"b", " a", "a"}}
+	normalized := NormalizePolicy(unsorted).(*listPolicy)
+	if !slices.Equal(normalized.Values, []string{"a", "b"}) {
+		t.Errorf("expected normalized values [a b], got %v", normalized.Values)
+	}
+	if !slices.Equal(unsorted.Values, []string{"b", " a", "a"}) {
+		t.Errorf("expected the policy not to be modified, got %v", unsorted.Values)
+	}
+
+	if !PoliciesEqual(unsorted, &listPolicy{TestPolicy: policy, Values: []string{"a", "b"}}) {
+		t.Errorf("expected semantically equal policies to be equal")
+	}
+	if PoliciesEqual(unsorted, &listPolicy{TestPolicy: policy, Values: []string{"a", "c"}}) {
+		t.Errorf("expected different policies not to be equal")
+	}
+}
+
+func TestEffectivePolicyNormalized(t *testing.T) {
+	gateway := &Gateway{Gateway: BuildGateway()}
+	gateway.SetPolicies([]Policy{&listPolicy{TestPolicy: buildPolicy(), Values: []string{"z", "b "}}})
+	route := &HTTPRoute{HTTPRoute: BuildHTTPRoute()}
+	route.SetPolicies([]Policy{&listPolicy{TestPolicy: buildPolicy(), Values: []string{"b", "a"}}})
+
+	effectivePolicy, found := ComputeEffectivePolicy[*listPolicy]([]Targetable{gateway, route})
+	if !found {
+		t.Fatal("expected effective policy")
+	}
+	if !slices.Equal(effectivePolicy.Values, []string{"a", "b", "z"}) {
+		t.Errorf("expected normalized effective policy with values [a b z], got %v", effectivePolicy.Values)
+	}
+}
@@ -0,0 +1,175 @@
+package machinery
+
+import (
+	"github.com/emicklei/dot"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// EdgeFilterFunc is a predicate on an edge of the topology, given its ends and the name of the link that originated it.
+type EdgeFilterFunc func(from, to Object, name string) bool
+
+// PathQuery is a builder of constrained path traversals on a collection of the topology.
+// All constraints are optional and can be combined.
+type PathQuery[T Object] struct {
+	collection  *collection[T]
+	from        []FilterFunc
+	to          []FilterFunc
+	maxDepth    int
+	nodeFilters []FilterFunc
+	edgeFilters []EdgeFilterFunc
+	relations   []EdgeRelationship
+	waypoints   []FilterFunc
+}
+
+// PathQuery returns a new path query on the collection.
+func (c *collection[T]) PathQuery() *PathQuery[T] {
+	return &PathQuery[T]{collection: c}
+}
+
+// From sets the predicates that the first item of the paths must satisfy.
+// If not set, the paths start from the roots of the collection.
+func (q *PathQuery[T]) From(filters ...FilterFunc) *PathQuery[T] {
+	q.from = append(q.from, filters...)
+	return q
+}
+
+// FromKinds starts the paths at the items of any of the given kinds, whether they are roots of the collection or not,
+// e.g. IngressRootKinds or MeshRootKinds. It can be combined with From.
+func (q *PathQuery[T]) FromKinds(kinds ...schema.GroupKind) *PathQuery[T] {
+	if len(kinds) == 0 {
+		return q
+	}
+	return q.From(IsAnyKind(kinds...))
+}
+
+// To sets the predicates that the last item of the paths must satisfy.
+// If not set, the paths end at the leaves of the collection.
+func (q *PathQuery[T]) To(filters ...FilterFunc) *PathQuery[T] {
+	q.to = append(q.to, filters...)
+	return q
+}
+
+// MaxDepth limits the length of the paths to a maximum number of edges. A value less than 1 means no limit.
+func (q *PathQuery[T]) MaxDepth(depth int) *PathQuery[T] {
+	q.maxDepth = depth
+	return q
+}
+
+// Where sets the predicates that all items of the paths must satisfy.
+func (q *PathQuery[T]) Where(filters ...FilterFunc) *PathQuery[T] {
+	q.nodeFilters = append(q.nodeFilters, filters...)
+	return q
+}
+
+// WhereEdge sets the predicates that all edges of the paths must satisfy.
+func (q *PathQuery[T]) WhereEdge(filters ...EdgeFilterFunc) *PathQuery[T] {
+	q.edgeFilters = append(q.edgeFilters, filters...)
+	return q
+}
+
+// WhereRelationship constrains the edges of the paths to the ones of any of the given kinds of relationships, e.g.
+// to follow only the parentRefs and backendRefs from gateways to services.
+func (q *PathQuery[T]) WhereRelationship(relationships ...EdgeRelationship) *PathQuery[T] {
+	q.relations = append(q.relations, relationships...)
+	return q
+}
+
+// Through adds a waypoint to the paths, i.e. a predicate that at least one item of the paths must satisfy.
+// Waypoints must be passed through in the order they are added.
+func (q *PathQuery[T]) Through(filter FilterFunc) *PathQuery[T] {
+	q.waypoints = append(q.waypoints, filter)
+	return q
+}
+
+// Paths returns all paths that satisfy the constraints of the query.
+// The order of the elements in the inner slices represents a path from the source to the destination.
+func (q *PathQuery[T]) Paths() [][]T {
+	var sources []T
+	if len(q.from) > 0 {
+		sources = q.collection.Items(q.from...)
+	} else {
+		sources = q.collection.Roots()
+	}
+	sources = sortedByURL(sources)
+
+	var paths [][]T
+	for _, source := range sources {
+		q.dfs(source, nil, 0, &paths, make(map[string]bool))
+	}
+	return paths
+}
+
+func (q *PathQuery[T]) dfs(current T, path []T, waypoint int, paths *[][]T, visited map[string]bool) {
+	currentURL := current.GetURL()
+	if visited[currentURL] || !matchesAll(current, q.nodeFilters) {
+		return
+	}
+	path = append(path, current)
+	visited[currentURL] = true
+	defer func() { visited[currentURL] = false }()
+
+	for waypoint < len(q.waypoints) && q.waypoints[waypoint](current) {
+		waypoint++
+	}
+
+	isDestination := len(q.to) > 0 && matchesAll(current, q.to) || len(q.to) == 0 && len(q.collection.Children(current)) == 0
+	if isDestination && waypoint == len(q.waypoints) {
+		pathCopy := make([]T, len(path))
+		copy(pathCopy, path)
+		*paths = append(*paths, pathCopy)
+	}
+
+	if q.maxDepth > 0 && len(path) > q.maxDepth {
+		return
+	}
+	for _, child := range q.children(current) {
+		q.dfs(child, path, waypoint, paths, visited)
+	}
+}
+
+// children returns the children of an item in the collection, connected by edges that satisfy the edge predicates.
+func (q *PathQuery[T]) children(item T) []T {
+	edges := q.collection.topology.graph.EdgesMap()[item.GetURL()]
+	children := lo.FilterMap(edges, func(edge dot.Edge, _ int) (T, bool) {
+		child, found := q.collection.items[edge.To().ID()]
+		if !found {
+			return child, false
+		}
+		if relationship, _ := edge.GetAttr(relationshipEdgeAttribute).(string); len(q.relations) > 0 && !lo.Contains(q.relations, EdgeRelationship(relationship)) {
+			return child, false
+		}
+		name, _ := edge.GetAttr("comment").(string)
+		return child, lo.EveryBy(q.edgeFilters, func(f EdgeFilterFunc) bool { return f(item, child, name) })
+	})
+	return sortedByURL(children)
+}
+
+func matchesAll(obj Object, filters []FilterFunc) bool {
+	return lo.EveryBy(filters, func(f FilterFunc) bool { return f(obj) })
+}
+
+// IsKind returns a predicate that matches objects of a given kind.
+func IsKind(kind schema.GroupKind) FilterFunc {
+	return func(obj Object) bool {
+		return obj.GroupVersionKind().GroupKind() == kind
+	}
+}
+
+// IsAnyKind returns a predicate that matches objects of any of the given kinds.
+func IsAnyKind(kinds ...schema.GroupKind) FilterFunc {
+	return func(obj Object) bool {
+		return lo.Contains(kinds, obj.GroupVersionKind().GroupKind())
+	}
+}
+
+// HasPolicy returns a predicate that matches targetables with at least one attached policy that satisfies the given
+// predicates.
+func HasPolicy(filters ...FilterFunc) FilterFunc {
+	return func(obj Object) bool {
+		targetable, ok := obj.(Targetable)
+		return ok && lo.ContainsBy(targetable.Policies(), func(policy Policy) bool {
+			return matchesAll(policy, filters)
+		})
+	}
+}
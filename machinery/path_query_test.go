@@ -0,0 +1,127 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestPathQuery(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	httpRouteKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"}
+	httpRouteRuleKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRouteRule"}
+	serviceKind := schema.GroupKind{Group: core.GroupName, Kind: "Service"}
+
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway(func(g *gwapiv1.Gateway) {
+			g.Spec.Listeners = append(g.Spec.Listeners, gwapiv1.Listener{Name: "my-other-listener", Port: 8080, Protocol: "HTTP"})
+		})),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+			r.Spec.Rules = append(r.Spec.Rules, gwapiv1.HTTPRouteRule{
+				BackendRefs: []gwapiv1.HTTPBackendRef{BuildHTTPBackendRef(func(ref *gwapiv1.BackendObjectReference) {
+					ref.Name = "my-other-service"
+				})},
+			})
+		})),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService(), BuildService(func(s *core.Service) { s.Name = "my-other-service" })),
+		ExpandServicePorts(),
+		WithGatewayAPITopologyPolicies(buildPolicy(func(p *TestPolicy) {
+			p.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "HTTPRoute",
+					Name:  "my-http-route",
+				},
+				SectionName: ptr.To(gwapiv1.SectionName("rule-2")),
+			}
+		})),
+	)
+
+	pathNames := func(paths [][]Targetable) [][]string {
+		return lo.Map(paths, func(path []Targetable, _ int) []string {
+			return lo.Map(path, func(t Targetable, _ int) string { return t.GetName() })
+		})
+	}
+
+	testCases := []struct {
+		name     string
+		query    *PathQuery[Targetable]
+		expected [][]string
+	}{
+		{
+			name:  "from gateway to services",
+			query: topology.Targetables().PathQuery().From(IsKind(gatewayKind)).To(IsKind(serviceKind)),
+			expected: [][]string{
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-1", "my-service"},
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+				{"my-gateway", "my-gateway#my-other-listener", "my-http-route", "my-http-route#rule-1", "my-service"},
+				{"my-gateway", "my-gateway#my-other-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+			},
+		},
+		{
+			name: "through http route rules with a policy attached",
+			query: topology.Targetables().PathQuery().From(IsKind(gatewayKind)).To(IsKind(serviceKind)).
+				Through(func(o Object) bool { return IsKind(httpRouteRuleKind)(o) && HasPolicy()(o) }),
+			expected: [][]string{
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+				{"my-gateway", "my-gateway#my-other-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+			},
+		},
+		{
+			name: "with node predicate",
+			query: topology.Targetables().PathQuery().From(IsKind(gatewayKind)).To(IsKind(serviceKind)).
+				Where(func(o Object) bool { return o.GetName() != "my-gateway#my-other-listener" }),
+			expected: [][]string{
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-1", "my-service"},
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+			},
+		},
+		{
+			name: "with edge predicate",
+			query: topology.Targetables().PathQuery().From(IsKind(gatewayKind)).To(IsKind(serviceKind)).
+				WhereEdge(func(from, to Object, _ string) bool { return from.GetName() != "my-http-route#rule-1" }),
+			expected: [][]string{
+				{"my-gateway", "my-gateway#my-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+				{"my-gateway", "my-gateway#my-other-listener", "my-http-route", "my-http-route#rule-2", "my-other-service"},
+			},
+		},
+		{
+			name:     "with max depth",
+			query:    topology.Targetables().PathQuery().From(IsKind(gatewayKind)).To(IsKind(serviceKind)).MaxDepth(3),
+			expected: nil,
+		},
+		{
+			name:  "from roots to leaves with max depth",
+			query: topology.Targetables().PathQuery().To(IsKind(httpRouteKind)).MaxDepth(3),
+			expected: [][]string{
+				{"my-gateway-class", "my-gateway", "my-gateway#my-listener", "my-http-route"},
+				{"my-gateway-class", "my-gateway", "my-gateway#my-other-listener", "my-http-route"},
+			},
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			actual := pathNames(tc.query.Paths())
+			if len(actual) != len(tc.expected) {
+				t.Fatalf("expected %d paths, got %d: %v", len(tc.expected), len(actual), actual)
+			}
+			for i := range tc.expected {
+				if !slices.Equal(actual[i], tc.expected[i]) {
+					t.Errorf("expected path %v, got %v", tc.expected[i], actual[i])
+				}
+			}
+		})
+	}
+}
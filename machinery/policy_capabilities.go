@@ -0,0 +1,101 @@
+package machinery
+
+import (
+	"errors"
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// PolicyCapabilities declares the targets supported by a kind of policy.
+// Policy kinds without declared capabilities support any target.
+type PolicyCapabilities struct {
+	// TargetKinds are the kinds of resources that the policies can target as a whole, e.g. Gateway or HTTPRoute.
+	TargetKinds []schema.GroupKind
+	// SectionTargetKinds are the kinds of resources whose sections the policies can target by section name, e.g.
+	// Gateway to target listeners or HTTPRoute to target route rules.
+	SectionTargetKinds []schema.GroupKind
+}
+
+// Supports returns nil if a target reference is supported by the capabilities; otherwise, it returns the reason why
+// the target is not supported.
+func (c PolicyCapabilities) Supports(targetRef PolicyTargetReference) error {
+	kind := targetRef.GroupVersionKind().GroupKind()
+	if isSectionTargetRef(targetRef) {
+		if !lo.Contains(c.SectionTargetKinds, kind) {
+			return errors.New("targeting sections of " + kind.String() + " is not supported")
+		}
+		return nil
+	}
+	if !lo.Contains(c.TargetKinds, kind) {
+		return errors.New("targeting " + kind.String() + " is not supported")
+	}
+	return nil
+}
+
+func isSectionTargetRef(targetRef PolicyTargetReference) bool {
+	return isSectionName(targetRef.GetName())
+}
+
+var policyCapabilities = struct {
+	sync.RWMutex
+	byKind map[schema.GroupKind]PolicyCapabilities
+}{
+	byKind: make(map[schema.GroupKind]PolicyCapabilities),
+}
+
+// RegisterPolicyCapabilities declares the targets supported by a kind of policy, replacing any capabilities
+// previously declared for the kind.
+func RegisterPolicyCapabilities(kind schema.GroupKind, capabilities PolicyCapabilities) {
+	policyCapabilities.Lock()
+	defer policyCapabilities.Unlock()
+	policyCapabilities.byKind[kind] = capabilities
+}
+
+// UnregisterPolicyCapabilities removes the capabilities declared for a kind of policy.
+func UnregisterPolicyCapabilities(kind schema.GroupKind) {
+	policyCapabilities.Lock()
+	defer policyCapabilities.Unlock()
+	delete(policyCapabilities.byKind, kind)
+}
+
+// PolicyCapabilitiesFor returns the capabilities declared for a kind of policy, if any.
+func PolicyCapabilitiesFor(kind schema.GroupKind) (PolicyCapabilities, bool) {
+	policyCapabilities.RLock()
+	defer policyCapabilities.RUnlock()
+	capabilities, ok := policyCapabilities.byKind[kind]
+	return capabilities, ok
+}
+
+// ValidateTargetRefs checks the target references of a policy against the capabilities declared for its kind.
+// Unsupported target references are reported as UnsupportedTargetError. It is meant to be used by target resolution
+// as well as by admission webhooks, to reject unsupported targets before they are stored.
+func ValidateTargetRefs(policy Policy) error {
+	var errs []error
+	for _, targetRef := range policy.GetTargetRefs() {
+		if err := ValidateTargetRef(policy, targetRef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// ValidateTargetRef checks a target reference of a policy against the capabilities declared for the kind of policy,
+// and a target reference to a section against the section kinds declared for the kind of target (see
+// RegisterSectionKinds). It returns an UnsupportedTargetError if the target reference is not supported.
+func ValidateTargetRef(policy Policy, targetRef PolicyTargetReference) error {
+	if kind := targetRef.GroupVersionKind().GroupKind(); isSectionTargetRef(targetRef) {
+		if kinds, ok := SectionKindsFor(kind); ok && len(kinds) == 0 {
+			return &UnsupportedTargetError{Policy: policy, TargetRef: targetRef, Reason: kind.String() + " has no sections"}
+		}
+	}
+	capabilities, ok := PolicyCapabilitiesFor(policy.GroupVersionKind().GroupKind())
+	if !ok {
+		return nil
+	}
+	if reason := capabilities.Supports(targetRef); reason != nil {
+		return &UnsupportedTargetError{Policy: policy, TargetRef: targetRef, Reason: reason.Error()}
+	}
+	return nil
+}
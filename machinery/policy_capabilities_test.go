@@ -0,0 +1,109 @@
+//go:build unit
+
+package machinery
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestPolicyCapabilities(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	serviceKind := schema.GroupKind{Kind: "Service"}
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+
+	servicePolicy := buildPolicy()
+	gatewayPolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "gateway-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "Gateway",
+				Name:  "my-gateway",
+			},
+		}
+	})
+	listenerPolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "listener-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "Gateway",
+				Name:  "my-gateway",
+			},
+			SectionName: ptr.To(gwapiv1.SectionName("my-listener")),
+		}
+	})
+
+	buildTopology := func() *Topology {
+		return NewGatewayAPITopology(
+			WithGatewayClasses(BuildGatewayClass()),
+			WithGateways(BuildGateway()),
+			ExpandGatewayListeners(),
+			WithServices(BuildService()),
+			WithGatewayAPITopologyPolicies(servicePolicy, gatewayPolicy, listenerPolicy),
+		)
+	}
+
+	// no capabilities declared: any target is supported
+	if err := ValidateTargetRefs(listenerPolicy); err != nil {
+		t.Errorf("expected no error without declared capabilities, got %v", err)
+	}
+	if policies := buildTopology().Targetables().Items(func(o Object) bool { return o.GetName() == "my-gateway#my-listener" })[0].Policies(); len(policies) != 1 {
+		t.Errorf("expected 1 policy attached to the listener, got %d", len(policies))
+	}
+
+	RegisterPolicyCapabilities(policyKind, PolicyCapabilities{
+		TargetKinds:        []schema.GroupKind{gatewayKind},
+		SectionTargetKinds: []schema.GroupKind{},
+	})
+	defer UnregisterPolicyCapabilities(policyKind)
+
+	if err := ValidateTargetRefs(gatewayPolicy); err != nil {
+		t.Errorf("expected gateway target to be supported, got %v", err)
+	}
+	for _, policy := range []*TestPolicy{servicePolicy, listenerPolicy} {
+		err := ValidateTargetRefs(policy)
+		if !errors.Is(err, ErrUnsupportedTarget) {
+			t.Errorf("expected unsupported target error for %s, got %v", policy.GetName(), err)
+		}
+		var unsupported *UnsupportedTargetError
+		if !errors.As(err, &unsupported) || unsupported.Policy != Policy(policy) {
+			t.Errorf("expected unsupported target error of %s, got %v", policy.GetName(), err)
+		}
+	}
+	if err := ValidateTargetRefs(listenerPolicy); err == nil || err.Error() != "unsupported target: gateway.gateway.networking.k8s.io:my-namespace/my-gateway#my-listener (policy testpolicy.test:my-namespace/listener-policy): targeting sections of Gateway.gateway.networking.k8s.io is not supported" {
+		t.Errorf("unexpected error message: %v", err)
+	}
+
+	topology := buildTopology()
+	for _, targetable := range topology.Targetables().Items() {
+		for _, policy := range targetable.Policies() {
+			if policy.GetName() != "gateway-policy" {
+				t.Errorf("expected policy %s not to be attached to %s", policy.GetName(), targetable.GetURL())
+			}
+		}
+	}
+	if _, err := topology.Targets(servicePolicy); !errors.Is(err, ErrUnsupportedTarget) || errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected unsupported target error resolving the targets of the service policy, got %v", err)
+	}
+	if targets, err := topology.Targets(gatewayPolicy); err != nil || len(targets) != 1 {
+		t.Errorf("expected the gateway policy to resolve to 1 target, got %v (%v)", targets, err)
+	}
+
+	RegisterPolicyCapabilities(policyKind, PolicyCapabilities{
+		TargetKinds:        []schema.GroupKind{serviceKind},
+		SectionTargetKinds: []schema.GroupKind{gatewayKind},
+	})
+	if err := ValidateTargetRefs(listenerPolicy); err != nil {
+		t.Errorf("expected listener target to be supported, got %v", err)
+	}
+	if err := ValidateTargetRefs(servicePolicy); err != nil {
+		t.Errorf("expected service target to be supported, got %v", err)
+	}
+}
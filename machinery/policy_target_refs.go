@@ -0,0 +1,56 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// GatewayAPIPolicyTargetReference is any of the shapes of the target references of policies defined by the Gateway
+// API (GEP-713): local, local with section name, or namespaced.
+type GatewayAPIPolicyTargetReference interface {
+	gwapiv1alpha2.LocalPolicyTargetReference | gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName | gwapiv1alpha2.NamespacedPolicyTargetReference
+}
+
+// ToPolicyTargetReference converts a Gateway API target reference of a policy into a PolicyTargetReference.
+// Local target references resolve in the namespace of the policy, and so do namespaced target references that do not
+// specify a namespace.
+func ToPolicyTargetReference[T GatewayAPIPolicyTargetReference](policyNamespace string, targetRef T) PolicyTargetReference {
+	switch ref := any(targetRef).(type) {
+	case gwapiv1alpha2.LocalPolicyTargetReference:
+		return LocalPolicyTargetReference{LocalPolicyTargetReference: ref, PolicyNamespace: policyNamespace}
+	case gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName:
+		return LocalPolicyTargetReferenceWithSectionName{LocalPolicyTargetReferenceWithSectionName: ref, PolicyNamespace: policyNamespace}
+	case gwapiv1alpha2.NamespacedPolicyTargetReference:
+		return NamespacedPolicyTargetReference{NamespacedPolicyTargetReference: ref, PolicyNamespace: policyNamespace}
+	}
+	return nil // unreachable
+}
+
+// ToPolicyTargetReferences converts Gateway API target references of a policy into PolicyTargetReferences.
+// See ToPolicyTargetReference.
+func ToPolicyTargetReferences[T GatewayAPIPolicyTargetReference](policyNamespace string, targetRefs ...T) []PolicyTargetReference {
+	return lo.Map(targetRefs, func(targetRef T, _ int) PolicyTargetReference {
+		return ToPolicyTargetReference(policyNamespace, targetRef)
+	})
+}
+
+// PolicyTargetReferencesOf converts the target references of a policy whose API has both a single `targetRef` field
+// and a `targetRefs` list, e.g. while migrating from the former to the latter, into PolicyTargetReferences.
+// The single target reference, if set, comes first. Target references without a name are deemed unset and skipped,
+// and so are duplicates, so a policy can set the same target in both fields.
+//
+// Example:
+//
+//	func (p *MyPolicy) GetTargetRefs() []machinery.PolicyTargetReference {
+//		return machinery.PolicyTargetReferencesOf(p.Namespace, p.Spec.TargetRef, p.Spec.TargetRefs)
+//	}
+func PolicyTargetReferencesOf[T GatewayAPIPolicyTargetReference](policyNamespace string, targetRef *T, targetRefs []T) []PolicyTargetReference {
+	if targetRef != nil {
+		targetRefs = append([]T{*targetRef}, targetRefs...)
+	}
+	refs := lo.Filter(ToPolicyTargetReferences(policyNamespace, targetRefs...), func(ref PolicyTargetReference, _ int) bool {
+		name, _, _ := ParseSectionName(ref.GetName())
+		return name != ""
+	})
+	return lo.UniqBy(refs, func(ref PolicyTargetReference) string { return ref.GetURL() })
+}
@@ -0,0 +1,90 @@
+//go:build unit
+
+package machinery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestToPolicyTargetReference(t *testing.T) {
+	local := gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"}
+
+	testCases := []struct {
+		name        string
+		targetRef   PolicyTargetReference
+		expectedURL string
+	}{
+		{
+			name:        "local",
+			targetRef:   ToPolicyTargetReference("my-namespace", local),
+			expectedURL: "gateway.gateway.networking.k8s.io:my-namespace/my-gateway",
+		},
+		{
+			name:        "local with section name",
+			targetRef:   ToPolicyTargetReference("my-namespace", gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{LocalPolicyTargetReference: local, SectionName: ptr.To(gwapiv1.SectionName("my-listener"))}),
+			expectedURL: "gateway.gateway.networking.k8s.io:my-namespace/my-gateway#my-listener",
+		},
+		{
+			name:        "local without section name",
+			targetRef:   ToPolicyTargetReference("my-namespace", gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{LocalPolicyTargetReference: local}),
+			expectedURL: "gateway.gateway.networking.k8s.io:my-namespace/my-gateway",
+		},
+		{
+			name:        "namespaced",
+			targetRef:   ToPolicyTargetReference("my-namespace", gwapiv1alpha2.NamespacedPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway", Namespace: ptr.To(gwapiv1.Namespace("other-namespace"))}),
+			expectedURL: "gateway.gateway.networking.k8s.io:other-namespace/my-gateway",
+		},
+		{
+			name:        "namespaced without namespace",
+			targetRef:   ToPolicyTargetReference("my-namespace", gwapiv1alpha2.NamespacedPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: "my-gateway"}),
+			expectedURL: "gateway.gateway.networking.k8s.io:my-namespace/my-gateway",
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			if url := tc.targetRef.GetURL(); url != tc.expectedURL {
+				t.Errorf("expected target ref URL %s, got %s", tc.expectedURL, url)
+			}
+		})
+	}
+}
+
+func TestPolicyTargetReferencesOf(t *testing.T) {
+	ref := func(name gwapiv1.ObjectName) gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName {
+		return gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: gwapiv1.GroupName, Kind: "Gateway", Name: name},
+		}
+	}
+	names := func(refs []PolicyTargetReference) []string {
+		return lo.Map(refs, func(ref PolicyTargetReference, _ int) string { return ref.GetName() })
+	}
+
+	testCases := []struct {
+		name       string
+		targetRef  *gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
+		targetRefs []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName
+		expected   []string
+	}{
+		{name: "single target ref", targetRef: ptr.To(ref("gateway-a")), expected: []string{"gateway-a"}},
+		{name: "target refs", targetRefs: []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{ref("gateway-a"), ref("gateway-b")}, expected: []string{"gateway-a", "gateway-b"}},
+		{name: "both", targetRef: ptr.To(ref("gateway-a")), targetRefs: []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{ref("gateway-b"), ref("gateway-a")}, expected: []string{"gateway-a", "gateway-b"}},
+		{name: "unset target ref", targetRef: ptr.To(ref("")), targetRefs: []gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{ref("gateway-b")}, expected: []string{"gateway-b"}},
+		{name: "none"},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			refs := PolicyTargetReferencesOf("my-namespace", tc.targetRef, tc.targetRefs)
+			if actual := names(refs); len(actual) != len(tc.expected) || len(actual) > 0 && !reflect.DeepEqual(actual, tc.expected) {
+				t.Errorf("expected target refs %v, got %v", tc.expected, actual)
+			}
+		})
+	}
+}
@@ -0,0 +1,39 @@
+package machinery
+
+import (
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// pruneEmptyNodes removes from a list of targetables the empty nodes of the given kinds, i.e. nodes with no attached
+// policies, no children and at most one parent, as well as the edges to the removed nodes.
+// Edges from the config object are ignored and removed along with the nodes.
+func pruneEmptyNodes(targetables []Targetable, edges []Edge, kinds []schema.GroupKind, config Object) ([]Targetable, []Edge) {
+	parents := make(map[string]int)
+	children := make(map[string]int)
+	for _, edge := range edges {
+		if config != nil && edge.From.GetURL() == config.GetURL() {
+			continue
+		}
+		parents[edge.To.GetURL()]++
+		children[edge.From.GetURL()]++
+	}
+
+	pruned := make(map[string]struct{})
+	targetables = lo.Filter(targetables, func(t Targetable, _ int) bool {
+		url := t.GetURL()
+		if !lo.Contains(kinds, t.GroupVersionKind().GroupKind()) || len(t.Policies()) > 0 || children[url] > 0 || parents[url] > 1 {
+			return true
+		}
+		pruned[url] = struct{}{}
+		return false
+	})
+	if len(pruned) == 0 {
+		return targetables, edges
+	}
+	edges = lo.Filter(edges, func(edge Edge, _ int) bool {
+		_, ok := pruned[edge.To.GetURL()]
+		return !ok
+	})
+	return targetables, edges
+}
@@ -0,0 +1,84 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestPruneEmptyExpansions(t *testing.T) {
+	gateway := BuildGateway(func(g *gwapiv1.Gateway) {
+		g.Spec.Listeners = []gwapiv1.Listener{
+			{Name: "routed", Port: 80, Protocol: gwapiv1.HTTPProtocolType},
+			{Name: "empty", Port: 8080, Protocol: gwapiv1.HTTPProtocolType},
+			{Name: "with-policy", Port: 8443, Protocol: gwapiv1.HTTPProtocolType},
+		}
+	})
+	route := BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) {
+		r.Spec.ParentRefs[0].SectionName = ptr.To(gwapiv1.SectionName("routed"))
+		r.Spec.Rules[0].BackendRefs[0].Port = ptr.To(gwapiv1.PortNumber(80))
+	})
+	service := BuildService(func(s *core.Service) {
+		s.Spec.Ports = append(s.Spec.Ports, core.ServicePort{Name: "metrics", Port: 9090})
+	})
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef.Group = gwapiv1.GroupName
+		policy.Spec.TargetRef.Kind = "Gateway"
+		policy.Spec.TargetRef.Name = "my-gateway"
+		policy.Spec.TargetRef.SectionName = ptr.To(gwapiv1.SectionName("with-policy"))
+	})
+
+	sections := func(topology *Topology) []string {
+		targetables := topology.Targetables().Items(func(o Object) bool {
+			kind := o.GroupVersionKind().Kind
+			return kind == "Listener" || kind == "ServicePort" || kind == "HTTPRouteRule"
+		})
+		return lo.Map(sortedByURL(targetables), MapTargetableToURLFunc)
+	}
+
+	options := []GatewayAPITopologyOptionsFunc{
+		WithGateways(gateway),
+		WithHTTPRoutes(route),
+		WithServices(service),
+		WithGatewayAPITopologyPolicies(policy),
+		ExpandGatewayListeners(),
+		ExpandHTTPRouteRules(),
+		ExpandServicePorts(),
+	}
+
+	expected := []string{
+		"gateway.gateway.networking.k8s.io:my-namespace/my-gateway#empty",
+		"gateway.gateway.networking.k8s.io:my-namespace/my-gateway#routed",
+		"gateway.gateway.networking.k8s.io:my-namespace/my-gateway#with-policy",
+		"httproute.gateway.networking.k8s.io:my-namespace/my-http-route#rule-1",
+		"service:my-namespace/my-service#http",
+		"service:my-namespace/my-service#metrics",
+	}
+	if actual := sections(NewGatewayAPITopology(options...)); !slices.Equal(actual, expected) {
+		t.Errorf("expected sections %v, got %v", expected, actual)
+	}
+
+	topology := NewGatewayAPITopology(append(options, PruneEmptyExpansions())...)
+	expected = []string{
+		"gateway.gateway.networking.k8s.io:my-namespace/my-gateway#routed",
+		"gateway.gateway.networking.k8s.io:my-namespace/my-gateway#with-policy",
+		"httproute.gateway.networking.k8s.io:my-namespace/my-http-route#rule-1",
+		"service:my-namespace/my-service#http",
+	}
+	if actual := sections(topology); !slices.Equal(actual, expected) {
+		t.Errorf("expected sections %v, got %v", expected, actual)
+	}
+	for _, edge := range topology.Snapshot().Edges {
+		if _, found := topology.Targetables().Get(edge.To); !found {
+			if _, found := topology.Policies().Get(edge.To); !found {
+				t.Errorf("unexpected edge to pruned node %s", edge.To)
+			}
+		}
+	}
+}
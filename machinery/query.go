@@ -0,0 +1,618 @@
+package machinery
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/samber/lo"
+)
+
+// Query is a read-only query of a topology, written in a subset of the Cypher query language, e.g. the routes under a
+// gateway without an AuthPolicy attached:
+//
+//	MATCH (g:Gateway {name: "prod-web"})-[*]->(r:HTTPRoute)
+//	WHERE NOT (r)<-[:TARGETS]-(:AuthPolicy)
+//	RETURN g, r
+//
+// The supported subset is:
+//   - a single MATCH clause with one path pattern, i.e. a chain of nodes and relationships;
+//   - nodes as (variable:Kind {property: "value", ...}), where the variable, the kind and the properties are optional;
+//   - the properties name, namespace, kind, group and url of the nodes;
+//   - relationships between nodes linked in the topology, as --> or <--, and between policies and their targets, as
+//     -[:TARGETS]-> or <-[:TARGETS]-; relationships can span multiple hops, as -[*]-> (one or more), -[*2]-> (exactly
+//     two), -[*1..3]-> (between one and three) or -[*..3]-> (up to three);
+//   - an optional WHERE clause, made of conditions joined with AND, each optionally negated with NOT: comparisons of
+//     the properties of the variables (variable.property = "value" or <> "value"), and path patterns that start at a
+//     variable of the MATCH clause, satisfied if the pattern matches at least once;
+//   - a RETURN clause with the list of variables to return.
+//
+// Keywords are case-insensitive; kinds, properties and values are case-sensitive. Strings are quoted with single or
+// double quotes.
+type Query struct {
+	match   queryPath
+	where   []queryCondition
+	columns []string
+}
+
+// QueryResult is the result of a query of a topology. Each row has the nodes bound to the returned variables, in the
+// order of the columns. Rows are distinct, sorted by the URLs of their nodes.
+type QueryResult struct {
+	Columns []string
+	Rows    [][]Object
+}
+
+// URLs returns the rows of the result with the URLs of the nodes.
+func (r *QueryResult) URLs() [][]string {
+	return lo.Map(r.Rows, func(row []Object, _ int) []string {
+		return lo.Map(row, func(obj Object, _ int) string { return obj.GetURL() })
+	})
+}
+
+// Query parses and runs a query on the topology (see Query).
+func (t *Topology) Query(query string) (*QueryResult, error) {
+	q, err := ParseQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	return q.Run(t), nil
+}
+
+// ParseQuery parses a query of a topology (see Query), to be run multiple times.
+func ParseQuery(query string) (*Query, error) {
+	tokens, err := tokenizeQuery(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &queryParser{tokens: tokens}
+	q, err := p.query()
+	if err != nil {
+		return nil, fmt.Errorf("invalid query: %w", err)
+	}
+	return q, nil
+}
+
+// Run returns the nodes of a topology that match the query.
+func (q *Query) Run(topology *Topology) *QueryResult {
+	graph := newQueryGraph(topology)
+	result := &QueryResult{Columns: q.columns}
+	seen := make(map[string]struct{})
+	for _, bindings := range graph.match(q.match, map[string]Object{}) {
+		if !lo.EveryBy(q.where, func(c queryCondition) bool { return graph.satisfies(c, bindings) }) {
+			continue
+		}
+		row := lo.Map(q.columns, func(column string, _ int) Object { return bindings[column] })
+		key := strings.Join(lo.Map(row, func(obj Object, _ int) string { return obj.GetURL() }), "\n")
+		if _, ok := seen[key]; ok {
+			continue
+		}
+		seen[key] = struct{}{}
+		result.Rows = append(result.Rows, row)
+	}
+	sort.Slice(result.Rows, func(i, j int) bool {
+		for k := range result.Rows[i] {
+			if a, b := result.Rows[i][k].GetURL(), result.Rows[j][k].GetURL(); a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	return result
+}
+
+type queryNode struct {
+	variable   string
+	kind       string
+	properties map[string]string
+}
+
+type queryRelationship struct {
+	targets bool // policy -> target, instead of a link between objects
+	reverse bool
+	minHops int
+	maxHops int // 0 means no limit
+}
+
+type queryPath struct {
+	nodes         []queryNode
+	relationships []queryRelationship
+}
+
+type queryCondition struct {
+	not      bool
+	variable string
+	property string
+	equals   bool
+	value    string
+	path     *queryPath
+}
+
+var queryProperties = map[string]func(Object) string{
+	"name":      func(obj Object) string { return obj.GetName() },
+	"namespace": func(obj Object) string { return obj.GetNamespace() },
+	"kind":      func(obj Object) string { return obj.GroupVersionKind().Kind },
+	"group":     func(obj Object) string { return obj.GroupVersionKind().Group },
+	"url":       func(obj Object) string { return obj.GetURL() },
+}
+
+// queryGraph indexes the nodes and the edges of a topology for the queries
+type queryGraph struct {
+	nodes   map[string]Object
+	urls    []string
+	links   map[string][]string
+	parents map[string][]string
+	targets map[string][]string
+	policy  map[string][]string
+}
+
+func newQueryGraph(topology *Topology) *queryGraph {
+	g := &queryGraph{
+		nodes:   make(map[string]Object),
+		links:   make(map[string][]string),
+		parents: make(map[string][]string),
+		targets: make(map[string][]string),
+		policy:  make(map[string][]string),
+	}
+	for url, obj := range topology.targetables {
+		g.nodes[url] = obj
+	}
+	for url, obj := range topology.policies {
+		g.nodes[url] = obj
+	}
+	for url, obj := range topology.objects {
+		g.nodes[url] = obj
+	}
+	g.urls = lo.Keys(g.nodes)
+	sort.Strings(g.urls)
+	for from, edges := range topology.graph.EdgesMap() {
+		for _, edge := range edges {
+			to := edge.To().ID()
+			if name, _ := edge.GetAttr("comment").(string); name == policyTargetEdgeName {
+				g.targets[from] = append(g.targets[from], to)
+				g.policy[to] = append(g.policy[to], from)
+				continue
+			}
+			g.links[from] = append(g.links[from], to)
+			g.parents[to] = append(g.parents[to], from)
+		}
+	}
+	return g
+}
+
+// match returns the bindings of the variables of a path for each match of the path in the graph, extending the given
+// bindings
+func (g *queryGraph) match(path queryPath, bindings map[string]Object) []map[string]Object {
+	var results []map[string]Object
+	for _, url := range g.urls {
+		if b, ok := g.bind(path.nodes[0], g.nodes[url], bindings); ok {
+			results = append(results, g.extend(path, 0, url, b)...)
+		}
+	}
+	return results
+}
+
+func (g *queryGraph) extend(path queryPath, i int, url string, bindings map[string]Object) []map[string]Object {
+	if i == len(path.relationships) {
+		return []map[string]Object{bindings}
+	}
+	var results []map[string]Object
+	for _, next := range g.neighbours(url, path.relationships[i]) {
+		if b, ok := g.bind(path.nodes[i+1], g.nodes[next], bindings); ok {
+			results = append(results, g.extend(path, i+1, next, b)...)
+		}
+	}
+	return results
+}
+
+// bind returns a copy of the bindings with the variable of a node pattern bound to a node of the graph, or false if the
+// node does not match the pattern or the variable is bound to another node
+func (g *queryGraph) bind(pattern queryNode, obj Object, bindings map[string]Object) (map[string]Object, bool) {
+	if obj == nil || pattern.kind != "" && obj.GroupVersionKind().Kind != pattern.kind {
+		return nil, false
+	}
+	for property, value := range pattern.properties {
+		if queryProperties[property](obj) != value {
+			return nil, false
+		}
+	}
+	if pattern.variable == "" {
+		return bindings, true
+	}
+	if bound, ok := bindings[pattern.variable]; ok {
+		return bindings, bound.GetURL() == obj.GetURL()
+	}
+	b := make(map[string]Object, len(bindings)+1)
+	for k, v := range bindings {
+		b[k] = v
+	}
+	b[pattern.variable] = obj
+	return b, true
+}
+
+// neighbours returns the nodes reachable from a node through a relationship, within its number of hops
+func (g *queryGraph) neighbours(url string, rel queryRelationship) []string {
+	edges := g.links
+	switch {
+	case rel.targets && rel.reverse:
+		edges = g.policy
+	case rel.targets:
+		edges = g.targets
+	case rel.reverse:
+		edges = g.parents
+	}
+	var reached []string
+	visited := map[string]struct{}{url: {}}
+	frontier := []string{url}
+	for hops := 1; len(frontier) > 0 && (rel.maxHops == 0 || hops <= rel.maxHops); hops++ {
+		var next []string
+		for _, from := range frontier {
+			for _, to := range edges[from] {
+				if _, ok := visited[to]; ok {
+					continue
+				}
+				visited[to] = struct{}{}
+				next = append(next, to)
+				if hops >= rel.minHops {
+					reached = append(reached, to)
+				}
+			}
+		}
+		frontier = next
+	}
+	sort.Strings(reached)
+	return reached
+}
+
+func (g *queryGraph) satisfies(condition queryCondition, bindings map[string]Object) bool {
+	var satisfied bool
+	if condition.path != nil {
+		satisfied = len(g.extend(*condition.path, 0, bindings[condition.path.nodes[0].variable].GetURL(), bindings)) > 0
+	} else {
+		value := queryProperties[condition.property](bindings[condition.variable])
+		satisfied = (value == condition.value) == condition.equals
+	}
+	return satisfied != condition.not
+}
+
+type queryToken struct {
+	text   string
+	quoted bool
+	pos    int
+}
+
+func tokenizeQuery(query string) ([]queryToken, error) {
+	var tokens []queryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(runes) && runes[end] != r {
+				end++
+			}
+			if end == len(runes) {
+				return nil, fmt.Errorf("invalid query: unterminated string at position %d", i)
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i+1 : end]), quoted: true, pos: i})
+			i = end + 1
+		case unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_':
+			end := i
+			for end < len(runes) && (unicode.IsLetter(runes[end]) || unicode.IsDigit(runes[end]) || runes[end] == '_') {
+				end++
+			}
+			tokens = append(tokens, queryToken{text: string(runes[i:end]), pos: i})
+			i = end
+		default:
+			text := string(r)
+			if i+1 < len(runes) {
+				if two := string(runes[i : i+2]); lo.Contains([]string{"<>", ".."}, two) {
+					text = two
+				}
+			}
+			if !strings.Contains("()[]{}:,.*=<>-", string(r)) {
+				return nil, fmt.Errorf("invalid query: unexpected character %q at position %d", r, i)
+			}
+			tokens = append(tokens, queryToken{text: text, pos: i})
+			i += len([]rune(text))
+		}
+	}
+	return tokens, nil
+}
+
+type queryParser struct {
+	tokens []queryToken
+	pos    int
+}
+
+func (p *queryParser) peek() (queryToken, bool) {
+	if p.pos >= len(p.tokens) {
+		return queryToken{}, false
+	}
+	return p.tokens[p.pos], true
+}
+
+// accept consumes the next token if it is a given symbol or keyword (case-insensitive)
+func (p *queryParser) accept(text string) bool {
+	if t, ok := p.peek(); ok && !t.quoted && strings.EqualFold(t.text, text) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *queryParser) expect(text string) error {
+	if !p.accept(text) {
+		return p.unexpected(text)
+	}
+	return nil
+}
+
+func (p *queryParser) unexpected(expected string) error {
+	t, ok := p.peek()
+	if !ok {
+		return fmt.Errorf("expected %s at end of query", expected)
+	}
+	return fmt.Errorf("expected %s at position %d, got %q", expected, t.pos, t.text)
+}
+
+func (p *queryParser) identifier() (string, error) {
+	t, ok := p.peek()
+	if !ok || t.quoted || !(unicode.IsLetter([]rune(t.text)[0]) || t.text[0] == '_') {
+		return "", p.unexpected("identifier")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *queryParser) isIdentifier() bool {
+	t, ok := p.peek()
+	return ok && !t.quoted && (unicode.IsLetter([]rune(t.text)[0]) || t.text[0] == '_')
+}
+
+func (p *queryParser) str() (string, error) {
+	t, ok := p.peek()
+	if !ok || !t.quoted {
+		return "", p.unexpected("quoted string")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *queryParser) number() (int, bool) {
+	t, ok := p.peek()
+	if !ok || t.quoted {
+		return 0, false
+	}
+	n, err := strconv.Atoi(t.text)
+	if err != nil {
+		return 0, false
+	}
+	p.pos++
+	return n, true
+}
+
+func (p *queryParser) query() (*Query, error) {
+	if err := p.expect("MATCH"); err != nil {
+		return nil, err
+	}
+	match, err := p.path()
+	if err != nil {
+		return nil, err
+	}
+	q := &Query{match: *match}
+	variables := match.variables()
+
+	if p.accept("WHERE") {
+		for {
+			condition, err := p.condition(variables)
+			if err != nil {
+				return nil, err
+			}
+			q.where = append(q.where, *condition)
+			if !p.accept("AND") {
+				break
+			}
+		}
+	}
+
+	if err := p.expect("RETURN"); err != nil {
+		return nil, err
+	}
+	for {
+		column, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		if !lo.Contains(variables, column) {
+			return nil, fmt.Errorf("undefined variable %s", column)
+		}
+		q.columns = append(q.columns, column)
+		if !p.accept(",") {
+			break
+		}
+	}
+	if _, ok := p.peek(); ok {
+		return nil, p.unexpected("end of query")
+	}
+	return q, nil
+}
+
+func (p *queryParser) condition(variables []string) (*queryCondition, error) {
+	condition := &queryCondition{not: p.accept("NOT")}
+	if t, ok := p.peek(); ok && !t.quoted && t.text == "(" {
+		path, err := p.path()
+		if err != nil {
+			return nil, err
+		}
+		if !lo.Contains(variables, path.nodes[0].variable) {
+			return nil, fmt.Errorf("patterns of the WHERE clause must start at a variable of the MATCH clause")
+		}
+		condition.path = path
+		return condition, nil
+	}
+	variable, err := p.identifier()
+	if err != nil {
+		return nil, err
+	}
+	if !lo.Contains(variables, variable) {
+		return nil, fmt.Errorf("undefined variable %s", variable)
+	}
+	if err := p.expect("."); err != nil {
+		return nil, err
+	}
+	property, err := p.property()
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case p.accept("="):
+		condition.equals = true
+	case p.accept("<>"):
+	default:
+		return nil, p.unexpected("= or <>")
+	}
+	value, err := p.str()
+	if err != nil {
+		return nil, err
+	}
+	condition.variable, condition.property, condition.value = variable, property, value
+	return condition, nil
+}
+
+func (p *queryParser) property() (string, error) {
+	property, err := p.identifier()
+	if err != nil {
+		return "", err
+	}
+	if _, ok := queryProperties[property]; !ok {
+		return "", fmt.Errorf("unknown property %s", property)
+	}
+	return property, nil
+}
+
+func (p *queryParser) path() (*queryPath, error) {
+	node, err := p.node()
+	if err != nil {
+		return nil, err
+	}
+	path := &queryPath{nodes: []queryNode{*node}}
+	for {
+		t, ok := p.peek()
+		if !ok || t.quoted || (t.text != "-" && t.text != "<") {
+			return path, nil
+		}
+		rel, err := p.relationship()
+		if err != nil {
+			return nil, err
+		}
+		node, err := p.node()
+		if err != nil {
+			return nil, err
+		}
+		path.relationships = append(path.relationships, *rel)
+		path.nodes = append(path.nodes, *node)
+	}
+}
+
+func (p *queryParser) node() (*queryNode, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	node := &queryNode{}
+	if p.isIdentifier() {
+		node.variable, _ = p.identifier()
+	}
+	if p.accept(":") {
+		kind, err := p.identifier()
+		if err != nil {
+			return nil, err
+		}
+		node.kind = kind
+	}
+	if p.accept("{") {
+		node.properties = make(map[string]string)
+		for {
+			property, err := p.property()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect(":"); err != nil {
+				return nil, err
+			}
+			value, err := p.str()
+			if err != nil {
+				return nil, err
+			}
+			node.properties[property] = value
+			if !p.accept(",") {
+				break
+			}
+		}
+		if err := p.expect("}"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect(")"); err != nil {
+		return nil, err
+	}
+	return node, nil
+}
+
+// relationship parses -->, <--, -[...]-> or <-[...]-
+func (p *queryParser) relationship() (*queryRelationship, error) {
+	rel := &queryRelationship{minHops: 1, maxHops: 1}
+	rel.reverse = p.accept("<")
+	if err := p.expect("-"); err != nil {
+		return nil, err
+	}
+	if p.accept("[") {
+		if p.accept(":") {
+			relType, err := p.identifier()
+			if err != nil {
+				return nil, err
+			}
+			if !strings.EqualFold(relType, "TARGETS") {
+				return nil, fmt.Errorf("unknown relationship type %s", relType)
+			}
+			rel.targets = true
+		}
+		if p.accept("*") {
+			rel.maxHops = 0
+			if n, ok := p.number(); ok {
+				rel.minHops, rel.maxHops = n, n
+			}
+			if p.accept("..") {
+				rel.maxHops = 0
+				if n, ok := p.number(); ok {
+					rel.maxHops = n
+				}
+			}
+			if rel.minHops < 1 || rel.maxHops != 0 && rel.maxHops < rel.minHops {
+				return nil, fmt.Errorf("invalid number of hops of relationship")
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.expect("-"); err != nil {
+		return nil, err
+	}
+	if !rel.reverse {
+		if err := p.expect(">"); err != nil {
+			return nil, err
+		}
+	}
+	return rel, nil
+}
+
+func (p *queryPath) variables() []string {
+	return lo.Uniq(lo.FilterMap(p.nodes, func(node queryNode, _ int) (string, bool) {
+		return node.variable, node.variable != ""
+	}))
+}
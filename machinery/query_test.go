@@ -0,0 +1,153 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestTopologyQuery(t *testing.T) {
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef.Group = gwapiv1.GroupName
+		policy.Spec.TargetRef.Kind = "HTTPRoute"
+		policy.Spec.TargetRef.Name = "my-http-route"
+	})
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute(), BuildHTTPRoute(func(r *gwapiv1.HTTPRoute) { r.Name = "other-http-route" })),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(policy),
+	)
+
+	gatewayClass := "gatewayclass.gateway.networking.k8s.io:my-gateway-class"
+	gateway := "gateway.gateway.networking.k8s.io:my-namespace/my-gateway"
+	route := "httproute.gateway.networking.k8s.io:my-namespace/my-http-route"
+	otherRoute := "httproute.gateway.networking.k8s.io:my-namespace/other-http-route"
+	service := "service:my-namespace/my-service"
+	policyURL := "testpolicy.test:my-namespace/my-policy"
+
+	testCases := []struct {
+		name            string
+		query           string
+		expectedColumns []string
+		expectedRows    [][]string
+	}{
+		{
+			name:            "nodes of a kind",
+			query:           "MATCH (r:HTTPRoute) RETURN r",
+			expectedColumns: []string{"r"},
+			expectedRows:    [][]string{{route}, {otherRoute}},
+		},
+		{
+			name:         "properties",
+			query:        `match (r:HTTPRoute {name: 'other-http-route', namespace: "my-namespace"}) return r`,
+			expectedRows: [][]string{{otherRoute}},
+		},
+		{
+			name:         "children",
+			query:        `MATCH (g:Gateway {name: "my-gateway"})-->(r) RETURN g, r`,
+			expectedRows: [][]string{{gateway, route}, {gateway, otherRoute}},
+		},
+		{
+			name:         "parents",
+			query:        `MATCH (s:Service)<--(r) RETURN r`,
+			expectedRows: [][]string{{route}, {otherRoute}},
+		},
+		{
+			name:         "descendants",
+			query:        `MATCH (c:GatewayClass)-[*]->(s:Service) RETURN c, s`,
+			expectedRows: [][]string{{gatewayClass, service}},
+		},
+		{
+			name:         "number of hops",
+			query:        `MATCH (c:GatewayClass)-[*2]->(n) RETURN n`,
+			expectedRows: [][]string{{route}, {otherRoute}},
+		},
+		{
+			name:         "range of hops",
+			query:        `MATCH (c:GatewayClass)-[*..2]->(n) RETURN n`,
+			expectedRows: [][]string{{gateway}, {route}, {otherRoute}},
+		},
+		{
+			name:         "policy targets",
+			query:        `MATCH (p:TestPolicy)-[:TARGETS]->(t) RETURN p, t`,
+			expectedRows: [][]string{{policyURL, route}},
+		},
+		{
+			name:         "routes under a gateway without a policy",
+			query:        `MATCH (g:Gateway {name: "my-gateway"})-[*]->(r:HTTPRoute) WHERE NOT (r)<-[:TARGETS]-(:TestPolicy) RETURN r`,
+			expectedRows: [][]string{{otherRoute}},
+		},
+		{
+			name:         "routes under a gateway with a policy",
+			query:        `MATCH (g:Gateway)-[*]->(r:HTTPRoute) WHERE (r)<-[:TARGETS]-(:TestPolicy) AND g.name = "my-gateway" RETURN r`,
+			expectedRows: [][]string{{route}},
+		},
+		{
+			name:         "property comparison",
+			query:        `MATCH (r:HTTPRoute)-->(s) WHERE r.name <> "my-http-route" AND NOT s.kind = "Gateway" RETURN s`,
+			expectedRows: [][]string{{service}},
+		},
+		{
+			name:         "distinct rows",
+			query:        `MATCH (c:GatewayClass)-[*]->(r:HTTPRoute)-->(s:Service) RETURN c, s`,
+			expectedRows: [][]string{{gatewayClass, service}},
+		},
+		{
+			name:         "no match",
+			query:        `MATCH (r:TLSRoute) RETURN r`,
+			expectedRows: nil,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			result, err := topology.Query(tc.query)
+			if err != nil {
+				t.Fatalf("expected no error, got %v", err)
+			}
+			if tc.expectedColumns != nil && !slices.Equal(result.Columns, tc.expectedColumns) {
+				t.Errorf("expected columns %v, got %v", tc.expectedColumns, result.Columns)
+			}
+			rows := result.URLs()
+			if len(rows) != len(tc.expectedRows) {
+				t.Fatalf("expected rows %v, got %v", tc.expectedRows, rows)
+			}
+			for i := range rows {
+				if !slices.Equal(rows[i], tc.expectedRows[i]) {
+					t.Errorf("expected rows %v, got %v", tc.expectedRows, rows)
+				}
+			}
+		})
+	}
+}
+
+func TestParseQueryErrors(t *testing.T) {
+	testCases := []struct {
+		query    string
+		expected string
+	}{
+		{query: "RETURN r", expected: `expected MATCH at position 0, got "RETURN"`},
+		{query: "MATCH (r:HTTPRoute)", expected: "expected RETURN at end of query"},
+		{query: "MATCH (r:HTTPRoute) RETURN s", expected: "undefined variable s"},
+		{query: "MATCH (r {color: 'red'}) RETURN r", expected: "unknown property color"},
+		{query: "MATCH (r)-[:OWNS]->(s) RETURN r", expected: "unknown relationship type OWNS"},
+		{query: "MATCH (r)-[*3..1]->(s) RETURN r", expected: "invalid number of hops of relationship"},
+		{query: "MATCH (r)-(s) RETURN r", expected: `expected - at position 10, got "("`},
+		{query: "MATCH (r) WHERE (:Gateway)-->(r) RETURN r", expected: "patterns of the WHERE clause must start at a variable of the MATCH clause"},
+		{query: "MATCH (r) WHERE r.name = 'a' RETURN r LIMIT", expected: `expected end of query at position 38, got "LIMIT"`},
+		{query: "MATCH (r {name: 'a}) RETURN r", expected: "unterminated string at position 16"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.query, func(t *testing.T) {
+			_, err := ParseQuery(tc.query)
+			if err == nil || !strings.Contains(err.Error(), tc.expected) {
+				t.Errorf("expected error %q, got %v", tc.expected, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,33 @@
+package machinery
+
+import (
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+// Kinds of targetables commonly treated as the roots of the paths of a Gateway API topology, so ingress and mesh use
+// cases can share one topology with different query semantics (see RootsOf, PathQuery.FromKinds,
+// EffectivePoliciesByTargetFrom and RootToLeafPathsFrom).
+var (
+	// IngressRootKinds starts the paths at the GatewayClasses, i.e. the paths of north-south traffic, including the
+	// policies attached to the GatewayClasses.
+	IngressRootKinds = []schema.GroupKind{{Group: gwapiv1.GroupName, Kind: "GatewayClass"}}
+	// GatewayRootKinds starts the paths at the Gateways, ignoring the GatewayClasses.
+	GatewayRootKinds = []schema.GroupKind{{Group: gwapiv1.GroupName, Kind: "Gateway"}}
+	// MeshRootKinds starts the paths at the Services, i.e. the paths of east-west traffic of a service mesh (GAMMA),
+	// where the routes and the policies attach to the Services rather than to Gateways.
+	MeshRootKinds = []schema.GroupKind{{Kind: "Service"}}
+	// NamespaceRootKinds starts the paths at the Namespaces, including the policies attached to the namespaces of the
+	// resources (see WithNamespaces).
+	NamespaceRootKinds = []schema.GroupKind{{Kind: "Namespace"}}
+)
+
+// RootsOf returns the items of the collection of any of the given kinds, to be treated as the roots of the paths of
+// the collection, whether they have parents or not. If no kind is given, it returns the items without parents, like
+// Roots.
+func (c *collection[T]) RootsOf(kinds ...schema.GroupKind) []T {
+	if len(kinds) == 0 {
+		return c.Roots()
+	}
+	return c.Items(IsAnyKind(kinds...))
+}
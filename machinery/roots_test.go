@@ -0,0 +1,86 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestRootKinds(t *testing.T) {
+	policy := buildPolicy(func(policy *TestPolicy) {
+		policy.Spec.TargetRef.Group = gwapiv1.GroupName
+		policy.Spec.TargetRef.Kind = "Gateway"
+		policy.Spec.TargetRef.Name = "my-gateway"
+	})
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(policy),
+	)
+
+	gatewayClass := "gatewayclass.gateway.networking.k8s.io:my-gateway-class"
+	gateway := "gateway.gateway.networking.k8s.io:my-namespace/my-gateway"
+	service := "service:my-namespace/my-service"
+
+	testCases := []struct {
+		name                      string
+		rootKinds                 []schema.GroupKind
+		expectedRoots             []string
+		expectedEffectivePolicies int
+	}{
+		{
+			name:                      "implicit roots",
+			expectedRoots:             []string{gatewayClass},
+			expectedEffectivePolicies: 1,
+		},
+		{
+			name:                      "ingress",
+			rootKinds:                 IngressRootKinds,
+			expectedRoots:             []string{gatewayClass},
+			expectedEffectivePolicies: 1,
+		},
+		{
+			name:                      "gateways",
+			rootKinds:                 GatewayRootKinds,
+			expectedRoots:             []string{gateway},
+			expectedEffectivePolicies: 1,
+		},
+		{
+			name:                      "mesh",
+			rootKinds:                 MeshRootKinds,
+			expectedRoots:             []string{service},
+			expectedEffectivePolicies: 0,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			roots := lo.Map(sortedByURL(topology.Targetables().RootsOf(tc.rootKinds...)), MapTargetableToURLFunc)
+			if !slices.Equal(roots, tc.expectedRoots) {
+				t.Errorf("expected roots %v, got %v", tc.expectedRoots, roots)
+			}
+
+			for _, path := range topology.Targetables().PathQuery().FromKinds(tc.rootKinds...).Paths() {
+				if first := path[0].GetURL(); !lo.Contains(tc.expectedRoots, first) {
+					t.Errorf("expected paths to start at %v, got %s", tc.expectedRoots, first)
+				}
+			}
+			for _, path := range RootToLeafPathsFrom(tc.rootKinds...)(topology) {
+				if first := path[0].GetURL(); !lo.Contains(tc.expectedRoots, first) {
+					t.Errorf("expected root-to-leaf paths to start at %v, got %s", tc.expectedRoots, first)
+				}
+			}
+
+			effectivePolicies := EffectivePoliciesByTargetFrom[*TestPolicy](topology, tc.rootKinds, IsKind(schema.GroupKind{Kind: "Service"}))
+			if len(effectivePolicies[service]) != tc.expectedEffectivePolicies {
+				t.Errorf("expected %d effective policies for %s, got %d", tc.expectedEffectivePolicies, service, len(effectivePolicies[service]))
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+package machinery
+
+import (
+	"errors"
+	"sort"
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+var sectionKinds = struct {
+	sync.RWMutex
+	byKind map[schema.GroupKind][]schema.GroupKind
+}{
+	byKind: map[schema.GroupKind][]schema.GroupKind{
+		{Group: gwapiv1.GroupName, Kind: "Gateway"}: {
+			{Group: gwapiv1.GroupName, Kind: "Listener"},
+			{Group: gwapiv1.GroupName, Kind: "GatewayInfrastructure"},
+			{Group: gwapiv1.GroupName, Kind: "GatewayAddress"},
+		},
+		{Group: gwapiv1.GroupName, Kind: "HTTPRoute"}: {
+			{Group: gwapiv1.GroupName, Kind: "HTTPRouteRule"},
+			{Group: gwapiv1.GroupName, Kind: "HTTPRouteMatch"},
+		},
+		{Group: gwapiv1.GroupName, Kind: "TLSRoute"}: {{Group: gwapiv1.GroupName, Kind: "TLSRouteRule"}},
+		{Group: gwapiv1.GroupName, Kind: "UDPRoute"}: {{Group: gwapiv1.GroupName, Kind: "UDPRouteRule"}},
+		{Kind: "Service"}: {{Kind: "ServicePort"}},
+	},
+}
+
+// RegisterSectionKinds declares the kinds of the sections of a kind of targetable, e.g. Listener for Gateway,
+// replacing any section kinds previously declared for the kind. Registering a kind without section kinds declares
+// that its resources have no sections that can be targeted.
+// The sections of the kinds of targetables of the Gateway API topology are registered by default.
+func RegisterSectionKinds(targetKind schema.GroupKind, kinds ...schema.GroupKind) {
+	sectionKinds.Lock()
+	defer sectionKinds.Unlock()
+	sectionKinds.byKind[targetKind] = kinds
+}
+
+// UnregisterSectionKinds removes the section kinds declared for a kind of targetable.
+func UnregisterSectionKinds(targetKind schema.GroupKind) {
+	sectionKinds.Lock()
+	defer sectionKinds.Unlock()
+	delete(sectionKinds.byKind, targetKind)
+}
+
+// SectionKindsFor returns the section kinds declared for a kind of targetable, if any.
+func SectionKindsFor(targetKind schema.GroupKind) ([]schema.GroupKind, bool) {
+	sectionKinds.RLock()
+	defer sectionKinds.RUnlock()
+	kinds, ok := sectionKinds.byKind[targetKind]
+	return kinds, ok
+}
+
+// SectionOwnerKind returns the kind of targetable a section kind is declared for, e.g. Gateway for Listener.
+func SectionOwnerKind(sectionKind schema.GroupKind) (schema.GroupKind, bool) {
+	sectionKinds.RLock()
+	defer sectionKinds.RUnlock()
+	for targetKind, kinds := range sectionKinds.byKind {
+		if lo.Contains(kinds, sectionKind) {
+			return targetKind, true
+		}
+	}
+	return schema.GroupKind{}, false
+}
+
+// ValidateSectionName checks the section name of a target reference of a policy against the sections of the target
+// in a topology, e.g. by an admission webhook that holds the latest topology, to catch typos in section names.
+// Target references to a section that the target does not have are reported as InvalidSectionNameError, listing the
+// names of the sections of the target. Target references to a whole resource, to kinds of targetables without
+// declared section kinds, and to resources missing from the topology are not checked.
+func ValidateSectionName(topology *Topology, policy Policy, targetRef PolicyTargetReference) error {
+	kinds, ok := SectionKindsFor(targetRef.GroupVersionKind().GroupKind())
+	if !ok {
+		return nil
+	}
+	locator, sectionName, isSection := ParseSectionName(targetRef.GetURL())
+	if !isSection {
+		return nil
+	}
+	if len(kinds) == 0 {
+		return nil // reported by ValidateTargetRef
+	}
+	target, found := topology.targetables[locator]
+	if !found {
+		return nil
+	}
+	if _, found := topology.targetables[topology.locators.resolve(targetRef.GetURL())]; found {
+		return nil
+	}
+	return &InvalidSectionNameError{
+		Policy:        policy,
+		TargetRef:     targetRef,
+		SectionName:   sectionName,
+		ValidSections: sectionNamesOf(topology, target, kinds),
+	}
+}
+
+// sectionNamesOf returns the sorted names of the sections of a target in the topology, among the children of the
+// target of the section kinds, and the children of the section kinds of those, e.g. the matches of the rules of a route
+func sectionNamesOf(topology *Topology, target Targetable, kinds []schema.GroupKind) []string {
+	var names []string
+	sections := []Targetable{target}
+	for len(sections) > 0 {
+		sections = lo.Filter(lo.FlatMap(sections, func(section Targetable, _ int) []Targetable {
+			return topology.Targetables().Children(section)
+		}), func(child Targetable, _ int) bool {
+			return lo.Contains(kinds, child.GroupVersionKind().GroupKind())
+		})
+		names = append(names, lo.FilterMap(sections, func(section Targetable, _ int) (string, bool) {
+			_, sectionName, isSection := topology.SectionNameSeparator().ParseSectionName(section.GetURL())
+			return string(sectionName), isSection
+		})...)
+	}
+	sort.Strings(names)
+	return lo.Uniq(names)
+}
+
+// ValidateSectionNames checks the section names of the target references of a policy against the sections of the
+// targets in a topology. See ValidateSectionName.
+func ValidateSectionNames(topology *Topology, policy Policy) error {
+	var errs []error
+	for _, targetRef := range policy.GetTargetRefs() {
+		if err := ValidateSectionName(topology, policy, targetRef); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
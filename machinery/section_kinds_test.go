@@ -0,0 +1,89 @@
+//go:build unit
+
+package machinery
+
+import (
+	"errors"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestSectionKinds(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	if kinds, ok := SectionKindsFor(gatewayKind); !ok || len(kinds) != 3 || kinds[0].Kind != "Listener" || kinds[1].Kind != "GatewayInfrastructure" || kinds[2].Kind != "GatewayAddress" {
+		t.Errorf("expected the Listener, GatewayInfrastructure and GatewayAddress section kinds registered for Gateway by default, got %v", kinds)
+	}
+
+	sectionPolicy := func(name string, sectionName gwapiv1.SectionName) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+					Group: gwapiv1.GroupName,
+					Kind:  "Gateway",
+					Name:  "my-gateway",
+				},
+				SectionName: ptr.To(sectionName),
+			}
+		})
+	}
+	validPolicy := sectionPolicy("valid-policy", "my-listener")
+	typoPolicy := sectionPolicy("typo-policy", "my-listner")
+
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway(func(g *gwapiv1.Gateway) {
+			g.Spec.Listeners = append(g.Spec.Listeners, gwapiv1.Listener{Name: "another-listener", Protocol: gwapiv1.HTTPProtocolType, Port: 8080})
+		})),
+		ExpandGatewayListeners(),
+		WithGatewayAPITopologyPolicies(validPolicy, typoPolicy),
+	)
+
+	if err := ValidateSectionNames(topology, validPolicy); err != nil {
+		t.Errorf("expected the section name to be valid, got %v", err)
+	}
+	err := ValidateSectionNames(topology, typoPolicy)
+	var invalid *InvalidSectionNameError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("expected an invalid section name error, got %v", err)
+	}
+	if invalid.SectionName != "my-listner" || len(invalid.ValidSections) != 2 || invalid.ValidSections[0] != "another-listener" || invalid.ValidSections[1] != "my-listener" {
+		t.Errorf("unexpected invalid section name error: %+v", invalid)
+	}
+	if err.Error() != `invalid section name "my-listner": gateway.gateway.networking.k8s.io:my-namespace/my-gateway#my-listner (policy testpolicy.test:my-namespace/typo-policy): valid sections are [another-listener, my-listener]` {
+		t.Errorf("unexpected error message: %v", err)
+	}
+	if _, err := topology.Targets(typoPolicy); !errors.Is(err, ErrInvalidSectionName) || !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected an invalid section name error resolving the targets of the policy, got %v", err)
+	}
+
+	// kinds without sections
+	RegisterSectionKinds(gatewayKind)
+	defer RegisterSectionKinds(gatewayKind, schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"})
+	if err := ValidateTargetRefs(validPolicy); !errors.Is(err, ErrUnsupportedTarget) {
+		t.Errorf("expected an unsupported target error for a kind without sections, got %v", err)
+	}
+
+	// kinds without declared section kinds
+	UnregisterSectionKinds(gatewayKind)
+	if err := ValidateTargetRefs(typoPolicy); err != nil {
+		t.Errorf("expected no error without declared section kinds, got %v", err)
+	}
+	if err := ValidateSectionNames(topology, typoPolicy); err != nil {
+		t.Errorf("expected no error without declared section kinds, got %v", err)
+	}
+}
+
+func TestSectionOwnerKind(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	if kind, ok := SectionOwnerKind(schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Listener"}); !ok || kind != gatewayKind {
+		t.Errorf("expected Gateway to declare the Listener section kind, got %v", kind)
+	}
+	if _, ok := SectionOwnerKind(gatewayKind); ok {
+		t.Errorf("expected Gateway not to be a section kind")
+	}
+}
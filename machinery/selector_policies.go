@@ -0,0 +1,132 @@
+package machinery
+
+import (
+	"sort"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+// PolicySelector selects the targetables of a policy by labels, in the fashion of the podSelector and
+// namespaceSelector of a Kubernetes NetworkPolicy, rather than by target references.
+type PolicySelector struct {
+	// Kinds are the kinds of targetables selected. Empty means Services.
+	Kinds []schema.GroupKind
+	// Selector matches the labels of the targetables. Nil selects nothing.
+	Selector labels.Selector
+	// NamespaceSelector matches the labels of the namespaces of the targetables (see WithNamespaceLabels). Nil means
+	// the namespace of the policy only.
+	NamespaceSelector labels.Selector
+}
+
+// SelectorPolicy is a policy whose targets are selected by label selectors.
+// The topology resolves the selectors of the policy to the matching targetables every time it is built, and sets the
+// references to the selected targetables in the policy, so they are returned by GetTargetRefs and the policy is
+// attached to the selected targetables like any other policy. Embed SelectedTargetRefs to implement
+// SetSelectedTargetRefs.
+type SelectorPolicy interface {
+	Policy
+
+	GetSelectors() []PolicySelector
+	SetSelectedTargetRefs([]PolicyTargetReference)
+}
+
+// SelectedTargetRefs holds the references to the targetables selected for a selector-based policy.
+// It implements the GetTargetRefs and SetSelectedTargetRefs functions of the SelectorPolicy interface.
+type SelectedTargetRefs struct {
+	refs []PolicyTargetReference
+}
+
+func (s *SelectedTargetRefs) GetTargetRefs() []PolicyTargetReference {
+	return s.refs
+}
+
+func (s *SelectedTargetRefs) SetSelectedTargetRefs(refs []PolicyTargetReference) {
+	s.refs = refs
+}
+
+// WithNamespaceLabels adds the labels of the namespaces to the options to initialize a new topology, to resolve the
+// namespace selectors of selector-based policies. Namespaces without labels match only empty selectors.
+func WithNamespaceLabels(namespaceLabels map[string]map[string]string) TopologyOptionsFunc {
+	return func(o *TopologyOptions) {
+		if o.NamespaceLabels == nil {
+			o.NamespaceLabels = make(map[string]map[string]string, len(namespaceLabels))
+		}
+		for namespace, l := range namespaceLabels {
+			o.NamespaceLabels[namespace] = l
+		}
+	}
+}
+
+// WithGatewayAPITopologyNamespaceLabels adds the labels of the namespaces to the options to initialize a new Gateway
+// API topology, to resolve the namespace selectors of selector-based policies.
+func WithGatewayAPITopologyNamespaceLabels(namespaceLabels map[string]map[string]string) GatewayAPITopologyOptionsFunc {
+	return func(o *GatewayAPITopologyOptions) {
+		if o.NamespaceLabels == nil {
+			o.NamespaceLabels = make(map[string]map[string]string, len(namespaceLabels))
+		}
+		for namespace, l := range namespaceLabels {
+			o.NamespaceLabels[namespace] = l
+		}
+	}
+}
+
+// SelectTargetables returns the targetables selected by a selector of a policy, sorted by URL.
+func SelectTargetables(policy Policy, selector PolicySelector, targetables []Targetable, namespaceLabels map[string]map[string]string) []Targetable {
+	if selector.Selector == nil {
+		return nil
+	}
+	kinds := selector.Kinds
+	if len(kinds) == 0 {
+		kinds = []schema.GroupKind{{Group: "", Kind: "Service"}}
+	}
+	selected := lo.Filter(targetables, func(t Targetable, _ int) bool {
+		if !lo.Contains(kinds, t.GroupVersionKind().GroupKind()) {
+			return false
+		}
+		if selector.NamespaceSelector == nil {
+			if t.GetNamespace() != policy.GetNamespace() {
+				return false
+			}
+		} else if !selector.NamespaceSelector.Matches(labels.Set(namespaceLabels[t.GetNamespace()])) {
+			return false
+		}
+		return selector.Selector.Matches(labels.Set(targetableLabels(t)))
+	})
+	sort.Slice(selected, func(i, j int) bool { return selected[i].GetURL() < selected[j].GetURL() })
+	return selected
+}
+
+// resolvePolicySelectors sets the references to the selected targetables in the selector-based policies.
+func resolvePolicySelectors(policies []Policy, targetables []Targetable, namespaceLabels map[string]map[string]string) {
+	for _, policy := range policies {
+		selectorPolicy, ok := policy.(SelectorPolicy)
+		if !ok {
+			continue
+		}
+		selected := lo.UniqBy(lo.FlatMap(selectorPolicy.GetSelectors(), func(selector PolicySelector, _ int) []Targetable {
+			return SelectTargetables(policy, selector, targetables, namespaceLabels)
+		}), func(t Targetable) string { return t.GetURL() })
+		sort.Slice(selected, func(i, j int) bool { return selected[i].GetURL() < selected[j].GetURL() })
+		selectorPolicy.SetSelectedTargetRefs(lo.Map(selected, func(t Targetable, _ int) PolicyTargetReference {
+			gk := t.GroupVersionKind().GroupKind()
+			return NamespacedPolicyTargetReference{
+				NamespacedPolicyTargetReference: gwapiv1alpha2.NamespacedPolicyTargetReference{
+					Group: gwapiv1alpha2.Group(gk.Group),
+					Kind:  gwapiv1alpha2.Kind(gk.Kind),
+					Name:  gwapiv1alpha2.ObjectName(t.GetName()),
+				},
+				PolicyNamespace: t.GetNamespace(),
+			}
+		}))
+	}
+}
+
+func targetableLabels(t Targetable) map[string]string {
+	if o, ok := t.(interface{ GetLabels() map[string]string }); ok {
+		return o.GetLabels()
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+//go:build unit
+
+package machinery
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/samber/lo"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+type selectorTestPolicy struct {
+	*TestPolicy
+	SelectedTargetRefs
+
+	selectors []PolicySelector
+}
+
+var _ SelectorPolicy = &selectorTestPolicy{}
+
+func (p *selectorTestPolicy) GetTargetRefs() []PolicyTargetReference {
+	return p.SelectedTargetRefs.GetTargetRefs()
+}
+
+func (p *selectorTestPolicy) GetSelectors() []PolicySelector {
+	return p.selectors
+}
+
+func TestSelectorPolicies(t *testing.T) {
+	service := func(namespace, name string, l map[string]string) *core.Service {
+		return BuildService(func(s *core.Service) {
+			s.Namespace = namespace
+			s.Name = name
+			s.Labels = l
+		})
+	}
+	services := []*core.Service{
+		service("my-namespace", "frontend", map[string]string{"tier": "frontend"}),
+		service("my-namespace", "backend", map[string]string{"tier": "backend"}),
+		service("other-namespace", "frontend", map[string]string{"tier": "frontend"}),
+		service("prod-namespace", "frontend", map[string]string{"tier": "frontend"}),
+	}
+	namespaceLabels := map[string]map[string]string{
+		"prod-namespace": {"env": "prod"},
+	}
+	selectorPolicy := func(selectors ...PolicySelector) *selectorTestPolicy {
+		return &selectorTestPolicy{
+			TestPolicy: buildPolicy(func(p *TestPolicy) { p.Name = "my-selector-policy" }),
+			selectors:  selectors,
+		}
+	}
+	frontend := labels.SelectorFromSet(labels.Set{"tier": "frontend"})
+
+	testCases := []struct {
+		name     string
+		policy   *selectorTestPolicy
+		expected []string
+	}{
+		{
+			name:     "namespace of the policy",
+			policy:   selectorPolicy(PolicySelector{Selector: frontend}),
+			expected: []string{"service:my-namespace/frontend"},
+		},
+		{
+			name:     "namespace selector",
+			policy:   selectorPolicy(PolicySelector{Selector: frontend, NamespaceSelector: labels.SelectorFromSet(labels.Set{"env": "prod"})}),
+			expected: []string{"service:prod-namespace/frontend"},
+		},
+		{
+			name:     "all namespaces",
+			policy:   selectorPolicy(PolicySelector{Selector: frontend, NamespaceSelector: labels.Everything()}),
+			expected: []string{"service:my-namespace/frontend", "service:other-namespace/frontend", "service:prod-namespace/frontend"},
+		},
+		{
+			name:     "multiple selectors",
+			policy:   selectorPolicy(PolicySelector{Selector: frontend}, PolicySelector{Selector: labels.Everything()}),
+			expected: []string{"service:my-namespace/backend", "service:my-namespace/frontend"},
+		},
+		{
+			name:   "nil selector",
+			policy: selectorPolicy(PolicySelector{}),
+		},
+		{
+			name:   "other kinds",
+			policy: selectorPolicy(PolicySelector{Selector: labels.Everything(), Kinds: []schema.GroupKind{{Group: gwapiv1.GroupName, Kind: "Gateway"}}}),
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			topology := NewGatewayAPITopology(
+				WithServices(services...),
+				WithGatewayAPITopologyPolicies(tc.policy),
+				WithGatewayAPITopologyNamespaceLabels(namespaceLabels),
+			)
+			targets := lo.Map(tc.policy.GetTargetRefs(), func(ref PolicyTargetReference, _ int) string { return ref.GetURL() })
+			if !reflect.DeepEqual(targets, tc.expected) && (len(targets) > 0 || len(tc.expected) > 0) {
+				t.Errorf("expected targets %v, got %v", tc.expected, targets)
+			}
+			attached := lo.FilterMap(topology.Targetables().Items(), func(targetable Targetable, _ int) (string, bool) {
+				return targetable.GetURL(), len(targetable.Policies()) > 0
+			})
+			if len(attached) != len(tc.expected) {
+				t.Errorf("expected policy attached to %v, got %v", tc.expected, attached)
+			}
+		})
+	}
+}
+
+func TestSelectorPoliciesRelabeling(t *testing.T) {
+	svc := BuildService()
+	policy := &selectorTestPolicy{
+		TestPolicy: buildPolicy(),
+		selectors:  []PolicySelector{{Selector: labels.SelectorFromSet(labels.Set{"tier": "frontend"})}},
+	}
+
+	topology := NewGatewayAPITopology(WithServices(svc), WithGatewayAPITopologyPolicies(policy))
+	if len(topology.Targetables().Items()[0].Policies()) != 0 {
+		t.Fatal("expected no policy attached to the unlabeled service")
+	}
+
+	relabeled := svc.DeepCopy()
+	relabeled.ObjectMeta = metav1.ObjectMeta{Name: svc.Name, Namespace: svc.Namespace, Labels: map[string]string{"tier": "frontend"}}
+	topology = NewGatewayAPITopology(WithServices(relabeled), WithGatewayAPITopologyPolicies(policy))
+	if len(topology.Targetables().Items()[0].Policies()) != 1 {
+		t.Fatal("expected the policy attached to the relabeled service")
+	}
+}
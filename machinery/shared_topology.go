@@ -0,0 +1,34 @@
+package machinery
+
+import "sync"
+
+// SharedTopology is a handle to the latest topology built, that can be read concurrently while a new topology is
+// built, e.g. by the reconcilers of a parallel workflow while the controller rebuilds the topology.
+//
+// A topology is never modified after it is returned by NewTopology, thus the handle has copy-on-write semantics:
+// builders build a whole new topology and swap it in with Store, while readers keep the topology returned by Load,
+// which is consistent for as long as they hold it. Readers never observe a partially built graph.
+//
+// Note that NewTopology sets the policies attached to the targetables given as options, thus the targetables and
+// policies of a topology being read must not be reused to build a new one. Topology.Snapshot is the serializable form
+// of a topology, rather than a handle to it.
+//
+// The zero value is ready to use and holds no topology.
+type SharedTopology struct {
+	mu       sync.RWMutex
+	topology *Topology
+}
+
+// Load returns the latest topology stored in the handle, or nil if none was stored yet.
+func (s *SharedTopology) Load() *Topology {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.topology
+}
+
+// Store replaces the topology of the handle with a new, fully built topology.
+func (s *SharedTopology) Store(topology *Topology) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.topology = topology
+}
@@ -0,0 +1,55 @@
+//go:build unit
+
+package machinery
+
+import (
+	"sync"
+	"testing"
+)
+
+func TestSharedTopology(t *testing.T) {
+	var shared SharedTopology
+	if topology := shared.Load(); topology != nil {
+		t.Fatalf("expected no topology before the first store, got %v", topology)
+	}
+
+	build := func() *Topology {
+		return NewGatewayAPITopology(
+			WithGatewayClasses(BuildGatewayClass()),
+			WithGateways(BuildGateway()),
+			WithHTTPRoutes(BuildHTTPRoute()),
+			WithServices(BuildService()),
+			WithGatewayAPITopologyPolicies(buildPolicy()),
+		)
+	}
+	shared.Store(build())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				topology := shared.Load()
+				targetables := topology.Targetables()
+				roots := targetables.Roots()
+				if len(roots) != 1 {
+					t.Errorf("expected 1 root, got %d", len(roots))
+					return
+				}
+				if items := targetables.Items(); len(items) != 4 {
+					t.Errorf("expected 4 targetables, got %d", len(items))
+					return
+				}
+				if children := targetables.Children(roots[0]); len(children) != 1 {
+					t.Errorf("expected 1 child of the root, got %d", len(children))
+					return
+				}
+			}
+		}()
+	}
+	for j := 0; j < 20; j++ {
+		shared.Store(build())
+	}
+	wg.Wait()
+}
@@ -0,0 +1,137 @@
+package machinery
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/samber/lo"
+)
+
+// TargetRefReason is the reason why a target reference of a policy does not resolve to a targetable of a topology.
+type TargetRefReason string
+
+const (
+	// TargetRefReasonUnsupported is the reason of target references not supported by the kind of policy or by the
+	// kind of target (see ValidateTargetRef).
+	TargetRefReasonUnsupported TargetRefReason = "Unsupported"
+	// TargetRefReasonMissingKind is the reason of target references to a kind of targetable of which the topology has
+	// no node at all, e.g. because the kind is not watched or not linked into the topology.
+	TargetRefReasonMissingKind TargetRefReason = "MissingKind"
+	// TargetRefReasonWrongNamespace is the reason of target references to a resource that only exists in other
+	// namespaces than the one referred to, e.g. the namespace of the policy for local references.
+	TargetRefReasonWrongNamespace TargetRefReason = "WrongNamespace"
+	// TargetRefReasonNoSuchSectionName is the reason of target references to a section that the target does not have.
+	TargetRefReasonNoSuchSectionName TargetRefReason = "NoSuchSectionName"
+	// TargetRefReasonNotFound is the reason of target references to a resource that does not exist in the topology,
+	// for any other reason than the ones above.
+	TargetRefReasonNotFound TargetRefReason = "NotFound"
+)
+
+// TargetRefDiagnostic is the result of the resolution of a target reference of a policy to a targetable of a topology.
+// Target is the targetable the target reference resolves to, or nil if the target reference dangles, in which case
+// Reason, Message and Err tell why.
+type TargetRefDiagnostic struct {
+	TargetRef PolicyTargetReference
+	Target    Targetable
+	Reason    TargetRefReason
+	Message   string
+	// Err is the error of the resolution: an UnsupportedTargetError, an InvalidSectionNameError or a
+	// TargetNotFoundError
+	Err error
+}
+
+// Resolved tells whether the target reference resolves to a targetable of the topology.
+func (d TargetRefDiagnostic) Resolved() bool {
+	return d.Target != nil
+}
+
+// TargetRefDiagnostics are the results of the resolution of the target references of a policy, in the order of the
+// target references.
+type TargetRefDiagnostics []TargetRefDiagnostic
+
+// Resolved returns the diagnostics of the target references that resolve to a targetable of the topology.
+func (d TargetRefDiagnostics) Resolved() TargetRefDiagnostics {
+	return lo.Filter(d, func(diagnostic TargetRefDiagnostic, _ int) bool { return diagnostic.Resolved() })
+}
+
+// Dangling returns the diagnostics of the target references that do not resolve to a targetable of the topology.
+func (d TargetRefDiagnostics) Dangling() TargetRefDiagnostics {
+	return lo.Filter(d, func(diagnostic TargetRefDiagnostic, _ int) bool { return !diagnostic.Resolved() })
+}
+
+// Targets returns the targetables the target references resolve to.
+func (d TargetRefDiagnostics) Targets() []Targetable {
+	return lo.FilterMap(d, func(diagnostic TargetRefDiagnostic, _ int) (Targetable, bool) {
+		return diagnostic.Target, diagnostic.Resolved()
+	})
+}
+
+// Err returns the errors of the target references that dangle, joined.
+func (d TargetRefDiagnostics) Err() error {
+	return errors.Join(lo.Map(d.Dangling(), func(diagnostic TargetRefDiagnostic, _ int) error { return diagnostic.Err })...)
+}
+
+// DiagnoseTargetRefs reports which target references of a policy resolve to targetables of the topology and which
+// dangle, with the reasons, e.g. so controllers can set precise TargetNotFound conditions on the policies.
+func (t *Topology) DiagnoseTargetRefs(policy Policy) TargetRefDiagnostics {
+	return lo.Map(policy.GetTargetRefs(), func(targetRef PolicyTargetReference, _ int) TargetRefDiagnostic {
+		return t.DiagnoseTargetRef(policy, targetRef)
+	})
+}
+
+// DiagnoseTargetRef reports whether a target reference of a policy resolves to a targetable of the topology, or why it
+// dangles. See DiagnoseTargetRefs.
+func (t *Topology) DiagnoseTargetRef(policy Policy, targetRef PolicyTargetReference) TargetRefDiagnostic {
+	diagnostic := TargetRefDiagnostic{TargetRef: targetRef}
+
+	var unsupported *UnsupportedTargetError
+	if err := ValidateTargetRef(policy, targetRef); errors.As(err, &unsupported) {
+		diagnostic.Reason = TargetRefReasonUnsupported
+		diagnostic.Message = unsupported.Reason
+		diagnostic.Err = err
+		return diagnostic
+	}
+
+	if target, found := t.targetables[t.locators.resolve(targetRef.GetURL())]; found {
+		diagnostic.Target = target
+		return diagnostic
+	}
+
+	var invalidSection *InvalidSectionNameError
+	if err := ValidateSectionName(t, policy, targetRef); errors.As(err, &invalidSection) {
+		diagnostic.Reason = TargetRefReasonNoSuchSectionName
+		diagnostic.Message = fmt.Sprintf("section %q not found, valid sections are [%s]", invalidSection.SectionName, strings.Join(invalidSection.ValidSections, ", "))
+		diagnostic.Err = err
+		return diagnostic
+	}
+
+	diagnostic.Err = &TargetNotFoundError{Policy: policy, TargetRef: targetRef}
+	kind := targetRef.GroupVersionKind().GroupKind()
+	name, sectionName, isSection := ParseSectionName(targetRef.GetName())
+	sameKind := t.Targetables().Items(IsKind(kind))
+	sameName := lo.Filter(sameKind, func(target Targetable, _ int) bool { return target.GetName() == name })
+
+	switch {
+	case len(sameKind) == 0:
+		diagnostic.Reason = TargetRefReasonMissingKind
+		diagnostic.Message = fmt.Sprintf("no %s in the topology", kind.String())
+	case len(sameName) > 0 && lo.NoneBy(sameName, func(target Targetable) bool { return target.GetNamespace() == targetRef.GetNamespace() }):
+		namespaces := lo.Uniq(lo.Map(sameName, func(target Targetable, _ int) string { return target.GetNamespace() }))
+		sort.Strings(namespaces)
+		diagnostic.Reason = TargetRefReasonWrongNamespace
+		diagnostic.Message = fmt.Sprintf("%s %s not found in namespace %q, only in [%s]", kind.String(), name, targetRef.GetNamespace(), strings.Join(namespaces, ", "))
+	case isSection && len(sameName) > 0:
+		// the kind of target has no declared section kinds, thus the sections of the target are unknown
+		diagnostic.Reason = TargetRefReasonNoSuchSectionName
+		diagnostic.Message = fmt.Sprintf("section %q not found", sectionName)
+	default:
+		diagnostic.Reason = TargetRefReasonNotFound
+		if namespace := targetRef.GetNamespace(); namespace != "" {
+			name = namespacedName(namespace, name)
+		}
+		diagnostic.Message = fmt.Sprintf("%s %s not found", kind.String(), name)
+	}
+	return diagnostic
+}
@@ -0,0 +1,114 @@
+//go:build unit
+
+package machinery
+
+import (
+	"errors"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/utils/ptr"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestDiagnoseTargetRefs(t *testing.T) {
+	gatewayClassKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "GatewayClass"}
+	RegisterSectionKinds(gatewayClassKind)
+	defer UnregisterSectionKinds(gatewayClassKind)
+
+	policyTargeting := func(name string, group gwapiv1.Group, kind gwapiv1.Kind, targetName gwapiv1.ObjectName, sectionName *gwapiv1.SectionName) *TestPolicy {
+		return buildPolicy(func(policy *TestPolicy) {
+			policy.Name = name
+			policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+				LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{Group: group, Kind: kind, Name: targetName},
+				SectionName:                sectionName,
+			}
+		})
+	}
+
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithServices(BuildService(), BuildService(func(s *core.Service) {
+			s.Name = "other-service"
+			s.Namespace = "other-namespace"
+		})),
+	)
+
+	testCases := []struct {
+		name            string
+		policy          *TestPolicy
+		expectedReason  TargetRefReason
+		expectedMessage string
+		expectedErr     error
+	}{
+		{
+			name:   "resolved",
+			policy: policyTargeting("resolved", "", "Service", "my-service", nil),
+		},
+		{
+			name:            "unsupported",
+			policy:          policyTargeting("unsupported", gwapiv1.GroupName, "GatewayClass", "my-gateway-class", ptr.To(gwapiv1.SectionName("section"))),
+			expectedReason:  TargetRefReasonUnsupported,
+			expectedMessage: "GatewayClass.gateway.networking.k8s.io has no sections",
+			expectedErr:     ErrUnsupportedTarget,
+		},
+		{
+			name:            "missing kind",
+			policy:          policyTargeting("missing-kind", gwapiv1alpha2.GroupName, "TLSRoute", "my-tls-route", nil),
+			expectedReason:  TargetRefReasonMissingKind,
+			expectedMessage: "no TLSRoute.gateway.networking.k8s.io in the topology",
+			expectedErr:     ErrTargetNotFound,
+		},
+		{
+			name:            "wrong namespace",
+			policy:          policyTargeting("wrong-namespace", "", "Service", "other-service", nil),
+			expectedReason:  TargetRefReasonWrongNamespace,
+			expectedMessage: `Service other-service not found in namespace "my-namespace", only in [other-namespace]`,
+			expectedErr:     ErrTargetNotFound,
+		},
+		{
+			name:            "no such section name",
+			policy:          policyTargeting("no-such-section", gwapiv1.GroupName, "Gateway", "my-gateway", ptr.To(gwapiv1.SectionName("my-listner"))),
+			expectedReason:  TargetRefReasonNoSuchSectionName,
+			expectedMessage: `section "my-listner" not found, valid sections are [my-listener]`,
+			expectedErr:     ErrInvalidSectionName,
+		},
+		{
+			name:            "not found",
+			policy:          policyTargeting("not-found", gwapiv1.GroupName, "HTTPRoute", "missing-route", nil),
+			expectedReason:  TargetRefReasonNotFound,
+			expectedMessage: "HTTPRoute.gateway.networking.k8s.io my-namespace/missing-route not found",
+			expectedErr:     ErrTargetNotFound,
+		},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			diagnostics := topology.DiagnoseTargetRefs(tc.policy)
+			if len(diagnostics) != 1 {
+				t.Fatalf("expected 1 diagnostic, got %d", len(diagnostics))
+			}
+			diagnostic := diagnostics[0]
+			if resolved := tc.expectedReason == ""; diagnostic.Resolved() != resolved || len(diagnostics.Resolved()) != len(diagnostics.Targets()) {
+				t.Errorf("expected resolved=%t, got %+v", resolved, diagnostic)
+			}
+			if diagnostic.Reason != tc.expectedReason {
+				t.Errorf("expected reason %q, got %q", tc.expectedReason, diagnostic.Reason)
+			}
+			if diagnostic.Message != tc.expectedMessage {
+				t.Errorf("expected message %q, got %q", tc.expectedMessage, diagnostic.Message)
+			}
+			if err := diagnostics.Err(); (tc.expectedErr == nil) != (err == nil) || !errors.Is(err, tc.expectedErr) {
+				t.Errorf("expected error %v, got %v", tc.expectedErr, err)
+			}
+			if targets, err := topology.Targets(tc.policy); len(targets) != len(diagnostics.Targets()) || err != nil && err.Error() != diagnostics.Err().Error() {
+				t.Errorf("expected the targets of the policy consistent with the diagnostics, got %v, %v", targets, err)
+			}
+		})
+	}
+}
@@ -2,25 +2,42 @@ package machinery
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 
 	"github.com/emicklei/dot"
 	"github.com/samber/lo"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/policy-machinery/machinery/api"
 )
 
+const policyTargetEdgeName = "Policy -> Target"
+
 type TopologyOptions struct {
 	Targetables []Targetable
 	Policies    []Policy
 	Objects     []Object
 	Links       []LinkFunc
+	Config      Object
+
+	NamespaceLabels      map[string]map[string]string
+	PruneKinds           []schema.GroupKind
+	SectionNameSeparator SectionNameSeparator
 }
 
 type LinkFunc struct {
 	From schema.GroupKind
 	To   schema.GroupKind
 	Func func(child Object) (parents []Object)
+	// Name optionally names the edges established by the function. Defaults to "<From kind> -> <To kind>".
+	Name string
+	// Relationship is the kind of relationship between the parents and the children linked by the function.
+	Relationship EdgeRelationship
+	// Attributes optionally returns the metadata of the edge between a parent and a child linked by the function, e.g.
+	// the weight of a backend. See Topology.EdgeAttributes.
+	Attributes func(parent, child Object) map[string]string
 }
 
 type TopologyOptionsFunc func(*TopologyOptions)
@@ -61,41 +78,103 @@ func WithLinks(links ...LinkFunc) TopologyOptionsFunc {
 	}
 }
 
+// WithConfig adds a singleton configuration object to the options to initialize a new topology.
+// The config object is added to the topology as a generic object, linked to all nodes of the kinds it configures.
+// Reconcilers can read it with the Config method of the topology.
+func WithConfig(config Object, configures ...schema.GroupKind) TopologyOptionsFunc {
+	return func(o *TopologyOptions) {
+		if config == nil {
+			return
+		}
+		o.Config = config
+		o.Objects = append(o.Objects, config)
+		o.Links = append(o.Links, lo.Map(configures, func(kind schema.GroupKind, _ int) LinkFunc {
+			return LinkConfigFunc(config, kind)
+		})...)
+	}
+}
+
+// WithEmptyNodesPruned adds kinds of targetables to prune from a new topology when they are empty, i.e. nodes with no
+// attached policies, no children and no parents other than the one they are linked from, such as the sections of an
+// object (see PruneEmptyExpansions). Links from the config object of the topology are not taken into account.
+func WithEmptyNodesPruned(kinds ...schema.GroupKind) TopologyOptionsFunc {
+	return func(o *TopologyOptions) {
+		o.PruneKinds = append(o.PruneKinds, kinds...)
+	}
+}
+
+// WithSectionNameSeparator sets the separator between the names of resources and the names of their sections in the
+// locators of a new topology, e.g. '~' to avoid clashes with the fragments of URLs when locators are used in HTTP
+// endpoints. Invalid separators (see SectionNameSeparator.Validate) are ignored.
+// Locators with the default separator, such as the ones of the target references of the policies, keep resolving.
+func WithSectionNameSeparator(separator SectionNameSeparator) TopologyOptionsFunc {
+	return func(o *TopologyOptions) {
+		o.SectionNameSeparator = separator
+	}
+}
+
+// LinkConfigFunc returns a link function that links a singleton configuration object to all nodes of a given kind.
+func LinkConfigFunc(config Object, kind schema.GroupKind) LinkFunc {
+	return LinkFunc{
+		From: config.GroupVersionKind().GroupKind(),
+		To:   kind,
+		Func: func(_ Object) []Object {
+			return []Object{config}
+		},
+	}
+}
+
 // NewTopology returns a network of targetable resources, attached policies, and other kinds of objects.
 // The topology is represented as a directed acyclic graph (DAG) with the structure given by link functions.
 // The links between policies to targteables are inferred from the policies' target references.
 // The targetables, policies, objects and link functions are provided as options.
+//
+// NewTopology and its options WithTargetables, WithPolicies, WithObjects and WithLinks are the stable, domain-agnostic
+// API to build topologies of any kind of objects, e.g. DNS records or certificate chains, with no dependency on the
+// Gateway API other than the shapes of the target references of the policies. Topologies of Gateway API resources are
+// one preset of it (see NewGatewayAPITopology), that can be composed with the targetables, objects and links of other
+// domains in the same topology with WithGatewayAPI.
 func NewTopology(options ...TopologyOptionsFunc) *Topology {
 	o := &TopologyOptions{}
 	for _, f := range options {
 		f(o)
 	}
 
+	separator := o.SectionNameSeparator.orDefault()
+	for _, t := range o.Targetables {
+		if section, ok := t.(sectionObject); ok {
+			section.setSectionNameSeparator(separator)
+		}
+	}
+
 	policies := o.Policies
+	resolvePolicySelectors(policies, o.Targetables, o.NamespaceLabels)
+	locators := topologyLocators{separator: separator, aliases: targetableAliases(o.Targetables)}
 	policiesByTargetRef := make(map[string][]Policy)
 	for i := range policies {
 		policy := policies[i]
 		for _, targetRef := range policy.GetTargetRefs() {
-			if policiesByTargetRef[targetRef.GetURL()] == nil {
-				policiesByTargetRef[targetRef.GetURL()] = make([]Policy, 0)
+			if ValidateTargetRef(policy, targetRef) != nil {
+				continue // unsupported targets are reported by Targets
 			}
-			policiesByTargetRef[targetRef.GetURL()] = append(policiesByTargetRef[targetRef.GetURL()], policy)
+			url := locators.resolve(targetRef.GetURL())
+			if policiesByTargetRef[url] == nil {
+				policiesByTargetRef[url] = make([]Policy, 0)
+			}
+			policiesByTargetRef[url] = append(policiesByTargetRef[url], policy)
 		}
 	}
 
 	targetables := lo.Map(o.Targetables, func(t Targetable, _ int) Targetable {
-		t.SetPolicies(policiesByTargetRef[t.GetURL()])
+		// a policy can target a targetable by both its url and an alias
+		t.SetPolicies(lo.UniqBy(policiesByTargetRef[t.GetURL()], func(p Policy) string { return p.GetURL() }))
 		return t
 	})
 
-	graph := dot.NewGraph(dot.Directed)
-
-	addObjectsToGraph(graph, o.Objects)
-	addTargetablesToGraph(graph, targetables)
-
 	linkables := append(o.Objects, lo.Map(targetables, AsObject[Targetable])...)
 	linkables = append(linkables, lo.Map(policies, AsObject[Policy])...)
 
+	var edges []Edge
 	for _, link := range o.Links {
 		children := lo.Filter(linkables, func(l Object, _ int) bool {
 			return l.GroupVersionKind().GroupKind() == link.To
@@ -103,28 +182,71 @@ func NewTopology(options ...TopologyOptionsFunc) *Topology {
 		for _, child := range children {
 			for _, parent := range link.Func(child) {
 				if parent != nil {
-					addEdgeToGraph(graph, fmt.Sprintf("%s -> %s", link.From.Kind, link.To.Kind), parent, child)
+					edge := Edge{Name: link.Name, Relationship: link.Relationship, From: parent, To: child}
+					if edge.Name == "" {
+						edge.Name = fmt.Sprintf("%s -> %s", link.From.Kind, link.To.Kind)
+					}
+					if link.Attributes != nil {
+						edge.Attributes = link.Attributes(parent, child)
+					}
+					edges = append(edges, edge)
 				}
 			}
 		}
 	}
 
-	addPoliciesToGraph(graph, policies)
+	if len(o.PruneKinds) > 0 {
+		targetables, edges = pruneEmptyNodes(targetables, edges, o.PruneKinds, o.Config)
+	}
+
+	graph := dot.NewGraph(dot.Directed)
+
+	addObjectsToGraph(graph, o.Objects)
+	addTargetablesToGraph(graph, targetables)
+
+	for _, edge := range edges {
+		addEdgeToGraph(graph, edge)
+	}
+
+	addPoliciesToGraph(graph, policies, locators)
 
 	return &Topology{
 		graph:       graph,
+		locators:    locators,
 		objects:     lo.SliceToMap(o.Objects, associateURL[Object]),
 		targetables: lo.SliceToMap(targetables, associateURL[Targetable]),
 		policies:    lo.SliceToMap(policies, associateURL[Policy]),
+		config:      o.Config,
+		edgeAttributes: lo.SliceToMap(lo.Filter(edges, func(edge Edge, _ int) bool { return len(edge.Attributes) > 0 }), func(edge Edge) (string, map[string]string) {
+			return edgeKey(edge.From, edge.To), edge.Attributes
+		}),
+
+		effectivePolicies: newEffectivePolicyCache(),
+		paths:             newPathCache(),
+		kinds:             &kindIndexes{},
 	}
 }
 
 // Topology models a network of related targetables and respective policies attached to them.
 type Topology struct {
 	graph       *dot.Graph
+	locators    topologyLocators
 	targetables map[string]Targetable
 	policies    map[string]Policy
 	objects     map[string]Object
+	config      Object
+
+	edgeAttributes map[string]map[string]string
+
+	effectivePolicies *effectivePolicyCache
+	paths             *pathCache
+	kinds             *kindIndexes
+}
+
+// SectionNameSeparator returns the separator between the names of resources and the names of their sections in the
+// locators of the topology (see WithSectionNameSeparator).
+func (t *Topology) SectionNameSeparator() SectionNameSeparator {
+	return t.locators.separator.orDefault()
 }
 
 // Targetables returns all targetable nodes in the topology.
@@ -154,10 +276,124 @@ func (t *Topology) Objects() *collection[Object] {
 	}
 }
 
+// Config returns the singleton configuration object of the topology, or nil if the topology has none.
+func (t *Topology) Config() Object {
+	return t.config
+}
+
+// ConfigAs returns the singleton configuration object of the topology as type T.
+// It returns false if the topology has no config object or if the config object is not of type T.
+func ConfigAs[T Object](topology *Topology) (T, bool) {
+	config, ok := topology.config.(T)
+	return config, ok
+}
+
+// Targets returns the targetables referred by the target references of a policy.
+// Target references not supported by the kind of policy (see RegisterPolicyCapabilities) are reported as
+// UnsupportedTargetError, the ones to a section that the target does not have (see RegisterSectionKinds) as
+// InvalidSectionNameError, and the other ones that do not resolve to a targetable of the topology as
+// TargetNotFoundError. See DiagnoseTargetRefs for the reasons why the target references do not resolve.
+func (t *Topology) Targets(policy Policy) ([]Targetable, error) {
+	diagnostics := t.DiagnoseTargetRefs(policy)
+	return diagnostics.Targets(), diagnostics.Err()
+}
+
 func (t *Topology) ToDot() string {
 	return t.graph.String()
 }
 
+// View returns a read-only view of the topology, for consumers that depend on the stable API only (see package api).
+func (t *Topology) View() api.TopologyView {
+	return topologyView{t}
+}
+
+type topologyView struct {
+	topology *Topology
+}
+
+func (v topologyView) Targetables() api.Collection[Targetable] {
+	return v.topology.Targetables()
+}
+
+func (v topologyView) Policies() api.Collection[Policy] {
+	return v.topology.Policies()
+}
+
+func (v topologyView) Objects() api.Collection[Object] {
+	return v.topology.Objects()
+}
+
+func (v topologyView) Targets(policy Policy) ([]Targetable, error) {
+	return v.topology.Targets(policy)
+}
+
+func (v topologyView) ToDot() string {
+	return v.topology.ToDot()
+}
+
+// ToGraphviz returns the topology in DOT format, like ToDot, with the nodes of the targetables annotated with the
+// policies attached to them and the edges labeled with the names of the links that originated them and the kinds of
+// relationships they represent.
+func (t *Topology) ToGraphviz() string {
+	graph := dot.NewGraph(dot.Directed)
+
+	addObjectsToGraph(graph, sortedByURL(lo.Values(t.objects)))
+	targetables := sortedByURL(lo.Values(t.targetables))
+	addTargetablesToGraph(graph, targetables)
+	for _, targetable := range targetables {
+		policies := targetable.Policies()
+		if len(policies) == 0 {
+			continue
+		}
+		node, _ := graph.FindNodeById(targetable.GetURL())
+		name := strings.TrimPrefix(namespacedName(targetable.GetNamespace(), targetable.GetName()), string(k8stypes.Separator))
+		annotations := lo.Map(policies, func(policy Policy, _ int) string {
+			return fmt.Sprintf("%s: %s", policy.GroupVersionKind().Kind, strings.TrimPrefix(namespacedName(policy.GetNamespace(), policy.GetName()), string(k8stypes.Separator)))
+		})
+		node.Label(fmt.Sprintf("%s\n%s\n%s", targetable.GroupVersionKind().Kind, name, strings.Join(annotations, "\n")))
+	}
+
+	for _, edgesFrom := range t.graph.EdgesMap() {
+		edges := append([]dot.Edge{}, edgesFrom...)
+		sort.SliceStable(edges, func(i, j int) bool {
+			return edges[i].To().ID() < edges[j].To().ID()
+		})
+		for _, edge := range edges {
+			name, _ := edge.GetAttr("comment").(string)
+			if name == policyTargetEdgeName {
+				continue // added with the policies below
+			}
+			from, foundFrom := graph.FindNodeById(edge.From().ID())
+			to, foundTo := graph.FindNodeById(edge.To().ID())
+			if foundFrom && foundTo {
+				label := name
+				if relationship, _ := edge.GetAttr(relationshipEdgeAttribute).(string); relationship != "" {
+					label = fmt.Sprintf("%s\n(%s)", name, relationship)
+				}
+				graph.Edge(from, to, label).Attr("comment", name)
+			}
+		}
+	}
+
+	addPoliciesToGraph(graph, sortedByURL(lo.Values(t.policies)), t.locators)
+	for _, edges := range graph.EdgesMap() {
+		for _, edge := range edges {
+			if name, _ := edge.GetAttr("comment").(string); name == policyTargetEdgeName {
+				edge.Label(name)
+			}
+		}
+	}
+
+	return graph.String()
+}
+
+func sortedByURL[T Object](objects []T) []T {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetURL() < objects[j].GetURL()
+	})
+	return objects
+}
+
 func addObjectsToGraph[T Object](graph *dot.Graph, objects []T) []dot.Node {
 	return lo.Map(objects, func(object T, _ int) dot.Node {
 		name := strings.TrimPrefix(namespacedName(object.GetNamespace(), object.GetName()), string(k8stypes.Separator))
@@ -178,7 +414,7 @@ func addTargetablesToGraph[T Targetable](graph *dot.Graph, targetables []T) {
 	}
 }
 
-func addPoliciesToGraph[T Policy](graph *dot.Graph, policies []T) {
+func addPoliciesToGraph[T Policy](graph *dot.Graph, policies []T, locators topologyLocators) {
 	for i, policyNode := range addObjectsToGraph(graph, policies) {
 		policyNode.Attrs(
 			"shape", "note",
@@ -186,23 +422,32 @@ func addPoliciesToGraph[T Policy](graph *dot.Graph, policies []T) {
 		)
 		// Policy -> Target edges
 		for _, targetRef := range policies[i].GetTargetRefs() {
-			targetNode, found := graph.FindNodeById(string(targetRef.GetURL()))
+			targetNode, found := graph.FindNodeById(locators.resolve(targetRef.GetURL()))
 			if !found {
 				continue
 			}
 			edge := graph.Edge(policyNode, targetNode)
-			edge.Attr("comment", "Policy -> Target")
+			edge.Attr("comment", policyTargetEdgeName)
+			edge.Attr(relationshipEdgeAttribute, string(EdgeRelationshipPolicyTargetRef))
 			edge.Dashed()
 		}
 	}
 }
 
-func addEdgeToGraph(graph *dot.Graph, name string, parent, child Object) {
-	p, foundParent := graph.FindNodeById(string(parent.GetURL()))
-	c, foundChild := graph.FindNodeById(string(child.GetURL()))
+func addEdgeToGraph(graph *dot.Graph, e Edge) {
+	p, foundParent := graph.FindNodeById(string(e.From.GetURL()))
+	c, foundChild := graph.FindNodeById(string(e.To.GetURL()))
 	if foundParent && foundChild {
 		edge := graph.Edge(p, c)
-		edge.Attr("comment", name)
+		edge.Attr("comment", e.Name)
+		if e.Relationship != "" {
+			edge.Attr(relationshipEdgeAttribute, string(e.Relationship))
+		}
+		keys := lo.Keys(e.Attributes)
+		sort.Strings(keys)
+		for _, key := range keys {
+			edge.Attr(key, e.Attributes[key])
+		}
 	}
 }
 
@@ -215,7 +460,7 @@ type collection[T Object] struct {
 	items    map[string]T
 }
 
-type FilterFunc func(Object) bool
+type FilterFunc = api.FilterFunc
 
 // Targetables returns all targetable nodes in the collection.
 // The list can be filtered by providing one or more filter functions.
@@ -257,6 +502,17 @@ func (c *collection[T]) Items(filters ...FilterFunc) []T {
 	})
 }
 
+// Get returns the item of the collection with a given locator (URL).
+// Locators of sections with the default section name separator (see WithSectionNameSeparator) and aliases of
+// targetables (see AliasedTargetable) are also resolved.
+func (c *collection[T]) Get(locator string) (T, bool) {
+	if item, ok := c.items[locator]; ok {
+		return item, true
+	}
+	item, ok := c.items[c.topology.locators.resolve(locator)]
+	return item, ok
+}
+
 // Roots returns all items that have no parents in the collection.
 func (c *collection[T]) Roots() []T {
 	return lo.Filter(lo.Values(c.items), func(item T, _ int) bool {
@@ -292,10 +548,20 @@ func (c *collection[T]) Children(item Object) []T {
 
 // Paths returns all paths from a source item to a destination item in the collection.
 // The order of the elements in the inner slices represents a path from the source to the destination.
+// Paths between targetables are cached in the topology (see Topology.Warm).
 func (c *collection[T]) Paths(from, to Object) [][]T {
 	if from == nil || to == nil {
 		return nil
 	}
+	if targetables, ok := any(c).(*collection[Targetable]); ok && c.topology != nil {
+		return any(c.topology.paths.getOrCompute(from.GetURL()+"|"+to.GetURL(), func() [][]Targetable {
+			return targetables.paths(from, to)
+		})).([][]T)
+	}
+	return c.paths(from, to)
+}
+
+func (c *collection[T]) paths(from, to Object) [][]T {
 	var paths [][]T
 	var path []T
 	visited := make(map[string]bool)
@@ -323,3 +589,154 @@ func (c *collection[T]) dfs(current, to Object, path []T, paths *[][]T, visited
 	path = path[:len(path)-1]
 	visited[currentURL] = false
 }
+
+// AncestorPaths returns all paths from the roots of the collection to a given item, i.e. all upstream chains that
+// reach the item. The order of the elements in the inner slices represents a path from the root to the item.
+// The paths of each ancestor are computed only once, so the enumeration is linear on the number of resulting paths.
+func (c *collection[T]) AncestorPaths(item Object) [][]T {
+	if item == nil {
+		return nil
+	}
+	if _, found := c.items[item.GetURL()]; !found {
+		return nil
+	}
+	parents := c.parentsIndex()
+	memo := make(map[string][][]T)
+	return c.ancestorPaths(item.GetURL(), parents, memo, make(map[string]bool))
+}
+
+// Ancestors returns all items in the collection from which a given item can be reached.
+func (c *collection[T]) Ancestors(item Object) []T {
+	ancestors := make(map[string]T)
+	for _, path := range c.AncestorPaths(item) {
+		for _, ancestor := range path[:len(path)-1] {
+			ancestors[ancestor.GetURL()] = ancestor
+		}
+	}
+	return sortedByURL(lo.Values(ancestors))
+}
+
+// parentsIndex returns the URLs of the parents of each item in the collection, sorted.
+func (c *collection[T]) parentsIndex() map[string][]string {
+	parents := make(map[string][]string)
+	for from, edges := range c.topology.graph.EdgesMap() {
+		if _, found := c.items[from]; !found {
+			continue
+		}
+		for _, edge := range edges {
+			to := edge.To().ID()
+			if _, found := c.items[to]; found {
+				parents[to] = append(parents[to], from)
+			}
+		}
+	}
+	for url := range parents {
+		sort.Strings(parents[url])
+	}
+	return parents
+}
+
+func (c *collection[T]) ancestorPaths(url string, parents map[string][]string, memo map[string][][]T, visiting map[string]bool) [][]T {
+	if paths, ok := memo[url]; ok {
+		return paths
+	}
+	item := c.items[url]
+	if len(parents[url]) == 0 {
+		return [][]T{{item}}
+	}
+	visiting[url] = true
+	defer delete(visiting, url)
+	var paths [][]T
+	for _, parent := range parents[url] {
+		if visiting[parent] { // cycle
+			continue
+		}
+		for _, path := range c.ancestorPaths(parent, parents, memo, visiting) {
+			pathCopy := make([]T, len(path), len(path)+1)
+			copy(pathCopy, path)
+			paths = append(paths, append(pathCopy, item))
+		}
+	}
+	memo[url] = paths
+	return paths
+}
+
+// TargetablesOfType returns all targetable nodes of type T in the topology.
+// The list can be filtered by providing one or more filter functions.
+func TargetablesOfType[T Targetable](topology *Topology, filters ...FilterFunc) []T {
+	return itemsOfType[T](topology.Targetables().Items(filters...))
+}
+
+// PoliciesOfType returns all policies of type T in the topology.
+// The list can be filtered by providing one or more filter functions.
+func PoliciesOfType[T Policy](topology *Topology, filters ...FilterFunc) []T {
+	return itemsOfType[T](topology.Policies().Items(filters...))
+}
+
+// ObjectsOfType returns all non-targetable, non-policy object nodes of type T in the topology.
+// The list can be filtered by providing one or more filter functions.
+func ObjectsOfType[T Object](topology *Topology, filters ...FilterFunc) []T {
+	return itemsOfType[T](topology.Objects().Items(filters...))
+}
+
+// ParentsOfType returns all parents of type T of a given item in the topology, whether targetables, policies or
+// other objects.
+func ParentsOfType[T Object](topology *Topology, item Object) []T {
+	parents := itemsOfType[T](topology.Targetables().Parents(item))
+	parents = append(parents, itemsOfType[T](topology.Policies().Parents(item))...)
+	return append(parents, itemsOfType[T](topology.Objects().Parents(item))...)
+}
+
+// ChildrenOfType returns all children of type T of a given item in the topology, whether targetables, policies or
+// other objects.
+func ChildrenOfType[T Object](topology *Topology, item Object) []T {
+	children := itemsOfType[T](topology.Targetables().Children(item))
+	children = append(children, itemsOfType[T](topology.Policies().Children(item))...)
+	return append(children, itemsOfType[T](topology.Objects().Children(item))...)
+}
+
+func itemsOfType[T Object, U Object](items []U) []T {
+	return lo.FilterMap(items, func(item U, _ int) (T, bool) {
+		t, ok := any(item).(T)
+		return t, ok
+	})
+}
+
+// targetableAliases maps the locator aliases of a list of targetables to their URLs (see AliasedTargetable).
+// Aliases that are the URL of another targetable are ignored.
+func targetableAliases(targetables []Targetable) map[string]string {
+	urls := lo.SliceToMap(targetables, func(t Targetable) (string, struct{}) { return t.GetURL(), struct{}{} })
+	aliases := make(map[string]string)
+	for _, t := range targetables {
+		for _, alias := range targetableLocatorAliases(t) {
+			if _, taken := urls[alias]; !taken {
+				aliases[alias] = t.GetURL()
+			}
+		}
+	}
+	return aliases
+}
+
+// topologyLocators resolves locators to the URLs of the nodes of a topology.
+type topologyLocators struct {
+	separator SectionNameSeparator
+	aliases   map[string]string // locator alias → url (see AliasedTargetable)
+}
+
+// resolve returns the URL of the node located by a locator with any of the section name separators of the topology,
+// or by an alias of a targetable.
+func (l topologyLocators) resolve(locator string) string {
+	normalized := l.separator.NormalizeLocator(locator)
+	if url, ok := l.aliases[normalized]; ok {
+		return url
+	}
+	return normalized
+}
+
+// targetableLocatorAliases returns the locator aliases of a targetable, if any (see AliasedTargetable).
+func targetableLocatorAliases(t Targetable) []string {
+	if aliased, ok := t.(AliasedTargetable); ok {
+		return aliased.LocatorAliases()
+	}
+	return nil
+}
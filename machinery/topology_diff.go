@@ -0,0 +1,153 @@
+package machinery
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+
+	"github.com/samber/lo"
+)
+
+// TopologyChanges are the differences between two builds of a topology, e.g. the topologies of two consecutive
+// reconciliations. Nodes are sorted by URL; edges and policy attachments, by URL of their ends.
+type TopologyChanges struct {
+	// Added are the nodes of the new topology that are not in the old one.
+	Added []Object
+	// Removed are the nodes of the old topology that are not in the new one.
+	Removed []Object
+	// Changed are the nodes in both topologies whose objects changed, as in the new topology. Objects are compared by
+	// resource version if they have one, and by their JSON representation otherwise.
+	Changed []Object
+
+	// AddedEdges are the edges of the new topology that are not in the old one.
+	AddedEdges []SnapshotEdge
+	// RemovedEdges are the edges of the old topology that are not in the new one.
+	RemovedEdges []SnapshotEdge
+
+	// Attached are the policies attached to targetables in the new topology that were not attached to them in the
+	// old one.
+	Attached []PolicyAttachment
+	// Detached are the policies attached to targetables in the old topology that are not attached to them in the new
+	// one. The policies and the targetables are as in the old topology.
+	Detached []PolicyAttachment
+}
+
+// PolicyAttachment is a policy attached to a targetable.
+type PolicyAttachment struct {
+	Policy Policy
+	Target Targetable
+}
+
+// Empty returns true if the topologies are equivalent.
+func (c *TopologyChanges) Empty() bool {
+	return len(c.Added) == 0 && len(c.Removed) == 0 && len(c.Changed) == 0 &&
+		len(c.AddedEdges) == 0 && len(c.RemovedEdges) == 0 &&
+		len(c.Attached) == 0 && len(c.Detached) == 0
+}
+
+// TopologyDiff returns the nodes, edges and policy attachments added, removed or changed between two topologies.
+// A nil topology is equivalent to an empty one.
+func TopologyDiff(oldTopology, newTopology *Topology) *TopologyChanges {
+	oldNodes, newNodes := topologyNodes(oldTopology), topologyNodes(newTopology)
+	changes := &TopologyChanges{}
+
+	for _, url := range sortedURLs(newNodes) {
+		oldNode, found := oldNodes[url]
+		switch {
+		case !found:
+			changes.Added = append(changes.Added, newNodes[url])
+		case objectChanged(oldNode, newNodes[url]):
+			changes.Changed = append(changes.Changed, newNodes[url])
+		}
+	}
+	for _, url := range sortedURLs(oldNodes) {
+		if _, found := newNodes[url]; !found {
+			changes.Removed = append(changes.Removed, oldNodes[url])
+		}
+	}
+
+	oldEdges, newEdges := topologyEdges(oldTopology), topologyEdges(newTopology)
+	changes.AddedEdges, changes.RemovedEdges = lo.Difference(newEdges, oldEdges)
+
+	oldAttachments, newAttachments := policyAttachments(oldTopology), policyAttachments(newTopology)
+	changes.Attached = lo.Filter(newAttachments, func(a PolicyAttachment, _ int) bool {
+		return !lo.ContainsBy(oldAttachments, a.equals)
+	})
+	changes.Detached = lo.Filter(oldAttachments, func(a PolicyAttachment, _ int) bool {
+		return !lo.ContainsBy(newAttachments, a.equals)
+	})
+
+	return changes
+}
+
+func (a PolicyAttachment) equals(other PolicyAttachment) bool {
+	return a.Policy.GetURL() == other.Policy.GetURL() && a.Target.GetURL() == other.Target.GetURL()
+}
+
+// topologyNodes returns all nodes of a topology, indexed by URL
+func topologyNodes(topology *Topology) map[string]Object {
+	nodes := make(map[string]Object)
+	if topology == nil {
+		return nodes
+	}
+	for url, targetable := range topology.targetables {
+		nodes[url] = targetable
+	}
+	for url, policy := range topology.policies {
+		nodes[url] = policy
+	}
+	for url, obj := range topology.objects {
+		nodes[url] = obj
+	}
+	return nodes
+}
+
+// topologyEdges returns all edges of a topology, sorted by URL of their ends
+func topologyEdges(topology *Topology) []SnapshotEdge {
+	if topology == nil {
+		return nil
+	}
+	return topology.Snapshot().Edges
+}
+
+// policyAttachments returns all policies attached to the targetables of a topology, sorted by URL of the targetables
+// and of the policies
+func policyAttachments(topology *Topology) []PolicyAttachment {
+	if topology == nil {
+		return nil
+	}
+	var attachments []PolicyAttachment
+	for _, targetable := range sortedByURL(lo.Values(topology.targetables)) {
+		for _, policy := range sortedByURL(append([]Policy(nil), targetable.Policies()...)) {
+			attachments = append(attachments, PolicyAttachment{Policy: policy, Target: targetable})
+		}
+	}
+	return attachments
+}
+
+// objectChanged returns true if two versions of an object differ
+func objectChanged(oldObj, newObj Object) bool {
+	type versioned interface{ GetResourceVersion() string }
+	o, oOk := oldObj.(versioned)
+	n, nOk := newObj.(versioned)
+	if oOk && nOk && o.GetResourceVersion() != "" && n.GetResourceVersion() != "" {
+		return o.GetResourceVersion() != n.GetResourceVersion()
+	}
+	if oldPolicy, ok := oldObj.(Policy); ok {
+		if newPolicy, ok := newObj.(Policy); ok {
+			oldObj, newObj = NormalizePolicy(oldPolicy), NormalizePolicy(newPolicy)
+		}
+	}
+	oldJSON, oldErr := json.Marshal(oldObj)
+	newJSON, newErr := json.Marshal(newObj)
+	if oldErr != nil || newErr != nil {
+		return !reflect.DeepEqual(oldObj, newObj)
+	}
+	return string(oldJSON) != string(newJSON)
+}
+
+func sortedURLs(nodes map[string]Object) []string {
+	urls := lo.Keys(nodes)
+	sort.Strings(urls)
+	return urls
+}
@@ -0,0 +1,87 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/samber/lo"
+)
+
+func TestTopologyDiff(t *testing.T) {
+	policy := func(name, resourceVersion, kind, target string) *FruitPolicy {
+		return buildFruitPolicy(func(policy *FruitPolicy) {
+			policy.Name = name
+			policy.ResourceVersion = resourceVersion
+			policy.Spec.TargetRef = FruitPolicyTargetReference{
+				Group: TestGroupName,
+				Kind:  kind,
+				Name:  target,
+			}
+		})
+	}
+
+	oldApples := []*Apple{{Name: "apple-1"}}
+	oldTopology := NewTopology(
+		WithTargetables(oldApples...),
+		WithTargetables(&Orange{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1"}}),
+		WithLinks(LinkApplesToOranges(oldApples)),
+		WithPolicies(
+			policy("policy-1", "1", "Orange", "orange-1"),
+			policy("policy-2", "1", "Apple", "apple-1"),
+		),
+	)
+
+	newApples := []*Apple{{Name: "apple-1"}, {Name: "apple-2"}}
+	newTopology := NewTopology(
+		WithTargetables(newApples...),
+		WithTargetables(&Orange{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-2"}}),
+		WithLinks(LinkApplesToOranges(newApples)),
+		WithPolicies(
+			policy("policy-1", "2", "Orange", "orange-1"),
+			policy("policy-3", "1", "Apple", "apple-2"),
+		),
+	)
+
+	urls := func(objs []Object) []string {
+		return lo.Map(objs, func(obj Object, _ int) string { return obj.GetURL() })
+	}
+	edges := func(edges []SnapshotEdge) []string {
+		return lo.Map(edges, func(edge SnapshotEdge, _ int) string { return edge.From + " -> " + edge.To })
+	}
+	attachments := func(attachments []PolicyAttachment) []string {
+		return lo.Map(attachments, func(a PolicyAttachment, _ int) string { return a.Policy.GetName() + " -> " + a.Target.GetName() })
+	}
+
+	changes := TopologyDiff(oldTopology, newTopology)
+
+	testCases := []struct {
+		name     string
+		actual   []string
+		expected []string
+	}{
+		{"added", urls(changes.Added), []string{"apple.example.test:apple-2", "fruitpolicy.test:my-namespace/policy-3"}},
+		{"removed", urls(changes.Removed), []string{"fruitpolicy.test:my-namespace/policy-2"}},
+		{"changed", urls(changes.Changed), []string{"fruitpolicy.test:my-namespace/policy-1", "orange.example.test:my-namespace/orange-1"}},
+		{"added edges", edges(changes.AddedEdges), []string{"apple.example.test:apple-2 -> orange.example.test:my-namespace/orange-1", "fruitpolicy.test:my-namespace/policy-3 -> apple.example.test:apple-2"}},
+		{"removed edges", edges(changes.RemovedEdges), []string{"apple.example.test:apple-1 -> orange.example.test:my-namespace/orange-1", "fruitpolicy.test:my-namespace/policy-2 -> apple.example.test:apple-1"}},
+		{"attached", attachments(changes.Attached), []string{"policy-3 -> apple-2"}},
+		{"detached", attachments(changes.Detached), []string{"policy-2 -> apple-1"}},
+	}
+	for _, tc := range testCases {
+		if !slices.Equal(tc.actual, tc.expected) {
+			t.Errorf("%s: expected %v, got %v", tc.name, tc.expected, tc.actual)
+		}
+	}
+
+	if changes.Empty() {
+		t.Errorf("expected changes")
+	}
+	if changes := TopologyDiff(newTopology, newTopology); !changes.Empty() {
+		t.Errorf("expected no changes, got %+v", changes)
+	}
+	if changes := TopologyDiff(nil, newTopology); len(changes.Added) != 5 || len(changes.Removed) != 0 {
+		t.Errorf("expected all nodes to be added, got %v", urls(changes.Added))
+	}
+}
@@ -0,0 +1,106 @@
+package machinery
+
+import (
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// kindIndex indexes the locators of the items of a collection by kind.
+// The index is built on first use, sorted by locator.
+type kindIndex struct {
+	once   sync.Once
+	byKind map[schema.GroupKind][]string
+}
+
+func (i *kindIndex) get(build func() map[schema.GroupKind][]string) map[schema.GroupKind][]string {
+	if i == nil {
+		return build()
+	}
+	i.once.Do(func() {
+		i.byKind = build()
+	})
+	return i.byKind
+}
+
+// kindIndexes are the indexes by kind of the collections of a topology.
+type kindIndexes struct {
+	targetables kindIndex
+	policies    kindIndex
+	objects     kindIndex
+}
+
+func (c *collection[T]) kindIndex() map[schema.GroupKind][]string {
+	var index *kindIndex
+	if indexes := c.topology.kinds; indexes != nil {
+		switch any(c.items).(type) {
+		case map[string]Targetable:
+			index = &indexes.targetables
+		case map[string]Policy:
+			index = &indexes.policies
+		case map[string]Object:
+			index = &indexes.objects
+		}
+	}
+	return index.get(func() map[schema.GroupKind][]string {
+		byKind := make(map[schema.GroupKind][]string)
+		for _, item := range sortedByURL(lo.Values(c.items)) {
+			kind := item.GroupVersionKind().GroupKind()
+			byKind[kind] = append(byKind[kind], item.GetURL())
+		}
+		return byKind
+	})
+}
+
+// ByKind returns the items of given kinds in the collection, sorted by URL.
+// Unlike filtering the items by kind with Items, the lookup is backed by an index of the topology.
+func (c *collection[T]) ByKind(kinds ...schema.GroupKind) []T {
+	index := c.kindIndex()
+	var items []T
+	for _, kind := range lo.Uniq(kinds) {
+		for _, url := range index[kind] {
+			items = append(items, c.items[url])
+		}
+	}
+	return items
+}
+
+// Where returns the items in the collection that match a predicate, sorted by URL.
+func (c *collection[T]) Where(predicate func(T) bool) []T {
+	return lo.Filter(sortedByURL(lo.Values(c.items)), func(item T, _ int) bool {
+		return predicate(item)
+	})
+}
+
+// Count returns the number of items in the collection.
+// The items counted can be filtered by providing one or more filter functions.
+func (c *collection[T]) Count(filters ...FilterFunc) int {
+	if len(filters) == 0 {
+		return len(c.items)
+	}
+	return len(c.Items(filters...))
+}
+
+// CountByKind returns the number of items of each kind in the collection.
+func (c *collection[T]) CountByKind() map[schema.GroupKind]int {
+	return lo.MapValues(c.kindIndex(), func(urls []string, _ schema.GroupKind) int {
+		return len(urls)
+	})
+}
+
+// PoliciesOfKind returns the policies of given kinds in the topology, as type T, sorted by URL.
+// Policies of the kinds that are not of type T are left out.
+func PoliciesOfKind[T Policy](topology *Topology, kinds ...schema.GroupKind) []T {
+	return itemsOfType[T](topology.Policies().ByKind(kinds...))
+}
+
+// EdgeCount returns the number of links between nodes of the topology, including the ones between policies and their
+// targets.
+func (t *Topology) EdgeCount() int {
+	count := 0
+	for _, edges := range t.graph.EdgesMap() {
+		count += len(edges)
+	}
+	return count
+}
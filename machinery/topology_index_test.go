@@ -0,0 +1,62 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestCollectionByKind(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	routeKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRoute"}
+	policyKind := schema.GroupKind{Group: "test", Kind: "TestPolicy"}
+	otherPolicyKind := schema.GroupKind{Group: "test", Kind: "OtherPolicy"}
+
+	policies := []*TestPolicy{
+		buildPolicy(func(p *TestPolicy) { p.Name = "policy-b" }),
+		buildPolicy(func(p *TestPolicy) { p.Name = "policy-a" }),
+		buildPolicy(func(p *TestPolicy) { p.Name = "other"; p.Kind = "OtherPolicy" }),
+	}
+	topology := NewGatewayAPITopology(
+		WithGateways(BuildGateway(), BuildGateway(func(g *gwapiv1.Gateway) { g.Name = "other-gateway" })),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		WithGatewayAPITopologyPolicies(lo.Map(policies, func(p *TestPolicy, _ int) Policy { return p })...),
+	)
+
+	names := func(objs []Policy) []string {
+		return lo.Map(objs, func(o Policy, _ int) string { return o.GetName() })
+	}
+	if got := names(topology.Policies().ByKind(policyKind)); len(got) != 2 || got[0] != "policy-a" || got[1] != "policy-b" {
+		t.Errorf("expected [policy-a policy-b], got %v", got)
+	}
+	if got := topology.Policies().ByKind(policyKind, otherPolicyKind, policyKind); len(got) != 3 {
+		t.Errorf("expected 3 policies, got %v", names(got))
+	}
+	if got := topology.Policies().ByKind(gatewayKind); len(got) != 0 {
+		t.Errorf("expected no policies, got %v", names(got))
+	}
+	if got := PoliciesOfKind[*TestPolicy](topology, otherPolicyKind); len(got) != 1 || got[0].GetName() != "other" {
+		t.Errorf("expected typed policy other, got %v", got)
+	}
+
+	if got := topology.Targetables().ByKind(gatewayKind); len(got) != 2 {
+		t.Errorf("expected 2 gateways, got %d", len(got))
+	}
+	if got := topology.Targetables().Where(func(t Targetable) bool { return t.GetName() == "other-gateway" }); len(got) != 1 {
+		t.Errorf("expected 1 targetable, got %d", len(got))
+	}
+
+	if count := topology.Policies().Count(); count != 3 {
+		t.Errorf("expected 3 policies, got %d", count)
+	}
+	if count := topology.Targetables().Count(func(o Object) bool { return o.GroupVersionKind().GroupKind() == routeKind }); count != 1 {
+		t.Errorf("expected 1 route, got %d", count)
+	}
+	if counts := topology.Policies().CountByKind(); counts[policyKind] != 2 || counts[otherPolicyKind] != 1 {
+		t.Errorf("unexpected counts by kind %v", counts)
+	}
+}
@@ -0,0 +1,248 @@
+package machinery
+
+import (
+	"encoding/json"
+	"sort"
+
+	"github.com/emicklei/dot"
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// TopologySnapshot is a serializable representation of a topology.
+// It holds the URLs and kinds of all nodes, the policy attachments and the edges of the graph, but not the full
+// content of the objects.
+type TopologySnapshot struct {
+	Targetables []SnapshotTargetable `json:"targetables,omitempty"`
+	Policies    []SnapshotPolicy     `json:"policies,omitempty"`
+	Objects     []SnapshotObject     `json:"objects,omitempty"`
+	Edges       []SnapshotEdge       `json:"edges,omitempty"`
+	Config      string               `json:"config,omitempty"`
+	// SectionNameSeparator is the separator of the names of the sections in the locators, if other than the default.
+	SectionNameSeparator string `json:"sectionNameSeparator,omitempty"`
+}
+
+// SnapshotObject is a node of a topology reconstructed out of a snapshot.
+type SnapshotObject struct {
+	URL       string `json:"url"`
+	Group     string `json:"group,omitempty"`
+	Version   string `json:"version,omitempty"`
+	Kind      string `json:"kind"`
+	Namespace string `json:"namespace,omitempty"`
+	Name      string `json:"name"`
+}
+
+var _ Object = &SnapshotObject{}
+
+func snapshotObjectFrom(obj Object) SnapshotObject {
+	gvk := obj.GroupVersionKind()
+	return SnapshotObject{
+		URL:       obj.GetURL(),
+		Group:     gvk.Group,
+		Version:   gvk.Version,
+		Kind:      gvk.Kind,
+		Namespace: obj.GetNamespace(),
+		Name:      obj.GetName(),
+	}
+}
+
+func (o *SnapshotObject) GroupVersionKind() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: o.Group, Version: o.Version, Kind: o.Kind}
+}
+
+func (o *SnapshotObject) SetGroupVersionKind(gvk schema.GroupVersionKind) {
+	o.Group, o.Version, o.Kind = gvk.Group, gvk.Version, gvk.Kind
+}
+
+func (o *SnapshotObject) GetNamespace() string {
+	return o.Namespace
+}
+
+func (o *SnapshotObject) GetName() string {
+	return o.Name
+}
+
+func (o *SnapshotObject) GetURL() string {
+	return o.URL
+}
+
+// SnapshotTargetable is a targetable node of a topology reconstructed out of a snapshot.
+// PolicyURLs lists the URLs of the policies attached to the targetable.
+type SnapshotTargetable struct {
+	SnapshotObject
+	PolicyURLs []string `json:"policies,omitempty"`
+	Aliases    []string `json:"aliases,omitempty"`
+
+	policies []Policy
+}
+
+var _ AliasedTargetable = &SnapshotTargetable{}
+
+func (t *SnapshotTargetable) LocatorAliases() []string {
+	return t.Aliases
+}
+
+func (t *SnapshotTargetable) SetPolicies(policies []Policy) {
+	t.policies = policies
+}
+
+func (t *SnapshotTargetable) Policies() []Policy {
+	return t.policies
+}
+
+// SnapshotPolicy is a policy node of a topology reconstructed out of a snapshot.
+type SnapshotPolicy struct {
+	SnapshotObject
+	TargetRefs []SnapshotObject `json:"targetRefs,omitempty"`
+}
+
+var _ Policy = &SnapshotPolicy{}
+
+func (p *SnapshotPolicy) GetTargetRefs() []PolicyTargetReference {
+	return lo.Map(p.TargetRefs, func(ref SnapshotObject, _ int) PolicyTargetReference {
+		return &ref
+	})
+}
+
+func (p *SnapshotPolicy) GetMergeStrategy() MergeStrategy {
+	return MergeStrategyFor(p.GroupVersionKind().GroupKind())
+}
+
+func (p *SnapshotPolicy) Merge(other Policy) Policy {
+	return p.GetMergeStrategy()(p, other)
+}
+
+// SnapshotEdge is a link between two nodes of a topology, identified by their URLs.
+// The name of the edge is the name of the link that originated it. The attributes of the edges are not kept.
+type SnapshotEdge struct {
+	From         string `json:"from"`
+	To           string `json:"to"`
+	Name         string `json:"name,omitempty"`
+	Relationship string `json:"relationship,omitempty"`
+}
+
+// Snapshot returns a serializable representation of the topology.
+// The nodes and edges are sorted by URL, so equivalent topologies produce identical snapshots.
+func (t *Topology) Snapshot() *TopologySnapshot {
+	snapshot := &TopologySnapshot{
+		Targetables: lo.Map(sortedByURL(lo.Values(t.targetables)), func(targetable Targetable, _ int) SnapshotTargetable {
+			return SnapshotTargetable{
+				SnapshotObject: snapshotObjectFrom(targetable),
+				PolicyURLs:     lo.Map(targetable.Policies(), func(policy Policy, _ int) string { return policy.GetURL() }),
+				Aliases:        targetableLocatorAliases(targetable),
+			}
+		}),
+		Policies: lo.Map(sortedByURL(lo.Values(t.policies)), func(policy Policy, _ int) SnapshotPolicy {
+			return SnapshotPolicy{
+				SnapshotObject: snapshotObjectFrom(policy),
+				TargetRefs: lo.Map(policy.GetTargetRefs(), func(ref PolicyTargetReference, _ int) SnapshotObject {
+					return snapshotObjectFrom(ref)
+				}),
+			}
+		}),
+		Objects: lo.Map(sortedByURL(lo.Values(t.objects)), func(obj Object, _ int) SnapshotObject {
+			return snapshotObjectFrom(obj)
+		}),
+	}
+	if t.config != nil {
+		snapshot.Config = t.config.GetURL()
+	}
+	if separator := t.SectionNameSeparator(); separator != DefaultSectionNameSeparator {
+		snapshot.SectionNameSeparator = string(rune(separator))
+	}
+
+	for _, edges := range t.graph.EdgesMap() {
+		for _, edge := range edges {
+			name, _ := edge.GetAttr("comment").(string)
+			relationship, _ := edge.GetAttr(relationshipEdgeAttribute).(string)
+			snapshot.Edges = append(snapshot.Edges, SnapshotEdge{From: edge.From().ID(), To: edge.To().ID(), Name: name, Relationship: relationship})
+		}
+	}
+	sort.Slice(snapshot.Edges, func(i, j int) bool {
+		if snapshot.Edges[i].From != snapshot.Edges[j].From {
+			return snapshot.Edges[i].From < snapshot.Edges[j].From
+		}
+		return snapshot.Edges[i].To < snapshot.Edges[j].To
+	})
+
+	return snapshot
+}
+
+// RebuildFromSnapshot reconstructs a topology out of a snapshot.
+// The nodes of the resulting topology are of types SnapshotTargetable, SnapshotPolicy and SnapshotObject.
+// Edges whose ends are not nodes of the snapshot are ignored.
+func RebuildFromSnapshot(snapshot *TopologySnapshot) *Topology {
+	policies := lo.Map(snapshot.Policies, func(p SnapshotPolicy, _ int) Policy {
+		policy := p
+		return &policy
+	})
+	policiesByURL := lo.SliceToMap(policies, associateURL[Policy])
+
+	targetables := lo.Map(snapshot.Targetables, func(t SnapshotTargetable, _ int) Targetable {
+		targetable := t
+		targetable.SetPolicies(lo.FilterMap(targetable.PolicyURLs, func(url string, _ int) (Policy, bool) {
+			policy, found := policiesByURL[url]
+			return policy, found
+		}))
+		return &targetable
+	})
+
+	objects := lo.Map(snapshot.Objects, func(o SnapshotObject, _ int) Object {
+		obj := o
+		return &obj
+	})
+
+	graph := dot.NewGraph(dot.Directed)
+
+	addObjectsToGraph(graph, objects)
+	addTargetablesToGraph(graph, targetables)
+	locators := topologyLocators{aliases: targetableAliases(targetables)}
+	if separator := []rune(snapshot.SectionNameSeparator); len(separator) == 1 {
+		locators.separator = SectionNameSeparator(separator[0]).orDefault()
+	}
+	addPoliciesToGraph(graph, policies, locators)
+
+	for _, edge := range snapshot.Edges {
+		if edge.Name == policyTargetEdgeName {
+			continue // added with the policies
+		}
+		from, foundFrom := graph.FindNodeById(edge.From)
+		to, foundTo := graph.FindNodeById(edge.To)
+		if foundFrom && foundTo {
+			e := graph.Edge(from, to).Attr("comment", edge.Name)
+			if edge.Relationship != "" {
+				e.Attr(relationshipEdgeAttribute, edge.Relationship)
+			}
+		}
+	}
+
+	objectsByURL := lo.SliceToMap(objects, associateURL[Object])
+
+	return &Topology{
+		graph:       graph,
+		locators:    locators,
+		objects:     objectsByURL,
+		targetables: lo.SliceToMap(targetables, associateURL[Targetable]),
+		policies:    policiesByURL,
+		config:      objectsByURL[snapshot.Config],
+
+		effectivePolicies: newEffectivePolicyCache(),
+		paths:             newPathCache(),
+		kinds:             &kindIndexes{},
+	}
+}
+
+// MarshalJSON encodes the snapshot of the topology as JSON.
+func (t *Topology) MarshalJSON() ([]byte, error) {
+	return json.Marshal(t.Snapshot())
+}
+
+// UnmarshalJSON decodes a JSON snapshot of a topology and rebuilds the topology out of it.
+func (t *Topology) UnmarshalJSON(data []byte) error {
+	snapshot := &TopologySnapshot{}
+	if err := json.Unmarshal(data, snapshot); err != nil {
+		return err
+	}
+	*t = *RebuildFromSnapshot(snapshot)
+	return nil
+}
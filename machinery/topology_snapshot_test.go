@@ -0,0 +1,71 @@
+//go:build unit
+
+package machinery
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestTopologyJSONRoundTrip(t *testing.T) {
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(BuildGatewayClass()),
+		WithGateways(BuildGateway()),
+		ExpandGatewayListeners(),
+		WithHTTPRoutes(BuildHTTPRoute()),
+		ExpandHTTPRouteRules(),
+		WithServices(BuildService()),
+		WithGatewayAPITopologyPolicies(buildPolicy()),
+		WithGatewayAPITopologyConfig(&Info{Name: "config"}, schema.GroupKind{Group: gwapiv1.GroupName, Kind: "GatewayClass"}),
+	)
+
+	data, err := json.Marshal(topology)
+	if err != nil {
+		t.Fatalf("failed to marshal topology: %v", err)
+	}
+
+	rebuilt := &Topology{}
+	if err := json.Unmarshal(data, rebuilt); err != nil {
+		t.Fatalf("failed to unmarshal topology: %v", err)
+	}
+
+	if expected, actual := topology.ToGraphviz(), rebuilt.ToGraphviz(); expected != actual {
+		t.Errorf("expected rebuilt topology to be equal to the original one\nexpected:\n%s\ngot:\n%s", expected, actual)
+	}
+
+	if config := rebuilt.Config(); config == nil || config.GetName() != "config" {
+		t.Errorf("expected rebuilt topology to have the config object, got %v", config)
+	}
+
+	redata, err := json.Marshal(rebuilt)
+	if err != nil {
+		t.Fatalf("failed to marshal rebuilt topology: %v", err)
+	}
+	if string(data) != string(redata) {
+		t.Errorf("expected snapshots to be equal\nexpected:\n%s\ngot:\n%s", data, redata)
+	}
+
+	service, found := lo.Find(rebuilt.Targetables().Items(), func(o Targetable) bool {
+		return o.GroupVersionKind().Kind == "Service"
+	})
+	if !found {
+		t.Fatalf("expected rebuilt topology to contain the service")
+	}
+	if policies := service.Policies(); len(policies) != 1 || policies[0].GetName() != "my-policy" {
+		t.Errorf("expected service to have policy my-policy attached, got %v", policies)
+	}
+	if targetRefs := rebuilt.Policies().Items()[0].GetTargetRefs(); len(targetRefs) != 1 || targetRefs[0].GetURL() != service.GetURL() {
+		t.Errorf("expected policy to target %s, got %v", service.GetURL(), targetRefs)
+	}
+
+	gatewayClass, _ := lo.Find(rebuilt.Targetables().Roots(), func(o Targetable) bool {
+		return o.GroupVersionKind().Kind == "GatewayClass"
+	})
+	if paths := rebuilt.Targetables().Paths(gatewayClass, service); len(paths) == 0 {
+		t.Errorf("expected paths from the gateway class to the service in the rebuilt topology")
+	}
+}
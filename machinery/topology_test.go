@@ -3,11 +3,15 @@
 package machinery
 
 import (
+	"errors"
 	"slices"
 	"strings"
 	"testing"
 
 	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	"github.com/kuadrant/policy-machinery/machinery/api"
 )
 
 func TestTopologyRoots(t *testing.T) {
@@ -369,3 +373,257 @@ func TestTopologyWithRuntimeObjects(t *testing.T) {
 
 	SaveToOutputDir(t, topology.ToDot(), "../tests/out", ".dot")
 }
+
+func TestTopologyToGraphviz(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}}
+	oranges := []*Orange{{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1"}}}
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithTargetables(oranges...),
+		WithPolicies(
+			buildFruitPolicy(func(policy *FruitPolicy) {
+				policy.Name = "policy-1"
+				policy.Spec.TargetRef.Kind = "Apple"
+				policy.Spec.TargetRef.Name = "apple-1"
+			}),
+		),
+		WithLinks(LinkApplesToOranges(apples)),
+	)
+
+	graphviz := topology.ToGraphviz()
+	for _, expected := range []string{
+		`label="Apple\napple-1\nFruitPolicy: my-namespace/policy-1"`,
+		`label="Orange\nmy-namespace/orange-1"`,
+		`label="Apple -> Orange"`,
+		`label="Policy -> Target"`,
+	} {
+		if !strings.Contains(graphviz, expected) {
+			t.Errorf("expected graphviz output to contain %s, got:\n%s", expected, graphviz)
+		}
+	}
+	if graphviz != topology.ToGraphviz() {
+		t.Errorf("expected graphviz output to be deterministic")
+	}
+	if topology.ToDot() == graphviz {
+		t.Errorf("expected ToDot output not to include the annotations")
+	}
+
+	SaveToOutputDir(t, graphviz, "../tests/out", ".dot")
+}
+
+func TestTopologyTypedAccessors(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}, {Name: "apple-2"}}
+	oranges := []*Orange{{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1", "apple-2"}}}
+	info := &Info{Name: "info-1", Ref: "apple.example.test:apple-1"}
+	policy := buildFruitPolicy(func(policy *FruitPolicy) {
+		policy.Spec.TargetRef.Kind = "Apple"
+		policy.Spec.TargetRef.Name = "apple-1"
+	})
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithTargetables(oranges...),
+		WithObjects(info),
+		WithPolicies(policy),
+		WithLinks(
+			LinkApplesToOranges(apples),
+			LinkInfoFrom("Apple", lo.Map(apples, AsObject[*Apple])),
+		),
+	)
+
+	if actual := TargetablesOfType[*Apple](topology); len(actual) != 2 {
+		t.Errorf("expected 2 apples, got %d", len(actual))
+	}
+	if actual := TargetablesOfType[*Apple](topology, func(o Object) bool { return o.GetName() == "apple-2" }); len(actual) != 1 || actual[0].Name != "apple-2" {
+		t.Errorf("expected apple-2, got %v", actual)
+	}
+	if actual := TargetablesOfType[*Banana](topology); len(actual) != 0 {
+		t.Errorf("expected no bananas, got %d", len(actual))
+	}
+	if actual := PoliciesOfType[*FruitPolicy](topology); len(actual) != 1 || actual[0] != policy {
+		t.Errorf("expected fruit policy, got %v", actual)
+	}
+	if actual := ObjectsOfType[*Info](topology); len(actual) != 1 || actual[0] != info {
+		t.Errorf("expected info object, got %v", actual)
+	}
+	if actual := ParentsOfType[*Apple](topology, oranges[0]); len(actual) != 2 {
+		t.Errorf("expected orange to have 2 apple parents, got %d", len(actual))
+	}
+	if actual := ParentsOfType[*FruitPolicy](topology, apples[0]); len(actual) != 1 || actual[0] != policy {
+		t.Errorf("expected apple-1 to have the fruit policy as parent, got %v", actual)
+	}
+	if actual := ChildrenOfType[*Orange](topology, apples[0]); len(actual) != 1 || actual[0] != oranges[0] {
+		t.Errorf("expected apple-1 to have orange-1 as child, got %v", actual)
+	}
+	if actual := ChildrenOfType[*Info](topology, apples[0]); len(actual) != 1 || actual[0] != info {
+		t.Errorf("expected apple-1 to have info-1 as child, got %v", actual)
+	}
+	if actual := ChildrenOfType[*Info](topology, apples[1]); len(actual) != 0 {
+		t.Errorf("expected apple-2 to have no info children, got %v", actual)
+	}
+}
+
+func TestTopologyConfig(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}, {Name: "apple-2"}}
+	oranges := []*Orange{{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1"}}}
+	config := &Info{Name: "config"}
+
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithTargetables(oranges...),
+		WithLinks(LinkApplesToOranges(apples)),
+		WithConfig(config, schema.GroupKind{Group: TestGroupName, Kind: "Apple"}),
+	)
+
+	if topology.Config() != config {
+		t.Errorf("expected topology config to be %v, got %v", config, topology.Config())
+	}
+	if actual, ok := ConfigAs[*Info](topology); !ok || actual != config {
+		t.Errorf("expected topology config as info, got %v", actual)
+	}
+	if _, ok := ConfigAs[*Apple](topology); ok {
+		t.Errorf("expected topology config not to be an apple")
+	}
+	if children := topology.Targetables().Children(config); len(children) != 2 {
+		t.Errorf("expected config to be linked to 2 apples, got %d", len(children))
+	}
+	if roots := topology.Targetables().Roots(); len(roots) != 2 {
+		t.Errorf("expected apples to remain root targetables, got %v", roots)
+	}
+	if topology := NewTopology(WithTargetables(apples...)); topology.Config() != nil {
+		t.Errorf("expected topology without config, got %v", topology.Config())
+	}
+}
+
+func TestTopologyAncestorPaths(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}, {Name: "apple-2"}}
+	oranges := []*Orange{
+		{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1", "apple-2"}, ChildBananas: []string{"banana-1"}},
+		{Name: "orange-2", Namespace: "my-namespace", AppleParents: []string{"apple-2"}, ChildBananas: []string{"banana-1"}},
+	}
+	bananas := []*Banana{{Name: "banana-1"}, {Name: "banana-2"}}
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithTargetables(oranges...),
+		WithTargetables(bananas...),
+		WithLinks(
+			LinkApplesToOranges(apples),
+			LinkOrangesToBananas(oranges),
+		),
+	)
+
+	pathNames := func(paths [][]Targetable) []string {
+		return lo.Map(paths, func(path []Targetable, _ int) string {
+			return strings.Join(lo.Map(path, func(t Targetable, _ int) string { return t.GetName() }), " -> ")
+		})
+	}
+
+	expected := []string{
+		"apple-1 -> orange-1 -> banana-1",
+		"apple-2 -> orange-1 -> banana-1",
+		"apple-2 -> orange-2 -> banana-1",
+	}
+	if actual := pathNames(topology.Targetables().AncestorPaths(bananas[0])); !slices.Equal(actual, expected) {
+		t.Errorf("expected ancestor paths %v, got %v", expected, actual)
+	}
+	if actual := pathNames(topology.Targetables().AncestorPaths(bananas[1])); !slices.Equal(actual, []string{"banana-2"}) {
+		t.Errorf("expected banana-2 to be its own single ancestor path, got %v", actual)
+	}
+	if actual := topology.Targetables().AncestorPaths(&Banana{Name: "unknown"}); actual != nil {
+		t.Errorf("expected no ancestor paths for unknown item, got %v", actual)
+	}
+
+	ancestors := lo.Map(topology.Targetables().Ancestors(bananas[0]), func(t Targetable, _ int) string { return t.GetName() })
+	if expected := []string{"apple-1", "apple-2", "orange-1", "orange-2"}; !slices.Equal(ancestors, expected) {
+		t.Errorf("expected ancestors %v, got %v", expected, ancestors)
+	}
+
+	// consistent with the paths from each root
+	for _, path := range topology.Targetables().AncestorPaths(bananas[0]) {
+		if !lo.ContainsBy(topology.Targetables().Paths(path[0], bananas[0]), func(p []Targetable) bool {
+			return slices.Equal(pathNames([][]Targetable{p}), pathNames([][]Targetable{path}))
+		}) {
+			t.Errorf("expected ancestor path %v to be a path from its root", pathNames([][]Targetable{path}))
+		}
+	}
+}
+
+func TestTopologyTargets(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}}
+	found := buildFruitPolicy(func(policy *FruitPolicy) {
+		policy.Spec.TargetRef.Kind = "Apple"
+		policy.Spec.TargetRef.Name = "apple-1"
+	})
+	notFound := buildFruitPolicy(func(policy *FruitPolicy) {
+		policy.Name = "policy-2"
+		policy.Spec.TargetRef.Kind = "Apple"
+		policy.Spec.TargetRef.Name = "apple-2"
+	})
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithPolicies(found, notFound),
+	)
+
+	targets, err := topology.Targets(found)
+	if err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+	if len(targets) != 1 || targets[0].GetName() != "apple-1" {
+		t.Errorf("expected apple-1 as target, got %v", targets)
+	}
+
+	targets, err = topology.Targets(notFound)
+	if len(targets) != 0 {
+		t.Errorf("expected no targets, got %v", targets)
+	}
+	if !errors.Is(err, ErrTargetNotFound) {
+		t.Errorf("expected target not found error, got %v", err)
+	}
+	var targetNotFound *TargetNotFoundError
+	if !errors.As(err, &targetNotFound) || targetNotFound.Policy != notFound {
+		t.Errorf("expected target not found error for policy-2, got %v", err)
+	}
+}
+
+func TestTopologyView(t *testing.T) {
+	apples := []*Apple{{Name: "apple-1"}}
+	orange := &Orange{Name: "orange-1", Namespace: "my-namespace", AppleParents: []string{"apple-1"}}
+	policy := buildFruitPolicy(func(policy *FruitPolicy) {
+		policy.Spec.TargetRef = FruitPolicyTargetReference{
+			Group: TestGroupName,
+			Kind:  "Orange",
+			Name:  "orange-1",
+		}
+	})
+	topology := NewTopology(
+		WithTargetables(apples...),
+		WithTargetables(orange),
+		WithLinks(LinkApplesToOranges(apples)),
+		WithPolicies(policy),
+	)
+
+	var view api.TopologyView = topology.View()
+
+	if roots := lo.Map(view.Targetables().Roots(), MapTargetableToURLFunc); !slices.Equal(roots, []string{apples[0].GetURL()}) {
+		t.Errorf("expected roots %v, got %v", []string{apples[0].GetURL()}, roots)
+	}
+	o, found := view.Targetables().Get(orange.GetURL())
+	if !found {
+		t.Fatalf("expected targetable %s not found", orange.GetURL())
+	}
+	if parents := lo.Map(view.Targetables().Parents(o), MapTargetableToURLFunc); !slices.Equal(parents, []string{apples[0].GetURL()}) {
+		t.Errorf("expected parents %v, got %v", []string{apples[0].GetURL()}, parents)
+	}
+	if policies := view.Policies().Items(); len(policies) != 1 || policies[0].GetURL() != policy.GetURL() {
+		t.Errorf("expected policy %s, got %v", policy.GetURL(), policies)
+	}
+	targets, err := view.Targets(policy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(targets) != 1 || targets[0].GetURL() != orange.GetURL() {
+		t.Errorf("expected target %s, got %v", orange.GetURL(), targets)
+	}
+	if view.ToDot() != topology.ToDot() {
+		t.Errorf("expected the view to render the same graph as the topology")
+	}
+}
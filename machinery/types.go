@@ -4,19 +4,15 @@ import (
 	"fmt"
 	"strings"
 
-	"k8s.io/apimachinery/pkg/runtime/schema"
 	k8stypes "k8s.io/apimachinery/pkg/types"
+
+	"github.com/kuadrant/policy-machinery/machinery/api"
 )
 
 const kindNameURLSeparator = ':'
 
-type Object interface {
-	schema.ObjectKind
-
-	GetNamespace() string
-	GetName() string
-	GetURL() string
-}
+// Object is a node of the topology. See api.Object.
+type Object = api.Object
 
 func UrlFromObject(obj Object) string {
 	name := strings.TrimPrefix(namespacedName(obj.GetNamespace(), obj.GetName()), string(k8stypes.Separator))
@@ -31,36 +27,32 @@ func namespacedName(namespace, name string) string {
 	return k8stypes.NamespacedName{Namespace: namespace, Name: name}.String()
 }
 
-// Targetable is an interface that represents an object that can be targeted by policies.
-type Targetable interface {
-	Object
-
-	SetPolicies([]Policy)
-	Policies() []Policy
-}
+// Targetable is an interface that represents an object that can be targeted by policies. See api.Targetable.
+type Targetable = api.Targetable
 
 func MapTargetableToURLFunc(t Targetable, _ int) string {
 	return t.GetURL()
 }
 
-// Policy targets objects and can be merged with another Policy based on a given MergeStrategy.
-type Policy interface {
-	Object
-
-	GetTargetRefs() []PolicyTargetReference
-	GetMergeStrategy() MergeStrategy
+// AliasedTargetable is a targetable that can also be located by other locators than its URL, e.g. a named rule of a
+// route, also located by its position in the route (see RouteRuleSectionName). Policies that target any of the aliases
+// of a targetable are attached to it, and the collections of the topology resolve the aliases (see Collection.Get).
+type AliasedTargetable interface {
+	Targetable
 
-	Merge(Policy) Policy
+	// LocatorAliases returns the other locators of the targetable, in the same form as its URL.
+	LocatorAliases() []string
 }
 
+// Policy targets objects and can be merged with another Policy based on a given MergeStrategy. See api.Policy.
+type Policy = api.Policy
+
 // PolicyTargetReference is a generic interface for all kinds of Gateway API policy target references.
-// It implements the Object interface for the referent.
-type PolicyTargetReference interface {
-	Object
-}
+// It implements the Object interface for the referent. See api.PolicyTargetReference.
+type PolicyTargetReference = api.PolicyTargetReference
 
 // MergeStrategy is a function that merges two Policy objects into a new Policy object.
-type MergeStrategy func(Policy, Policy) Policy
+type MergeStrategy = api.MergeStrategy
 
 var DefaultMergeStrategy = NoMergeStrategy
 
@@ -0,0 +1,88 @@
+package machinery
+
+import (
+	"sync"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// WarmingHint declares a query of the topology expected to be hot, e.g. all Gateway→HTTPRouteRule paths.
+// Topology.Warm precomputes the hinted queries, so their results are cached in the topology before the first
+// reconciler asks for them.
+type WarmingHint func(*Topology)
+
+// WarmPaths returns a warming hint that precomputes the paths from all targetables of a kind to all targetables of
+// another kind.
+func WarmPaths(from, to schema.GroupKind) WarmingHint {
+	return func(t *Topology) {
+		warmPaths(t, from, to)
+	}
+}
+
+// WarmEffectivePolicies returns a warming hint that precomputes the paths from all targetables of a kind to all
+// targetables of another kind, and the effective policies of type T for those paths.
+func WarmEffectivePolicies[T Policy](from, to schema.GroupKind) WarmingHint {
+	return func(t *Topology) {
+		for _, path := range warmPaths(t, from, to) {
+			EffectivePolicyForPath[T](t, path)
+		}
+	}
+}
+
+// Warm runs the warming hints concurrently and waits for all of them to complete.
+func (t *Topology) Warm(hints ...WarmingHint) {
+	var wg sync.WaitGroup
+	for _, hint := range hints {
+		wg.Add(1)
+		go func(hint WarmingHint) {
+			defer wg.Done()
+			hint(t)
+		}(hint)
+	}
+	wg.Wait()
+}
+
+func warmPaths(t *Topology, from, to schema.GroupKind) [][]Targetable {
+	targetables := t.Targetables()
+	ofKind := func(kind schema.GroupKind) []Targetable {
+		return targetables.Items(func(o Object) bool { return o.GroupVersionKind().GroupKind() == kind })
+	}
+	destinations := ofKind(to)
+	return lo.FlatMap(ofKind(from), func(source Targetable, _ int) [][]Targetable {
+		return lo.FlatMap(destinations, func(destination Targetable, _ int) [][]Targetable {
+			return targetables.Paths(source, destination)
+		})
+	})
+}
+
+// pathCache memoizes the paths between targetables of a topology.
+// Paths are computed outside of the lock, so concurrent warming hints do not wait on each other.
+type pathCache struct {
+	sync.RWMutex
+	paths map[string][][]Targetable
+}
+
+func newPathCache() *pathCache {
+	return &pathCache{paths: make(map[string][][]Targetable)}
+}
+
+// getOrCompute returns a copy of the cached paths for a key, computing them if not cached yet. A nil cache computes
+// every time.
+func (c *pathCache) getOrCompute(key string, compute func() [][]Targetable) [][]Targetable {
+	if c == nil {
+		return compute()
+	}
+	c.RLock()
+	paths, ok := c.paths[key]
+	c.RUnlock()
+	if !ok {
+		paths = compute()
+		c.Lock()
+		c.paths[key] = paths
+		c.Unlock()
+	}
+	return lo.Map(paths, func(path []Targetable, _ int) []Targetable {
+		return append([]Targetable(nil), path...)
+	})
+}
@@ -0,0 +1,55 @@
+//go:build unit
+
+package machinery
+
+import (
+	"testing"
+
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+)
+
+func TestTopologyWarm(t *testing.T) {
+	gatewayKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "Gateway"}
+	ruleKind := schema.GroupKind{Group: gwapiv1.GroupName, Kind: "HTTPRouteRule"}
+
+	resources := BuildScaledGatewayAPITopology(GatewayAPITopologyScale{
+		GatewayClasses:       1,
+		GatewaysPerClass:     2,
+		ListenersPerGateway:  2,
+		HTTPRoutesPerGateway: 2,
+		RulesPerHTTPRoute:    2,
+	})
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(resources.GatewayClasses...),
+		WithGateways(resources.Gateways...),
+		WithHTTPRoutes(resources.HTTPRoutes...),
+		ExpandGatewayListeners(),
+		ExpandHTTPRouteRules(),
+	)
+	topology.Warm(WarmPaths(gatewayKind, ruleKind), WarmEffectivePolicies[*TestPolicy](gatewayKind, ruleKind))
+
+	cached := len(topology.paths.paths)
+	if cached == 0 {
+		t.Fatal("expected paths cached")
+	}
+
+	targetables := topology.Targetables()
+	gateway := targetables.Items(func(o Object) bool { return o.GroupVersionKind().GroupKind() == gatewayKind })[0]
+	rule := targetables.Items(func(o Object) bool { return o.GroupVersionKind().GroupKind() == ruleKind })[0]
+	paths := targetables.Paths(gateway, rule)
+	if len(topology.paths.paths) != cached {
+		t.Errorf("expected warmed paths to be served from the cache, got %d cached paths, want %d", len(topology.paths.paths), cached)
+	}
+	if expected := targetables.paths(gateway, rule); len(paths) != len(expected) {
+		t.Errorf("expected %d paths, got %d", len(expected), len(paths))
+	}
+
+	// cached paths are copied, so callers can modify them
+	if len(paths) > 0 {
+		paths[0][0] = nil
+		if again := targetables.Paths(gateway, rule); again[0][0] == nil {
+			t.Error("expected the cached paths not to be modified")
+		}
+	}
+}
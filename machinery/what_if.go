@@ -0,0 +1,168 @@
+package machinery
+
+import (
+	"reflect"
+
+	"github.com/samber/lo"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// EffectivePolicyFunc computes the effective policy for a path of targetables, based on the policies attached to each
+// targetable in the path. It returns nil if no policy applies to the path.
+type EffectivePolicyFunc func(path []Targetable) Policy
+
+// PathsFunc returns the paths of targetables of a topology for which effective policies are computed.
+type PathsFunc func(*Topology) [][]Targetable
+
+// PolicyImpact is a change to the effective policy of a path of targetables.
+// Before is nil if no policy applied to the path; After is nil if no policy applies to the path after the change.
+type PolicyImpact struct {
+	Path   []Targetable
+	Before Policy
+	After  Policy
+}
+
+// Unprotected returns true if the path had an effective policy before the change and has none after the change.
+func (i PolicyImpact) Unprotected() bool {
+	return i.Before != nil && i.After == nil
+}
+
+type WhatIfOptions struct {
+	Paths PathsFunc
+}
+
+type WhatIfOptionsFunc func(*WhatIfOptions)
+
+// WithWhatIfPaths sets the function that selects the paths of targetables for which effective policies are simulated.
+// Defaults to all paths from the roots to the leaves of the topology.
+func WithWhatIfPaths(f PathsFunc) WhatIfOptionsFunc {
+	return func(o *WhatIfOptions) {
+		o.Paths = f
+	}
+}
+
+// WhatIf simulates changes to the policies of a topology and reports their impact on the effective policies, without
+// modifying the topology.
+type WhatIf struct {
+	topology        *Topology
+	effectivePolicy EffectivePolicyFunc
+	paths           PathsFunc
+}
+
+// NewWhatIf returns a simulator of policy changes on a given topology, using a function to compute effective policies.
+func NewWhatIf(topology *Topology, effectivePolicy EffectivePolicyFunc, options ...WhatIfOptionsFunc) *WhatIf {
+	o := &WhatIfOptions{
+		Paths: RootToLeafPaths,
+	}
+	for _, f := range options {
+		f(o)
+	}
+	return &WhatIf{
+		topology:        topology,
+		effectivePolicy: effectivePolicy,
+		paths:           o.Paths,
+	}
+}
+
+// SimulateDelete returns the paths of targetables whose effective policies would change if the policy was deleted,
+// and how.
+// Only paths that contain a targetable targeted by the policy are considered.
+//
+// The targetables passed to the effective policy function during the simulation are views of the targetables of the
+// topology whose Policies() exclude the deleted policy. Effective policy functions should therefore rely on the
+// methods of the Targetable interface rather than on the concrete types of the targetables.
+func (w *WhatIf) SimulateDelete(policy Policy) []PolicyImpact {
+	targetRefs := lo.Map(policy.GetTargetRefs(), func(ref PolicyTargetReference, _ int) string {
+		return w.topology.locators.resolve(ref.GetURL())
+	})
+
+	var impacts []PolicyImpact
+	for _, path := range w.paths(w.topology) {
+		if !lo.ContainsBy(path, func(t Targetable) bool { return lo.Contains(targetRefs, t.GetURL()) }) {
+			continue
+		}
+		simulatedPath := lo.Map(path, func(t Targetable, _ int) Targetable {
+			return &simulatedTargetable{
+				Targetable: t,
+				policies: lo.Filter(t.Policies(), func(p Policy, _ int) bool {
+					return p.GetURL() != policy.GetURL()
+				}),
+			}
+		})
+		before := w.effectivePolicy(path)
+		after := w.effectivePolicy(simulatedPath)
+		if isNilPolicy(before) && isNilPolicy(after) {
+			continue
+		}
+		if !isNilPolicy(before) && !isNilPolicy(after) && PoliciesEqual(before, after) {
+			continue
+		}
+		impact := PolicyImpact{Path: path}
+		if !isNilPolicy(before) {
+			impact.Before = before
+		}
+		if !isNilPolicy(after) {
+			impact.After = after
+		}
+		impacts = append(impacts, impact)
+	}
+	return impacts
+}
+
+// RootToLeafPaths returns all paths of targetables from the roots to the leaves of a topology.
+func RootToLeafPaths(topology *Topology) [][]Targetable {
+	return rootToLeafPaths(topology, nil)
+}
+
+// RootToLeafPathsFrom returns a function that returns all paths of targetables of a topology from the targetables of
+// any of the given root kinds (see RootsOf) to the leaves, e.g. to simulate changes to mesh policies only with
+// WithWhatIfPaths(RootToLeafPathsFrom(MeshRootKinds...)).
+func RootToLeafPathsFrom(rootKinds ...schema.GroupKind) PathsFunc {
+	return func(topology *Topology) [][]Targetable {
+		return rootToLeafPaths(topology, rootKinds)
+	}
+}
+
+func rootToLeafPaths(topology *Topology, rootKinds []schema.GroupKind) [][]Targetable {
+	targetables := topology.Targetables()
+	leaves := targetables.Items(func(o Object) bool {
+		return len(targetables.Children(o)) == 0
+	})
+	var paths [][]Targetable
+	seen := make(map[string]struct{})
+	for _, root := range targetables.RootsOf(rootKinds...) {
+		for _, leaf := range leaves {
+			for _, path := range targetables.Paths(root, leaf) {
+				id := PathID(path)
+				if _, ok := seen[id]; ok {
+					continue
+				}
+				seen[id] = struct{}{}
+				paths = append(paths, path)
+			}
+		}
+	}
+	return paths
+}
+
+// simulatedTargetable is a view of a targetable with a different set of attached policies
+type simulatedTargetable struct {
+	Targetable
+	policies []Policy
+}
+
+func (t *simulatedTargetable) SetPolicies(policies []Policy) {
+	t.policies = policies
+}
+
+func (t *simulatedTargetable) Policies() []Policy {
+	return t.policies
+}
+
+func isNilPolicy(policy Policy) bool {
+	if policy == nil {
+		return true
+	}
+	v := reflect.ValueOf(policy)
+	return v.Kind() == reflect.Pointer && v.IsNil()
+}
@@ -0,0 +1,101 @@
+//go:build unit
+
+package machinery
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/samber/lo"
+	gwapiv1 "sigs.k8s.io/gateway-api/apis/v1"
+	gwapiv1alpha2 "sigs.k8s.io/gateway-api/apis/v1alpha2"
+)
+
+func TestWhatIfSimulateDelete(t *testing.T) {
+	gatewayPolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "gateway-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "Gateway",
+				Name:  "gateway-1",
+			},
+		}
+	})
+	routePolicy := buildPolicy(func(policy *TestPolicy) {
+		policy.Name = "route-policy"
+		policy.Spec.TargetRef = gwapiv1alpha2.LocalPolicyTargetReferenceWithSectionName{
+			LocalPolicyTargetReference: gwapiv1alpha2.LocalPolicyTargetReference{
+				Group: gwapiv1.GroupName,
+				Kind:  "HTTPRoute",
+				Name:  "route-1",
+			},
+		}
+	})
+
+	resources := BuildComplexGatewayAPITopology()
+	topology := NewGatewayAPITopology(
+		WithGatewayClasses(resources.GatewayClasses...),
+		WithGateways(resources.Gateways...),
+		WithHTTPRoutes(resources.HTTPRoutes...),
+		WithServices(resources.Services...),
+		WithGatewayAPITopologyPolicies(gatewayPolicy, routePolicy),
+	)
+
+	// the most specific policy in the path wins
+	mostSpecificPolicy := func(path []Targetable) Policy {
+		var effectivePolicy Policy
+		for _, targetable := range path {
+			if policies := targetable.Policies(); len(policies) > 0 {
+				effectivePolicy = policies[len(policies)-1]
+			}
+		}
+		return effectivePolicy
+	}
+
+	pathName := func(path []Targetable) string {
+		return strings.Join(lo.Map(path, func(t Targetable, _ int) string { return t.GetName() }), " -> ")
+	}
+
+	whatIf := NewWhatIf(topology, mostSpecificPolicy)
+
+	impacts := whatIf.SimulateDelete(gatewayPolicy)
+	if len(impacts) != 1 {
+		t.Fatalf("expected 1 impacted path, got %d", len(impacts))
+	}
+	if expected := "gatewayclass-1 -> gateway-1 -> route-2 -> service-3"; pathName(impacts[0].Path) != expected {
+		t.Errorf("expected impacted path %s, got %s", expected, pathName(impacts[0].Path))
+	}
+	if !impacts[0].Unprotected() {
+		t.Errorf("expected path to be left unprotected")
+	}
+
+	impacts = whatIf.SimulateDelete(routePolicy)
+	paths := lo.Map(impacts, func(impact PolicyImpact, _ int) string { return pathName(impact.Path) })
+	slices.Sort(paths)
+	expectedPaths := []string{
+		"gatewayclass-1 -> gateway-1 -> route-1 -> service-1",
+		"gatewayclass-1 -> gateway-1 -> route-1 -> service-2",
+	}
+	if !slices.Equal(paths, expectedPaths) {
+		t.Errorf("expected impacted paths %v, got %v", expectedPaths, paths)
+	}
+	for _, impact := range impacts {
+		if impact.Unprotected() {
+			t.Errorf("expected path %s to remain protected", pathName(impact.Path))
+		}
+		if impact.Before.GetName() != "route-policy" || impact.After.GetName() != "gateway-policy" {
+			t.Errorf("expected effective policy to change from route-policy to gateway-policy, got %s to %s", impact.Before.GetName(), impact.After.GetName())
+		}
+	}
+
+	// the topology is not modified by the simulation
+	for _, policy := range []Policy{gatewayPolicy, routePolicy} {
+		if len(topology.Targetables().Items(func(o Object) bool {
+			return lo.Contains(o.(Targetable).Policies(), policy)
+		})) != 1 {
+			t.Errorf("expected policy %s to remain attached", policy.GetName())
+		}
+	}
+}